@@ -13,17 +13,16 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"yanta/internal/plugins/manifest"
 )
 
 const (
 	defaultBuildEntry = "plugin.ts"
 	defaultBuildOut   = "main.js"
-	defaultBuildMeta  = "main.meta.json"
+	defaultBuildMeta  = manifest.BuildMetadataFile
 
-	buildMetadataBuilder       = "yanta-plugin-cli"
-	buildMetadataBuilderV1     = "v1"
-	buildMetadataToolBun       = "bun"
-	buildMetadataFormatYantaV1 = "yanta-esm-v1"
+	buildMetadataBuilderV1 = "v1"
 )
 
 var hostRuntimeAliases = map[string]string{
@@ -37,25 +36,6 @@ var hostRuntimeAliases = map[string]string{
 	"yjs":                   "Yjs",
 }
 
-var forbiddenBundledPackages = []string{
-	"react",
-	"react-dom",
-	"@blocknote/core",
-	"@blocknote/react",
-	"yjs",
-}
-
-type pluginBuildMetadata struct {
-	Builder                 string   `json:"builder"`
-	BuilderVersion          string   `json:"builder_version"`
-	BuildTool               string   `json:"build_tool"`
-	Format                  string   `json:"format"`
-	HostExternals           []string `json:"host_externals"`
-	DetectedBundledPackages []string `json:"detected_bundled_packages,omitempty"`
-	EntryHashSHA256         string   `json:"entry_hash_sha256"`
-	GeneratedAt             string   `json:"generated_at"`
-}
-
 func runBuild(args []string) {
 	fs := flag.NewFlagSet("build", flag.ExitOnError)
 	pluginDir := fs.String("plugin", ".", "plugin directory (contains plugin.toml)")
@@ -65,43 +45,64 @@ func runBuild(args []string) {
 	_ = fs.Parse(args)
 
 	root := mustPluginDir(*pluginDir)
-	entryPath := filepath.Join(root, filepath.Clean(strings.TrimSpace(*entry)))
-	outPath := filepath.Join(root, filepath.Clean(strings.TrimSpace(*out)))
-	metaPath := filepath.Join(root, filepath.Clean(strings.TrimSpace(*meta)))
+	outPath, metaPath, err := buildPlugin(root, *entry, *out, *meta)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	fmt.Printf("built: %s\n", outPath)
+	fmt.Printf("metadata: %s\n", metaPath)
+}
+
+// buildPlugin bundles entry into out and writes its build metadata to meta,
+// all relative to the plugin root. It returns the bundle and metadata paths.
+func buildPlugin(root, entry, out, meta string) (string, string, error) {
+	entryPath := filepath.Join(root, filepath.Clean(strings.TrimSpace(entry)))
+	outPath := filepath.Join(root, filepath.Clean(strings.TrimSpace(out)))
+	metaPath := filepath.Join(root, filepath.Clean(strings.TrimSpace(meta)))
 
 	entryInfo, err := os.Stat(entryPath)
 	if err != nil {
-		fatalf("entry file %q is not accessible: %v", entryPath, err)
+		return "", "", fmt.Errorf("entry file %q is not accessible: %w", entryPath, err)
 	}
 	if entryInfo.IsDir() {
-		fatalf("entry must be a file: %s", entryPath)
+		return "", "", fmt.Errorf("entry must be a file: %s", entryPath)
 	}
 
 	if err := buildWithBun(root, entryPath, outPath, hostRuntimeAliases); err != nil {
-		fatalf("bun build failed: %v", err)
+		return "", "", fmt.Errorf("bun build failed: %w", err)
 	}
 
 	bundle, err := os.ReadFile(outPath)
 	if err != nil {
-		fatalf("read bundle output: %v", err)
+		return "", "", fmt.Errorf("read bundle output: %w", err)
+	}
+	if err := writeBuildMetadata(metaPath, bundle); err != nil {
+		return "", "", err
 	}
+	return outPath, metaPath, nil
+}
+
+// writeBuildMetadata describes bundle in metaPath the way YANTA checks it on
+// install. A bundle that includes a host runtime package gets no metadata.
+func writeBuildMetadata(metaPath string, bundle []byte) error {
 	hash := sha256.Sum256(bundle)
 	bundledForbidden := detectBundledForbiddenPackages(string(bundle))
 	if len(bundledForbidden) > 0 {
 		_ = os.Remove(metaPath)
-		fatalf("bundle contains forbidden host runtime packages: %s", strings.Join(bundledForbidden, ", "))
+		return fmt.Errorf("bundle contains forbidden host runtime packages: %s", strings.Join(bundledForbidden, ", "))
 	}
 
 	if err := os.MkdirAll(filepath.Dir(metaPath), 0o755); err != nil {
-		fatalf("create metadata directory: %v", err)
+		return fmt.Errorf("create metadata directory: %w", err)
 	}
 
-	buildMeta := pluginBuildMetadata{
-		Builder:                 buildMetadataBuilder,
+	buildMeta := manifest.BuildMetadata{
+		Builder:                 manifest.BuildMetadataBuilder,
 		BuilderVersion:          buildMetadataBuilderV1,
-		BuildTool:               buildMetadataToolBun,
-		Format:                  buildMetadataFormatYantaV1,
-		HostExternals:           append([]string(nil), forbiddenBundledPackages...),
+		BuildTool:               manifest.BuildMetadataTool,
+		Format:                  manifest.BuildMetadataFormat,
+		HostExternals:           append([]string(nil), manifest.HostExternals...),
 		DetectedBundledPackages: bundledForbidden,
 		EntryHashSHA256:         hex.EncodeToString(hash[:]),
 		GeneratedAt:             time.Now().UTC().Format(time.RFC3339),
@@ -109,14 +110,12 @@ func runBuild(args []string) {
 
 	encoded, err := json.MarshalIndent(buildMeta, "", "  ")
 	if err != nil {
-		fatalf("encode metadata: %v", err)
+		return fmt.Errorf("encode metadata: %w", err)
 	}
 	if err := os.WriteFile(metaPath, encoded, 0o644); err != nil {
-		fatalf("write metadata: %v", err)
+		return fmt.Errorf("write metadata: %w", err)
 	}
-
-	fmt.Printf("built: %s\n", outPath)
-	fmt.Printf("metadata: %s\n", metaPath)
+	return nil
 }
 
 func buildWithBun(pluginRoot string, entryPath string, outPath string, aliases map[string]string) error {
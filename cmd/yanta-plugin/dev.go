@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"yanta/internal/plugins/manifest"
+)
+
+// devRebuildDebounce collapses the writes of one editor save into one
+// rebuild.
+const devRebuildDebounce = 300 * time.Millisecond
+
+// devSession rebuilds and validates a linked plugin as its files change.
+// YANTA reloads the plugin itself when the rebuilt entry lands.
+type devSession struct {
+	root  string
+	entry string
+	out   string
+	meta  string
+}
+
+func runDev(args []string) {
+	fs := flag.NewFlagSet("dev", flag.ExitOnError)
+	pluginDir := fs.String("plugin", ".", "plugin directory (contains plugin.toml)")
+	entry := fs.String("entry", defaultBuildEntry, "plugin source entry relative to -plugin")
+	out := fs.String("out", defaultBuildOut, "output bundle path relative to -plugin")
+	meta := fs.String("meta", defaultBuildMeta, "metadata output path relative to -plugin")
+	unlink := fs.Bool("unlink", false, "remove the plugin's dev link and exit")
+	_ = fs.Parse(args)
+
+	root := mustPluginDir(*pluginDir)
+	linkDir, err := manifest.Directory()
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	if *unlink {
+		m, _ := manifest.ValidateDirectory(root)
+		if err := manifest.UnlinkDev(linkDir, m.ID); err != nil {
+			fatalf("unlink: %v", err)
+		}
+		fmt.Printf("unlinked: %s\n", m.ID)
+		return
+	}
+
+	session := devSession{root: root, entry: *entry, out: *out, meta: *meta}
+	session.rebuild()
+
+	linked, err := manifest.LinkDev(linkDir, root)
+	if err != nil {
+		fatalf("link: %v", err)
+	}
+	fmt.Printf("linked: %s -> %s\n", filepath.Join(linkDir, linked.ID), root)
+	fmt.Println("while the plugin is enabled in Settings -> Plugins, YANTA reloads it on every rebuild")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := session.watch(ctx); err != nil {
+		fatalf("watch: %v", err)
+	}
+	fmt.Printf("stopped; %s stays linked, remove it with yanta-plugin dev -unlink\n", linked.ID)
+}
+
+// rebuild builds a JS plugin whose source entry exists, then reports what
+// YANTA would make of the result. WASM plugins are built by their own
+// toolchain and only validated.
+func (d devSession) rebuild() {
+	m, _ := manifest.ValidateDirectory(d.root)
+	if !manifest.IsWASMEntry(m.Entry) {
+		if _, err := os.Stat(filepath.Join(d.root, filepath.Clean(d.entry))); err == nil {
+			if _, _, err := buildPlugin(d.root, d.entry, d.out, d.meta); err != nil {
+				fmt.Fprintf(os.Stderr, "build failed: %v\n", err)
+				return
+			}
+		}
+	}
+
+	m, issues := manifest.ValidateDirectory(d.root)
+	if len(issues) > 0 {
+		printIssues(issues)
+		return
+	}
+	fmt.Printf("[%s] ready: %s %s\n", time.Now().Format("15:04:05"), m.ID, m.Version)
+}
+
+// watch rebuilds on every change under the plugin directory, apart from the
+// build's own output, until ctx is done.
+func (d devSession) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, d.root); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if d.isOutput(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = addWatchDirs(watcher, event.Name)
+				}
+			}
+			timer.Reset(devRebuildDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+		case <-timer.C:
+			d.rebuild()
+		}
+	}
+}
+
+// isOutput reports whether path is written by a build or by pack: the
+// bundle and its siblings, such as main.css, the metadata, the signature
+// and packages.
+func (d devSession) isOutput(path string) bool {
+	out := filepath.Join(d.root, filepath.Clean(d.out))
+	outBase := strings.TrimSuffix(filepath.Base(out), filepath.Ext(out)) + "."
+	switch {
+	case filepath.Dir(path) == filepath.Dir(out) && strings.HasPrefix(filepath.Base(path), outBase):
+		return true
+	case path == filepath.Join(d.root, filepath.Clean(d.meta)), path == filepath.Join(d.root, signatureFileName):
+		return true
+	}
+	return strings.HasSuffix(path, packageExtension)
+}
+
+// addWatchDirs watches dir and its subdirectories, skipping the ones that
+// are never part of a plugin.
+func addWatchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if _, skip := skippedDirs[entry.Name()]; skip && path != dir {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/plugins/manifest"
+)
+
+func TestDevSession_IsOutput(t *testing.T) {
+	root := t.TempDir()
+	session := devSession{root: root, entry: defaultBuildEntry, out: defaultBuildOut, meta: defaultBuildMeta}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "bundle", path: "main.js", want: true},
+		{name: "bundle sibling", path: "main.css", want: true},
+		{name: "build metadata", path: "main.meta.json", want: true},
+		{name: "signature", path: signatureFileName, want: true},
+		{name: "package", path: "acme.test-0.1.0.yplg", want: true},
+		{name: "nested package", path: "dist/acme.test-0.1.0.yplg", want: true},
+		{name: "source entry", path: "plugin.ts"},
+		{name: "manifest", path: manifest.FileName},
+		{name: "nested bundle name", path: "src/main.js"},
+		{name: "similar name", path: "mainline.ts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, session.isOutput(filepath.Join(root, filepath.FromSlash(tt.path))))
+		})
+	}
+}
+
+func TestDevLink(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("YANTA_HOME", home)
+	linkDir, err := manifest.Directory()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(home, "plugins"), linkDir)
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, root string)
+		wantErr string
+	}{
+		{
+			name:    "unbuilt plugin",
+			prepare: func(t *testing.T, root string) {},
+		},
+		{
+			name: "installed copy",
+			prepare: func(t *testing.T, root string) {
+				writeFile(t, linkDir, "acme.test/"+manifest.FileName, "installed")
+			},
+			wantErr: manifest.ErrAlreadyInstalled,
+		},
+		{
+			name: "invalid manifest",
+			prepare: func(t *testing.T, root string) {
+				writeFile(t, root, manifest.FileName, `id = "acme.test"`)
+			},
+			wantErr: manifest.ErrInvalidManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.RemoveAll(linkDir))
+			root := initPlugin(t, templateJS)
+			tt.prepare(t, root)
+
+			linked, err := manifest.LinkDev(linkDir, root)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			target, err := os.Readlink(filepath.Join(linkDir, linked.ID))
+			require.NoError(t, err)
+			require.Equal(t, root, target)
+
+			require.NoError(t, manifest.UnlinkDev(linkDir, linked.ID))
+			_, err = os.Lstat(filepath.Join(linkDir, linked.ID))
+			require.True(t, os.IsNotExist(err))
+			_, err = os.Stat(filepath.Join(root, manifest.FileName))
+			require.NoError(t, err, "unlinking leaves the working directory alone")
+		})
+	}
+}
+
+func TestDevUnlink_KeepsInstalledCopies(t *testing.T) {
+	linkDir := t.TempDir()
+	writeFile(t, linkDir, "acme.test/"+manifest.FileName, "installed")
+
+	err := manifest.UnlinkDev(linkDir, "acme.test")
+	require.ErrorContains(t, err, manifest.ErrBadSource)
+	_, err = os.Stat(filepath.Join(linkDir, "acme.test", manifest.FileName))
+	require.NoError(t, err)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"yanta/internal/plugins/manifest"
+)
+
+const (
+	templateJS   = "js"
+	templateWASM = "wasm"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// scaffold is what the init templates are rendered with.
+type scaffold struct {
+	ID         string
+	Name       string
+	APIVersion string
+}
+
+func runInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory to create the plugin in")
+	id := fs.String("id", "", "plugin id, such as acme.my-plugin")
+	name := fs.String("name", "", "plugin name (defaults to the id)")
+	kind := fs.String("template", templateJS, "plugin template: js or wasm")
+	force := fs.Bool("force", false, "overwrite existing files")
+	_ = fs.Parse(args)
+
+	pluginID := strings.TrimSpace(*id)
+	if pluginID == "" {
+		fatalf("-id is required")
+	}
+	if filepath.Base(pluginID) != pluginID || !filepath.IsLocal(pluginID) {
+		fatalf("-id %q cannot name a directory", pluginID)
+	}
+	if *kind != templateJS && *kind != templateWASM {
+		fatalf("unknown template %q (want %s or %s)", *kind, templateJS, templateWASM)
+	}
+	data := scaffold{
+		ID:         pluginID,
+		Name:       strings.TrimSpace(*name),
+		APIVersion: manifest.SupportedAPIVersion,
+	}
+	if data.Name == "" {
+		data.Name = pluginID
+	}
+
+	root, err := filepath.Abs(strings.TrimSpace(*dir))
+	if err != nil {
+		fatalf("resolve plugin directory: %v", err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		fatalf("create plugin directory %q: %v", root, err)
+	}
+	written, err := renderTemplate(*kind, root, data, *force)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	for _, file := range written {
+		fmt.Printf("created: %s\n", file)
+	}
+}
+
+// renderTemplate writes the files of a template into root. A name.tmpl file
+// becomes name; gitignore becomes .gitignore, as embed skips dot files.
+func renderTemplate(kind, root string, data scaffold, force bool) ([]string, error) {
+	base := path.Join("templates", kind)
+	entries, err := templates.ReadDir(base)
+	if err != nil {
+		return nil, fmt.Errorf("read template %q: %w", kind, err)
+	}
+
+	targets := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".tmpl")
+		if name == "gitignore" {
+			name = ".gitignore"
+		}
+		target := filepath.Join(root, name)
+		if _, err := os.Stat(target); err == nil && !force {
+			return nil, fmt.Errorf("%s already exists (pass -force to overwrite)", target)
+		}
+		targets[entry.Name()] = target
+	}
+
+	written := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		tmpl, err := template.ParseFS(templates, path.Join(base, entry.Name()))
+		if err != nil {
+			return written, fmt.Errorf("parse template %s: %w", entry.Name(), err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return written, fmt.Errorf("render template %s: %w", entry.Name(), err)
+		}
+		target := targets[entry.Name()]
+		if err := os.WriteFile(target, []byte(out.String()), 0o644); err != nil {
+			return written, fmt.Errorf("write %s: %w", target, err)
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
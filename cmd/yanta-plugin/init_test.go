@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/plugins/manifest"
+)
+
+// initPlugin scaffolds the template kind into a new directory, as init does.
+func initPlugin(t *testing.T, kind string) string {
+	t.Helper()
+	root := t.TempDir()
+	data := scaffold{ID: "acme.test", Name: "Test Plugin", APIVersion: manifest.SupportedAPIVersion}
+	_, err := renderTemplate(kind, root, data, false)
+	require.NoError(t, err)
+	return root
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		wantFiles []string
+		wantEntry string
+	}{
+		{
+			name:      "js",
+			kind:      templateJS,
+			wantFiles: []string{".gitignore", "package.json", "plugin.toml", "plugin.ts"},
+			wantEntry: manifest.Entrypoint,
+		},
+		{
+			name:      "wasm",
+			kind:      templateWASM,
+			wantFiles: []string{".gitignore", "go.mod", "main.go", "plugin.toml"},
+			wantEntry: "plugin.wasm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := initPlugin(t, tt.kind)
+			for _, name := range tt.wantFiles {
+				_, err := os.Stat(filepath.Join(root, name))
+				require.NoError(t, err, name)
+			}
+
+			m, decodeErr := manifest.Load(root)
+			require.NoError(t, decodeErr)
+			status, issues := manifest.Validate(m, decodeErr)
+			require.Equal(t, manifest.StatusOK, status, issues)
+			require.Equal(t, "acme.test", m.ID)
+			require.Equal(t, "Test Plugin", m.Name)
+			require.Equal(t, manifest.SupportedAPIVersion, m.APIVersion)
+			require.Equal(t, tt.wantEntry, m.Entry)
+		})
+	}
+}
+
+func TestRenderTemplate_KeepsExistingFiles(t *testing.T) {
+	root := t.TempDir()
+	data := scaffold{ID: "acme.test", Name: "Test Plugin", APIVersion: manifest.SupportedAPIVersion}
+	existing := filepath.Join(root, manifest.FileName)
+	require.NoError(t, os.WriteFile(existing, []byte("mine"), 0o644))
+
+	_, err := renderTemplate(templateJS, root, data, false)
+	require.ErrorContains(t, err, "already exists")
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "mine", string(content))
+	_, err = os.Stat(filepath.Join(root, "plugin.ts"))
+	require.True(t, os.IsNotExist(err), "nothing is written when a file exists")
+
+	_, err = renderTemplate(templateJS, root, data, true)
+	require.NoError(t, err)
+	content, err = os.ReadFile(existing)
+	require.NoError(t, err)
+	require.NotEqual(t, "mine", string(content))
+}
@@ -17,6 +17,10 @@ import (
 
 const signatureFileName = "signature.json"
 
+// packageExtension marks packages built by pack. They are never part of a
+// plugin's files, so packing into the plugin directory is safe.
+const packageExtension = ".yplg"
+
 var skippedDirs = map[string]struct{}{
 	".git":         {},
 	".keys":        {},
@@ -38,8 +42,16 @@ func main() {
 	}
 
 	switch os.Args[1] {
+	case "init":
+		runInit(os.Args[2:])
 	case "build":
 		runBuild(os.Args[2:])
+	case "validate":
+		runValidate(os.Args[2:])
+	case "pack":
+		runPack(os.Args[2:])
+	case "dev":
+		runDev(os.Args[2:])
 	case "keygen":
 		runKeygen(os.Args[2:])
 	case "sign":
@@ -96,44 +108,54 @@ func runSign(args []string) {
 		fatalf("-key-id is required")
 	}
 
-	encodedPrivateKey, err := os.ReadFile(*privateKeyPath)
+	signaturePath, err := signPlugin(root, *privateKeyPath, strings.TrimSpace(*publisherID), strings.TrimSpace(*keyID))
 	if err != nil {
-		fatalf("read private key: %v", err)
+		fatalf("%v", err)
+	}
+	fmt.Printf("signed: %s\n", signaturePath)
+}
+
+// signPlugin signs the files of the plugin at root and writes the signature
+// next to them, returning its path.
+func signPlugin(root, privateKeyPath, publisherID, keyID string) (string, error) {
+	encodedPrivateKey, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("read private key: %w", err)
 	}
 	privateKeyRaw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedPrivateKey)))
 	if err != nil {
-		fatalf("decode private key: %v", err)
+		return "", fmt.Errorf("decode private key: %w", err)
 	}
 	if len(privateKeyRaw) != ed25519.PrivateKeySize {
-		fatalf("private key must be %d raw bytes in base64", ed25519.PrivateKeySize)
+		return "", fmt.Errorf("private key must be %d raw bytes in base64", ed25519.PrivateKeySize)
 	}
 
 	digestHex, err := computeDigest(root)
 	if err != nil {
-		fatalf("compute digest: %v", err)
+		return "", fmt.Errorf("compute digest: %w", err)
 	}
 	digestBytes, err := hex.DecodeString(digestHex)
 	if err != nil {
-		fatalf("decode digest: %v", err)
+		return "", fmt.Errorf("decode digest: %w", err)
 	}
 	signature := ed25519.Sign(ed25519.PrivateKey(privateKeyRaw), digestBytes)
 	payload := signaturePayload{
 		Algorithm:   "ed25519",
-		PublisherID: strings.TrimSpace(*publisherID),
-		KeyID:       strings.TrimSpace(*keyID),
+		PublisherID: publisherID,
+		KeyID:       keyID,
 		Digest:      digestHex,
 		Signature:   base64.StdEncoding.EncodeToString(signature),
 	}
 	encoded, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
-		fatalf("encode signature payload: %v", err)
+		return "", fmt.Errorf("encode signature payload: %w", err)
 	}
 
 	signaturePath := filepath.Join(root, signatureFileName)
 	if err := os.WriteFile(signaturePath, encoded, 0o644); err != nil {
-		fatalf("write signature: %v", err)
+		return "", fmt.Errorf("write signature: %w", err)
 	}
-	fmt.Printf("signed: %s\n", signaturePath)
+	return signaturePath, nil
 }
 
 func mustPluginDir(raw string) string {
@@ -201,7 +223,7 @@ func collectPluginFiles(root string) ([]string, error) {
 		if rel == "." || strings.HasPrefix(rel, "../") {
 			return fmt.Errorf("invalid file outside plugin root: %s", path)
 		}
-		if rel == signatureFileName {
+		if rel == signatureFileName || strings.HasSuffix(rel, packageExtension) {
 			return nil
 		}
 		files = append(files, rel)
@@ -221,14 +243,22 @@ Usage:
   yanta-plugin <command> [flags]
 
 Commands:
-  build    Build plugin entrypoint with Bun and generate main.meta.json
-  keygen   Generate publisher keypair
-  sign     Sign plugin directory and write signature.json
+  init      Create a plugin from the js or wasm template
+  build     Build plugin entrypoint with Bun and generate main.meta.json
+  validate  Run the manifest, capability and build checks YANTA runs on install
+  keygen    Generate publisher keypair
+  sign      Sign plugin directory and write signature.json
+  pack      Validate and package a signed plugin for Install from Package
+  dev       Link a plugin into YANTA and rebuild it on change
 
 Examples:
+  go run ./cmd/yanta-plugin init -dir ./my-plugin -id acme.my-plugin -name "My Plugin"
   go run ./cmd/yanta-plugin build -plugin ./examples/plugins/generic-editor-extension -entry plugin.ts -out main.js
   go run ./cmd/yanta-plugin keygen -out ./my-plugin/.keys
-  go run ./cmd/yanta-plugin sign -plugin ./my-plugin -private-key ./my-plugin/.keys/private.key -publisher-id my.publisher -key-id my-key-1`)
+  go run ./cmd/yanta-plugin sign -plugin ./my-plugin -private-key ./my-plugin/.keys/private.key -publisher-id my.publisher -key-id my-key-1
+  go run ./cmd/yanta-plugin validate -plugin ./my-plugin
+  go run ./cmd/yanta-plugin pack -plugin ./my-plugin -out acme.my-plugin-1.0.0.yplg
+  go run ./cmd/yanta-plugin dev -plugin ./my-plugin`)
 }
 
 func fatalf(format string, args ...any) {
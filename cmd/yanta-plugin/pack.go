@@ -0,0 +1,187 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yanta/internal/plugins/manifest"
+)
+
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	pluginDir := fs.String("plugin", ".", "plugin directory (contains plugin.toml)")
+	_ = fs.Parse(args)
+
+	root := mustPluginDir(*pluginDir)
+	m := mustValidate(root)
+	fmt.Printf("valid: %s %s\n", m.ID, m.Version)
+}
+
+// mustValidate runs the checks YANTA runs when installing root and exits
+// listing every issue found.
+func mustValidate(root string) manifest.Manifest {
+	m, issues := manifest.ValidateDirectory(root)
+	if len(issues) > 0 {
+		printIssues(issues)
+		fatalf("%s is not a valid plugin", root)
+	}
+	return m
+}
+
+func printIssues(issues []manifest.ValidationIssue) {
+	for _, issue := range issues {
+		if issue.Field != "" {
+			fmt.Fprintf(os.Stderr, "%s (%s): %s\n", issue.Code, issue.Field, issue.Message)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", issue.Code, issue.Message)
+	}
+}
+
+func runPack(args []string) {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	pluginDir := fs.String("plugin", ".", "plugin directory (contains plugin.toml)")
+	out := fs.String("out", "", "package path (defaults to <id>-<version>.yplg)")
+	privateKeyPath := fs.String("private-key", "", "sign with this base64 private key file before packing")
+	publisherID := fs.String("publisher-id", "", "publisher identifier, with -private-key")
+	keyID := fs.String("key-id", "", "signing key identifier, with -private-key")
+	_ = fs.Parse(args)
+
+	root := mustPluginDir(*pluginDir)
+	m := mustValidate(root)
+
+	if strings.TrimSpace(*privateKeyPath) != "" {
+		if strings.TrimSpace(*publisherID) == "" || strings.TrimSpace(*keyID) == "" {
+			fatalf("-publisher-id and -key-id are required with -private-key")
+		}
+		if _, err := signPlugin(root, *privateKeyPath, strings.TrimSpace(*publisherID), strings.TrimSpace(*keyID)); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	if err := checkSignature(root); err != nil {
+		fatalf("%v", err)
+	}
+
+	outPath := strings.TrimSpace(*out)
+	if outPath == "" {
+		outPath = m.ID + "-" + m.Version + packageExtension
+	}
+	if err := writePackage(root, outPath); err != nil {
+		fatalf("write package: %v", err)
+	}
+	sum, err := fileDigest(outPath)
+	if err != nil {
+		fatalf("hash package: %v", err)
+	}
+
+	fmt.Printf("packed: %s\n", outPath)
+	fmt.Printf("sha256: %s\n", sum)
+}
+
+// checkSignature fails unless signature.json signs the plugin's current
+// files, as an unsigned or stale package would be rejected on install.
+func checkSignature(root string) error {
+	data, err := os.ReadFile(filepath.Join(root, signatureFileName))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s is missing: run sign, or pack with -private-key", signatureFileName)
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", signatureFileName, err)
+	}
+	var payload signaturePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("invalid %s: %w", signatureFileName, err)
+	}
+	digest, err := computeDigest(root)
+	if err != nil {
+		return fmt.Errorf("compute digest: %w", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(payload.Digest), digest) {
+		return fmt.Errorf("%s does not match the plugin files: sign again, or pack with -private-key", signatureFileName)
+	}
+	return nil
+}
+
+// writePackage zips the signed files of the plugin at root, plus its
+// signature, into outPath. The package is written under a temporary name
+// and renamed, so a failed pack leaves no partial package behind.
+func writePackage(root, outPath string) error {
+	files, err := collectPluginFiles(root)
+	if err != nil {
+		return err
+	}
+	files = append(files, signatureFileName)
+
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), ".yanta-plugin-pack-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	archive := zip.NewWriter(tmp)
+	for _, rel := range files {
+		if err := addPackageFile(archive, root, rel); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), outPath)
+}
+
+func addPackageFile(archive *zip.Writer, root, rel string) error {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = rel
+	header.Method = zip.Deflate
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if _, err := io.Copy(writer, in); err != nil {
+		return fmt.Errorf("add %s: %w", rel, err)
+	}
+	return nil
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/plugins/manifest"
+)
+
+// buildJSPlugin scaffolds a JS plugin and stands in for build: it writes a
+// bundle and the metadata build would write for it.
+func buildJSPlugin(t *testing.T) string {
+	t.Helper()
+	root := initPlugin(t, templateJS)
+	bundle := []byte("export function setup() {}")
+	require.NoError(t, os.WriteFile(filepath.Join(root, defaultBuildOut), bundle, 0o644))
+	require.NoError(t, writeBuildMetadata(filepath.Join(root, defaultBuildMeta), bundle))
+	return root
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T) string
+		wantCode string
+	}{
+		{
+			name:    "built js plugin",
+			prepare: buildJSPlugin,
+		},
+		{
+			name: "not built",
+			prepare: func(t *testing.T) string {
+				return initPlugin(t, templateJS)
+			},
+			wantCode: manifest.ErrInvalidManifest,
+		},
+		{
+			name: "bundle without build metadata",
+			prepare: func(t *testing.T) string {
+				root := buildJSPlugin(t)
+				require.NoError(t, os.Remove(filepath.Join(root, defaultBuildMeta)))
+				return root
+			},
+			wantCode: manifest.ErrBuildMetadataMissing,
+		},
+		{
+			name: "bundle edited after build",
+			prepare: func(t *testing.T) string {
+				root := buildJSPlugin(t)
+				writeFile(t, root, defaultBuildOut, "export const changed = true")
+				return root
+			},
+			wantCode: manifest.ErrBuildHashMismatch,
+		},
+		{
+			name: "unknown capability",
+			prepare: func(t *testing.T) string {
+				root := buildJSPlugin(t)
+				writeFile(t, root, manifest.FileName, `id = "acme.test"
+name = "Test Plugin"
+version = "0.1.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["commands", "teleport"]
+`)
+				return root
+			},
+			wantCode: "UNKNOWN_CAPABILITY",
+		},
+		{
+			name: "wasm entry is not a module",
+			prepare: func(t *testing.T) string {
+				root := initPlugin(t, templateWASM)
+				writeFile(t, root, "plugin.wasm", "not wasm")
+				return root
+			},
+			wantCode: manifest.ErrInvalidManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, issues := manifest.ValidateDirectory(tt.prepare(t))
+			if tt.wantCode == "" {
+				require.Empty(t, issues)
+				require.Equal(t, "acme.test", m.ID)
+				return
+			}
+			require.Len(t, issues, 1, issues)
+			require.Equal(t, tt.wantCode, issues[0].Code)
+		})
+	}
+}
+
+func TestWriteBuildMetadata_RefusesBundledHostPackages(t *testing.T) {
+	metaPath := filepath.Join(t.TempDir(), defaultBuildMeta)
+	require.NoError(t, os.WriteFile(metaPath, []byte("{}"), 0o644))
+
+	err := writeBuildMetadata(metaPath, []byte("// node_modules/react/index.js"))
+	require.ErrorContains(t, err, "react")
+	_, statErr := os.Stat(metaPath)
+	require.True(t, os.IsNotExist(statErr), "stale metadata is removed")
+}
+
+// signTestPlugin signs root with a fresh key, as sign does.
+func signTestPlugin(t *testing.T, root string) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "private.key")
+	require.NoError(t, os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(privateKey)), 0o600))
+	_, err = signPlugin(root, keyPath, "acme", "key-1")
+	require.NoError(t, err)
+}
+
+func TestCheckSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, root string)
+		wantErr string
+	}{
+		{
+			name:    "unsigned",
+			prepare: func(t *testing.T, root string) {},
+			wantErr: "is missing",
+		},
+		{
+			name:    "signed",
+			prepare: signTestPlugin,
+		},
+		{
+			name: "changed after signing",
+			prepare: func(t *testing.T, root string) {
+				signTestPlugin(t, root)
+				writeFile(t, root, "plugin.ts", "// changed")
+			},
+			wantErr: "does not match",
+		},
+		{
+			name: "package added after signing",
+			prepare: func(t *testing.T, root string) {
+				signTestPlugin(t, root)
+				writeFile(t, root, "acme.test-0.1.0"+packageExtension, "zip")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := buildJSPlugin(t)
+			tt.prepare(t, root)
+			err := checkSignature(root)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWritePackage(t *testing.T) {
+	root := buildJSPlugin(t)
+	writeFile(t, root, "assets/icon.svg", "<svg/>")
+	writeFile(t, root, "acme.test-0.0.9"+packageExtension, "old package")
+	writeFile(t, root, "dist/acme.test-0.0.8"+packageExtension, "older package")
+	writeFile(t, root, ".git/HEAD", "ref: refs/heads/main")
+	writeFile(t, root, ".keys/private.key", "secret")
+	writeFile(t, root, "node_modules/left-pad/index.js", "module.exports = 1")
+	signTestPlugin(t, root)
+
+	// Packing into the plugin directory, over a package of the same name.
+	outPath := filepath.Join(root, "acme.test-0.1.0"+packageExtension)
+	writeFile(t, root, filepath.Base(outPath), "previous pack")
+	require.NoError(t, checkSignature(root))
+	require.NoError(t, writePackage(root, outPath))
+
+	archive, err := zip.OpenReader(outPath)
+	require.NoError(t, err)
+	defer archive.Close()
+	packed := map[string]bool{}
+	for _, file := range archive.File {
+		require.False(t, packed[file.Name], "%s is packed once", file.Name)
+		packed[file.Name] = true
+	}
+
+	tests := []struct {
+		path       string
+		wantPacked bool
+	}{
+		{path: manifest.FileName, wantPacked: true},
+		{path: manifest.Entrypoint, wantPacked: true},
+		{path: manifest.BuildMetadataFile, wantPacked: true},
+		{path: "plugin.ts", wantPacked: true},
+		{path: "assets/icon.svg", wantPacked: true},
+		{path: signatureFileName, wantPacked: true},
+		{path: "acme.test-0.1.0" + packageExtension},
+		{path: "acme.test-0.0.9" + packageExtension},
+		{path: "dist/acme.test-0.0.8" + packageExtension},
+		{path: ".git/HEAD"},
+		{path: ".keys/private.key"},
+		{path: "node_modules/left-pad/index.js"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			require.Equal(t, tt.wantPacked, packed[tt.path])
+		})
+	}
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.NotContains(t, entry.Name(), ".yanta-plugin-pack-", "no temporary package is left behind")
+	}
+}
@@ -5,11 +5,13 @@ import { Button } from "../../shared/ui";
 export interface ConflictBannerProps {
 	onKeepMine?: () => void;
 	onReloadFromDisk?: () => void;
+	/** Shown when the version on disk is known and can be merged in. */
+	onMerge?: () => void;
 	compact?: boolean;
 }
 
 export const ConflictBanner: React.FC<ConflictBannerProps> = React.memo(
-	({ onKeepMine, onReloadFromDisk, compact = false }) => {
+	({ onKeepMine, onReloadFromDisk, onMerge, compact = false }) => {
 		const padding = compact ? "px-4 py-2" : "px-6 py-3";
 		const textSize = compact ? "text-xs" : "text-xs";
 
@@ -33,6 +35,16 @@ export const ConflictBanner: React.FC<ConflictBannerProps> = React.memo(
 							Keep Mine
 						</Button>
 					)}
+					{onMerge && (
+						<Button
+							variant="ghost"
+							size="sm"
+							onClick={onMerge}
+							className="text-xs font-semibold uppercase tracking-widest"
+						>
+							Merge
+						</Button>
+					)}
 					{onReloadFromDisk && (
 						<Button
 							variant="primary"
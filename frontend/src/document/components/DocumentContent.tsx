@@ -58,6 +58,7 @@ export interface DocumentContentProps {
 	hasConflict?: boolean;
 	onReloadFromDisk?: () => void;
 	onKeepMine?: () => void;
+	onMerge?: () => void;
 	/** Ref to the find bar for external refocus/seed control. */
 	findBarRef?: React.RefObject<{ setQuery: (q: string) => void; focusInput: () => void } | null>;
 	/** Whether the document outline panel is open. */
@@ -124,6 +125,7 @@ export const DocumentContent: React.FC<DocumentContentProps> = React.memo(
 		hasConflict = false,
 		onReloadFromDisk,
 		onKeepMine,
+		onMerge,
 		findBarRef: externalFindBarRef,
 		isOutlineOpen = false,
 		onCloseOutline,
@@ -225,7 +227,11 @@ export const DocumentContent: React.FC<DocumentContentProps> = React.memo(
 							</div>
 						)}
 						{hasConflict && (
-							<ConflictBanner onKeepMine={onKeepMine} onReloadFromDisk={onReloadFromDisk} />
+							<ConflictBanner
+								onKeepMine={onKeepMine}
+								onReloadFromDisk={onReloadFromDisk}
+								onMerge={onMerge}
+							/>
 						)}
 						<DocumentEditorForm
 							title={documentTitle ?? ""}
@@ -0,0 +1,214 @@
+import { act, renderHook } from "@testing-library/react";
+import { beforeEach, describe, expect, it, vi } from "vitest";
+import type { BlockNoteBlock, DocumentConflict } from "../../../shared/types/Document";
+import { useDocumentController } from "../useDocumentController";
+
+const mocks = vi.hoisted(() => {
+	const block = (id: string, text: string) => ({
+		id,
+		type: "paragraph",
+		content: [{ type: "text", text }],
+	});
+	const baseBlocks = [block("a", "one"), block("b", "two")];
+	return {
+		block,
+		baseBlocks,
+		data: { blocks: baseBlocks },
+		persistence: { current: null as null | { onConflict?: (c: unknown) => void } },
+		updateDocumentHash: vi.fn(),
+		refreshHash: vi.fn(async () => {}),
+		getDocument: vi.fn(),
+		setPageContext: vi.fn(),
+	};
+});
+
+vi.mock("../../../../bindings/yanta/internal/tag/service", () => ({
+	GetDocumentTags: vi.fn(async () => []),
+}));
+
+vi.mock("../../../help", () => ({
+	useHelp: () => ({ setPageContext: mocks.setPageContext }),
+}));
+
+vi.mock("../../../onboarding", () => ({
+	useUserProgressContext: () => ({ incrementDocumentsCreated: vi.fn() }),
+}));
+
+vi.mock("../../../pane", () => ({
+	usePaneLayout: () => ({ activePaneId: null }),
+}));
+
+vi.mock("../../../project", () => ({
+	useProjectContext: () => ({ currentProject: { alias: "@proj", name: "Project" } }),
+}));
+
+vi.mock("../../../shared/hooks", () => ({
+	useNotification: () => ({ error: vi.fn(), success: vi.fn() }),
+	useRecentDocuments: () => ({ addRecentDocument: vi.fn() }),
+	useSidebarSections: () => [],
+}));
+
+vi.mock("../../../shared/services/DocumentService", () => ({
+	DocumentServiceWrapper: { get: mocks.getDocument, getHash: vi.fn(), restore: vi.fn() },
+}));
+
+vi.mock("../useDocumentEditor", () => ({
+	useDocumentEditor: () => ({ handleEditorReady: vi.fn() }),
+}));
+
+vi.mock("../useDocumentExports", () => ({
+	useDocumentExports: () => ({
+		handleExportToMarkdown: vi.fn(),
+		handleExportToPDF: vi.fn(),
+		handleExportCanvasImage: vi.fn(),
+	}),
+}));
+
+vi.mock("../useDocumentHotkeysConfig", () => ({
+	useDocumentHotkeysConfig: () => [],
+}));
+
+vi.mock("../useDocumentEscapeHandling", () => ({
+	useDocumentEscapeHandling: () => ({ handleEscape: vi.fn(), handleUnfocus: vi.fn() }),
+}));
+
+vi.mock("../useCanvasEscapeSequence", () => ({
+	useCanvasEscapeSequence: () => vi.fn(),
+}));
+
+vi.mock("../useDocumentInitialization", async () => {
+	const { useEffect } = await import("react");
+	return {
+		useDocumentInitialization: ({
+			initializeForm,
+		}: {
+			initializeForm: (data: {
+				title: string;
+				blocks: BlockNoteBlock[];
+				tags: string[];
+				kind: "document";
+			}) => void;
+		}) => {
+			useEffect(() => {
+				initializeForm({ title: "one", blocks: mocks.baseBlocks, tags: [], kind: "document" });
+			}, [initializeForm]);
+			return {
+				data: mocks.data,
+				isLoading: false,
+				loadError: null,
+				reload: vi.fn(),
+				shouldAutoSave: false,
+				resetAutoSave: vi.fn(),
+				documentHash: "hash-1",
+				refreshHash: mocks.refreshHash,
+				updateDocumentHash: mocks.updateDocumentHash,
+			};
+		},
+	};
+});
+
+vi.mock("../useDocumentPersistence", () => ({
+	useDocumentPersistence: (props: { onConflict?: (c: unknown) => void }) => {
+		mocks.persistence.current = props;
+		return {
+			autoSave: {
+				saveNow: vi.fn(async () => {}),
+				hasUnsavedChanges: false,
+				saveState: "idle",
+				lastSaved: null,
+				saveError: null,
+			},
+		};
+	},
+}));
+
+const { block } = mocks;
+
+const diskBlocks = [block("a", "one"), block("b", "two (disk)"), block("c", "added on disk")];
+
+const conflict: DocumentConflict = {
+	path: "projects/@proj/doc-1.json",
+	currentHash: "hash-2",
+	current: {
+		meta: {
+			project: "@proj",
+			title: "one",
+			tags: ["disk"],
+			aliases: [],
+			created: "2026-01-01T00:00:00Z",
+			updated: "2026-01-02T00:00:00Z",
+		},
+		blocks: diskBlocks,
+	},
+};
+
+const renderController = () => {
+	const hook = renderHook(() =>
+		useDocumentController({ documentPath: "projects/@proj/doc-1.json" }),
+	);
+	act(() => {
+		mocks.persistence.current?.onConflict?.(conflict);
+	});
+	return hook;
+};
+
+describe("useDocumentController save conflicts", () => {
+	beforeEach(() => {
+		mocks.updateDocumentHash.mockClear();
+		mocks.refreshHash.mockClear();
+		mocks.getDocument.mockClear();
+	});
+
+	it("keeps the conflict payload a refused save reports", () => {
+		const { result } = renderController();
+
+		expect(result.current.hasConflict).toBe(true);
+		expect(result.current.conflict).toEqual(conflict);
+		expect(result.current.onMerge).toBeDefined();
+	});
+
+	it("reloads from the version the conflict carries", async () => {
+		const { result } = renderController();
+
+		await act(async () => {
+			await result.current.onReloadFromDisk();
+		});
+
+		expect(mocks.getDocument).not.toHaveBeenCalled();
+		expect(mocks.updateDocumentHash).toHaveBeenCalledWith("hash-2");
+		expect(result.current.contentProps.formData.blocks).toEqual(diskBlocks);
+		expect(result.current.contentProps.formData.tags).toEqual(["disk"]);
+		expect(result.current.conflict).toBeNull();
+	});
+
+	it("rebases keep-mine onto the conflicting hash", async () => {
+		const { result } = renderController();
+
+		await act(async () => {
+			await result.current.onKeepMine();
+		});
+
+		expect(mocks.updateDocumentHash).toHaveBeenCalledWith("hash-2");
+		expect(mocks.refreshHash).not.toHaveBeenCalled();
+		expect(result.current.hasConflict).toBe(false);
+	});
+
+	it("merges the version on disk into the editor content", () => {
+		const { result } = renderController();
+		act(() => {
+			result.current.contentProps.onBlocksChange?.([block("a", "one (mine)"), block("b", "two")]);
+		});
+
+		act(() => {
+			result.current.onMerge?.();
+		});
+
+		expect(mocks.updateDocumentHash).toHaveBeenCalledWith("hash-2");
+		expect(result.current.contentProps.formData.blocks).toEqual([
+			block("a", "one (mine)"),
+			block("b", "two (disk)"),
+			block("c", "added on disk"),
+		]);
+		expect(result.current.hasConflict).toBe(false);
+	});
+});
@@ -10,10 +10,12 @@ import { useNotification, useRecentDocuments, useSidebarSections } from "../../s
 import { DocumentServiceWrapper } from "../../shared/services/DocumentService";
 import { useDocumentCommandStore } from "../../shared/stores/documentCommand.store";
 import type { NavigationState, PageName } from "../../shared/types";
+import type { BlockNoteBlock, DocumentConflict } from "../../shared/types/Document";
 import type { HotkeyConfig } from "../../shared/types/hotkeys";
 import { BackendLogger } from "../../shared/utils/backendLogger";
 import type { DocumentContentProps } from "../components/DocumentContent";
 import { createEmptyDocument } from "../utils/documentBlockUtils";
+import { mergeConflictBlocks } from "../utils/documentMergeUtils";
 import { getSelectedText } from "../utils/editorSelection";
 import { useCanvasEscapeSequence } from "./useCanvasEscapeSequence";
 import { useDocumentEditor } from "./useDocumentEditor";
@@ -46,10 +48,17 @@ export interface DocumentControllerResult {
 	escapeHandler: (e: KeyboardEvent) => void;
 	/** True when the file was modified externally and the user must choose to reload or keep. */
 	hasConflict: boolean;
+	/**
+	 * The save conflict the backend reported, with the version on disk. Null
+	 * when there is none, or when only an external change event was seen.
+	 */
+	conflict: DocumentConflict | null;
 	/** Accept the external changes and reload the document. */
 	onReloadFromDisk: () => void;
 	/** Dismiss the conflict banner and keep the current editor content. */
 	onKeepMine: () => void;
+	/** Merge the version on disk into the editor; set only when a save conflict carries it. */
+	onMerge?: () => void;
 }
 
 export function useDocumentController({
@@ -107,7 +116,20 @@ export function useDocumentController({
 		initializeForm,
 	});
 
-	const [hasConflict, setHasConflict] = useState(false);
+	// An external change event only says the file changed; a refused save also
+	// carries the version on disk (see DocumentConflict).
+	const [hasExternalChange, setHasExternalChange] = useState(false);
+	const [conflict, setConflict] = useState<DocumentConflict | null>(null);
+	const hasConflict = hasExternalChange || conflict !== null;
+	const clearConflict = useCallback(() => {
+		setHasExternalChange(false);
+		setConflict(null);
+	}, []);
+	// The blocks last loaded from or saved to disk: the base a conflict merge
+	// compares both sides against.
+	const baseBlocksRef = useRef<BlockNoteBlock[]>([]);
+	const formBlocksRef = useRef(formData.blocks);
+	formBlocksRef.current = formData.blocks;
 
 	const { handleEditorReady } = useDocumentEditor();
 	const { addRecentDocument } = useRecentDocuments();
@@ -220,9 +242,13 @@ export function useDocumentController({
 		setHasRestored(false);
 		setIsRestoring(false);
 		setIsEditorReady(false);
-		setHasConflict(false);
+		clearConflict();
 		lastAddedPathRef.current = null;
-	}, [documentPath]);
+	}, [documentPath, clearConflict]);
+
+	useEffect(() => {
+		baseBlocksRef.current = data?.blocks ?? [];
+	}, [data]);
 
 	useEffect(() => {
 		if (!data?.deletedAt) {
@@ -266,10 +292,11 @@ export function useDocumentController({
 		isEditorReady,
 		onNewDocumentSaved: incrementDocumentsCreated,
 		documentHash,
-		onConflict: () => setHasConflict(true),
+		onConflict: setConflict,
 		onSaveComplete: (newHash) => {
 			updateDocumentHash(newHash);
-			setHasConflict(false);
+			baseBlocksRef.current = formBlocksRef.current;
+			clearConflict();
 		},
 	});
 
@@ -488,7 +515,7 @@ export function useDocumentController({
 		const unsubscribeExternalChange = Events.On("yanta/entry/external-change", (ev) => {
 			const data = ev.data as { path?: string };
 			if (data?.path === documentPath) {
-				setHasConflict(true);
+				setHasExternalChange(true);
 			}
 		});
 
@@ -500,6 +527,22 @@ export function useDocumentController({
 
 	const handleReloadFromDisk = useCallback(async () => {
 		if (!documentPath) return;
+		// A refused save already carries the version on disk; reload from it
+		// rather than a second read that may have moved on again. Canvases are
+		// sent without their scene, so they always read the file.
+		const current = conflict?.current;
+		if (conflict?.currentHash && current?.blocks && formData.kind !== "canvas") {
+			initializeForm({
+				title: current.meta.title,
+				blocks: current.blocks,
+				tags: current.meta.tags ?? [],
+				kind: formData.kind,
+			});
+			baseBlocksRef.current = current.blocks;
+			updateDocumentHash(conflict.currentHash);
+			clearConflict();
+			return;
+		}
 		try {
 			const doc = await DocumentServiceWrapper.get(documentPath);
 			initializeForm({
@@ -515,17 +558,47 @@ export function useDocumentController({
 			// the next edit would then flush back over disk). Bump a nonce that keys
 			// the CanvasEditor so a reload remounts it with the disk scene.
 			setReloadNonce((n) => n + 1);
+			baseBlocksRef.current = doc.blocks;
 			await refreshHash();
-			setHasConflict(false);
+			clearConflict();
 		} catch (err) {
 			BackendLogger.error("Failed to reload document from disk:", err);
 		}
-	}, [documentPath, initializeForm, refreshHash]);
+	}, [
+		documentPath,
+		conflict,
+		formData.kind,
+		initializeForm,
+		updateDocumentHash,
+		refreshHash,
+		clearConflict,
+	]);
 
+	// Keeping mine rebases the next save onto the version the conflict showed,
+	// so it overwrites exactly that; a newer change on disk conflicts again.
 	const handleKeepMine = useCallback(async () => {
-		await refreshHash();
-		setHasConflict(false);
-	}, [refreshHash]);
+		if (conflict?.currentHash) {
+			updateDocumentHash(conflict.currentHash);
+		} else {
+			await refreshHash();
+		}
+		clearConflict();
+	}, [conflict, updateDocumentHash, refreshHash, clearConflict]);
+
+	const canMerge = Boolean(conflict?.currentHash && conflict.current?.blocks) && !isCanvas;
+	const handleMerge = useCallback(() => {
+		const theirs = conflict?.current?.blocks;
+		if (!conflict?.currentHash || !theirs) return;
+		const merged = mergeConflictBlocks(baseBlocksRef.current, formData.blocks, theirs);
+		baseBlocksRef.current = theirs;
+		updateDocumentHash(conflict.currentHash);
+		setBlocks(merged);
+		const editor = editorRef.current;
+		if (editor) {
+			editor.replaceBlocks(editor.document, merged as Parameters<typeof editor.replaceBlocks>[1]);
+		}
+		clearConflict();
+	}, [conflict, formData.blocks, updateDocumentHash, setBlocks, clearConflict]);
 
 	const sidebarSections = useSidebarSections({
 		currentPage: "document",
@@ -564,6 +637,7 @@ export function useDocumentController({
 		hasConflict,
 		onReloadFromDisk: handleReloadFromDisk,
 		onKeepMine: handleKeepMine,
+		onMerge: canMerge ? handleMerge : undefined,
 		findBarRef,
 		isOutlineOpen,
 		onCloseOutline: () => setOutlineOpen(false),
@@ -580,7 +654,9 @@ export function useDocumentController({
 		documentTitle: formData.title,
 		escapeHandler: isCanvas ? handleCanvasEscape : handleEscape,
 		hasConflict,
+		conflict,
 		onReloadFromDisk: handleReloadFromDisk,
 		onKeepMine: handleKeepMine,
+		onMerge: canMerge ? handleMerge : undefined,
 	};
 }
@@ -2,7 +2,12 @@ import { useCallback, useEffect, useMemo, useRef, useState } from "react";
 import { PERSISTED_APP_STATE_KEYS } from "../../editor/canvasScene";
 import { useAutoSave } from "../../shared/hooks";
 import { DocumentServiceWrapper } from "../../shared/services/DocumentService";
-import type { BlockNoteBlock, DocumentKind, ExcalidrawScene } from "../../shared/types/Document";
+import type {
+	BlockNoteBlock,
+	DocumentConflict,
+	DocumentKind,
+	ExcalidrawScene,
+} from "../../shared/types/Document";
 import type { Project } from "../../shared/types/Project";
 import { BackendLogger } from "../../shared/utils/backendLogger";
 import { computeContentHash } from "../../shared/utils/contentHash";
@@ -30,7 +35,7 @@ interface UseDocumentPersistenceProps {
 	isEditorReady?: boolean;
 	onNewDocumentSaved?: () => void;
 	documentHash?: string | null;
-	onConflict?: () => void;
+	onConflict?: (conflict: DocumentConflict) => void;
 	onSaveComplete?: (newHash: string) => void;
 }
 
@@ -134,9 +139,9 @@ export const useDocumentPersistence = ({
 				}
 			}
 		} catch (err) {
-			const message = err instanceof Error ? err.message : String(err);
-			if (message.includes("ERR_CONFLICT")) {
-				onConflict?.();
+			const conflict = DocumentServiceWrapper.conflictFromError(err);
+			if (conflict) {
+				onConflict?.(conflict);
 				return;
 			}
 			BackendLogger.error("Save failed:", err);
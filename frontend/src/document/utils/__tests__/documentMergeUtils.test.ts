@@ -0,0 +1,60 @@
+import { describe, expect, it } from "vitest";
+import type { BlockNoteBlock } from "../../../shared/types/Document";
+import { mergeConflictBlocks } from "../documentMergeUtils";
+
+const block = (id: string, text: string): BlockNoteBlock => ({
+	id,
+	type: "paragraph",
+	content: [{ type: "text", text }],
+});
+
+const ids = (blocks: BlockNoteBlock[]) => blocks.map((b) => b.id);
+
+describe("mergeConflictBlocks", () => {
+	const base = [block("a", "one"), block("b", "two"), block("c", "three")];
+
+	it("takes edits made on either side", () => {
+		const mine = [block("a", "one (mine)"), block("b", "two"), block("c", "three")];
+		const theirs = [block("a", "one"), block("b", "two"), block("c", "three (theirs)")];
+
+		expect(mergeConflictBlocks(base, mine, theirs)).toEqual([
+			block("a", "one (mine)"),
+			block("b", "two"),
+			block("c", "three (theirs)"),
+		]);
+	});
+
+	it("keeps mine when both sides edited a block", () => {
+		const mine = [block("a", "mine"), block("b", "two"), block("c", "three")];
+		const theirs = [block("a", "theirs"), block("b", "two"), block("c", "three")];
+
+		expect(mergeConflictBlocks(base, mine, theirs)[0]).toEqual(block("a", "mine"));
+	});
+
+	it("inserts blocks added on disk after their preceding block", () => {
+		const mine = [...base, block("m", "added here")];
+		const theirs = [
+			block("x", "first"),
+			block("a", "one"),
+			block("y", "after a"),
+			block("b", "two"),
+			block("c", "three"),
+		];
+
+		expect(ids(mergeConflictBlocks(base, mine, theirs))).toEqual(["x", "a", "y", "b", "c", "m"]);
+	});
+
+	it("honours deletions on either side", () => {
+		const mine = [block("a", "one"), block("c", "three")];
+		const theirs = [block("a", "one"), block("b", "two")];
+
+		expect(ids(mergeConflictBlocks(base, mine, theirs))).toEqual(["a"]);
+	});
+
+	it("keeps a block deleted on disk that was edited here", () => {
+		const mine = [block("a", "one"), block("b", "two (mine)"), block("c", "three")];
+		const theirs = [block("a", "one"), block("c", "three")];
+
+		expect(ids(mergeConflictBlocks(base, mine, theirs))).toEqual(["a", "b", "c"]);
+	});
+});
@@ -0,0 +1,48 @@
+import type { BlockNoteBlock } from "../../shared/types/Document";
+
+const sameBlock = (a: BlockNoteBlock, b: BlockNoteBlock) => JSON.stringify(a) === JSON.stringify(b);
+
+/**
+ * Three-way merges top-level blocks by id after a save conflict. `base` is the
+ * version both sides started from, `mine` the editor's and `theirs` the one on
+ * disk. A block changed on one side only takes that side; a block changed on
+ * both keeps mine. Blocks deleted on disk are dropped unless edited here, and
+ * blocks added on disk are inserted after the block preceding them there.
+ */
+export function mergeConflictBlocks(
+	base: BlockNoteBlock[],
+	mine: BlockNoteBlock[],
+	theirs: BlockNoteBlock[],
+): BlockNoteBlock[] {
+	const baseById = new Map(base.map((block) => [block.id, block]));
+	const theirsById = new Map(theirs.map((block) => [block.id, block]));
+	const mineIds = new Set(mine.map((block) => block.id));
+
+	const merged: BlockNoteBlock[] = [];
+	for (const block of mine) {
+		const original = baseById.get(block.id);
+		const disk = theirsById.get(block.id);
+		const unchangedHere = original !== undefined && sameBlock(original, block);
+		if (!disk) {
+			if (!unchangedHere) merged.push(block);
+			continue;
+		}
+		merged.push(unchangedHere ? disk : block);
+	}
+
+	theirs.forEach((block, index) => {
+		// Present here already, or in the base and so deleted here.
+		if (mineIds.has(block.id) || baseById.has(block.id)) return;
+		let at = 0;
+		for (let prev = index - 1; prev >= 0; prev--) {
+			const found = merged.findIndex((b) => b.id === theirs[prev].id);
+			if (found >= 0) {
+				at = found + 1;
+				break;
+			}
+		}
+		merged.splice(at, 0, block);
+	});
+
+	return merged;
+}
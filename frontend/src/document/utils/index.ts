@@ -1,3 +1,4 @@
 export { extractAssetHashes } from "./assetExtractor";
 export { createEmptyDocument, createTitleBlock } from "./documentBlockUtils";
+export { mergeConflictBlocks } from "./documentMergeUtils";
 export { extractTitleFromBlocks } from "./documentUtils";
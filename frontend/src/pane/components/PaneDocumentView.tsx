@@ -198,6 +198,7 @@ export const PaneDocumentView: React.FC<PaneDocumentViewProps> = React.memo(
 					<ConflictBanner
 						onKeepMine={controller.onKeepMine}
 						onReloadFromDisk={controller.onReloadFromDisk}
+						onMerge={controller.onMerge}
 						compact
 					/>
 				)}
@@ -1,8 +1,9 @@
+import { Events } from "@wailsio/runtime";
 import { useEffect, useRef } from "react";
 import { usePreferencesStore } from "@/shared/stores/preferences.store";
 import { registerBuiltInPlugins } from "./bootstrap";
 import { installPluginHost } from "./pluginHost";
-import { loadEnabledPlugins, registerInstalledPlugins } from "./registry";
+import { loadEnabledPlugins, registerInstalledPlugins, reloadPlugin } from "./registry";
 
 export function PluginBootstrap() {
 	const isLoadingPreferences = usePreferencesStore((s) => s.isLoading);
@@ -22,5 +23,18 @@ export function PluginBootstrap() {
 		})();
 	}, [isLoadingPreferences]);
 
+	// Dev-linked plugins are reloaded as `yanta-plugin dev` rebuilds them.
+	useEffect(() => {
+		const unsubscribe = Events.On("yanta/plugin/reloaded", (ev) => {
+			const id = ev?.data?.id;
+			if (typeof id === "string" && id && hasStartedRef.current) {
+				void reloadPlugin(id);
+			}
+		});
+		return () => {
+			if (unsubscribe) unsubscribe();
+		};
+	}, []);
+
 	return null;
 }
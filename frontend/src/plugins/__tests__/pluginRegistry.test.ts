@@ -18,6 +18,7 @@ import {
 	loadEnabledPlugins,
 	registerInstalledPlugins,
 	registerPlugin,
+	reloadPlugin,
 	unloadPlugin,
 } from "../registry";
 import type { PluginDefinition } from "../types";
@@ -27,11 +28,13 @@ const {
 	getCommunityPluginsEnabledMock,
 	listInstalledMock,
 	readPluginEntrypointMock,
+	callHostMock,
 } = vi.hoisted(() => ({
 	getSupportedPluginAPIMajorMock: vi.fn(async () => 1),
 	getCommunityPluginsEnabledMock: vi.fn(async () => true),
 	listInstalledMock: vi.fn(async () => []),
-	readPluginEntrypointMock: vi.fn(async () => ""),
+	readPluginEntrypointMock: vi.fn(async (id: string) => ({ source: "", session: `session:${id}` })),
+	callHostMock: vi.fn(async (): Promise<unknown> => null),
 }));
 
 vi.mock("../../../bindings/yanta/internal/plugins/wailsservice", () => ({
@@ -39,6 +42,8 @@ vi.mock("../../../bindings/yanta/internal/plugins/wailsservice", () => ({
 	GetSupportedPluginAPIMajor: getSupportedPluginAPIMajorMock,
 	ListInstalled: listInstalledMock,
 	ReadPluginEntrypoint: readPluginEntrypointMock,
+	CallHost: callHostMock,
+	ReleasePlugin: vi.fn(async () => undefined),
 }));
 
 describe("plugin registry", () => {
@@ -50,7 +55,12 @@ describe("plugin registry", () => {
 		listInstalledMock.mockReset();
 		listInstalledMock.mockResolvedValue([]);
 		readPluginEntrypointMock.mockReset();
-		readPluginEntrypointMock.mockResolvedValue("");
+		readPluginEntrypointMock.mockImplementation(async (id: string) => ({
+			source: "",
+			session: `session:${id}`,
+		}));
+		callHostMock.mockReset();
+		callHostMock.mockResolvedValue(null);
 		__resetPluginRegistryForTests();
 		useCommandRegistryStore.setState({ sources: {} });
 		useSidebarRegistryStore.setState({ sources: {} });
@@ -243,7 +253,9 @@ describe("plugin registry", () => {
 				canExecute: true,
 			},
 		]);
-		readPluginEntrypointMock.mockResolvedValueOnce(`
+		readPluginEntrypointMock.mockResolvedValueOnce({
+			session: "session:external.signed",
+			source: `
 export function setup(api) {
 	api.registerCommands([
 		{
@@ -254,7 +266,8 @@ export function setup(api) {
 		},
 	]);
 }
-`);
+`,
+		});
 
 		await registerInstalledPlugins();
 		await loadEnabledPlugins();
@@ -265,6 +278,49 @@ export function setup(api) {
 		expect(runtime?.isActive).toBe(true);
 	});
 
+	it("reloads a rebuilt plugin from disk", async () => {
+		const record = {
+			manifest: {
+				ID: "dev.plugin",
+				Name: "Dev Plugin",
+				Version: "0.1.0",
+				APIVersion: "1",
+				Entry: "main.js",
+				Capabilities: ["commands"],
+				Description: "",
+				Author: "",
+				Homepage: "",
+			},
+			path: "/plugins/dev.plugin",
+			source: "dev",
+			enabled: true,
+			status: "ok",
+			canExecute: true,
+		};
+		const entry = (text: string) => ({
+			session: `session:${text}`,
+			source: `
+export function setup(api) {
+	api.registerCommands([{ id: "dev-command", text: ${JSON.stringify(text)}, group: "Plugins", action: () => {} }]);
+}
+`,
+		});
+		listInstalledMock.mockResolvedValue([record]);
+		readPluginEntrypointMock.mockResolvedValueOnce(entry("Before"));
+		await registerInstalledPlugins();
+		await loadEnabledPlugins();
+		expect(useCommandRegistryStore.getState().sources["plugin:dev.plugin"]?.[0]?.text).toBe("Before");
+
+		readPluginEntrypointMock.mockResolvedValueOnce(entry("After"));
+		await reloadPlugin("dev.plugin");
+		expect(useCommandRegistryStore.getState().sources["plugin:dev.plugin"]?.[0]?.text).toBe("After");
+
+		listInstalledMock.mockResolvedValue([]);
+		await reloadPlugin("dev.plugin");
+		expect(useCommandRegistryStore.getState().sources["plugin:dev.plugin"]).toBeUndefined();
+		expect(listPlugins().find((item) => item.manifest.id === "dev.plugin")).toBeUndefined();
+	});
+
 	it("blocks a register call for a capability the manifest did not declare", async () => {
 		registerPlugin({
 			manifest: {
@@ -314,6 +370,71 @@ export function setup(api) {
 		expect(useCommandRegistryStore.getState().sources["plugin:declared.caps"]).toHaveLength(1);
 	});
 
+	it("routes host API calls through the backend bridge with the plugin's session", async () => {
+		listInstalledMock.mockResolvedValueOnce([installedRecord("host.caller", ["documentsRead"])]);
+		readPluginEntrypointMock.mockResolvedValueOnce({
+			session: "token-1",
+			source: `
+export async function setup(api) {
+	globalThis.__hostCallerMarkdown = (await api.host.documents.read("projects/@work/doc-1.json")).markdown;
+}
+`,
+		});
+		callHostMock.mockResolvedValueOnce({ path: "projects/@work/doc-1.json", markdown: "# Plan" });
+
+		await registerInstalledPlugins();
+		await loadEnabledPlugins();
+
+		expect(callHostMock).toHaveBeenCalledWith({
+			session: "token-1",
+			method: "documents.read",
+			params: { path: "projects/@work/doc-1.json" },
+		});
+		expect((globalThis as Record<string, unknown>).__hostCallerMarkdown).toBe("# Plan");
+	});
+
+	it("gives built-in plugins no host session", async () => {
+		registerPlugin({
+			manifest: {
+				id: "builtin.caller",
+				name: "Built-in Caller",
+				version: "1.0.0",
+				apiVersion: "1",
+				entry: "builtin:caller",
+				capabilities: ["documentsRead"],
+			},
+			setup: async (api) => {
+				await api.host.documents.read("projects/@work/doc-1.json");
+			},
+		});
+
+		await loadEnabledPlugins();
+
+		const runtime = listPlugins().find((item) => item.manifest.id === "builtin.caller");
+		expect(runtime?.lastError).toContain("no host session");
+		expect(callHostMock).not.toHaveBeenCalled();
+	});
+
+	it("reads every installed entrypoint before running any plugin code", async () => {
+		const order: string[] = [];
+		(globalThis as Record<string, unknown>).__pluginOrder = order;
+		listInstalledMock.mockResolvedValueOnce([
+			installedRecord("order.a", ["commands"]),
+			installedRecord("order.b", ["commands"]),
+		]);
+		readPluginEntrypointMock.mockImplementation(async (id: string) => {
+			order.push(`read:${id}`);
+			return {
+				session: `session:${id}`,
+				source: `globalThis.__pluginOrder.push("run:${id}");\nexport function setup() {}\n`,
+			};
+		});
+
+		await registerInstalledPlugins();
+
+		expect(order).toEqual(["read:order.a", "read:order.b", "run:order.a", "run:order.b"]);
+	});
+
 	it("rejects an installed plugin whose manifest fails validation", async () => {
 		listInstalledMock.mockResolvedValueOnce([
 			{
@@ -346,3 +467,24 @@ export function setup(api) {
 		expect(readPluginEntrypointMock).not.toHaveBeenCalled();
 	});
 });
+
+function installedRecord(id: string, capabilities: string[]) {
+	return {
+		manifest: {
+			ID: id,
+			Name: id,
+			Version: "1.0.0",
+			APIVersion: "1",
+			Entry: "main.js",
+			Capabilities: capabilities,
+			Description: "",
+			Author: "",
+			Homepage: "",
+		},
+		path: `/plugins/${id}`,
+		source: "package",
+		enabled: true,
+		status: "ok",
+		canExecute: true,
+	};
+}
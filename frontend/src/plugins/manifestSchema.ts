@@ -13,6 +13,11 @@ const PLUGIN_CAPABILITIES = [
 	"editorBlockActions",
 	"editorLifecycle",
 	"settings",
+	"documentsRead",
+	"documentsWrite",
+	"search",
+	"journal",
+	"storage",
 ] as const satisfies readonly PluginCapability[];
 
 const pluginManifestSchema = z.object({
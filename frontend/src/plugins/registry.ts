@@ -4,12 +4,17 @@ import {
 	unregisterPluginConfig,
 } from "@/config/public";
 import { usePreferencesStore } from "@/shared/stores/preferences.store";
-import type { InstallRecord } from "../../bindings/yanta/internal/plugins/models";
+import type {
+	InstallRecord,
+	PluginEntrypoint,
+} from "../../bindings/yanta/internal/plugins/models";
 import {
+	CallHost,
 	GetCommunityPluginsEnabled,
 	GetSupportedPluginAPIMajor,
 	ListInstalled,
 	ReadPluginEntrypoint,
+	ReleasePlugin,
 } from "../../bindings/yanta/internal/plugins/wailsservice";
 import { useCommandRegistryStore } from "../command-palette/registry";
 import {
@@ -35,6 +40,7 @@ import type {
 	PluginAPI,
 	PluginCapability,
 	PluginDefinition,
+	PluginHostAPI,
 	PluginRuntimeRecord,
 } from "./types";
 
@@ -44,6 +50,9 @@ const PLUGIN_STATE_ENABLED_KEY = "enabled";
 const definitions = new Map<string, PluginDefinition>();
 const cleanups = new Map<string, () => void>();
 const runtime = new Map<string, PluginRuntimeRecord>();
+// The host session of each installed plugin, issued with its entrypoint.
+// Host calls carry it; the backend works out the calling plugin from it.
+const sessions = new Map<string, string>();
 let supportedPluginAPIMajor: number | null = null;
 let supportedPluginAPIMajorPromise: Promise<number> | null = null;
 
@@ -78,6 +87,44 @@ async function writePersistedState(state: PersistedPluginState): Promise<void> {
 	});
 }
 
+function createPluginHostAPI(pluginId: string, session: string | undefined): PluginHostAPI {
+	const call = async <T>(method: string, params?: Record<string, unknown>): Promise<T> => {
+		if (!session) {
+			throw new Error(
+				`Plugin "${pluginId}" has no host session; only installed plugins can call the host.`,
+			);
+		}
+		return (await CallHost({ session, method, params })) as T;
+	};
+
+	return {
+		documents: {
+			read: (path) => call("documents.read", { path }),
+			create: (doc) => call("documents.create", doc),
+			update: (patch) => call("documents.update", patch),
+		},
+		search: async (query, limit) => (await call("search", { query, limit })) ?? [],
+		journal: {
+			append: (entry) => call("journal.append", entry),
+		},
+		commands: {
+			register: async (commands) => {
+				await call("commands.register", { commands });
+			},
+		},
+		storage: {
+			get: (key) => call("storage.get", { key }),
+			set: async (key, value) => {
+				await call("storage.set", { key, value });
+			},
+			delete: async (key) => {
+				await call("storage.delete", { key });
+			},
+			keys: async () => (await call("storage.keys")) ?? [],
+		},
+	};
+}
+
 function createPluginAPI(pluginId: string, capabilities: readonly PluginCapability[]): PluginAPI {
 	const source = toSource(pluginId);
 	const granted = new Set<PluginCapability>(capabilities ?? []);
@@ -135,6 +182,7 @@ function createPluginAPI(pluginId: string, capabilities: readonly PluginCapabili
 			setEditorLifecycleHooks(source, hooks);
 		},
 		registerConfig: registerConfigForPlugin,
+		host: createPluginHostAPI(pluginId, sessions.get(pluginId)),
 	};
 }
 
@@ -144,6 +192,10 @@ function cleanupPluginContributions(pluginId: string): void {
 	useSidebarRegistryStore.getState().removeSource(source);
 	removeAllEditorPluginContributions(source);
 	unregisterPluginConfig(pluginId);
+	const session = sessions.get(pluginId);
+	if (session) {
+		void ReleasePlugin(session).catch(() => undefined);
+	}
 }
 
 function getPluginIsolationMode(entry: string): PluginRuntimeRecord["isolationMode"] {
@@ -267,6 +319,14 @@ export async function registerInstalledPlugins(): Promise<void> {
 	}
 
 	const sorted = [...installed].sort((a, b) => a.manifest.ID.localeCompare(b.manifest.ID));
+	// Every entrypoint is read, opening the plugin's host session, before any
+	// plugin code runs: code already running could otherwise open the session
+	// of a plugin read after it.
+	const pending: Array<{
+		manifest: PluginDefinition["manifest"];
+		entry?: PluginEntrypoint;
+		error?: string;
+	}> = [];
 	for (const record of sorted) {
 		const manifest = toPluginDefinitionManifest(record as InstallRecord);
 		if (!manifest.id || definitions.has(manifest.id)) {
@@ -275,6 +335,10 @@ export async function registerInstalledPlugins(): Promise<void> {
 		if (manifest.entry.startsWith("builtin:")) {
 			continue;
 		}
+		// WASM plugins run in the backend; the frontend has nothing to load.
+		if (record.runtime === "wasm") {
+			continue;
+		}
 		if (record.status !== "ok") {
 			continue;
 		}
@@ -289,11 +353,24 @@ export async function registerInstalledPlugins(): Promise<void> {
 			});
 			continue;
 		}
-		const validManifest = parsed.manifest;
+		try {
+			const entry = await ReadPluginEntrypoint(parsed.manifest.id);
+			sessions.set(parsed.manifest.id, entry.session);
+			pending.push({ manifest: parsed.manifest, entry });
+		} catch (err) {
+			pending.push({
+				manifest: parsed.manifest,
+				error: err instanceof Error ? err.message : String(err),
+			});
+		}
+	}
 
+	for (const { manifest: validManifest, entry, error } of pending) {
 		try {
-			const entrySource = await ReadPluginEntrypoint(validManifest.id);
-			const moduleValue = await importPluginModule(entrySource, validManifest.id);
+			if (!entry) {
+				throw new Error(error);
+			}
+			const moduleValue = await importPluginModule(entry.source, validManifest.id);
 			const setup = resolvePluginSetupExport(moduleValue);
 			if (!setup) {
 				registerPlugin({
@@ -434,6 +511,19 @@ export async function loadEnabledPlugins(): Promise<void> {
 	}
 }
 
+// Re-reads an installed plugin from disk, as after a dev-linked plugin is
+// rebuilt, and loads it again if it is enabled. A plugin that is no longer
+// installed is dropped.
+export async function reloadPlugin(pluginId: string): Promise<void> {
+	unloadPlugin(pluginId);
+	definitions.delete(pluginId);
+	runtime.delete(pluginId);
+	// The backend closed the session when it reloaded the plugin.
+	sessions.delete(pluginId);
+	await registerInstalledPlugins();
+	await loadEnabledPlugins();
+}
+
 export function getActiveExternalPluginIds(): string[] {
 	return Array.from(runtime.values())
 		.filter((record) => isExternalPluginManifestEntry(record.manifest.entry) && record.isActive)
@@ -492,6 +582,7 @@ export function __resetPluginRegistryForTests(): void {
 	definitions.clear();
 	cleanups.clear();
 	runtime.clear();
+	sessions.clear();
 	supportedPluginAPIMajor = null;
 	supportedPluginAPIMajorPromise = null;
 }
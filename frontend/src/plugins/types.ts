@@ -23,7 +23,12 @@ export type PluginCapability =
 	| "editorTools"
 	| "editorBlockActions"
 	| "editorLifecycle"
-	| "settings";
+	| "settings"
+	| "documentsRead"
+	| "documentsWrite"
+	| "search"
+	| "journal"
+	| "storage";
 
 export type PluginIsolationMode = "builtin_trusted" | "external_local";
 
@@ -56,6 +61,72 @@ export interface PluginAPI {
 	registerEditorBlockActions: (actions: EditorBlockActionContribution[]) => void;
 	registerEditorLifecycleHooks: (hooks: EditorLifecycleHooks) => void;
 	registerConfig: <T>(def: PluginConfigSchema<T>) => void;
+	host: PluginHostAPI;
+}
+
+export interface HostDocument {
+	path: string;
+	title: string;
+	projectAlias: string;
+	tags?: string[];
+	markdown: string;
+	hash: string;
+}
+
+export interface HostSearchHit {
+	id: string;
+	type: string;
+	title: string;
+	snippet: string;
+	projectAlias: string;
+}
+
+export interface HostJournalEntry {
+	id: string;
+	content: string;
+	tags?: string[];
+	created: string;
+}
+
+/**
+ * Vault access served by the backend. Every call is checked there against the
+ * manifest's capabilities; an undeclared one rejects with
+ * PLUGIN_CAPABILITY_DENIED and is listed in the plugin's issues.
+ */
+export interface PluginHostAPI {
+	documents: {
+		read: (path: string) => Promise<HostDocument>;
+		create: (doc: {
+			projectAlias: string;
+			title: string;
+			markdown?: string;
+			tags?: string[];
+		}) => Promise<HostDocument>;
+		update: (patch: {
+			path: string;
+			expectedHash?: string;
+			title?: string;
+			markdown?: string;
+			tags?: string[];
+		}) => Promise<HostDocument>;
+	};
+	search: (query: string, limit?: number) => Promise<HostSearchHit[]>;
+	journal: {
+		append: (entry: {
+			projectAlias: string;
+			content: string;
+			tags?: string[];
+		}) => Promise<HostJournalEntry>;
+	};
+	commands: {
+		register: (commands: { id: string; title: string }[]) => Promise<void>;
+	};
+	storage: {
+		get: <T = unknown>(key: string) => Promise<T | null>;
+		set: (key: string, value: unknown) => Promise<void>;
+		delete: (key: string) => Promise<void>;
+		keys: () => Promise<string[]>;
+	};
 }
 
 export interface PluginRuntimeRecord {
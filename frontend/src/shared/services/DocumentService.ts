@@ -13,6 +13,7 @@ import { recordDocumentGetInFlightDelta, recordDocumentGetTiming } from "../moni
 import {
 	blocksToModel,
 	type Document,
+	type DocumentConflict,
 	type DocumentWithTags,
 	documentsFromModels,
 	documentWithTagsFromModel,
@@ -92,11 +93,31 @@ export async function getDocumentHash(path: string): Promise<string> {
 	return await GetDocumentHash(path);
 }
 
+/**
+ * Returns the conflict a failed save reports, or null for any other error.
+ * The backend's ConflictError reaches us as the Wails error's cause.
+ */
+export function documentConflictFromError(err: unknown): DocumentConflict | null {
+	const message = err instanceof Error ? err.message : String(err);
+	if (!message.includes("ERR_CONFLICT")) {
+		return null;
+	}
+	const cause = (err as { cause?: unknown } | null)?.cause as
+		| { path?: string; current_hash?: string; current?: DocumentConflict["current"] }
+		| undefined;
+	return {
+		path: cause?.path ?? "",
+		currentHash: cause?.current_hash ?? "",
+		current: cause?.current ?? undefined,
+	};
+}
+
 // Legacy wrapper for backward compatibility
 export const DocumentServiceWrapper = {
 	save: saveDocument,
 	get: getDocument,
 	getHash: getDocumentHash,
+	conflictFromError: documentConflictFromError,
 	listByProject: listDocumentsByProject,
 	listRecent: listRecentDocuments,
 	softDelete: softDeleteDocument,
@@ -32,6 +32,19 @@ export interface DocumentMeta {
 
 export type DocumentKind = "document" | "canvas";
 
+/**
+ * A save the backend refused because the document changed on disk since the
+ * hash it was based on. `current` is the version on disk, to rebase onto.
+ */
+export interface DocumentConflict {
+	path: string;
+	currentHash: string;
+	current?: {
+		meta: DocumentMeta;
+		blocks?: BlockNoteBlock[];
+	};
+}
+
 export interface Document {
 	path: string;
 	projectAlias: string;
@@ -24,6 +24,7 @@ import (
 	"yanta/internal/export"
 	"yanta/internal/git"
 	"yanta/internal/hotkeys"
+	"yanta/internal/importer"
 	"yanta/internal/indexer"
 	"yanta/internal/journal"
 	"yanta/internal/link"
@@ -38,6 +39,7 @@ import (
 	"yanta/internal/system"
 	"yanta/internal/tag"
 	"yanta/internal/vault"
+	"yanta/internal/vaultlock"
 )
 
 type App struct {
@@ -60,6 +62,12 @@ type App struct {
 
 	mcpVault   mcp.Vault
 	mcpManager *mcpctl.Manager
+
+	pluginHost       *plugins.Host
+	wasmPlugins      *plugins.WASMRuntime
+	pluginDevWatcher *plugins.DevWatcher
+
+	vaultLock *vaultlock.Lock
 }
 
 type Config struct {
@@ -85,7 +93,15 @@ func New(cfg Config) (*App, error) {
 		DBPath: cfg.DBPath,
 	}
 
-	var err error
+	// Become the vault's primary before touching the database, so that a
+	// `yanta mcp --standalone` server has stopped its watcher and git sync
+	// before migrations and our own sync start.
+	lock, err := vaultlock.Claim(context.Background(), vaultlock.OwnerApp)
+	if err != nil {
+		logger.Warnf("vault lock not acquired: %v", err)
+	}
+	a.vaultLock = lock
+
 	a.DB, err = db.OpenDB(a.DBPath)
 	if err != nil {
 		return nil, err
@@ -184,8 +200,17 @@ func New(cfg Config) (*App, error) {
 	backupService := backup.NewService()
 	exportService := export.NewService(export.ServiceConfig{
 		DocumentService: documentService,
+		DocumentLister:  documentService,
+		Journal:         journalService,
 		Vault:           v,
 	})
+	importService := importer.NewService(importer.ServiceConfig{
+		Projects:  projectService,
+		Documents: documentService,
+		Assets:    assetService,
+		Journal:   journalService,
+		EventBus:  eventBus,
+	})
 
 	logger.Debugf("services created")
 
@@ -226,8 +251,6 @@ func New(cfg Config) (*App, error) {
 	)
 	globalCommands := commandline.NewGlobalCommands(projectService, systemService)
 	documentCommands := commandline.NewDocumentCommands(documentService, tagService)
-	pluginService := plugins.NewService()
-	pluginWailsService := plugins.NewWailsService(pluginService)
 
 	logger.Debugf("command handlers created")
 
@@ -238,8 +261,32 @@ func New(cfg Config) (*App, error) {
 		projectCache: projectCache,
 		journal:      journalService,
 		tags:         tagService,
+		assets:       assetService,
+		vault:        v,
+	}
+	pluginService := plugins.NewService()
+	if err := pluginService.RecoverInterruptedSwaps(); err != nil {
+		logger.Warnf("failed to recover interrupted plugin upgrades: %v", err)
+	}
+	pluginHost := plugins.NewHost(pluginService, pluginVault{vault: a.mcpVault})
+	a.pluginHost = pluginHost
+	a.wasmPlugins = plugins.NewWASMRuntime(pluginService, pluginHost)
+	eventBus.Subscribe(a.wasmPlugins.Listen)
+	a.wasmPlugins.Start()
+	a.pluginDevWatcher = plugins.NewDevWatcher(pluginService, func(pluginID string) {
+		pluginHost.Release(pluginID)
+		pluginHost.EndSession(pluginID)
+		a.wasmPlugins.Unload(context.Background(), pluginID)
+		eventBus.Emit(events.PluginReloaded, events.PluginReloadedData{ID: pluginID})
+	})
+	if err := a.pluginDevWatcher.Start(); err != nil {
+		logger.Warnf("failed to start plugin dev watcher: %v", err)
 	}
+	pluginWailsService := plugins.NewWailsService(pluginService, pluginHost, a.wasmPlugins)
+
 	a.mcpManager = mcpctl.NewManager(a.mcpVault)
+	a.mcpManager.SetSyncNotifier(syncManager)
+	eventBus.Subscribe(mcpResourceListener(a.mcpManager.ResourceUpdated, projectCache))
 	mcpService := mcpctl.NewService(a.mcpManager)
 
 	a.Bindings = &Bindings{
@@ -254,6 +301,7 @@ func New(cfg Config) (*App, error) {
 		Config:                   config.NewWailsService(),
 		Backup:                   backupService,
 		Export:                   exportService,
+		Import:                   importService,
 		ProjectCommands:          projectCommands,
 		GlobalCommands:           globalCommands,
 		DocumentCommands:         documentCommands,
@@ -398,6 +446,14 @@ func backgroundRecoveryMessage(goos string) string {
 	return "YANTA is running in the background. Click the system tray icon to restore."
 }
 
+// FrontendLoaded runs when the main window's frontend has (re)loaded. The
+// plugin code it ran is gone, so the host sessions issued to it are closed.
+func (a *App) FrontendLoaded() {
+	if a.pluginHost != nil {
+		a.pluginHost.ResetSessions()
+	}
+}
+
 func (a *App) OnShutdown() {
 	a.Shutdown()
 }
@@ -431,6 +487,15 @@ func (a *App) Shutdown() {
 			}
 		}
 
+		if a.pluginDevWatcher != nil {
+			a.pluginDevWatcher.Close()
+		}
+
+		if a.wasmPlugins != nil {
+			a.wasmPlugins.Close(context.Background())
+			logger.Debug("wasm plugins stopped")
+		}
+
 		if a.DB != nil {
 			logger.Debug("closing database connection...")
 			if err := db.CloseDB(a.DB); err != nil {
@@ -441,6 +506,8 @@ func (a *App) Shutdown() {
 			a.DB = nil
 		}
 
+		a.vaultLock.Release()
+
 		if a.hotkeyManager != nil {
 			logger.Debug("stopping hotkey manager...")
 			if err := a.hotkeyManager.Stop(); err != nil {
@@ -8,6 +8,7 @@ import (
 	"yanta/internal/document"
 	"yanta/internal/events"
 	"yanta/internal/export"
+	"yanta/internal/importer"
 	"yanta/internal/journal"
 	"yanta/internal/mcpctl"
 	"yanta/internal/plugins"
@@ -29,6 +30,7 @@ type Bindings struct {
 	Config           *config.WailsService
 	Backup           *backup.Service
 	Export           *export.Service
+	Import           *importer.Service
 	ProjectCommands  *commandline.ProjectCommands
 	GlobalCommands   *commandline.GlobalCommands
 	DocumentCommands *commandline.DocumentCommands
@@ -61,6 +63,7 @@ func (b *Bindings) Bind() []any {
 		b.Config,
 		b.Backup,
 		b.Export,
+		b.Import,
 		b.ProjectCommands,
 		b.GlobalCommands,
 		b.DocumentCommands,
@@ -1,6 +1,14 @@
 package app
 
-import "context"
+import (
+	"context"
+	"path"
+	"strings"
+
+	"yanta/internal/events"
+	"yanta/internal/mcp"
+	"yanta/internal/project"
+)
 
 // StartMCPIfEnabled starts the MCP server if it is enabled in config. Called at
 // boot. Safe to call when the manager was not constructed.
@@ -18,3 +26,92 @@ func (a *App) StopMCP(ctx context.Context) error {
 	}
 	return a.mcpManager.Stop(ctx)
 }
+
+// mcpResourceListener forwards vault changes on the event bus, from the app's
+// own services and from the file watcher alike, to MCP clients subscribed to
+// the affected resources.
+// notify is mcpctl.Manager.ResourceUpdated in the app and the server's own
+// notifier in standalone mode.
+func mcpResourceListener(notify func(ctx context.Context, uri string), projects *project.Cache) events.Listener {
+	return func(name string, data any) {
+		switch name {
+		case events.EntryCreated, events.EntryUpdated, events.EntryDeleted,
+			events.EntryRestored, events.EntryMoved, events.EntryExternalChange,
+			events.ProjectChanged:
+		default:
+			return
+		}
+		// Resolving project IDs may hit the database; keep it off the
+		// emitting goroutine.
+		go func() {
+			ctx := context.Background()
+			aliasOf := func(id string) string {
+				if strings.HasPrefix(id, "@") {
+					return id
+				}
+				p, err := projects.GetByID(ctx, id)
+				if err != nil || p == nil {
+					return ""
+				}
+				return p.Alias
+			}
+			for _, uri := range mcpResourceURIs(data, aliasOf) {
+				notify(ctx, uri)
+			}
+		}()
+	}
+}
+
+// mcpResourceURIs returns the MCP resources an event payload touches. aliasOf
+// maps a project ID to its alias, or "" when it is unknown; journal events
+// already carry the alias in ProjectID.
+func mcpResourceURIs(data any, aliasOf func(id string) string) []string {
+	var uris []string
+	entry := func(kind, docPath, projectID, date string) {
+		if kind == "journal" {
+			if projectID != "" && date != "" {
+				uris = append(uris, mcp.JournalURI(projectID, date))
+			}
+			return
+		}
+		if docPath != "" {
+			uris = append(uris, mcp.DocumentURI(docPath))
+		}
+		if alias := aliasOf(projectID); alias != "" {
+			uris = append(uris, mcp.ProjectURI(alias))
+		}
+	}
+
+	switch d := data.(type) {
+	case events.EntryCreatedData:
+		entry(d.Type, d.Path, d.ProjectID, d.Date)
+	case events.EntryUpdatedData:
+		entry(d.Type, d.Path, d.ProjectID, d.Date)
+	case events.EntryDeletedData:
+		entry(d.Type, d.Path, d.ProjectID, d.Date)
+	case events.EntryRestoredData:
+		entry(d.Type, d.Path, d.ProjectID, d.Date)
+	case events.EntryMovedData:
+		uris = append(uris, mcp.DocumentURI(d.Path))
+		for _, id := range []string{d.FromProjectID, d.ToProjectID} {
+			if alias := aliasOf(id); alias != "" {
+				uris = append(uris, mcp.ProjectURI(alias))
+			}
+		}
+	case events.EntryExternalChangeData:
+		// projects/@alias/doc-....json or projects/@alias/journal/<date>.json
+		parts := strings.Split(d.Path, "/")
+		if len(parts) < 3 || parts[0] != "projects" {
+			return nil
+		}
+		if len(parts) == 4 && parts[2] == "journal" {
+			return []string{mcp.JournalURI(parts[1], strings.TrimSuffix(path.Base(d.Path), ".json"))}
+		}
+		uris = append(uris, mcp.DocumentURI(d.Path), mcp.ProjectURI(parts[1]))
+	case events.ProjectChangedData:
+		if alias := aliasOf(d.ID); alias != "" {
+			uris = append(uris, mcp.ProjectURI(alias))
+		}
+	}
+	return uris
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"yanta/internal/events"
+)
+
+func TestMCPResourceURIs(t *testing.T) {
+	aliasOf := func(id string) string {
+		return map[string]string{"p1": "@work", "p2": "@home", "@work": "@work"}[id]
+	}
+
+	tests := []struct {
+		name string
+		data any
+		want []string
+	}{
+		{
+			name: "document update",
+			data: events.EntryUpdatedData{Path: "projects/@work/doc-1.json", ProjectID: "p1"},
+			want: []string{"yanta://doc/projects/@work/doc-1.json", "yanta://project/work"},
+		},
+		{
+			name: "journal entry",
+			data: events.EntryCreatedData{Type: "journal", ProjectID: "@work", Date: "2026-07-03"},
+			want: []string{"yanta://journal/work/2026-07-03"},
+		},
+		{
+			name: "move",
+			data: events.EntryMovedData{Path: "projects/@work/doc-1.json", FromProjectID: "p1", ToProjectID: "p2"},
+			want: []string{"yanta://doc/projects/@work/doc-1.json", "yanta://project/work", "yanta://project/home"},
+		},
+		{
+			name: "external document change",
+			data: events.EntryExternalChangeData{Path: "projects/@work/doc-1.json"},
+			want: []string{"yanta://doc/projects/@work/doc-1.json", "yanta://project/work"},
+		},
+		{
+			name: "external journal change",
+			data: events.EntryExternalChangeData{Path: "projects/@work/journal/2026-07-03.json"},
+			want: []string{"yanta://journal/work/2026-07-03"},
+		},
+		{
+			name: "project change",
+			data: events.ProjectChangedData{ID: "p2", Op: "update"},
+			want: []string{"yanta://project/home"},
+		},
+		{
+			name: "unknown project",
+			data: events.ProjectChangedData{ID: "gone", Op: "delete"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mcpResourceURIs(tt.data, aliasOf); !slices.Equal(got, tt.want) {
+				t.Errorf("mcpResourceURIs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,16 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"yanta/internal/asset"
 	"yanta/internal/blocknote"
+	"yanta/internal/blocktype"
 	"yanta/internal/document"
 	"yanta/internal/journal"
 	"yanta/internal/mcp"
@@ -27,6 +33,8 @@ type mcpVault struct {
 	projectCache *project.Cache
 	journal      *journal.Service
 	tags         *tag.Service
+	assets       *asset.Service
+	vault        asset.VaultProvider
 }
 
 var _ mcp.Vault = (*mcpVault)(nil)
@@ -59,7 +67,7 @@ func (m *mcpVault) ListProjects(ctx context.Context, includeArchived bool) ([]mc
 	}
 	out := make([]mcp.ProjectInfo, 0, len(active))
 	for _, p := range active {
-		out = append(out, mcp.ProjectInfo{ID: p.ID, Name: p.Name, Alias: p.Alias, Archived: false})
+		out = append(out, toMCPProject(p, false))
 	}
 	if includeArchived {
 		archived, err := m.projects.ListArchived(ctx)
@@ -67,7 +75,7 @@ func (m *mcpVault) ListProjects(ctx context.Context, includeArchived bool) ([]mc
 			return nil, err
 		}
 		for _, p := range archived {
-			out = append(out, mcp.ProjectInfo{ID: p.ID, Name: p.Name, Alias: p.Alias, Archived: true})
+			out = append(out, toMCPProject(p, true))
 		}
 	}
 	return out, nil
@@ -92,27 +100,49 @@ func (m *mcpVault) ListDocuments(ctx context.Context, alias string, includeArchi
 }
 
 func (m *mcpVault) GetDocument(ctx context.Context, path string) (mcp.DocumentContent, error) {
+	path, slug := document.SplitAnchor(path)
 	doc, err := m.documents.Get(ctx, path)
 	if err != nil {
 		return mcp.DocumentContent{}, err
 	}
-	var md string
+	outline, err := m.documents.Outline(ctx, path)
+	if err != nil {
+		return mcp.DocumentContent{}, err
+	}
+	var md, hash string
+	infos := []mcp.BlockInfo{}
 	if doc.File != nil {
-		md, err = docBlocksToMarkdown(doc.File.Blocks)
+		blocks := doc.File.Blocks
+		if slug != "" {
+			heading, ok := document.FindHeading(outline, slug)
+			if !ok {
+				return mcp.DocumentContent{}, fmt.Errorf("no heading %q in %s", slug, path)
+			}
+			if blocks, ok = document.Section(blocks, heading.BlockID); !ok {
+				return mcp.DocumentContent{}, fmt.Errorf("heading %q not found in %s; the index may be stale", slug, path)
+			}
+		}
+		md, err = docBlocksToMarkdown(blocks)
 		if err != nil {
 			return mcp.DocumentContent{}, err
 		}
+		hash = document.ComputeFileHash(doc.File)
+		infos = blockInfos(infos, blocks, 0)
 	}
 	return mcp.DocumentContent{
 		Path:         doc.Path,
 		Title:        doc.Title,
 		ProjectAlias: doc.ProjectAlias,
 		Tags:         doc.Tags,
+		Section:      slug,
 		Markdown:     md,
+		Outline:      mcpOutline(outline),
+		Hash:         hash,
+		Blocks:       infos,
 	}, nil
 }
 
-func (m *mcpVault) ReadJournal(ctx context.Context, alias, date string) ([]mcp.JournalEntryInfo, error) {
+func (m *mcpVault) ReadJournal(ctx context.Context, alias, date string, includeDeleted bool) ([]mcp.JournalEntryInfo, error) {
 	var jf *journal.JournalFile
 	var err error
 	if date == "" {
@@ -125,7 +155,7 @@ func (m *mcpVault) ReadJournal(ctx context.Context, alias, date string) ([]mcp.J
 	}
 	out := make([]mcp.JournalEntryInfo, 0, len(jf.Entries))
 	for i := range jf.Entries {
-		if jf.Entries[i].Deleted {
+		if jf.Entries[i].Deleted && !includeDeleted {
 			continue
 		}
 		out = append(out, journalEntryInfo(&jf.Entries[i]))
@@ -140,6 +170,23 @@ func (m *mcpVault) ListJournalDates(ctx context.Context, alias string) ([]string
 	return m.journal.ListDates(ctx, alias, 0, 0)
 }
 
+func (m *mcpVault) SearchJournal(ctx context.Context, alias, query string, limit int) ([]mcp.JournalHit, error) {
+	results, err := m.journal.SearchEntries(ctx, alias, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]mcp.JournalHit, 0, len(results))
+	for i := range results {
+		hits = append(hits, mcp.JournalHit{
+			ProjectAlias: results[i].ProjectAlias,
+			Date:         results[i].Date,
+			Entry:        journalEntryInfo(&results[i].Entry),
+			Snippet:      results[i].Snippet,
+		})
+	}
+	return hits, nil
+}
+
 func (m *mcpVault) ListTags(ctx context.Context) ([]string, error) {
 	tags, err := m.tags.ListActive(ctx)
 	if err != nil {
@@ -152,6 +199,46 @@ func (m *mcpVault) ListTags(ctx context.Context) ([]string, error) {
 	return names, nil
 }
 
+func (m *mcpVault) ReadAllJournals(ctx context.Context, date string) ([]mcp.ProjectJournalEntry, error) {
+	entries, err := m.journal.GetAllActiveEntries(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]mcp.ProjectJournalEntry, 0, len(entries))
+	for i := range entries {
+		out = append(out, mcp.ProjectJournalEntry{
+			JournalEntryInfo: journalEntryInfo(&entries[i].JournalEntry),
+			ProjectAlias:     entries[i].ProjectAlias,
+		})
+	}
+	return out, nil
+}
+
+func (m *mcpVault) ListTaggedDocuments(ctx context.Context, tagName string, limit int) ([]mcp.DocumentInfo, error) {
+	paths, err := m.tags.GetDocumentPaths(ctx, tagName)
+	if err != nil {
+		return nil, err
+	}
+	out := []mcp.DocumentInfo{}
+	for _, path := range paths {
+		if len(out) == limit {
+			break
+		}
+		d, err := m.documents.Get(ctx, path)
+		if err != nil || d.IsDeleted() {
+			continue
+		}
+		out = append(out, mcp.DocumentInfo{
+			Path:         d.Path,
+			Title:        d.Title,
+			ProjectAlias: d.ProjectAlias,
+			Tags:         d.Tags,
+			Updated:      d.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
 // --- write ---
 
 func (m *mcpVault) CreateDocument(ctx context.Context, alias, title, markdown string, tags []string) (string, error) {
@@ -172,19 +259,25 @@ func (m *mcpVault) CreateDocument(ctx context.Context, alias, title, markdown st
 	})
 }
 
-func (m *mcpVault) UpdateDocument(ctx context.Context, path string, title, markdown *string, tags *[]string) error {
+func (m *mcpVault) UpdateDocument(ctx context.Context, path, expectedHash string, title, markdown *string, tags *[]string) (mcp.EditResult, error) {
 	doc, err := m.documents.Get(ctx, path)
 	if err != nil {
-		return err
+		return mcp.EditResult{}, err
 	}
 	req := document.SaveRequest{
 		Path:         path,
 		ProjectAlias: doc.ProjectAlias,
 		Title:        doc.Title,
 		Tags:         doc.Tags,
+		ExpectedHash: expectedHash,
 	}
 	if doc.File != nil {
 		req.Blocks = doc.File.Blocks
+		// Without a hash from the agent, still guard the read-modify-write
+		// below: a title or tag change must not undo a save made meanwhile.
+		if req.ExpectedHash == "" {
+			req.ExpectedHash = document.ComputeFileHash(doc.File)
+		}
 	}
 	if title != nil {
 		req.Title = *title
@@ -195,15 +288,15 @@ func (m *mcpVault) UpdateDocument(ctx context.Context, path string, title, markd
 	if markdown != nil {
 		blocks, err := markdownToDocBlocks(*markdown)
 		if err != nil {
-			return err
+			return mcp.EditResult{}, err
 		}
 		req.Blocks = blocks
 	}
 	if req.Blocks == nil {
 		req.Blocks = []document.BlockNoteBlock{}
 	}
-	_, err = m.documents.Save(ctx, req)
-	return err
+	_, hash, err := m.documents.SaveWithHash(ctx, req)
+	return editResult(path, hash, err)
 }
 
 func (m *mcpVault) MoveDocument(ctx context.Context, path, targetProject string) error {
@@ -217,6 +310,255 @@ func (m *mcpVault) DeleteDocument(ctx context.Context, path string, hard bool) e
 	return m.documents.SoftDelete(ctx, path)
 }
 
+// --- assets ---
+
+func (m *mcpVault) ReadAsset(_ context.Context, alias, file string, maxBytes int64) (mcp.AssetData, error) {
+	ext := filepath.Ext(file)
+	hash := strings.TrimSuffix(file, ext)
+	if err := asset.ValidateHash(hash); err != nil {
+		return mcp.AssetData{}, fmt.Errorf("invalid asset %q: %w", file, err)
+	}
+	if ext != "" {
+		if err := asset.ValidateExtension(ext); err != nil {
+			return mcp.AssetData{}, fmt.Errorf("invalid asset %q: %w", file, err)
+		}
+	}
+	// Check the size before asset.ReadAsset pulls the whole file into memory.
+	fi, err := os.Stat(filepath.Join(m.vault.AssetsPath(alias), hash+asset.NormalizeExtension(ext)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return mcp.AssetData{}, fmt.Errorf("asset not found: %s", file)
+		}
+		return mcp.AssetData{}, err
+	}
+	if fi.Size() > maxBytes {
+		return mcp.AssetData{}, fmt.Errorf("asset %s is %d bytes, over the %d byte limit", file, fi.Size(), maxBytes)
+	}
+	data, err := asset.ReadAsset(m.vault, alias, hash, ext)
+	if err != nil {
+		return mcp.AssetData{}, err
+	}
+	return mcp.AssetData{MIME: asset.DetectMIME(ext), Data: data}, nil
+}
+
+func (m *mcpVault) UploadAsset(ctx context.Context, alias, filename string, data []byte) (mcp.UploadedAsset, error) {
+	// Like CreateDocument: the asset service writes into any alias it is given.
+	if _, err := m.projectCache.GetByAlias(ctx, alias); err != nil {
+		return mcp.UploadedAsset{}, fmt.Errorf("project %q not found: %w", alias, err)
+	}
+	info, err := m.assets.Upload(ctx, alias, data, filename)
+	if err != nil {
+		return mcp.UploadedAsset{}, err
+	}
+	ref, err := m.assets.BuildURL(ctx, alias, info.Hash, info.Ext)
+	if err != nil {
+		return mcp.UploadedAsset{}, err
+	}
+	return mcp.UploadedAsset{Ref: ref, MIME: info.MIME, Bytes: info.Bytes}, nil
+}
+
+// --- administration ---
+
+func (m *mcpVault) CreateProject(ctx context.Context, name, alias, startDate, endDate string) (mcp.ProjectInfo, error) {
+	id, err := m.projects.Create(ctx, name, alias, startDate, endDate)
+	if err != nil {
+		return mcp.ProjectInfo{}, err
+	}
+	p, err := m.projects.Get(ctx, id)
+	if err != nil {
+		return mcp.ProjectInfo{}, err
+	}
+	return toMCPProject(p, false), nil
+}
+
+func (m *mcpVault) UpdateProject(ctx context.Context, alias string, patch mcp.ProjectPatch) (mcp.ProjectInfo, error) {
+	current, err := m.projectCache.GetByAlias(ctx, project.NormalizeAlias(alias))
+	if err != nil {
+		return mcp.ProjectInfo{}, fmt.Errorf("project %q not found: %w", alias, err)
+	}
+	// The cache hands out shared pointers; edit a copy.
+	p := *current
+	if patch.Name != nil || patch.StartDate != nil || patch.EndDate != nil {
+		if patch.Name != nil {
+			p.Name = *patch.Name
+		}
+		if patch.StartDate != nil {
+			p.StartDate = *patch.StartDate
+		}
+		if patch.EndDate != nil {
+			p.EndDate = *patch.EndDate
+		}
+		if err := m.projects.Update(ctx, &p); err != nil {
+			return mcp.ProjectInfo{}, err
+		}
+	}
+	if patch.Alias != nil && project.NormalizeAlias(*patch.Alias) != p.Alias {
+		renamed, err := m.projects.RenameAlias(ctx, p.ID, *patch.Alias)
+		if err != nil {
+			return mcp.ProjectInfo{}, err
+		}
+		p = *renamed
+	}
+	return toMCPProject(&p, false), nil
+}
+
+func (m *mcpVault) ArchiveProject(ctx context.Context, alias string) error {
+	p, err := m.projectCache.GetByAlias(ctx, project.NormalizeAlias(alias))
+	if err != nil {
+		return fmt.Errorf("project %q not found: %w", alias, err)
+	}
+	return m.projects.SoftDelete(ctx, p.ID)
+}
+
+func (m *mcpVault) RestoreProject(ctx context.Context, alias string) error {
+	archived, err := m.projects.ListArchived(ctx)
+	if err != nil {
+		return err
+	}
+	want := project.NormalizeAlias(alias)
+	for _, p := range archived {
+		if p.Alias == want {
+			return m.projects.Restore(ctx, p.ID)
+		}
+	}
+	return fmt.Errorf("no archived project %q", alias)
+}
+
+func (m *mcpVault) CreateTag(ctx context.Context, name string) (string, error) {
+	return m.tags.Create(ctx, name)
+}
+
+func (m *mcpVault) DeleteTag(ctx context.Context, name string) error {
+	return m.tags.SoftDelete(ctx, name)
+}
+
+func (m *mcpVault) RenameTag(ctx context.Context, from, to string) (int, error) {
+	return m.tags.Rename(ctx, from, to)
+}
+
+// --- block edits ---
+
+func (m *mcpVault) InsertBlocks(ctx context.Context, path, expectedHash string, after mcp.BlockTarget, markdown string) (mcp.EditResult, error) {
+	blocks, err := markdownToDocBlocks(markdown)
+	if err != nil {
+		return mcp.EditResult{}, err
+	}
+	hash, err := m.documents.InsertBlocks(ctx, document.EditRequest{
+		Path:         path,
+		ExpectedHash: expectedHash,
+		BlockID:      after.BlockID,
+		Heading:      after.Heading,
+		Blocks:       blocks,
+	})
+	return editResult(path, hash, err)
+}
+
+func (m *mcpVault) AppendBlocks(ctx context.Context, path, expectedHash, markdown string) (mcp.EditResult, error) {
+	blocks, err := markdownToDocBlocks(markdown)
+	if err != nil {
+		return mcp.EditResult{}, err
+	}
+	hash, err := m.documents.AppendBlocks(ctx, document.EditRequest{
+		Path:         path,
+		ExpectedHash: expectedHash,
+		Blocks:       blocks,
+	})
+	return editResult(path, hash, err)
+}
+
+func (m *mcpVault) ReplaceSection(ctx context.Context, path, expectedHash, heading, markdown string) (mcp.EditResult, error) {
+	blocks, err := markdownToDocBlocks(markdown)
+	if err != nil {
+		return mcp.EditResult{}, err
+	}
+	hash, err := m.documents.ReplaceSection(ctx, document.EditRequest{
+		Path:         path,
+		ExpectedHash: expectedHash,
+		Heading:      heading,
+		Blocks:       blocks,
+	})
+	return editResult(path, hash, err)
+}
+
+func (m *mcpVault) DeleteBlock(ctx context.Context, path, expectedHash string, target mcp.BlockTarget) (mcp.EditResult, error) {
+	hash, err := m.documents.DeleteBlock(ctx, document.EditRequest{
+		Path:         path,
+		ExpectedHash: expectedHash,
+		BlockID:      target.BlockID,
+		Heading:      target.Heading,
+	})
+	return editResult(path, hash, err)
+}
+
+func (m *mcpVault) ToggleCheckListItem(ctx context.Context, path, expectedHash, blockID string) (mcp.EditResult, error) {
+	hash, err := m.documents.ToggleCheckListItem(ctx, document.EditRequest{
+		Path:         path,
+		ExpectedHash: expectedHash,
+		BlockID:      blockID,
+	})
+	return editResult(path, hash, err)
+}
+
+// --- undo ---
+
+func (m *mcpVault) SnapshotDocument(ctx context.Context, path string) (mcp.DocumentSnapshot, error) {
+	doc, err := m.documents.Get(ctx, path)
+	if err != nil {
+		return mcp.DocumentSnapshot{}, err
+	}
+	if doc.File == nil {
+		return mcp.DocumentSnapshot{}, fmt.Errorf("%s has no stored content", path)
+	}
+	data, err := json.Marshal(doc.File)
+	if err != nil {
+		return mcp.DocumentSnapshot{}, err
+	}
+	return mcp.DocumentSnapshot{Hash: document.ComputeFileHash(doc.File), Data: data}, nil
+}
+
+// RestoreDocument saves the snapshot's blocks and metadata as they were, so
+// block IDs, props and attributes Markdown cannot carry come back intact.
+func (m *mcpVault) RestoreDocument(ctx context.Context, path, expectedHash string, snapshot mcp.DocumentSnapshot) (mcp.EditResult, error) {
+	var file document.DocumentFile
+	if err := json.Unmarshal(snapshot.Data, &file); err != nil {
+		return mcp.EditResult{}, fmt.Errorf("decoding snapshot of %s: %w", path, err)
+	}
+	blocks := file.Blocks
+	if blocks == nil {
+		blocks = []document.BlockNoteBlock{}
+	}
+	_, hash, err := m.documents.SaveWithHash(ctx, document.SaveRequest{
+		Path:         path,
+		ProjectAlias: file.Meta.Project,
+		Title:        file.Meta.Title,
+		Kind:         file.Kind,
+		Blocks:       blocks,
+		Scene:        file.Scene,
+		Assets:       file.Assets,
+		Tags:         file.Meta.Tags,
+		Aliases:      append([]string{}, file.Meta.Aliases...),
+		ExpectedHash: expectedHash,
+	})
+	return editResult(path, hash, err)
+}
+
+// editResult reports the outcome of a document.Service edit, handing a
+// conflict back with the current content for the agent to rebase onto.
+func editResult(path, hash string, err error) (mcp.EditResult, error) {
+	var conflict *document.ConflictError
+	if errors.As(err, &conflict) {
+		var md string
+		if conflict.Current != nil {
+			md, _ = docBlocksToMarkdown(conflict.Current.Blocks)
+		}
+		return mcp.EditResult{}, &mcp.ConflictError{Path: path, CurrentHash: conflict.CurrentHash, Markdown: md}
+	}
+	if err != nil {
+		return mcp.EditResult{}, err
+	}
+	return mcp.EditResult{Path: path, Hash: hash}, nil
+}
+
 func (m *mcpVault) AppendJournal(ctx context.Context, alias, content string, tags []string, date string) (mcp.JournalEntryInfo, error) {
 	var entry *journal.JournalEntry
 	var err error
@@ -235,6 +577,59 @@ func (m *mcpVault) AppendJournal(ctx context.Context, alias, content string, tag
 	return journalEntryInfo(entry), nil
 }
 
+func (m *mcpVault) UpdateJournalEntry(ctx context.Context, alias, date, entryID, content string, tags *[]string) (mcp.JournalEntryInfo, error) {
+	req := journal.UpdateEntryRequest{ProjectAlias: alias, Date: date, EntryID: entryID, Content: content}
+	if tags != nil {
+		// A nil slice would keep the current tags; clearing needs an empty one.
+		req.Tags = append([]string{}, *tags...)
+	}
+	entry, err := m.journal.UpdateEntry(ctx, req)
+	if err != nil {
+		return mcp.JournalEntryInfo{}, err
+	}
+	return journalEntryInfo(entry), nil
+}
+
+func (m *mcpVault) DeleteJournalEntry(ctx context.Context, alias, date, entryID string) error {
+	return m.journal.DeleteEntry(ctx, alias, date, entryID)
+}
+
+func (m *mcpVault) RestoreJournalEntry(ctx context.Context, alias, date, entryID string) error {
+	return m.journal.RestoreEntry(ctx, alias, date, entryID)
+}
+
+func (m *mcpVault) PromoteJournalEntries(ctx context.Context, p mcp.JournalPromotion) (mcp.JournalPromotionResult, error) {
+	if _, err := m.projectCache.GetByAlias(ctx, p.TargetProject); err != nil {
+		return mcp.JournalPromotionResult{}, fmt.Errorf("project %q not found: %w", p.TargetProject, err)
+	}
+	ids := p.EntryIDs
+	if len(ids) == 0 {
+		// Resolve "the whole day" here so the result can name the entries.
+		entries, err := m.journal.GetActiveEntries(ctx, p.SourceProject, p.Date)
+		if err != nil {
+			return mcp.JournalPromotionResult{}, err
+		}
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		if len(ids) == 0 {
+			return mcp.JournalPromotionResult{}, fmt.Errorf("no journal entries in %s on %s", p.SourceProject, p.Date)
+		}
+	}
+	path, err := m.journal.PromoteToDocument(ctx, journal.PromoteRequest{
+		SourceProject: p.SourceProject,
+		Date:          p.Date,
+		EntryIDs:      ids,
+		TargetProject: p.TargetProject,
+		Title:         p.Title,
+		KeepOriginal:  p.KeepOriginal,
+	})
+	if err != nil {
+		return mcp.JournalPromotionResult{}, err
+	}
+	return mcp.JournalPromotionResult{Path: path, EntryIDs: ids}, nil
+}
+
 func (m *mcpVault) AddTagsToDocument(ctx context.Context, path string, tags []string) error {
 	return m.tags.AddTagsToDocument(ctx, path, tags)
 }
@@ -245,13 +640,46 @@ func (m *mcpVault) RemoveTagsFromDocument(ctx context.Context, path string, tags
 
 // --- helpers ---
 
+func toMCPProject(p *project.Project, archived bool) mcp.ProjectInfo {
+	return mcp.ProjectInfo{
+		ID:        p.ID,
+		Name:      p.Name,
+		Alias:     p.Alias,
+		Archived:  archived,
+		StartDate: p.StartDate,
+		EndDate:   p.EndDate,
+	}
+}
+
 func journalEntryInfo(e *journal.JournalEntry) mcp.JournalEntryInfo {
 	return mcp.JournalEntryInfo{
 		ID:      e.ID,
 		Content: e.Content,
 		Tags:    e.Tags,
 		Created: e.Created.Format(time.RFC3339),
+		Deleted: e.Deleted,
+	}
+}
+
+// blockInfos lists blocks depth-first, the order they appear in Markdown.
+func blockInfos(out []mcp.BlockInfo, blocks []document.BlockNoteBlock, depth int) []mcp.BlockInfo {
+	for _, b := range blocks {
+		info := mcp.BlockInfo{ID: b.ID, Type: b.Type, Depth: depth, Text: document.BlockText(b)}
+		if b.Type == blocktype.CheckListItem {
+			checked := document.PropBool(b.Props, "checked", false)
+			info.Checked = &checked
+		}
+		out = blockInfos(append(out, info), b.Children, depth+1)
+	}
+	return out
+}
+
+func mcpOutline(outline []document.OutlineEntry) []mcp.OutlineEntry {
+	out := make([]mcp.OutlineEntry, 0, len(outline))
+	for _, e := range outline {
+		out = append(out, mcp.OutlineEntry{Level: e.Level, Text: e.Text, BlockID: e.BlockID, Slug: e.Slug})
 	}
+	return out
 }
 
 // markdownToDocBlocks converts Markdown to document.BlockNoteBlock via the
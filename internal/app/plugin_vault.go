@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+
+	"yanta/internal/mcp"
+	"yanta/internal/plugins"
+)
+
+// pluginVault adapts the MCP vault adapter to plugins.HostVault, so plugin
+// host calls share its Markdown conversion and save paths instead of
+// duplicating them.
+type pluginVault struct {
+	vault mcp.Vault
+}
+
+var _ plugins.HostVault = pluginVault{}
+
+func (p pluginVault) GetDocument(ctx context.Context, path string) (plugins.HostDocument, error) {
+	doc, err := p.vault.GetDocument(ctx, path)
+	if err != nil {
+		return plugins.HostDocument{}, err
+	}
+	return plugins.HostDocument{
+		Path:         doc.Path,
+		Title:        doc.Title,
+		ProjectAlias: doc.ProjectAlias,
+		Tags:         doc.Tags,
+		Markdown:     doc.Markdown,
+		Hash:         doc.Hash,
+	}, nil
+}
+
+func (p pluginVault) CreateDocument(ctx context.Context, alias, title, markdown string, tags []string) (plugins.HostDocument, error) {
+	path, err := p.vault.CreateDocument(ctx, alias, title, markdown, tags)
+	if err != nil {
+		return plugins.HostDocument{}, err
+	}
+	return p.GetDocument(ctx, path)
+}
+
+func (p pluginVault) UpdateDocument(ctx context.Context, path, expectedHash string, title, markdown *string, tags *[]string) (plugins.HostDocument, error) {
+	res, err := p.vault.UpdateDocument(ctx, path, expectedHash, title, markdown, tags)
+	if err != nil {
+		return plugins.HostDocument{}, err
+	}
+	return p.GetDocument(ctx, res.Path)
+}
+
+func (p pluginVault) Search(ctx context.Context, query string, limit int) ([]plugins.HostSearchHit, error) {
+	hits, err := p.vault.SearchNotes(ctx, query, limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]plugins.HostSearchHit, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, plugins.HostSearchHit{
+			ID:           h.ID,
+			Type:         h.Type,
+			Title:        h.Title,
+			Snippet:      h.Snippet,
+			ProjectAlias: h.ProjectAlias,
+		})
+	}
+	return out, nil
+}
+
+func (p pluginVault) AppendJournal(ctx context.Context, alias, content string, tags []string) (plugins.HostJournalEntry, error) {
+	entry, err := p.vault.AppendJournal(ctx, alias, content, tags, "")
+	if err != nil {
+		return plugins.HostJournalEntry{}, err
+	}
+	return plugins.HostJournalEntry{
+		ID:      entry.ID,
+		Content: entry.Content,
+		Tags:    entry.Tags,
+		Created: entry.Created,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"yanta/internal/asset"
+	"yanta/internal/db"
+	"yanta/internal/document"
+	"yanta/internal/events"
+	"yanta/internal/git"
+	"yanta/internal/indexer"
+	"yanta/internal/journal"
+	"yanta/internal/link"
+	"yanta/internal/logger"
+	"yanta/internal/mcp"
+	"yanta/internal/mcpctl"
+	"yanta/internal/project"
+	"yanta/internal/search"
+	"yanta/internal/system"
+	"yanta/internal/tag"
+	"yanta/internal/vault"
+	"yanta/internal/vaultlock"
+)
+
+// RunStandalone serves the vault over MCP on stdio without the GUI, for
+// `yanta mcp --standalone`. It blocks until the client disconnects or ctx is
+// done.
+//
+// If no app holds the vault lock, the server becomes primary: it migrates the
+// database, rescans the vault and runs the file watcher and git auto-sync
+// like the app does. When an app starts later it takes the lock over, and the
+// server stops its watcher and sync before acknowledging, then keeps serving
+// as a secondary. A server started next to a running app is a secondary from
+// the start. A secondary only reads and writes through the shared WAL
+// database and the vault files; the primary's watcher indexes its file
+// changes and commits them. A secondary is not promoted when the app quits:
+// its sync manager is shut down for good, so it has to be restarted to take
+// over watching and syncing.
+func RunStandalone(ctx context.Context, dbPath string) error {
+	lock, err := vaultlock.Claim(ctx, vaultlock.OwnerStandalone)
+	var held *vaultlock.HeldError
+	switch {
+	case errors.As(err, &held):
+		logger.Infof("vault is held by %s (pid %d); serving as secondary", held.Holder.Owner, held.Holder.PID)
+	case err != nil:
+		return err
+	}
+	defer lock.Release()
+	primary := lock != nil
+
+	conn, err := db.OpenDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := db.CloseDB(conn); err != nil {
+			logger.Errorf("failed to close database: %v", err)
+		}
+	}()
+
+	if primary {
+		if err := db.RunMigrations(conn); err != nil {
+			return err
+		}
+		if err := db.IntegrityCheck(conn); err != nil {
+			return err
+		}
+	} else if err := db.CheckSchema(conn); err != nil {
+		return fmt.Errorf("%w; update Yanta or run the server while the app is closed", err)
+	}
+
+	v, err := vault.New(vault.Config{})
+	if err != nil {
+		return err
+	}
+
+	eventBus := events.NewHeadlessEventBus()
+
+	// A secondary never syncs: its sync manager is shut down before first use,
+	// which makes every NotifyChange a no-op.
+	syncManager := git.NewSyncManager(conn)
+	syncManager.SetOperationLock(git.NewOperationLock())
+	defer syncManager.Shutdown()
+
+	projectStore := project.NewStore(conn)
+	documentStore := document.NewStore(conn)
+	tagStore := tag.NewStore(conn)
+	ftsStore := search.NewStore(conn)
+	assetStore := asset.NewStore(conn)
+
+	idx := indexer.New(
+		conn,
+		v,
+		documentStore,
+		projectStore,
+		ftsStore,
+		tagStore,
+		link.NewStore(conn),
+		assetStore,
+		syncManager,
+		eventBus,
+	)
+
+	projectCache := project.NewCache(projectStore)
+	projectService := project.NewService(conn, projectStore, projectCache, v, syncManager, eventBus)
+	documentService := document.NewService(conn, documentStore, v, idx, projectCache, eventBus)
+	tagService := tag.NewService(conn, tagStore, document.NewFileManager(v), eventBus)
+	tagService.SetSyncNotifier(syncManager)
+	searchService := search.NewService(conn, eventBus)
+	assetService := asset.NewService(asset.ServiceConfig{
+		DB:          conn,
+		Store:       assetStore,
+		Vault:       v,
+		SyncManager: syncManager,
+	})
+	journalService := journal.NewService(v, eventBus, ftsStore)
+	journalService.SetIndexer(idx)
+	journalService.SetSyncNotifier(syncManager)
+
+	stopWatcher := func() {}
+	if primary {
+		syncManager.Start()
+		go syncManager.ReconcileOnStartup()
+
+		if _, err := idx.ScanAndIndexVault(ctx); err != nil {
+			logger.Errorf("failed to scan and index vault: %v", err)
+		}
+
+		watcher, err := indexer.NewWatcher(v, idx, indexer.WithEventBus(eventBus))
+		if err != nil {
+			logger.Warnf("failed to create file watcher: %v", err)
+		} else if err := watcher.Start(ctx); err != nil {
+			logger.Warnf("failed to start file watcher: %v", err)
+		} else {
+			documentService.SetWatcher(watcher)
+			stopWatcher = sync.OnceFunc(func() {
+				if err := watcher.Stop(); err != nil {
+					logger.WithError(err).Warn("failed to stop file watcher")
+				}
+			})
+		}
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go lock.Watch(watchCtx, func() {
+			logger.Info("vault lock taken over by the app; continuing as secondary")
+			syncManager.Shutdown()
+			stopWatcher()
+		})
+	} else {
+		syncManager.Shutdown()
+	}
+	defer func() { stopWatcher() }()
+
+	server := mcp.NewServer(&mcpVault{
+		documents:    documentService,
+		search:       searchService,
+		projects:     projectService,
+		projectCache: projectCache,
+		journal:      journalService,
+		tags:         tagService,
+		assets:       assetService,
+		vault:        v,
+	}, system.BuildVersion)
+	audit := mcpctl.NewAuditLog()
+	audit.SetSyncNotifier(syncManager)
+	server.SetAudit(audit.Record)
+	eventBus.Subscribe(mcpResourceListener(func(ctx context.Context, uri string) {
+		if err := server.NotifyResourceUpdated(ctx, uri); err != nil {
+			logger.Debugf("mcp: resource update for %s: %v", uri, err)
+		}
+	}, projectCache))
+
+	logger.Infof("serving MCP on stdio (primary: %t)", primary)
+	return server.RunStdio(ctx)
+}
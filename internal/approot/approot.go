@@ -0,0 +1,25 @@
+// Package approot resolves the app root directory: the base directory for
+// app-scoped files such as config, logs and plugins. It uses only the
+// standard library, so tools built without the GUI, like the yanta-plugin
+// CLI, resolve exactly the directory the app does.
+package approot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the app root directory. Precedence:
+// 1. YANTA_HOME
+// 2. ~/.yanta
+func Dir() (string, error) {
+	if envDir := os.Getenv("YANTA_HOME"); envDir != "" {
+		return envDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".yanta"), nil
+}
@@ -0,0 +1,29 @@
+package approot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	t.Run("YANTA_HOME", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("YANTA_HOME", home)
+		dir, err := Dir()
+		require.NoError(t, err)
+		assert.Equal(t, home, dir)
+	})
+
+	t.Run("home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("YANTA_HOME", "")
+		t.Setenv("HOME", home)
+		t.Setenv("USERPROFILE", home)
+		dir, err := Dir()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".yanta"), dir)
+	})
+}
@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeArchive writes the vault directory and database file into a tar
+// stream using the same layout as a plaintext backup directory.
+func writeArchive(w io.Writer, vaultSrc, dbSrc string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(vaultSrc, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(vaultSrc, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		name := filepath.ToSlash(filepath.Join("vault", relPath))
+
+		return addArchiveEntry(tw, path, name, info)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive vault directory: %w", err)
+	}
+
+	dbInfo, err := os.Stat(dbSrc)
+	if err != nil {
+		return fmt.Errorf("failed to stat database: %w", err)
+	}
+	if err := addArchiveEntry(tw, dbSrc, "yanta.db", dbInfo); err != nil {
+		return fmt.Errorf("failed to archive database: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	return nil
+}
+
+func addArchiveEntry(tw *tar.Writer, path, name string, info os.FileInfo) error {
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to create header for %s: %w", path, err)
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write header for %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", path, err)
+	}
+	return nil
+}
+
+// extractArchive unpacks a tar stream produced by writeArchive into dest.
+// Entries that would escape dest are rejected.
+func extractArchive(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	root := filepath.Clean(dest) + string(os.PathSeparator)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target+string(os.PathSeparator), root) {
+			return fmt.Errorf("archive entry escapes destination: %s", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", target, err)
+			}
+			if err := extractFile(tr, target, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported archive entry type for %s", hdr.Name)
+		}
+	}
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted archive layout:
+//
+//	magic (8) | logN (1) | r (1) | p (1) | salt (16) | nonce prefix (7) | key check (32)
+//	followed by AES-256-GCM sealed chunks of up to chunkSize plaintext bytes.
+//
+// Each chunk nonce is the prefix, a big-endian chunk counter and a final-chunk
+// flag, so truncated or reordered archives fail authentication. The header is
+// bound to every chunk as additional data.
+const (
+	archiveMagic    = "YANTABK1"
+	saltSize        = 16
+	noncePrefixSize = 7
+	keyCheckSize    = 32
+	keySize         = 32
+	chunkSize       = 64 * 1024
+	headerSize      = len(archiveMagic) + 3 + saltSize + noncePrefixSize + keyCheckSize
+
+	defaultScryptLogN = 15
+	defaultScryptR    = 8
+	defaultScryptP    = 1
+	maxScryptLogN     = 22
+)
+
+var (
+	// ErrWrongPassphrase is returned when an encrypted backup cannot be opened
+	// with the supplied passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase for encrypted backup")
+	// ErrCorruptArchive is returned when an encrypted backup fails authentication
+	// after the passphrase has been verified.
+	ErrCorruptArchive = errors.New("encrypted backup is corrupted or truncated")
+)
+
+type archiveHeader struct {
+	logN        uint8
+	r           uint8
+	p           uint8
+	salt        []byte
+	noncePrefix []byte
+	keyCheck    []byte
+}
+
+func (h *archiveHeader) marshal() []byte {
+	buf := make([]byte, 0, headerSize)
+	buf = append(buf, archiveMagic...)
+	buf = append(buf, h.logN, h.r, h.p)
+	buf = append(buf, h.salt...)
+	buf = append(buf, h.noncePrefix...)
+	buf = append(buf, h.keyCheck...)
+	return buf
+}
+
+func readArchiveHeader(r io.Reader) (*archiveHeader, []byte, error) {
+	raw := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive header: %w", err)
+	}
+	if string(raw[:len(archiveMagic)]) != archiveMagic {
+		return nil, nil, fmt.Errorf("not an encrypted yanta backup")
+	}
+
+	off := len(archiveMagic)
+	h := &archiveHeader{logN: raw[off], r: raw[off+1], p: raw[off+2]}
+	off += 3
+	h.salt = raw[off : off+saltSize]
+	off += saltSize
+	h.noncePrefix = raw[off : off+noncePrefixSize]
+	off += noncePrefixSize
+	h.keyCheck = raw[off : off+keyCheckSize]
+
+	if h.logN == 0 || h.logN > maxScryptLogN || h.r == 0 || h.p == 0 {
+		return nil, nil, fmt.Errorf("unsupported key derivation parameters in archive header")
+	}
+
+	return h, raw, nil
+}
+
+// deriveKeys stretches the passphrase with scrypt and splits the output into
+// the AEAD key and a check value stored in the header, which lets a wrong
+// passphrase be reported before any chunk is decrypted.
+func deriveKeys(passphrase string, h *archiveHeader) (key, check []byte, err error) {
+	out, err := scrypt.Key([]byte(passphrase), h.salt, 1<<h.logN, int(h.r), int(h.p), keySize+keyCheckSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return out[:keySize], out[keySize:], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// encryptWriter seals everything written to it into fixed-size AEAD chunks.
+// Close must be called to emit the final chunk.
+type encryptWriter struct {
+	dst     io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	aad     []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+}
+
+func newEncryptWriter(dst io.Writer, passphrase string) (*encryptWriter, error) {
+	h := &archiveHeader{
+		logN:        defaultScryptLogN,
+		r:           defaultScryptR,
+		p:           defaultScryptP,
+		salt:        make([]byte, saltSize),
+		noncePrefix: make([]byte, noncePrefixSize),
+	}
+	if _, err := rand.Read(h.salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if _, err := rand.Read(h.noncePrefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	key, check, err := deriveKeys(passphrase, h)
+	if err != nil {
+		return nil, err
+	}
+	h.keyCheck = check
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := h.marshal()
+	if _, err := dst.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write archive header: %w", err)
+	}
+
+	return &encryptWriter{
+		dst:    dst,
+		aead:   aead,
+		prefix: h.noncePrefix,
+		aad:    header,
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("write to closed encrypt writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// Only flush when more data follows, so the final chunk is always
+		// emitted by Close with the last flag set.
+		if len(w.buf) == chunkSize {
+			if err := w.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (w *encryptWriter) flush(last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.prefix, w.counter, last), w.buf, w.aad)
+	if _, err := w.dst.Write(sealed); err != nil {
+		return fmt.Errorf("failed to write encrypted chunk: %w", err)
+	}
+	w.counter++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *encryptWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(true)
+}
+
+// decryptReader opens chunks produced by encryptWriter. It returns
+// ErrCorruptArchive if any chunk fails authentication or the stream ends
+// before the final chunk.
+type decryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	aad     []byte
+	chunk   []byte
+	plain   []byte
+	counter uint32
+	done    bool
+}
+
+func newDecryptReader(src io.Reader, passphrase string) (*decryptReader, error) {
+	h, header, err := readArchiveHeader(src)
+	if err != nil {
+		return nil, err
+	}
+
+	key, check, err := deriveKeys(passphrase, h)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(check, h.keyCheck) != 1 {
+		return nil, ErrWrongPassphrase
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		src:    bufio.NewReaderSize(src, chunkSize+aead.Overhead()+1),
+		aead:   aead,
+		prefix: h.noncePrefix,
+		aad:    header,
+		chunk:  make([]byte, chunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *decryptReader) next() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	switch {
+	case err == io.EOF:
+		return ErrCorruptArchive
+	case err == io.ErrUnexpectedEOF:
+		r.done = true
+	case err != nil:
+		return fmt.Errorf("failed to read encrypted chunk: %w", err)
+	default:
+		if _, peekErr := r.src.Peek(1); peekErr == io.EOF {
+			r.done = true
+		}
+	}
+
+	plain, err := r.aead.Open(r.chunk[:0], chunkNonce(r.prefix, r.counter, r.done), r.chunk[:n], r.aad)
+	if err != nil {
+		return ErrCorruptArchive
+	}
+	r.counter++
+	r.plain = plain
+	return nil
+}
@@ -0,0 +1,89 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptBytes(t *testing.T, plain []byte, passphrase string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, passphrase)
+	require.NoError(t, err)
+	_, err = w.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "small", size: 100},
+		{name: "exact chunk", size: chunkSize},
+		{name: "multiple chunks", size: chunkSize*3 + 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := make([]byte, tt.size)
+			_, err := rand.Read(plain)
+			require.NoError(t, err)
+
+			sealed := encryptBytes(t, plain, "correct horse")
+
+			r, err := newDecryptReader(bytes.NewReader(sealed), "correct horse")
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, plain, got)
+		})
+	}
+}
+
+func TestDecrypt_WrongPassphrase(t *testing.T) {
+	sealed := encryptBytes(t, []byte("secret notes"), "correct horse")
+
+	_, err := newDecryptReader(bytes.NewReader(sealed), "battery staple")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestDecrypt_TamperedArchive(t *testing.T) {
+	plain := make([]byte, chunkSize*2+10)
+	sealed := encryptBytes(t, plain, "pass")
+
+	t.Run("flipped ciphertext byte", func(t *testing.T) {
+		tampered := bytes.Clone(sealed)
+		tampered[headerSize+5] ^= 0xff
+
+		r, err := newDecryptReader(bytes.NewReader(tampered), "pass")
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, ErrCorruptArchive)
+	})
+
+	t.Run("truncated at chunk boundary", func(t *testing.T) {
+		truncated := sealed[:headerSize+chunkSize+16]
+
+		r, err := newDecryptReader(bytes.NewReader(truncated), "pass")
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, ErrCorruptArchive)
+	})
+
+	t.Run("not an archive", func(t *testing.T) {
+		_, err := newDecryptReader(bytes.NewReader(make([]byte, headerSize)), "pass")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not an encrypted yanta backup")
+	})
+}
@@ -19,14 +19,48 @@ import (
 	"yanta/internal/paths"
 )
 
+const (
+	timestampFormat = "2006-01-02_15-04-05"
+	encryptedExt    = ".ybak"
+
+	// PassphraseEnvVar holds the passphrase used for automatic encrypted
+	// backups. It is never written to config.toml.
+	PassphraseEnvVar = "YANTA_BACKUP_PASSPHRASE"
+)
+
 type Service struct{}
 
 func NewService() *Service {
 	return &Service{}
 }
 
-// CreateBackup creates a timestamped backup of the data directory
-func (s *Service) CreateBackup(dataDir string) error {
+type options struct {
+	passphrase string
+}
+
+// Option configures a single backup or restore operation.
+type Option func(*options)
+
+// WithPassphrase encrypts a new backup, or decrypts an existing one, with the
+// given passphrase. An empty passphrase leaves the backup in plaintext.
+func WithPassphrase(passphrase string) Option {
+	return func(o *options) { o.passphrase = passphrase }
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CreateBackup creates a timestamped backup of the data directory. With
+// WithPassphrase the backup is written as a single encrypted archive instead
+// of a plain directory copy.
+func (s *Service) CreateBackup(dataDir string, opts ...Option) error {
+	o := applyOptions(opts)
+
 	if strings.TrimSpace(dataDir) == "" {
 		return fmt.Errorf("data directory path is empty")
 	}
@@ -49,8 +83,12 @@ func (s *Service) CreateBackup(dataDir string) error {
 		return fmt.Errorf("failed to create backups directory: %w", err)
 	}
 
+	timestamp := time.Now().Format(timestampFormat)
+	if o.passphrase != "" {
+		return createEncryptedBackup(dataDir, filepath.Join(backupsPath, timestamp+encryptedExt), o.passphrase)
+	}
+
 	// Create timestamped backup directory
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
 	backupPath := filepath.Join(backupsPath, timestamp)
 
 	if err := os.MkdirAll(backupPath, 0755); err != nil {
@@ -85,6 +123,52 @@ func (s *Service) CreateBackup(dataDir string) error {
 	return nil
 }
 
+func createEncryptedBackup(dataDir, archivePath, passphrase string) error {
+	logger.WithFields(map[string]any{
+		"dataDir":    dataDir,
+		"backupPath": archivePath,
+	}).Info("creating encrypted backup")
+
+	// Write to a temporary name so an interrupted backup never shows up in
+	// ListBackups as a truncated archive.
+	tmpPath := archivePath + ".tmp"
+	if err := writeEncryptedArchive(tmpPath, passphrase); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to finalize encrypted backup: %w", err)
+	}
+
+	logger.WithField("backupPath", archivePath).Info("encrypted backup created successfully")
+
+	return nil
+}
+
+func writeEncryptedArchive(path, passphrase string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup archive: %w", err)
+	}
+	defer f.Close()
+
+	ew, err := newEncryptWriter(f, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writeArchive(ew, paths.GetVaultPath(), paths.GetDatabasePath()); err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync backup archive: %w", err)
+	}
+	return nil
+}
+
 // ListBackups returns a list of available backups, sorted by timestamp (newest first)
 func (s *Service) ListBackups(dataDir string) ([]BackupInfo, error) {
 	if strings.TrimSpace(dataDir) == "" {
@@ -105,24 +189,39 @@ func (s *Service) ListBackups(dataDir string) ([]BackupInfo, error) {
 
 	var backups []BackupInfo
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		name := entry.Name()
+		// Skip hidden entries such as staging directories left by an
+		// interrupted restore.
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		encrypted := !entry.IsDir() && strings.HasSuffix(name, encryptedExt)
+		if !entry.IsDir() && !encrypted {
 			continue
 		}
 
-		backupPath := filepath.Join(backupsPath, entry.Name())
+		backupPath := filepath.Join(backupsPath, name)
 
-		// Parse timestamp from directory name
-		timestamp, err := time.Parse("2006-01-02_15-04-05", entry.Name())
+		// Parse timestamp from directory or archive name
+		timestamp, err := time.Parse(timestampFormat, strings.TrimSuffix(name, encryptedExt))
 		if err != nil {
 			logger.WithFields(map[string]any{
-				"dirName": entry.Name(),
+				"dirName": name,
 				"error":   err.Error(),
 			}).Warn("skipping directory with invalid timestamp format")
 			continue
 		}
 
 		// Calculate backup size
-		size, err := calculateDirSize(backupPath)
+		var size int64
+		if encrypted {
+			var info os.FileInfo
+			if info, err = entry.Info(); err == nil {
+				size = info.Size()
+			}
+		} else {
+			size, err = calculateDirSize(backupPath)
+		}
 		if err != nil {
 			logger.WithFields(map[string]any{
 				"path":  backupPath,
@@ -135,6 +234,7 @@ func (s *Service) ListBackups(dataDir string) ([]BackupInfo, error) {
 			Timestamp: timestamp,
 			Path:      backupPath,
 			Size:      size,
+			Encrypted: encrypted,
 		})
 	}
 
@@ -146,8 +246,12 @@ func (s *Service) ListBackups(dataDir string) ([]BackupInfo, error) {
 	return backups, nil
 }
 
-// RestoreBackup restores data from a backup
-func (s *Service) RestoreBackup(dataDir, backupPath string) error {
+// RestoreBackup restores data from a backup. Encrypted backups require
+// WithPassphrase; they are decrypted into a staging directory first so a wrong
+// passphrase or corrupted archive leaves the live vault untouched.
+func (s *Service) RestoreBackup(dataDir, backupPath string, opts ...Option) error {
+	o := applyOptions(opts)
+
 	if strings.TrimSpace(dataDir) == "" {
 		return fmt.Errorf("data directory path is empty")
 	}
@@ -165,7 +269,19 @@ func (s *Service) RestoreBackup(dataDir, backupPath string) error {
 		return fmt.Errorf("cannot access backup path %q: %w", backupPath, err)
 	}
 	if !info.IsDir() {
-		return fmt.Errorf("backup path is not a directory: %s", backupPath)
+		if !strings.HasSuffix(backupPath, encryptedExt) {
+			return fmt.Errorf("backup path is not a directory: %s", backupPath)
+		}
+		if o.passphrase == "" {
+			return fmt.Errorf("backup is encrypted: passphrase required")
+		}
+
+		stagingPath, err := decryptToStaging(dataDir, backupPath, o.passphrase)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(stagingPath)
+		backupPath = stagingPath
 	}
 
 	// Validate backup contains required files
@@ -211,6 +327,40 @@ func (s *Service) RestoreBackup(dataDir, backupPath string) error {
 	return nil
 }
 
+// decryptToStaging fully decrypts and unpacks an encrypted archive into a
+// temporary directory in dataDir, outside the backups, and returns its
+// path.
+func decryptToStaging(dataDir, archivePath, passphrase string) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open encrypted backup: %w", err)
+	}
+	defer f.Close()
+
+	dr, err := newDecryptReader(f, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	stagingPath, err := os.MkdirTemp(dataDir, ".restore-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create restore staging directory: %w", err)
+	}
+
+	if err := extractArchive(dr, stagingPath); err != nil {
+		os.RemoveAll(stagingPath)
+		return "", fmt.Errorf("failed to unpack encrypted backup: %w", err)
+	}
+	// Drain to the final chunk so trailing tampering is detected before the
+	// live vault is replaced.
+	if _, err := io.Copy(io.Discard, dr); err != nil {
+		os.RemoveAll(stagingPath)
+		return "", fmt.Errorf("failed to unpack encrypted backup: %w", err)
+	}
+
+	return stagingPath, nil
+}
+
 // DeleteBackup deletes a specific backup
 func (s *Service) DeleteBackup(backupPath string) error {
 	if strings.TrimSpace(backupPath) == "" {
@@ -225,7 +375,7 @@ func (s *Service) DeleteBackup(backupPath string) error {
 	if err != nil {
 		return fmt.Errorf("cannot access backup path %q: %w", backupPath, err)
 	}
-	if !info.IsDir() {
+	if !info.IsDir() && !strings.HasSuffix(backupPath, encryptedExt) {
 		return fmt.Errorf("backup path is not a directory: %s", backupPath)
 	}
 
@@ -329,6 +479,43 @@ func (s *Service) Restore(ctx context.Context, backupPath string) error {
 	return nil
 }
 
+// CreateEncrypted creates an encrypted backup of the data directory with a
+// passphrase supplied by the user, independent of the automatic backup
+// settings and PassphraseEnvVar.
+func (s *Service) CreateEncrypted(ctx context.Context, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("failed to create backup: passphrase is required")
+	}
+
+	dataDir := config.GetDataDirectory()
+
+	logger.WithField("dataDir", dataDir).Info("creating encrypted backup from frontend")
+
+	if err := s.CreateBackup(dataDir, WithPassphrase(passphrase)); err != nil {
+		logger.WithError(err).Error("failed to create encrypted backup")
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreEncrypted restores data from an encrypted backup using passphrase
+func (s *Service) RestoreEncrypted(ctx context.Context, backupPath, passphrase string) error {
+	dataDir := config.GetDataDirectory()
+
+	logger.WithFields(map[string]any{
+		"dataDir":    dataDir,
+		"backupPath": backupPath,
+	}).Info("restoring encrypted backup from frontend")
+
+	if err := s.RestoreBackup(dataDir, backupPath, WithPassphrase(passphrase)); err != nil {
+		logger.WithError(err).Error("failed to restore encrypted backup")
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	return nil
+}
+
 // Delete deletes a specific backup
 func (s *Service) Delete(ctx context.Context, backupPath string) error {
 	logger.WithField("backupPath", backupPath).Info("deleting backup from frontend")
@@ -355,6 +542,7 @@ func (s *Service) SetConfig(ctx context.Context, cfg config.BackupConfig) error
 	logger.WithFields(map[string]any{
 		"enabled":    cfg.Enabled,
 		"maxBackups": cfg.MaxBackups,
+		"encrypt":    cfg.Encrypt,
 	}).Info("updating backup configuration from frontend")
 
 	if err := config.SetBackupConfig(cfg); err != nil {
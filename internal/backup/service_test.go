@@ -1,8 +1,10 @@
 package backup
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -755,3 +757,104 @@ func TestBackupWithDataDirectoryChange(t *testing.T) {
 	// Verify backup location is within the data directory
 	assert.Contains(t, backups[0].Path, config.GetDataDirectory())
 }
+
+func TestEncryptedBackup(t *testing.T) {
+	service := NewService()
+	dataDir := setupTestDataDir(t)
+
+	err := service.CreateBackup(dataDir, WithPassphrase("s3cret"))
+	require.NoError(t, err)
+
+	backups, err := service.ListBackups(dataDir)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	backupPath := backups[0].Path
+
+	t.Run("lists encrypted status", func(t *testing.T) {
+		assert.True(t, backups[0].Encrypted)
+		assert.True(t, strings.HasSuffix(backupPath, encryptedExt))
+		assert.Greater(t, backups[0].Size, int64(0))
+
+		raw, err := os.ReadFile(backupPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "test content")
+	})
+
+	t.Run("requires passphrase to restore", func(t *testing.T) {
+		err := service.RestoreBackup(dataDir, backupPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "passphrase required")
+	})
+
+	t.Run("wrong passphrase leaves vault untouched", func(t *testing.T) {
+		testFile := filepath.Join(paths.GetVaultPath(), "test-file.txt")
+		require.NoError(t, os.WriteFile(testFile, []byte("live content"), 0644))
+
+		err := service.RestoreBackup(dataDir, backupPath, WithPassphrase("wrong"))
+		require.ErrorIs(t, err, ErrWrongPassphrase)
+
+		content, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		assert.Equal(t, "live content", string(content))
+
+		entries, err := os.ReadDir(paths.GetBackupsPath())
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "staging directory should not be left behind")
+
+		stale, err := filepath.Glob(filepath.Join(dataDir, ".restore-*"))
+		require.NoError(t, err)
+		assert.Empty(t, stale)
+	})
+
+	t.Run("ignores staging left by an interrupted restore", func(t *testing.T) {
+		stale := filepath.Join(paths.GetBackupsPath(), ".restore-123")
+		require.NoError(t, os.MkdirAll(stale, 0755))
+		t.Cleanup(func() { os.RemoveAll(stale) })
+
+		listed, err := service.ListBackups(dataDir)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, backupPath, listed[0].Path)
+	})
+
+	t.Run("restores with correct passphrase", func(t *testing.T) {
+		err := service.RestoreBackup(dataDir, backupPath, WithPassphrase("s3cret"))
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(paths.GetVaultPath(), "test-file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "test content", string(content))
+
+		dbContent, err := os.ReadFile(paths.GetDatabasePath())
+		require.NoError(t, err)
+		assert.Equal(t, "fake db content", string(dbContent))
+	})
+
+	t.Run("deletes encrypted backup", func(t *testing.T) {
+		require.NoError(t, service.DeleteBackup(backupPath))
+		assert.NoFileExists(t, backupPath)
+	})
+}
+
+func TestCreateEncrypted(t *testing.T) {
+	service := NewService()
+	dataDir := setupTestDataDir(t)
+	t.Setenv(PassphraseEnvVar, "")
+
+	t.Run("requires a passphrase", func(t *testing.T) {
+		err := service.CreateEncrypted(context.Background(), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "passphrase is required")
+	})
+
+	t.Run("creates an encrypted backup without the env var", func(t *testing.T) {
+		require.NoError(t, service.CreateEncrypted(context.Background(), "user passphrase"))
+
+		backups, err := service.ListBackups(dataDir)
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		assert.True(t, backups[0].Encrypted)
+
+		require.NoError(t, service.RestoreEncrypted(context.Background(), backups[0].Path, "user passphrase"))
+	})
+}
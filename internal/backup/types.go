@@ -15,6 +15,7 @@ const (
 type BackupConfig struct {
 	Enabled    bool `json:"enabled" toml:"enabled"`
 	MaxBackups int  `json:"maxBackups" toml:"max_backups"`
+	Encrypt    bool `json:"encrypt" toml:"encrypt"`
 }
 
 // BackupInfo contains metadata about a specific backup
@@ -22,6 +23,7 @@ type BackupInfo struct {
 	Timestamp time.Time `json:"timestamp"`
 	Path      string    `json:"path"`
 	Size      int64     `json:"size"`
+	Encrypted bool      `json:"encrypted"`
 }
 
 // BackupResult represents the result of a backup operation
@@ -0,0 +1,175 @@
+package blocknote
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+
+	"yanta/internal/blocktype"
+
+	"github.com/google/uuid"
+)
+
+// blockAttrs is the part of a block plain Markdown has no syntax for. It is
+// written as an HTML comment, either at the end of the block's line or on the
+// line before it:
+//
+//	Some text <!-- blocknote {"props":{"textColor":"red"}} -->
+//
+// A comment that stands alone (followed by a blank line) is itself a block
+// without inline content, such as an empty paragraph.
+type blockAttrs struct {
+	// Type overrides the block type the Markdown would parse as.
+	Type  string         `json:"type,omitempty"`
+	Props map[string]any `json:"props,omitempty"`
+	// Children is the number of following sibling blocks that are really
+	// this block's children, for block types Markdown cannot nest under.
+	Children int `json:"children,omitempty"`
+	// Content carries block content that is not inline content, for block
+	// types this package does not know.
+	Content json.RawMessage `json:"content,omitempty"`
+	// Cells holds the full props of table cells whose props differ from
+	// what the column alignment implies; nil entries follow the alignment.
+	Cells        [][]map[string]any `json:"cells,omitempty"`
+	ColumnWidths []any              `json:"columnWidths,omitempty"`
+}
+
+var attrsCommentRe = regexp.MustCompile(`^<!--\s*blocknote\s+(\{.*\})\s*-->$`)
+
+// parseAttrs decodes an attribute comment. It reports false for anything
+// else, including ordinary HTML comments.
+func parseAttrs(raw string) (*blockAttrs, bool) {
+	m := attrsCommentRe.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return nil, false
+	}
+	var a blockAttrs
+	if err := json.Unmarshal([]byte(m[1]), &a); err != nil {
+		return nil, false
+	}
+	return &a, true
+}
+
+func (a *blockAttrs) empty() bool {
+	return a == nil || (a.Type == "" && len(a.Props) == 0 && a.Children == 0 &&
+		a.Content == nil && a.Cells == nil && a.ColumnWidths == nil)
+}
+
+// comment renders a as an HTML comment. encoding/json already escapes < and
+// >, so only "--" needs care to keep the comment well-formed.
+func (a *blockAttrs) comment() string {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return ""
+	}
+	return "<!-- blocknote " + strings.ReplaceAll(string(data), "--", `-\u002d`) + " -->"
+}
+
+// defaultProps are the values BlockNote gives every block; they are implied
+// by the Markdown and never written out.
+var defaultProps = map[string]any{
+	"textColor":       "default",
+	"backgroundColor": "default",
+	"textAlignment":   "left",
+	"showPreview":     true,
+}
+
+// extraProps returns the props of b that neither have a Markdown form for
+// its type (native) nor hold their default value.
+func extraProps(b Block, native ...string) map[string]any {
+	var extra map[string]any
+	for k, v := range b.Props {
+		if def, ok := defaultProps[k]; ok && def == v {
+			continue
+		}
+		if slices.Contains(native, k) {
+			continue
+		}
+		if extra == nil {
+			extra = map[string]any{}
+		}
+		extra[k] = v
+	}
+	return extra
+}
+
+// applyAttrs folds parsed attributes back into b.
+func applyAttrs(b Block, a *blockAttrs) pblock {
+	if a == nil {
+		return pblock{Block: b}
+	}
+	if a.Type != "" {
+		b.Type = a.Type
+	}
+	if a.Content != nil {
+		b.Content = a.Content
+	}
+	if len(a.Props) > 0 {
+		props := make(map[string]any, len(b.Props)+len(a.Props))
+		for k, v := range b.Props {
+			props[k] = v
+		}
+		for k, v := range a.Props {
+			props[k] = v
+		}
+		b.Props = props
+	}
+	if b.Type == blocktype.Table && (a.Cells != nil || a.ColumnWidths != nil) {
+		b.Content = applyTableAttrs(b.Content, a)
+	}
+	return pblock{Block: b, nest: a.Children}
+}
+
+func applyTableAttrs(raw json.RawMessage, a *blockAttrs) json.RawMessage {
+	var tc tableContent
+	if err := json.Unmarshal(raw, &tc); err != nil {
+		return raw
+	}
+	if a.ColumnWidths != nil {
+		tc.ColumnWidths = a.ColumnWidths
+	}
+	for r := range tc.Rows {
+		if r >= len(a.Cells) {
+			break
+		}
+		for c := range tc.Rows[r].Cells {
+			if c < len(a.Cells[r]) && a.Cells[r][c] != nil {
+				tc.Rows[r].Cells[c].Props = a.Cells[r][c]
+			}
+		}
+	}
+	data, err := json.Marshal(tc)
+	if err != nil {
+		return raw
+	}
+	return data
+}
+
+// standaloneBlock builds the content-less block a standalone attribute
+// comment stands for.
+func standaloneBlock(a *blockAttrs) pblock {
+	typ := a.Type
+	if typ == "" {
+		typ = blocktype.Paragraph
+	}
+	b := Block{ID: uuid.NewString(), Type: typ}
+	if a.Content == nil && hasInlineContent(typ) {
+		b.Content = marshalInline(nil)
+	}
+	rest := *a
+	rest.Type = ""
+	return applyAttrs(b, &rest)
+}
+
+// hasInlineContent reports whether blocks of typ carry inline content.
+func hasInlineContent(typ string) bool {
+	switch typ {
+	case blocktype.Paragraph, blocktype.Heading, blocktype.BulletListItem,
+		blocktype.NumberedListItem, blocktype.CheckListItem, blocktype.Quote,
+		blocktype.CodeBlock, blocktype.Callout, blocktype.Toggle, blocktype.Math,
+		blocktype.Diagram:
+		return true
+	}
+	return false
+}
@@ -8,21 +8,25 @@
 // marshals to JSON that unmarshals losslessly into []document.BlockNoteBlock
 // (and vice-versa). The MCP layer bridges the two with a single json round-trip.
 //
-// The conversion targets the block types Yanta actually renders (see
-// internal/export/renderer.go): heading, paragraph, codeBlock, bulletListItem,
-// numberedListItem, checkListItem, quote, image, file, table; and the inline
-// styles bold, italic, code, strike plus links. It is intentionally a pragmatic
-// subset aimed at agent-authored content, not a full CommonMark implementation.
-// Notably: nested lists are flattened on the Markdown->blocks path, and
-// multi-line paragraphs are joined with a single space (Markdown soft-break
-// semantics).
+// Parsing is CommonMark with the GFM extensions (tables with alignment,
+// strikethrough, task lists, autolinks) plus footnotes, backed by goldmark.
+// Callouts are GitHub alerts ("> [!NOTE]"), toggles are <details> elements,
+// math is a $$ block and diagrams are ```mermaid fences.
+// Rendering is the inverse and is written so that blocks -> Markdown -> blocks
+// is lossless for every block type Yanta renders (see
+// internal/export/renderer.go): nesting, hard breaks, ordered-list start
+// numbers, image and file blocks, table alignment, and the inline styles bold,
+// italic, strike, code, underline and text/background colors plus links.
+//
+// What plain Markdown cannot express — non-default colors or alignment on a
+// block, the children of a paragraph or heading, block types this package
+// does not know — is carried by an attribute comment next to the block (see
+// attrs.go), so such blocks still survive the round trip. Block IDs are not
+// preserved: parsed blocks always get fresh ones.
 package blocknote
 
 import (
 	"encoding/json"
-	"fmt"
-	"regexp"
-	"strings"
 
 	"yanta/internal/blocktype"
 
@@ -48,241 +52,26 @@ type Inline struct {
 	Content []Inline       `json:"content,omitempty"`
 }
 
-var (
-	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
-	checkRe    = regexp.MustCompile(`^[-*]\s+\[([ xX])\]\s+(.*)$`)
-	bulletRe   = regexp.MustCompile(`^[-*]\s+(.*)$`)
-	numRe      = regexp.MustCompile(`^(\d+)\.\s+(.*)$`)
-	thematicRe = regexp.MustCompile(`^(-{3,}|\*{3,}|_{3,})$`)
-	tableSepRe = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)+\|?$`)
-)
-
-// mdNode is a scratch tree node used while parsing so nested list items can be
-// attached to their parent before the whole tree is flattened to []Block.
-type mdNode struct {
-	block    Block
-	children []*mdNode
-}
-
-func flattenNodes(nodes []*mdNode) []Block {
-	out := make([]Block, 0, len(nodes))
-	for _, n := range nodes {
-		b := n.block
-		if len(n.children) > 0 {
-			b.Children = flattenNodes(n.children)
-		}
-		out = append(out, b)
-	}
-	return out
-}
-
-func leadingIndent(line string) int {
-	return len(line) - len(strings.TrimLeft(line, " \t"))
-}
-
 // MarkdownToBlocks parses Markdown into BlockNote blocks. It always returns a
 // non-nil slice (empty for empty input) so it satisfies BlockNote's "blocks
-// cannot be nil" invariant when marshaled into a document. Indented list items
-// are nested under their parent list item; a blank-line-separated paragraph (or
-// any non-list block) resets the current list nesting.
+// cannot be nil" invariant when marshaled into a document.
 func MarkdownToBlocks(md string) []Block {
-	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
-	roots := []*mdNode{}
-
-	type frame struct {
-		indent int
-		node   *mdNode
-	}
-	var stack []frame
-
-	var para []string
-	flushPara := func() {
-		if len(para) == 0 {
-			return
-		}
-		roots = append(roots, &mdNode{block: inlineBlock(blocktype.Paragraph, nil, strings.Join(para, " "))})
-		para = nil
-		stack = stack[:0] // a paragraph separates lists
-	}
-
-	addRoot := func(b Block) {
-		flushPara()
-		stack = stack[:0]
-		roots = append(roots, &mdNode{block: b})
-	}
-
-	addListItem := func(indent int, b Block) {
-		flushPara()
-		n := &mdNode{block: b}
-		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
-			stack = stack[:len(stack)-1]
-		}
-		if len(stack) > 0 {
-			parent := stack[len(stack)-1].node
-			parent.children = append(parent.children, n)
-		} else {
-			roots = append(roots, n)
-		}
-		stack = append(stack, frame{indent: indent, node: n})
-	}
-
-	for i := 0; i < len(lines); {
-		indent := leadingIndent(lines[i])
-		line := strings.TrimSpace(lines[i])
-
-		switch {
-		case strings.HasPrefix(line, "```"):
-			lang := strings.TrimSpace(line[3:])
-			i++
-			var code []string
-			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
-				code = append(code, lines[i])
-				i++
-			}
-			if i < len(lines) { // consume closing fence
-				i++
-			}
-			addRoot(codeBlock(lang, strings.Join(code, "\n")))
-
-		case line == "":
-			flushPara()
-			i++
-
-		case headingRe.MatchString(line):
-			m := headingRe.FindStringSubmatch(line)
-			level := len(m[1])
-			if level > 3 { // BlockNote headings support levels 1-3
-				level = 3
-			}
-			addRoot(inlineBlock(blocktype.Heading, map[string]any{"level": level}, m[2]))
-			i++
-
-		case thematicRe.MatchString(line):
-			addRoot(dividerBlock())
-			i++
-
-		case isTableStart(lines, i):
-			rows := [][]string{splitTableRow(line)}
-			i += 2 // consume header row and delimiter row
-			for i < len(lines) {
-				t := strings.TrimSpace(lines[i])
-				if t == "" || !strings.Contains(t, "|") {
-					break
-				}
-				rows = append(rows, splitTableRow(t))
-				i++
-			}
-			addRoot(tableBlock(rows))
-
-		case strings.HasPrefix(line, ">"):
-			flushPara()
-			stack = stack[:0]
-			var q []string
-			for i < len(lines) {
-				t := strings.TrimSpace(lines[i])
-				if !strings.HasPrefix(t, ">") {
-					break
-				}
-				q = append(q, strings.TrimSpace(strings.TrimPrefix(t, ">")))
-				i++
-			}
-			roots = append(roots, &mdNode{block: inlineBlock(blocktype.Quote, nil, strings.Join(q, " "))})
-
-		case checkRe.MatchString(line): // must precede bulletRe
-			m := checkRe.FindStringSubmatch(line)
-			checked := m[1] == "x" || m[1] == "X"
-			addListItem(indent, inlineBlock(blocktype.CheckListItem, map[string]any{"checked": checked}, m[2]))
-			i++
-
-		case bulletRe.MatchString(line):
-			m := bulletRe.FindStringSubmatch(line)
-			addListItem(indent, inlineBlock(blocktype.BulletListItem, nil, m[1]))
-			i++
-
-		case numRe.MatchString(line):
-			m := numRe.FindStringSubmatch(line)
-			addListItem(indent, inlineBlock(blocktype.NumberedListItem, nil, m[2]))
-			i++
-
-		default:
-			para = append(para, line)
-			i++
-		}
-	}
-	flushPara()
-	return flattenNodes(roots)
+	return parseMarkdown([]byte(md))
 }
 
 // BlocksToMarkdown renders BlockNote blocks back to Markdown.
 func BlocksToMarkdown(blocks []Block) string {
-	var b strings.Builder
-	renderBlocks(&b, blocks, 0)
-	return strings.TrimRight(b.String(), "\n")
-}
-
-func renderBlocks(b *strings.Builder, blocks []Block, depth int) {
-	indent := strings.Repeat("  ", depth)
-	num := 0
-	for _, blk := range blocks {
-		if blk.Type != blocktype.NumberedListItem {
-			num = 0
-		}
-		switch blk.Type {
-		case blocktype.Heading:
-			level := propInt(blk.Props, "level", 1)
-			if level < 1 {
-				level = 1
-			} else if level > 3 {
-				level = 3
-			}
-			fmt.Fprintf(b, "%s%s %s\n\n", indent, strings.Repeat("#", level), renderInline(blk.Content))
-		case blocktype.Paragraph:
-			if t := renderInline(blk.Content); t != "" {
-				fmt.Fprintf(b, "%s%s\n\n", indent, t)
-			}
-		case blocktype.CodeBlock:
-			fmt.Fprintf(b, "%s```%s\n%s\n```\n\n", indent, propString(blk.Props, "language", ""), plainText(blk.Content))
-		case blocktype.BulletListItem:
-			fmt.Fprintf(b, "%s- %s\n", indent, renderInline(blk.Content))
-		case blocktype.NumberedListItem:
-			num++
-			fmt.Fprintf(b, "%s%d. %s\n", indent, num, renderInline(blk.Content))
-		case blocktype.CheckListItem:
-			mark := " "
-			if propBool(blk.Props, "checked", false) {
-				mark = "x"
-			}
-			fmt.Fprintf(b, "%s- [%s] %s\n", indent, mark, renderInline(blk.Content))
-		case blocktype.Quote:
-			fmt.Fprintf(b, "%s> %s\n\n", indent, renderInline(blk.Content))
-		case blocktype.Image:
-			fmt.Fprintf(b, "%s![%s](%s)\n\n", indent, propString(blk.Props, "caption", ""), propString(blk.Props, "url", ""))
-		case blocktype.File:
-			url := propString(blk.Props, "url", "")
-			fmt.Fprintf(b, "%s[%s](%s)\n\n", indent, propString(blk.Props, "name", url), url)
-		case blocktype.Table:
-			renderTable(b, indent, blk.Content)
-		case blocktype.Divider:
-			fmt.Fprintf(b, "%s---\n\n", indent)
-		default:
-			if t := renderInline(blk.Content); t != "" {
-				fmt.Fprintf(b, "%s%s\n\n", indent, t)
-			}
-		}
-		if len(blk.Children) > 0 {
-			renderBlocks(b, blk.Children, depth+1)
-		}
-	}
+	return renderDocument(blocks)
 }
 
 // --- block builders ---
 
-func inlineBlock(typ string, props map[string]any, text string) Block {
+func inlineBlock(typ string, props map[string]any, items []Inline) Block {
 	return Block{
 		ID:      uuid.NewString(),
 		Type:    typ,
 		Props:   props,
-		Content: marshalInline(parseInline(text)),
+		Content: marshalInline(items),
 	}
 }
 
@@ -291,7 +80,22 @@ func codeBlock(lang, code string) Block {
 		ID:      uuid.NewString(),
 		Type:    blocktype.CodeBlock,
 		Props:   map[string]any{"language": lang},
-		Content: marshalInline([]Inline{{Type: "text", Text: code, Styles: map[string]any{}}}),
+		Content: marshalInline([]Inline{textInline(code, nil)}),
+	}
+}
+
+// sourceBlock builds a block whose content is source text rendered by the
+// editor, such as math (LaTeX) or a diagram (Mermaid).
+func sourceBlock(typ string, props map[string]any, src string) Block {
+	var items []Inline
+	if src != "" {
+		items = []Inline{textInline(src, nil)}
+	}
+	return Block{
+		ID:      uuid.NewString(),
+		Type:    typ,
+		Props:   props,
+		Content: marshalInline(items),
 	}
 }
 
@@ -305,65 +109,74 @@ func dividerBlock() Block {
 	}
 }
 
-// isTableStart reports whether the line at index i begins a GFM pipe table: a
-// row containing a pipe, immediately followed by a delimiter row (| --- | --- |).
-func isTableStart(lines []string, i int) bool {
-	if !strings.Contains(strings.TrimSpace(lines[i]), "|") {
-		return false
+func imageBlock(url, caption, name string) Block {
+	props := map[string]any{"url": url}
+	if caption != "" {
+		props["caption"] = caption
 	}
-	if i+1 >= len(lines) {
-		return false
+	if name != "" {
+		props["name"] = name
 	}
-	return tableSepRe.MatchString(strings.TrimSpace(lines[i+1]))
+	return Block{ID: uuid.NewString(), Type: blocktype.Image, Props: props}
 }
 
-// splitTableRow splits a GFM table row into trimmed cell texts, dropping the
-// optional leading and trailing pipes.
-func splitTableRow(line string) []string {
-	s := strings.TrimSpace(line)
-	s = strings.TrimPrefix(s, "|")
-	s = strings.TrimSuffix(s, "|")
-	parts := strings.Split(s, "|")
-	cells := make([]string, len(parts))
-	for i, p := range parts {
-		cells[i] = strings.TrimSpace(p)
+func fileBlock(url, name string) Block {
+	props := map[string]any{"url": url}
+	if name != "" {
+		props["name"] = name
 	}
-	return cells
+	return Block{ID: uuid.NewString(), Type: blocktype.File, Props: props}
 }
 
-// tableBlock builds a BlockNote table block from parsed rows of cell text. The
-// first row is treated as the header. Cell text is parsed for inline styles.
-func tableBlock(rows [][]string) Block {
+// tableCell is one cell of a BlockNote tableContent.
+type tableCell struct {
+	Type    string         `json:"type"`
+	Content []Inline       `json:"content"`
+	Props   map[string]any `json:"props"`
+}
+
+type tableRow struct {
+	Cells []tableCell `json:"cells"`
+}
+
+// tableContent mirrors BlockNote's table block content. A nil column width
+// means "auto".
+type tableContent struct {
+	Type         string     `json:"type"`
+	ColumnWidths []any      `json:"columnWidths"`
+	Rows         []tableRow `json:"rows"`
+}
+
+// tableBlock builds a BlockNote table block from rows of cells. Rows shorter
+// than the widest one are padded with empty cells.
+func tableBlock(rows [][]tableCell) Block {
 	cols := 0
 	for _, r := range rows {
-		if len(r) > cols {
-			cols = len(r)
-		}
+		cols = max(cols, len(r))
+	}
+	tc := tableContent{
+		Type:         "tableContent",
+		ColumnWidths: make([]any, cols),
+		Rows:         make([]tableRow, 0, len(rows)),
 	}
-	widths := make([]any, cols)
-	jsonRows := make([]map[string]any, 0, len(rows))
 	for _, r := range rows {
-		cells := make([]map[string]any, 0, cols)
-		for c := 0; c < cols; c++ {
-			text := ""
-			if c < len(r) {
-				text = r[c]
+		for len(r) < cols {
+			r = append(r, tableCell{})
+		}
+		for i := range r {
+			r[i].Type = "tableCell"
+			if r[i].Content == nil {
+				r[i].Content = []Inline{}
+			}
+			if r[i].Props == nil {
+				r[i].Props = map[string]any{}
 			}
-			cells = append(cells, map[string]any{
-				"type":    "tableCell",
-				"content": parseInline(text),
-				"props":   map[string]any{},
-			})
 		}
-		jsonRows = append(jsonRows, map[string]any{"cells": cells})
+		tc.Rows = append(tc.Rows, tableRow{Cells: r})
 	}
-	content, err := json.Marshal(map[string]any{
-		"type":         "tableContent",
-		"columnWidths": widths,
-		"rows":         jsonRows,
-	})
+	content, err := json.Marshal(tc)
 	if err != nil {
-		return inlineBlock(blocktype.Paragraph, nil, "")
+		return inlineBlock(blocktype.Paragraph, nil, nil)
 	}
 	return Block{
 		ID:      uuid.NewString(),
@@ -372,6 +185,13 @@ func tableBlock(rows [][]string) Block {
 	}
 }
 
+func textInline(text string, styles map[string]any) Inline {
+	if styles == nil {
+		styles = map[string]any{}
+	}
+	return Inline{Type: blocktype.InlineText, Text: text, Styles: styles}
+}
+
 func marshalInline(items []Inline) json.RawMessage {
 	if items == nil {
 		items = []Inline{}
@@ -383,159 +203,39 @@ func marshalInline(items []Inline) json.RawMessage {
 	return json.RawMessage(data)
 }
 
-// --- inline parsing (Markdown -> []Inline) ---
-
-func parseInline(s string) []Inline {
-	out := []Inline{}
-	var buf strings.Builder
-	flush := func() {
-		if buf.Len() > 0 {
-			out = append(out, Inline{Type: "text", Text: buf.String(), Styles: map[string]any{}})
-			buf.Reset()
-		}
-	}
-
-	for i := 0; i < len(s); {
-		switch {
-		case s[i] == '`':
-			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
-				flush()
-				out = append(out, Inline{Type: "text", Text: s[i+1 : i+1+end], Styles: map[string]any{"code": true}})
-				i += end + 2
-				continue
-			}
-		case s[i] == '[':
-			if label, url, n, ok := matchLink(s[i:]); ok {
-				flush()
-				out = append(out, Inline{Type: "link", Href: url, Content: parseInline(label)})
-				i += n
-				continue
-			}
-		case strings.HasPrefix(s[i:], "**"):
-			if inner, n, ok := matchDelim(s[i:], "**"); ok {
-				flush()
-				out = append(out, applyStyle(parseInline(inner), "bold")...)
-				i += n
-				continue
-			}
-		case strings.HasPrefix(s[i:], "~~"):
-			if inner, n, ok := matchDelim(s[i:], "~~"); ok {
-				flush()
-				out = append(out, applyStyle(parseInline(inner), "strike")...)
-				i += n
-				continue
-			}
-		case s[i] == '*' || s[i] == '_':
-			if inner, n, ok := matchDelim(s[i:], string(s[i])); ok {
-				flush()
-				out = append(out, applyStyle(parseInline(inner), "italic")...)
-				i += n
-				continue
-			}
-		}
-		buf.WriteByte(s[i])
-		i++
-	}
-	flush()
-	return out
-}
-
-// matchDelim matches s[0:] against a balanced pair of delim, returning the inner
-// text, the number of bytes consumed, and whether a non-empty match was found.
-func matchDelim(s, delim string) (string, int, bool) {
-	if !strings.HasPrefix(s, delim) {
-		return "", 0, false
-	}
-	rest := s[len(delim):]
-	idx := strings.Index(rest, delim)
-	if idx <= 0 { // no close, or empty inner
-		return "", 0, false
-	}
-	return rest[:idx], len(delim)*2 + idx, true
-}
+// --- helpers ---
 
-// matchLink matches a `[label](url)` link at the start of s.
-func matchLink(s string) (label, url string, n int, ok bool) {
-	closeB := strings.IndexByte(s, ']')
-	if closeB < 1 || closeB+1 >= len(s) || s[closeB+1] != '(' {
-		return "", "", 0, false
-	}
-	rest := s[closeB+2:]
-	closeP := strings.IndexByte(rest, ')')
-	if closeP < 0 {
-		return "", "", 0, false
+func decodeInline(raw json.RawMessage) ([]Inline, error) {
+	if len(raw) == 0 {
+		return nil, nil
 	}
-	return s[1:closeB], rest[:closeP], closeB + 2 + closeP + 1, true
-}
-
-func applyStyle(items []Inline, style string) []Inline {
-	for i := range items {
-		switch items[i].Type {
-		case "text":
-			if items[i].Styles == nil {
-				items[i].Styles = map[string]any{}
-			}
-			items[i].Styles[style] = true
-		case "link":
-			items[i].Content = applyStyle(items[i].Content, style)
-		}
+	var items []Inline
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
 	}
-	return items
+	return items, nil
 }
 
-// --- inline rendering ([]Inline -> Markdown) ---
-
-func renderInline(raw json.RawMessage) string {
+func plainText(raw json.RawMessage) string {
 	items, err := decodeInline(raw)
 	if err != nil {
 		return ""
 	}
-	return inlineToMarkdown(items)
+	return inlineText(items)
 }
 
-func inlineToMarkdown(items []Inline) string {
-	var sb strings.Builder
+// inlineText concatenates the text of items, descending into links.
+func inlineText(items []Inline) string {
+	var n int
 	for _, it := range items {
-		switch it.Type {
-		case "text":
-			sb.WriteString(styleWrap(it.Text, it.Styles))
-		case "link":
-			sb.WriteString("[" + inlineToMarkdown(it.Content) + "](" + it.Href + ")")
-		}
-	}
-	return sb.String()
-}
-
-// styleWrap wraps text with Markdown markers. Order matters for round-tripping:
-// code innermost, then strike, italic, and bold outermost.
-func styleWrap(text string, styles map[string]any) string {
-	t := text
-	if boolFrom(styles, blocktype.StyleCode) {
-		t = "`" + t + "`"
-	}
-	if boolFrom(styles, blocktype.StyleStrike) {
-		t = "~~" + t + "~~"
-	}
-	if boolFrom(styles, blocktype.StyleItalic) {
-		t = "_" + t + "_"
-	}
-	if boolFrom(styles, blocktype.StyleBold) {
-		t = "**" + t + "**"
-	}
-	return t
-}
-
-func plainText(raw json.RawMessage) string {
-	items, err := decodeInline(raw)
-	if err != nil {
-		return ""
+		n += len(it.Text)
 	}
-	var sb strings.Builder
+	buf := make([]byte, 0, n)
 	var walk func([]Inline)
 	walk = func(items []Inline) {
 		for _, it := range items {
-			if it.Type == "text" {
-				sb.WriteString(it.Text)
+			if it.Type == blocktype.InlineText {
+				buf = append(buf, it.Text...)
 			}
 			if len(it.Content) > 0 {
 				walk(it.Content)
@@ -543,48 +243,7 @@ func plainText(raw json.RawMessage) string {
 		}
 	}
 	walk(items)
-	return sb.String()
-}
-
-func renderTable(b *strings.Builder, indent string, raw json.RawMessage) {
-	var tc struct {
-		Rows []struct {
-			Cells []struct {
-				Content []Inline `json:"content"`
-			} `json:"cells"`
-		} `json:"rows"`
-	}
-	if err := json.Unmarshal(raw, &tc); err != nil || len(tc.Rows) == 0 {
-		return
-	}
-	for ri, row := range tc.Rows {
-		cells := make([]string, 0, len(row.Cells))
-		for _, c := range row.Cells {
-			cells = append(cells, inlineToMarkdown(c.Content))
-		}
-		fmt.Fprintf(b, "%s| %s |\n", indent, strings.Join(cells, " | "))
-		if ri == 0 {
-			seps := make([]string, len(cells))
-			for i := range seps {
-				seps[i] = "---"
-			}
-			fmt.Fprintf(b, "%s| %s |\n", indent, strings.Join(seps, " | "))
-		}
-	}
-	b.WriteString("\n")
-}
-
-// --- helpers ---
-
-func decodeInline(raw json.RawMessage) ([]Inline, error) {
-	if len(raw) == 0 {
-		return nil, nil
-	}
-	var items []Inline
-	if err := json.Unmarshal(raw, &items); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return string(buf)
 }
 
 func propInt(props map[string]any, key string, def int) int {
@@ -0,0 +1,141 @@
+package blocknote
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// TestConformance parses each testdata/conformance/*.md input and compares the
+// blocks (IDs blanked) and their re-rendered Markdown against golden files.
+// The rendered Markdown must parse back to the very same blocks.
+func TestConformance(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/conformance/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".golden.md") {
+			continue
+		}
+		base := strings.TrimSuffix(input, ".md")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocks := MarkdownToBlocks(string(src))
+			got := canonicalJSON(t, blocks, false)
+			checkGolden(t, base+".json", got)
+
+			md := BlocksToMarkdown(blocks)
+			checkGolden(t, base+".golden.md", md+"\n")
+
+			if again := canonicalJSON(t, MarkdownToBlocks(md), false); again != got {
+				t.Errorf("rendered Markdown does not parse back to the same blocks:\n--- rendered ---\n%s\n--- reparsed ---\n%s", md, again)
+			}
+		})
+	}
+}
+
+// TestRoundTripLossless renders each testdata/roundtrip/*.json document, as
+// the editor saves it, to Markdown (compared against a golden file) and
+// checks that parsing that Markdown restores the document. Block IDs and
+// props holding BlockNote defaults are not compared, and the trailing empty
+// paragraph the editor keeps is not part of the Markdown.
+func TestRoundTripLossless(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/roundtrip/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		base := strings.TrimSuffix(input, ".json")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var blocks []Block
+			if err := json.Unmarshal(data, &blocks); err != nil {
+				t.Fatal(err)
+			}
+
+			md := BlocksToMarkdown(blocks)
+			checkGolden(t, base+".golden.md", md+"\n")
+
+			for len(blocks) > 0 && isEmptyParagraph(blocks[len(blocks)-1]) {
+				blocks = blocks[:len(blocks)-1]
+			}
+			want := canonicalJSON(t, blocks, true)
+			if got := canonicalJSON(t, MarkdownToBlocks(md), true); got != want {
+				t.Errorf("round trip changed the document\n--- markdown ---\n%s\n--- got ---\n%s\n--- want ---\n%s", md, got, want)
+			}
+		})
+	}
+}
+
+// canonicalJSON marshals blocks for comparison with IDs removed and, when
+// dropDefaults is set, without props holding BlockNote's default values.
+func canonicalJSON(t *testing.T, blocks []Block, dropDefaults bool) string {
+	t.Helper()
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	canonicalize(v, dropDefaults)
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out) + "\n"
+}
+
+func canonicalize(v any, dropDefaults bool) {
+	switch val := v.(type) {
+	case []any:
+		for _, item := range val {
+			canonicalize(item, dropDefaults)
+		}
+	case map[string]any:
+		delete(val, "id")
+		if props, ok := val["props"].(map[string]any); ok && dropDefaults {
+			for k, def := range defaultProps {
+				if props[k] == def {
+					delete(props, k)
+				}
+			}
+			if len(props) == 0 && val["type"] != "tableCell" {
+				delete(val, "props")
+			}
+		}
+		for _, item := range val {
+			canonicalize(item, dropDefaults)
+		}
+	}
+}
+
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run go test -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch (run go test -update to accept):\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
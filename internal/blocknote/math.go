@@ -0,0 +1,96 @@
+package blocknote
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mathBlock is a display-math block: LaTeX between lines holding only "$$",
+// or "$$ ... $$" on a single line.
+type mathBlock struct {
+	ast.BaseBlock
+	indent int
+	// closed is set for the single-line form, which needs no closing line.
+	closed bool
+}
+
+var kindMathBlock = ast.NewNodeKind("MathBlock")
+
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlock) IsRaw() bool { return true }
+
+func (n *mathBlock) Dump(src []byte, level int) { ast.DumpHelper(n, src, level, nil, nil) }
+
+var mathFence = []byte("$$")
+
+// mathBlockParser parses $$ blocks. It is modelled on goldmark's fenced code
+// block parser, with "$$" as the only fence.
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathFence) {
+		return nil, parser.NoChildren
+	}
+	node := &mathBlock{indent: pos}
+	rest := line[pos+len(mathFence):]
+	if util.IsBlank(rest) {
+		return node, parser.NoChildren
+	}
+
+	// $$ ... $$ on one line.
+	left, right := util.TrimLeftSpaceLength(rest), util.TrimRightSpaceLength(rest)
+	inner := rest[left : len(rest)-right]
+	if len(inner) < len(mathFence) || !bytes.HasSuffix(inner, mathFence) {
+		return nil, parser.NoChildren
+	}
+	body := inner[:len(inner)-len(mathFence)]
+	start := segment.Start - segment.Padding + pos + len(mathFence) + left
+	stop := start + len(body) - util.TrimRightSpaceLength(body)
+	node.Lines().Append(text.NewSegment(start, stop))
+	node.closed = true
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*mathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+
+	w, pos := util.IndentWidth(line, reader.LineOffset())
+	if w < 4 && bytes.Equal(util.TrimRightSpace(line[pos:]), mathFence) {
+		newline := 1
+		if line[len(line)-1] != '\n' {
+			newline = 0
+		}
+		reader.Advance(segment.Stop - segment.Start - newline + segment.Padding)
+		return parser.Close
+	}
+
+	pos, padding := util.IndentPositionPadding(line, reader.LineOffset(), segment.Padding, n.indent)
+	if pos < 0 {
+		pos = max(0, util.FirstNonSpacePosition(line)) - segment.Padding
+		padding = 0
+	}
+	seg := text.NewSegmentPadding(segment.Start+pos, segment.Stop, padding)
+	seg.ForceNewline = true // EOF as newline
+	node.Lines().Append(seg)
+	reader.AdvanceAndSetPadding(segment.Stop-segment.Start-pos-1, padding)
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (mathBlockParser) CanAcceptIndentedLine() bool { return false }
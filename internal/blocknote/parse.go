@@ -0,0 +1,1109 @@
+package blocknote
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"yanta/internal/blocktype"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// markdown parses CommonMark with the GFM extensions, plus $$ math blocks.
+// Footnotes are wired up by hand rather than with extension.Footnote: its AST
+// transformer moves every definition to the end of the document and drops
+// unreferenced ones, and we want them kept where they were written.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(mathBlockParser{}, 701),
+			util.Prioritized(extension.NewFootnoteBlockParser(), 999),
+		),
+		parser.WithInlineParsers(util.Prioritized(extension.NewFootnoteParser(), 101)),
+	),
+)
+
+// pblock is a parsed block plus the number of following siblings its
+// attribute comment claims as children.
+type pblock struct {
+	Block
+	nest int
+}
+
+type mdParser struct {
+	src []byte
+	// footnotes maps goldmark's footnote indexes to their labels, so
+	// references can be written back as [^label].
+	footnotes map[int]string
+}
+
+func parseMarkdown(src []byte) []Block {
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	doc := markdown.Parser().Parse(text.NewReader(src))
+
+	p := &mdParser{src: src, footnotes: map[int]string{}}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if fn, ok := n.(*east.Footnote); ok && entering && fn.Index > 0 {
+			p.footnotes[fn.Index] = string(fn.Ref)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	blocks := p.siblings(doc.FirstChild())
+	if blocks == nil {
+		return []Block{}
+	}
+	return blocks
+}
+
+// siblings converts first and the nodes after it into blocks, resolving
+// attribute comments and the nesting they declare.
+func (p *mdParser) siblings(first ast.Node) []Block {
+	return p.siblingsUntil(first, nil)
+}
+
+// siblingsUntil is siblings for the nodes from first up to, but excluding,
+// stop.
+func (p *mdParser) siblingsUntil(first, stop ast.Node) []Block {
+	type entry struct {
+		blocks []pblock
+		offset int
+	}
+	var entries []entry
+	var pending *blockAttrs
+	footnotes := false
+
+	for n := first; n != nil && n != stop; n = n.NextSibling() {
+		if a, ok := p.attrsBlock(n); ok {
+			if next := n.NextSibling(); next != nil && !next.HasBlankPreviousLines() {
+				pending = a
+			} else {
+				entries = append(entries, entry{[]pblock{standaloneBlock(a)}, p.offset(n)})
+			}
+			continue
+		}
+
+		if list, ok := n.(*east.FootnoteList); ok {
+			// Definitions are grouped in one list where the first was
+			// written; put each back at its own position.
+			footnotes = true
+			for fn := list.FirstChild(); fn != nil; fn = fn.NextSibling() {
+				entries = append(entries, entry{p.footnote(fn.(*east.Footnote)), p.offset(fn)})
+			}
+			continue
+		}
+
+		offset := p.offset(n)
+		var blocks []pblock
+		if summary, ok := p.toggleOpen(n); ok {
+			// The toggle's children are the blocks up to the matching
+			// </details>; without one they run to the end.
+			closer, after := p.toggleClose(n, stop)
+			blocks = append([]pblock{p.toggle(summary, n.NextSibling(), closer)}, htmlToBlocks(after)...)
+			if closer == nil {
+				entries = append(entries, entry{attachAttrs(pending, blocks), offset})
+				pending = nil
+				break
+			}
+			n = closer
+		} else {
+			blocks = p.block(n)
+		}
+		if len(blocks) > 0 {
+			blocks = attachAttrs(pending, blocks)
+			pending = nil
+		}
+		entries = append(entries, entry{blocks, offset})
+	}
+
+	if footnotes {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+	}
+	var flat []pblock
+	for _, e := range entries {
+		flat = append(flat, e.blocks...)
+	}
+	return nest(flat)
+}
+
+// attachAttrs applies an attribute comment written on the line before a
+// block to the first of blocks.
+func attachAttrs(a *blockAttrs, blocks []pblock) []pblock {
+	if a == nil || len(blocks) == 0 {
+		return blocks
+	}
+	nb := applyAttrs(blocks[0].Block, a)
+	if blocks[0].nest > 0 && nb.nest == 0 {
+		nb.nest = blocks[0].nest
+	}
+	blocks[0] = nb
+	return blocks
+}
+
+// nest folds blocks into the parents whose attributes claim them.
+func nest(flat []pblock) []Block {
+	var out []Block
+	for i := 0; i < len(flat); {
+		var b Block
+		b, i = nestOne(flat, i)
+		out = append(out, b)
+	}
+	return out
+}
+
+func nestOne(flat []pblock, i int) (Block, int) {
+	b := flat[i].Block
+	next := i + 1
+	for k := 0; k < flat[i].nest && next < len(flat); k++ {
+		var child Block
+		child, next = nestOne(flat, next)
+		b.Children = append(b.Children, child)
+	}
+	return b, next
+}
+
+// offset returns the source position a block node starts at, or -1.
+func (p *mdParser) offset(n ast.Node) int {
+	for ; n != nil; n = n.FirstChild() {
+		if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+			return n.Lines().At(0).Start
+		}
+		if t, ok := n.(*ast.Text); ok {
+			return t.Segment.Start
+		}
+	}
+	return -1
+}
+
+func (p *mdParser) block(n ast.Node) []pblock {
+	switch n := n.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		return p.paragraph(n)
+
+	case *ast.Heading:
+		level := min(max(n.Level, 1), 3) // BlockNote headings support levels 1-3
+		items, a := p.inline(n)
+		return []pblock{applyAttrs(inlineBlock(blocktype.Heading, map[string]any{"level": level}, items), a)}
+
+	case *ast.ThematicBreak:
+		return []pblock{{Block: dividerBlock()}}
+
+	case *ast.FencedCodeBlock:
+		lang := string(n.Language(p.src))
+		if lang == blocktype.DiagramMermaid {
+			return []pblock{{Block: sourceBlock(blocktype.Diagram, map[string]any{"language": lang}, p.lines(n))}}
+		}
+		return []pblock{{Block: codeBlock(lang, p.lines(n))}}
+
+	case *mathBlock:
+		return []pblock{{Block: sourceBlock(blocktype.Math, nil, p.lines(n))}}
+
+	case *ast.CodeBlock:
+		return []pblock{{Block: codeBlock("", p.lines(n))}}
+
+	case *ast.Blockquote:
+		if variant, ok := p.calloutVariant(n); ok {
+			return []pblock{p.callout(n, variant)}
+		}
+		return []pblock{p.container(n, blocktype.Quote, nil)}
+
+	case *ast.List:
+		return p.list(n)
+
+	case *ast.HTMLBlock:
+		return htmlToBlocks(p.htmlText(n))
+
+	case *east.Table:
+		return []pblock{p.table(n)}
+
+	default:
+		var out []pblock
+		for _, b := range p.siblings(n.FirstChild()) {
+			out = append(out, pblock{Block: b})
+		}
+		return out
+	}
+}
+
+// paragraph converts a paragraph. Images standing directly in it become image
+// blocks of their own, splitting the text around them, and a paragraph that
+// is nothing but a link to a vault asset becomes a file block.
+func (p *mdParser) paragraph(n ast.Node) []pblock {
+	var out []pblock
+	var split bool
+	b := &inlineBuilder{}
+	flush := func() {
+		items := b.take()
+		if split {
+			// The spaces separating text from an image belong to neither.
+			items = trimInline(items)
+		}
+		if isBlank(items) {
+			return
+		}
+		out = append(out, pblock{Block: inlineBlock(blocktype.Paragraph, nil, items)})
+	}
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		img, ok := c.(*ast.Image)
+		if !ok {
+			p.inlineNode(b, c)
+			continue
+		}
+		split = true
+		flush()
+		alt, _ := p.inline(img)
+		out = append(out, pblock{Block: imageBlock(
+			unescape(string(img.Destination)),
+			inlineText(alt),
+			unescape(string(img.Title)),
+		)})
+	}
+	flush()
+
+	a := b.attrs
+	if len(out) == 0 {
+		if a == nil {
+			return nil
+		}
+		return []pblock{standaloneBlock(a)}
+	}
+
+	last := &out[len(out)-1]
+	if last.Type == blocktype.Paragraph && (a == nil || a.Type == "" || a.Type == blocktype.File) {
+		items, _ := decodeInline(last.Content)
+		if url, name, ok := fileLink(items, a != nil && a.Type == blocktype.File); ok {
+			last.Block = fileBlock(url, name)
+		}
+	}
+	*last = applyAttrs(last.Block, a)
+	return out
+}
+
+// fileLink reports whether items are a lone, unstyled link to a vault asset,
+// which is how file blocks are written. anyURL accepts links to any URL.
+func fileLink(items []Inline, anyURL bool) (url, name string, ok bool) {
+	if len(items) != 1 || items[0].Type != blocktype.InlineLink {
+		return "", "", false
+	}
+	link := items[0]
+	if !anyURL && !strings.HasPrefix(link.Href, "/assets/") {
+		return "", "", false
+	}
+	for _, it := range link.Content {
+		if it.Type != blocktype.InlineText || len(activeStyles(it.Styles)) > 0 {
+			return "", "", false
+		}
+	}
+	return link.Href, inlineText(link.Content), true
+}
+
+// container converts a block quote or list item: its leading paragraph is the
+// block's own content and everything after it becomes children.
+func (p *mdParser) container(n ast.Node, typ string, props map[string]any) pblock {
+	var items []Inline
+	var a *blockAttrs
+	rest := n.FirstChild()
+	switch first := rest.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		items, a = p.inline(first)
+		rest = first.NextSibling()
+	case *ast.HTMLBlock:
+		if attrs, ok := p.attrsBlock(first); ok {
+			if next := first.NextSibling(); next == nil || next.HasBlankPreviousLines() {
+				a, rest = attrs, next
+			}
+		}
+	}
+
+	b := inlineBlock(typ, props, items)
+	b.Children = p.siblings(rest)
+	return applyAttrs(b, a)
+}
+
+// calloutMarkerRe matches the first line of a GitHub alert, "[!NOTE]".
+var calloutMarkerRe = regexp.MustCompile(`^\[!([A-Za-z]+)\][ \t]*\n?$`)
+
+// calloutVariant reports whether q is a GitHub alert, a block quote whose
+// first line is a marker naming a callout variant.
+func (p *mdParser) calloutVariant(q *ast.Blockquote) (string, bool) {
+	first, ok := q.FirstChild().(*ast.Paragraph)
+	if !ok || first.Lines().Len() == 0 {
+		return "", false
+	}
+	line := first.Lines().At(0)
+	m := calloutMarkerRe.FindSubmatch(line.Value(p.src))
+	if m == nil {
+		return "", false
+	}
+	variant := strings.ToLower(string(m[1]))
+	return variant, blocktype.CalloutVariant(variant)
+}
+
+// callout converts a GitHub alert. The text after the marker line is the
+// callout's content and the blocks after its first paragraph are children.
+func (p *mdParser) callout(q *ast.Blockquote, variant string) pblock {
+	first := q.FirstChild()
+	markerEnd := first.Lines().At(0).Stop
+
+	b := &inlineBuilder{}
+	for c := first.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok && t.Segment.Start < markerEnd {
+			continue
+		}
+		p.inlineNode(b, c)
+	}
+	items, a := b.take(), b.attrs
+
+	// An attribute comment on the line after the marker is an HTML block of
+	// its own.
+	rest := first.NextSibling()
+	if attrs, ok := p.attrsBlock(rest); ok && a == nil {
+		if next := rest.NextSibling(); next == nil || next.HasBlankPreviousLines() {
+			a, rest = attrs, next
+		}
+	}
+
+	blk := inlineBlock(blocktype.Callout, map[string]any{"variant": variant}, items)
+	blk.Children = p.siblings(rest)
+	return applyAttrs(blk, a)
+}
+
+var (
+	// toggleOpenRe matches the HTML block opening a toggle: <details> and a
+	// <summary>, optionally followed by an attribute comment. Attribute
+	// JSON escapes <, so the last </summary> is the real one.
+	toggleOpenRe  = regexp.MustCompile(`(?is)^<details(?:\s[^>]*)?>\s*<summary(?:\s[^>]*)?>(.*)</summary>[ \t]*(<!--.*-->)?\s*$`)
+	toggleCloseRe = regexp.MustCompile(`(?i)^</details\s*>`)
+)
+
+// toggleOpen reports whether n is the HTML block opening a toggle and returns
+// its summary, with the attribute comment if there is one.
+func (p *mdParser) toggleOpen(n ast.Node) (string, bool) {
+	h, ok := n.(*ast.HTMLBlock)
+	if !ok {
+		return "", false
+	}
+	m := toggleOpenRe.FindStringSubmatch(p.htmlText(h))
+	if m == nil {
+		return "", false
+	}
+	return strings.TrimSpace(m[1] + " " + m[2]), true
+}
+
+// toggleClose finds the HTML block closing the toggle opened by open,
+// skipping nested toggles. It also returns any HTML that follows the closing
+// tag in that block.
+func (p *mdParser) toggleClose(open, stop ast.Node) (ast.Node, string) {
+	depth := 0
+	for n := open.NextSibling(); n != nil && n != stop; n = n.NextSibling() {
+		if _, ok := p.toggleOpen(n); ok {
+			depth++
+			continue
+		}
+		h, ok := n.(*ast.HTMLBlock)
+		if !ok {
+			continue
+		}
+		s := p.htmlText(h)
+		loc := toggleCloseRe.FindStringIndex(s)
+		if loc == nil {
+			continue
+		}
+		if depth == 0 {
+			return n, s[loc[1]:]
+		}
+		depth--
+	}
+	return nil, ""
+}
+
+// toggle builds a toggle from its summary and the nodes from first up to
+// closer, which are its children.
+func (p *mdParser) toggle(summary string, first, closer ast.Node) pblock {
+	items, a := p.inlineFragment(summary)
+	b := inlineBlock(blocktype.Toggle, nil, items)
+	b.Children = p.siblingsUntil(first, closer)
+	return applyAttrs(b, a)
+}
+
+// inlineFragment parses s, Markdown embedded in HTML such as a toggle
+// summary, as inline content.
+func (p *mdParser) inlineFragment(s string) ([]Inline, *blockAttrs) {
+	if a, ok := parseAttrs(s); ok {
+		return nil, a
+	}
+	src := []byte(s)
+	doc := markdown.Parser().Parse(text.NewReader(src))
+	first, ok := doc.FirstChild().(*ast.Paragraph)
+	if !ok || first.NextSibling() != nil {
+		if s == "" {
+			return nil, nil
+		}
+		return []Inline{textInline(html.UnescapeString(s), nil)}, nil
+	}
+	sub := &mdParser{src: src, footnotes: p.footnotes}
+	return sub.inline(first)
+}
+
+func (p *mdParser) list(l *ast.List) []pblock {
+	var out []pblock
+	for item := l.FirstChild(); item != nil; item = item.NextSibling() {
+		typ := blocktype.BulletListItem
+		var props map[string]any
+		switch {
+		case l.IsOrdered():
+			typ = blocktype.NumberedListItem
+			if item == l.FirstChild() && l.Start != 1 {
+				props = map[string]any{"start": l.Start}
+			}
+		default:
+			if box := taskCheckBox(item); box != nil {
+				typ = blocktype.CheckListItem
+				props = map[string]any{"checked": box.IsChecked}
+			}
+		}
+		out = append(out, p.container(item, typ, props))
+	}
+	return out
+}
+
+func taskCheckBox(item ast.Node) *east.TaskCheckBox {
+	if first := item.FirstChild(); first != nil {
+		if box, ok := first.FirstChild().(*east.TaskCheckBox); ok {
+			return box
+		}
+	}
+	return nil
+}
+
+func (p *mdParser) table(t *east.Table) pblock {
+	var rows [][]tableCell
+	for r := t.FirstChild(); r != nil; r = r.NextSibling() {
+		var cells []tableCell
+		for c := r.FirstChild(); c != nil; c = c.NextSibling() {
+			cell, ok := c.(*east.TableCell)
+			if !ok {
+				continue
+			}
+			items, _ := p.inline(cell)
+			props := map[string]any{}
+			if align := alignmentName(cell.Alignment); align != "" {
+				props["textAlignment"] = align
+			}
+			cells = append(cells, tableCell{Content: items, Props: props})
+		}
+		rows = append(rows, cells)
+	}
+	return pblock{Block: tableBlock(rows)}
+}
+
+func alignmentName(a east.Alignment) string {
+	switch a {
+	case east.AlignLeft:
+		return "left"
+	case east.AlignCenter:
+		return "center"
+	case east.AlignRight:
+		return "right"
+	}
+	return ""
+}
+
+// footnote converts a footnote definition into its blocks, with the
+// "[^label]: " marker leading the first paragraph.
+func (p *mdParser) footnote(fn *east.Footnote) []pblock {
+	marker := "[^" + string(fn.Ref) + "]:"
+	blocks := p.siblings(fn.FirstChild())
+
+	var out []pblock
+	if len(blocks) > 0 && blocks[0].Type == blocktype.Paragraph {
+		items, _ := decodeInline(blocks[0].Content)
+		blocks[0].Content = marshalInline(appendInline([]Inline{textInline(marker+" ", nil)}, items...))
+	} else {
+		out = append(out, pblock{Block: inlineBlock(blocktype.Paragraph, nil, []Inline{textInline(marker, nil)})})
+	}
+	for _, b := range blocks {
+		out = append(out, pblock{Block: b})
+	}
+	return out
+}
+
+func (p *mdParser) lines(n ast.Node) string {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		buf.Write(seg.Value(p.src))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (p *mdParser) htmlText(n *ast.HTMLBlock) string {
+	s := p.lines(n)
+	if n.HasClosure() {
+		s += "\n" + string(n.ClosureLine.Value(p.src))
+	}
+	return s
+}
+
+// attrsBlock reports whether n is an HTML block holding only an attribute
+// comment.
+func (p *mdParser) attrsBlock(n ast.Node) (*blockAttrs, bool) {
+	h, ok := n.(*ast.HTMLBlock)
+	if !ok {
+		return nil, false
+	}
+	return parseAttrs(p.htmlText(h))
+}
+
+// --- inline content ---
+
+// inline converts the inline children of n.
+func (p *mdParser) inline(n ast.Node) ([]Inline, *blockAttrs) {
+	b := &inlineBuilder{}
+	p.inlineChildren(b, n)
+	return b.take(), b.attrs
+}
+
+func (p *mdParser) inlineChildren(b *inlineBuilder, n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		p.inlineNode(b, c)
+	}
+}
+
+func (p *mdParser) inlineNode(b *inlineBuilder, n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Text:
+		s := string(n.Segment.Value(p.src))
+		if !n.IsRaw() {
+			s = unescape(s)
+		}
+		b.text(s)
+		switch {
+		case n.HardLineBreak():
+			b.text("\n")
+		case n.SoftLineBreak():
+			b.text(" ")
+		}
+
+	case *ast.String:
+		s := string(n.Value)
+		if !n.IsRaw() && !n.IsCode() {
+			s = unescape(s)
+		}
+		b.text(s)
+
+	case *ast.CodeSpan:
+		var sb strings.Builder
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			switch t := c.(type) {
+			case *ast.Text:
+				sb.Write(t.Segment.Value(p.src))
+			case *ast.String:
+				sb.Write(t.Value)
+			}
+		}
+		m := b.push("", map[string]any{blocktype.StyleCode: true})
+		b.text(strings.ReplaceAll(sb.String(), "\n", " "))
+		b.remove(m)
+
+	case *ast.Emphasis:
+		style := blocktype.StyleItalic
+		if n.Level >= 2 {
+			style = blocktype.StyleBold
+		}
+		m := b.push("", map[string]any{style: true})
+		p.inlineChildren(b, n)
+		b.remove(m)
+
+	case *east.Strikethrough:
+		m := b.push("", map[string]any{blocktype.StyleStrike: true})
+		p.inlineChildren(b, n)
+		b.remove(m)
+
+	case *ast.Link:
+		b.openLink(unescape(string(n.Destination)))
+		p.inlineChildren(b, n)
+		b.closeLink()
+
+	case *ast.AutoLink:
+		href := string(n.URL(p.src))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(href), "mailto:") {
+			href = "mailto:" + href
+		}
+		b.openLink(href)
+		b.text(string(n.Label(p.src)))
+		b.closeLink()
+
+	case *ast.Image:
+		// Inline content cannot hold images; keep it as a link to the
+		// image so nothing is lost.
+		dest := unescape(string(n.Destination))
+		b.openLink(dest)
+		p.inlineChildren(b, n)
+		if b.link != nil && len(b.link.Content) == 0 {
+			b.text(dest)
+		}
+		b.closeLink()
+
+	case *ast.RawHTML:
+		var sb strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			sb.Write(seg.Value(p.src))
+		}
+		b.html(sb.String())
+
+	case *east.FootnoteLink:
+		b.text("[^" + p.footnotes[n.Index] + "]")
+
+	case *east.TaskCheckBox:
+		// The list item type carries it.
+
+	default:
+		p.inlineChildren(b, n)
+	}
+}
+
+// inlineBuilder accumulates inline content while tracking the styles opened
+// by Markdown emphasis and by inline HTML tags.
+type inlineBuilder struct {
+	items []Inline
+	marks []*mark
+	link  *Inline
+	attrs *blockAttrs
+}
+
+type mark struct {
+	tag    string
+	styles map[string]any
+}
+
+func (b *inlineBuilder) push(tag string, styles map[string]any) *mark {
+	m := &mark{tag: tag, styles: styles}
+	b.marks = append(b.marks, m)
+	return m
+}
+
+func (b *inlineBuilder) remove(m *mark) {
+	for i := len(b.marks) - 1; i >= 0; i-- {
+		if b.marks[i] == m {
+			b.marks = append(b.marks[:i], b.marks[i+1:]...)
+			return
+		}
+	}
+}
+
+// pop closes the innermost mark opened by an HTML tag.
+func (b *inlineBuilder) pop(tag string) {
+	for i := len(b.marks) - 1; i >= 0; i-- {
+		if b.marks[i].tag == tag {
+			b.marks = append(b.marks[:i], b.marks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *inlineBuilder) styles() map[string]any {
+	styles := map[string]any{}
+	for _, m := range b.marks {
+		for k, v := range m.styles {
+			styles[k] = v
+		}
+	}
+	return styles
+}
+
+func (b *inlineBuilder) text(s string) {
+	if s == "" {
+		return
+	}
+	it := textInline(s, b.styles())
+	if b.link != nil {
+		b.link.Content = appendInline(b.link.Content, it)
+	} else {
+		b.items = appendInline(b.items, it)
+	}
+}
+
+func (b *inlineBuilder) openLink(href string) {
+	b.closeLink()
+	b.link = &Inline{Type: blocktype.InlineLink, Href: href, Styles: map[string]any{}}
+}
+
+func (b *inlineBuilder) closeLink() {
+	if b.link == nil {
+		return
+	}
+	b.items = append(b.items, *b.link)
+	b.link = nil
+}
+
+// take returns the content built so far and resets the builder's content.
+func (b *inlineBuilder) take() []Inline {
+	b.closeLink()
+	items := b.items
+	b.items = nil
+	if items == nil {
+		items = []Inline{}
+	}
+	return items
+}
+
+// setAttrs records an attribute comment, dropping the space written before
+// it.
+func (b *inlineBuilder) setAttrs(a *blockAttrs) {
+	b.attrs = a
+	items := b.items
+	if b.link != nil {
+		return
+	}
+	if n := len(items); n > 0 && items[n-1].Type == blocktype.InlineText {
+		items[n-1].Text = strings.TrimSuffix(items[n-1].Text, " ")
+		if items[n-1].Text == "" {
+			b.items = items[:n-1]
+		}
+	}
+}
+
+// html applies an inline HTML tag: the formatting tags BlockNote styles map
+// to, <br>, <a href> and attribute comments. Other tags are dropped.
+func (b *inlineBuilder) html(raw string) {
+	if a, ok := parseAttrs(raw); ok {
+		b.setAttrs(a)
+		return
+	}
+	t, ok := parseTag(raw)
+	if !ok {
+		return
+	}
+	style := ""
+	switch t.name {
+	case "b", "strong":
+		style = blocktype.StyleBold
+	case "i", "em":
+		style = blocktype.StyleItalic
+	case "u", "ins":
+		style = blocktype.StyleUnderline
+	case "s", "del", "strike":
+		style = blocktype.StyleStrike
+	case "code":
+		style = blocktype.StyleCode
+	case "span":
+		if t.closing {
+			b.pop(t.name)
+		} else {
+			b.push(t.name, spanStyles(t.attrs))
+		}
+		return
+	case "br":
+		b.text("\n")
+		return
+	case "a":
+		if t.closing {
+			b.closeLink()
+		} else if href, ok := t.attrs["href"]; ok {
+			b.openLink(href)
+		}
+		return
+	default:
+		return
+	}
+	if t.closing {
+		b.pop(t.name)
+	} else if !t.selfClosing {
+		b.push(t.name, map[string]any{style: true})
+	}
+}
+
+// spanStyles reads text and background colors from a span, either from the
+// data attributes the renderer writes or from inline CSS.
+func spanStyles(attrs map[string]string) map[string]any {
+	styles := map[string]any{}
+	if v := attrs["data-text-color"]; v != "" {
+		styles[blocktype.StyleTextColor] = v
+	}
+	if v := attrs["data-background-color"]; v != "" {
+		styles[blocktype.StyleBackgroundColor] = v
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		prop, val, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch strings.ToLower(strings.TrimSpace(prop)) {
+		case "color":
+			styles[blocktype.StyleTextColor] = val
+		case "background-color", "background":
+			styles[blocktype.StyleBackgroundColor] = val
+		}
+	}
+	return styles
+}
+
+// appendInline appends items, merging adjacent text runs with equal styles.
+func appendInline(list []Inline, items ...Inline) []Inline {
+	for _, it := range items {
+		if n := len(list); n > 0 && it.Type == blocktype.InlineText && list[n-1].Type == blocktype.InlineText &&
+			sameStyles(list[n-1].Styles, it.Styles) {
+			list[n-1].Text += it.Text
+			continue
+		}
+		list = append(list, it)
+	}
+	return list
+}
+
+// activeStyles returns the styles that are actually set.
+func activeStyles(styles map[string]any) map[string]any {
+	out := map[string]any{}
+	for k, v := range styles {
+		switch val := v.(type) {
+		case bool:
+			if val {
+				out[k] = true
+			}
+		case string:
+			if val != "" && val != "default" {
+				out[k] = val
+			}
+		case nil:
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func sameStyles(a, b map[string]any) bool {
+	a, b = activeStyles(a), activeStyles(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func isBlank(items []Inline) bool {
+	for _, it := range items {
+		if it.Type != blocktype.InlineText || strings.TrimSpace(it.Text) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+var unescapeRe = regexp.MustCompile("\\\\[!-/:-@\\[-`{-~]|&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});")
+
+// unescape resolves backslash escapes and entity references in one pass, as
+// CommonMark does for text, link destinations and titles.
+func unescape(s string) string {
+	if !strings.ContainsAny(s, `\&`) {
+		return s
+	}
+	return unescapeRe.ReplaceAllStringFunc(s, func(m string) string {
+		if m[0] == '\\' {
+			return m[1:]
+		}
+		return html.UnescapeString(m)
+	})
+}
+
+// --- HTML ---
+
+type htmlTag struct {
+	name        string
+	closing     bool
+	selfClosing bool
+	attrs       map[string]string
+}
+
+var (
+	tagRe       = regexp.MustCompile(`^<(/?)([A-Za-z][A-Za-z0-9-]*)((?:\s+[^\s"'>/=]+(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+))?)*)\s*(/?)>$`)
+	tagAttrRe   = regexp.MustCompile(`([^\s"'>/=]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+)))?`)
+	htmlTokRe   = regexp.MustCompile(`(?s)<!--.*?-->|</?[A-Za-z][^>]*>`)
+	htmlSpaceRe = regexp.MustCompile(`\s+`)
+)
+
+func parseTag(raw string) (htmlTag, bool) {
+	m := tagRe.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return htmlTag{}, false
+	}
+	t := htmlTag{
+		name:        strings.ToLower(m[2]),
+		closing:     m[1] == "/",
+		selfClosing: m[4] == "/",
+		attrs:       map[string]string{},
+	}
+	for _, am := range tagAttrRe.FindAllStringSubmatch(m[3], -1) {
+		t.attrs[strings.ToLower(am[1])] = html.UnescapeString(am[2] + am[3] + am[4])
+	}
+	return t, true
+}
+
+// htmlToBlocks converts an HTML block into blocks: paragraphs, headings and
+// list items for the common block tags, images, and <pre> as code. Inline
+// formatting is kept as styles; anything else is reduced to its text.
+func htmlToBlocks(s string) []pblock {
+	var out []pblock
+	b := &inlineBuilder{}
+	typ := blocktype.Paragraph
+	var props map[string]any
+	flush := func() {
+		items := trimInline(b.take())
+		if !isBlank(items) {
+			out = append(out, pblock{Block: inlineBlock(typ, props, items)})
+		}
+		typ, props = blocktype.Paragraph, nil
+	}
+
+	var skip string
+	var pre *strings.Builder
+	// toggles holds, for each open <details>, the index of its toggle in
+	// out (-1 until its <summary> has been read).
+	var toggles []int
+	emitText := func(raw string) {
+		switch {
+		case skip != "":
+		case pre != nil:
+			pre.WriteString(html.UnescapeString(raw))
+		default:
+			b.text(htmlSpaceRe.ReplaceAllString(html.UnescapeString(raw), " "))
+		}
+	}
+
+	last := 0
+	for _, loc := range htmlTokRe.FindAllStringIndex(s, -1) {
+		emitText(s[last:loc[0]])
+		last = loc[1]
+		raw := s[loc[0]:loc[1]]
+		t, ok := parseTag(raw)
+		if !ok {
+			continue
+		}
+		if skip != "" {
+			if t.closing && t.name == skip {
+				skip = ""
+			}
+			continue
+		}
+		switch t.name {
+		case "script", "style":
+			if !t.closing {
+				skip = t.name
+			}
+		case "pre":
+			if !t.closing {
+				flush()
+				pre = &strings.Builder{}
+			} else if pre != nil {
+				out = append(out, pblock{Block: codeBlock("", strings.TrimSuffix(strings.TrimPrefix(pre.String(), "\n"), "\n"))})
+				pre = nil
+			}
+		case "p", "div", "section", "article", "header", "footer", "ul", "ol", "table", "tr", "td", "th", "li",
+			"blockquote", "h1", "h2", "h3", "h4", "h5", "h6":
+			if pre != nil {
+				continue
+			}
+			flush()
+			if t.closing {
+				continue
+			}
+			switch t.name {
+			case "li":
+				typ = blocktype.BulletListItem
+			case "blockquote":
+				typ = blocktype.Quote
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				typ = blocktype.Heading
+				props = map[string]any{"level": min(int(t.name[1]-'0'), 3)}
+			}
+		case "details":
+			// A toggle's children are the blocks up to </details>.
+			if pre != nil {
+				continue
+			}
+			flush()
+			if !t.closing {
+				toggles = append(toggles, -1)
+			} else if n := len(toggles); n > 0 {
+				if i := toggles[n-1]; i >= 0 {
+					for j := i + 1; j < len(out); j = subtreeEnd(out, j) {
+						out[i].nest++
+					}
+				}
+				toggles = toggles[:n-1]
+			}
+		case "summary":
+			if pre != nil {
+				continue
+			}
+			if !t.closing {
+				flush()
+				continue
+			}
+			out = append(out, pblock{Block: inlineBlock(blocktype.Toggle, nil, trimInline(b.take()))})
+			if n := len(toggles); n > 0 && toggles[n-1] < 0 {
+				toggles[n-1] = len(out) - 1
+			}
+		case "img":
+			flush()
+			out = append(out, pblock{Block: imageBlock(t.attrs["src"], t.attrs["alt"], t.attrs["title"])})
+		default:
+			if pre == nil {
+				b.html(raw)
+			}
+		}
+	}
+	emitText(s[last:])
+	if pre != nil {
+		out = append(out, pblock{Block: codeBlock("", strings.TrimPrefix(pre.String(), "\n"))})
+	}
+	flush()
+	return out
+}
+
+// subtreeEnd returns the index just past flat[i] and the blocks it nests.
+func subtreeEnd(flat []pblock, i int) int {
+	next := i + 1
+	for k := 0; k < flat[i].nest && next < len(flat); k++ {
+		next = subtreeEnd(flat, next)
+	}
+	return next
+}
+
+// trimInline trims whitespace from both ends of inline content.
+func trimInline(items []Inline) []Inline {
+	for len(items) > 0 && items[0].Type == blocktype.InlineText {
+		items[0].Text = strings.TrimLeft(items[0].Text, " \t\n")
+		if items[0].Text != "" {
+			break
+		}
+		items = items[1:]
+	}
+	for n := len(items); n > 0 && items[n-1].Type == blocktype.InlineText; n = len(items) {
+		items[n-1].Text = strings.TrimRight(items[n-1].Text, " \t\n")
+		if items[n-1].Text != "" {
+			break
+		}
+		items = items[:n-1]
+	}
+	return items
+}
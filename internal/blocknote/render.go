@@ -0,0 +1,982 @@
+package blocknote
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"yanta/internal/blocktype"
+)
+
+// unit is a block as written at one Markdown nesting level. Blocks whose
+// children Markdown cannot nest are followed by those children, and nest
+// records how many of the following units belong to them.
+type unit struct {
+	Block
+	nest int
+}
+
+func renderDocument(blocks []Block) string {
+	// The editor always keeps an empty paragraph at the end of a document;
+	// it is not content.
+	for len(blocks) > 0 && isEmptyParagraph(blocks[len(blocks)-1]) {
+		blocks = blocks[:len(blocks)-1]
+	}
+	return renderBlocks(blocks)
+}
+
+func isEmptyParagraph(b Block) bool {
+	if b.Type != blocktype.Paragraph || len(b.Children) > 0 || len(extraProps(b)) > 0 {
+		return false
+	}
+	items, err := decodeInline(b.Content)
+	return err == nil && len(items) == 0
+}
+
+// nestsNatively reports whether Markdown can nest children under blocks of
+// typ by indentation.
+func nestsNatively(typ string) bool {
+	switch typ {
+	case blocktype.BulletListItem, blocktype.NumberedListItem, blocktype.CheckListItem, blocktype.Quote,
+		blocktype.Callout, blocktype.Toggle:
+		return true
+	}
+	return false
+}
+
+func flattenUnits(blocks []Block) []unit {
+	var out []unit
+	for _, b := range blocks {
+		if nestsNatively(b.Type) || len(b.Children) == 0 {
+			out = append(out, unit{Block: b})
+			continue
+		}
+		out = append(out, unit{Block: b, nest: len(b.Children)})
+		out = append(out, flattenUnits(b.Children)...)
+	}
+	return out
+}
+
+func renderBlocks(blocks []Block) string {
+	var sb strings.Builder
+	var prev string
+	num := 0
+	for i, u := range flattenUnits(blocks) {
+		runStart := false
+		if u.Type == blocktype.NumberedListItem {
+			start, hasStart := startProp(u.Props)
+			switch {
+			case prev != blocktype.NumberedListItem:
+				runStart = true
+				num = 1
+				if hasStart {
+					num = start
+				}
+			case hasStart:
+				num = start
+			default:
+				num++
+			}
+		}
+		if i > 0 {
+			sb.WriteString(separator(prev, u.Type))
+		}
+		sb.WriteString(renderUnit(u, num, runStart))
+		prev = u.Type
+	}
+	return sb.String()
+}
+
+func startProp(props map[string]any) (int, bool) {
+	switch props["start"].(type) {
+	case float64, int:
+		return propInt(props, "start", 1), true
+	}
+	return 0, false
+}
+
+// separator returns what goes between two consecutive blocks: items of the
+// same list sit on adjacent lines, everything else is blank-line separated.
+func separator(prev, next string) string {
+	if g := listGroup(prev); g != 0 && g == listGroup(next) {
+		return "\n"
+	}
+	return "\n\n"
+}
+
+func listGroup(typ string) int {
+	switch typ {
+	case blocktype.BulletListItem, blocktype.CheckListItem:
+		return 1
+	case blocktype.NumberedListItem:
+		return 2
+	}
+	return 0
+}
+
+func renderUnit(u unit, num int, runStart bool) string {
+	b := u.Block
+	items, err := decodeInline(b.Content)
+	if err != nil && hasInlineContent(b.Type) {
+		return renderUnknown(u)
+	}
+
+	switch b.Type {
+	case blocktype.Paragraph:
+		a := &blockAttrs{Props: extraProps(b), Children: u.nest}
+		if _, _, ok := fileLink(items, false); ok {
+			a.Type = blocktype.Paragraph
+		}
+		line := renderInline(items, ctxParagraph)
+		if line == "" {
+			a.Type = blocktype.Paragraph
+			return a.comment()
+		}
+		return line + trailing(a)
+
+	case blocktype.Heading:
+		level := propInt(b.Props, "level", 1)
+		native := []string{"level"}
+		if level < 1 || level > 3 {
+			native = nil
+			level = min(max(level, 1), 3)
+		}
+		a := &blockAttrs{Props: extraProps(b, native...), Children: u.nest}
+		marker := strings.Repeat("#", level)
+		line := renderInline(items, ctxHeading)
+		if strings.HasSuffix(line, "#") {
+			line = line[:len(line)-1] + `\#` // a trailing # would close the heading
+		}
+		switch {
+		case line != "":
+			return marker + " " + line + trailing(a)
+		case !a.empty():
+			return marker + " " + a.comment()
+		}
+		return marker
+
+	case blocktype.BulletListItem, blocktype.NumberedListItem, blocktype.CheckListItem:
+		return renderListItem(u, items, num, runStart)
+
+	case blocktype.Quote:
+		a := &blockAttrs{Props: extraProps(b)}
+		line := renderInline(items, ctxBlock)
+		children := renderBlocks(b.Children)
+		s := line + trailing(a)
+		switch {
+		case line != "":
+		case a.empty() && children != "" && !opensWithParagraph(b.Children):
+			return prefixLines(children, "> ", ">")
+		case children != "" || !a.empty():
+			s = a.comment()
+		}
+		if children != "" {
+			s += "\n\n" + children
+		}
+		return prefixLines(s, "> ", ">")
+
+	case blocktype.Callout:
+		return renderCallout(b, items)
+
+	case blocktype.Toggle:
+		a := &blockAttrs{Props: extraProps(b)}
+		s := "<details>\n<summary>" + renderInline(items, ctxHeading) + "</summary>" + trailing(a) + "\n\n"
+		if children := renderBlocks(b.Children); children != "" {
+			s += children + "\n\n"
+		}
+		return s + "</details>"
+
+	case blocktype.Math:
+		a := &blockAttrs{Props: extraProps(b), Children: u.nest}
+		src := inlineText(items)
+		for _, line := range strings.Split(src, "\n") {
+			if strings.TrimSpace(line) == "$$" {
+				// The line would close the block early; keep the source
+				// in the attribute comment instead.
+				a.Type, a.Content = b.Type, b.Content
+				return a.comment()
+			}
+		}
+		if src != "" {
+			src += "\n"
+		}
+		return leading(a) + "$$\n" + src + "$$"
+
+	case blocktype.Diagram:
+		var a *blockAttrs
+		if propString(b.Props, "language", blocktype.DiagramMermaid) == blocktype.DiagramMermaid {
+			a = &blockAttrs{Props: extraProps(b, "language"), Children: u.nest}
+		} else {
+			a = &blockAttrs{Props: extraProps(b), Children: u.nest}
+		}
+		src := inlineText(items)
+		fence := codeFence(src, blocktype.DiagramMermaid)
+		s := fence + blocktype.DiagramMermaid + "\n"
+		if src != "" {
+			s += src + "\n"
+		}
+		return leading(a) + s + fence
+
+	case blocktype.CodeBlock:
+		lang := propString(b.Props, "language", "")
+		a := &blockAttrs{Props: extraProps(b, "language"), Children: u.nest}
+		if lang == blocktype.DiagramMermaid {
+			// A mermaid fence is read back as a diagram.
+			a = &blockAttrs{Type: blocktype.CodeBlock, Props: extraProps(b), Children: u.nest}
+		}
+		code := inlineText(items)
+		fence := codeFence(code, lang)
+		s := fence + lang + "\n"
+		if code != "" {
+			s += code + "\n"
+		}
+		return leading(a) + s + fence
+
+	case blocktype.Image:
+		a := &blockAttrs{Props: extraProps(b, "url", "caption", "name"), Children: u.nest}
+		s := "![" + escapeText(propString(b.Props, "caption", ""), false, false) + "](" +
+			linkDestination(propString(b.Props, "url", "")) + linkTitle(propString(b.Props, "name", "")) + ")"
+		return s + trailing(a)
+
+	case blocktype.File:
+		url := propString(b.Props, "url", "")
+		a := &blockAttrs{Props: extraProps(b, "url", "name"), Children: u.nest}
+		if !strings.HasPrefix(url, "/assets/") {
+			a.Type = blocktype.File
+		}
+		return "[" + escapeText(propString(b.Props, "name", ""), false, false) + "](" + linkDestination(url) + ")" + trailing(a)
+
+	case blocktype.Table:
+		if s, ok := renderTable(u); ok {
+			return s
+		}
+		return renderUnknown(u)
+
+	case blocktype.Divider:
+		a := &blockAttrs{Props: extraProps(b), Children: u.nest}
+		return leading(a) + "---"
+	}
+	return renderUnknown(u)
+}
+
+// renderCallout writes a callout as a GitHub alert: a block quote opening
+// with a "[!NOTE]" line, followed by the callout's text and children.
+func renderCallout(b Block, items []Inline) string {
+	variant := propString(b.Props, "variant", blocktype.CalloutNote)
+	a := &blockAttrs{Props: extraProps(b, "variant")}
+	if !blocktype.CalloutVariant(variant) {
+		a.Props = extraProps(b)
+		variant = blocktype.CalloutNote
+	}
+
+	s := "[!" + strings.ToUpper(variant) + "]"
+	if line := renderInline(items, ctxBlock); line != "" {
+		s += "\n" + line + trailing(a)
+	} else if !a.empty() {
+		s += "\n" + a.comment()
+	}
+	if children := renderBlocks(b.Children); children != "" {
+		s += "\n\n" + children
+	}
+	return prefixLines(s, "> ", ">")
+}
+
+// renderUnknown writes a block type Markdown has no syntax for. Inline
+// content is written as a paragraph; the attribute comment restores the type.
+func renderUnknown(u unit) string {
+	b := u.Block
+	a := &blockAttrs{Type: b.Type, Props: extraProps(b), Children: u.nest}
+	if items, err := decodeInline(b.Content); err == nil && len(items) > 0 {
+		if line := renderInline(items, ctxBlock); line != "" {
+			return line + trailing(a)
+		}
+	}
+	if b.Content != nil {
+		a.Content = b.Content
+	}
+	return a.comment()
+}
+
+func renderListItem(u unit, items []Inline, num int, runStart bool) string {
+	b := u.Block
+	marker := "- "
+	var native []string
+	switch b.Type {
+	case blocktype.CheckListItem:
+		native = []string{"checked"}
+	case blocktype.NumberedListItem:
+		marker = fmt.Sprintf("%d. ", num)
+		if start, ok := startProp(b.Props); ok && runStart && start != 1 {
+			native = []string{"start"}
+		}
+	}
+	indent := strings.Repeat(" ", len(marker))
+	if b.Type == blocktype.CheckListItem {
+		if propBool(b.Props, "checked", false) {
+			marker += "[x] "
+		} else {
+			marker += "[ ] "
+		}
+	}
+
+	a := &blockAttrs{Props: extraProps(b, native...)}
+	line := renderInline(items, ctxBlock)
+	children := renderBlocks(b.Children)
+
+	var s string
+	bare := false
+	switch {
+	case line != "":
+		s = marker + line + trailing(a)
+	case a.empty() && (children == "" || !opensWithParagraph(b.Children)):
+		s, bare = strings.TrimRight(marker, " "), true
+	default:
+		// A comment marks the item as empty, so its first child is not
+		// read back as the item's own text.
+		s = marker + a.comment()
+	}
+	if children != "" {
+		// A blank line straight after a bare marker would end the item.
+		if bare || (line != "" && listGroup(b.Children[0].Type) != 0) {
+			s += "\n" + children
+		} else {
+			s += "\n\n" + children
+		}
+	}
+	return indentLines(s, indent)
+}
+
+// opensWithParagraph reports whether the first of blocks is written as a
+// paragraph line (or a standalone comment), which a container with no text of
+// its own would read back as that text.
+func opensWithParagraph(blocks []Block) bool {
+	if len(blocks) == 0 {
+		return false
+	}
+	switch blocks[0].Type {
+	case blocktype.Heading, blocktype.BulletListItem, blocktype.NumberedListItem,
+		blocktype.CheckListItem, blocktype.Quote, blocktype.CodeBlock, blocktype.Divider:
+		return false
+	}
+	return true
+}
+
+func renderTable(u unit) (string, bool) {
+	var tc tableContent
+	if err := json.Unmarshal(u.Content, &tc); err != nil || len(tc.Rows) == 0 {
+		return "", false
+	}
+	cols := 0
+	for _, r := range tc.Rows {
+		cols = max(cols, len(r.Cells))
+	}
+	if cols == 0 {
+		return "", false
+	}
+
+	aligns := make([]string, cols)
+	for c, cell := range tc.Rows[0].Cells {
+		switch align := propString(cell.Props, "textAlignment", ""); align {
+		case "left", "center", "right":
+			aligns[c] = align
+		}
+	}
+
+	a := &blockAttrs{Props: extraProps(u.Block), Children: u.nest}
+	if len(tc.ColumnWidths) != cols {
+		a.ColumnWidths = tc.ColumnWidths
+	}
+	for _, w := range tc.ColumnWidths {
+		if w != nil {
+			a.ColumnWidths = tc.ColumnWidths
+		}
+	}
+
+	lines := make([]string, 0, len(tc.Rows)+1)
+	cells := make([][]map[string]any, len(tc.Rows))
+	overrides := false
+	for r, row := range tc.Rows {
+		texts := make([]string, cols)
+		cells[r] = make([]map[string]any, cols)
+		for c := 0; c < cols; c++ {
+			var cell tableCell
+			if c < len(row.Cells) {
+				cell = row.Cells[c]
+			}
+			texts[c] = strings.ReplaceAll(renderInline(cell.Content, ctxTable), "|", `\|`)
+
+			want := map[string]any{}
+			if aligns[c] != "" {
+				want["textAlignment"] = aligns[c]
+			}
+			if cell.Props == nil {
+				cell.Props = map[string]any{}
+			}
+			if !reflect.DeepEqual(cell.Props, want) {
+				cells[r][c] = cell.Props
+				overrides = true
+			}
+		}
+		lines = append(lines, "| "+strings.Join(texts, " | ")+" |")
+		if r == 0 {
+			delims := make([]string, cols)
+			for c, align := range aligns {
+				switch align {
+				case "left":
+					delims[c] = ":---"
+				case "center":
+					delims[c] = ":---:"
+				case "right":
+					delims[c] = "---:"
+				default:
+					delims[c] = "---"
+				}
+			}
+			lines = append(lines, "| "+strings.Join(delims, " | ")+" |")
+		}
+	}
+	if overrides {
+		a.Cells = cells
+	}
+	return leading(a) + strings.Join(lines, "\n"), true
+}
+
+// trailing returns a's comment for the end of a block's line.
+func trailing(a *blockAttrs) string {
+	if a.empty() {
+		return ""
+	}
+	return " " + a.comment()
+}
+
+// leading returns a's comment for the line before a block.
+func leading(a *blockAttrs) string {
+	if a.empty() {
+		return ""
+	}
+	return a.comment() + "\n"
+}
+
+func codeFence(code, lang string) string {
+	ch := "`"
+	if strings.Contains(lang, "`") {
+		ch = "~"
+	}
+	longest, run := 0, 0
+	for _, r := range code {
+		if string(r) == ch {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat(ch, max(3, longest+1))
+}
+
+// indentLines indents every line but the first, leaving blank lines empty.
+func indentLines(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func prefixLines(s, prefix, blank string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blank
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// --- inline rendering ([]Inline -> Markdown) ---
+
+// inlineToMarkdown renders inline content as it would appear in a paragraph.
+func inlineToMarkdown(items []Inline) string {
+	return renderInline(items, ctxBlock)
+}
+
+type inlineCtx int
+
+const (
+	// ctxParagraph is a paragraph's text, which may open with a footnote
+	// definition marker.
+	ctxParagraph inlineCtx = iota
+	// ctxBlock is the text of other blocks; hard breaks start a new line.
+	ctxBlock
+	// ctxHeading and ctxTable must stay on one line; hard breaks are <br>.
+	ctxHeading
+	ctxTable
+)
+
+type tokenKind int
+
+const (
+	tokText tokenKind = iota
+	tokBreak
+	tokLink
+)
+
+// token is a piece of inline content: a text run split around its leading
+// and trailing whitespace (so emphasis markers can hug the text, as
+// CommonMark requires), a hard break, or a link.
+type token struct {
+	kind              tokenKind
+	lead, core, trail string
+	code              bool
+	marks             []string
+	link              *Inline
+}
+
+type openMark struct {
+	key   string
+	close string
+}
+
+type inlineRenderer struct {
+	ctx       inlineCtx
+	toks      []token
+	out       []byte
+	stack     []openMark
+	pending   string
+	lineStart bool
+}
+
+func renderInline(items []Inline, ctx inlineCtx) string {
+	r := &inlineRenderer{ctx: ctx, toks: tokenize(items), lineStart: true}
+	return r.render()
+}
+
+func tokenize(items []Inline) []token {
+	var toks []token
+	for i := range items {
+		it := items[i]
+		switch it.Type {
+		case blocktype.InlineText:
+			styles := activeStyles(it.Styles)
+			code := boolFrom(styles, blocktype.StyleCode)
+			for j, part := range strings.Split(it.Text, "\n") {
+				if j > 0 {
+					toks = append(toks, token{kind: tokBreak})
+				}
+				if part == "" {
+					continue
+				}
+				t := token{kind: tokText, code: code, marks: markKeys(styles)}
+				if code {
+					t.core = part
+				} else {
+					t.lead, t.core, t.trail = splitSpace(part)
+				}
+				toks = append(toks, t)
+			}
+		case blocktype.InlineLink:
+			toks = append(toks, token{kind: tokLink, link: &items[i]})
+		}
+	}
+	return toks
+}
+
+// markKeys lists the styles that need markers, outermost first.
+func markKeys(styles map[string]any) []string {
+	var keys []string
+	for _, k := range []string{blocktype.StyleBold, blocktype.StyleItalic, blocktype.StyleStrike, blocktype.StyleUnderline} {
+		if boolFrom(styles, k) {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range []string{blocktype.StyleTextColor, blocktype.StyleBackgroundColor} {
+		if v, ok := styles[k].(string); ok {
+			keys = append(keys, k+"="+v)
+		}
+	}
+	return keys
+}
+
+func splitSpace(s string) (lead, core, trail string) {
+	core = strings.TrimLeft(s, " \t")
+	lead = s[:len(s)-len(core)]
+	trimmed := strings.TrimRight(core, " \t")
+	return lead, trimmed, core[len(trimmed):]
+}
+
+func (r *inlineRenderer) render() string {
+	for i, t := range r.toks {
+		switch t.kind {
+		case tokText:
+			if t.core == "" {
+				r.pending += t.lead
+				continue
+			}
+			keep := 0
+			for keep < len(r.stack) && slices.Contains(t.marks, r.stack[keep].key) {
+				keep++
+			}
+			r.closeTo(keep)
+			r.space(r.pending+t.lead, false)
+			r.pending = ""
+
+			var missing []string
+			for _, k := range t.marks {
+				if !r.isOpen(k) {
+					missing = append(missing, k)
+				}
+			}
+			sort.SliceStable(missing, func(a, b int) bool { return r.extent(missing[a], i) > r.extent(missing[b], i) })
+			for _, k := range missing {
+				r.open(k, i)
+			}
+			r.write(r.core(t, r.lineStart))
+			r.lineStart = false
+			r.pending = t.trail
+
+		case tokBreak:
+			if r.ctx == ctxHeading || r.ctx == ctxTable || r.lineStart || !r.textFollows(i) {
+				r.write(r.pending)
+				r.pending = ""
+				r.write("<br>")
+				r.lineStart = false
+				continue
+			}
+			r.space(r.pending, true)
+			r.pending = ""
+			r.write("\\\n")
+			r.lineStart = true
+
+		case tokLink:
+			r.closeTo(0)
+			r.space(r.pending, false)
+			r.pending = ""
+			if n := len(r.out); n > 0 && r.out[n-1] == '!' && (n < 2 || r.out[n-2] != '\\') {
+				r.out = append(r.out[:n-1], '\\', '!') // "![" would start an image
+			}
+			r.write(r.renderLink(t.link))
+			r.lineStart = false
+		}
+	}
+	r.closeTo(0)
+	r.space(r.pending, true)
+	return string(r.out)
+}
+
+func (r *inlineRenderer) write(s string) {
+	r.out = append(r.out, s...)
+}
+
+// space writes whitespace. Markdown strips it at the start and end of a line,
+// so there the outermost character is written as a character reference.
+func (r *inlineRenderer) space(ws string, lineEnd bool) {
+	if ws == "" {
+		return
+	}
+	rs := []rune(ws)
+	for i, c := range rs {
+		if (i == 0 && r.lineStart) || (i == len(rs)-1 && lineEnd) {
+			r.write(fmt.Sprintf("&#%d;", c))
+		} else {
+			r.write(string(c))
+		}
+	}
+	r.lineStart = false
+}
+
+// textFollows reports whether a hard break at i is followed by text on its
+// line, which a backslash break needs.
+func (r *inlineRenderer) textFollows(i int) bool {
+	if i+1 >= len(r.toks) || r.toks[i+1].kind == tokBreak {
+		return false
+	}
+	return true
+}
+
+func (r *inlineRenderer) isOpen(key string) bool {
+	for _, m := range r.stack {
+		if m.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *inlineRenderer) closeTo(n int) {
+	for len(r.stack) > n {
+		m := r.stack[len(r.stack)-1]
+		r.stack = r.stack[:len(r.stack)-1]
+		r.write(m.close)
+	}
+}
+
+// extent returns the index of the last text token the style key, opened at
+// token i, stays open for.
+func (r *inlineRenderer) extent(key string, i int) int {
+	end := i
+	for k := i + 1; k < len(r.toks); k++ {
+		t := r.toks[k]
+		if t.kind == tokLink {
+			break
+		}
+		if t.kind == tokBreak || t.core == "" {
+			continue
+		}
+		if !slices.Contains(t.marks, key) {
+			break
+		}
+		end = k
+	}
+	return end
+}
+
+// open writes the opening marker for key at token i. Emphasis uses Markdown
+// delimiters where CommonMark's flanking rules let them apply, and falls back
+// to HTML tags where they would not (e.g. bold text ending in punctuation
+// directly followed by a letter).
+func (r *inlineRenderer) open(key string, i int) {
+	j := r.extent(key, i)
+	first, _ := utf8.DecodeRuneInString(r.core(r.toks[i], r.lineStart))
+	last, _ := utf8.DecodeLastRuneInString(r.core(r.toks[j], false))
+	before := ' '
+	if n := len(r.out); n > 0 && !r.lineStart {
+		before, _ = utf8.DecodeLastRune(r.out)
+	}
+	after := r.runeAfter(j)
+
+	var open, closing string
+	switch key {
+	case blocktype.StyleBold, blocktype.StyleItalic, blocktype.StyleStrike:
+		delim, tag := "**", "b"
+		switch key {
+		case blocktype.StyleItalic:
+			delim, tag = "_", "i"
+			if isWordRune(before) || isWordRune(after) {
+				delim = "*"
+			}
+		case blocktype.StyleStrike:
+			delim, tag = "~~", "s"
+		}
+		if (isPunctRune(first) && isWordRune(before)) || (isPunctRune(last) && isWordRune(after)) {
+			open, closing = "<"+tag+">", "</"+tag+">"
+		} else {
+			open, closing = delim, delim
+		}
+	case blocktype.StyleUnderline:
+		open, closing = "<u>", "</u>"
+	default:
+		style, value, _ := strings.Cut(key, "=")
+		attr := "data-text-color"
+		if style == blocktype.StyleBackgroundColor {
+			attr = "data-background-color"
+		}
+		open, closing = `<span `+attr+`="`+htmlAttrEscaper.Replace(value)+`">`, "</span>"
+	}
+	r.write(open)
+	r.stack = append(r.stack, openMark{key: key, close: closing})
+}
+
+var htmlAttrEscaper = strings.NewReplacer("&", "&amp;", `"`, "&quot;", "<", "&lt;", ">", "&gt;")
+
+// runeAfter returns the first character written after token j's text.
+func (r *inlineRenderer) runeAfter(j int) rune {
+	if r.toks[j].trail != "" || j+1 >= len(r.toks) {
+		return ' '
+	}
+	next := r.toks[j+1]
+	switch {
+	case next.kind == tokLink:
+		return '['
+	case next.kind == tokBreak || next.lead != "":
+		return ' '
+	}
+	c, _ := utf8.DecodeRuneInString(r.core(next, false))
+	return c
+}
+
+func (r *inlineRenderer) core(t token, lineStart bool) string {
+	if t.code {
+		return codeSpan(t.core)
+	}
+	return escapeText(t.core, lineStart, r.ctx == ctxParagraph && len(r.out) == 0)
+}
+
+func (r *inlineRenderer) renderLink(link *Inline) string {
+	if s, ok := autolink(link); ok {
+		return s
+	}
+	ctx := r.ctx
+	if ctx == ctxParagraph {
+		ctx = ctxBlock
+	}
+	sub := &inlineRenderer{ctx: ctx, toks: tokenize(link.Content)}
+	return "[" + sub.render() + "](" + linkDestination(link.Href) + ")"
+}
+
+var (
+	autolinkURIRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>\\&|]*$`)
+	emailRe       = regexp.MustCompile(`^[a-zA-Z0-9.!#$%'*+/=?^_{}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+)
+
+// autolink writes a link whose text is its own URL (or e-mail address) in the
+// <...> form.
+func autolink(link *Inline) (string, bool) {
+	if len(link.Content) != 1 || link.Content[0].Type != blocktype.InlineText || len(activeStyles(link.Content[0].Styles)) > 0 {
+		return "", false
+	}
+	text := link.Content[0].Text
+	switch {
+	case text == link.Href && autolinkURIRe.MatchString(text):
+		return "<" + text + ">", true
+	case link.Href == "mailto:"+text && emailRe.MatchString(text):
+		return "<" + text + ">", true
+	}
+	return "", false
+}
+
+func linkDestination(href string) string {
+	href = strings.ReplaceAll(href, "\n", "")
+	if href == "" || strings.ContainsAny(href, " \t<>()") {
+		return "<" + escapeEntities(strings.NewReplacer(`\`, `\\`, "<", `\<`, ">", `\>`).Replace(href)) + ">"
+	}
+	return escapeEntities(strings.ReplaceAll(href, `\`, `\\`))
+}
+
+func linkTitle(title string) string {
+	if title == "" {
+		return ""
+	}
+	return ` "` + escapeEntities(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title)) + `"`
+}
+
+var entityRe = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+
+// escapeEntities escapes & where it would otherwise start a character
+// reference.
+func escapeEntities(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '&' && entityRe.MatchString(s[i:]) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func codeSpan(s string) string {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") ||
+		(strings.HasPrefix(s, " ") && strings.HasSuffix(s, " ") && strings.Trim(s, " ") != "") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+var footnoteRefRe = regexp.MustCompile(`^\[\^[A-Za-z0-9_-]+\]`)
+
+// escapeText backslash-escapes the characters of plain text that Markdown
+// would otherwise read as syntax. lineStart marks text at the start of a
+// line, where block markers (#, >, -, 1.) matter; footnoteDef allows a
+// leading "[^label]:" to stay a footnote definition.
+func escapeText(s string, lineStart, footnoteDef bool) string {
+	rs := []rune(s)
+	var sb strings.Builder
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		prev, next := runeAt(rs, i-1), runeAt(rs, i+1)
+		start := lineStart && i == 0
+		esc := false
+		switch c {
+		case '\\', '`', '*', ']', '<', '~':
+			esc = true
+		case '[':
+			// Footnote references are written as-is, so they keep working
+			// when the Markdown is read by people or other tools.
+			if m := footnoteRefRe.FindString(string(rs[i:])); m != "" {
+				n := utf8.RuneCountInString(m)
+				after := runeAt(rs, i+n)
+				if after != '(' && after != '[' && (after != ':' || !start || footnoteDef) {
+					sb.WriteString(m)
+					i += n - 1
+					continue
+				}
+			}
+			esc = true
+		case '_':
+			esc = !(isAlnumRune(prev) && isAlnumRune(next))
+		case '&':
+			esc = entityRe.MatchString(string(rs[i:]))
+		case '#', '>', '+', '-', '=':
+			esc = start
+		case '$':
+			esc = start && next == '$' // $$ opens a math block
+		case '.', ')':
+			esc = lineStart && i > 0 && i <= 9 && allDigits(rs[:i])
+			if c == '.' && i >= 3 && strings.EqualFold(string(rs[i-3:i]), "www") && !isAlnumRune(runeAt(rs, i-4)) {
+				esc = true // www. would be autolinked
+			}
+		case ':':
+			esc = next == '/' && runeAt(rs, i+2) == '/' // so would scheme://
+		case '@':
+			esc = isAlnumRune(next) && (isAlnumRune(prev) || strings.ContainsRune("._+-", prev))
+		}
+		if esc {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func runeAt(rs []rune, i int) rune {
+	if i < 0 || i >= len(rs) {
+		return 0
+	}
+	return rs[i]
+}
+
+func allDigits(rs []rune) bool {
+	for _, c := range rs {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnumRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func isPunctRune(c rune) bool {
+	return unicode.IsPunct(c) || unicode.IsSymbol(c)
+}
+
+// isWordRune reports whether c is neither whitespace nor punctuation, the
+// distinction CommonMark's emphasis rules turn on.
+func isWordRune(c rune) bool {
+	return c != 0 && !unicode.IsSpace(c) && !isPunctRune(c)
+}
@@ -22,8 +22,26 @@ const (
 	Quote            = "quote"
 	Table            = "table"
 	Divider          = "divider"
+	Callout          = "callout"
+	Toggle           = "toggle"
+	Math             = "math"
+	Diagram          = "diagram"
 )
 
+// Callout variants, stored in a callout block's "variant" prop. They are the
+// GitHub alert kinds, so a callout maps onto "> [!NOTE]" and back.
+const (
+	CalloutNote      = "note"
+	CalloutTip       = "tip"
+	CalloutImportant = "important"
+	CalloutWarning   = "warning"
+	CalloutCaution   = "caution"
+)
+
+// DiagramMermaid is the only diagram language so far; it is the default of a
+// diagram block's "language" prop.
+const DiagramMermaid = "mermaid"
+
 // Inline content types.
 const (
 	InlineText = "text"
@@ -32,17 +50,31 @@ const (
 
 // Inline style keys.
 const (
-	StyleBold   = "bold"
-	StyleItalic = "italic"
-	StyleCode   = "code"
-	StyleStrike = "strike"
+	StyleBold            = "bold"
+	StyleItalic          = "italic"
+	StyleCode            = "code"
+	StyleStrike          = "strike"
+	StyleUnderline       = "underline"
+	StyleTextColor       = "textColor"
+	StyleBackgroundColor = "backgroundColor"
 )
 
 // Known reports whether t is a block type the backend renders explicitly.
 func Known(t string) bool {
 	switch t {
 	case Heading, Paragraph, CodeBlock, BulletListItem, NumberedListItem,
-		CheckListItem, Image, File, Quote, Table, Divider:
+		CheckListItem, Image, File, Quote, Table, Divider, Callout, Toggle,
+		Math, Diagram:
+		return true
+	default:
+		return false
+	}
+}
+
+// CalloutVariant reports whether v is a known callout variant.
+func CalloutVariant(v string) bool {
+	switch v {
+	case CalloutNote, CalloutTip, CalloutImportant, CalloutWarning, CalloutCaution:
 		return true
 	default:
 		return false
@@ -5,7 +5,8 @@ import "testing"
 func TestKnown(t *testing.T) {
 	known := []string{
 		Heading, Paragraph, CodeBlock, BulletListItem, NumberedListItem,
-		CheckListItem, Image, File, Quote, Table,
+		CheckListItem, Image, File, Quote, Table, Divider, Callout, Toggle,
+		Math, Diagram,
 	}
 	for _, typ := range known {
 		if !Known(typ) {
@@ -24,7 +25,8 @@ func TestBlockTypeConstantsUnique(t *testing.T) {
 	all := map[string]bool{}
 	for _, typ := range []string{
 		Heading, Paragraph, CodeBlock, BulletListItem, NumberedListItem,
-		CheckListItem, Image, File, Quote, Table,
+		CheckListItem, Image, File, Quote, Table, Divider, Callout, Toggle,
+		Math, Diagram,
 	} {
 		if typ == "" {
 			t.Fatal("block type constant is empty")
@@ -35,3 +37,16 @@ func TestBlockTypeConstantsUnique(t *testing.T) {
 		all[typ] = true
 	}
 }
+
+func TestCalloutVariant(t *testing.T) {
+	for _, v := range []string{CalloutNote, CalloutTip, CalloutImportant, CalloutWarning, CalloutCaution} {
+		if !CalloutVariant(v) {
+			t.Errorf("CalloutVariant(%q) = false, want true", v)
+		}
+	}
+	for _, v := range []string{"", "NOTE", "info", "danger"} {
+		if CalloutVariant(v) {
+			t.Errorf("CalloutVariant(%q) = true, want false", v)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/sirupsen/logrus"
+
+	"yanta/internal/approot"
 )
 
 type GitSyncConfig struct {
@@ -24,6 +26,7 @@ type GitSyncConfig struct {
 type BackupConfig struct {
 	Enabled    bool `toml:"enabled"`
 	MaxBackups int  `toml:"max_backups"` // number of backups to retain, 0 = unlimited
+	Encrypt    bool `toml:"encrypt"`     // encrypt automatic backups with YANTA_BACKUP_PASSPHRASE
 }
 
 type HotkeyConfig struct {
@@ -295,7 +298,7 @@ func SetStartHidden(hidden bool) error {
 }
 
 func getConfigPath() (string, error) {
-	root, err := getAppRootDirectory()
+	root, err := approot.Dir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get app root directory: %w", err)
 	}
@@ -336,7 +339,7 @@ func GetDataDirectory() string {
 		return cfg.DataDirectory
 	}
 
-	root, err := getAppRootDirectory()
+	root, err := approot.Dir()
 	if err != nil {
 		home, _ := os.UserHomeDir()
 		return filepath.Join(home, ".yanta")
@@ -361,7 +364,7 @@ func GetAppHomeEnvVar() string {
 // 1. YANTA_HOME
 // 2. ~/.yanta
 func GetAppRootDirectory() string {
-	root, err := getAppRootDirectory()
+	root, err := approot.Dir()
 	if err != nil {
 		home, _ := os.UserHomeDir()
 		return filepath.Join(home, ".yanta")
@@ -377,17 +380,6 @@ func MCPDiscoveryPath() string {
 	return filepath.Join(GetAppRootDirectory(), "mcp.json")
 }
 
-func getAppRootDirectory() (string, error) {
-	if envDir := os.Getenv("YANTA_HOME"); envDir != "" {
-		return envDir, nil
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(home, ".yanta"), nil
-}
-
 func SetDataDirectory(dir string) error {
 	mu.Lock()
 	defer mu.Unlock()
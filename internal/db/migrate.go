@@ -18,6 +18,7 @@ var embedMigrations embed.FS
 var (
 	ErrFailedToSetDialect    = errors.New("failed to set dialect")
 	ErrFailedToRunMigrations = errors.New("failed to run migrations")
+	ErrSchemaMismatch        = errors.New("database schema does not match this build")
 )
 
 func RunMigrations(db *sql.DB) error {
@@ -53,3 +54,31 @@ func MigrationStatus(db *sql.DB) error {
 
 	return goose.Status(db, "migrations")
 }
+
+// CheckSchema reports whether the database is at exactly the schema version
+// this build migrates to. Processes that must not migrate, such as a
+// `yanta mcp --standalone` server next to a running app, call it instead of
+// RunMigrations.
+func CheckSchema(db *sql.DB) error {
+	goose.SetBaseFS(embedMigrations)
+	if err := goose.SetDialect(dialect); err != nil {
+		return fmt.Errorf("%w: %v", ErrFailedToSetDialect, err)
+	}
+
+	current, err := goose.GetDBVersion(db)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	migrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("collecting migrations: %w", err)
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		return fmt.Errorf("collecting migrations: %w", err)
+	}
+	if current != latest.Version {
+		return fmt.Errorf("%w: database is at version %d, this build expects %d", ErrSchemaMismatch, current, latest.Version)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSchema(t *testing.T) {
+	conn, err := OpenDB(filepath.Join(t.TempDir(), "yanta.db"))
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer CloseDB(conn)
+
+	if err := CheckSchema(conn); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("CheckSchema on an empty database = %v, want ErrSchemaMismatch", err)
+	}
+
+	if err := RunMigrations(conn); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if err := CheckSchema(conn); err != nil {
+		t.Fatalf("CheckSchema after migrating = %v, want nil", err)
+	}
+}
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Heading outline of each document, in document order, for navigation and
+-- path#slug deep links
+
+CREATE TABLE IF NOT EXISTS doc_heading (
+    path TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    level INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    block_id TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    PRIMARY KEY (path, position),
+    FOREIGN KEY (path) REFERENCES doc (path) ON UPDATE CASCADE ON DELETE CASCADE,
+    CHECK (level BETWEEN 1 AND 6)
+);
+
+CREATE INDEX IF NOT EXISTS idx_doc_heading_slug ON doc_heading (path, slug);
+
+-- Update schema version
+UPDATE kv SET value = '4' WHERE key = 'schema_version';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_doc_heading_slug;
+
+DROP TABLE IF EXISTS doc_heading;
+
+-- Revert schema version
+UPDATE kv SET value = '3' WHERE key = 'schema_version';
@@ -12,6 +12,12 @@ func (p *Parser) parseHeading(block BlockNoteBlock, content *ExtractedContent) {
 	}
 
 	content.Headings = append(content.Headings, text)
+	content.Outline = append(content.Outline, OutlineEntry{
+		Level:   headingLevel(block),
+		Text:    text,
+		BlockID: block.ID,
+		Slug:    content.slugs.slug(text),
+	})
 
 	links := p.extractLinksFromContent(block.Content)
 	content.Links = append(content.Links, links...)
@@ -7,6 +7,7 @@ import (
 type ExtractedContent struct {
 	Title    string
 	Headings []string
+	Outline  []OutlineEntry
 	Body     []string
 	Code     []string
 
@@ -16,6 +17,8 @@ type ExtractedContent struct {
 	HasCode   bool
 	HasImages bool
 	HasLinks  bool
+
+	slugs slugger
 }
 
 type Link struct {
@@ -0,0 +1,102 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+
+	"yanta/internal/blocktype"
+)
+
+var ErrBlockNotFound = errors.New("block not found")
+
+// findBlock returns the sibling list holding the block with the given ID,
+// searching children depth-first, and the block's index in it.
+func findBlock(blocks *[]BlockNoteBlock, id string) (*[]BlockNoteBlock, int) {
+	for i := range *blocks {
+		if (*blocks)[i].ID == id {
+			return blocks, i
+		}
+		if list, j := findBlock(&(*blocks)[i].Children, id); list != nil {
+			return list, j
+		}
+	}
+	return nil, -1
+}
+
+// sectionEnd returns the index just past the section opened by the heading
+// at blocks[i]: the next heading of the same or a higher level, or the end.
+func sectionEnd(blocks []BlockNoteBlock, i int) int {
+	level := headingLevel(blocks[i])
+	end := i + 1
+	for end < len(blocks) {
+		next := blocks[end]
+		if next.Type == blocktype.Heading && headingLevel(next) <= level {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// InsertBlocksAfter inserts inserted as siblings directly after the block
+// with the given ID.
+func InsertBlocksAfter(blocks []BlockNoteBlock, afterID string, inserted []BlockNoteBlock) ([]BlockNoteBlock, error) {
+	list, i := findBlock(&blocks, afterID)
+	if list == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, afterID)
+	}
+	*list = append((*list)[:i+1], append(append([]BlockNoteBlock{}, inserted...), (*list)[i+1:]...)...)
+	return blocks, nil
+}
+
+// ReplaceSection replaces everything under the heading with the given ID, up
+// to the next heading of the same or a higher level, with replacement. The
+// heading block itself, and so its ID and slug, is kept.
+func ReplaceSection(blocks []BlockNoteBlock, headingID string, replacement []BlockNoteBlock) ([]BlockNoteBlock, error) {
+	list, i := findBlock(&blocks, headingID)
+	if list == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, headingID)
+	}
+	if (*list)[i].Type != blocktype.Heading {
+		return nil, fmt.Errorf("block %s is a %s, not a heading", headingID, (*list)[i].Type)
+	}
+	end := sectionEnd(*list, i)
+	*list = append((*list)[:i+1], append(append([]BlockNoteBlock{}, replacement...), (*list)[end:]...)...)
+	return blocks, nil
+}
+
+// DeleteBlock removes the block with the given ID, together with its
+// children.
+func DeleteBlock(blocks []BlockNoteBlock, id string) ([]BlockNoteBlock, error) {
+	list, i := findBlock(&blocks, id)
+	if list == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, id)
+	}
+	*list = append((*list)[:i], (*list)[i+1:]...)
+	return blocks, nil
+}
+
+// ToggleCheckListItem flips the checked state of the checklist item with the
+// given ID.
+func ToggleCheckListItem(blocks []BlockNoteBlock, id string) ([]BlockNoteBlock, error) {
+	list, i := findBlock(&blocks, id)
+	if list == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, id)
+	}
+	block := &(*list)[i]
+	if block.Type != blocktype.CheckListItem {
+		return nil, fmt.Errorf("block %s is a %s, not a checklist item", id, block.Type)
+	}
+	props := make(map[string]any, len(block.Props)+1)
+	for k, v := range block.Props {
+		props[k] = v
+	}
+	props["checked"] = !PropBool(block.Props, "checked", false)
+	block.Props = props
+	return blocks, nil
+}
+
+// BlockText returns the plain text of a block's inline content.
+func BlockText(block BlockNoteBlock) string {
+	return NewParser().extractTextFromContent(block.Content)
+}
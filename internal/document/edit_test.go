@@ -0,0 +1,116 @@
+package document
+
+import (
+	"errors"
+	"testing"
+)
+
+func editFixture() []BlockNoteBlock {
+	return []BlockNoteBlock{
+		headingBlock("intro", 1, "Intro"),
+		paragraphBlock("p1", "welcome"),
+		headingBlock("todo", 2, "Todo"),
+		{
+			ID:       "c1",
+			Type:     "checkListItem",
+			Props:    map[string]any{"checked": false},
+			Content:  mustMarshalContent([]BlockNoteContent{{Type: "text", Text: "write docs"}}),
+			Children: []BlockNoteBlock{paragraphBlock("n1", "nested")},
+		},
+		headingBlock("done", 2, "Done"),
+		paragraphBlock("p2", "shipped"),
+	}
+}
+
+func blockIDs(blocks []BlockNoteBlock) []string {
+	var ids []string
+	for _, b := range blocks {
+		ids = append(ids, b.ID)
+		ids = append(ids, blockIDs(b.Children)...)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, blocks []BlockNoteBlock, want ...string) {
+	t.Helper()
+	got := blockIDs(blocks)
+	if len(got) != len(want) {
+		t.Fatalf("blocks = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("blocks = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestInsertBlocksAfter(t *testing.T) {
+	blocks, err := InsertBlocksAfter(editFixture(), "todo", []BlockNoteBlock{paragraphBlock("x1", "a"), paragraphBlock("x2", "b")})
+	if err != nil {
+		t.Fatalf("InsertBlocksAfter() error: %v", err)
+	}
+	assertIDs(t, blocks, "intro", "p1", "todo", "x1", "x2", "c1", "n1", "done", "p2")
+
+	blocks, err = InsertBlocksAfter(editFixture(), "n1", []BlockNoteBlock{paragraphBlock("x1", "a")})
+	if err != nil {
+		t.Fatalf("InsertBlocksAfter() nested error: %v", err)
+	}
+	assertIDs(t, blocks, "intro", "p1", "todo", "c1", "n1", "x1", "done", "p2")
+
+	if _, err := InsertBlocksAfter(editFixture(), "missing", nil); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("Expected ErrBlockNotFound, got %v", err)
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	blocks, err := ReplaceSection(editFixture(), "todo", []BlockNoteBlock{paragraphBlock("x1", "nothing left")})
+	if err != nil {
+		t.Fatalf("ReplaceSection() error: %v", err)
+	}
+	assertIDs(t, blocks, "intro", "p1", "todo", "x1", "done", "p2")
+
+	blocks, err = ReplaceSection(editFixture(), "intro", nil)
+	if err != nil {
+		t.Fatalf("ReplaceSection() error: %v", err)
+	}
+	assertIDs(t, blocks, "intro")
+
+	if _, err := ReplaceSection(editFixture(), "p1", nil); err == nil {
+		t.Error("ReplaceSection() should reject a block that is not a heading")
+	}
+}
+
+func TestDeleteBlock(t *testing.T) {
+	blocks, err := DeleteBlock(editFixture(), "c1")
+	if err != nil {
+		t.Fatalf("DeleteBlock() error: %v", err)
+	}
+	assertIDs(t, blocks, "intro", "p1", "todo", "done", "p2")
+
+	if _, err := DeleteBlock(editFixture(), "missing"); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("Expected ErrBlockNotFound, got %v", err)
+	}
+}
+
+func TestToggleCheckListItem(t *testing.T) {
+	original := editFixture()
+	blocks, err := ToggleCheckListItem(original, "c1")
+	if err != nil {
+		t.Fatalf("ToggleCheckListItem() error: %v", err)
+	}
+	if !PropBool(blocks[3].Props, "checked", false) {
+		t.Error("Expected item to be checked")
+	}
+
+	blocks, err = ToggleCheckListItem(blocks, "c1")
+	if err != nil {
+		t.Fatalf("ToggleCheckListItem() error: %v", err)
+	}
+	if PropBool(blocks[3].Props, "checked", true) {
+		t.Error("Expected item to be unchecked again")
+	}
+
+	if _, err := ToggleCheckListItem(editFixture(), "p1"); err == nil {
+		t.Error("ToggleCheckListItem() should reject a block that is not a checklist item")
+	}
+}
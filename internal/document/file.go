@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"yanta/internal/blocktype"
 	"yanta/internal/project"
 )
 
@@ -282,6 +283,10 @@ func validateBlock(block BlockNoteBlock, depth int) error {
 		return fmt.Errorf("block type cannot be empty")
 	}
 
+	if err := validateBlockProps(block); err != nil {
+		return err
+	}
+
 	for i, child := range block.Children {
 		if err := validateBlock(child, depth+1); err != nil {
 			return fmt.Errorf("child block %d: %w", i, err)
@@ -291,6 +296,46 @@ func validateBlock(block BlockNoteBlock, depth int) error {
 	return nil
 }
 
+// validateBlockProps checks the props and content of block types whose
+// renderers depend on them.
+func validateBlockProps(block BlockNoteBlock) error {
+	switch block.Type {
+	case blocktype.Callout:
+		if v, ok := block.Props["variant"]; ok {
+			variant, isString := v.(string)
+			if !isString || !blocktype.CalloutVariant(variant) {
+				return fmt.Errorf("callout variant %v is not one of note, tip, important, warning, caution", v)
+			}
+		}
+	case blocktype.Diagram:
+		if v, ok := block.Props["language"]; ok && v != blocktype.DiagramMermaid {
+			return fmt.Errorf("diagram language %v is not supported (want %q)", v, blocktype.DiagramMermaid)
+		}
+		return validateSourceContent(block)
+	case blocktype.Math:
+		return validateSourceContent(block)
+	}
+	return nil
+}
+
+// validateSourceContent checks that a math or diagram block's content is
+// plain source text: text items only, no links.
+func validateSourceContent(block BlockNoteBlock) error {
+	if len(block.Content) == 0 {
+		return nil
+	}
+	var items []BlockNoteContent
+	if err := json.Unmarshal(block.Content, &items); err != nil {
+		return fmt.Errorf("%s content must be an inline text array: %w", block.Type, err)
+	}
+	for i, item := range items {
+		if item.Type != blocktype.InlineText {
+			return fmt.Errorf("%s content[%d]: only text is allowed, got %q", block.Type, i, item.Type)
+		}
+	}
+	return nil
+}
+
 func NewDocumentFile(project, title string, tags []string) *DocumentFile {
 	now := time.Now()
 
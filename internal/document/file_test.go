@@ -462,6 +462,86 @@ func TestValidateBlock_MaxDepth(t *testing.T) {
 	}
 }
 
+func TestValidateBlock_BlockProps(t *testing.T) {
+	text := func(items ...BlockNoteContent) json.RawMessage {
+		raw, _ := json.Marshal(items)
+		return raw
+	}
+
+	tests := []struct {
+		name    string
+		block   BlockNoteBlock
+		wantErr string
+	}{
+		{
+			name:  "callout without variant",
+			block: BlockNoteBlock{ID: "c", Type: "callout"},
+		},
+		{
+			name:  "callout with known variant",
+			block: BlockNoteBlock{ID: "c", Type: "callout", Props: map[string]any{"variant": "tip"}},
+		},
+		{
+			name:    "callout with unknown variant",
+			block:   BlockNoteBlock{ID: "c", Type: "callout", Props: map[string]any{"variant": "danger"}},
+			wantErr: "callout variant",
+		},
+		{
+			name:    "callout with non-string variant",
+			block:   BlockNoteBlock{ID: "c", Type: "callout", Props: map[string]any{"variant": 3.0}},
+			wantErr: "callout variant",
+		},
+		{
+			name:  "math with source text",
+			block: BlockNoteBlock{ID: "m", Type: "math", Content: text(BlockNoteContent{Type: "text", Text: "x^2", Styles: map[string]any{}})},
+		},
+		{
+			name: "math with a link",
+			block: BlockNoteBlock{ID: "m", Type: "math", Content: text(BlockNoteContent{
+				Type: "link", Href: "https://example.com",
+				Content: []BlockNoteContent{{Type: "text", Text: "x", Styles: map[string]any{}}},
+			})},
+			wantErr: "only text is allowed",
+		},
+		{
+			name:    "math with non-array content",
+			block:   BlockNoteBlock{ID: "m", Type: "math", Content: json.RawMessage(`"x^2"`)},
+			wantErr: "inline text array",
+		},
+		{
+			name:  "mermaid diagram",
+			block: BlockNoteBlock{ID: "d", Type: "diagram", Props: map[string]any{"language": "mermaid"}},
+		},
+		{
+			name:    "unsupported diagram language",
+			block:   BlockNoteBlock{ID: "d", Type: "diagram", Props: map[string]any{"language": "plantuml"}},
+			wantErr: "diagram language",
+		},
+		{
+			name: "invalid nested callout",
+			block: BlockNoteBlock{ID: "t", Type: "toggle", Children: []BlockNoteBlock{
+				{ID: "c", Type: "callout", Props: map[string]any{"variant": "NOTE"}},
+			}},
+			wantErr: "child block 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBlock(tt.block, 0)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateBlock() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateBlock() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestDocumentFile_Validate_Canvas(t *testing.T) {
 	now := time.Now()
 	validScene := json.RawMessage(`{"elements":[],"appState":{}}`)
@@ -91,6 +91,17 @@ func (m *MarkdownConverter) convertBlock(block BlockNoteBlock, lines *[]string,
 		m.convertTable(block, lines)
 	case blocktype.Divider:
 		*lines = append(*lines, "", "---")
+	case blocktype.Callout:
+		m.convertCallout(block, lines)
+	case blocktype.Toggle:
+		// A toggle's children are its collapsed body and belong inside the
+		// <details> element.
+		m.convertToggle(block, lines, depth)
+		return
+	case blocktype.Math:
+		m.convertMath(block, lines)
+	case blocktype.Diagram:
+		m.convertDiagram(block, lines)
 	default:
 		text := m.extractFormattedText(block.Content)
 		if text != "" {
@@ -323,3 +334,72 @@ func (m *MarkdownConverter) convertTable(block BlockNoteBlock, lines *[]string)
 		}
 	}
 }
+
+// convertCallout writes a callout as a GitHub alert ("> [!NOTE]").
+func (m *MarkdownConverter) convertCallout(block BlockNoteBlock, lines *[]string) {
+	variant := PropString(block.Props, "variant", blocktype.CalloutNote)
+	if !blocktype.CalloutVariant(variant) {
+		variant = blocktype.CalloutNote
+	}
+
+	*lines = append(*lines, "", fmt.Sprintf("> [!%s]", strings.ToUpper(variant)))
+	text := m.extractFormattedText(block.Content)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		*lines = append(*lines, strings.TrimRight("> "+line, " "))
+	}
+}
+
+// convertToggle writes a toggle as a <details> element whose summary is the
+// toggle's text and whose body is its children.
+func (m *MarkdownConverter) convertToggle(block BlockNoteBlock, lines *[]string, depth int) {
+	text := m.extractFormattedText(block.Content)
+
+	*lines = append(*lines, "", "<details>", fmt.Sprintf("<summary>%s</summary>", strings.ReplaceAll(text, "\n", "<br>")))
+	for _, child := range block.Children {
+		m.convertBlock(child, lines, depth+1)
+	}
+	*lines = append(*lines, "", "</details>")
+}
+
+func (m *MarkdownConverter) convertMath(block BlockNoteBlock, lines *[]string) {
+	text := m.extractPlainText(block.Content)
+	if text == "" {
+		return
+	}
+
+	*lines = append(*lines, "", "$$", text, "$$")
+}
+
+func (m *MarkdownConverter) convertDiagram(block BlockNoteBlock, lines *[]string) {
+	text := m.extractPlainText(block.Content)
+	if text == "" {
+		return
+	}
+
+	language := PropString(block.Props, "language", blocktype.DiagramMermaid)
+	*lines = append(*lines, "", "```"+language, text, "```")
+}
+
+// extractPlainText returns the unstyled text of source blocks (math,
+// diagrams), where Markdown style markers would corrupt the source.
+func (m *MarkdownConverter) extractPlainText(rawContent json.RawMessage) string {
+	if len(rawContent) == 0 {
+		return ""
+	}
+
+	var inlineContent []BlockNoteContent
+	if err := json.Unmarshal(rawContent, &inlineContent); err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, item := range inlineContent {
+		if item.Type == blocktype.InlineText {
+			sb.WriteString(item.Text)
+		}
+	}
+	return sb.String()
+}
@@ -463,6 +463,76 @@ func TestMarkdownConverter_Quote(t *testing.T) {
 	}
 }
 
+func TestMarkdownConverter_RichBlocks(t *testing.T) {
+	converter := NewMarkdownConverter()
+
+	doc := &DocumentFile{
+		Meta: DocumentMeta{
+			Project: "@test",
+			Title:   "Test",
+			Tags:    []string{},
+			Created: time.Now(),
+			Updated: time.Now(),
+		},
+		Blocks: []BlockNoteBlock{
+			{
+				ID:    "callout1",
+				Type:  "callout",
+				Props: map[string]any{"variant": "warning"},
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "Mind the gap."},
+				}),
+			},
+			{
+				ID:   "toggle1",
+				Type: "toggle",
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "More"},
+				}),
+				Children: []BlockNoteBlock{
+					{
+						ID:   "hidden",
+						Type: "paragraph",
+						Content: mustMarshalContent([]BlockNoteContent{
+							{Type: "text", Text: "Hidden text"},
+						}),
+					},
+				},
+			},
+			{
+				ID:   "math1",
+				Type: "math",
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "a^2 + b^2 = c^2"},
+				}),
+			},
+			{
+				ID:   "diagram1",
+				Type: "diagram",
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "graph TD\n  A-->B"},
+				}),
+			},
+		},
+	}
+
+	markdown, err := converter.ToMarkdown(doc)
+	if err != nil {
+		t.Fatalf("ToMarkdown() error: %v", err)
+	}
+
+	for _, want := range []string{
+		"> [!WARNING]\n> Mind the gap.",
+		"<details>\n<summary>More</summary>\n\nHidden text\n\n</details>",
+		"$$\na^2 + b^2 = c^2\n$$",
+		"```mermaid\ngraph TD\n  A-->B\n```",
+	} {
+		if !contains(markdown, want) {
+			t.Errorf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
 func TestMarkdownConverter_File(t *testing.T) {
 	converter := NewMarkdownConverter()
 
@@ -0,0 +1,113 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"yanta/internal/blocktype"
+)
+
+// OutlineEntry is one heading of a document's outline.
+type OutlineEntry struct {
+	Level   int    `json:"level"`
+	Text    string `json:"text"`
+	BlockID string `json:"block_id"`
+	// Slug is the heading's anchor, unique within the document, as used in
+	// "path#slug" deep links.
+	Slug string `json:"slug"`
+}
+
+// Slugify turns heading text into an anchor the way GitHub does: lowercase,
+// punctuation dropped, spaces turned into hyphens.
+func Slugify(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), r == '-', r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteByte('-')
+		}
+	}
+	return sb.String()
+}
+
+// slugger hands out unique slugs within one document, suffixing repeats
+// with -1, -2, ...
+type slugger map[string]int
+
+func (s slugger) slug(text string) string {
+	base := Slugify(text)
+	if base == "" {
+		base = "section"
+	}
+	slug := base
+	for {
+		if _, taken := s[slug]; !taken {
+			break
+		}
+		s[base]++
+		slug = base + "-" + strconv.Itoa(s[base])
+	}
+	s[slug] = 0
+	return slug
+}
+
+// SplitAnchor splits an internal link such as
+// "projects/@work/doc-1.json#setup" into the document path and the heading
+// slug, which is empty when the link has no anchor.
+func SplitAnchor(ref string) (path, slug string) {
+	path, slug, _ = strings.Cut(ref, "#")
+	return path, slug
+}
+
+// FindHeading returns the outline entry with the given slug.
+func FindHeading(outline []OutlineEntry, slug string) (OutlineEntry, bool) {
+	for _, entry := range outline {
+		if entry.Slug == slug {
+			return entry, true
+		}
+	}
+	return OutlineEntry{}, false
+}
+
+// HeadingAnchors maps the block ID of every heading in a document's blocks
+// to its outline slug.
+func HeadingAnchors(blocks []BlockNoteBlock) map[string]string {
+	content, _ := NewParser().Parse(&DocumentFile{Blocks: blocks})
+	anchors := make(map[string]string, len(content.Outline))
+	for _, entry := range content.Outline {
+		if entry.BlockID != "" {
+			anchors[entry.BlockID] = entry.Slug
+		}
+	}
+	return anchors
+}
+
+// HeadingID returns the block ID of the heading with the given slug.
+func HeadingID(blocks []BlockNoteBlock, slug string) (string, bool) {
+	content, _ := NewParser().Parse(&DocumentFile{Blocks: blocks})
+	entry, ok := FindHeading(content.Outline, slug)
+	return entry.BlockID, ok
+}
+
+// Section returns the heading block with the given ID together with the
+// blocks that follow it up to the next heading of the same or a higher
+// level, the way the section reads in the editor. It reports false when no
+// heading has that ID.
+func Section(blocks []BlockNoteBlock, headingID string) ([]BlockNoteBlock, bool) {
+	for i, block := range blocks {
+		if block.ID == headingID && block.Type == blocktype.Heading {
+			return blocks[i:sectionEnd(blocks, i)], true
+		}
+		if section, ok := Section(block.Children, headingID); ok {
+			return section, true
+		}
+	}
+	return nil, false
+}
+
+func headingLevel(block BlockNoteBlock) int {
+	return min(max(PropInt(block.Props, "level", 1), 1), 6)
+}
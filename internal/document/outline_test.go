@@ -0,0 +1,148 @@
+package document
+
+import (
+	"testing"
+)
+
+func headingBlock(id string, level int, text string) BlockNoteBlock {
+	return BlockNoteBlock{
+		ID:      id,
+		Type:    "heading",
+		Props:   map[string]any{"level": level},
+		Content: mustMarshalContent([]BlockNoteContent{{Type: "text", Text: text}}),
+	}
+}
+
+func paragraphBlock(id, text string) BlockNoteBlock {
+	return BlockNoteBlock{
+		ID:      id,
+		Type:    "paragraph",
+		Content: mustMarshalContent([]BlockNoteContent{{Type: "text", Text: text}}),
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Getting Started", "getting-started"},
+		{"  API: v2 (beta)!  ", "api-v2-beta"},
+		{"snake_case & kebab-case", "snake_case--kebab-case"},
+		{"Überblick", "überblick"},
+		{"日本語", "日本語"},
+		{"???", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.text); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParser_Outline(t *testing.T) {
+	doc := &DocumentFile{
+		Blocks: []BlockNoteBlock{
+			headingBlock("h1", 1, "Setup"),
+			paragraphBlock("p1", "text"),
+			headingBlock("h2", 2, "Setup"),
+			headingBlock("h3", 2, "Setup-1"),
+			headingBlock("h4", 3, "!!!"),
+			{
+				ID:       "q1",
+				Type:     "toggle",
+				Content:  mustMarshalContent([]BlockNoteContent{{Type: "text", Text: "More"}}),
+				Children: []BlockNoteBlock{headingBlock("h5", 9, "Nested")},
+			},
+		},
+	}
+
+	content, err := NewParser().Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []OutlineEntry{
+		{Level: 1, Text: "Setup", BlockID: "h1", Slug: "setup"},
+		{Level: 2, Text: "Setup", BlockID: "h2", Slug: "setup-1"},
+		{Level: 2, Text: "Setup-1", BlockID: "h3", Slug: "setup-1-1"},
+		{Level: 3, Text: "!!!", BlockID: "h4", Slug: "section"},
+		{Level: 6, Text: "Nested", BlockID: "h5", Slug: "nested"},
+	}
+	if len(content.Outline) != len(want) {
+		t.Fatalf("Expected %d outline entries, got %d: %+v", len(want), len(content.Outline), content.Outline)
+	}
+	for i, entry := range content.Outline {
+		if entry != want[i] {
+			t.Errorf("Outline[%d] = %+v, want %+v", i, entry, want[i])
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	blocks := []BlockNoteBlock{
+		headingBlock("intro", 1, "Intro"),
+		paragraphBlock("p1", "welcome"),
+		headingBlock("setup", 2, "Setup"),
+		paragraphBlock("p2", "install"),
+		headingBlock("deps", 3, "Dependencies"),
+		paragraphBlock("p3", "go"),
+		headingBlock("usage", 2, "Usage"),
+		paragraphBlock("p4", "run"),
+		{
+			ID:       "t1",
+			Type:     "toggle",
+			Children: []BlockNoteBlock{headingBlock("faq", 2, "FAQ"), paragraphBlock("p5", "answers")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantIDs []string
+	}{
+		{"stops at same level", "setup", []string{"setup", "p2", "deps", "p3"}},
+		{"stops at higher level", "deps", []string{"deps", "p3"}},
+		{"runs to end", "intro", []string{"intro", "p1", "setup", "p2", "deps", "p3", "usage", "p4", "t1"}},
+		{"nested heading", "faq", []string{"faq", "p5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section, ok := Section(blocks, tt.id)
+			if !ok {
+				t.Fatalf("Section(%q) not found", tt.id)
+			}
+			var ids []string
+			for _, b := range section {
+				ids = append(ids, b.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("Section(%q) = %v, want %v", tt.id, ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("Section(%q) = %v, want %v", tt.id, ids, tt.wantIDs)
+					break
+				}
+			}
+		})
+	}
+
+	if _, ok := Section(blocks, "p1"); ok {
+		t.Error("Section() should not accept a non-heading block")
+	}
+}
+
+func TestSplitAnchor(t *testing.T) {
+	path, slug := SplitAnchor("projects/@work/doc-1.json#getting-started")
+	if path != "projects/@work/doc-1.json" || slug != "getting-started" {
+		t.Errorf("SplitAnchor() = %q, %q", path, slug)
+	}
+
+	path, slug = SplitAnchor("projects/@work/doc-1.json")
+	if path != "projects/@work/doc-1.json" || slug != "" {
+		t.Errorf("SplitAnchor() without anchor = %q, %q", path, slug)
+	}
+}
@@ -18,10 +18,12 @@ func (p *Parser) Parse(doc *DocumentFile) (*ExtractedContent, error) {
 	content := &ExtractedContent{
 		Title:    doc.Meta.Title,
 		Headings: []string{},
+		Outline:  []OutlineEntry{},
 		Body:     []string{},
 		Code:     []string{},
 		Links:    []Link{},
 		Assets:   []Asset{},
+		slugs:    slugger{},
 	}
 
 	kind := doc.Kind
@@ -106,7 +108,8 @@ func (p *Parser) parseBlock(block BlockNoteBlock, content *ExtractedContent) {
 		p.parseHeading(block, content)
 	case blocktype.Paragraph:
 		p.parseParagraph(block, content)
-	case blocktype.CodeBlock:
+	case blocktype.CodeBlock, blocktype.Math, blocktype.Diagram:
+		// LaTeX and Mermaid sources are indexed like code.
 		p.parseCodeBlock(block, content)
 	case blocktype.BulletListItem, blocktype.NumberedListItem, blocktype.CheckListItem:
 		p.parseListItem(block, content)
@@ -114,7 +117,7 @@ func (p *Parser) parseBlock(block BlockNoteBlock, content *ExtractedContent) {
 		p.parseImage(block, content)
 	case blocktype.File:
 		p.parseFile(block, content)
-	case blocktype.Quote:
+	case blocktype.Quote, blocktype.Callout, blocktype.Toggle:
 		p.parseQuote(block, content)
 	case blocktype.Table:
 		p.parseTable(block, content)
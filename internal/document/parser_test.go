@@ -483,6 +483,85 @@ func TestParser_ParseQuote(t *testing.T) {
 	}
 }
 
+func TestParser_ParseRichBlocks(t *testing.T) {
+	p := NewParser()
+
+	doc := &DocumentFile{
+		Meta: DocumentMeta{
+			Project: "@test",
+			Title:   "Test",
+			Tags:    []string{},
+			Created: time.Now(),
+			Updated: time.Now(),
+		},
+		Blocks: []BlockNoteBlock{
+			{
+				ID:    "callout1",
+				Type:  "callout",
+				Props: map[string]any{"variant": "warning"},
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "Mind the gap."},
+				}),
+			},
+			{
+				ID:   "toggle1",
+				Type: "toggle",
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "Details"},
+				}),
+				Children: []BlockNoteBlock{
+					{
+						ID:   "hidden",
+						Type: "paragraph",
+						Content: mustMarshalContent([]BlockNoteContent{
+							{Type: "text", Text: "Hidden text"},
+						}),
+					},
+				},
+			},
+			{
+				ID:   "math1",
+				Type: "math",
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: `e^{i\pi} + 1 = 0`},
+				}),
+			},
+			{
+				ID:   "diagram1",
+				Type: "diagram",
+				Content: mustMarshalContent([]BlockNoteContent{
+					{Type: "text", Text: "graph TD; A-->B"},
+				}),
+			},
+		},
+	}
+
+	content, err := p.Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	wantBody := []string{"Mind the gap.", "Details", "Hidden text"}
+	if len(content.Body) != len(wantBody) {
+		t.Fatalf("Body = %q, want %q", content.Body, wantBody)
+	}
+	for i, want := range wantBody {
+		if content.Body[i] != want {
+			t.Errorf("Body[%d] = %q, want %q", i, content.Body[i], want)
+		}
+	}
+
+	wantCode := []string{`e^{i\pi} + 1 = 0`, "graph TD; A-->B"}
+	if len(content.Code) != len(wantCode) {
+		t.Fatalf("Code = %q, want %q", content.Code, wantCode)
+	}
+	for i, want := range wantCode {
+		if content.Code[i] != want {
+			t.Errorf("Code[%d] = %q, want %q", i, content.Code[i], want)
+		}
+	}
+}
+
 func TestParser_ParseFile(t *testing.T) {
 	p := NewParser()
 
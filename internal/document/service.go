@@ -107,14 +107,51 @@ type SaveRequest struct {
 	// for canvas documents. Persisted into DocumentFile.Assets so the indexer can
 	// link these images in doc_asset; without it canvas images are never linked
 	// and are treated as orphans by asset GC.
-	Assets       map[string]string
-	Tags         []string
+	Assets map[string]string
+	Tags   []string
+	// ExpectedHash, when set, is the GetDocumentHash value the save is based
+	// on. It is checked under the save lock; if the document has changed
+	// since, nothing is written and Save returns a *ConflictError.
 	ExpectedHash string
+	// Aliases replaces the document's aliases when non-nil; nil keeps the
+	// existing ones.
+	Aliases []string
+	// Created and Updated override the file timestamps when set, so imports
+	// keep the source's history. Created only applies to new documents.
+	Created time.Time
+	Updated time.Time
 }
 
 var ErrConflict = errors.New("ERR_CONFLICT: document was modified externally")
 
+// ConflictError is returned when a save's ExpectedHash no longer matches the
+// document on disk. It carries the current version so the caller can rebase
+// its edit onto it, and matches ErrConflict under errors.Is. Bound methods
+// return it unwrapped: Wails serializes it as the JS error's cause.
+type ConflictError struct {
+	Path        string        `json:"path"`
+	CurrentHash string        `json:"current_hash"`
+	Current     *DocumentFile `json:"current"`
+}
+
+func (e *ConflictError) Error() string {
+	return ErrConflict.Error()
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
 func (s *Service) Save(ctx context.Context, req SaveRequest) (string, error) {
+	docPath, _, err := s.SaveWithHash(ctx, req)
+	return docPath, err
+}
+
+// SaveWithHash saves like Save and also returns the hash of what it wrote,
+// read back under the save lock, or "" if it could not be read back. A caller
+// chaining hash-guarded edits must use this hash: one fetched after the lock
+// is released may belong to a save that landed in between.
+func (s *Service) SaveWithHash(ctx context.Context, req SaveRequest) (string, string, error) {
 	// Serialize Save operations to prevent race conditions where concurrent saves
 	// can cause file write conflicts and FK constraint errors during IndexDocument.
 	// This ensures that WriteFile and IndexDocument are atomic with respect to each other.
@@ -122,12 +159,12 @@ func (s *Service) Save(ctx context.Context, req SaveRequest) (string, error) {
 	defer s.saveMu.Unlock()
 
 	if err := project.ValidateAlias(strings.TrimSpace(req.ProjectAlias)); err != nil {
-		return "", fmt.Errorf("invalid project_alias: %w", err)
+		return "", "", fmt.Errorf("invalid project_alias: %w", err)
 	}
 
 	req.ProjectAlias = strings.TrimSpace(req.ProjectAlias)
 	if strings.TrimSpace(req.Title) == "" {
-		return "", errors.New("title is required")
+		return "", "", errors.New("title is required")
 	}
 
 	isNew := req.Path == ""
@@ -158,14 +195,14 @@ func (s *Service) Save(ctx context.Context, req SaveRequest) (string, error) {
 		// Kind is a closed enum: reject anything unrecognized here rather than
 		// leaving docFile nil and panicking on the dereferences below (or in
 		// WriteFile). Empty is already normalized to "document" above.
-		return "", fmt.Errorf("unsupported document kind: %q", kind)
+		return "", "", fmt.Errorf("unsupported document kind: %q", kind)
 	}
 
 	if !isNew {
 		existing, err := s.fm.ReadFile(docPath)
 		if err != nil {
 			logger.WithError(err).WithField("path", docPath).Error("failed to read existing document")
-			return "", fmt.Errorf("reading existing document: %w", err)
+			return "", "", fmt.Errorf("reading existing document: %w", err)
 		}
 		docFile.Meta.Created = existing.Meta.Created
 		// Preserve aliases across saves: SaveRequest carries none, and the file
@@ -183,7 +220,7 @@ func (s *Service) Save(ctx context.Context, req SaveRequest) (string, error) {
 			existingKind = DocumentKindDocument
 		}
 		if existingKind != kind {
-			return "", fmt.Errorf("cannot change document kind from %q to %q", existingKind, kind)
+			return "", "", fmt.Errorf("cannot change document kind from %q to %q", existingKind, kind)
 		}
 
 		if req.ExpectedHash != "" {
@@ -194,32 +231,41 @@ func (s *Service) Save(ctx context.Context, req SaveRequest) (string, error) {
 					"expectedHash": req.ExpectedHash,
 					"currentHash":  currentHash,
 				}).Warn("document conflict detected: file was modified externally")
-				return "", ErrConflict
+				return "", "", &ConflictError{Path: docPath, CurrentHash: currentHash, Current: existing}
 			}
 		}
 	}
 
+	if req.Aliases != nil {
+		docFile.Meta.Aliases = req.Aliases
+	}
+	if isNew && !req.Created.IsZero() {
+		docFile.Meta.Created = req.Created
+	}
+	if !req.Updated.IsZero() {
+		docFile.Meta.Updated = req.Updated
+	}
+
 	if err := s.fm.WriteFile(docPath, docFile); err != nil {
 		logger.WithError(err).WithField("path", docPath).Error("failed to write document file")
-		return "", fmt.Errorf("writing document file: %w", err)
+		return "", "", fmt.Errorf("writing document file: %w", err)
 	}
 
-	// Tell the watcher the content we just wrote so its debounced filesystem
-	// event for this write is recognized as a self-write and not reported as an
-	// external change. The hash is read back from disk so it is computed exactly
-	// as the watcher computes it (post-normalization), independent of any timing.
-	if s.watcher != nil {
-		if writtenHash, err := s.GetDocumentHash(ctx, docPath); err == nil {
-			s.watcher.NoteAppWrite(docPath, writtenHash)
-		} else {
-			logger.WithError(err).WithField("path", docPath).Warn("failed to hash written document for watcher reconciliation")
-		}
+	// The hash is read back from disk so it is computed exactly as the watcher
+	// and later hash checks compute it (post-normalization). The watcher is
+	// told it, so its debounced filesystem event for this write is recognized
+	// as a self-write and not reported as an external change.
+	writtenHash, err := s.GetDocumentHash(ctx, docPath)
+	if err != nil {
+		logger.WithError(err).WithField("path", docPath).Warn("failed to hash written document for watcher reconciliation")
+	} else if s.watcher != nil {
+		s.watcher.NoteAppWrite(docPath, writtenHash)
 	}
 
 	if err := s.indexer.IndexDocument(ctx, docPath); err != nil {
 		logger.WithError(err).WithField("path", docPath).Error("failed to index document")
 		_ = s.fm.DeleteFile(docPath)
-		return "", fmt.Errorf("indexing document: %w", err)
+		return "", "", fmt.Errorf("indexing document: %w", err)
 	}
 
 	projectID := req.ProjectAlias
@@ -248,7 +294,7 @@ func (s *Service) Save(ctx context.Context, req SaveRequest) (string, error) {
 		"isNew":   isNew,
 	}).Info("document saved")
 
-	return docPath, nil
+	return docPath, writtenHash, nil
 }
 
 func (s *Service) GetDocumentHash(ctx context.Context, path string) (string, error) {
@@ -259,6 +305,130 @@ func (s *Service) GetDocumentHash(ctx context.Context, path string) (string, err
 	return ComputeFileHash(file), nil
 }
 
+// EditRequest describes a block-level change to a document. The block it
+// applies to is named by BlockID or, instead, by the slug of a heading.
+// ExpectedHash is the GetDocumentHash value the edit was based on; it is
+// required, and the edit fails with ErrConflict once the document has
+// changed since.
+type EditRequest struct {
+	Path         string
+	ExpectedHash string
+	BlockID      string
+	Heading      string
+	Blocks       []BlockNoteBlock
+}
+
+func (req EditRequest) target(blocks []BlockNoteBlock) (string, error) {
+	switch {
+	case req.BlockID != "" && req.Heading != "":
+		return "", errors.New("give either a block ID or a heading, not both")
+	case req.BlockID != "":
+		return req.BlockID, nil
+	case req.Heading != "":
+		id, ok := HeadingID(blocks, req.Heading)
+		if !ok {
+			return "", fmt.Errorf("%w: no heading %q", ErrBlockNotFound, req.Heading)
+		}
+		return id, nil
+	default:
+		return "", errors.New("block ID or heading is required")
+	}
+}
+
+// InsertBlocks inserts req.Blocks directly after the target block. For a
+// heading that is the start of its section. It returns the new document hash.
+func (s *Service) InsertBlocks(ctx context.Context, req EditRequest) (string, error) {
+	return s.edit(ctx, req, func(blocks []BlockNoteBlock) ([]BlockNoteBlock, error) {
+		id, err := req.target(blocks)
+		if err != nil {
+			return nil, err
+		}
+		return InsertBlocksAfter(blocks, id, req.Blocks)
+	})
+}
+
+// AppendBlocks adds req.Blocks to the end of the document. It returns the
+// new document hash.
+func (s *Service) AppendBlocks(ctx context.Context, req EditRequest) (string, error) {
+	return s.edit(ctx, req, func(blocks []BlockNoteBlock) ([]BlockNoteBlock, error) {
+		return append(blocks, req.Blocks...), nil
+	})
+}
+
+// ReplaceSection replaces the content of the target heading's section with
+// req.Blocks, keeping the heading itself. It returns the new document hash.
+func (s *Service) ReplaceSection(ctx context.Context, req EditRequest) (string, error) {
+	return s.edit(ctx, req, func(blocks []BlockNoteBlock) ([]BlockNoteBlock, error) {
+		id, err := req.target(blocks)
+		if err != nil {
+			return nil, err
+		}
+		return ReplaceSection(blocks, id, req.Blocks)
+	})
+}
+
+// DeleteBlock removes the target block and its children. It returns the new
+// document hash.
+func (s *Service) DeleteBlock(ctx context.Context, req EditRequest) (string, error) {
+	return s.edit(ctx, req, func(blocks []BlockNoteBlock) ([]BlockNoteBlock, error) {
+		id, err := req.target(blocks)
+		if err != nil {
+			return nil, err
+		}
+		return DeleteBlock(blocks, id)
+	})
+}
+
+// ToggleCheckListItem checks or unchecks the target checklist item. It
+// returns the new document hash.
+func (s *Service) ToggleCheckListItem(ctx context.Context, req EditRequest) (string, error) {
+	return s.edit(ctx, req, func(blocks []BlockNoteBlock) ([]BlockNoteBlock, error) {
+		id, err := req.target(blocks)
+		if err != nil {
+			return nil, err
+		}
+		return ToggleCheckListItem(blocks, id)
+	})
+}
+
+// edit applies a block-level change through SaveWithHash, which repeats the
+// hash check under the save lock, so an edit racing another save cannot win,
+// and returns the hash of the edit itself rather than of a later save.
+func (s *Service) edit(ctx context.Context, req EditRequest, apply func([]BlockNoteBlock) ([]BlockNoteBlock, error)) (string, error) {
+	if strings.TrimSpace(req.Path) == "" {
+		return "", errors.New("path is required")
+	}
+	if req.ExpectedHash == "" {
+		return "", errors.New("expected hash is required")
+	}
+
+	file, err := s.fm.ReadFile(req.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading document file: %w", err)
+	}
+	if file.Kind == DocumentKindCanvas {
+		return "", errors.New("canvas documents have no blocks to edit")
+	}
+	if currentHash := ComputeFileHash(file); currentHash != req.ExpectedHash {
+		return "", &ConflictError{Path: req.Path, CurrentHash: currentHash, Current: file}
+	}
+
+	blocks, err := apply(file.Blocks)
+	if err != nil {
+		return "", err
+	}
+
+	_, hash, err := s.SaveWithHash(ctx, SaveRequest{
+		Path:         req.Path,
+		ProjectAlias: file.Meta.Project,
+		Title:        file.Meta.Title,
+		Blocks:       blocks,
+		Tags:         file.Meta.Tags,
+		ExpectedHash: req.ExpectedHash,
+	})
+	return hash, err
+}
+
 type DocumentWithTags struct {
 	*Document
 	File *DocumentFile
@@ -327,6 +497,21 @@ func (s *Service) Get(ctx context.Context, path string) (*DocumentWithTags, erro
 	}, nil
 }
 
+// Outline returns the heading outline of a document as recorded in the
+// index: one entry per heading, in document order, with the slug that
+// "path#slug" links use to jump to it.
+func (s *Service) Outline(ctx context.Context, path string) ([]OutlineEntry, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("path is required")
+	}
+
+	outline, err := s.store.GetOutline(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("getting document outline: %w", err)
+	}
+	return outline, nil
+}
+
 // previewMaxBlocks caps how many blocks the finder preview renders, so a very
 // large note doesn't make the read-only preview editor sluggish. Notes are
 // rarely this long; the cap is a safety valve, not a common path.
@@ -3,6 +3,8 @@ package document
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -291,8 +293,8 @@ func TestService_Save_PreservesAliases(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	// Aliases aren't part of SaveRequest; inject them onto the persisted file to
-	// simulate a doc that already carries aliases.
+	// Inject aliases onto the persisted file to simulate a doc that already
+	// carries them; the update below leaves SaveRequest.Aliases nil.
 	fm := NewFileManager(v)
 	file, err := fm.ReadFile(path)
 	require.NoError(t, err)
@@ -313,6 +315,48 @@ func TestService_Save_PreservesAliases(t *testing.T) {
 	assert.Equal(t, []string{"nickname"}, after.Meta.Aliases, "aliases must survive a save")
 }
 
+func TestService_Save_ImportedMetadata(t *testing.T) {
+	service, v, cleanup := setupServiceTest(t)
+	defer cleanup()
+
+	created := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2021, 6, 2, 12, 0, 0, 0, time.UTC)
+
+	path, err := service.Save(context.Background(), SaveRequest{
+		ProjectAlias: "@test",
+		Title:        "Imported",
+		Blocks:       []BlockNoteBlock{},
+		Tags:         []string{},
+		Aliases:      []string{"old-name"},
+		Created:      created,
+		Updated:      updated,
+	})
+	require.NoError(t, err)
+
+	fm := NewFileManager(v)
+	file, err := fm.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old-name"}, file.Meta.Aliases)
+	assert.True(t, file.Meta.Created.Equal(created))
+	assert.True(t, file.Meta.Updated.Equal(updated))
+
+	_, err = service.Save(context.Background(), SaveRequest{
+		Path:         path,
+		ProjectAlias: "@test",
+		Title:        "Imported",
+		Blocks:       []BlockNoteBlock{},
+		Tags:         []string{},
+		Aliases:      []string{},
+	})
+	require.NoError(t, err)
+
+	after, err := fm.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, after.Meta.Aliases, "non-nil aliases replace the existing ones")
+	assert.True(t, after.Meta.Created.Equal(created))
+	assert.True(t, after.Meta.Updated.After(updated))
+}
+
 func TestService_Save_RejectsKindFlip(t *testing.T) {
 	service, _, cleanup := setupServiceTest(t)
 	defer cleanup()
@@ -452,6 +496,25 @@ func TestService_Save_ExpectedHash_Conflict(t *testing.T) {
 
 	_, err = service.Save(context.Background(), updateReq)
 	assert.ErrorIs(t, err, ErrConflict, "Expected ErrConflict for hash mismatch")
+
+	// The conflict carries the version on disk to rebase onto.
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	currentHash, err := service.GetDocumentHash(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, path, conflict.Path)
+	assert.Equal(t, currentHash, conflict.CurrentHash)
+	require.NotNil(t, conflict.Current)
+	assert.Equal(t, "Externally Modified", conflict.Current.Meta.Title)
+	assert.Contains(t, string(conflict.Current.Blocks[0].Content), "External change")
+
+	doc, err := service.Get(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, "Externally Modified", doc.Title, "a conflicting save must not write")
+
+	data, err := json.Marshal(conflict)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"current_hash":"`+currentHash+`"`)
 }
 
 func TestService_Save_ExpectedHash_Match(t *testing.T) {
@@ -1145,3 +1208,122 @@ func TestService_MoveToProject_NonexistentDocument(t *testing.T) {
 	err := service.MoveToProject(context.Background(), "projects/@test/doc-doesnotexist.json", "@other")
 	assert.Error(t, err)
 }
+
+func TestService_BlockEdits_ReturnTheirOwnHash(t *testing.T) {
+	service, _, cleanup := setupServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	path, hash, err := service.SaveWithHash(ctx, SaveRequest{
+		ProjectAlias: "@test",
+		Title:        "Spec",
+		Blocks:       editFixture(),
+	})
+	require.NoError(t, err)
+	current, err := service.GetDocumentHash(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, current, hash)
+
+	// Plain saves race the edits. An edit must return the hash of what it
+	// wrote, never that of a save that landed right after it.
+	const rounds = 30
+	saved := make(chan string, rounds)
+	go func() {
+		defer close(saved)
+		for i := range rounds {
+			_, h, err := service.SaveWithHash(ctx, SaveRequest{
+				Path:         path,
+				ProjectAlias: "@test",
+				Title:        fmt.Sprintf("Spec %d", i),
+				Blocks:       editFixture(),
+			})
+			if err == nil {
+				saved <- h
+			}
+		}
+	}()
+
+	edited := map[string]bool{}
+	for i := range rounds {
+		base, err := service.GetDocumentHash(ctx, path)
+		require.NoError(t, err)
+		h, err := service.AppendBlocks(ctx, EditRequest{
+			Path:         path,
+			ExpectedHash: base,
+			Blocks:       []BlockNoteBlock{paragraphBlock(fmt.Sprintf("a%d", i), "appended")},
+		})
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		require.NoError(t, err)
+		edited[h] = true
+	}
+	for h := range saved {
+		assert.False(t, edited[h], "an edit returned the hash of another save")
+	}
+}
+
+func TestService_BlockEdits(t *testing.T) {
+	service, _, cleanup := setupServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	path, err := service.Save(ctx, SaveRequest{
+		ProjectAlias: "@test",
+		Title:        "Spec",
+		Blocks:       editFixture(),
+	})
+	require.NoError(t, err)
+
+	hash, err := service.GetDocumentHash(ctx, path)
+	require.NoError(t, err)
+
+	hash, err = service.InsertBlocks(ctx, EditRequest{
+		Path:         path,
+		ExpectedHash: hash,
+		Heading:      "intro",
+		Blocks:       []BlockNoteBlock{paragraphBlock("x1", "first")},
+	})
+	require.NoError(t, err)
+
+	hash, err = service.ToggleCheckListItem(ctx, EditRequest{Path: path, ExpectedHash: hash, BlockID: "c1"})
+	require.NoError(t, err)
+
+	hash, err = service.ReplaceSection(ctx, EditRequest{
+		Path:         path,
+		ExpectedHash: hash,
+		Heading:      "done",
+		Blocks:       []BlockNoteBlock{paragraphBlock("x2", "released")},
+	})
+	require.NoError(t, err)
+
+	hash, err = service.DeleteBlock(ctx, EditRequest{Path: path, ExpectedHash: hash, BlockID: "p1"})
+	require.NoError(t, err)
+
+	stale := hash
+	hash, err = service.AppendBlocks(ctx, EditRequest{
+		Path:         path,
+		ExpectedHash: hash,
+		Blocks:       []BlockNoteBlock{paragraphBlock("x3", "the end")},
+	})
+	require.NoError(t, err)
+
+	current, err := service.GetDocumentHash(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, current, hash, "edits should return the new document hash")
+
+	doc, err := service.Get(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"intro", "x1", "todo", "c1", "n1", "done", "x2", "x3"}, blockIDs(doc.File.Blocks))
+	assert.True(t, PropBool(doc.File.Blocks[3].Props, "checked", false))
+	assert.Equal(t, "Spec", doc.Title)
+
+	_, err = service.DeleteBlock(ctx, EditRequest{Path: path, ExpectedHash: stale, BlockID: "x1"})
+	assert.ErrorIs(t, err, ErrConflict, "an edit based on a stale hash must be rejected")
+
+	_, err = service.DeleteBlock(ctx, EditRequest{Path: path, BlockID: "x1"})
+	assert.Error(t, err, "the expected hash is required")
+
+	_, err = service.InsertBlocks(ctx, EditRequest{Path: path, ExpectedHash: hash, Heading: "missing"})
+	assert.ErrorIs(t, err, ErrBlockNotFound)
+}
@@ -202,6 +202,17 @@ func (s *Store) SoftDeleteByProjectTx(ctx context.Context, tx *sql.Tx, projectAl
 	return s.softDeleteByProject(ctx, tx, projectAlias)
 }
 
+// GetOutline returns the indexed heading outline of a document, in
+// document order.
+func (s *Store) GetOutline(ctx context.Context, path string) ([]OutlineEntry, error) {
+	return s.getOutline(ctx, s.db, path)
+}
+
+// ReplaceOutlineTx replaces the indexed heading outline of a document.
+func (s *Store) ReplaceOutlineTx(ctx context.Context, tx *sql.Tx, path string, outline []OutlineEntry) error {
+	return s.replaceOutline(ctx, tx, path, outline)
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
@@ -580,3 +591,52 @@ func (s *Store) softDeleteByProject(ctx context.Context, qe queryExecer, project
 
 	return nil
 }
+
+func (s *Store) getOutline(ctx context.Context, q queryer, path string) ([]OutlineEntry, error) {
+	query := `
+		SELECT level, text, block_id, slug
+		FROM doc_heading
+		WHERE path = ?
+		ORDER BY position;
+	`
+
+	rows, err := q.QueryContext(ctx, query, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query document outline: %w", err)
+	}
+	defer rows.Close()
+
+	outline := []OutlineEntry{}
+	for rows.Next() {
+		var e OutlineEntry
+		if err := rows.Scan(&e.Level, &e.Text, &e.BlockID, &e.Slug); err != nil {
+			return nil, fmt.Errorf("failed to scan outline entry: %w", err)
+		}
+		outline = append(outline, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outline: %w", err)
+	}
+
+	return outline, nil
+}
+
+func (s *Store) replaceOutline(ctx context.Context, qe queryExecer, path string, outline []OutlineEntry) error {
+	if _, err := qe.ExecContext(ctx, `DELETE FROM doc_heading WHERE path = ?;`, path); err != nil {
+		return fmt.Errorf("failed to clear document outline: %w", err)
+	}
+
+	query := `
+		INSERT INTO doc_heading (path, position, level, text, block_id, slug)
+		VALUES (?, ?, ?, ?, ?, ?);
+	`
+
+	for i, e := range outline {
+		if _, err := qe.ExecContext(ctx, query, path, i, e.Level, e.Text, e.BlockID, e.Slug); err != nil {
+			return fmt.Errorf("failed to insert outline entry: %w", err)
+		}
+	}
+
+	return nil
+}
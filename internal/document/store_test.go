@@ -1290,3 +1290,48 @@ func stringPtr(s string) *string {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestStore_Outline(t *testing.T) {
+	store, cleanup := setupStoreTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	created, err := store.Create(ctx, New(docPath(testProjectAlias, "outline/document.json"), testProjectAlias, "Outline Document",
+		WithModificationTime(1640995200000000000),
+		WithSize(1024),
+	))
+	require.NoError(t, err)
+
+	outline, err := store.GetOutline(ctx, created.Path)
+	require.NoError(t, err)
+	assert.Empty(t, outline)
+
+	want := []OutlineEntry{
+		{Level: 1, Text: "Intro", BlockID: "b1", Slug: "intro"},
+		{Level: 2, Text: "Setup", BlockID: "b2", Slug: "setup"},
+	}
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.ReplaceOutlineTx(ctx, tx, created.Path, want))
+	require.NoError(t, tx.Commit())
+
+	outline, err = store.GetOutline(ctx, created.Path)
+	require.NoError(t, err)
+	assert.Equal(t, want, outline)
+
+	tx, err = store.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.ReplaceOutlineTx(ctx, tx, created.Path, want[1:]))
+	require.NoError(t, tx.Commit())
+
+	outline, err = store.GetOutline(ctx, created.Path)
+	require.NoError(t, err)
+	assert.Equal(t, want[1:], outline)
+
+	require.NoError(t, store.HardDelete(ctx, created.Path))
+	outline, err = store.GetOutline(ctx, created.Path)
+	require.NoError(t, err)
+	assert.Empty(t, outline, "outline should be removed with the document")
+}
@@ -15,13 +15,23 @@ import (
 //  2. Services receive EventBus in constructor (clean DI)
 //  3. EventBus.Connect() called after app creation
 //  4. Events emitted before connection are buffered
+//
+// In-process listeners registered with Subscribe see every event as it is
+// emitted, whether or not the bus is connected yet.
 type EventBus struct {
-	mu       sync.RWMutex
-	app      *application.App
-	window   *application.WebviewWindow
-	buffered []bufferedEvent
+	mu        sync.RWMutex
+	app       *application.App
+	window    *application.WebviewWindow
+	buffered  []bufferedEvent
+	listeners map[int]Listener
+	nextID    int
+	headless  bool
 }
 
+// Listener receives events emitted on the bus. It runs on the emitting
+// goroutine, so it must not block.
+type Listener func(name string, data any)
+
 type bufferedEvent struct {
 	name string
 	data any
@@ -33,6 +43,13 @@ func NewEventBus() *EventBus {
 	}
 }
 
+// NewHeadlessEventBus returns a bus for processes without a UI, such as
+// `yanta mcp --standalone`: events reach Subscribe listeners only and are
+// never buffered.
+func NewHeadlessEventBus() *EventBus {
+	return &EventBus{headless: true}
+}
+
 func (eb *EventBus) Connect(app *application.App, window *application.WebviewWindow) {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
@@ -48,11 +65,42 @@ func (eb *EventBus) Connect(app *application.App, window *application.WebviewWin
 	eb.buffered = nil
 }
 
+// Subscribe registers an in-process listener and returns a function that
+// removes it again.
+func (eb *EventBus) Subscribe(fn Listener) (unsubscribe func()) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if eb.listeners == nil {
+		eb.listeners = make(map[int]Listener)
+	}
+	id := eb.nextID
+	eb.nextID++
+	eb.listeners[id] = fn
+
+	return func() {
+		eb.mu.Lock()
+		defer eb.mu.Unlock()
+		delete(eb.listeners, id)
+	}
+}
+
 func (eb *EventBus) Emit(name string, data any) {
 	eb.mu.RLock()
 	app := eb.app
+	listeners := make([]Listener, 0, len(eb.listeners))
+	for _, fn := range eb.listeners {
+		listeners = append(listeners, fn)
+	}
 	eb.mu.RUnlock()
 
+	for _, fn := range listeners {
+		fn(name, data)
+	}
+	if eb.headless {
+		return
+	}
+
 	if app != nil {
 		app.Event.Emit(name, data)
 	} else {
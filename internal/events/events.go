@@ -57,6 +57,10 @@ type ThemeChangedData struct {
 	Theme string `json:"theme"`
 }
 
+type PluginReloadedData struct {
+	ID string `json:"id"`
+}
+
 func init() {
 	application.RegisterEvent[EntryCreatedData](EntryCreated)
 	application.RegisterEvent[EntryUpdatedData](EntryUpdated)
@@ -66,6 +70,7 @@ func init() {
 	application.RegisterEvent[EntryExternalChangeData](EntryExternalChange)
 	application.RegisterEvent[ProjectChangedData](ProjectChanged)
 	application.RegisterEvent[ThemeChangedData](ThemeChanged)
+	application.RegisterEvent[PluginReloadedData](PluginReloaded)
 }
 
 const (
@@ -99,4 +104,5 @@ const (
 	EntryCountChanged   = "yanta/project/entry-count"            // payload: {projectId, count}
 	VaultReindexed      = "yanta/vault/reindexed"                // payload: {reason}; vault content changed wholesale (sync pull / manual reindex)
 	EntryExternalChange = "yanta/entry/external-change"          // payload: {path}; file changed on disk outside the app
+	PluginReloaded      = "yanta/plugin/reloaded"                // payload: {id}; a dev-linked plugin changed on disk
 )
@@ -0,0 +1,337 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"yanta/internal/document"
+	"yanta/internal/journal"
+	"yanta/internal/logger"
+)
+
+// JournalProvider reads a project's journal for book exports.
+type JournalProvider interface {
+	ListDates(ctx context.Context, projectAlias string, year, month int) ([]string, error)
+	GetActiveEntries(ctx context.Context, projectAlias, date string) ([]journal.JournalEntry, error)
+}
+
+// BookOrder selects the chapter order of a book export.
+type BookOrder string
+
+const (
+	// BookOrderTitle sorts chapters alphabetically by title.
+	BookOrderTitle BookOrder = "title"
+	// BookOrderUpdated puts the most recently updated documents first.
+	BookOrderUpdated BookOrder = "updated"
+	// BookOrderExplicit keeps the order of DocumentPaths.
+	BookOrderExplicit BookOrder = "explicit"
+)
+
+// BookExportRequest describes a multi-document PDF export. Either a project
+// or an explicit set of documents (for example search results) is exported;
+// when both are given, DocumentPaths wins and ProjectAlias is only used for
+// the journal chapter and the cover.
+type BookExportRequest struct {
+	Title         string
+	ProjectAlias  string
+	DocumentPaths []string
+	// Order defaults to explicit when DocumentPaths is set, title otherwise.
+	Order      BookOrder
+	OutputPath string
+
+	// IncludeJournal appends the project's journal as a final chapter.
+	// JournalFrom and JournalTo (YYYY-MM-DD, inclusive) bound the dates; an
+	// empty bound is open.
+	IncludeJournal bool
+	JournalFrom    string
+	JournalTo      string
+
+	PDFOptions
+}
+
+type bookJournalDay struct {
+	date    string
+	entries []journal.JournalEntry
+}
+
+// ExportBookToPDF writes several documents into one PDF with a cover page and
+// one chapter per document. Links between exported documents become internal
+// PDF links; links to documents outside the book keep their text only.
+func (s *Service) ExportBookToPDF(ctx context.Context, req BookExportRequest) error {
+	if s.docService == nil || s.vault == nil {
+		return fmt.Errorf("service not initialised correctly")
+	}
+
+	if err := s.validateBookRequest(&req); err != nil {
+		return err
+	}
+
+	logger.WithFields(map[string]any{
+		"projectAlias": req.ProjectAlias,
+		"documents":    len(req.DocumentPaths),
+		"order":        req.Order,
+		"outputPath":   req.OutputPath,
+	}).Info("starting PDF book export")
+
+	docs, err := s.loadBookDocuments(ctx, req)
+	if err != nil {
+		return err
+	}
+	sortBookDocuments(docs, req.Order)
+
+	var days []bookJournalDay
+	if req.IncludeJournal {
+		days, err = s.loadJournalDays(ctx, req.ProjectAlias, req.JournalFrom, req.JournalTo)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(docs) == 0 && len(days) == 0 {
+		return fmt.Errorf("nothing to export")
+	}
+
+	title := req.Title
+	if strings.TrimSpace(title) == "" {
+		title = req.ProjectAlias
+	}
+	if strings.TrimSpace(title) == "" {
+		title = "Documents"
+	}
+
+	cover := func(pdf *PDF) {
+		var lines []string
+		if req.ProjectAlias != "" && req.ProjectAlias != title {
+			lines = append(lines, req.ProjectAlias)
+		}
+		lines = append(lines,
+			fmt.Sprintf("%d documents", len(docs)),
+			time.Now().Format("2 January 2006"),
+		)
+		pdf.AddCoverPage(title, lines...)
+	}
+
+	pdf, err := buildPDF(PDFConfig{
+		Title:      title,
+		Author:     "Yanta",
+		Subject:    req.ProjectAlias,
+		PDFOptions: req.PDFOptions,
+	}, cover, func(pdf *PDF) error {
+		return s.renderBook(pdf, docs, days)
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to render PDF book")
+		return fmt.Errorf("rendering PDF: %w", err)
+	}
+
+	outputDir := filepath.Dir(req.OutputPath)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		logger.WithError(err).WithField("dir", outputDir).Error("failed to create output directory")
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	if err := pdf.SaveToFile(req.OutputPath); err != nil {
+		logger.WithError(err).WithField("path", req.OutputPath).Error("failed to save PDF")
+		return fmt.Errorf("saving PDF: %w", err)
+	}
+
+	logger.WithFields(map[string]any{
+		"outputPath":  req.OutputPath,
+		"chapters":    len(docs),
+		"journalDays": len(days),
+	}).Info("PDF book export completed successfully")
+
+	return nil
+}
+
+func (s *Service) validateBookRequest(req *BookExportRequest) error {
+	if strings.TrimSpace(req.OutputPath) == "" {
+		return fmt.Errorf("output path is required")
+	}
+
+	if strings.TrimSpace(req.ProjectAlias) == "" && len(req.DocumentPaths) == 0 {
+		return fmt.Errorf("project alias or document paths are required")
+	}
+
+	if len(req.DocumentPaths) == 0 && s.docLister == nil {
+		return fmt.Errorf("service not initialised correctly")
+	}
+
+	switch req.Order {
+	case "":
+		req.Order = BookOrderTitle
+		if len(req.DocumentPaths) > 0 {
+			req.Order = BookOrderExplicit
+		}
+	case BookOrderTitle, BookOrderUpdated:
+	case BookOrderExplicit:
+		if len(req.DocumentPaths) == 0 {
+			return fmt.Errorf("explicit order requires document paths")
+		}
+	default:
+		return fmt.Errorf("unsupported order: %s", req.Order)
+	}
+
+	if req.IncludeJournal {
+		if s.journal == nil {
+			return fmt.Errorf("service not initialised correctly")
+		}
+		if strings.TrimSpace(req.ProjectAlias) == "" {
+			return fmt.Errorf("project alias is required to include the journal")
+		}
+		for _, date := range []string{req.JournalFrom, req.JournalTo} {
+			if date == "" {
+				continue
+			}
+			if err := journal.ValidateDate(date); err != nil {
+				return fmt.Errorf("invalid journal date: %w", err)
+			}
+		}
+		if req.JournalFrom != "" && req.JournalTo != "" && req.JournalFrom > req.JournalTo {
+			return fmt.Errorf("journal start date is after end date")
+		}
+	}
+
+	_, err := pdfPageSize(req.PageSize)
+	return err
+}
+
+func (s *Service) loadBookDocuments(ctx context.Context, req BookExportRequest) ([]*document.DocumentWithTags, error) {
+	if len(req.DocumentPaths) == 0 {
+		return s.loadProjectDocuments(ctx, req.ProjectAlias)
+	}
+
+	seen := make(map[string]bool, len(req.DocumentPaths))
+	docs := make([]*document.DocumentWithTags, 0, len(req.DocumentPaths))
+	for _, docPath := range req.DocumentPaths {
+		if seen[docPath] {
+			continue
+		}
+		seen[docPath] = true
+
+		doc, err := s.docService.Get(ctx, docPath)
+		if err != nil {
+			logger.WithError(err).WithField("path", docPath).Error("failed to get document")
+			return nil, fmt.Errorf("getting document %s: %w", docPath, err)
+		}
+		if doc.File == nil {
+			return nil, fmt.Errorf("document file is nil: %s", docPath)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func sortBookDocuments(docs []*document.DocumentWithTags, order BookOrder) {
+	switch order {
+	case BookOrderTitle:
+		sort.SliceStable(docs, func(i, j int) bool {
+			return strings.ToLower(docs[i].File.Meta.Title) < strings.ToLower(docs[j].File.Meta.Title)
+		})
+	case BookOrderUpdated:
+		sort.SliceStable(docs, func(i, j int) bool {
+			return docs[i].File.Meta.Updated.After(docs[j].File.Meta.Updated)
+		})
+	}
+}
+
+// loadJournalDays returns the non-empty journal days in [from, to], oldest
+// first.
+func (s *Service) loadJournalDays(ctx context.Context, projectAlias, from, to string) ([]bookJournalDay, error) {
+	dates, err := s.journal.ListDates(ctx, projectAlias, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing journal dates: %w", err)
+	}
+	sort.Strings(dates)
+
+	var days []bookJournalDay
+	for _, date := range dates {
+		if (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+
+		entries, err := s.journal.GetActiveEntries(ctx, projectAlias, date)
+		if err != nil {
+			return nil, fmt.Errorf("reading journal for %s: %w", date, err)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Created.Before(entries[j].Created)
+		})
+		days = append(days, bookJournalDay{date: date, entries: entries})
+	}
+	return days, nil
+}
+
+// renderBook writes one chapter per document, each on a new page, followed by
+// the journal chapter. Chapter links are created up front so documents can
+// link forward to chapters not yet written.
+func (s *Service) renderBook(pdf *PDF, docs []*document.DocumentWithTags, days []bookJournalDay) error {
+	chapters := make(map[string]int, len(docs))
+	for _, doc := range docs {
+		chapters[doc.Path] = pdf.AddLink()
+	}
+
+	resolveLink := func(href string) int {
+		docPath, _, ok := vaultDocumentLink(href)
+		if !ok {
+			return 0
+		}
+		return chapters[docPath]
+	}
+
+	for i, doc := range docs {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		pdf.SetLinkTarget(chapters[doc.Path])
+		pdf.AddHeading(1, doc.File.Meta.Title)
+
+		if len(doc.Tags) > 0 {
+			pdf.AddRichParagraph([]TextSpan{{Text: "#" + strings.Join(doc.Tags, "  #"), Italic: true}})
+		}
+
+		if doc.File.Kind == document.DocumentKindCanvas {
+			pdf.AddRichParagraph([]TextSpan{{Text: "Canvas document — visual content is only viewable in the app.", Italic: true}})
+			continue
+		}
+
+		renderer := NewRenderer(pdf, s.vault, doc.File.Meta.Project)
+		renderer.ResolveLink = resolveLink
+		renderer.HeadingOffset = 1
+		if err := renderer.RenderBlocks(doc.File.Blocks); err != nil {
+			return fmt.Errorf("rendering %s: %w", doc.Path, err)
+		}
+	}
+
+	if len(days) == 0 {
+		return nil
+	}
+
+	if len(docs) > 0 {
+		pdf.AddPage()
+	}
+	pdf.AddHeading(1, "Journal")
+	for _, day := range days {
+		pdf.AddHeading(2, day.date)
+		for _, entry := range day.entries {
+			spans := []TextSpan{
+				{Text: entry.Created.Local().Format("15:04") + "  ", Bold: true},
+				{Text: entry.Content},
+			}
+			if len(entry.Tags) > 0 {
+				spans = append(spans, TextSpan{Text: "  #" + strings.Join(entry.Tags, " #"), Italic: true})
+			}
+			pdf.AddRichParagraph(spans)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yanta/internal/document"
+	"yanta/internal/journal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockJournal struct {
+	days map[string][]journal.JournalEntry
+}
+
+func (m *mockJournal) ListDates(ctx context.Context, projectAlias string, year, month int) ([]string, error) {
+	var dates []string
+	for date := range m.days {
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+func (m *mockJournal) GetActiveEntries(ctx context.Context, projectAlias, date string) ([]journal.JournalEntry, error) {
+	return m.days[date], nil
+}
+
+func setupBookTest(t *testing.T) (*Service, *mockSiteDocuments) {
+	t.Helper()
+
+	link := func(text, href string) document.BlockNoteBlock {
+		return document.BlockNoteBlock{
+			Type: "paragraph",
+			Content: mustMarshalContent([]document.BlockNoteContent{
+				{Type: "text", Text: "See "},
+				{Type: "link", Href: href, Content: []document.BlockNoteContent{{Type: "text", Text: text}}},
+			}),
+		}
+	}
+	heading := document.BlockNoteBlock{
+		Type:    "heading",
+		Props:   map[string]any{"level": float64(1)},
+		Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Overview"}}),
+	}
+
+	alpha := siteDoc("projects/@test/doc-a.json", "Alpha", []string{"x"}, heading, link("Beta", "projects/@test/doc-b.json"))
+	beta := siteDoc("projects/@test/doc-b.json", "Beta", nil, link("Alpha", "projects/@test/doc-a.json"))
+	beta.File.Meta.Updated = alpha.File.Meta.Updated.Add(time.Hour)
+
+	docs := &mockSiteDocuments{docs: map[string]*document.DocumentWithTags{
+		alpha.Path: alpha,
+		beta.Path:  beta,
+	}}
+
+	tmpDir := t.TempDir()
+	service := NewService(ServiceConfig{
+		DocumentService: docs,
+		DocumentLister:  docs,
+		Journal: &mockJournal{days: map[string][]journal.JournalEntry{
+			"2025-01-01": {{ID: "1", Content: "old entry", Created: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)}},
+			"2025-01-05": {{ID: "2", Content: "kept entry", Tags: []string{"work"}, Created: time.Date(2025, 1, 5, 9, 0, 0, 0, time.UTC)}},
+		}},
+		Vault: &mockVaultProvider{rootPath: tmpDir, assetsPath: filepath.Join(tmpDir, "assets")},
+	})
+	return service, docs
+}
+
+func TestService_ExportBookToPDF(t *testing.T) {
+	service, _ := setupBookTest(t)
+	out := filepath.Join(t.TempDir(), "book", "project.pdf")
+
+	err := service.ExportBookToPDF(context.Background(), BookExportRequest{
+		ProjectAlias:   "@test",
+		Order:          BookOrderUpdated,
+		OutputPath:     out,
+		IncludeJournal: true,
+		JournalFrom:    "2025-01-02",
+		PDFOptions:     PDFOptions{TableOfContents: true},
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF")))
+	// Cross-document references and contents entries are internal links.
+	assert.Contains(t, string(data), "/Dest [")
+}
+
+func TestService_ExportBookToPDF_ExplicitDocuments(t *testing.T) {
+	service, _ := setupBookTest(t)
+	out := filepath.Join(t.TempDir(), "results.pdf")
+
+	err := service.ExportBookToPDF(context.Background(), BookExportRequest{
+		Title:         "Search results",
+		DocumentPaths: []string{"projects/@test/doc-b.json"},
+		OutputPath:    out,
+	})
+	require.NoError(t, err)
+
+	_, err = os.Stat(out)
+	assert.NoError(t, err)
+
+	err = service.ExportBookToPDF(context.Background(), BookExportRequest{
+		DocumentPaths: []string{"projects/@test/missing.json"},
+		OutputPath:    out,
+	})
+	assert.ErrorContains(t, err, "getting document")
+}
+
+func TestService_ExportBookToPDF_Validation(t *testing.T) {
+	service, _ := setupBookTest(t)
+	out := filepath.Join(t.TempDir(), "book.pdf")
+
+	tests := []struct {
+		name    string
+		req     BookExportRequest
+		wantErr string
+	}{
+		{"no output", BookExportRequest{ProjectAlias: "@test"}, "output path is required"},
+		{"no source", BookExportRequest{OutputPath: out}, "project alias or document paths are required"},
+		{"bad order", BookExportRequest{ProjectAlias: "@test", OutputPath: out, Order: "random"}, "unsupported order"},
+		{"explicit without paths", BookExportRequest{ProjectAlias: "@test", OutputPath: out, Order: BookOrderExplicit}, "explicit order requires document paths"},
+		{"journal without project", BookExportRequest{DocumentPaths: []string{"projects/@test/doc-a.json"}, OutputPath: out, IncludeJournal: true}, "project alias is required"},
+		{"bad journal date", BookExportRequest{ProjectAlias: "@test", OutputPath: out, IncludeJournal: true, JournalFrom: "01/02/2025"}, "invalid journal date"},
+		{"reversed range", BookExportRequest{ProjectAlias: "@test", OutputPath: out, IncludeJournal: true, JournalFrom: "2025-02-01", JournalTo: "2025-01-01"}, "after end date"},
+		{"bad page size", BookExportRequest{ProjectAlias: "@test", OutputPath: out, PDFOptions: PDFOptions{PageSize: "B9"}}, "unsupported page size"},
+		{"empty project", BookExportRequest{ProjectAlias: "@empty", OutputPath: out}, "nothing to export"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ExportBookToPDF(context.Background(), tt.req)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSortBookDocuments(t *testing.T) {
+	_, docs := setupBookTest(t)
+	list := []*document.DocumentWithTags{docs.docs["projects/@test/doc-b.json"], docs.docs["projects/@test/doc-a.json"]}
+
+	sortBookDocuments(list, BookOrderTitle)
+	assert.Equal(t, "Alpha", list[0].File.Meta.Title)
+
+	sortBookDocuments(list, BookOrderUpdated)
+	assert.Equal(t, "Beta", list[0].File.Meta.Title)
+}
+
+func TestService_LoadJournalDays(t *testing.T) {
+	service, _ := setupBookTest(t)
+
+	days, err := service.loadJournalDays(context.Background(), "@test", "2025-01-02", "")
+	require.NoError(t, err)
+	require.Len(t, days, 1)
+	assert.Equal(t, "2025-01-05", days[0].date)
+
+	days, err = service.loadJournalDays(context.Background(), "@test", "", "")
+	require.NoError(t, err)
+	assert.Len(t, days, 2)
+	assert.Equal(t, "2025-01-01", days[0].date)
+}
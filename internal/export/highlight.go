@@ -0,0 +1,267 @@
+package export
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies a run of source code for colouring in PDF code blocks.
+type tokenKind int
+
+const (
+	tokenPlain tokenKind = iota
+	tokenKeyword
+	tokenString
+	tokenComment
+	tokenNumber
+)
+
+type codeToken struct {
+	Kind tokenKind
+	Text string
+}
+
+// languageSyntax is the minimal lexical description the highlighter needs.
+// It is deliberately coarse: good enough to colour keywords, strings, numbers
+// and comments for the languages notes usually contain, without pulling in a
+// full lexer dependency.
+type languageSyntax struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var (
+	cFamilyComments = []string{"//"}
+	hashComments    = []string{"#"}
+
+	syntaxGo = &languageSyntax{
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto if
+			import interface map package range return select struct switch type var nil true false iota`),
+		lineComments: cFamilyComments,
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	}
+	syntaxJS = &languageSyntax{
+		keywords: words(`async await break case catch class const continue debugger default delete do else
+			export extends finally for from function if import in instanceof interface let new null of return
+			static super switch this throw try type typeof undefined var void while yield true false enum
+			implements private protected public readonly`),
+		lineComments: cFamilyComments,
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	}
+	syntaxPython = &languageSyntax{
+		keywords: words(`and as assert async await break class continue def del elif else except finally for
+			from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self`),
+		lineComments: hashComments,
+		quotes:       "\"'",
+	}
+	syntaxRust = &languageSyntax{
+		keywords: words(`as async await break const continue crate dyn else enum extern false fn for if impl in
+			let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while`),
+		lineComments: cFamilyComments,
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"",
+	}
+	syntaxC = &languageSyntax{
+		keywords: words(`auto break case char class const continue default delete do double else enum extern
+			final float for goto if import int long new null nullptr package private protected public return short
+			signed sizeof static struct switch template this throw try typedef union unsigned using virtual void
+			volatile while boolean byte extends implements interface true false namespace`),
+		lineComments: cFamilyComments,
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+	}
+	syntaxShell = &languageSyntax{
+		keywords:     words(`if then else elif fi for while until do done case esac in function return export local echo`),
+		lineComments: hashComments,
+		quotes:       "\"'",
+	}
+	syntaxSQL = &languageSyntax{
+		keywords: words(`select from where insert into values update set delete create table drop alter index
+			join left right inner outer on and or not null as order by group having limit offset distinct union
+			primary key foreign references begin commit rollback`),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+	}
+	syntaxYAML = &languageSyntax{
+		keywords:     words(`true false null yes no on off`),
+		lineComments: hashComments,
+		quotes:       "\"'",
+	}
+	syntaxJSON = &languageSyntax{
+		keywords: words(`true false null`),
+		quotes:   "\"",
+	}
+)
+
+func syntaxFor(language string) *languageSyntax {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "go", "golang":
+		return syntaxGo
+	case "js", "javascript", "jsx", "ts", "typescript", "tsx":
+		return syntaxJS
+	case "py", "python":
+		return syntaxPython
+	case "rs", "rust":
+		return syntaxRust
+	case "c", "cpp", "c++", "h", "hpp", "java", "kotlin", "csharp", "c#", "cs", "swift":
+		return syntaxC
+	case "sh", "bash", "shell", "zsh", "console":
+		return syntaxShell
+	case "sql", "sqlite", "postgres", "postgresql", "mysql":
+		return syntaxSQL
+	case "yaml", "yml", "toml":
+		return syntaxYAML
+	case "json", "jsonc":
+		return syntaxJSON
+	default:
+		return nil
+	}
+}
+
+// highlightCode splits code into lines of coloured tokens. Unknown languages
+// yield a single plain token per line. Block comments and multi-line strings
+// carry their state across lines.
+func highlightCode(code, language string) [][]codeToken {
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	syntax := syntaxFor(language)
+
+	out := make([][]codeToken, 0, len(lines))
+	if syntax == nil {
+		for _, line := range lines {
+			out = append(out, []codeToken{{Kind: tokenPlain, Text: line}})
+		}
+		return out
+	}
+
+	inBlockComment := false
+	for _, line := range lines {
+		var tokens []codeToken
+		tokens, inBlockComment = highlightLine(line, syntax, inBlockComment)
+		out = append(out, tokens)
+	}
+	return out
+}
+
+func highlightLine(line string, syntax *languageSyntax, inBlockComment bool) ([]codeToken, bool) {
+	var tokens []codeToken
+	emit := func(kind tokenKind, text string) {
+		if text == "" {
+			return
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind {
+			tokens[n-1].Text += text
+			return
+		}
+		tokens = append(tokens, codeToken{Kind: kind, Text: text})
+	}
+
+	caseInsensitive := syntax == syntaxSQL
+	i := 0
+	for i < len(line) {
+		rest := line[i:]
+
+		if inBlockComment {
+			end := strings.Index(rest, syntax.blockComment[1])
+			if end < 0 {
+				emit(tokenComment, rest)
+				return tokens, true
+			}
+			end += len(syntax.blockComment[1])
+			emit(tokenComment, rest[:end])
+			i += end
+			inBlockComment = false
+			continue
+		}
+
+		if syntax.blockComment[0] != "" && strings.HasPrefix(rest, syntax.blockComment[0]) {
+			inBlockComment = true
+			emit(tokenComment, syntax.blockComment[0])
+			i += len(syntax.blockComment[0])
+			continue
+		}
+
+		if hasAnyPrefix(rest, syntax.lineComments) {
+			emit(tokenComment, rest)
+			break
+		}
+
+		c := line[i]
+		if strings.IndexByte(syntax.quotes, c) >= 0 {
+			j := i + 1
+			for j < len(line) && line[j] != c {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(line) {
+				j++
+			} else {
+				j = len(line)
+			}
+			emit(tokenString, line[i:j])
+			i = j
+			continue
+		}
+
+		if c >= '0' && c <= '9' {
+			j := i
+			for j < len(line) && (isIdentByte(line[j]) || line[j] == '.') {
+				j++
+			}
+			emit(tokenNumber, line[i:j])
+			i = j
+			continue
+		}
+
+		if isIdentByte(c) {
+			j := i
+			for j < len(line) && isIdentByte(line[j]) {
+				j++
+			}
+			word := line[i:j]
+			lookup := word
+			if caseInsensitive {
+				lookup = strings.ToLower(word)
+			}
+			if syntax.keywords[lookup] {
+				emit(tokenKeyword, word)
+			} else {
+				emit(tokenPlain, word)
+			}
+			i = j
+			continue
+		}
+
+		emit(tokenPlain, line[i:i+1])
+		i++
+	}
+
+	return tokens, inBlockComment
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightCode(t *testing.T) {
+	lines := highlightCode("func main() { // entry\n\treturn \"hi\", 42\n}", "go")
+
+	assert.Len(t, lines, 3)
+	assert.Equal(t, []codeToken{
+		{Kind: tokenKeyword, Text: "func"},
+		{Kind: tokenPlain, Text: " main() { "},
+		{Kind: tokenComment, Text: "// entry"},
+	}, lines[0])
+	assert.Equal(t, []codeToken{
+		{Kind: tokenPlain, Text: "\t"},
+		{Kind: tokenKeyword, Text: "return"},
+		{Kind: tokenPlain, Text: " "},
+		{Kind: tokenString, Text: "\"hi\""},
+		{Kind: tokenPlain, Text: ", "},
+		{Kind: tokenNumber, Text: "42"},
+	}, lines[1])
+}
+
+func TestHighlightCode_BlockCommentSpansLines(t *testing.T) {
+	lines := highlightCode("/* start\nstill comment */ let x", "ts")
+
+	assert.Equal(t, []codeToken{{Kind: tokenComment, Text: "/* start"}}, lines[0])
+	assert.Equal(t, tokenComment, lines[1][0].Kind)
+	assert.Equal(t, "still comment */", lines[1][0].Text)
+	assert.Contains(t, lines[1], codeToken{Kind: tokenKeyword, Text: "let"})
+}
+
+func TestHighlightCode_SQLIsCaseInsensitive(t *testing.T) {
+	lines := highlightCode("SELECT id FROM notes -- all", "sql")
+
+	assert.Equal(t, codeToken{Kind: tokenKeyword, Text: "SELECT"}, lines[0][0])
+	assert.Equal(t, codeToken{Kind: tokenComment, Text: "-- all"}, lines[0][len(lines[0])-1])
+}
+
+func TestHighlightCode_UnknownLanguage(t *testing.T) {
+	lines := highlightCode("func x() {}", "brainfuck")
+
+	assert.Equal(t, [][]codeToken{{{Kind: tokenPlain, Text: "func x() {}"}}}, lines)
+}
+
+func TestWrapCodeLine(t *testing.T) {
+	rows := wrapCodeLine([]codeToken{
+		{Kind: tokenKeyword, Text: "return"},
+		{Kind: tokenString, Text: "\"abcdef\""},
+	}, 5)
+
+	var texts []string
+	for _, row := range rows {
+		line := ""
+		for _, tok := range row {
+			line += tok.Text
+		}
+		texts = append(texts, line)
+	}
+	assert.Equal(t, []string{"retur", "n\"abc", "def\""}, texts)
+	assert.Equal(t, tokenKeyword, rows[1][0].Kind)
+	assert.Equal(t, tokenString, rows[1][1].Kind)
+}
@@ -0,0 +1,386 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"yanta/internal/blocktype"
+	"yanta/internal/document"
+)
+
+// HTMLRenderer renders BlockNote block trees into HTML fragments. URL
+// resolution is pluggable so the same renderer serves single-document exports
+// and static sites, where asset and inter-document links must be rewritten to
+// relative paths.
+type HTMLRenderer struct {
+	// ResolveAsset maps an image or file URL to the src/href written out.
+	// Nil leaves URLs unchanged.
+	ResolveAsset func(url string) string
+	// ResolveLink maps an inline link href to the href written out. Returning
+	// "" renders the link text without an anchor. Nil leaves hrefs unchanged.
+	ResolveLink func(href string) string
+
+	// anchors maps heading block IDs to the slugs "path#slug" links use.
+	anchors map[string]string
+}
+
+func NewHTMLRenderer() *HTMLRenderer {
+	return &HTMLRenderer{}
+}
+
+// RenderBlocks renders a sibling sequence. Consecutive list items of the same
+// kind are grouped into a single <ul>/<ol> so numbering and bullets match the
+// editor.
+func (r *HTMLRenderer) RenderBlocks(blocks []document.BlockNoteBlock) string {
+	var sb strings.Builder
+	r.anchors = document.HeadingAnchors(blocks)
+	r.renderBlocks(&sb, blocks)
+	return sb.String()
+}
+
+func (r *HTMLRenderer) renderBlocks(sb *strings.Builder, blocks []document.BlockNoteBlock) {
+	for i := 0; i < len(blocks); {
+		listTag, listClass := htmlListTag(blocks[i].Type)
+		if listTag == "" {
+			r.renderBlock(sb, blocks[i])
+			i++
+			continue
+		}
+
+		if listClass != "" {
+			fmt.Fprintf(sb, "<%s class=\"%s\">\n", listTag, listClass)
+		} else {
+			fmt.Fprintf(sb, "<%s>\n", listTag)
+		}
+		for ; i < len(blocks) && sameList(blocks[i].Type, listTag, listClass); i++ {
+			r.renderListItem(sb, blocks[i])
+		}
+		fmt.Fprintf(sb, "</%s>\n", listTag)
+	}
+}
+
+func sameList(blockType, listTag, listClass string) bool {
+	tag, class := htmlListTag(blockType)
+	return tag == listTag && class == listClass
+}
+
+func htmlListTag(blockType string) (tag, class string) {
+	switch blockType {
+	case blocktype.BulletListItem:
+		return "ul", ""
+	case blocktype.NumberedListItem:
+		return "ol", ""
+	case blocktype.CheckListItem:
+		return "ul", "checklist"
+	default:
+		return "", ""
+	}
+}
+
+func (r *HTMLRenderer) renderListItem(sb *strings.Builder, block document.BlockNoteBlock) {
+	sb.WriteString("<li>")
+	if block.Type == blocktype.CheckListItem {
+		if document.PropBool(block.Props, "checked", false) {
+			sb.WriteString(`<input type="checkbox" disabled checked> `)
+		} else {
+			sb.WriteString(`<input type="checkbox" disabled> `)
+		}
+	}
+	sb.WriteString(r.inlineFromRaw(block.Content))
+	if len(block.Children) > 0 {
+		sb.WriteString("\n")
+		r.renderBlocks(sb, block.Children)
+	}
+	sb.WriteString("</li>\n")
+}
+
+func (r *HTMLRenderer) renderBlock(sb *strings.Builder, block document.BlockNoteBlock) {
+	switch block.Type {
+	case blocktype.Heading:
+		level := document.PropInt(block.Props, "level", 1)
+		if level < 1 || level > 6 {
+			level = 1
+		}
+		if slug, ok := r.anchors[block.ID]; ok {
+			fmt.Fprintf(sb, "<h%d id=\"%s\">%s</h%d>\n", level, html.EscapeString(slug), r.inlineFromRaw(block.Content), level)
+		} else {
+			fmt.Fprintf(sb, "<h%d>%s</h%d>\n", level, r.inlineFromRaw(block.Content), level)
+		}
+	case blocktype.Paragraph:
+		text := r.inlineFromRaw(block.Content)
+		if text != "" {
+			fmt.Fprintf(sb, "<p>%s</p>\n", text)
+		}
+	case blocktype.CodeBlock:
+		r.renderCodeBlock(sb, block)
+	case blocktype.Image:
+		r.renderImage(sb, block)
+	case blocktype.File:
+		r.renderFile(sb, block)
+	case blocktype.Quote:
+		fmt.Fprintf(sb, "<blockquote>%s</blockquote>\n", r.inlineFromRaw(block.Content))
+	case blocktype.Table:
+		r.renderTable(sb, block)
+	case blocktype.Divider:
+		sb.WriteString("<hr>\n")
+	case blocktype.Callout:
+		// Callouts and toggles hold their children.
+		r.renderCallout(sb, block)
+		return
+	case blocktype.Toggle:
+		fmt.Fprintf(sb, "<details>\n<summary>%s</summary>\n", r.inlineFromRaw(block.Content))
+		r.renderBlocks(sb, block.Children)
+		sb.WriteString("</details>\n")
+		return
+	case blocktype.Math:
+		// \[...\] is the display-math delimiter MathJax and KaTeX's
+		// auto-render pick up.
+		fmt.Fprintf(sb, "<div class=\"math\">\\[%s\\]</div>\n", html.EscapeString(sourceText(block.Content)))
+	case blocktype.Diagram:
+		// mermaid.js renders <pre class="mermaid"> elements in place.
+		fmt.Fprintf(sb, "<pre class=\"mermaid\">%s</pre>\n", html.EscapeString(sourceText(block.Content)))
+	default:
+		text := r.inlineFromRaw(block.Content)
+		if text != "" {
+			fmt.Fprintf(sb, "<p>%s</p>\n", text)
+		}
+	}
+
+	if len(block.Children) > 0 {
+		sb.WriteString("<div class=\"children\">\n")
+		r.renderBlocks(sb, block.Children)
+		sb.WriteString("</div>\n")
+	}
+}
+
+func (r *HTMLRenderer) renderCodeBlock(sb *strings.Builder, block document.BlockNoteBlock) {
+	// Code is rendered verbatim; inline styles inside a code block are not
+	// meaningful and would break syntax highlighters on the wiki host.
+	code := sourceText(block.Content)
+
+	language := document.PropString(block.Props, "language", "")
+	if language != "" {
+		fmt.Fprintf(sb, "<pre><code class=\"language-%s\">%s</code></pre>\n",
+			html.EscapeString(language), html.EscapeString(code))
+		return
+	}
+	fmt.Fprintf(sb, "<pre><code>%s</code></pre>\n", html.EscapeString(code))
+}
+
+// sourceText concatenates the text of a code, math or diagram block, whose
+// content is source rather than styled text.
+func sourceText(rawContent json.RawMessage) string {
+	var inline []document.BlockNoteContent
+	if len(rawContent) > 0 {
+		_ = json.Unmarshal(rawContent, &inline)
+	}
+
+	var sb strings.Builder
+	for _, item := range inline {
+		sb.WriteString(item.Text)
+	}
+	return sb.String()
+}
+
+func (r *HTMLRenderer) renderCallout(sb *strings.Builder, block document.BlockNoteBlock) {
+	variant := document.PropString(block.Props, "variant", blocktype.CalloutNote)
+	style, ok := calloutStyles[variant]
+	if !ok {
+		variant, style = blocktype.CalloutNote, calloutStyles[blocktype.CalloutNote]
+	}
+
+	fmt.Fprintf(sb, "<div class=\"callout callout-%s\">\n<p class=\"callout-title\">%s</p>\n", variant, style.title)
+	if text := r.inlineFromRaw(block.Content); text != "" {
+		fmt.Fprintf(sb, "<p>%s</p>\n", text)
+	}
+	r.renderBlocks(sb, block.Children)
+	sb.WriteString("</div>\n")
+}
+
+func (r *HTMLRenderer) renderImage(sb *strings.Builder, block document.BlockNoteBlock) {
+	url := document.PropString(block.Props, "url", "")
+	if url == "" {
+		return
+	}
+	caption := document.PropString(block.Props, "caption", "")
+
+	sb.WriteString("<figure>")
+	fmt.Fprintf(sb, "<img src=\"%s\" alt=\"%s\">", html.EscapeString(r.assetURL(url)), html.EscapeString(caption))
+	if caption != "" {
+		fmt.Fprintf(sb, "<figcaption>%s</figcaption>", html.EscapeString(caption))
+	}
+	sb.WriteString("</figure>\n")
+}
+
+func (r *HTMLRenderer) renderFile(sb *strings.Builder, block document.BlockNoteBlock) {
+	url := document.PropString(block.Props, "url", "")
+	if url == "" {
+		return
+	}
+	name := document.PropString(block.Props, "name", "")
+	if name == "" {
+		name = url
+	}
+
+	fmt.Fprintf(sb, "<p class=\"file\"><a href=\"%s\">📎 %s</a></p>\n",
+		html.EscapeString(r.assetURL(url)), html.EscapeString(name))
+}
+
+func (r *HTMLRenderer) renderTable(sb *strings.Builder, block document.BlockNoteBlock) {
+	if len(block.Content) == 0 {
+		return
+	}
+
+	var table document.TableContent
+	if err := json.Unmarshal(block.Content, &table); err != nil || len(table.Rows) == 0 {
+		return
+	}
+
+	sb.WriteString("<table>\n")
+	for i, row := range table.Rows {
+		cellTag := "td"
+		if i == 0 {
+			cellTag = "th"
+			sb.WriteString("<thead>\n")
+		} else if i == 1 {
+			sb.WriteString("<tbody>\n")
+		}
+
+		sb.WriteString("<tr>")
+		for _, cell := range row.Cells {
+			fmt.Fprintf(sb, "<%s>%s</%s>", cellTag, r.inline(cell.Content), cellTag)
+		}
+		sb.WriteString("</tr>\n")
+
+		if i == 0 {
+			sb.WriteString("</thead>\n")
+		}
+	}
+	if len(table.Rows) > 1 {
+		sb.WriteString("</tbody>\n")
+	}
+	sb.WriteString("</table>\n")
+}
+
+func (r *HTMLRenderer) inlineFromRaw(rawContent json.RawMessage) string {
+	if len(rawContent) == 0 {
+		return ""
+	}
+
+	var inline []document.BlockNoteContent
+	if err := json.Unmarshal(rawContent, &inline); err != nil {
+		return ""
+	}
+
+	return r.inline(inline)
+}
+
+func (r *HTMLRenderer) inline(content []document.BlockNoteContent) string {
+	var sb strings.Builder
+	for _, item := range content {
+		switch item.Type {
+		case blocktype.InlineText:
+			sb.WriteString(styledHTML(item.Text, item.Styles))
+		case blocktype.InlineLink:
+			text := r.inline(item.Content)
+			if text == "" {
+				text = html.EscapeString(item.Href)
+			}
+			href := r.linkURL(item.Href)
+			if href == "" {
+				sb.WriteString(text)
+				continue
+			}
+			fmt.Fprintf(&sb, "<a href=\"%s\">%s</a>", html.EscapeString(href), text)
+		}
+	}
+	return sb.String()
+}
+
+func styledHTML(text string, styles map[string]any) string {
+	out := html.EscapeString(text)
+	if out == "" {
+		return ""
+	}
+	out = strings.ReplaceAll(out, "\n", "<br>")
+
+	if document.PropBool(styles, blocktype.StyleCode, false) {
+		out = "<code>" + out + "</code>"
+	}
+	if document.PropBool(styles, blocktype.StyleBold, false) {
+		out = "<strong>" + out + "</strong>"
+	}
+	if document.PropBool(styles, blocktype.StyleItalic, false) {
+		out = "<em>" + out + "</em>"
+	}
+	if document.PropBool(styles, blocktype.StyleStrike, false) {
+		out = "<s>" + out + "</s>"
+	}
+	return out
+}
+
+func (r *HTMLRenderer) assetURL(url string) string {
+	if r.ResolveAsset == nil {
+		return url
+	}
+	return r.ResolveAsset(url)
+}
+
+func (r *HTMLRenderer) linkURL(href string) string {
+	// Script and data URLs would execute on the wiki host; drop them before
+	// any resolver sees them.
+	if unsafeLinkScheme(href) {
+		return ""
+	}
+	if r.ResolveLink == nil {
+		return href
+	}
+	return r.ResolveLink(href)
+}
+
+// unsafeLinkScheme reports whether href uses a scheme that executes code or
+// embeds content when followed.
+func unsafeLinkScheme(href string) bool {
+	scheme := strings.ToLower(strings.TrimSpace(href))
+	return strings.HasPrefix(scheme, "javascript:") || strings.HasPrefix(scheme, "data:") || strings.HasPrefix(scheme, "vbscript:")
+}
+
+// htmlPageCSS is inlined into every exported page so the output has no
+// external dependencies and can be dropped onto any static host.
+const htmlPageCSS = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;max-width:48rem;margin:2rem auto;padding:0 1rem;line-height:1.6;color:#1f2328}
+nav{margin-bottom:1.5rem;font-size:.9rem}
+pre{background:#f6f8fa;padding:1rem;overflow:auto;border-radius:6px}
+code{font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:.9em}
+blockquote{margin:0;padding:0 1rem;color:#59636e;border-left:.25rem solid #d1d9e0}
+.callout{margin:1rem 0;padding:.5rem 1rem;border-left:.25rem solid}.callout-title{font-weight:600;margin:0}
+.callout-note{border-color:#0969da}.callout-note .callout-title{color:#0969da}
+.callout-tip{border-color:#1a7f37}.callout-tip .callout-title{color:#1a7f37}
+.callout-important{border-color:#8250df}.callout-important .callout-title{color:#8250df}
+.callout-warning{border-color:#9a6700}.callout-warning .callout-title{color:#9a6700}
+.callout-caution{border-color:#d1242f}.callout-caution .callout-title{color:#d1242f}
+details{margin:1rem 0}summary{cursor:pointer}.math{overflow-x:auto;text-align:center}
+table{border-collapse:collapse}th,td{border:1px solid #d1d9e0;padding:.3rem .8rem}th{background:#f6f8fa}
+img{max-width:100%}figure{margin:1rem 0}figcaption{color:#59636e;font-size:.9rem}
+ul.checklist{list-style:none;padding-left:1.2rem}
+.children{margin-left:1.5rem}
+.tags a{margin-right:.5rem}
+.meta{color:#59636e;font-size:.9rem}`
+
+// renderHTMLPage wraps a body fragment into a complete, self-contained page.
+func renderHTMLPage(title, nav, body string) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&sb, "<style>\n%s\n</style>\n", htmlPageCSS)
+	sb.WriteString("</head>\n<body>\n")
+	if nav != "" {
+		fmt.Fprintf(&sb, "<nav>%s</nav>\n", nav)
+	}
+	sb.WriteString("<main>\n")
+	sb.WriteString(body)
+	sb.WriteString("</main>\n</body>\n</html>\n")
+	return sb.String()
+}
@@ -0,0 +1,204 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	"yanta/internal/document"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLRenderer_Blocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		blocks   []document.BlockNoteBlock
+		contains []string
+	}{
+		{
+			name: "heading with level",
+			blocks: []document.BlockNoteBlock{{
+				Type:    "heading",
+				Props:   map[string]any{"level": float64(2)},
+				Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Title"}}),
+			}},
+			contains: []string{"<h2>Title</h2>"},
+		},
+		{
+			name: "heading with id gets its outline anchor",
+			blocks: []document.BlockNoteBlock{
+				{
+					ID:      "h1",
+					Type:    "heading",
+					Props:   map[string]any{"level": float64(2)},
+					Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Getting Started"}}),
+				},
+				{
+					ID:      "h2",
+					Type:    "heading",
+					Props:   map[string]any{"level": float64(3)},
+					Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Getting Started"}}),
+				},
+			},
+			contains: []string{`<h2 id="getting-started">Getting Started</h2>`, `<h3 id="getting-started-1">Getting Started</h3>`},
+		},
+		{
+			name: "paragraph escapes html and renders styles",
+			blocks: []document.BlockNoteBlock{{
+				Type: "paragraph",
+				Content: mustMarshalContent([]document.BlockNoteContent{
+					{Type: "text", Text: "<b>raw</b> "},
+					{Type: "text", Text: "bold", Styles: map[string]any{"bold": true}},
+					{Type: "text", Text: "x", Styles: map[string]any{"code": true, "italic": true}},
+				}),
+			}},
+			contains: []string{"&lt;b&gt;raw&lt;/b&gt; ", "<strong>bold</strong>", "<em><code>x</code></em>"},
+		},
+		{
+			name: "code block with language class",
+			blocks: []document.BlockNoteBlock{{
+				Type:    "codeBlock",
+				Props:   map[string]any{"language": "go"},
+				Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "if a < b {}"}}),
+			}},
+			contains: []string{`<pre><code class="language-go">if a &lt; b {}</code></pre>`},
+		},
+		{
+			name: "consecutive list items share one list",
+			blocks: []document.BlockNoteBlock{
+				{Type: "numberedListItem", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "one"}})},
+				{Type: "numberedListItem", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "two"}})},
+			},
+			contains: []string{"<ol>\n<li>one</li>\n<li>two</li>\n</ol>"},
+		},
+		{
+			name: "nested bullet list",
+			blocks: []document.BlockNoteBlock{{
+				Type:    "bulletListItem",
+				Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "parent"}}),
+				Children: []document.BlockNoteBlock{
+					{Type: "bulletListItem", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "child"}})},
+				},
+			}},
+			contains: []string{"<li>parent\n<ul>\n<li>child</li>\n</ul>\n</li>"},
+		},
+		{
+			name: "check list",
+			blocks: []document.BlockNoteBlock{
+				{Type: "checkListItem", Props: map[string]any{"checked": true}, Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "done"}})},
+				{Type: "checkListItem", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "todo"}})},
+			},
+			contains: []string{`<ul class="checklist">`, `<input type="checkbox" disabled checked> done`, `<input type="checkbox" disabled> todo`},
+		},
+		{
+			name: "quote",
+			blocks: []document.BlockNoteBlock{{
+				Type:    "quote",
+				Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "wise words"}}),
+			}},
+			contains: []string{"<blockquote>wise words</blockquote>"},
+		},
+		{
+			name: "image and file",
+			blocks: []document.BlockNoteBlock{
+				{Type: "image", Props: map[string]any{"url": "/assets/@p/a.png", "caption": "Diagram"}},
+				{Type: "file", Props: map[string]any{"url": "/assets/@p/b.pdf", "name": "spec.pdf"}},
+			},
+			contains: []string{
+				`<img src="/assets/@p/a.png" alt="Diagram"><figcaption>Diagram</figcaption>`,
+				`<a href="/assets/@p/b.pdf">📎 spec.pdf</a>`,
+			},
+		},
+		{
+			name: "table with header row",
+			blocks: []document.BlockNoteBlock{{
+				Type: "table",
+				Content: mustMarshalJSON(document.TableContent{
+					Type: "tableContent",
+					Rows: []document.TableRow{
+						{Cells: []document.TableCell{{Content: []document.BlockNoteContent{{Type: "text", Text: "H"}}}}},
+						{Cells: []document.TableCell{{Content: []document.BlockNoteContent{{Type: "text", Text: "V"}}}}},
+					},
+				}),
+			}},
+			contains: []string{"<thead>\n<tr><th>H</th></tr>\n</thead>", "<tbody>\n<tr><td>V</td></tr>\n</tbody>"},
+		},
+		{
+			name: "callout holds its children",
+			blocks: []document.BlockNoteBlock{{
+				Type:    "callout",
+				Props:   map[string]any{"variant": "warning"},
+				Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Careful"}}),
+				Children: []document.BlockNoteBlock{
+					{Type: "paragraph", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "inside"}})},
+				},
+			}},
+			contains: []string{`<div class="callout callout-warning">
+<p class="callout-title">Warning</p>
+<p>Careful</p>
+<p>inside</p>
+</div>`},
+		},
+		{
+			name: "toggle is a details element",
+			blocks: []document.BlockNoteBlock{{
+				Type:    "toggle",
+				Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "More"}}),
+				Children: []document.BlockNoteBlock{
+					{Type: "paragraph", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "hidden"}})},
+				},
+			}},
+			contains: []string{"<details>\n<summary>More</summary>\n<p>hidden</p>\n</details>"},
+		},
+		{
+			name: "math and diagram keep their source",
+			blocks: []document.BlockNoteBlock{
+				{Type: "math", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "a < b"}})},
+				{Type: "diagram", Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "graph TD; A-->B"}})},
+			},
+			contains: []string{`<div class="math">\[a &lt; b\]</div>`, `<pre class="mermaid">graph TD; A--&gt;B</pre>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := NewHTMLRenderer().RenderBlocks(tt.blocks)
+			for _, want := range tt.contains {
+				assert.Contains(t, out, want)
+			}
+		})
+	}
+}
+
+func TestHTMLRenderer_Links(t *testing.T) {
+	block := document.BlockNoteBlock{
+		Type: "paragraph",
+		Content: mustMarshalContent([]document.BlockNoteContent{
+			{Type: "link", Href: "projects/@p/doc-a.json", Content: []document.BlockNoteContent{{Type: "text", Text: "doc"}}},
+			{Type: "link", Href: "javascript:alert(1)", Content: []document.BlockNoteContent{{Type: "text", Text: "evil"}}},
+			{Type: "link", Href: "https://example.com", Content: []document.BlockNoteContent{{Type: "text", Text: "ext"}}},
+		}),
+	}
+
+	r := NewHTMLRenderer()
+	r.ResolveLink = func(href string) string {
+		if href == "projects/@p/doc-a.json" {
+			return "doc-a.html"
+		}
+		return href
+	}
+
+	out := r.RenderBlocks([]document.BlockNoteBlock{block})
+	assert.Contains(t, out, `<a href="doc-a.html">doc</a>`)
+	assert.Contains(t, out, "evil")
+	assert.NotContains(t, out, "javascript:")
+	assert.Contains(t, out, `<a href="https://example.com">ext</a>`)
+}
+
+func mustMarshalJSON(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -2,41 +2,228 @@ package export
 
 import (
 	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/jung-kurt/gofpdf"
 )
 
+const (
+	pdfMargin        = 20.0
+	pdfBreakMargin   = 15.0
+	pdfIndentStep    = 7.0
+	pdfMaxIndent     = 8
+	pdfCodeLine      = 5.0
+	pdfCodePadding   = 2.0
+	pdfTOCRowHeight  = 7.0
+	pdfTOCTitleSpace = 20.0
+)
+
+// pdfPageSizes maps accepted page size names (case-insensitive) to the names
+// gofpdf understands.
+var pdfPageSizes = map[string]string{
+	"a3":     "A3",
+	"a4":     "A4",
+	"a5":     "A5",
+	"letter": "Letter",
+	"legal":  "Legal",
+}
+
 type PDF struct {
-	pdf *gofpdf.Fpdf
-	tr  func(string) string
+	pdf      *gofpdf.Fpdf
+	tr       func(string) string
+	baseLeft float64
+
+	headings []TOCEntry
+	tocLinks []int
+}
+
+// PDFOptions are the user-facing layout options shared by every PDF export.
+type PDFOptions struct {
+	// PageSize is one of A3, A4, A5, Letter or Legal. Empty means A4.
+	PageSize string
+	// TableOfContents prepends a generated contents page with page numbers.
+	TableOfContents bool
+	// Header is printed at the top of every page when set.
+	Header string
+	// Footer is printed at the bottom-left of every page when set. Page
+	// numbers are added whenever a footer or table of contents is requested.
+	Footer string
 }
 
 type PDFConfig struct {
 	Title   string
 	Author  string
 	Subject string
+	PDFOptions
+}
+
+// TOCEntry is a heading recorded while rendering, used to build the table of
+// contents.
+type TOCEntry struct {
+	Level int
+	Text  string
+	Page  int
+}
+
+// TextSpan is a run of inline text sharing one set of styles.
+type TextSpan struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Strike bool
+	// Link is an external URL. Spans with a link are rendered as clickable,
+	// underlined text.
+	Link string
+	// LinkID is an internal link created with AddLink. It takes precedence
+	// over Link.
+	LinkID int
+}
+
+func pdfPageSize(name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "A4", nil
+	}
+	size, ok := pdfPageSizes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("unsupported page size: %s", name)
+	}
+	return size, nil
 }
 
 func NewPDF(cfg PDFConfig) (*PDF, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
+	size, err := pdfPageSize(cfg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := gofpdf.New("P", "mm", size, "")
 	tr := pdf.UnicodeTranslatorFromDescriptor("")
 
 	pdf.SetTitle(tr(cfg.Title), true)
 	pdf.SetAuthor(tr(cfg.Author), true)
 	pdf.SetSubject(tr(cfg.Subject), true)
 	pdf.SetCreator("Yanta PDF Exporter", true)
+
+	p := &PDF{pdf: pdf, tr: tr, baseLeft: pdfMargin}
+	p.setHeaderFooter(cfg.PDFOptions)
+
+	pdf.SetAutoPageBreak(true, pdfBreakMargin)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
 	pdf.AddPage()
 	pdf.SetFont("Arial", "", 12)
-	pdf.SetAutoPageBreak(true, 15)
-	pdf.SetMargins(20, 20, 20)
 
-	return &PDF{pdf: pdf, tr: tr}, nil
+	return p, nil
+}
+
+func (p *PDF) setHeaderFooter(opts PDFOptions) {
+	if opts.Header != "" {
+		header := p.tr(opts.Header)
+		p.pdf.SetHeaderFuncMode(func() {
+			p.pdf.SetY(8)
+			p.pdf.SetX(p.baseLeft)
+			p.pdf.SetFont("Arial", "I", 9)
+			p.pdf.SetTextColor(120, 120, 120)
+			p.pdf.CellFormat(0, 5, header, "", 0, "C", false, 0, "")
+		}, true)
+	}
+
+	if opts.Footer != "" || opts.TableOfContents {
+		footer := p.tr(opts.Footer)
+		p.pdf.SetFooterFunc(func() {
+			p.pdf.SetY(-12)
+			p.pdf.SetX(p.baseLeft)
+			p.pdf.SetFont("Arial", "I", 9)
+			p.pdf.SetTextColor(120, 120, 120)
+			if footer != "" {
+				p.pdf.CellFormat(0, 5, footer, "", 0, "L", false, 0, "")
+				p.pdf.SetX(p.baseLeft)
+			}
+			p.pdf.CellFormat(0, 5, fmt.Sprintf("Page %d", p.pdf.PageNo()), "", 0, "R", false, 0, "")
+		})
+	}
 }
 
 func (p *PDF) GetFpdf() *gofpdf.Fpdf {
 	return p.pdf
 }
 
+// Headings returns the headings rendered so far with the page each started on.
+func (p *PDF) Headings() []TOCEntry {
+	return p.headings
+}
+
+// AddLink creates an internal link whose target is set later with
+// SetLinkTarget, so content can link forward to pages not yet written.
+func (p *PDF) AddLink() int {
+	return p.pdf.AddLink()
+}
+
+// SetLinkTarget points link at the current position.
+func (p *PDF) SetLinkTarget(link int) {
+	p.pdf.SetLink(link, -1, -1)
+}
+
+// AddPage starts a new page at the current indentation.
+func (p *PDF) AddPage() {
+	p.pdf.AddPage()
+}
+
+// AddCoverPage fills the current page with a centred title block. The caller
+// starts the next page.
+func (p *PDF) AddCoverPage(title string, lines ...string) {
+	_, pageHeight := p.pdf.GetPageSize()
+	p.pdf.SetY(pageHeight / 3)
+
+	p.pdf.SetFont("Arial", "B", 28)
+	p.pdf.MultiCell(0, 12, p.tr(title), "", "C", false)
+	p.pdf.Ln(6)
+
+	p.pdf.SetFont("Arial", "", 13)
+	p.pdf.SetTextColor(90, 90, 90)
+	for _, line := range lines {
+		p.pdf.MultiCell(0, 7, p.tr(line), "", "C", false)
+	}
+	p.pdf.SetTextColor(0, 0, 0)
+	p.pdf.SetFont("Arial", "", 12)
+}
+
+// SetIndent shifts the left margin for nested content. Depth is capped so
+// deeply nested blocks keep a usable line width.
+func (p *PDF) SetIndent(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	if depth > pdfMaxIndent {
+		depth = pdfMaxIndent
+	}
+	left := p.baseLeft + float64(depth)*pdfIndentStep
+	p.pdf.SetLeftMargin(left)
+	p.pdf.SetX(left)
+}
+
+func (p *PDF) leftMargin() float64 {
+	left, _, _, _ := p.pdf.GetMargins()
+	return left
+}
+
+func (p *PDF) contentWidth() float64 {
+	pageWidth, _ := p.pdf.GetPageSize()
+	left, _, right, _ := p.pdf.GetMargins()
+	return pageWidth - left - right
+}
+
+// ensureSpace starts a new page when fewer than height millimetres remain
+// above the bottom margin.
+func (p *PDF) ensureSpace(height float64) {
+	_, pageHeight := p.pdf.GetPageSize()
+	if p.pdf.GetY()+height > pageHeight-pdfBreakMargin {
+		p.pdf.AddPage()
+	}
+}
+
 func (p *PDF) AddHeading(level int, text string) {
 	var fontSize float64
 	style := "B"
@@ -56,6 +243,16 @@ func (p *PDF) AddHeading(level int, text string) {
 		p.pdf.Ln(3)
 	}
 
+	// Keep the heading with at least a line of the text that follows it.
+	p.ensureSpace(fontSize*0.5 + 10)
+
+	if text != "" {
+		if n := len(p.headings); n < len(p.tocLinks) {
+			p.pdf.SetLink(p.tocLinks[n], -1, -1)
+		}
+		p.headings = append(p.headings, TOCEntry{Level: level, Text: text, Page: p.pdf.PageNo()})
+	}
+
 	p.pdf.SetFont("Arial", style, fontSize)
 	p.pdf.MultiCell(0, fontSize*0.5, p.tr(text), "", "", false)
 	p.pdf.Ln(4)
@@ -63,22 +260,91 @@ func (p *PDF) AddHeading(level int, text string) {
 }
 
 func (p *PDF) AddParagraph(text string) {
-	p.pdf.SetFont("Arial", "", 12)
-	p.pdf.MultiCell(0, 6, p.tr(text), "", "", false)
+	p.AddRichParagraph([]TextSpan{{Text: text}})
+}
+
+// AddRichParagraph writes a paragraph of styled spans, wrapping at the
+// current margins.
+func (p *PDF) AddRichParagraph(spans []TextSpan) {
+	p.pdf.SetX(p.leftMargin())
+	p.writeSpans(spans, 6, 12, "")
+	p.pdf.Ln(6)
 	p.pdf.Ln(3)
 }
 
+func (p *PDF) writeSpans(spans []TextSpan, lineHeight, fontSize float64, baseStyle string) {
+	for _, span := range spans {
+		if span.Text == "" {
+			continue
+		}
+
+		family := "Arial"
+		style := baseStyle
+		if span.Code {
+			family = "Courier"
+		}
+		if span.Bold && !strings.Contains(style, "B") {
+			style += "B"
+		}
+		if span.Italic && !strings.Contains(style, "I") {
+			style += "I"
+		}
+		if span.Strike {
+			style += "S"
+		}
+
+		text := p.tr(span.Text)
+		if span.LinkID != 0 || span.Link != "" {
+			p.pdf.SetFont(family, style+"U", fontSize)
+			p.pdf.SetTextColor(0, 90, 200)
+			if span.LinkID != 0 {
+				p.pdf.WriteLinkID(lineHeight, text, span.LinkID)
+			} else {
+				p.pdf.WriteLinkString(lineHeight, text, span.Link)
+			}
+			p.pdf.SetTextColor(0, 0, 0)
+			continue
+		}
+
+		p.pdf.SetFont(family, style, fontSize)
+		p.pdf.Write(lineHeight, text)
+	}
+	p.pdf.SetFont("Arial", "", 12)
+}
+
 func (p *PDF) AddCodeBlock(code, language string) {
-	p.pdf.SetFillColor(245, 245, 245)
-	p.pdf.SetTextColor(40, 40, 40)
+	left := p.leftMargin()
+	width := p.contentWidth()
 
 	if language != "" {
+		p.pdf.SetTextColor(40, 40, 40)
 		p.pdf.SetFont("Arial", "I", 10)
 		p.pdf.CellFormat(0, 5, p.tr(fmt.Sprintf("# %s", language)), "", 1, "", false, 0, "")
 	}
 
 	p.pdf.SetFont("Courier", "", 10)
-	p.pdf.MultiCell(0, 5, p.tr(code), "1", "", true)
+	charWidth := p.pdf.GetStringWidth("M")
+	maxChars := int((width - 2*pdfCodePadding) / charWidth)
+
+	code = strings.ReplaceAll(code, "\t", "    ")
+	var rows [][]codeToken
+	for _, line := range highlightCode(code, language) {
+		rows = append(rows, wrapCodeLine(line, maxChars)...)
+	}
+
+	p.pdf.SetFillColor(245, 245, 245)
+	for _, row := range rows {
+		p.ensureSpace(pdfCodeLine)
+		y := p.pdf.GetY()
+		p.pdf.Rect(left, y, width, pdfCodeLine, "F")
+		p.pdf.SetX(left + pdfCodePadding)
+		for _, tok := range row {
+			p.setCodeTokenStyle(tok.Kind)
+			text := p.tr(tok.Text)
+			p.pdf.CellFormat(p.pdf.GetStringWidth(text), pdfCodeLine, text, "", 0, "L", false, 0, "")
+		}
+		p.pdf.SetXY(left, y+pdfCodeLine)
+	}
 	p.pdf.Ln(3)
 
 	p.pdf.SetFillColor(255, 255, 255)
@@ -86,50 +352,200 @@ func (p *PDF) AddCodeBlock(code, language string) {
 	p.pdf.SetFont("Arial", "", 12)
 }
 
+func (p *PDF) setCodeTokenStyle(kind tokenKind) {
+	switch kind {
+	case tokenKeyword:
+		p.pdf.SetFont("Courier", "B", 10)
+		p.pdf.SetTextColor(0, 70, 170)
+	case tokenString:
+		p.pdf.SetFont("Courier", "", 10)
+		p.pdf.SetTextColor(10, 110, 30)
+	case tokenComment:
+		p.pdf.SetFont("Courier", "I", 10)
+		p.pdf.SetTextColor(110, 115, 125)
+	case tokenNumber:
+		p.pdf.SetFont("Courier", "", 10)
+		p.pdf.SetTextColor(180, 80, 0)
+	default:
+		p.pdf.SetFont("Courier", "", 10)
+		p.pdf.SetTextColor(40, 40, 40)
+	}
+}
+
+// wrapCodeLine hard-wraps a highlighted line into rows of at most maxChars
+// characters. Code is monospaced, so character count is an exact width.
+func wrapCodeLine(tokens []codeToken, maxChars int) [][]codeToken {
+	if maxChars <= 0 {
+		return [][]codeToken{tokens}
+	}
+
+	var rows [][]codeToken
+	var row []codeToken
+	used := 0
+	for _, tok := range tokens {
+		text := tok.Text
+		for text != "" {
+			room := maxChars - used
+			if room == 0 {
+				rows = append(rows, row)
+				row, used = nil, 0
+				room = maxChars
+			}
+			n := utf8.RuneCountInString(text)
+			if n <= room {
+				row = append(row, codeToken{Kind: tok.Kind, Text: text})
+				used += n
+				break
+			}
+			cut := runeOffset(text, room)
+			row = append(row, codeToken{Kind: tok.Kind, Text: text[:cut]})
+			used += room
+			text = text[cut:]
+		}
+	}
+	return append(rows, row)
+}
+
+func runeOffset(s string, n int) int {
+	i := 0
+	for n > 0 && i < len(s) {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n--
+	}
+	return i
+}
+
 func (p *PDF) AddListItem(text string, ordered bool, index int) {
-	bullet := p.tr("• ")
+	marker := "• "
 	if ordered {
-		bullet = fmt.Sprintf("%d. ", index)
+		marker = fmt.Sprintf("%d. ", index)
 	}
+	p.AddRichListItem(marker, []TextSpan{{Text: text}})
+}
 
-	currentX := p.pdf.GetX()
-	currentY := p.pdf.GetY()
+// AddRichListItem writes a list item with a text marker (bullet or number)
+// and a hanging indent for wrapped lines.
+func (p *PDF) AddRichListItem(marker string, spans []TextSpan) {
+	p.addListItem(func(x, y float64) {
+		p.pdf.SetFont("Arial", "", 12)
+		p.pdf.SetXY(x, y)
+		p.pdf.Cell(10, 6, p.tr(marker))
+	}, spans)
+}
 
-	p.pdf.SetFont("Arial", "", 12)
-	p.pdf.SetXY(currentX+5, currentY)
-	p.pdf.Cell(10, 6, bullet)
+// AddCheckListItem writes a list item with a drawn checkbox.
+func (p *PDF) AddCheckListItem(checked bool, spans []TextSpan) {
+	p.addListItem(func(x, y float64) {
+		const box = 3.5
+		top := y + (6-box)/2
+		p.pdf.SetDrawColor(80, 80, 80)
+		p.pdf.SetLineWidth(0.3)
+		p.pdf.Rect(x, top, box, box, "D")
+		if checked {
+			p.pdf.Line(x+0.7, top+1.9, x+1.5, top+2.8)
+			p.pdf.Line(x+1.5, top+2.8, x+2.9, top+0.7)
+		}
+		p.pdf.SetDrawColor(0, 0, 0)
+		p.pdf.SetLineWidth(0.2)
+	}, spans)
+}
+
+func (p *PDF) addListItem(drawMarker func(x, y float64), spans []TextSpan) {
+	left := p.leftMargin()
+	p.ensureSpace(6)
+	y := p.pdf.GetY()
 
-	p.pdf.SetXY(currentX+15, currentY)
-	p.pdf.MultiCell(0, 6, p.tr(text), "", "", false)
+	drawMarker(left+5, y)
+
+	p.pdf.SetLeftMargin(left + 15)
+	p.pdf.SetXY(left+15, y)
+	p.writeSpans(spans, 6, 12, "")
+	p.pdf.Ln(6)
+	p.pdf.SetLeftMargin(left)
+	p.pdf.SetX(left)
 	p.pdf.Ln(1)
 }
 
 func (p *PDF) AddQuote(text string) {
-	p.pdf.SetFont("Arial", "I", 12)
-	p.pdf.SetTextColor(80, 80, 80)
-
-	currentX := p.pdf.GetX()
-	currentY := p.pdf.GetY()
+	p.AddRichQuote([]TextSpan{{Text: text}})
+}
 
-	pageWidth, _ := p.pdf.GetPageSize()
-	leftMargin, _, rightMargin, _ := p.pdf.GetMargins()
-	availableWidth := pageWidth - leftMargin - rightMargin - 15
+// AddRichQuote writes an italic block quote with a bar in the left gutter.
+// The bar is drawn after the text so its height matches the wrapped lines.
+func (p *PDF) AddRichQuote(spans []TextSpan) {
+	left := p.leftMargin()
+	p.ensureSpace(6)
+	startY := p.pdf.GetY()
+	startPage := p.pdf.PageNo()
 
-	lineHeight := 6.0
-	numLines := len(text)/int(availableWidth*0.5) + 1
-	estimatedHeight := float64(numLines) * lineHeight
+	p.pdf.SetTextColor(80, 80, 80)
+	p.pdf.SetLeftMargin(left + 10)
+	p.pdf.SetXY(left+10, startY)
+	p.writeSpans(spans, 6, 12, "I")
+	p.pdf.Ln(6)
+	p.pdf.SetLeftMargin(left)
+	p.pdf.SetX(left)
+	p.pdf.SetTextColor(0, 0, 0)
 
+	// A quote that crossed a page break only gets the bar on its last page.
+	endY := p.pdf.GetY()
+	if p.pdf.PageNo() != startPage {
+		startY = pdfMargin
+	}
 	p.pdf.SetDrawColor(200, 200, 200)
 	p.pdf.SetLineWidth(1)
-	p.pdf.Line(currentX+5, currentY, currentX+5, currentY+estimatedHeight+3)
-
-	p.pdf.SetX(currentX + 10)
-	p.pdf.MultiCell(0, lineHeight, p.tr(text), "", "", false)
+	p.pdf.Line(left+5, startY, left+5, endY)
+	p.pdf.SetLineWidth(0.2)
+	p.pdf.SetDrawColor(0, 0, 0)
 	p.pdf.Ln(3)
+}
 
+// AddCallout writes a callout: a bold title in the callout's color above its
+// text, with a bar of the same color in the left gutter.
+func (p *PDF) AddCallout(title string, color [3]int, spans []TextSpan) {
+	left := p.leftMargin()
+	p.ensureSpace(12)
+	startY := p.pdf.GetY()
+	startPage := p.pdf.PageNo()
+
+	p.pdf.SetLeftMargin(left + 10)
+	p.pdf.SetXY(left+10, startY)
+	p.pdf.SetFont("Arial", "B", 12)
+	p.pdf.SetTextColor(color[0], color[1], color[2])
+	p.pdf.Write(6, p.tr(title))
+	p.pdf.Ln(6)
 	p.pdf.SetTextColor(0, 0, 0)
-	p.pdf.SetFont("Arial", "", 12)
+	if plainText(spans) != "" {
+		p.writeSpans(spans, 6, 12, "")
+		p.pdf.Ln(6)
+	}
+	p.pdf.SetLeftMargin(left)
+	p.pdf.SetX(left)
+
+	endY := p.pdf.GetY()
+	if p.pdf.PageNo() != startPage {
+		startY = pdfMargin
+	}
+	p.pdf.SetDrawColor(color[0], color[1], color[2])
+	p.pdf.SetLineWidth(1)
+	p.pdf.Line(left+5, startY, left+5, endY)
+	p.pdf.SetLineWidth(0.2)
 	p.pdf.SetDrawColor(0, 0, 0)
+	p.pdf.SetFont("Arial", "", 12)
+	p.pdf.Ln(3)
+}
+
+// AddMath writes display math as its LaTeX source, centered. There is no TeX
+// engine to typeset it, and the source is what a reader of the PDF can use.
+func (p *PDF) AddMath(src string) {
+	p.pdf.SetFont("Courier", "", 11)
+	for _, line := range strings.Split(src, "\n") {
+		p.pdf.SetX(p.leftMargin())
+		p.pdf.MultiCell(0, 6, p.tr(line), "", "C", false)
+	}
+	p.pdf.Ln(3)
+	p.pdf.SetFont("Arial", "", 12)
 }
 
 func (p *PDF) AddImage(imagePath string, caption string) error {
@@ -138,9 +554,7 @@ func (p *PDF) AddImage(imagePath string, caption string) error {
 		ReadDpi:   false,
 	}
 
-	pageWidth, _ := p.pdf.GetPageSize()
-	leftMargin, _, rightMargin, _ := p.pdf.GetMargins()
-	maxWidth := pageWidth - leftMargin - rightMargin
+	maxWidth := p.contentWidth()
 
 	p.pdf.ImageOptions(imagePath, p.pdf.GetX(), p.pdf.GetY(), maxWidth*0.8, 0, false, opt, 0, "")
 
@@ -162,9 +576,7 @@ func (p *PDF) AddTable(cells [][]string) {
 		return
 	}
 
-	pageWidth, _ := p.pdf.GetPageSize()
-	leftMargin, _, rightMargin, _ := p.pdf.GetMargins()
-	availableWidth := pageWidth - leftMargin - rightMargin
+	availableWidth := p.contentWidth()
 
 	numCols := len(cells[0])
 	colWidth := availableWidth / float64(numCols)
@@ -204,6 +616,123 @@ func (p *PDF) AddLineBreak() {
 	p.pdf.Ln(5)
 }
 
+// tocEntriesPerPage is fixed for a page size so the number of contents pages
+// is known before they are drawn, which the page numbers depend on.
+func (p *PDF) tocEntriesPerPage() int {
+	_, pageHeight := p.pdf.GetPageSize()
+	usable := pageHeight - pdfMargin - pdfBreakMargin - pdfTOCTitleSpace
+	return int(math.Max(1, math.Floor(usable/pdfTOCRowHeight)))
+}
+
+// TOCPageCount returns how many pages AddTableOfContents will use for n
+// entries.
+func (p *PDF) TOCPageCount(n int) int {
+	if n == 0 {
+		return 0
+	}
+	per := p.tocEntriesPerPage()
+	return (n + per - 1) / per
+}
+
+// AddTableOfContents writes contents pages for entries whose Page values were
+// collected from a draft render of the same content, then starts a new page
+// for the content itself. Every entry links to its heading; headings added
+// afterwards resolve the links in order.
+func (p *PDF) AddTableOfContents(entries []TOCEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	offset := p.TOCPageCount(len(entries))
+	per := p.tocEntriesPerPage()
+	width := p.contentWidth()
+
+	p.tocLinks = make([]int, len(entries))
+	for i, entry := range entries {
+		if i%per == 0 {
+			if i > 0 {
+				p.pdf.AddPage()
+			}
+			p.pdf.SetFont("Arial", "B", 18)
+			p.pdf.CellFormat(0, 10, "Contents", "", 1, "", false, 0, "")
+			p.pdf.Ln(pdfTOCTitleSpace - 10)
+		}
+
+		link := p.pdf.AddLink()
+		p.tocLinks[i] = link
+
+		indent := float64(min(max(entry.Level-1, 0), 3)) * 6
+		style := ""
+		if entry.Level <= 1 {
+			style = "B"
+		}
+		page := fmt.Sprintf("%d", entry.Page+offset)
+
+		p.pdf.SetFont("Arial", style, 11)
+		pageWidth := p.pdf.GetStringWidth(page) + 2
+		textWidth := width - indent - pageWidth
+		text := p.truncateToWidth(p.tr(entry.Text), textWidth-2)
+
+		p.pdf.SetX(p.leftMargin() + indent)
+		p.pdf.CellFormat(textWidth, pdfTOCRowHeight, text, "", 0, "L", false, link, "")
+		p.pdf.CellFormat(pageWidth, pdfTOCRowHeight, page, "", 1, "R", false, link, "")
+	}
+
+	p.pdf.SetFont("Arial", "", 12)
+	p.pdf.AddPage()
+}
+
+func (p *PDF) truncateToWidth(text string, width float64) string {
+	if p.pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && p.pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
 func (p *PDF) SaveToFile(path string) error {
 	return p.pdf.OutputFileAndClose(path)
 }
+
+// buildPDF renders content into a new PDF, after an optional cover page.
+// With a table of contents the content is rendered twice: a draft pass
+// records which page every heading lands on, then the final pass writes the
+// contents pages followed by the same content, shifted by the number of
+// contents pages.
+func buildPDF(cfg PDFConfig, cover func(*PDF), render func(*PDF) error) (*PDF, error) {
+	start := func() (*PDF, error) {
+		pdf, err := NewPDF(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if cover != nil {
+			cover(pdf)
+			pdf.AddPage()
+		}
+		return pdf, nil
+	}
+
+	var headings []TOCEntry
+	if cfg.TableOfContents {
+		draft, err := start()
+		if err != nil {
+			return nil, err
+		}
+		if err := render(draft); err != nil {
+			return nil, err
+		}
+		headings = draft.Headings()
+	}
+
+	pdf, err := start()
+	if err != nil {
+		return nil, err
+	}
+	pdf.AddTableOfContents(headings)
+	if err := render(pdf); err != nil {
+		return nil, err
+	}
+	return pdf, nil
+}
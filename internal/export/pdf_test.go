@@ -398,3 +398,113 @@ func TestPDF_MultiplePages(t *testing.T) {
 	require.NoError(t, err, "Output file should exist")
 	assert.Greater(t, info.Size(), int64(5000), "Multi-page document should be larger")
 }
+
+func TestNewPDF_PageSize(t *testing.T) {
+	tests := []struct {
+		size      string
+		wantWidth float64
+	}{
+		{"", 210},
+		{"A4", 210},
+		{"letter", 215.9},
+		{"A5", 148.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			pdf, err := NewPDF(PDFConfig{PDFOptions: PDFOptions{PageSize: tt.size}})
+			require.NoError(t, err)
+
+			width, _ := pdf.GetFpdf().GetPageSize()
+			assert.InDelta(t, tt.wantWidth, width, 0.5)
+		})
+	}
+
+	_, err := NewPDF(PDFConfig{PDFOptions: PDFOptions{PageSize: "B7"}})
+	assert.ErrorContains(t, err, "unsupported page size")
+}
+
+func TestPDF_RichText(t *testing.T) {
+	pdf, err := NewPDF(PDFConfig{Title: "Test"})
+	require.NoError(t, err)
+
+	spans := []TextSpan{
+		{Text: "plain "},
+		{Text: "bold ", Bold: true},
+		{Text: "both ", Bold: true, Italic: true},
+		{Text: "code ", Code: true},
+		{Text: "gone ", Strike: true},
+		{Text: "link", Link: "https://example.com"},
+	}
+	pdf.AddRichParagraph(spans)
+	pdf.AddRichListItem("1. ", spans)
+	pdf.AddCheckListItem(true, spans)
+	pdf.AddRichQuote(spans)
+
+	require.NoError(t, pdf.GetFpdf().Error())
+}
+
+func TestPDF_SetIndent(t *testing.T) {
+	pdf, err := NewPDF(PDFConfig{Title: "Test"})
+	require.NoError(t, err)
+
+	pdf.SetIndent(2)
+	assert.InDelta(t, pdfMargin+2*pdfIndentStep, pdf.leftMargin(), 0.001)
+
+	pdf.SetIndent(100)
+	assert.InDelta(t, pdfMargin+pdfMaxIndent*pdfIndentStep, pdf.leftMargin(), 0.001)
+
+	pdf.SetIndent(0)
+	assert.InDelta(t, pdfMargin, pdf.leftMargin(), 0.001)
+}
+
+func TestPDF_AddCodeBlock_LongLinesAcrossPages(t *testing.T) {
+	pdf, err := NewPDF(PDFConfig{Title: "Test"})
+	require.NoError(t, err)
+
+	code := ""
+	for i := 0; i < 120; i++ {
+		code += "x := \"a very long string literal that is wider than the page and must wrap onto another row\"\n"
+	}
+	pdf.AddCodeBlock(code, "go")
+
+	require.NoError(t, pdf.GetFpdf().Error())
+	assert.Greater(t, pdf.GetFpdf().PageNo(), 2)
+}
+
+func TestBuildPDF_TableOfContents(t *testing.T) {
+	render := func(pdf *PDF) error {
+		pdf.AddHeading(1, "Intro")
+		pdf.AddParagraph("Hello")
+		pdf.GetFpdf().AddPage()
+		pdf.AddHeading(2, "Details")
+		return nil
+	}
+
+	pdf, err := buildPDF(PDFConfig{
+		Title:      "Doc",
+		PDFOptions: PDFOptions{TableOfContents: true, Header: "Header", Footer: "Footer"},
+	}, nil, render)
+	require.NoError(t, err)
+	require.NoError(t, pdf.GetFpdf().Error())
+
+	// One contents page followed by the two content pages.
+	assert.Equal(t, 3, pdf.GetFpdf().PageNo())
+	headings := pdf.Headings()
+	require.Len(t, headings, 2)
+	assert.Equal(t, TOCEntry{Level: 1, Text: "Intro", Page: 2}, headings[0])
+	assert.Equal(t, TOCEntry{Level: 2, Text: "Details", Page: 3}, headings[1])
+
+	out := filepath.Join(t.TempDir(), "toc.pdf")
+	require.NoError(t, pdf.SaveToFile(out))
+}
+
+func TestPDF_TOCPageCount(t *testing.T) {
+	pdf, err := NewPDF(PDFConfig{Title: "Test"})
+	require.NoError(t, err)
+
+	per := pdf.tocEntriesPerPage()
+	assert.Equal(t, 0, pdf.TOCPageCount(0))
+	assert.Equal(t, 1, pdf.TOCPageCount(per))
+	assert.Equal(t, 2, pdf.TOCPageCount(per+1))
+}
@@ -16,6 +16,15 @@ type Renderer struct {
 	pdf          *PDF
 	vault        VaultProvider
 	projectAlias string
+	depth        int
+
+	// ResolveLink maps a link to another vault document to an internal PDF
+	// link created with PDF.AddLink. Returning 0 renders the link text
+	// without a link. Nil leaves document links unlinked.
+	ResolveLink func(href string) int
+	// HeadingOffset demotes every heading by this many levels, so documents
+	// rendered as chapters nest under the chapter title.
+	HeadingOffset int
 }
 
 func NewRenderer(pdf *PDF, vault VaultProvider, projectAlias string) *Renderer {
@@ -93,95 +102,97 @@ func (r *Renderer) renderBlock(block document.BlockNoteBlock, listNumber int) er
 		if err := r.renderTable(block); err != nil {
 			return err
 		}
+	case blocktype.Callout:
+		r.renderCallout(block)
+	case blocktype.Toggle:
+		r.renderToggle(block)
+	case blocktype.Math:
+		r.renderMath(block)
+	case blocktype.Diagram:
+		r.renderDiagram(block)
 	default:
 		// For unknown block types, try to extract text
-		text := r.extractTextFromBlock(block)
-		if text != "" {
-			r.pdf.AddParagraph(text)
+		spans := r.spansFromContent(block.Content)
+		if plainText(spans) != "" {
+			r.pdf.AddRichParagraph(spans)
 		}
 	}
 
-	return r.RenderBlocks(block.Children)
+	if len(block.Children) == 0 {
+		return nil
+	}
+
+	// Children are indented one step per nesting level, matching the editor.
+	r.depth++
+	r.pdf.SetIndent(r.depth)
+	err := r.RenderBlocks(block.Children)
+	r.depth--
+	r.pdf.SetIndent(r.depth)
+	return err
 }
 
 func (r *Renderer) renderHeading(block document.BlockNoteBlock) error {
-	text := r.extractTextFromContent(block.Content)
+	text := plainText(r.spansFromContent(block.Content))
 	if text == "" {
 		return nil
 	}
 
-	level := document.PropInt(block.Props, "level", 1)
+	level := document.PropInt(block.Props, "level", 1) + r.HeadingOffset
 
 	r.pdf.AddHeading(level, text)
 	return nil
 }
 
 func (r *Renderer) renderParagraph(block document.BlockNoteBlock) error {
-	text := r.extractTextFromContent(block.Content)
-	if text == "" {
+	spans := r.spansFromContent(block.Content)
+	if plainText(spans) == "" {
 		return nil
 	}
 
-	r.pdf.AddParagraph(text)
+	r.pdf.AddRichParagraph(spans)
 	return nil
 }
 
 func (r *Renderer) renderCodeBlock(block document.BlockNoteBlock) error {
-	text := r.extractTextFromContent(block.Content)
+	// Code is rendered verbatim; inline styles are replaced by highlighting.
+	text := plainText(r.spansFromContent(block.Content))
 	if text == "" {
 		return nil
 	}
 
-	language := ""
-	if block.Props != nil {
-		if lang, ok := block.Props["language"].(string); ok {
-			language = lang
-		}
-	}
+	language := document.PropString(block.Props, "language", "")
 
 	r.pdf.AddCodeBlock(text, language)
 	return nil
 }
 
 func (r *Renderer) renderBulletListItem(block document.BlockNoteBlock) error {
-	text := r.extractTextFromContent(block.Content)
-	if text == "" {
+	spans := r.spansFromContent(block.Content)
+	if plainText(spans) == "" {
 		return nil
 	}
 
-	r.pdf.AddListItem(text, false, 0)
+	r.pdf.AddRichListItem("• ", spans)
 	return nil
 }
 
 func (r *Renderer) renderNumberedListItem(block document.BlockNoteBlock, number int) error {
-	text := r.extractTextFromContent(block.Content)
-	if text == "" {
+	spans := r.spansFromContent(block.Content)
+	if plainText(spans) == "" {
 		return nil
 	}
 
-	r.pdf.AddListItem(text, true, number)
+	r.pdf.AddRichListItem(fmt.Sprintf("%d. ", number), spans)
 	return nil
 }
 
 func (r *Renderer) renderCheckListItem(block document.BlockNoteBlock) error {
-	text := r.extractTextFromContent(block.Content)
-	if text == "" {
+	spans := r.spansFromContent(block.Content)
+	if plainText(spans) == "" {
 		return nil
 	}
 
-	checked := false
-	if block.Props != nil {
-		if chk, ok := block.Props["checked"].(bool); ok {
-			checked = chk
-		}
-	}
-
-	prefix := "☐"
-	if checked {
-		prefix = "☑"
-	}
-
-	r.pdf.AddListItem(fmt.Sprintf("%s %s", prefix, text), false, 0)
+	r.pdf.AddCheckListItem(document.PropBool(block.Props, "checked", false), spans)
 	return nil
 }
 
@@ -248,12 +259,12 @@ func (r *Renderer) renderFile(block document.BlockNoteBlock) error {
 }
 
 func (r *Renderer) renderQuote(block document.BlockNoteBlock) error {
-	text := r.extractTextFromContent(block.Content)
-	if text == "" {
+	spans := r.spansFromContent(block.Content)
+	if plainText(spans) == "" {
 		return nil
 	}
 
-	r.pdf.AddQuote(text)
+	r.pdf.AddRichQuote(spans)
 	return nil
 }
 
@@ -276,7 +287,7 @@ func (r *Renderer) renderTable(block document.BlockNoteBlock) error {
 	for _, row := range table.Rows {
 		var rowCells []string
 		for _, cell := range row.Cells {
-			text := r.extractTextFromContentSlice(cell.Content)
+			text := plainText(r.spansFromContentSlice(cell.Content))
 			rowCells = append(rowCells, text)
 		}
 		cells = append(cells, rowCells)
@@ -286,6 +297,54 @@ func (r *Renderer) renderTable(block document.BlockNoteBlock) error {
 	return nil
 }
 
+// calloutStyles gives each callout variant its title and color, after the
+// GitHub alerts callouts map to.
+var calloutStyles = map[string]struct {
+	title string
+	color [3]int
+}{
+	blocktype.CalloutNote:      {"Note", [3]int{9, 105, 218}},
+	blocktype.CalloutTip:       {"Tip", [3]int{26, 127, 55}},
+	blocktype.CalloutImportant: {"Important", [3]int{130, 80, 223}},
+	blocktype.CalloutWarning:   {"Warning", [3]int{154, 103, 0}},
+	blocktype.CalloutCaution:   {"Caution", [3]int{209, 36, 47}},
+}
+
+func (r *Renderer) renderCallout(block document.BlockNoteBlock) {
+	style, ok := calloutStyles[document.PropString(block.Props, "variant", blocktype.CalloutNote)]
+	if !ok {
+		style = calloutStyles[blocktype.CalloutNote]
+	}
+	r.pdf.AddCallout(style.title, style.color, r.spansFromContent(block.Content))
+}
+
+// renderToggle writes a toggle's summary in bold. Its children, the
+// collapsed body, are printed expanded below it.
+func (r *Renderer) renderToggle(block document.BlockNoteBlock) {
+	spans := r.spansFromContent(block.Content)
+	if plainText(spans) == "" {
+		return
+	}
+	for i := range spans {
+		spans[i].Bold = true
+	}
+	r.pdf.AddRichParagraph(spans)
+}
+
+func (r *Renderer) renderMath(block document.BlockNoteBlock) {
+	if src := plainText(r.spansFromContent(block.Content)); src != "" {
+		r.pdf.AddMath(src)
+	}
+}
+
+// renderDiagram writes a diagram's Mermaid source as a code block; diagrams
+// are laid out by the editor's browser engine, which the PDF export lacks.
+func (r *Renderer) renderDiagram(block document.BlockNoteBlock) {
+	if src := plainText(r.spansFromContent(block.Content)); src != "" {
+		r.pdf.AddCodeBlock(src, document.PropString(block.Props, "language", blocktype.DiagramMermaid))
+	}
+}
+
 // Helper methods for extracting text from BlockNote content
 
 func (r *Renderer) extractTextFromBlock(block document.BlockNoteBlock) string {
@@ -346,8 +405,72 @@ func (r *Renderer) extractTextFromContentSlice(inlineContent []document.BlockNot
 	return strings.Join(parts, "")
 }
 
+func (r *Renderer) spansFromContent(rawContent json.RawMessage) []TextSpan {
+	if len(rawContent) == 0 {
+		return nil
+	}
+
+	var inlineContent []document.BlockNoteContent
+	if err := json.Unmarshal(rawContent, &inlineContent); err != nil {
+		return nil
+	}
+
+	return r.spansFromContentSlice(inlineContent)
+}
+
+// spansFromContentSlice converts inline content into styled spans. External
+// URLs and resolvable document links become clickable; other hrefs keep their
+// text.
+func (r *Renderer) spansFromContentSlice(inlineContent []document.BlockNoteContent) []TextSpan {
+	return r.spansWithLink(inlineContent, "", 0)
+}
+
+func (r *Renderer) spansWithLink(inlineContent []document.BlockNoteContent, link string, linkID int) []TextSpan {
+	var spans []TextSpan
+	for _, item := range inlineContent {
+		switch item.Type {
+		case blocktype.InlineText:
+			spans = append(spans, TextSpan{
+				Text:   item.Text,
+				Bold:   document.PropBool(item.Styles, blocktype.StyleBold, false),
+				Italic: document.PropBool(item.Styles, blocktype.StyleItalic, false),
+				Code:   document.PropBool(item.Styles, blocktype.StyleCode, false),
+				Strike: document.PropBool(item.Styles, blocktype.StyleStrike, false),
+				Link:   link,
+				LinkID: linkID,
+			})
+		case blocktype.InlineLink:
+			href, id := "", 0
+			if document.IsExternalURL(item.Href) && !unsafeLinkScheme(item.Href) {
+				href = item.Href
+			} else if _, _, ok := vaultDocumentLink(item.Href); ok && r.ResolveLink != nil {
+				id = r.ResolveLink(item.Href)
+			}
+			linkSpans := r.spansWithLink(item.Content, href, id)
+			if plainText(linkSpans) == "" {
+				linkSpans = []TextSpan{{Text: item.Href, Link: href, LinkID: id}}
+			}
+			spans = append(spans, linkSpans...)
+		}
+	}
+	return spans
+}
+
+func plainText(spans []TextSpan) string {
+	var sb strings.Builder
+	for _, span := range spans {
+		sb.WriteString(span.Text)
+	}
+	return sb.String()
+}
+
 func (r *Renderer) resolveImagePath(url string) (string, error) {
-	// Expected format: /assets/{project}/{hash}{ext}
+	return resolveAssetPath(r.vault, url)
+}
+
+// resolveAssetPath maps an asset URL in the format /assets/{project}/{file} to
+// the file's location inside the vault.
+func resolveAssetPath(vault VaultProvider, url string) (string, error) {
 	parts := strings.Split(strings.Trim(url, "/"), "/")
 	if len(parts) < 3 || parts[0] != "assets" {
 		return "", fmt.Errorf("invalid asset URL format: %s", url)
@@ -357,9 +480,8 @@ func (r *Renderer) resolveImagePath(url string) (string, error) {
 	filename := parts[2]
 
 	// Get assets directory from vault
-	assetsDir := r.vault.AssetsPath(projectAlias)
+	assetsDir := vault.AssetsPath(projectAlias)
 	imagePath := filepath.Join(assetsDir, filename)
 
 	return imagePath, nil
 }
-
@@ -214,6 +214,60 @@ func TestRenderer_RenderBlock_Quote(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRenderer_RenderBlock_RichBlocks(t *testing.T) {
+	renderer, pdf, _ := setupRendererTest(t)
+
+	blocks := []document.BlockNoteBlock{
+		{
+			ID:    "c1",
+			Type:  "callout",
+			Props: map[string]any{"variant": "tip"},
+			Content: mustMarshalContent([]document.BlockNoteContent{
+				{Type: "text", Text: "A useful tip"},
+			}),
+		},
+		{
+			ID:    "c2",
+			Type:  "callout",
+			Props: map[string]any{"variant": "bogus"},
+		},
+		{
+			ID:   "t1",
+			Type: "toggle",
+			Content: mustMarshalContent([]document.BlockNoteContent{
+				{Type: "text", Text: "Details"},
+			}),
+			Children: []document.BlockNoteBlock{
+				{
+					ID:   "t1p",
+					Type: "paragraph",
+					Content: mustMarshalContent([]document.BlockNoteContent{
+						{Type: "text", Text: "Printed expanded"},
+					}),
+				},
+			},
+		},
+		{
+			ID:   "m1",
+			Type: "math",
+			Content: mustMarshalContent([]document.BlockNoteContent{
+				{Type: "text", Text: "\\frac{a}{b}\n= c"},
+			}),
+		},
+		{
+			ID:   "d1",
+			Type: "diagram",
+			Content: mustMarshalContent([]document.BlockNoteContent{
+				{Type: "text", Text: "graph TD\n  A --> B"},
+			}),
+		},
+	}
+
+	require.NoError(t, renderer.RenderBlocks(blocks))
+	assert.Equal(t, 1, pdf.GetFpdf().PageNo())
+	assert.NoError(t, pdf.GetFpdf().Error())
+}
+
 func TestRenderer_RenderBlock_Image(t *testing.T) {
 	renderer, _, vault := setupRendererTest(t)
 
@@ -671,3 +725,69 @@ func TestRenderer_RenderBlock_EmptyContent(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderer_SpansFromContent(t *testing.T) {
+	renderer, _, _ := setupRendererTest(t)
+
+	content := mustMarshalContent([]document.BlockNoteContent{
+		{Type: "text", Text: "Hello ", Styles: map[string]any{"bold": true}},
+		{Type: "link", Href: "https://example.com", Content: []document.BlockNoteContent{
+			{Type: "text", Text: "site", Styles: map[string]any{"italic": true}},
+		}},
+		{Type: "link", Href: "projects/@test/doc-2.json", Content: []document.BlockNoteContent{
+			{Type: "text", Text: " other"},
+		}},
+		{Type: "link", Href: "javascript:alert(1)", Content: []document.BlockNoteContent{
+			{Type: "text", Text: " bad"},
+		}},
+	})
+
+	spans := renderer.spansFromContent(content)
+
+	assert.Equal(t, []TextSpan{
+		{Text: "Hello ", Bold: true},
+		{Text: "site", Italic: true, Link: "https://example.com"},
+		{Text: " other"},
+		{Text: " bad"},
+	}, spans)
+	assert.Equal(t, "Hello site other bad", plainText(spans))
+}
+
+func TestRenderer_NestedChildrenIndent(t *testing.T) {
+	renderer, pdf, _ := setupRendererTest(t)
+
+	item := func(text string, children ...document.BlockNoteBlock) document.BlockNoteBlock {
+		return document.BlockNoteBlock{
+			Type:     "bulletListItem",
+			Content:  mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: text}}),
+			Children: children,
+		}
+	}
+
+	err := renderer.RenderBlocks([]document.BlockNoteBlock{
+		item("one", item("two", item("three"))),
+	})
+	require.NoError(t, err)
+
+	// Indentation is restored once the nested lists are done.
+	assert.Equal(t, 0, renderer.depth)
+	assert.InDelta(t, pdfMargin, pdf.leftMargin(), 0.001)
+}
+
+func TestRenderer_ResolveDocumentLinks(t *testing.T) {
+	renderer, pdf, _ := setupRendererTest(t)
+	target := pdf.AddLink()
+	renderer.ResolveLink = func(href string) int {
+		if href == "projects/@test/doc-2.json#intro" {
+			return target
+		}
+		return 0
+	}
+
+	spans := renderer.spansFromContent(mustMarshalContent([]document.BlockNoteContent{
+		{Type: "link", Href: "projects/@test/doc-2.json#intro", Content: []document.BlockNoteContent{{Type: "text", Text: "in book"}}},
+		{Type: "link", Href: "projects/@test/doc-3.json", Content: []document.BlockNoteContent{{Type: "text", Text: "elsewhere"}}},
+	}))
+
+	assert.Equal(t, []TextSpan{{Text: "in book", LinkID: target}, {Text: "elsewhere"}}, spans)
+}
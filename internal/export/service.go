@@ -25,17 +25,23 @@ type VaultProvider interface {
 
 type Service struct {
 	docService DocumentProvider
+	docLister  DocumentLister
+	journal    JournalProvider
 	vault      VaultProvider
 }
 
 type ServiceConfig struct {
 	DocumentService DocumentProvider
+	DocumentLister  DocumentLister
+	Journal         JournalProvider
 	Vault           VaultProvider
 }
 
 func NewService(cfg ServiceConfig) *Service {
 	return &Service{
 		docService: cfg.DocumentService,
+		docLister:  cfg.DocumentLister,
+		journal:    cfg.Journal,
 		vault:      cfg.Vault,
 	}
 }
@@ -43,6 +49,8 @@ func NewService(cfg ServiceConfig) *Service {
 type ExportRequest struct {
 	DocumentPath string
 	OutputPath   string
+	// PDFOptions only apply to PDF exports.
+	PDFOptions
 }
 
 func (s *Service) ExportToPDF(ctx context.Context, req ExportRequest) error {
@@ -58,6 +66,10 @@ func (s *Service) ExportToPDF(ctx context.Context, req ExportRequest) error {
 		return fmt.Errorf("output path is required")
 	}
 
+	if _, err := pdfPageSize(req.PageSize); err != nil {
+		return err
+	}
+
 	logger.WithFields(map[string]any{
 		"docPath":    req.DocumentPath,
 		"outputPath": req.OutputPath,
@@ -79,25 +91,19 @@ func (s *Service) ExportToPDF(ctx context.Context, req ExportRequest) error {
 		"blockCount": len(docWithTags.File.Blocks),
 	}).Debug("document loaded successfully")
 
-	// Create PDF document
-	pdf, err := NewPDF(PDFConfig{
-		Title:   docWithTags.File.Meta.Title,
-		Author:  "Yanta",
-		Subject: strings.Join(docWithTags.Tags, ", "),
-	})
-	if err != nil {
-		logger.WithError(err).Error("failed to create PDF")
-		return fmt.Errorf("creating PDF: %w", err)
-	}
-
-	// Create renderer
-	renderer := NewRenderer(pdf, s.vault, docWithTags.File.Meta.Project)
-
 	// Render all blocks as one sibling sequence so numbered-list numbering and
 	// nested children render correctly.
-	if err := renderer.RenderBlocks(docWithTags.File.Blocks); err != nil {
-		logger.WithError(err).Error("failed to render blocks")
-		return fmt.Errorf("rendering blocks: %w", err)
+	pdf, err := buildPDF(PDFConfig{
+		Title:      docWithTags.File.Meta.Title,
+		Author:     "Yanta",
+		Subject:    strings.Join(docWithTags.Tags, ", "),
+		PDFOptions: req.PDFOptions,
+	}, nil, func(pdf *PDF) error {
+		return NewRenderer(pdf, s.vault, docWithTags.File.Meta.Project).RenderBlocks(docWithTags.File.Blocks)
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to render PDF")
+		return fmt.Errorf("rendering PDF: %w", err)
 	}
 
 	// Ensure output directory exists
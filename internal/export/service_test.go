@@ -505,3 +505,51 @@ func TestService_ExportCanvasImage_Validation(t *testing.T) {
 		assert.True(t, os.IsNotExist(statErr), "no file should be written for an oversized payload")
 	})
 }
+
+func TestService_ExportToPDF_WithOptions(t *testing.T) {
+	service, mockDoc, _ := setupServiceTest(t)
+	ctx := context.Background()
+
+	mockDoc.doc = &document.DocumentWithTags{
+		Document: &document.Document{Path: "projects/@test/doc-123.json", ProjectAlias: "@test"},
+		File: &document.DocumentFile{
+			Meta: document.DocumentMeta{Title: "Options", Project: "@test"},
+			Blocks: []document.BlockNoteBlock{
+				{
+					Type:    "heading",
+					Props:   map[string]any{"level": float64(1)},
+					Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Section"}}),
+				},
+				{
+					Type:    "codeBlock",
+					Props:   map[string]any{"language": "go"},
+					Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "package main"}}),
+				},
+			},
+		},
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "options.pdf")
+	err := service.ExportToPDF(ctx, ExportRequest{
+		DocumentPath: "projects/@test/doc-123.json",
+		OutputPath:   outputPath,
+		PDFOptions: PDFOptions{
+			PageSize:        "Letter",
+			TableOfContents: true,
+			Header:          "Yanta",
+			Footer:          "Exported notes",
+		},
+	})
+	require.NoError(t, err)
+
+	info, err := os.Stat(outputPath)
+	require.NoError(t, err)
+	assert.Greater(t, info.Size(), int64(0))
+
+	err = service.ExportToPDF(ctx, ExportRequest{
+		DocumentPath: "projects/@test/doc-123.json",
+		OutputPath:   outputPath,
+		PDFOptions:   PDFOptions{PageSize: "postcard"},
+	})
+	assert.ErrorContains(t, err, "unsupported page size")
+}
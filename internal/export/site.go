@@ -0,0 +1,366 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"yanta/internal/document"
+	"yanta/internal/logger"
+)
+
+// DocumentLister lists the documents of a project for multi-document exports.
+type DocumentLister interface {
+	ListByProject(ctx context.Context, projectAlias string, includeArchived bool, limit, offset int) ([]*document.Document, error)
+}
+
+// SiteExportRequest describes a static HTML site export of one project.
+type SiteExportRequest struct {
+	ProjectAlias string
+	OutputDir    string
+}
+
+const (
+	siteAssetsDir = "assets"
+	siteTagsDir   = "tags"
+	listPageSize  = 200
+)
+
+type sitePage struct {
+	doc      *document.DocumentWithTags
+	filename string
+}
+
+// siteBuilder holds the state of a single static site export: the mapping from
+// vault document paths to output pages, and the assets already copied so each
+// is written once however many pages reference it.
+type siteBuilder struct {
+	vault     VaultProvider
+	outputDir string
+	linkTags  bool
+	pages     []*sitePage
+	byPath    map[string]*sitePage
+	assets    map[string]string
+}
+
+// ExportToHTML writes a single document as a self-contained HTML page. Assets
+// are copied into an assets/ directory next to the output file.
+func (s *Service) ExportToHTML(ctx context.Context, req ExportRequest) error {
+	if s.docService == nil || s.vault == nil {
+		return fmt.Errorf("service not initialised correctly")
+	}
+
+	if strings.TrimSpace(req.DocumentPath) == "" {
+		return fmt.Errorf("document path is required")
+	}
+
+	if strings.TrimSpace(req.OutputPath) == "" {
+		return fmt.Errorf("output path is required")
+	}
+
+	logger.WithFields(map[string]any{
+		"docPath":    req.DocumentPath,
+		"outputPath": req.OutputPath,
+	}).Info("starting HTML export")
+
+	docWithTags, err := s.docService.Get(ctx, req.DocumentPath)
+	if err != nil {
+		logger.WithError(err).WithField("path", req.DocumentPath).Error("failed to get document")
+		return fmt.Errorf("getting document: %w", err)
+	}
+
+	if docWithTags.File == nil {
+		return fmt.Errorf("document file is nil")
+	}
+
+	outputDir := filepath.Dir(req.OutputPath)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		logger.WithError(err).WithField("dir", outputDir).Error("failed to create output directory")
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	b := newSiteBuilder(s.vault, outputDir)
+	page := &sitePage{doc: docWithTags, filename: filepath.Base(req.OutputPath)}
+	body := b.renderDocumentBody(page)
+
+	out := renderHTMLPage(docWithTags.File.Meta.Title, "", body)
+	if err := writeFileAtomic(req.OutputPath, []byte(out), 0644); err != nil {
+		logger.WithError(err).WithField("path", req.OutputPath).Error("failed to write HTML")
+		return fmt.Errorf("writing HTML file: %w", err)
+	}
+
+	logger.WithFields(map[string]any{
+		"outputPath": req.OutputPath,
+		"title":      docWithTags.File.Meta.Title,
+	}).Info("HTML export completed successfully")
+
+	return nil
+}
+
+// ExportProjectSite writes every document of a project as a static HTML site:
+// an index page, one page per tag, one page per document with inter-document
+// links rewritten to relative paths, and referenced assets copied alongside.
+func (s *Service) ExportProjectSite(ctx context.Context, req SiteExportRequest) error {
+	if s.docService == nil || s.vault == nil || s.docLister == nil {
+		return fmt.Errorf("service not initialised correctly")
+	}
+
+	if strings.TrimSpace(req.ProjectAlias) == "" {
+		return fmt.Errorf("project alias is required")
+	}
+
+	if strings.TrimSpace(req.OutputDir) == "" {
+		return fmt.Errorf("output directory is required")
+	}
+
+	logger.WithFields(map[string]any{
+		"projectAlias": req.ProjectAlias,
+		"outputDir":    req.OutputDir,
+	}).Info("starting static site export")
+
+	docs, err := s.loadProjectDocuments(ctx, req.ProjectAlias)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(req.OutputDir, 0755); err != nil {
+		logger.WithError(err).WithField("dir", req.OutputDir).Error("failed to create output directory")
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	b := newSiteBuilder(s.vault, req.OutputDir)
+	b.linkTags = true
+	for _, doc := range docs {
+		b.addPage(doc)
+	}
+
+	if err := b.build(req.ProjectAlias); err != nil {
+		logger.WithError(err).WithField("outputDir", req.OutputDir).Error("failed to build static site")
+		return err
+	}
+
+	logger.WithFields(map[string]any{
+		"projectAlias": req.ProjectAlias,
+		"outputDir":    req.OutputDir,
+		"pages":        len(b.pages),
+		"assets":       len(b.assets),
+	}).Info("static site export completed successfully")
+
+	return nil
+}
+
+// loadProjectDocuments pages through the project's live documents and loads
+// each file. Documents that fail to load are skipped with a warning so one
+// corrupt file doesn't block the whole export.
+func (s *Service) loadProjectDocuments(ctx context.Context, projectAlias string) ([]*document.DocumentWithTags, error) {
+	var docs []*document.DocumentWithTags
+	for offset := 0; ; offset += listPageSize {
+		batch, err := s.docLister.ListByProject(ctx, projectAlias, false, listPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("listing project documents: %w", err)
+		}
+
+		for _, meta := range batch {
+			doc, err := s.docService.Get(ctx, meta.Path)
+			if err != nil || doc.File == nil {
+				logger.WithError(err).WithField("path", meta.Path).Warn("failed to load document for export, skipping")
+				continue
+			}
+			docs = append(docs, doc)
+		}
+
+		if len(batch) < listPageSize {
+			return docs, nil
+		}
+	}
+}
+
+func newSiteBuilder(vault VaultProvider, outputDir string) *siteBuilder {
+	return &siteBuilder{
+		vault:     vault,
+		outputDir: outputDir,
+		byPath:    make(map[string]*sitePage),
+		assets:    make(map[string]string),
+	}
+}
+
+func (b *siteBuilder) addPage(doc *document.DocumentWithTags) {
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(doc.Path)), ".json") + ".html"
+	page := &sitePage{doc: doc, filename: name}
+	b.pages = append(b.pages, page)
+	b.byPath[doc.Path] = page
+}
+
+func (b *siteBuilder) build(projectAlias string) error {
+	sort.SliceStable(b.pages, func(i, j int) bool {
+		return strings.ToLower(b.pages[i].doc.File.Meta.Title) < strings.ToLower(b.pages[j].doc.File.Meta.Title)
+	})
+
+	tagPages := make(map[string][]*sitePage)
+	for _, page := range b.pages {
+		for _, tag := range page.doc.Tags {
+			tagPages[tag] = append(tagPages[tag], page)
+		}
+
+		nav := `<a href="index.html">Index</a>`
+		body := b.renderDocumentBody(page)
+		out := renderHTMLPage(page.doc.File.Meta.Title, nav, body)
+		if err := writeFileAtomic(filepath.Join(b.outputDir, page.filename), []byte(out), 0644); err != nil {
+			return fmt.Errorf("writing page %s: %w", page.filename, err)
+		}
+	}
+
+	tags := make([]string, 0, len(tagPages))
+	for tag := range tagPages {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	if len(tags) > 0 {
+		if err := os.MkdirAll(filepath.Join(b.outputDir, siteTagsDir), 0755); err != nil {
+			return fmt.Errorf("creating tags directory: %w", err)
+		}
+	}
+	for _, tag := range tags {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "<h1>#%s</h1>\n", html.EscapeString(tag))
+		writePageList(&sb, tagPages[tag], "../")
+
+		nav := `<a href="../index.html">Index</a>`
+		out := renderHTMLPage("#"+tag, nav, sb.String())
+		if err := writeFileAtomic(filepath.Join(b.outputDir, siteTagsDir, tag+".html"), []byte(out), 0644); err != nil {
+			return fmt.Errorf("writing tag page %s: %w", tag, err)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n", html.EscapeString(projectAlias))
+	writePageList(&sb, b.pages, "")
+	if len(tags) > 0 {
+		sb.WriteString("<h2>Tags</h2>\n<p class=\"tags\">")
+		for _, tag := range tags {
+			fmt.Fprintf(&sb, "<a href=\"%s/%s.html\">#%s</a>", siteTagsDir, html.EscapeString(tag), html.EscapeString(tag))
+		}
+		sb.WriteString("</p>\n")
+	}
+
+	out := renderHTMLPage(projectAlias, "", sb.String())
+	if err := writeFileAtomic(filepath.Join(b.outputDir, "index.html"), []byte(out), 0644); err != nil {
+		return fmt.Errorf("writing index page: %w", err)
+	}
+
+	return nil
+}
+
+func writePageList(sb *strings.Builder, pages []*sitePage, prefix string) {
+	sb.WriteString("<ul>\n")
+	for _, page := range pages {
+		fmt.Fprintf(sb, "<li><a href=\"%s%s\">%s</a> <span class=\"meta\">%s</span></li>\n",
+			prefix, html.EscapeString(page.filename),
+			html.EscapeString(page.doc.File.Meta.Title),
+			page.doc.File.Meta.Updated.Format("2006-01-02"))
+	}
+	sb.WriteString("</ul>\n")
+}
+
+// renderDocumentBody renders a page's title, tags and blocks. Pages sit at the
+// output root, so asset and page links are relative to it.
+func (b *siteBuilder) renderDocumentBody(page *sitePage) string {
+	file := page.doc.File
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n", html.EscapeString(file.Meta.Title))
+	if len(page.doc.Tags) > 0 {
+		sb.WriteString("<p class=\"tags\">")
+		for _, tag := range page.doc.Tags {
+			if b.linkTags {
+				fmt.Fprintf(&sb, "<a href=\"%s/%s.html\">#%s</a>", siteTagsDir, html.EscapeString(tag), html.EscapeString(tag))
+			} else {
+				fmt.Fprintf(&sb, "<span>#%s</span> ", html.EscapeString(tag))
+			}
+		}
+		sb.WriteString("</p>\n")
+	}
+
+	if file.Kind == document.DocumentKindCanvas {
+		sb.WriteString("<p class=\"meta\">Canvas document — visual content is only viewable in the app.</p>\n")
+		return sb.String()
+	}
+
+	renderer := NewHTMLRenderer()
+	renderer.ResolveAsset = b.copyAsset
+	renderer.ResolveLink = b.resolveLink
+	sb.WriteString(renderer.RenderBlocks(file.Blocks))
+
+	return sb.String()
+}
+
+// copyAsset copies a vault asset into the output assets directory and returns
+// its relative URL. Non-asset URLs (external images) are returned unchanged.
+func (b *siteBuilder) copyAsset(url string) string {
+	if rel, ok := b.assets[url]; ok {
+		return rel
+	}
+
+	src, err := resolveAssetPath(b.vault, url)
+	if err != nil {
+		return url
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		logger.WithError(err).WithField("path", src).Warn("asset not found, leaving link unchanged")
+		return url
+	}
+
+	dir := filepath.Join(b.outputDir, siteAssetsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.WithError(err).WithField("dir", dir).Warn("failed to create assets directory")
+		return url
+	}
+
+	name := filepath.Base(src)
+	if err := writeFileAtomic(filepath.Join(dir, name), data, 0644); err != nil {
+		logger.WithError(err).WithField("asset", name).Warn("failed to copy asset")
+		return url
+	}
+
+	rel := siteAssetsDir + "/" + name
+	b.assets[url] = rel
+	return rel
+}
+
+// resolveLink rewrites links to other vault documents into relative page
+// links. Links to documents outside the export are rendered as plain text;
+// everything else is left as-is.
+func (b *siteBuilder) resolveLink(href string) string {
+	docPath, fragment, ok := vaultDocumentLink(href)
+	if !ok {
+		return href
+	}
+
+	page, exported := b.byPath[docPath]
+	if !exported {
+		return ""
+	}
+	return page.filename + fragment
+}
+
+// vaultDocumentLink reports whether href points at a vault document
+// (projects/@alias/doc-*.json) and returns its normalized path and any
+// #fragment.
+func vaultDocumentLink(href string) (docPath, fragment string, ok bool) {
+	p := strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(href)), "/")
+	if i := strings.Index(p, "#"); i >= 0 {
+		p, fragment = p[:i], p[i:]
+	}
+	if !strings.HasPrefix(p, "projects/") || !strings.HasSuffix(p, ".json") {
+		return "", "", false
+	}
+	return p, fragment, true
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"yanta/internal/document"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSiteDocuments struct {
+	docs map[string]*document.DocumentWithTags
+}
+
+func (m *mockSiteDocuments) Get(ctx context.Context, path string) (*document.DocumentWithTags, error) {
+	doc, ok := m.docs[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return doc, nil
+}
+
+func (m *mockSiteDocuments) ListByProject(ctx context.Context, projectAlias string, includeArchived bool, limit, offset int) ([]*document.Document, error) {
+	var out []*document.Document
+	for _, doc := range m.docs {
+		if doc.ProjectAlias == projectAlias {
+			out = append(out, doc.Document)
+		}
+	}
+	if offset >= len(out) {
+		return nil, nil
+	}
+	return out[offset:], nil
+}
+
+func siteDoc(path, title string, tags []string, blocks ...document.BlockNoteBlock) *document.DocumentWithTags {
+	return &document.DocumentWithTags{
+		Document: &document.Document{Path: path, ProjectAlias: "@test", Title: title},
+		File: &document.DocumentFile{
+			Meta:   document.DocumentMeta{Project: "@test", Title: title, Tags: tags, Updated: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+			Blocks: blocks,
+		},
+		Tags: tags,
+	}
+}
+
+func TestService_ExportProjectSite(t *testing.T) {
+	tmpDir := t.TempDir()
+	vault := &mockVaultProvider{rootPath: tmpDir}
+
+	assetName := strings.Repeat("a", 64) + ".png"
+	assetsDir := vault.AssetsPath("@test")
+	require.NoError(t, os.MkdirAll(assetsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(assetsDir, assetName), []byte("png"), 0644))
+
+	docs := &mockSiteDocuments{docs: map[string]*document.DocumentWithTags{
+		"projects/@test/doc-a.json": siteDoc("projects/@test/doc-a.json", "Alpha", []string{"go"},
+			document.BlockNoteBlock{
+				Type: "paragraph",
+				Content: mustMarshalContent([]document.BlockNoteContent{
+					{Type: "link", Href: "projects/@test/doc-b.json", Content: []document.BlockNoteContent{{Type: "text", Text: "see beta"}}},
+					{Type: "link", Href: "projects/@other/doc-c.json", Content: []document.BlockNoteContent{{Type: "text", Text: "elsewhere"}}},
+				}),
+			},
+			document.BlockNoteBlock{Type: "image", Props: map[string]any{"url": "/assets/@test/" + assetName}},
+		),
+		"projects/@test/doc-b.json": siteDoc("projects/@test/doc-b.json", "Beta", []string{"go", "notes"}),
+	}}
+
+	service := NewService(ServiceConfig{DocumentService: docs, DocumentLister: docs, Vault: vault})
+	outDir := filepath.Join(t.TempDir(), "site")
+
+	err := service.ExportProjectSite(context.Background(), SiteExportRequest{ProjectAlias: "@test", OutputDir: outDir})
+	require.NoError(t, err)
+
+	index, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `<a href="doc-a.html">Alpha</a>`)
+	assert.Contains(t, string(index), `<a href="doc-b.html">Beta</a>`)
+	assert.Contains(t, string(index), `<a href="tags/notes.html">#notes</a>`)
+
+	alpha, err := os.ReadFile(filepath.Join(outDir, "doc-a.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(alpha), `<a href="doc-b.html">see beta</a>`)
+	assert.NotContains(t, string(alpha), "@other")
+	assert.Contains(t, string(alpha), `<img src="assets/`+assetName+`"`)
+	assert.Contains(t, string(alpha), `<a href="tags/go.html">#go</a>`)
+
+	tagPage, err := os.ReadFile(filepath.Join(outDir, "tags", "go.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(tagPage), `<a href="../doc-a.html">Alpha</a>`)
+	assert.Contains(t, string(tagPage), `<a href="../doc-b.html">Beta</a>`)
+
+	assert.FileExists(t, filepath.Join(outDir, "assets", assetName))
+}
+
+func TestService_ExportProjectSite_Validation(t *testing.T) {
+	docs := &mockSiteDocuments{}
+	vault := &mockVaultProvider{rootPath: t.TempDir()}
+
+	t.Run("requires lister", func(t *testing.T) {
+		service := NewService(ServiceConfig{DocumentService: docs, Vault: vault})
+		err := service.ExportProjectSite(context.Background(), SiteExportRequest{ProjectAlias: "@test", OutputDir: t.TempDir()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not initialised")
+	})
+
+	t.Run("requires project alias", func(t *testing.T) {
+		service := NewService(ServiceConfig{DocumentService: docs, DocumentLister: docs, Vault: vault})
+		err := service.ExportProjectSite(context.Background(), SiteExportRequest{OutputDir: t.TempDir()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "project alias is required")
+	})
+}
+
+func TestService_ExportToHTML(t *testing.T) {
+	service, mockDoc, _ := setupServiceTest(t)
+	mockDoc.doc = siteDoc("projects/@test/doc-a.json", "Alpha <1>", []string{"go"},
+		document.BlockNoteBlock{
+			Type:    "paragraph",
+			Content: mustMarshalContent([]document.BlockNoteContent{{Type: "text", Text: "Body"}}),
+		},
+	)
+
+	outputPath := filepath.Join(t.TempDir(), "alpha.html")
+	err := service.ExportToHTML(context.Background(), ExportRequest{DocumentPath: "projects/@test/doc-a.json", OutputPath: outputPath})
+	require.NoError(t, err)
+
+	out, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "<!DOCTYPE html>")
+	assert.Contains(t, string(out), "<title>Alpha &lt;1&gt;</title>")
+	assert.Contains(t, string(out), "<p>Body</p>")
+	assert.Contains(t, string(out), "<span>#go</span>")
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -301,10 +303,17 @@ func (sm *SyncManager) performSync(ctx context.Context, reasons []string) *SyncR
 	}
 
 	backupCfg := config.GetBackupConfig()
-	if backupCfg.Enabled {
+	passphrase := os.Getenv(backup.PassphraseEnvVar)
+	if backupCfg.Enabled && backupCfg.Encrypt && passphrase == "" {
+		logger.Warn("auto-sync: backup encryption enabled but " + backup.PassphraseEnvVar + " is not set, skipping pre-sync backup")
+	} else if backupCfg.Enabled {
 		logger.Debug("auto-sync: creating pre-sync backup")
 		backupService := backup.NewService()
-		if err := backupService.CreateBackup(dataDir); err != nil {
+		var opts []backup.Option
+		if backupCfg.Encrypt {
+			opts = append(opts, backup.WithPassphrase(passphrase))
+		}
+		if err := backupService.CreateBackup(dataDir, opts...); err != nil {
 			logger.WithField("error", err).Warn("auto-sync: backup creation failed, continuing with sync")
 		} else {
 			logger.Info("auto-sync: pre-sync backup created successfully")
@@ -516,12 +525,26 @@ func failureKey(r *SyncResult) string {
 	}
 }
 
+// mcpReasonPrefix marks reasons recorded for MCP tool calls. A multi-change
+// commit lists them in its body so an agent's edits stay attributable.
+const mcpReasonPrefix = "mcp("
+
 func (sm *SyncManager) buildCommitMessage(reasons []string) string {
 	if len(reasons) == 1 {
 		return fmt.Sprintf("auto: %s", reasons[0])
 	}
 
-	return fmt.Sprintf("auto: %d changes", len(reasons))
+	msg := fmt.Sprintf("auto: %d changes", len(reasons))
+	var mcpReasons []string
+	for _, r := range reasons {
+		if strings.HasPrefix(r, mcpReasonPrefix) && !slices.Contains(mcpReasons, r) {
+			mcpReasons = append(mcpReasons, r)
+		}
+	}
+	if len(mcpReasons) > 0 {
+		msg += "\n\n" + strings.Join(mcpReasons, "\n")
+	}
+	return msg
 }
 
 // clearPendingState removes the `consumed` reasons that this sync cycle
@@ -249,6 +249,16 @@ func TestSyncManager_BuildCommitMessage(t *testing.T) {
 		msg := sm.buildCommitMessage([]string{"change 1", "change 2", "change 3"})
 		assert.Equal(t, "auto: 3 changes", msg)
 	})
+
+	t.Run("mcp reasons are listed", func(t *testing.T) {
+		msg := sm.buildCommitMessage([]string{
+			"saved doc.json",
+			"mcp(claude/work): update_document doc.json",
+			"mcp(claude/work): update_document doc.json",
+			"mcp(cursor): append_journal @work",
+		})
+		assert.Equal(t, "auto: 4 changes\n\nmcp(claude/work): update_document doc.json\nmcp(cursor): append_journal @work", msg)
+	})
 }
 
 func TestSyncManager_NotGitRepo_SkipsSync(t *testing.T) {
@@ -0,0 +1,430 @@
+package importer
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"yanta/internal/blocktype"
+	"yanta/internal/document"
+
+	"github.com/google/uuid"
+)
+
+var enmlSpaceRe = regexp.MustCompile(`[ \t\r\n]+`)
+
+// enmlNode is an element or, when tag is empty, a text node of an ENML
+// document.
+type enmlNode struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	children []*enmlNode
+}
+
+// parseENML parses note content into a node tree. ENML is XHTML, but exports
+// in the wild contain HTML entities and unclosed tags, so the parser runs in
+// HTML mode.
+func parseENML(content string) (*enmlNode, error) {
+	d := xml.NewDecoder(strings.NewReader(content))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	root := &enmlNode{tag: "root"}
+	stack := []*enmlNode{root}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing ENML: %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &enmlNode{tag: strings.ToLower(t.Name.Local), attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &enmlNode{text: string(t)})
+		}
+	}
+	return root, nil
+}
+
+// style returns the node's inline style with whitespace removed, for
+// substring checks such as "-en-codeblock:true".
+func (n *enmlNode) style() string {
+	return strings.ToLower(strings.Join(strings.Fields(n.attrs["style"]), ""))
+}
+
+// enmlConverter turns ENML into blocks, uploading the note's attachments as
+// they are referenced.
+type enmlConverter struct {
+	ctx       context.Context
+	r         *run
+	source    string
+	project   string
+	resources map[string]*enexResource
+}
+
+// blockBuilder collects blocks, gathering inline content into a pending
+// paragraph (or check list item after an en-todo) until a block boundary.
+type blockBuilder struct {
+	blocks  []document.BlockNoteBlock
+	pending []document.BlockNoteContent
+	todo    *bool
+}
+
+func (b *blockBuilder) flush() {
+	content := trimInline(b.pending)
+	switch {
+	case b.todo != nil:
+		b.blocks = append(b.blocks, inlineBlock(blocktype.CheckListItem, map[string]any{"checked": *b.todo}, content))
+	case len(content) > 0:
+		b.blocks = append(b.blocks, inlineBlock(blocktype.Paragraph, nil, content))
+	}
+	b.pending, b.todo = nil, nil
+}
+
+func (b *blockBuilder) add(blocks ...document.BlockNoteBlock) {
+	b.flush()
+	b.blocks = append(b.blocks, blocks...)
+}
+
+func (c *enmlConverter) convert(content string) ([]document.BlockNoteBlock, error) {
+	root, err := parseENML(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var b blockBuilder
+	c.walk(&b, root.children, nil)
+	b.flush()
+	if b.blocks == nil {
+		b.blocks = []document.BlockNoteBlock{}
+	}
+	return b.blocks, nil
+}
+
+func (c *enmlConverter) walk(b *blockBuilder, nodes []*enmlNode, styles map[string]any) {
+	for _, n := range nodes {
+		switch n.tag {
+		case "":
+			b.pending = append(b.pending, styledText(enmlSpaceRe.ReplaceAllString(n.text, " "), styles)...)
+		case "br":
+			b.flush()
+		case "en-todo":
+			b.flush()
+			checked := strings.EqualFold(n.attrs["checked"], "true")
+			b.todo = &checked
+		case "en-media":
+			b.add(c.media(n)...)
+		case "en-crypt":
+			b.flush()
+			c.r.report.warnf("%s: encrypted content was not imported", c.source)
+		case "img":
+			if src := n.attrs["src"]; document.IsExternalURL(src) {
+				b.add(imageBlock(src, "", n.attrs["alt"]))
+			}
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level, _ := strconv.Atoi(n.tag[1:])
+			b.add(inlineBlock(blocktype.Heading, map[string]any{"level": min(level, 3)}, c.inline(n.children, nil)))
+		case "ul", "ol":
+			b.add(c.list(n)...)
+		case "table":
+			if table, ok := c.table(n); ok {
+				b.add(table)
+			}
+		case "pre":
+			b.add(codeBlock(codeText(n)))
+		case "blockquote":
+			b.add(inlineBlock(blocktype.Quote, nil, c.inline(n.children, nil)))
+		case "hr":
+			b.add(document.BlockNoteBlock{ID: uuid.NewString(), Type: blocktype.Divider})
+		case "a":
+			if href := n.attrs["href"]; href != "" {
+				content := trimInline(c.inline(n.children, styles))
+				if len(content) == 0 {
+					content = textContent(href)
+				}
+				b.pending = append(b.pending, document.BlockNoteContent{Type: blocktype.InlineLink, Href: href, Content: content})
+				continue
+			}
+			c.walk(b, n.children, styles)
+		case "div", "p", "section", "article", "header", "footer", "center", "en-note":
+			if strings.Contains(n.style(), "-en-codeblock:true") {
+				b.add(codeBlock(codeText(n)))
+				continue
+			}
+			b.flush()
+			c.walk(b, n.children, styles)
+			b.flush()
+		default:
+			c.walk(b, n.children, inlineStyles(n, styles))
+		}
+	}
+}
+
+// inline returns the inline content of nodes, with line breaks and block
+// boundaries as newlines. Used where only inline content fits: headings,
+// list items, table cells and quotes.
+func (c *enmlConverter) inline(nodes []*enmlNode, styles map[string]any) []document.BlockNoteContent {
+	return trimInline(c.inlineContent(nodes, styles))
+}
+
+func (c *enmlConverter) inlineContent(nodes []*enmlNode, styles map[string]any) []document.BlockNoteContent {
+	var out []document.BlockNoteContent
+	newline := func() {
+		if len(out) > 0 && !strings.HasSuffix(out[len(out)-1].Text, "\n") {
+			out = append(out, document.BlockNoteContent{Type: blocktype.InlineText, Text: "\n", Styles: map[string]any{}})
+		}
+	}
+
+	for _, n := range nodes {
+		switch n.tag {
+		case "":
+			out = append(out, styledText(enmlSpaceRe.ReplaceAllString(n.text, " "), styles)...)
+		case "br":
+			newline()
+		case "en-todo", "en-media", "en-crypt", "img", "table":
+		case "a":
+			content := trimInline(c.inline(n.children, styles))
+			if href := n.attrs["href"]; href != "" {
+				if len(content) == 0 {
+					content = textContent(href)
+				}
+				out = append(out, document.BlockNoteContent{Type: blocktype.InlineLink, Href: href, Content: content})
+			} else {
+				out = append(out, content...)
+			}
+		case "div", "p", "li", "h1", "h2", "h3", "h4", "h5", "h6", "pre", "blockquote":
+			newline()
+			out = append(out, c.inlineContent(n.children, styles)...)
+			newline()
+		default:
+			out = append(out, c.inlineContent(n.children, inlineStyles(n, styles))...)
+		}
+	}
+	return out
+}
+
+// list converts ul/ol into list items. Evernote's own check lists are lists
+// styled with --en-todo, their items with --en-checked.
+func (c *enmlConverter) list(n *enmlNode) []document.BlockNoteBlock {
+	itemType := blocktype.BulletListItem
+	if n.tag == "ol" {
+		itemType = blocktype.NumberedListItem
+	}
+	todoList := strings.Contains(n.style(), "--en-todo:true")
+
+	var items []document.BlockNoteBlock
+	for _, li := range n.children {
+		if li.tag != "li" {
+			continue
+		}
+
+		var inlineNodes, nested []*enmlNode
+		for _, child := range li.children {
+			if child.tag == "ul" || child.tag == "ol" {
+				nested = append(nested, child)
+			} else {
+				inlineNodes = append(inlineNodes, child)
+			}
+		}
+
+		typ, props := itemType, map[string]any(nil)
+		if todoList {
+			typ = blocktype.CheckListItem
+			props = map[string]any{"checked": strings.Contains(li.style(), "--en-checked:true")}
+		}
+		item := inlineBlock(typ, props, c.inline(inlineNodes, nil))
+		for _, sub := range nested {
+			item.Children = append(item.Children, c.list(sub)...)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (c *enmlConverter) table(n *enmlNode) (document.BlockNoteBlock, bool) {
+	var rows [][][]document.BlockNoteContent
+	var collect func(nodes []*enmlNode)
+	collect = func(nodes []*enmlNode) {
+		for _, child := range nodes {
+			switch child.tag {
+			case "tr":
+				var cells [][]document.BlockNoteContent
+				for _, cell := range child.children {
+					if cell.tag == "td" || cell.tag == "th" {
+						cells = append(cells, c.inline(cell.children, nil))
+					}
+				}
+				rows = append(rows, cells)
+			case "thead", "tbody", "tfoot":
+				collect(child.children)
+			}
+		}
+	}
+	collect(n.children)
+
+	if len(rows) == 0 {
+		return document.BlockNoteBlock{}, false
+	}
+	block, err := tableBlock(rows)
+	if err != nil {
+		c.r.report.warnf("%s: %v", c.source, err)
+		return document.BlockNoteBlock{}, false
+	}
+	return block, true
+}
+
+// media converts an en-media reference into an image block backed by an
+// uploaded asset. Other attachment types are named in a paragraph.
+func (c *enmlConverter) media(n *enmlNode) []document.BlockNoteBlock {
+	hash := strings.ToLower(n.attrs["hash"])
+	res, ok := c.resources[hash]
+	if !ok {
+		c.r.report.warnf("%s: attachment %s not found in export", c.source, hash)
+		return nil
+	}
+
+	name := res.filename(hash)
+	if _, isImage := imageMimeExts[strings.ToLower(res.Mime)]; !isImage {
+		c.r.report.warnf("%s: attachment %q is not an image and was not imported", c.source, name)
+		return []document.BlockNoteBlock{inlineBlock(blocktype.Paragraph, nil, textContent(name))}
+	}
+
+	assetURL, err := c.r.uploadAsset(c.ctx, c.project, hash, name, res.data)
+	if err != nil {
+		c.r.report.warnf("%s: %v", c.source, err)
+		return []document.BlockNoteBlock{inlineBlock(blocktype.Paragraph, nil, textContent(name))}
+	}
+	return []document.BlockNoteBlock{imageBlock(assetURL, name, "")}
+}
+
+// codeText returns the text of a code block, one line per div or br.
+func codeText(n *enmlNode) string {
+	var sb strings.Builder
+	var walk func(nodes []*enmlNode)
+	walk = func(nodes []*enmlNode) {
+		for _, child := range nodes {
+			switch child.tag {
+			case "":
+				sb.WriteString(child.text)
+			case "br":
+				sb.WriteString("\n")
+			case "div", "p":
+				if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+					sb.WriteString("\n")
+				}
+				walk(child.children)
+				if !strings.HasSuffix(sb.String(), "\n") {
+					sb.WriteString("\n")
+				}
+			default:
+				walk(child.children)
+			}
+		}
+	}
+	walk(n.children)
+	return strings.TrimRight(strings.ReplaceAll(sb.String(), "\u00a0", " "), "\n")
+}
+
+func codeBlock(code string) document.BlockNoteBlock {
+	return inlineBlock(blocktype.CodeBlock, map[string]any{"language": ""}, []document.BlockNoteContent{
+		{Type: blocktype.InlineText, Text: code, Styles: map[string]any{}},
+	})
+}
+
+// inlineStyles adds the styles an inline element applies to those
+// inherited from its parents.
+func inlineStyles(n *enmlNode, styles map[string]any) map[string]any {
+	var add []string
+	switch n.tag {
+	case "b", "strong":
+		add = append(add, blocktype.StyleBold)
+	case "i", "em":
+		add = append(add, blocktype.StyleItalic)
+	case "u":
+		add = append(add, "underline")
+	case "s", "strike", "del":
+		add = append(add, blocktype.StyleStrike)
+	case "code", "tt", "kbd":
+		add = append(add, blocktype.StyleCode)
+	case "span", "font":
+		style := n.style()
+		if strings.Contains(style, "font-weight:bold") || strings.Contains(style, "font-weight:700") {
+			add = append(add, blocktype.StyleBold)
+		}
+		if strings.Contains(style, "font-style:italic") {
+			add = append(add, blocktype.StyleItalic)
+		}
+		if strings.Contains(style, "underline") {
+			add = append(add, "underline")
+		}
+		if strings.Contains(style, "line-through") {
+			add = append(add, blocktype.StyleStrike)
+		}
+	}
+	if len(add) == 0 {
+		return styles
+	}
+
+	merged := make(map[string]any, len(styles)+len(add))
+	for k, v := range styles {
+		merged[k] = v
+	}
+	for _, s := range add {
+		merged[s] = true
+	}
+	return merged
+}
+
+func styledText(text string, styles map[string]any) []document.BlockNoteContent {
+	if text == "" {
+		return nil
+	}
+	copied := make(map[string]any, len(styles))
+	for k, v := range styles {
+		copied[k] = v
+	}
+	return []document.BlockNoteContent{{Type: blocktype.InlineText, Text: strings.ReplaceAll(text, "\u00a0", " "), Styles: copied}}
+}
+
+// trimInline drops leading and trailing whitespace from inline content, as
+// HTML rendering would.
+func trimInline(items []document.BlockNoteContent) []document.BlockNoteContent {
+	for len(items) > 0 && items[0].Type == blocktype.InlineText && strings.TrimSpace(items[0].Text) == "" {
+		items = items[1:]
+	}
+	for len(items) > 0 && items[len(items)-1].Type == blocktype.InlineText && strings.TrimSpace(items[len(items)-1].Text) == "" {
+		items = items[:len(items)-1]
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	out := append([]document.BlockNoteContent{}, items...)
+	if out[0].Type == blocktype.InlineText {
+		out[0].Text = strings.TrimLeft(out[0].Text, " \n")
+	}
+	if last := len(out) - 1; out[last].Type == blocktype.InlineText {
+		out[last].Text = strings.TrimRight(out[last].Text, " \n")
+	}
+	return out
+}
@@ -0,0 +1,290 @@
+package importer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"yanta/internal/logger"
+	"yanta/internal/project"
+)
+
+// EvernoteImportRequest describes an import of Evernote .enex exports.
+type EvernoteImportRequest struct {
+	// Path is an .enex file or a directory of them. Each file is one
+	// notebook, named after the file.
+	Path string
+	// ProjectMapping maps notebook names to project aliases. Unmapped
+	// notebooks become projects named after the notebook.
+	ProjectMapping map[string]string
+	DryRun         bool
+}
+
+const enexTimeLayout = "20060102T150405Z"
+
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+
+	data []byte
+}
+
+type enexNotebook struct {
+	source  string
+	name    string
+	project string
+	notes   []enexNote
+}
+
+var imageMimeExts = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ImportEvernote imports Evernote .enex exports. Each notebook becomes a
+// project and each note a document with its created and updated times and
+// tags. Note content (ENML) is converted to blocks; image attachments are
+// uploaded as assets and referenced from image blocks.
+func (s *Service) ImportEvernote(ctx context.Context, req EvernoteImportRequest) (*Report, error) {
+	if strings.TrimSpace(req.Path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	for notebook, alias := range req.ProjectMapping {
+		if err := project.ValidateAlias(alias); err != nil {
+			return nil, fmt.Errorf("invalid project alias for notebook %s: %w", notebook, err)
+		}
+	}
+
+	files, err := enexFiles(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.newRun(ctx, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(map[string]any{
+		"path":   req.Path,
+		"files":  len(files),
+		"dryRun": req.DryRun,
+	}).Info("starting Evernote import")
+
+	var notebooks []*enexNotebook
+	total := 0
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		notes, err := readENEX(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", filepath.Base(file), err)
+		}
+
+		nb := &enexNotebook{source: filepath.Base(file), name: name, notes: notes}
+		if mapped, ok := req.ProjectMapping[name]; ok {
+			nb.project = mapped
+		} else if nb.project, err = projectAlias(name); err != nil {
+			return nil, err
+		}
+		notebooks = append(notebooks, nb)
+		total += len(notes)
+	}
+
+	s.emitProgress(0, total, "Importing notes...")
+	done := 0
+	for _, nb := range notebooks {
+		if len(nb.notes) > 0 {
+			if err := r.ensureProject(ctx, nb.project, nb.name); err != nil {
+				return nil, err
+			}
+		}
+
+		for i := range nb.notes {
+			if err := r.importENEXNote(ctx, nb, &nb.notes[i]); err != nil {
+				return nil, err
+			}
+			done++
+			if done%10 == 0 || done == total {
+				s.emitProgress(done, total, fmt.Sprintf("Imported %d/%d notes", done, total))
+			}
+		}
+	}
+
+	logger.WithFields(map[string]any{
+		"path":      req.Path,
+		"dryRun":    req.DryRun,
+		"projects":  len(r.report.Projects),
+		"documents": len(r.report.Documents),
+		"assets":    r.report.Assets,
+		"warnings":  len(r.report.Warnings),
+	}).Info("Evernote import completed")
+
+	return r.report, nil
+}
+
+// enexFiles resolves the request path to the .enex files to import.
+func enexFiles(p string) ([]string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading path: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{p}, nil
+	}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".enex") {
+			files = append(files, filepath.Join(p, e.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .enex files found in %s", p)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readENEX decodes the notes of an export one at a time, so large exports
+// aren't held as a single document tree.
+func readENEX(file string) ([]enexNote, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var notes []enexNote
+	d := xml.NewDecoder(f)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing XML: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+		var note enexNote
+		if err := d.DecodeElement(&note, &start); err != nil {
+			return nil, fmt.Errorf("parsing note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (r *run) importENEXNote(ctx context.Context, nb *enexNotebook, note *enexNote) error {
+	title := strings.TrimSpace(note.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	source := nb.source + ": " + title
+
+	resources := make(map[string]*enexResource, len(note.Resources))
+	for i := range note.Resources {
+		res := &note.Resources[i]
+		if res.Data.Encoding != "" && res.Data.Encoding != "base64" {
+			r.report.warnf("%s: attachment with unsupported encoding %q skipped", source, res.Data.Encoding)
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(res.Data.Value), ""))
+		if err != nil {
+			r.report.warnf("%s: attachment %q could not be decoded: %v", source, res.FileName, err)
+			continue
+		}
+		res.data = data
+		sum := md5.Sum(data)
+		resources[hex.EncodeToString(sum[:])] = res
+	}
+
+	conv := &enmlConverter{
+		ctx:       ctx,
+		r:         r,
+		source:    source,
+		project:   nb.project,
+		resources: resources,
+	}
+	blocks, err := conv.convert(note.Content)
+	if err != nil {
+		r.report.warnf("%s: %v; importing as plain text", source, err)
+		blocks, err = markdownToBlocks(note.Content)
+		if err != nil {
+			return fmt.Errorf("converting %s: %w", source, err)
+		}
+	}
+
+	created := parseENEXTime(note.Created)
+	updated := parseENEXTime(note.Updated)
+	if updated.IsZero() {
+		updated = created
+	}
+	if !created.IsZero() && updated.Before(created) {
+		updated = created
+	}
+
+	_, err = r.saveDocument(ctx, documentDraft{
+		Source:  source,
+		Project: nb.project,
+		Title:   title,
+		Tags:    normalizeTags(note.Tags),
+		Blocks:  blocks,
+		Created: created,
+		Updated: updated,
+	}, "")
+	return err
+}
+
+func parseENEXTime(value string) time.Time {
+	t, err := time.Parse(enexTimeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// filename returns the attachment's name, deriving one from the hash and
+// MIME type when the export has none, and making sure images carry an
+// extension matching their type.
+func (res *enexResource) filename(hash string) string {
+	name := filepath.Base(strings.TrimSpace(res.FileName))
+	if name == "." || name == "" || name == "/" {
+		name = "attachment-" + hash[:min(8, len(hash))]
+	}
+	if ext, ok := imageMimeExts[strings.ToLower(res.Mime)]; ok && !imageExts[strings.ToLower(filepath.Ext(name))] {
+		name += ext
+	}
+	return name
+}
@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yanta/internal/document"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enexFixture() string {
+	sum := md5.Sum(pngBytes)
+	hash := hex.EncodeToString(sum[:])
+
+	content := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note>
+<h1>Agenda</h1>
+<div>Hello <b>bold</b> and <a href="https://example.com">a link</a>&nbsp;here</div>
+<div><en-todo checked="true"/>Done item</div>
+<div><en-todo/>Open item</div>
+<div><br/></div>
+<ul><li>One<ul><li>Nested</li></ul></li><li>Two</li></ul>
+<ul style="--en-todo:true;"><li style="--en-checked:true;">Checked</li><li>Unchecked</li></ul>
+<table><tbody><tr><td>A</td><td><i>B</i></td></tr><tr><td>1</td><td>2</td></tr></tbody></table>
+<div style="box-sizing: border-box; -en-codeblock: true;"><div>func main() {</div><div>&nbsp; run()</div><div>}</div></div>
+<en-media hash="` + hash + `" type="image/png"/>
+<en-media hash="ffffffffffffffffffffffffffffffff" type="application/pdf"/>
+<en-media hash="` + md5Hex([]byte("%PDF")) + `" type="application/pdf"/>
+</en-note>`
+
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export4.dtd">
+<en-export export-date="20240101T000000Z" application="Evernote" version="10.0">
+<note>
+<title>Meeting Notes</title>
+<created>20230102T030405Z</created>
+<updated>20230304T050607Z</updated>
+<tag>Work</tag>
+<tag>project/alpha</tag>
+<content><![CDATA[` + content + `]]></content>
+<resource>
+<data encoding="base64">
+` + base64.StdEncoding.EncodeToString(pngBytes) + `
+</data>
+<mime>image/png</mime>
+<resource-attributes><file-name>diagram</file-name></resource-attributes>
+</resource>
+<resource>
+<data encoding="base64">` + base64.StdEncoding.EncodeToString([]byte("%PDF")) + `</data>
+<mime>application/pdf</mime>
+<resource-attributes><file-name>spec.pdf</file-name></resource-attributes>
+</resource>
+</note>
+<note>
+<title></title>
+<created>20230105T000000Z</created>
+<content><![CDATA[<en-note>Just text</en-note>]]></content>
+</note>
+</en-export>`
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeENEX(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(p, []byte(enexFixture()), 0644))
+	return p
+}
+
+func TestService_ImportEvernote(t *testing.T) {
+	svc, f := setupImporterTest(t)
+	file := writeENEX(t, t.TempDir(), "Work Notes.enex")
+
+	report, err := svc.ImportEvernote(context.Background(), EvernoteImportRequest{Path: file})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@work-notes"}, f.projects.created)
+	assert.Equal(t, []string{"@work-notes/diagram.png"}, f.assets.uploads)
+	assert.Len(t, report.Documents, 2)
+	assert.Contains(t, report.Warnings, "Work Notes.enex: Meeting Notes: attachment ffffffffffffffffffffffffffffffff not found in export")
+	assert.Contains(t, report.Warnings, `Work Notes.enex: Meeting Notes: attachment "spec.pdf" is not an image and was not imported`)
+
+	_, note, ok := f.documents.byTitle("Meeting Notes")
+	require.True(t, ok)
+	assert.Equal(t, "@work-notes", note.ProjectAlias)
+	assert.Equal(t, []string{"project-alpha", "work"}, note.Tags)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), note.Created)
+	assert.Equal(t, time.Date(2023, 3, 4, 5, 6, 7, 0, time.UTC), note.Updated)
+
+	assert.Equal(t, []string{
+		"heading:Agenda",
+		"paragraph:Hello bold and a link->https://example.com here",
+		"checkListItem:Done item",
+		"checkListItem:Open item",
+		"bulletListItem:One",
+		"  bulletListItem:Nested",
+		"bulletListItem:Two",
+		"checkListItem:Checked",
+		"checkListItem:Unchecked",
+		"table:",
+		"codeBlock:func main() {\n  run()\n}",
+		"image:",
+		"paragraph:spec.pdf",
+	}, blockTexts(t, note.Blocks))
+	assert.Equal(t, true, note.Blocks[2].Props["checked"])
+	assert.Equal(t, false, note.Blocks[3].Props["checked"])
+	assert.Equal(t, true, note.Blocks[6].Props["checked"])
+	assert.Equal(t, false, note.Blocks[7].Props["checked"])
+	assert.Contains(t, note.Blocks[10].Props["url"], "/assets/@work-notes/")
+	assert.Equal(t, "diagram.png", note.Blocks[10].Props["name"])
+
+	var table document.TableContent
+	require.NoError(t, json.Unmarshal(note.Blocks[8].Content, &table))
+	require.Len(t, table.Rows, 2)
+	assert.Equal(t, "B", table.Rows[0].Cells[1].Content[0].Text)
+	assert.Equal(t, true, table.Rows[0].Cells[1].Content[0].Styles["italic"])
+
+	_, untitled, ok := f.documents.byTitle("Untitled")
+	require.True(t, ok)
+	assert.Equal(t, []string{"paragraph:Just text"}, blockTexts(t, untitled.Blocks))
+	assert.Equal(t, untitled.Created, untitled.Updated)
+}
+
+func TestService_ImportEvernote_Directory(t *testing.T) {
+	svc, f := setupImporterTest(t)
+	dir := t.TempDir()
+	writeENEX(t, dir, "Personal.enex")
+	writeENEX(t, dir, "Work.enex")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("x"), 0644))
+
+	report, err := svc.ImportEvernote(context.Background(), EvernoteImportRequest{
+		Path:           dir,
+		ProjectMapping: map[string]string{"Work": "@job"},
+		DryRun:         true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@personal", "@job"}, report.Projects)
+	assert.Len(t, report.Documents, 4)
+	assert.Equal(t, 2, report.Assets)
+	assert.Empty(t, f.documents.saved)
+
+	last := f.events.events[len(f.events.events)-1]
+	assert.Equal(t, 4, last["current"])
+}
+
+func TestService_ImportEvernote_Validation(t *testing.T) {
+	svc, _ := setupImporterTest(t)
+
+	_, err := svc.ImportEvernote(context.Background(), EvernoteImportRequest{})
+	assert.ErrorContains(t, err, "path is required")
+
+	_, err = svc.ImportEvernote(context.Background(), EvernoteImportRequest{Path: t.TempDir()})
+	assert.ErrorContains(t, err, "no .enex files found")
+
+	_, err = svc.ImportEvernote(context.Background(), EvernoteImportRequest{Path: "x.enex", ProjectMapping: map[string]string{"x": "bad"}})
+	assert.ErrorContains(t, err, "invalid project alias for notebook x")
+
+	bad := filepath.Join(t.TempDir(), "bad.enex")
+	require.NoError(t, os.WriteFile(bad, []byte("<en-export><note><title>x</note>"), 0644))
+	_, err = svc.ImportEvernote(context.Background(), EvernoteImportRequest{Path: bad})
+	assert.ErrorContains(t, err, "reading bad.enex")
+}
+
+func TestENMLInlineSpacing(t *testing.T) {
+	svc, _ := setupImporterTest(t)
+	r, err := svc.newRun(context.Background(), true)
+	require.NoError(t, err)
+
+	conv := &enmlConverter{ctx: context.Background(), r: r}
+	blocks, err := conv.convert(`<en-note><h2><span>Split </span><span>heading</span></h2><div>  lots   of
+	space  </div><blockquote>Quoted<br/>line</blockquote><hr/></en-note>`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"heading:Split heading", "paragraph:lots of space", "quote:Quoted\nline", "divider:"}, blockTexts(t, blocks))
+}
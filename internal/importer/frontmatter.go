@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// splitFrontmatter separates a leading YAML frontmatter block (between ---
+// lines) from the Markdown body. Files without frontmatter return a nil map.
+func splitFrontmatter(content string) (map[string]any, string, error) {
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\ufeff")
+	if !strings.HasPrefix(content, "---\n") {
+		return nil, content, nil
+	}
+
+	rest := content[len("---\n"):]
+	if strings.HasPrefix(rest, "---") {
+		rest = "\n" + rest
+	}
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, content, nil
+	}
+	raw := rest[:end]
+	body := rest[end+len("\n---"):]
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	} else {
+		body = ""
+	}
+
+	meta := map[string]any{}
+	if err := yaml.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil, body, fmt.Errorf("parsing frontmatter: %w", err)
+	}
+	return meta, body, nil
+}
+
+// stringList reads a frontmatter value that may be a single string, a
+// comma-separated string or a list.
+func stringList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		var out []string
+		for _, part := range strings.Split(val, ",") {
+			if p := strings.TrimSpace(part); p != "" {
+				out = append(out, p)
+			}
+		}
+		return out
+	case []any:
+		var out []string
+		for _, item := range val {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" && item != nil {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// frontmatterString returns the first non-empty string value among keys.
+func frontmatterString(meta map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := meta[key].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// frontmatterTime reads a timestamp value. YAML decodes unquoted dates to
+// time.Time; quoted ones are parsed in the common formats.
+func frontmatterTime(meta map[string]any, keys ...string) time.Time {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case time.Time:
+			return v
+		case string:
+			for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+				if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+					return t
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,552 @@
+// Package importer brings notes from other tools into the vault. Each source
+// format has its own entry point on Service; they share project creation,
+// asset upload and Markdown conversion, and all support a dry-run mode that
+// reports what would be imported without writing anything.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"yanta/internal/asset"
+	"yanta/internal/blocknote"
+	"yanta/internal/blocktype"
+	"yanta/internal/document"
+	"yanta/internal/journal"
+	"yanta/internal/project"
+	"yanta/internal/tag"
+
+	"github.com/google/uuid"
+)
+
+var (
+	wikiEmbedRe   = regexp.MustCompile(`!\[\[([^\]]+)\]\]`)
+	mdImageRe     = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	mdLinkRe      = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	fenceMarkerRe = regexp.MustCompile("^\\s*(```|~~~)")
+	imageExts     = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+)
+
+// ProjectStore lists and creates projects.
+type ProjectStore interface {
+	ListActive(ctx context.Context) ([]*project.Project, error)
+	Create(ctx context.Context, name, alias, startDate, endDate string) (string, error)
+}
+
+// DocumentSaver persists documents.
+type DocumentSaver interface {
+	Save(ctx context.Context, req document.SaveRequest) (string, error)
+}
+
+// AssetUploader stores attachments in a project's asset directory.
+type AssetUploader interface {
+	Upload(ctx context.Context, projectAlias string, data []byte, filename string) (*asset.AssetInfo, error)
+	BuildURL(ctx context.Context, projectAlias, hash, ext string) (string, error)
+}
+
+// JournalAppender appends entries to a project's journal on a given day.
+type JournalAppender interface {
+	AppendEntryToDate(ctx context.Context, req journal.AppendEntryRequestWithDate) (*journal.JournalEntry, error)
+}
+
+// EventEmitter publishes import progress to the frontend.
+type EventEmitter interface {
+	Emit(name string, data any)
+}
+
+type Service struct {
+	projects  ProjectStore
+	documents DocumentSaver
+	assets    AssetUploader
+	journal   JournalAppender
+	eventBus  EventEmitter
+}
+
+type ServiceConfig struct {
+	Projects  ProjectStore
+	Documents DocumentSaver
+	Assets    AssetUploader
+	Journal   JournalAppender
+	EventBus  EventEmitter
+}
+
+func NewService(cfg ServiceConfig) *Service {
+	return &Service{
+		projects:  cfg.Projects,
+		documents: cfg.Documents,
+		assets:    cfg.Assets,
+		journal:   cfg.Journal,
+		eventBus:  cfg.EventBus,
+	}
+}
+
+func (s *Service) emitProgress(current, total int, message string) {
+	if s.eventBus != nil {
+		s.eventBus.Emit("import:progress", map[string]any{
+			"current": current,
+			"total":   total,
+			"message": message,
+		})
+	}
+}
+
+// Report summarises an import. In dry-run mode it describes what would be
+// written; document paths are then empty.
+type Report struct {
+	DryRun         bool               `json:"dryRun"`
+	Projects       []string           `json:"projects"`
+	Documents      []ImportedDocument `json:"documents"`
+	JournalEntries int                `json:"journalEntries"`
+	Assets         int                `json:"assets"`
+	Warnings       []string           `json:"warnings"`
+}
+
+// ImportedDocument describes one document created by an import.
+type ImportedDocument struct {
+	Source  string `json:"source"`
+	Project string `json:"project"`
+	Title   string `json:"title"`
+	Path    string `json:"path,omitempty"`
+}
+
+func (r *Report) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// run holds the state of a single import: known projects and uploaded
+// assets, so each project is created and each file uploaded once.
+type run struct {
+	svc      *Service
+	report   *Report
+	projects map[string]bool
+	uploads  map[string]string
+}
+
+func (s *Service) newRun(ctx context.Context, dryRun bool) (*run, error) {
+	if s.projects == nil || s.documents == nil {
+		return nil, fmt.Errorf("service not initialised correctly")
+	}
+
+	active, err := s.projects.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+
+	r := &run{
+		svc:      s,
+		report:   &Report{DryRun: dryRun, Projects: []string{}, Documents: []ImportedDocument{}, Warnings: []string{}},
+		projects: make(map[string]bool, len(active)),
+		uploads:  make(map[string]string),
+	}
+	for _, p := range active {
+		r.projects[p.Alias] = true
+	}
+	return r, nil
+}
+
+// projectAlias derives a valid project alias from a folder or notebook name.
+func projectAlias(name string) (string, error) {
+	alias := project.NormalizeAlias(name)
+	if err := project.ValidateAlias(alias); err != nil {
+		return "", fmt.Errorf("cannot derive project alias from %q: %w", name, err)
+	}
+	return alias, nil
+}
+
+// ensureProject creates the project if it doesn't exist yet. New projects are
+// listed in the report.
+func (r *run) ensureProject(ctx context.Context, alias, name string) error {
+	if r.projects[alias] {
+		return nil
+	}
+
+	if !r.report.DryRun {
+		if _, err := r.svc.projects.Create(ctx, name, alias, "", ""); err != nil {
+			return fmt.Errorf("creating project %s: %w", alias, err)
+		}
+	}
+	r.projects[alias] = true
+	r.report.Projects = append(r.report.Projects, alias)
+	return nil
+}
+
+// uploadAsset stores data as a project asset and returns its vault URL. key
+// identifies the source file so repeated references upload once.
+func (r *run) uploadAsset(ctx context.Context, projectAlias, key, filename string, data []byte) (string, error) {
+	cacheKey := projectAlias + "\x00" + key
+	if url, ok := r.uploads[cacheKey]; ok {
+		return url, nil
+	}
+
+	if r.report.DryRun {
+		url := "/assets/" + projectAlias + "/" + filename
+		r.uploads[cacheKey] = url
+		r.report.Assets++
+		return url, nil
+	}
+
+	if r.svc.assets == nil {
+		return "", fmt.Errorf("asset uploads are not available")
+	}
+
+	info, err := r.svc.assets.Upload(ctx, projectAlias, data, filename)
+	if err != nil {
+		return "", fmt.Errorf("uploading %s: %w", filename, err)
+	}
+	url, err := r.svc.assets.BuildURL(ctx, projectAlias, info.Hash, info.Ext)
+	if err != nil {
+		return "", fmt.Errorf("building asset URL for %s: %w", filename, err)
+	}
+
+	r.uploads[cacheKey] = url
+	r.report.Assets++
+	return url, nil
+}
+
+// documentDraft is a document ready to be saved.
+type documentDraft struct {
+	Source  string
+	Project string
+	Title   string
+	Tags    []string
+	Aliases []string
+	Blocks  []document.BlockNoteBlock
+	Created time.Time
+	Updated time.Time
+}
+
+// saveDocument writes a draft, or an update of an already saved document when
+// path is set, and returns the document path. Dry runs only record the draft.
+func (r *run) saveDocument(ctx context.Context, draft documentDraft, path string) (string, error) {
+	if path == "" {
+		r.report.Documents = append(r.report.Documents, ImportedDocument{
+			Source:  draft.Source,
+			Project: draft.Project,
+			Title:   draft.Title,
+		})
+	}
+	if r.report.DryRun {
+		return "", nil
+	}
+
+	blocks := draft.Blocks
+	if blocks == nil {
+		blocks = []document.BlockNoteBlock{}
+	}
+	tags := draft.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	aliases := draft.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	saved, err := r.svc.documents.Save(ctx, document.SaveRequest{
+		Path:         path,
+		ProjectAlias: draft.Project,
+		Title:        draft.Title,
+		Blocks:       blocks,
+		Tags:         tags,
+		Aliases:      aliases,
+		Created:      draft.Created,
+		Updated:      draft.Updated,
+	})
+	if err != nil {
+		return "", fmt.Errorf("saving %s: %w", draft.Source, err)
+	}
+
+	if path == "" {
+		r.report.Documents[len(r.report.Documents)-1].Path = saved
+	}
+	return saved, nil
+}
+
+// appendJournal adds an entry to a project's journal. Content over the
+// journal's length limit is split across several entries.
+func (r *run) appendJournal(ctx context.Context, projectAlias, date, content string, tags []string) error {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+
+	for _, chunk := range splitContent(content, journal.MaxEntryContentLength) {
+		if !r.report.DryRun {
+			if r.svc.journal == nil {
+				return fmt.Errorf("journal is not available")
+			}
+			if _, err := r.svc.journal.AppendEntryToDate(ctx, journal.AppendEntryRequestWithDate{
+				ProjectAlias: projectAlias,
+				Content:      chunk,
+				Tags:         tags,
+				Date:         date,
+			}); err != nil {
+				return fmt.Errorf("appending journal entry for %s: %w", date, err)
+			}
+		}
+		r.report.JournalEntries++
+	}
+	return nil
+}
+
+// splitContent splits s into pieces of at most max bytes, preferring line
+// breaks and never cutting a UTF-8 sequence.
+func splitContent(s string, max int) []string {
+	var out []string
+	for len(s) > max {
+		cut := strings.LastIndexByte(s[:max], '\n')
+		if cut <= 0 {
+			cut = max
+			for cut > 0 && !utf8RuneStart(s[cut]) {
+				cut--
+			}
+		}
+		out = append(out, strings.TrimSpace(s[:cut]))
+		s = strings.TrimSpace(s[cut:])
+	}
+	if s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// markdownToBlocks converts Markdown to document blocks via the blocknote
+// codec. The two block types share an identical JSON shape, so the bridge is
+// a single marshal/unmarshal round-trip.
+func markdownToBlocks(md string) ([]document.BlockNoteBlock, error) {
+	raw, err := json.Marshal(blocknote.MarkdownToBlocks(md))
+	if err != nil {
+		return nil, err
+	}
+	var blocks []document.BlockNoteBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, err
+	}
+	if blocks == nil {
+		blocks = []document.BlockNoteBlock{}
+	}
+	return blocks, nil
+}
+
+// embedRef is an embedded file found in Markdown: ![[target|label]] or
+// ![label](target).
+type embedRef struct {
+	target string
+	label  string
+}
+
+// nextEmbed finds the first ![[...]] or ![](...) embed in line.
+func nextEmbed(line string) (int, int, embedRef) {
+	wiki := wikiEmbedRe.FindStringSubmatchIndex(line)
+	image := mdImageRe.FindStringSubmatchIndex(line)
+
+	switch {
+	case wiki != nil && (image == nil || wiki[0] < image[0]):
+		target, label := splitWikiTarget(line[wiki[2]:wiki[3]])
+		return wiki[0], wiki[1], embedRef{target: target, label: label}
+	case image != nil:
+		target := line[image[4]:image[5]]
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+		return image[0], image[1], embedRef{target: target, label: line[image[2]:image[3]]}
+	default:
+		return -1, -1, embedRef{}
+	}
+}
+
+// convertMarkdown converts a note body into blocks. Each embed is replaced by
+// what embed returns: an image block, which splits the surrounding text, or
+// replacement text. All other lines outside fenced code pass through rewrite
+// so the caller can resolve links.
+func convertMarkdown(body string, embed func(embedRef) (*document.BlockNoteBlock, string), rewrite func(string) string) ([]document.BlockNoteBlock, error) {
+	var blocks []document.BlockNoteBlock
+	var md []string
+	flush := func() error {
+		if len(md) == 0 {
+			return nil
+		}
+		converted, err := markdownToBlocks(strings.Join(md, "\n"))
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, converted...)
+		md = nil
+		return nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if fenceMarkerRe.MatchString(line) {
+			inFence = !inFence
+			md = append(md, line)
+			continue
+		}
+		if inFence {
+			md = append(md, line)
+			continue
+		}
+
+		for {
+			start, end, ref := nextEmbed(line)
+			if start < 0 {
+				break
+			}
+
+			block, text := embed(ref)
+			if block == nil {
+				line = line[:start] + text + line[end:]
+				continue
+			}
+
+			if before := strings.TrimSpace(line[:start]); before != "" {
+				md = append(md, rewrite(line[:start]))
+			}
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, *block)
+			line = line[end:]
+		}
+		md = append(md, rewrite(line))
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if blocks == nil {
+		blocks = []document.BlockNoteBlock{}
+	}
+	return blocks, nil
+}
+
+// normalizeTags maps source tags onto valid, de-duplicated, sorted tag names.
+// Hierarchical tags (a/b) become a-b. Tags that can't be normalized are
+// dropped.
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, name := range names {
+		n := tag.Normalize(strings.ReplaceAll(strings.TrimPrefix(name, "#"), "/", "-"))
+		if n == "" || seen[n] || tag.Validate(n) != nil {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normalizeAliases maps free-text aliases onto the document alias format
+// (letters, digits, - and _). Aliases that can't be mapped are dropped.
+func normalizeAliases(names []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, name := range names {
+		var sb strings.Builder
+		for _, r := range strings.TrimSpace(name) {
+			switch {
+			case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_':
+				sb.WriteRune(r)
+			case r == ' ' || r == '.' || r == '/':
+				sb.WriteRune('-')
+			}
+		}
+		a := strings.Trim(sb.String(), "-")
+		if len(a) < 2 || len(a) > 128 || seen[a] {
+			continue
+		}
+		seen[a] = true
+		out = append(out, a)
+	}
+	return out
+}
+
+func textContent(text string) []document.BlockNoteContent {
+	if text == "" {
+		return []document.BlockNoteContent{}
+	}
+	return []document.BlockNoteContent{{Type: "text", Text: text, Styles: map[string]any{}}}
+}
+
+func linkContent(text, href string) []document.BlockNoteContent {
+	return []document.BlockNoteContent{{Type: "link", Href: href, Content: textContent(text)}}
+}
+
+func inlineBlock(typ string, props map[string]any, content []document.BlockNoteContent) document.BlockNoteBlock {
+	if content == nil {
+		content = []document.BlockNoteContent{}
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		raw = json.RawMessage("[]")
+	}
+	return document.BlockNoteBlock{
+		ID:      uuid.NewString(),
+		Type:    typ,
+		Props:   props,
+		Content: raw,
+	}
+}
+
+// tableBlock builds a table block from rows of cell contents. Short rows are
+// padded to the widest row.
+func tableBlock(rows [][][]document.BlockNoteContent) (document.BlockNoteBlock, error) {
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+
+	table := document.TableContent{
+		Type:         "tableContent",
+		ColumnWidths: make([]any, cols),
+		Rows:         make([]document.TableRow, 0, len(rows)),
+	}
+	for _, row := range rows {
+		cells := make([]document.TableCell, cols)
+		for c := range cells {
+			content := []document.BlockNoteContent{}
+			if c < len(row) && row[c] != nil {
+				content = row[c]
+			}
+			cells[c] = document.TableCell{Type: "tableCell", Content: content, Props: map[string]any{}}
+		}
+		table.Rows = append(table.Rows, document.TableRow{Cells: cells})
+	}
+
+	content, err := json.Marshal(table)
+	if err != nil {
+		return document.BlockNoteBlock{}, fmt.Errorf("encoding table: %w", err)
+	}
+	return document.BlockNoteBlock{
+		ID:      uuid.NewString(),
+		Type:    blocktype.Table,
+		Content: content,
+	}, nil
+}
+
+func imageBlock(url, name, caption string) document.BlockNoteBlock {
+	props := map[string]any{"url": url}
+	if name != "" {
+		props["name"] = name
+	}
+	if caption != "" {
+		props["caption"] = caption
+	}
+	return document.BlockNoteBlock{
+		ID:    uuid.NewString(),
+		Type:  blocktype.Image,
+		Props: props,
+	}
+}
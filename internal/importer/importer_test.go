@@ -0,0 +1,200 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"yanta/internal/asset"
+	"yanta/internal/document"
+	"yanta/internal/journal"
+	"yanta/internal/project"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProjects struct {
+	existing []*project.Project
+	created  []string
+}
+
+func (f *fakeProjects) ListActive(ctx context.Context) ([]*project.Project, error) {
+	return f.existing, nil
+}
+
+func (f *fakeProjects) Create(ctx context.Context, name, alias, startDate, endDate string) (string, error) {
+	f.created = append(f.created, alias)
+	return "id-" + alias, nil
+}
+
+type fakeDocuments struct {
+	saved map[string]document.SaveRequest
+	saves int
+}
+
+func (f *fakeDocuments) Save(ctx context.Context, req document.SaveRequest) (string, error) {
+	if f.saved == nil {
+		f.saved = make(map[string]document.SaveRequest)
+	}
+	f.saves++
+	path := req.Path
+	if path == "" {
+		path = fmt.Sprintf("projects/%s/doc-%d.json", req.ProjectAlias, len(f.saved)+1)
+	}
+	f.saved[path] = req
+	return path, nil
+}
+
+func (f *fakeDocuments) byTitle(title string) (string, document.SaveRequest, bool) {
+	for path, req := range f.saved {
+		if req.Title == title {
+			return path, req, true
+		}
+	}
+	return "", document.SaveRequest{}, false
+}
+
+type fakeAssets struct {
+	uploads []string
+}
+
+func (f *fakeAssets) Upload(ctx context.Context, projectAlias string, data []byte, filename string) (*asset.AssetInfo, error) {
+	f.uploads = append(f.uploads, projectAlias+"/"+filename)
+	sum := sha256.Sum256(data)
+	return &asset.AssetInfo{Hash: hex.EncodeToString(sum[:]), Ext: strings.ToLower(filepath.Ext(filename))}, nil
+}
+
+func (f *fakeAssets) BuildURL(ctx context.Context, projectAlias, hash, ext string) (string, error) {
+	return "/assets/" + projectAlias + "/" + hash + ext, nil
+}
+
+type fakeJournal struct {
+	entries []journal.AppendEntryRequestWithDate
+}
+
+func (f *fakeJournal) AppendEntryToDate(ctx context.Context, req journal.AppendEntryRequestWithDate) (*journal.JournalEntry, error) {
+	f.entries = append(f.entries, req)
+	return journal.NewJournalEntry(req.Content, req.Tags), nil
+}
+
+type fakeEvents struct {
+	events []map[string]any
+}
+
+func (f *fakeEvents) Emit(name string, data any) {
+	if name == "import:progress" {
+		f.events = append(f.events, data.(map[string]any))
+	}
+}
+
+type fakes struct {
+	projects  *fakeProjects
+	documents *fakeDocuments
+	assets    *fakeAssets
+	journal   *fakeJournal
+	events    *fakeEvents
+}
+
+func setupImporterTest(t *testing.T, existing ...string) (*Service, *fakes) {
+	t.Helper()
+
+	f := &fakes{
+		projects:  &fakeProjects{},
+		documents: &fakeDocuments{},
+		assets:    &fakeAssets{},
+		journal:   &fakeJournal{},
+		events:    &fakeEvents{},
+	}
+	for _, alias := range existing {
+		f.projects.existing = append(f.projects.existing, &project.Project{Alias: alias})
+	}
+
+	svc := NewService(ServiceConfig{
+		Projects:  f.projects,
+		Documents: f.documents,
+		Assets:    f.assets,
+		Journal:   f.journal,
+		EventBus:  f.events,
+	})
+	return svc, f
+}
+
+// blockTexts flattens blocks to "type:text" strings for assertions.
+func blockTexts(t *testing.T, blocks []document.BlockNoteBlock) []string {
+	t.Helper()
+
+	var out []string
+	for _, b := range blocks {
+		var inline []document.BlockNoteContent
+		if len(b.Content) > 0 {
+			_ = json.Unmarshal(b.Content, &inline)
+		}
+		var sb strings.Builder
+		for _, item := range inline {
+			sb.WriteString(item.Text)
+			if item.Type == "link" {
+				for _, c := range item.Content {
+					sb.WriteString(c.Text)
+				}
+				sb.WriteString("->" + item.Href)
+			}
+		}
+		out = append(out, b.Type+":"+sb.String())
+		for _, child := range blockTexts(t, b.Children) {
+			out = append(out, "  "+child)
+		}
+	}
+	return out
+}
+
+func TestService_NotInitialised(t *testing.T) {
+	svc := NewService(ServiceConfig{})
+	_, err := svc.ImportObsidian(context.Background(), ObsidianImportRequest{VaultPath: t.TempDir()})
+	assert.ErrorContains(t, err, "service not initialised correctly")
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{"#Work", "project/alpha", "work", "  ", "Needs Review"})
+	assert.Equal(t, []string{"needs-review", "project-alpha", "work"}, got)
+}
+
+func TestNormalizeAliases(t *testing.T) {
+	got := normalizeAliases([]string{"My Note", "x", "dotted.name", "My Note", "emoji 🎉 name"})
+	assert.Equal(t, []string{"My-Note", "dotted-name", "emoji--name"}, got)
+}
+
+func TestSplitContent(t *testing.T) {
+	assert.Equal(t, []string{"short"}, splitContent("short", 10))
+	assert.Equal(t, []string{"line one", "line two"}, splitContent("line one\nline two", 12))
+	assert.Equal(t, []string{"abcde", "fghij"}, splitContent("abcdefghij", 5))
+	assert.Equal(t, []string{"é", "é"}, splitContent("éé", 3))
+}
+
+func TestSplitFrontmatter(t *testing.T) {
+	meta, body, err := splitFrontmatter("---\ntitle: Hello\ntags: [a, b]\n---\n# Body\n")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", meta["title"])
+	assert.Equal(t, []string{"a", "b"}, stringList(meta["tags"]))
+	assert.Equal(t, "# Body\n", body)
+
+	meta, body, err = splitFrontmatter("no frontmatter")
+	require.NoError(t, err)
+	assert.Nil(t, meta)
+	assert.Equal(t, "no frontmatter", body)
+
+	meta, body, err = splitFrontmatter("---\n---\nbody")
+	require.NoError(t, err)
+	assert.Empty(t, meta)
+	assert.Equal(t, "body", body)
+
+	_, _, err = splitFrontmatter("---\n: bad: [\n---\nbody")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"one", "two"}, stringList("one, two"))
+}
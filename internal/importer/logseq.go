@@ -0,0 +1,646 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"yanta/internal/blocktype"
+	"yanta/internal/document"
+	"yanta/internal/journal"
+	"yanta/internal/logger"
+	"yanta/internal/project"
+)
+
+// LogseqImportRequest describes a Logseq graph import.
+type LogseqImportRequest struct {
+	GraphPath string
+	// Project receives the pages and the journal. Empty derives a project
+	// from the graph directory name.
+	Project string
+	DryRun  bool
+}
+
+var (
+	logseqJournalRe   = regexp.MustCompile(`^(\d{4})[_-](\d{2})[_-](\d{2})$`)
+	logseqBulletRe    = regexp.MustCompile(`^([ \t]*)-(?:[ \t]+(.*))?$`)
+	logseqPropertyRe  = regexp.MustCompile(`^([A-Za-z0-9_-]+)::\s*(.*)$`)
+	logseqMarkerRe    = regexp.MustCompile(`^(TODO|DOING|NOW|LATER|WAITING|DONE)\s+`)
+	logseqBlockRefRe  = regexp.MustCompile(`\(\(([0-9a-fA-F-]{36})\)\)`)
+	logseqEmbedRe     = regexp.MustCompile(`\{\{embed\s+(.+?)\}\}`)
+	logseqTagRefRe    = regexp.MustCompile(`#\[\[([^\]]+)\]\]`)
+	logseqLabelLinkRe = regexp.MustCompile(`\[([^\]]+)\]\(\[\[([^\]]+)\]\]\)`)
+)
+
+// outlineBlock is one Logseq block: a bullet with its text, properties and
+// nested blocks. Text before a page's first bullet is a block without a
+// bullet.
+type outlineBlock struct {
+	bullet   bool
+	lines    []string
+	props    map[string]string
+	children []*outlineBlock
+}
+
+func (b *outlineBlock) text() string {
+	return strings.TrimSpace(strings.Join(b.lines, "\n"))
+}
+
+type logseqPage struct {
+	rel     string
+	title   string
+	tags    []string
+	aliases []string
+	blocks  []*outlineBlock
+	created time.Time
+	updated time.Time
+	date    string
+
+	path     string
+	hasLinks bool
+}
+
+// logseqGraph indexes pages by lower-cased name and alias, and block text by
+// block id for ((block references)).
+type logseqGraph struct {
+	root      string
+	project   string
+	pages     []*logseqPage
+	journals  []*logseqPage
+	byName    map[string]*logseqPage
+	blockText map[string]string
+}
+
+// ImportLogseq imports a Logseq graph. Each top-level bullet of a journal day
+// becomes a journal entry on that date; pages become documents whose nested
+// bullets become nested blocks, with TODO/DONE blocks as check list items.
+// Page properties provide the title, tags and aliases.
+func (s *Service) ImportLogseq(ctx context.Context, req LogseqImportRequest) (*Report, error) {
+	if strings.TrimSpace(req.GraphPath) == "" {
+		return nil, fmt.Errorf("graph path is required")
+	}
+	if info, err := os.Stat(req.GraphPath); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("graph path is not a directory: %s", req.GraphPath)
+	}
+
+	alias, name := req.Project, strings.TrimPrefix(req.Project, "@")
+	if alias == "" {
+		name = filepath.Base(filepath.Clean(req.GraphPath))
+		derived, err := projectAlias(name)
+		if err != nil {
+			return nil, err
+		}
+		alias = derived
+	} else if err := project.ValidateAlias(alias); err != nil {
+		return nil, fmt.Errorf("invalid project alias: %w", err)
+	}
+
+	r, err := s.newRun(ctx, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(map[string]any{
+		"graphPath": req.GraphPath,
+		"project":   alias,
+		"dryRun":    req.DryRun,
+	}).Info("starting Logseq import")
+
+	g, err := scanLogseqGraph(req.GraphPath, r.report)
+	if err != nil {
+		return nil, err
+	}
+	g.project = alias
+
+	if len(g.pages) > 0 || len(g.journals) > 0 {
+		if err := r.ensureProject(ctx, alias, name); err != nil {
+			return nil, err
+		}
+	}
+
+	total := len(g.pages) + len(g.journals)
+	s.emitProgress(0, total, "Importing pages...")
+
+	// Links need their target's path, so linking pages are saved twice, as in
+	// the Obsidian import.
+	for i, page := range g.pages {
+		blocks, err := r.convertOutline(ctx, g, page, page.blocks, true)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s: %w", page.rel, err)
+		}
+		page.path, err = r.saveDocument(ctx, page.draft(alias, blocks), "")
+		if err != nil {
+			return nil, err
+		}
+		if (i+1)%10 == 0 {
+			s.emitProgress(i+1, total, fmt.Sprintf("Imported %d/%d pages", i+1, total))
+		}
+	}
+
+	if !req.DryRun {
+		for _, page := range g.pages {
+			if !page.hasLinks {
+				continue
+			}
+			blocks, err := r.convertOutline(ctx, g, page, page.blocks, false)
+			if err != nil {
+				return nil, fmt.Errorf("converting %s: %w", page.rel, err)
+			}
+			if _, err := r.saveDocument(ctx, page.draft(alias, blocks), page.path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, day := range g.journals {
+		for _, block := range day.blocks {
+			content := r.logseqEntryText(g, day, block, 0)
+			tags := append(append([]string{}, day.tags...), logseqPropertyList(block.props["tags"])...)
+			tags = append(tags, inlineTags(content)...)
+			if err := r.appendJournal(ctx, alias, day.date, content, normalizeTags(tags)); err != nil {
+				return nil, err
+			}
+		}
+		done := len(g.pages) + i + 1
+		if done%10 == 0 || done == total {
+			s.emitProgress(done, total, fmt.Sprintf("Imported %d/%d pages", done, total))
+		}
+	}
+
+	logger.WithFields(map[string]any{
+		"graphPath":      req.GraphPath,
+		"dryRun":         req.DryRun,
+		"documents":      len(r.report.Documents),
+		"journalEntries": r.report.JournalEntries,
+		"assets":         r.report.Assets,
+		"warnings":       len(r.report.Warnings),
+	}).Info("Logseq import completed")
+
+	return r.report, nil
+}
+
+func (p *logseqPage) draft(projectAlias string, blocks []document.BlockNoteBlock) documentDraft {
+	return documentDraft{
+		Source:  p.rel,
+		Project: projectAlias,
+		Title:   p.title,
+		Tags:    p.tags,
+		Aliases: normalizeAliases(p.aliases),
+		Blocks:  blocks,
+		Created: p.created,
+		Updated: p.updated,
+	}
+}
+
+func scanLogseqGraph(root string, report *Report) (*logseqGraph, error) {
+	g := &logseqGraph{
+		root:      root,
+		byName:    make(map[string]*logseqPage),
+		blockText: make(map[string]string),
+	}
+
+	for _, dir := range []string{"pages", "journals"} {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			page, err := readLogseqPage(p, filepath.ToSlash(relPath), dir == "journals", report)
+			if err != nil {
+				report.warnf("%s: %v", filepath.ToSlash(relPath), err)
+				return nil
+			}
+			if page.date != "" {
+				g.journals = append(g.journals, page)
+			} else {
+				g.pages = append(g.pages, page)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scanning graph: %w", err)
+		}
+	}
+
+	sort.Slice(g.pages, func(i, j int) bool { return g.pages[i].rel < g.pages[j].rel })
+	sort.Slice(g.journals, func(i, j int) bool { return g.journals[i].date < g.journals[j].date })
+
+	for _, page := range g.pages {
+		g.byName[strings.ToLower(page.title)] = page
+	}
+	for _, page := range g.pages {
+		for _, alias := range page.aliases {
+			if _, exists := g.byName[strings.ToLower(alias)]; !exists {
+				g.byName[strings.ToLower(alias)] = page
+			}
+		}
+	}
+
+	var collect func(blocks []*outlineBlock)
+	collect = func(blocks []*outlineBlock) {
+		for _, b := range blocks {
+			if id := b.props["id"]; id != "" {
+				first, _, _ := strings.Cut(b.text(), "\n")
+				g.blockText[strings.ToLower(id)] = logseqMarkerRe.ReplaceAllString(first, "")
+			}
+			collect(b.children)
+		}
+	}
+	for _, page := range append(append([]*logseqPage{}, g.pages...), g.journals...) {
+		collect(page.blocks)
+	}
+
+	return g, nil
+}
+
+func readLogseqPage(absPath, rel string, inJournals bool, report *Report) (*logseqPage, error) {
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+	props, blocks := parseOutline(content)
+
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	page := &logseqPage{
+		rel:     rel,
+		title:   logseqPageName(name),
+		blocks:  blocks,
+		created: info.ModTime(),
+		updated: info.ModTime(),
+	}
+	if title := strings.TrimSpace(props["title"]); title != "" {
+		page.title = title
+	}
+
+	tags := logseqPropertyList(props["tags"])
+	var collect func(blocks []*outlineBlock)
+	collect = func(blocks []*outlineBlock) {
+		for _, b := range blocks {
+			tags = append(tags, logseqBlockTags(b)...)
+			tags = append(tags, inlineTags(b.text())...)
+			collect(b.children)
+		}
+	}
+	if !inJournals {
+		collect(blocks)
+	}
+	page.tags = normalizeTags(tags)
+	page.aliases = logseqPropertyList(props["alias"])
+
+	if inJournals {
+		m := logseqJournalRe.FindStringSubmatch(name)
+		if m == nil {
+			report.warnf("%s: journal file name is not a date; importing as a page", rel)
+			return page, nil
+		}
+		date := m[1] + "-" + m[2] + "-" + m[3]
+		if err := journal.ValidateDate(date); err != nil {
+			return nil, err
+		}
+		page.date = date
+	}
+	return page, nil
+}
+
+// logseqPageName decodes a page file name: namespaces are stored as "___"
+// (or %2F in older graphs) and other reserved characters are URL-encoded.
+func logseqPageName(name string) string {
+	name = strings.ReplaceAll(name, "___", "/")
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+	return name
+}
+
+// logseqPropertyList splits a property value such as "[[a]], b, #c" into
+// its items.
+func logseqPropertyList(value string) []string {
+	value = strings.NewReplacer("[[", "", "]]", "").Replace(value)
+	var out []string
+	for _, item := range stringList(value) {
+		if item = strings.TrimPrefix(item, "#"); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func logseqBlockTags(b *outlineBlock) []string {
+	tags := logseqPropertyList(b.props["tags"])
+	return append(tags, inlineTags(b.text())...)
+}
+
+// parseOutline splits a Logseq file into page properties and its block tree.
+// Properties before the first bullet, or in a first bullet holding nothing
+// else, belong to the page; "key:: value" lines inside a block belong to the
+// block and are removed from its text, as are LOGBOOK drawers.
+func parseOutline(content string) (map[string]string, []*outlineBlock) {
+	pageProps := make(map[string]string)
+	var roots []*outlineBlock
+
+	type frame struct {
+		indent int
+		block  *outlineBlock
+	}
+	var stack []frame
+	var current *outlineBlock
+	var prefix string
+	inFence, inDrawer := false, false
+
+	addLine := func(text string) {
+		trimmed := strings.TrimSpace(text)
+		if fenceMarkerRe.MatchString(text) {
+			inFence = !inFence
+			current.lines = append(current.lines, text)
+			return
+		}
+		if inFence {
+			current.lines = append(current.lines, text)
+			return
+		}
+		if trimmed == ":LOGBOOK:" {
+			inDrawer = true
+			return
+		}
+		if inDrawer {
+			if trimmed == ":END:" {
+				inDrawer = false
+			}
+			return
+		}
+		if m := logseqPropertyRe.FindStringSubmatch(trimmed); m != nil {
+			if current.props == nil {
+				current.props = make(map[string]string)
+			}
+			current.props[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+			return
+		}
+		current.lines = append(current.lines, text)
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if !inFence {
+			if m := logseqBulletRe.FindStringSubmatch(line); m != nil {
+				indent := indentWidth(m[1])
+				b := &outlineBlock{bullet: true}
+				for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+					stack = stack[:len(stack)-1]
+				}
+				if len(stack) == 0 {
+					roots = append(roots, b)
+				} else {
+					parent := stack[len(stack)-1].block
+					parent.children = append(parent.children, b)
+				}
+				stack = append(stack, frame{indent: indent, block: b})
+				current, prefix, inDrawer = b, m[1], false
+				if m[2] != "" {
+					addLine(m[2])
+				}
+				continue
+			}
+		}
+
+		if current == nil {
+			if m := logseqPropertyRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				pageProps[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+				continue
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			current = &outlineBlock{}
+			roots = append(roots, current)
+		}
+
+		text := line
+		if current.bullet {
+			if strings.HasPrefix(line, prefix) {
+				text = strings.TrimPrefix(strings.TrimPrefix(line, prefix), "  ")
+			} else {
+				text = strings.TrimLeft(line, " \t")
+			}
+		}
+		addLine(text)
+	}
+
+	if len(pageProps) == 0 && len(roots) > 0 {
+		first := roots[0]
+		if first.bullet && first.text() == "" && len(first.props) > 0 && len(first.children) == 0 {
+			pageProps = first.props
+			roots = roots[1:]
+		}
+	}
+	return pageProps, roots
+}
+
+// indentWidth measures leading whitespace, counting a tab as four columns.
+func indentWidth(ws string) int {
+	n := 0
+	for _, c := range ws {
+		if c == '\t' {
+			n += 4
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// resolveRefs replaces ((block references)) with the referenced block's text
+// and {{embed ...}} macros with a plain reference to what they embed.
+func (g *logseqGraph) resolveRefs(page *logseqPage, text string, warn func(string, ...any)) string {
+	text = logseqEmbedRe.ReplaceAllString(text, "$1")
+	text = logseqTagRefRe.ReplaceAllStringFunc(text, func(m string) string {
+		return "#" + strings.ReplaceAll(m[3:len(m)-2], " ", "-")
+	})
+	return logseqBlockRefRe.ReplaceAllStringFunc(text, func(m string) string {
+		id := strings.ToLower(m[2 : len(m)-2])
+		if ref, ok := g.blockText[id]; ok {
+			return ref
+		}
+		warn("%s: unresolved block reference %s", page.rel, id)
+		return ""
+	})
+}
+
+// rewriteLogseqLinks replaces [[page]] and [label]([[page]]) references with
+// whatever link returns for them.
+func rewriteLogseqLinks(line string, link func(target, label string) string) string {
+	line = logseqLabelLinkRe.ReplaceAllStringFunc(line, func(m string) string {
+		sub := logseqLabelLinkRe.FindStringSubmatch(m)
+		return link(sub[2], sub[1])
+	})
+	return wikiLinkRe.ReplaceAllStringFunc(line, func(m string) string {
+		target := strings.TrimSpace(m[2 : len(m)-2])
+		return link(target, target)
+	})
+}
+
+// convertOutline turns a block tree into document blocks. Bullets become
+// bullet list items (or check list items for TODO/DONE, or headings and code
+// blocks when that's what they hold) with their nested bullets as children.
+func (r *run) convertOutline(ctx context.Context, g *logseqGraph, page *logseqPage, blocks []*outlineBlock, firstPass bool) ([]document.BlockNoteBlock, error) {
+	warn := r.report.warnf
+	if !firstPass {
+		warn = func(string, ...any) {}
+	}
+
+	link := func(target, label string) string {
+		// Logseq creates pages for any reference, so references without a
+		// page file are ordinary text rather than broken links.
+		dest := g.byName[strings.ToLower(target)]
+		if dest == nil || dest == page {
+			return label
+		}
+		if firstPass {
+			page.hasLinks = true
+			return label
+		}
+		return "[" + label + "](" + dest.path + ")"
+	}
+
+	out := []document.BlockNoteBlock{}
+	for _, b := range blocks {
+		text := b.text()
+		marker := ""
+		if m := logseqMarkerRe.FindStringSubmatch(text); b.bullet && m != nil {
+			marker = m[1]
+			text = text[len(m[0]):]
+		}
+		text = g.resolveRefs(page, text, warn)
+
+		converted, err := convertMarkdown(text, func(ref embedRef) (*document.BlockNoteBlock, string) {
+			return r.logseqEmbed(ctx, g, page, ref, warn)
+		}, func(line string) string {
+			return rewriteLogseqLinks(line, link)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		children, err := r.convertOutline(ctx, g, page, b.children, firstPass)
+		if err != nil {
+			return nil, err
+		}
+
+		if !b.bullet {
+			out = append(out, converted...)
+			out = append(out, children...)
+			continue
+		}
+
+		var head document.BlockNoteBlock
+		if len(converted) == 0 {
+			head = inlineBlock(blocktype.BulletListItem, nil, nil)
+		} else {
+			head, converted = converted[0], converted[1:]
+		}
+		switch {
+		case marker != "" && head.Type == blocktype.Paragraph:
+			head.Type = blocktype.CheckListItem
+			head.Props = map[string]any{"checked": marker == "DONE"}
+		case head.Type == blocktype.Paragraph:
+			head.Type = blocktype.BulletListItem
+		}
+		head.Children = append(append(head.Children, converted...), children...)
+		out = append(out, head)
+	}
+	return out, nil
+}
+
+// logseqEmbed uploads an image from the graph's assets. Other files are
+// replaced by their name.
+func (r *run) logseqEmbed(ctx context.Context, g *logseqGraph, page *logseqPage, ref embedRef, warn func(string, ...any)) (*document.BlockNoteBlock, string) {
+	if document.IsExternalURL(ref.target) {
+		block := imageBlock(ref.target, "", ref.label)
+		return &block, ""
+	}
+
+	filePath := filepath.Join(g.root, filepath.FromSlash(path.Join(path.Dir(page.rel), ref.target)))
+	if rel, err := filepath.Rel(g.root, filePath); err != nil || strings.HasPrefix(rel, "..") {
+		warn("%s: embedded file %q is outside the graph", page.rel, ref.target)
+		return nil, ref.label
+	}
+
+	name := filepath.Base(filePath)
+	if !imageExts[strings.ToLower(filepath.Ext(name))] {
+		warn("%s: attachment %q is not an image and was not imported", page.rel, name)
+		return nil, name
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		warn("%s: embedded file %q not found", page.rel, ref.target)
+		return nil, ref.label
+	}
+
+	assetURL, err := r.uploadAsset(ctx, g.project, filePath, name, data)
+	if err != nil {
+		warn("%s: %v", page.rel, err)
+		return nil, name
+	}
+
+	caption := ref.label
+	if caption == name || caption == "image" {
+		caption = ""
+	}
+	block := imageBlock(assetURL, name, caption)
+	return &block, ""
+}
+
+// logseqEntryText renders a journal block and its children as the plain
+// text of one journal entry, nested blocks as indented list items.
+func (r *run) logseqEntryText(g *logseqGraph, day *logseqPage, b *outlineBlock, depth int) string {
+	text := g.resolveRefs(day, b.text(), r.report.warnf)
+	text = mdImageRe.ReplaceAllStringFunc(text, func(m string) string {
+		r.report.warnf("%s: image %q dropped from journal entry", day.rel, mdImageRe.FindStringSubmatch(m)[2])
+		return ""
+	})
+	text = rewriteLogseqLinks(text, func(target, label string) string { return label })
+
+	var sb strings.Builder
+	indent := strings.Repeat("  ", max(depth-1, 0))
+	for i, line := range strings.Split(text, "\n") {
+		switch {
+		case depth == 0:
+			sb.WriteString(line)
+		case i == 0:
+			sb.WriteString(indent + "- " + line)
+		default:
+			sb.WriteString(indent + "  " + line)
+		}
+		sb.WriteString("\n")
+	}
+	for _, child := range b.children {
+		sb.WriteString(r.logseqEntryText(g, day, child, depth+1))
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"yanta/internal/document"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLogseqGraph(t *testing.T) string {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "graph")
+	writeTestFiles(t, root, map[string]string{
+		"pages/Project Plan.md": "title:: Project Plan\ntags:: [[planning]], work\nalias:: roadmap, The Plan\n\n" +
+			"- # Goals\n" +
+			"\t- TODO write the spec\n" +
+			"\t  id:: 6530f6a2-1111-4c3e-9a2d-0123456789ab\n" +
+			"\t- DONE pick a name\n" +
+			"\t\t- nested under done\n" +
+			"- See [[Notes]] and [[Unknown Page]]\n" +
+			"  second line\n" +
+			"  :LOGBOOK:\n" +
+			"  CLOCK: [2024-01-01 Mon 10:00]\n" +
+			"  :END:\n" +
+			"- ```go\n" +
+			"  - not a bullet\n" +
+			"  ```\n" +
+			"- ![diagram](../assets/diagram.png)\n",
+		"pages/tech___Notes.md": "- refers to ((6530f6a2-1111-4c3e-9a2d-0123456789ab)) and [the plan]([[roadmap]])\n",
+		"pages/Notes.md":        "- tags:: misc\n- plain page\n",
+		"journals/2024_03_05.md": "- Morning standup with [[Project Plan]] #meeting\n" +
+			"\t- discussed ((6530f6a2-1111-4c3e-9a2d-0123456789ab))\n" +
+			"- LATER call back\n" +
+			"  tags:: calls\n",
+		"journals/notes.md":  "- not a date\n",
+		"assets/diagram.png": string(pngBytes),
+		"logseq/config.edn":  "{}",
+	})
+	return root
+}
+
+func TestParseOutline(t *testing.T) {
+	props, blocks := parseOutline("alias:: x\n\nIntro text\n- a\n  more a\n\t- b\n\t\t- c\n\t- d\n- e\n  key:: v\n")
+	assert.Equal(t, map[string]string{"alias": "x"}, props)
+	require.Len(t, blocks, 3)
+
+	assert.False(t, blocks[0].bullet)
+	assert.Equal(t, "Intro text", blocks[0].text())
+	assert.Equal(t, "a\nmore a", blocks[1].text())
+	require.Len(t, blocks[1].children, 2)
+	assert.Equal(t, "b", blocks[1].children[0].text())
+	assert.Equal(t, "c", blocks[1].children[0].children[0].text())
+	assert.Equal(t, "d", blocks[1].children[1].text())
+	assert.Equal(t, map[string]string{"key": "v"}, blocks[2].props)
+
+	props, blocks = parseOutline("- title:: Page\n  tags:: a\n- body\n")
+	assert.Equal(t, map[string]string{"title": "Page", "tags": "a"}, props)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "body", blocks[0].text())
+}
+
+func TestLogseqHelpers(t *testing.T) {
+	assert.Equal(t, "tech/Notes", logseqPageName("tech___Notes"))
+	assert.Equal(t, "a/b c", logseqPageName("a%2Fb c"))
+	assert.Equal(t, []string{"a b", "c", "d"}, logseqPropertyList("[[a b]], #c, d"))
+}
+
+func TestService_ImportLogseq(t *testing.T) {
+	svc, f := setupImporterTest(t)
+	root := setupLogseqGraph(t)
+
+	report, err := svc.ImportLogseq(context.Background(), LogseqImportRequest{GraphPath: root})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@graph"}, f.projects.created)
+	assert.Equal(t, []string{"@graph/diagram.png"}, f.assets.uploads)
+	assert.Len(t, report.Documents, 4)
+	assert.Contains(t, report.Warnings, "journals/notes.md: journal file name is not a date; importing as a page")
+
+	planPath, plan, ok := f.documents.byTitle("Project Plan")
+	require.True(t, ok)
+	assert.Equal(t, []string{"planning", "work"}, plan.Tags)
+	assert.Equal(t, []string{"roadmap", "The-Plan"}, plan.Aliases)
+
+	notesPath, _, ok := f.documents.byTitle("Notes")
+	require.True(t, ok)
+
+	assert.Equal(t, []string{
+		"heading:Goals",
+		"  checkListItem:write the spec",
+		"  checkListItem:pick a name",
+		"    bulletListItem:nested under done",
+		"bulletListItem:See Notes->" + notesPath + " and Unknown Page second line",
+		"codeBlock:- not a bullet",
+		"image:",
+	}, blockTexts(t, plan.Blocks))
+	assert.Equal(t, false, plan.Blocks[0].Children[0].Props["checked"])
+	assert.Equal(t, true, plan.Blocks[0].Children[1].Props["checked"])
+
+	_, tech, ok := f.documents.byTitle("tech/Notes")
+	require.True(t, ok)
+	assert.Equal(t, []string{"bulletListItem:refers to write the spec and the plan->" + planPath}, blockTexts(t, tech.Blocks))
+
+	require.Len(t, f.journal.entries, 2)
+	assert.Equal(t, "2024-03-05", f.journal.entries[0].Date)
+	assert.Equal(t, "@graph", f.journal.entries[0].ProjectAlias)
+	assert.Equal(t, "Morning standup with Project Plan #meeting\n- discussed write the spec", f.journal.entries[0].Content)
+	assert.Equal(t, []string{"meeting"}, f.journal.entries[0].Tags)
+	assert.Equal(t, "LATER call back", f.journal.entries[1].Content)
+	assert.Equal(t, []string{"calls"}, f.journal.entries[1].Tags)
+}
+
+func TestService_ImportLogseq_DryRun(t *testing.T) {
+	svc, f := setupImporterTest(t, "@notes")
+	root := setupLogseqGraph(t)
+
+	report, err := svc.ImportLogseq(context.Background(), LogseqImportRequest{GraphPath: root, Project: "@notes", DryRun: true})
+	require.NoError(t, err)
+
+	assert.Empty(t, report.Projects)
+	assert.Len(t, report.Documents, 4)
+	assert.Equal(t, 2, report.JournalEntries)
+	assert.Empty(t, f.documents.saved)
+	assert.Empty(t, f.journal.entries)
+}
+
+func TestService_ImportLogseq_Validation(t *testing.T) {
+	svc, _ := setupImporterTest(t)
+
+	_, err := svc.ImportLogseq(context.Background(), LogseqImportRequest{})
+	assert.ErrorContains(t, err, "graph path is required")
+
+	_, err = svc.ImportLogseq(context.Background(), LogseqImportRequest{GraphPath: t.TempDir(), Project: "bad"})
+	assert.ErrorContains(t, err, "invalid project alias")
+}
+
+// Ensure outline blocks survive the document JSON round trip.
+func TestConvertOutline_Children(t *testing.T) {
+	svc, _ := setupImporterTest(t)
+	r, err := svc.newRun(context.Background(), true)
+	require.NoError(t, err)
+
+	_, blocks := parseOutline("- parent\n  - child\n")
+	page := &logseqPage{rel: "pages/x.md"}
+	out, err := r.convertOutline(context.Background(), &logseqGraph{byName: map[string]*logseqPage{}}, page, blocks, true)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(out)
+	require.NoError(t, err)
+	var decoded []document.BlockNoteBlock
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Len(t, decoded, 1)
+	require.Len(t, decoded[0].Children, 1)
+	assert.Equal(t, "bulletListItem", decoded[0].Children[0].Type)
+}
@@ -0,0 +1,698 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"yanta/internal/document"
+	"yanta/internal/logger"
+	"yanta/internal/project"
+)
+
+// NotionImportRequest describes an import of Notion's "Markdown & CSV" export
+// zip.
+type NotionImportRequest struct {
+	ZipPath string
+	// Project receives every page. Empty turns each top-level page or
+	// database into its own project.
+	Project string
+	// RowsAsDocuments imports every database row as a document of its own.
+	// Otherwise only rows whose page has content beyond its properties get a
+	// document; the rest live in the database's table only.
+	RowsAsDocuments bool
+	DryRun          bool
+}
+
+const notionFallbackProject = "@notion"
+
+var (
+	notionIDRe        = regexp.MustCompile(`(?:^|\s+)[0-9a-f]{32}$`)
+	notionPropertyRe  = regexp.MustCompile(`^([^:]{1,100}):\s?(.*)$`)
+	notionTimeLayouts = []string{
+		"January 2, 2006 3:04 PM",
+		"January 2, 2006",
+		time.RFC3339,
+		"2006-01-02",
+	}
+)
+
+// notionDoc is a page, database or database row that becomes one document.
+type notionDoc struct {
+	// key orders documents so databases precede their rows and pages their
+	// children.
+	key         string
+	source      string
+	title       string
+	project     string
+	projectName string
+	tags        []string
+	created     time.Time
+	updated     time.Time
+	body        string
+
+	// db is set on a database's document; props on a row document.
+	db    *notionDatabase
+	props [][2]string
+
+	path     string
+	hasLinks bool
+}
+
+type notionDatabase struct {
+	header []string
+	rows   [][]string
+	// rowDocs holds each row's document, nil for rows without one.
+	rowDocs []*notionDoc
+}
+
+// notionPage is a Markdown page file, before it's known whether it becomes a
+// document of its own.
+type notionPage struct {
+	rel   string
+	title string
+	body  string
+}
+
+// notionExport indexes an export by path inside the zip.
+type notionExport struct {
+	files map[string]*zip.File
+	docs  []*notionDoc
+	// byPath resolves page and CSV paths to their document.
+	byPath map[string]*notionDoc
+}
+
+// ImportNotion imports a Notion "Markdown & CSV" export. Page IDs are
+// stripped from names, top-level pages and databases become projects (or
+// all land in Project), nested pages become documents, relative links
+// between pages become document links, images are uploaded as assets and
+// database CSVs become documents with a table. Progress is emitted as
+// "import:progress" events.
+func (s *Service) ImportNotion(ctx context.Context, req NotionImportRequest) (*Report, error) {
+	if strings.TrimSpace(req.ZipPath) == "" {
+		return nil, fmt.Errorf("zip path is required")
+	}
+	if req.Project != "" {
+		if err := project.ValidateAlias(req.Project); err != nil {
+			return nil, fmt.Errorf("invalid project alias: %w", err)
+		}
+	}
+
+	r, err := s.newRun(ctx, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(map[string]any{
+		"zipPath": req.ZipPath,
+		"dryRun":  req.DryRun,
+	}).Info("starting Notion import")
+
+	zr, err := zip.OpenReader(req.ZipPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening zip: %w", err)
+	}
+	defer zr.Close()
+
+	s.emitProgress(0, 0, "Reading Notion export...")
+
+	files, err := notionFiles(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := buildNotionExport(files, req, r.report)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range exp.docs {
+		if err := r.ensureProject(ctx, doc.project, doc.projectName); err != nil {
+			return nil, err
+		}
+	}
+
+	// As with Obsidian vaults, links need their target's path, so documents
+	// are saved with links as text first and linking documents saved again.
+	total := len(exp.docs)
+	s.emitProgress(0, total, "Importing pages...")
+	for i, doc := range exp.docs {
+		blocks, err := r.convertNotionDoc(ctx, exp, doc, true)
+		if err != nil {
+			return nil, err
+		}
+		doc.path, err = r.saveDocument(ctx, doc.draft(blocks), "")
+		if err != nil {
+			return nil, err
+		}
+		if (i+1)%10 == 0 || i == total-1 {
+			s.emitProgress(i+1, total, fmt.Sprintf("Imported %d/%d pages", i+1, total))
+		}
+	}
+
+	if !req.DryRun {
+		for _, doc := range exp.docs {
+			if !doc.hasLinks {
+				continue
+			}
+			blocks, err := r.convertNotionDoc(ctx, exp, doc, false)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := r.saveDocument(ctx, doc.draft(blocks), doc.path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	logger.WithFields(map[string]any{
+		"zipPath":   req.ZipPath,
+		"dryRun":    req.DryRun,
+		"projects":  len(r.report.Projects),
+		"documents": len(r.report.Documents),
+		"assets":    r.report.Assets,
+		"warnings":  len(r.report.Warnings),
+	}).Info("Notion import completed")
+
+	return r.report, nil
+}
+
+func (d *notionDoc) draft(blocks []document.BlockNoteBlock) documentDraft {
+	return documentDraft{
+		Source:  d.source,
+		Project: d.project,
+		Title:   d.title,
+		Tags:    d.tags,
+		Blocks:  blocks,
+		Created: d.created,
+		Updated: d.updated,
+	}
+}
+
+// notionFiles lists the files of an export by path. Large exports nest one
+// zip per part inside the outer zip; their contents are merged. A single
+// wrapping folder around the whole export is removed.
+func notionFiles(zr *zip.Reader) (map[string]*zip.File, error) {
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		name := path.Clean(strings.ReplaceAll(f.Name, "\\", "/"))
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			continue
+		}
+
+		if strings.EqualFold(path.Ext(name), ".zip") {
+			inner, err := readNestedZip(f)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			nested, err := notionFiles(inner)
+			if err != nil {
+				return nil, err
+			}
+			for n, nf := range nested {
+				files[n] = nf
+			}
+			continue
+		}
+		files[name] = f
+	}
+
+	for {
+		var prefix string
+		wrapped := len(files) > 0
+		for name := range files {
+			top, _, nested := strings.Cut(name, "/")
+			if !nested || (prefix != "" && top != prefix) {
+				wrapped = false
+				break
+			}
+			prefix = top
+		}
+		if !wrapped {
+			break
+		}
+		unwrapped := make(map[string]*zip.File, len(files))
+		for name, f := range files {
+			unwrapped[strings.TrimPrefix(name, prefix+"/")] = f
+		}
+		files = unwrapped
+	}
+
+	return files, nil
+}
+
+func readNestedZip(f *zip.File) (*zip.Reader, error) {
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// stripNotionID removes the 32-hex page ID Notion appends to file and folder
+// names, along with a page or database extension.
+func stripNotionID(name string) string {
+	if ext := strings.ToLower(path.Ext(name)); ext == ".md" || ext == ".csv" {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	name = strings.TrimSuffix(name, "_all")
+	return strings.TrimSpace(notionIDRe.ReplaceAllString(name, ""))
+}
+
+// notionKey identifies a page or database independent of its file type: the
+// path without extension, so "A id.md", "A id.csv" and the children folder
+// "A id/" share a key.
+func notionKey(name string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(name, path.Ext(name)), "_all")
+}
+
+func buildNotionExport(files map[string]*zip.File, req NotionImportRequest, report *Report) (*notionExport, error) {
+	exp := &notionExport{
+		files:  files,
+		byPath: make(map[string]*notionDoc),
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Newer exports write each database twice: the current view and "_all"
+	// with every row. Prefer the complete one.
+	csvs := make(map[string]string)
+	for _, name := range names {
+		if !strings.EqualFold(path.Ext(name), ".csv") {
+			continue
+		}
+		key := notionKey(name)
+		if _, ok := csvs[key]; !ok || strings.HasSuffix(strings.TrimSuffix(name, path.Ext(name)), "_all") {
+			csvs[key] = name
+		}
+	}
+
+	pages := make(map[string]*notionPage)
+	for _, name := range names {
+		if !strings.EqualFold(path.Ext(name), ".md") {
+			continue
+		}
+		data, err := readZipFile(files[name])
+		if err != nil {
+			report.warnf("%s: %v", name, err)
+			continue
+		}
+		pages[notionKey(name)] = parseNotionPage(name, string(data))
+	}
+
+	projectFor := func(key string) (string, string) {
+		if req.Project != "" {
+			return req.Project, strings.TrimPrefix(req.Project, "@")
+		}
+		top, _, _ := strings.Cut(key, "/")
+		name := stripNotionID(top)
+		alias, err := projectAlias(name)
+		if err != nil {
+			report.warnf("%s: %v; importing into %s", top, err, notionFallbackProject)
+			return notionFallbackProject, "Notion"
+		}
+		return alias, name
+	}
+
+	// Databases first, so their row pages can be claimed before the
+	// remaining pages are imported.
+	rowPages := make(map[string]bool)
+	keys := make([]string, 0, len(csvs))
+	for key := range csvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := csvs[key]
+		data, err := readZipFile(files[name])
+		if err != nil {
+			report.warnf("%s: %v", name, err)
+			continue
+		}
+		db, err := parseNotionCSV(data)
+		if err != nil {
+			report.warnf("%s: %v", name, err)
+			continue
+		}
+
+		proj, projName := projectFor(key)
+		dbDoc := &notionDoc{
+			key:         key,
+			source:      name,
+			title:       stripNotionID(path.Base(name)),
+			project:     proj,
+			projectName: projName,
+			db:          db,
+		}
+		if page, ok := pages[key]; ok {
+			// A database page exported alongside its CSV carries the
+			// database's description.
+			dbDoc.body = page.body
+			rowPages[key] = true
+		}
+		exp.docs = append(exp.docs, dbDoc)
+		exp.byPath[key] = dbDoc
+
+		byTitle := make(map[string][]*notionPage)
+		for pageKey, page := range pages {
+			if path.Dir(pageKey) == key {
+				byTitle[page.title] = append(byTitle[page.title], page)
+			}
+		}
+		for _, list := range byTitle {
+			sort.Slice(list, func(i, j int) bool { return list[i].rel < list[j].rel })
+		}
+
+		db.rowDocs = make([]*notionDoc, len(db.rows))
+		for i, row := range db.rows {
+			title := row[0]
+			var page *notionPage
+			if list := byTitle[title]; len(list) > 0 {
+				page, byTitle[title] = list[0], list[1:]
+				rowPages[notionKey(page.rel)] = true
+				exp.byPath[notionKey(page.rel)] = dbDoc
+			}
+
+			body := ""
+			if page != nil {
+				body = stripNotionProperties(page.body, db.header)
+			}
+			if !req.RowsAsDocuments && strings.TrimSpace(body) == "" {
+				continue
+			}
+
+			rowDoc := notionRowDoc(db, row, body)
+			rowDoc.key = fmt.Sprintf("%s/%06d", key, i)
+			rowDoc.source = fmt.Sprintf("%s (row %d)", name, i+1)
+			if page != nil {
+				rowDoc.key = notionKey(page.rel)
+				rowDoc.source = page.rel
+				exp.byPath[notionKey(page.rel)] = rowDoc
+			}
+			rowDoc.project, rowDoc.projectName = proj, projName
+			db.rowDocs[i] = rowDoc
+			exp.docs = append(exp.docs, rowDoc)
+		}
+	}
+
+	pageKeys := make([]string, 0, len(pages))
+	for key := range pages {
+		if !rowPages[key] {
+			pageKeys = append(pageKeys, key)
+		}
+	}
+	sort.Strings(pageKeys)
+	for _, key := range pageKeys {
+		page := pages[key]
+		proj, projName := projectFor(key)
+		doc := &notionDoc{
+			key:         key,
+			source:      page.rel,
+			title:       page.title,
+			project:     proj,
+			projectName: projName,
+			body:        page.body,
+		}
+		exp.docs = append(exp.docs, doc)
+		exp.byPath[key] = doc
+	}
+
+	sort.SliceStable(exp.docs, func(i, j int) bool { return exp.docs[i].key < exp.docs[j].key })
+	return exp, nil
+}
+
+// parseNotionPage reads a page's title from its leading "# " heading,
+// falling back to the file name.
+func parseNotionPage(rel, content string) *notionPage {
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\ufeff")
+	page := &notionPage{rel: rel, title: stripNotionID(path.Base(rel)), body: content}
+
+	trimmed := strings.TrimLeft(content, "\n")
+	if strings.HasPrefix(trimmed, "# ") {
+		heading, rest, _ := strings.Cut(trimmed, "\n")
+		if title := strings.TrimSpace(strings.TrimPrefix(heading, "# ")); title != "" {
+			page.title = title
+		}
+		page.body = strings.TrimLeft(rest, "\n")
+	}
+	if page.title == "" {
+		page.title = "Untitled"
+	}
+	return page
+}
+
+func parseNotionCSV(data []byte) (*notionDatabase, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing CSV: %w", err)
+	}
+	if len(records) == 0 || len(records[0]) == 0 {
+		return nil, fmt.Errorf("database has no columns")
+	}
+
+	db := &notionDatabase{header: records[0]}
+	for _, record := range records[1:] {
+		row := make([]string, len(db.header))
+		copy(row, record)
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		db.rows = append(db.rows, row)
+	}
+	return db, nil
+}
+
+// stripNotionProperties removes the "Property: value" lines Notion writes at
+// the top of a database row page, leaving the page's own content.
+func stripNotionProperties(body string, header []string) string {
+	columns := make(map[string]bool, len(header))
+	for _, h := range header {
+		columns[h] = true
+	}
+
+	lines := strings.Split(body, "\n")
+	i := 0
+	for i < len(lines) {
+		m := notionPropertyRe.FindStringSubmatch(lines[i])
+		if m == nil || !columns[m[1]] {
+			break
+		}
+		i++
+	}
+	return strings.TrimLeft(strings.Join(lines[i:], "\n"), "\n")
+}
+
+// notionRowDoc builds a row's document: its properties, tags from a "Tags"
+// column and timestamps from Notion's created/edited columns.
+func notionRowDoc(db *notionDatabase, row []string, body string) *notionDoc {
+	doc := &notionDoc{title: strings.TrimSpace(row[0]), body: body}
+	if doc.title == "" {
+		doc.title = "Untitled"
+	}
+
+	for c := 1; c < len(db.header); c++ {
+		value := strings.TrimSpace(row[c])
+		if value == "" {
+			continue
+		}
+		doc.props = append(doc.props, [2]string{db.header[c], value})
+
+		switch strings.ToLower(db.header[c]) {
+		case "tags", "tag":
+			doc.tags = normalizeTags(stringList(value))
+		case "created", "created time":
+			doc.created = parseNotionTime(value)
+		case "last edited time", "updated", "last edited":
+			doc.updated = parseNotionTime(value)
+		}
+	}
+	if !doc.created.IsZero() && (doc.updated.IsZero() || doc.updated.Before(doc.created)) {
+		doc.updated = doc.created
+	}
+	return doc
+}
+
+func parseNotionTime(value string) time.Time {
+	for _, layout := range notionTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// convertNotionDoc builds a document's blocks: the database table or row
+// properties, then the page body. The first pass renders links as text.
+func (r *run) convertNotionDoc(ctx context.Context, exp *notionExport, doc *notionDoc, firstPass bool) ([]document.BlockNoteBlock, error) {
+	warn := r.report.warnf
+	if !firstPass {
+		warn = func(string, ...any) {}
+	}
+
+	link := func(dest *notionDoc, label string) []document.BlockNoteContent {
+		if dest == nil || dest == doc {
+			return textContent(label)
+		}
+		if firstPass {
+			doc.hasLinks = true
+			return textContent(label)
+		}
+		return linkContent(label, dest.path)
+	}
+
+	var blocks []document.BlockNoteBlock
+	switch {
+	case doc.db != nil:
+		rows := make([][][]document.BlockNoteContent, 0, len(doc.db.rows)+1)
+		header := make([][]document.BlockNoteContent, len(doc.db.header))
+		for c, h := range doc.db.header {
+			header[c] = textContent(h)
+		}
+		rows = append(rows, header)
+		for i, row := range doc.db.rows {
+			cells := make([][]document.BlockNoteContent, len(row))
+			for c, value := range row {
+				cells[c] = textContent(value)
+			}
+			if rowDoc := doc.db.rowDocs[i]; rowDoc != nil && row[0] != "" {
+				cells[0] = link(rowDoc, row[0])
+			}
+			rows = append(rows, cells)
+		}
+		table, err := tableBlock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s: %w", doc.source, err)
+		}
+		blocks = append(blocks, table)
+	case len(doc.props) > 0:
+		rows := make([][][]document.BlockNoteContent, 0, len(doc.props))
+		for _, prop := range doc.props {
+			rows = append(rows, [][]document.BlockNoteContent{textContent(prop[0]), textContent(prop[1])})
+		}
+		table, err := tableBlock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s: %w", doc.source, err)
+		}
+		blocks = append(blocks, table)
+	}
+
+	if strings.TrimSpace(doc.body) == "" {
+		if blocks == nil {
+			blocks = []document.BlockNoteBlock{}
+		}
+		return blocks, nil
+	}
+
+	dir := path.Dir(doc.source)
+	body, err := convertMarkdown(doc.body, func(ref embedRef) (*document.BlockNoteBlock, string) {
+		return r.notionEmbed(ctx, exp, doc, dir, ref, warn)
+	}, func(line string) string {
+		return rewriteNotionLinks(line, func(target, label string) string {
+			if dest, ok := exp.byPath[notionKey(path.Join(dir, target))]; ok {
+				if dest == doc {
+					return label
+				}
+				if firstPass {
+					doc.hasLinks = true
+					return label
+				}
+				return "[" + label + "](" + dest.path + ")"
+			}
+			if _, ok := exp.files[path.Join(dir, target)]; ok {
+				warn("%s: attachment %q is not an image and was not imported", doc.source, path.Base(target))
+			} else {
+				warn("%s: unresolved link to %q", doc.source, target)
+			}
+			return label
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("converting %s: %w", doc.source, err)
+	}
+	return append(blocks, body...), nil
+}
+
+// notionEmbed uploads an embedded image. External images are linked in place.
+func (r *run) notionEmbed(ctx context.Context, exp *notionExport, doc *notionDoc, dir string, ref embedRef, warn func(string, ...any)) (*document.BlockNoteBlock, string) {
+	if document.IsExternalURL(ref.target) {
+		block := imageBlock(ref.target, "", ref.label)
+		return &block, ""
+	}
+
+	rel := path.Join(dir, ref.target)
+	f, ok := exp.files[rel]
+	if !ok {
+		warn("%s: embedded file %q not found", doc.source, ref.target)
+		return nil, ref.label
+	}
+
+	name := path.Base(rel)
+	if !imageExts[strings.ToLower(path.Ext(name))] {
+		warn("%s: attachment %q is not an image and was not imported", doc.source, name)
+		return nil, name
+	}
+
+	data, err := readZipFile(f)
+	if err != nil {
+		warn("%s: reading %q: %v", doc.source, name, err)
+		return nil, name
+	}
+
+	assetURL, err := r.uploadAsset(ctx, doc.project, rel, name, data)
+	if err != nil {
+		warn("%s: %v", doc.source, err)
+		return nil, name
+	}
+
+	caption := ref.label
+	if caption == name || caption == stripNotionID(name) || caption == "Untitled" {
+		caption = ""
+	}
+	block := imageBlock(assetURL, name, caption)
+	return &block, ""
+}
+
+// rewriteNotionLinks passes relative links to pages, databases and files
+// through link. External and anchor links are kept as they are.
+func rewriteNotionLinks(line string, link func(target, label string) string) string {
+	return mdLinkRe.ReplaceAllStringFunc(line, func(m string) string {
+		sub := mdLinkRe.FindStringSubmatch(m)
+		label, href := sub[1], sub[2]
+		if document.IsExternalURL(href) || strings.HasPrefix(href, "#") || strings.Contains(href, "://") || strings.HasPrefix(href, "mailto:") {
+			return m
+		}
+		target := strings.SplitN(href, "#", 2)[0]
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+		if label == "" {
+			label = stripNotionID(path.Base(target))
+		}
+		return link(target, label)
+	})
+}
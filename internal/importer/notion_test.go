@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	idWork  = "0123456789abcdef0123456789abcdef"
+	idPlan  = "11111111111111111111111111111111"
+	idTasks = "22222222222222222222222222222222"
+	idRowA  = "33333333333333333333333333333333"
+	idRowB  = "44444444444444444444444444444444"
+)
+
+func writeZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func setupNotionExport(t *testing.T) string {
+	t.Helper()
+
+	prefix := "Export-abc/"
+	files := map[string]string{
+		prefix + "Work " + idWork + ".md":                                                 "# Work\n\nSee [Plan](Work%20" + idWork + "/Plan%20" + idPlan + ".md) and [Tasks](Work%20" + idWork + "/Tasks%20" + idTasks + ".csv).\n",
+		prefix + "Work " + idWork + "/Plan " + idPlan + ".md":                             "# Plan\n\n![diagram.png](Plan%20" + idPlan + "/diagram.png)\n\nBack to [Work](../Work%20" + idWork + ".md), [spec](Plan%20" + idPlan + "/spec.pdf) and [site](https://example.com).\n",
+		prefix + "Work " + idWork + "/Plan " + idPlan + "/diagram.png":                    string(pngBytes),
+		prefix + "Work " + idWork + "/Plan " + idPlan + "/spec.pdf":                       "%PDF",
+		prefix + "Work " + idWork + "/Tasks " + idTasks + ".csv":                          "\ufeffName,Status,Tags,Created\nWrite docs,Done,\"docs, writing\",\"January 2, 2023 3:04 PM\"\nShip it,Todo,,\n",
+		prefix + "Work " + idWork + "/Tasks " + idTasks + "_all.csv":                      "\ufeffName,Status,Tags,Created\nWrite docs,Done,\"docs, writing\",\"January 2, 2023 3:04 PM\"\nShip it,Todo,,\nHidden row,Todo,,\n",
+		prefix + "Work " + idWork + "/Tasks " + idTasks + "/Write docs " + idRowA + ".md": "# Write docs\n\nStatus: Done\nTags: docs, writing\n\nDraft the [Plan](../Plan%20" + idPlan + ".md).\n",
+		prefix + "Work " + idWork + "/Tasks " + idTasks + "/Ship it " + idRowB + ".md":    "# Ship it\n\nStatus: Todo\n",
+	}
+
+	var inner bytes.Buffer
+	inner.Write(writeZip(t, files))
+
+	outer := writeZip(t, map[string]string{"Export-abc-Part-1.zip": inner.String()})
+	zipPath := filepath.Join(t.TempDir(), "export.zip")
+	require.NoError(t, os.WriteFile(zipPath, outer, 0644))
+	return zipPath
+}
+
+func TestStripNotionID(t *testing.T) {
+	assert.Equal(t, "Plan", stripNotionID("Plan "+idPlan+".md"))
+	assert.Equal(t, "Tasks", stripNotionID("Tasks "+idTasks+"_all.csv"))
+	assert.Equal(t, "v1.2 Notes", stripNotionID("v1.2 Notes "+idPlan))
+	assert.Equal(t, "", stripNotionID(idPlan+".md"))
+	assert.Equal(t, "image.png", stripNotionID("image.png"))
+}
+
+func TestService_ImportNotion_DryRun(t *testing.T) {
+	svc, f := setupImporterTest(t)
+	zipPath := setupNotionExport(t)
+
+	report, err := svc.ImportNotion(context.Background(), NotionImportRequest{ZipPath: zipPath, DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@work"}, report.Projects)
+	var titles []string
+	for _, doc := range report.Documents {
+		titles = append(titles, doc.Title)
+	}
+	assert.Equal(t, []string{"Work", "Plan", "Tasks", "Write docs"}, titles)
+	assert.Equal(t, 1, report.Assets)
+	assert.Contains(t, report.Warnings, `Work `+idWork+`/Plan `+idPlan+`.md: attachment "spec.pdf" is not an image and was not imported`)
+
+	assert.Empty(t, f.projects.created)
+	assert.Empty(t, f.documents.saved)
+	assert.Empty(t, f.assets.uploads)
+
+	require.NotEmpty(t, f.events.events)
+	last := f.events.events[len(f.events.events)-1]
+	assert.Equal(t, 4, last["current"])
+	assert.Equal(t, 4, last["total"])
+}
+
+func TestService_ImportNotion(t *testing.T) {
+	svc, f := setupImporterTest(t)
+	zipPath := setupNotionExport(t)
+
+	report, err := svc.ImportNotion(context.Background(), NotionImportRequest{ZipPath: zipPath})
+	require.NoError(t, err)
+	assert.Len(t, report.Documents, 4)
+	assert.Equal(t, []string{"@work"}, f.projects.created)
+	assert.Equal(t, []string{"@work/diagram.png"}, f.assets.uploads)
+
+	workPath, work, ok := f.documents.byTitle("Work")
+	require.True(t, ok)
+	planPath, plan, ok := f.documents.byTitle("Plan")
+	require.True(t, ok)
+	tasksPath, tasks, ok := f.documents.byTitle("Tasks")
+	require.True(t, ok)
+	rowPath, row, ok := f.documents.byTitle("Write docs")
+	require.True(t, ok)
+	_, _, ok = f.documents.byTitle("Ship it")
+	assert.False(t, ok, "rows without content stay in the table")
+
+	assert.Equal(t, []string{"paragraph:See Plan->" + planPath + " and Tasks->" + tasksPath + "."}, blockTexts(t, work.Blocks))
+
+	planText := blockTexts(t, plan.Blocks)
+	require.Len(t, planText, 2)
+	assert.Equal(t, "image:", planText[0])
+	assert.Equal(t, "paragraph:Back to Work->"+workPath+", spec and site->https://example.com.", planText[1])
+
+	require.Len(t, tasks.Blocks, 1)
+	assert.Equal(t, "table", tasks.Blocks[0].Type)
+	assert.Contains(t, string(tasks.Blocks[0].Content), `"href":"`+rowPath+`"`)
+	assert.Contains(t, string(tasks.Blocks[0].Content), "Hidden row")
+
+	assert.Equal(t, "@work", row.ProjectAlias)
+	assert.Equal(t, []string{"docs", "writing"}, row.Tags)
+	assert.Equal(t, 2023, row.Created.Year())
+	rowText := blockTexts(t, row.Blocks)
+	assert.Equal(t, "table:", rowText[0])
+	assert.Equal(t, "paragraph:Draft the Plan->"+planPath+".", rowText[1])
+}
+
+func TestService_ImportNotion_RowsAsDocuments(t *testing.T) {
+	svc, f := setupImporterTest(t, "@notes")
+	zipPath := setupNotionExport(t)
+
+	report, err := svc.ImportNotion(context.Background(), NotionImportRequest{
+		ZipPath:         zipPath,
+		Project:         "@notes",
+		RowsAsDocuments: true,
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, f.projects.created)
+	assert.Len(t, report.Documents, 6)
+	for _, title := range []string{"Write docs", "Ship it", "Hidden row"} {
+		_, doc, ok := f.documents.byTitle(title)
+		require.True(t, ok, title)
+		assert.Equal(t, "@notes", doc.ProjectAlias)
+	}
+
+	_, ship, _ := f.documents.byTitle("Ship it")
+	assert.Equal(t, []string{"table:"}, blockTexts(t, ship.Blocks))
+}
+
+func TestService_ImportNotion_Validation(t *testing.T) {
+	svc, _ := setupImporterTest(t)
+
+	_, err := svc.ImportNotion(context.Background(), NotionImportRequest{})
+	assert.ErrorContains(t, err, "zip path is required")
+
+	_, err = svc.ImportNotion(context.Background(), NotionImportRequest{ZipPath: "x.zip", Project: "notes"})
+	assert.ErrorContains(t, err, "invalid project alias")
+
+	_, err = svc.ImportNotion(context.Background(), NotionImportRequest{ZipPath: filepath.Join(t.TempDir(), "missing.zip")})
+	assert.ErrorContains(t, err, "opening zip")
+}
+
+func TestStripNotionProperties(t *testing.T) {
+	body := stripNotionProperties("Status: Done\nOwner: Sam\n\nNote: keep this\n", []string{"Name", "Status", "Owner"})
+	assert.Equal(t, "Note: keep this\n", body)
+}
@@ -0,0 +1,566 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"yanta/internal/document"
+	"yanta/internal/journal"
+	"yanta/internal/logger"
+	"yanta/internal/project"
+)
+
+// ObsidianImportRequest describes an Obsidian vault import.
+type ObsidianImportRequest struct {
+	VaultPath string
+	// ProjectMapping maps top-level folder names to project aliases. Unmapped
+	// folders become projects named after the folder.
+	ProjectMapping map[string]string
+	// DefaultProject receives notes at the vault root. Empty derives a project
+	// from the vault directory name.
+	DefaultProject string
+	DryRun         bool
+}
+
+var (
+	dailyNoteRe  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	wikiLinkRe   = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+	inlineTagRe  = regexp.MustCompile(`(?:^|[\s(,])#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+	inlineCodeRe = regexp.MustCompile("`[^`\n]*`")
+	allDigitsTag = regexp.MustCompile(`^[0-9]+$`)
+	listMarkerRe = regexp.MustCompile(`^(?:[-*+]|\d+\.)\s+`)
+)
+
+type obsidianNote struct {
+	rel         string
+	project     string
+	projectName string
+	title       string
+	tags        []string
+	aliases     []string
+	body        string
+	created     time.Time
+	updated     time.Time
+	date        string
+
+	path     string
+	hasLinks bool
+}
+
+// obsidianVault indexes a vault the way Obsidian resolves links: by path
+// relative to the vault root, by bare file name, and by frontmatter alias.
+type obsidianVault struct {
+	root   string
+	notes  []*obsidianNote
+	byName map[string]*obsidianNote
+	files  map[string]string
+}
+
+// ImportObsidian imports an Obsidian vault. Top-level folders become projects
+// (or the projects named in ProjectMapping), notes become documents, daily
+// notes (YYYY-MM-DD.md) become journal entries, and embedded images are
+// uploaded as assets. Wikilinks between notes become document links.
+func (s *Service) ImportObsidian(ctx context.Context, req ObsidianImportRequest) (*Report, error) {
+	if strings.TrimSpace(req.VaultPath) == "" {
+		return nil, fmt.Errorf("vault path is required")
+	}
+	if info, err := os.Stat(req.VaultPath); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("vault path is not a directory: %s", req.VaultPath)
+	}
+
+	for folder, alias := range req.ProjectMapping {
+		if err := project.ValidateAlias(alias); err != nil {
+			return nil, fmt.Errorf("invalid project alias for folder %s: %w", folder, err)
+		}
+	}
+	if req.DefaultProject != "" {
+		if err := project.ValidateAlias(req.DefaultProject); err != nil {
+			return nil, fmt.Errorf("invalid default project alias: %w", err)
+		}
+	}
+
+	r, err := s.newRun(ctx, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(map[string]any{
+		"vaultPath": req.VaultPath,
+		"dryRun":    req.DryRun,
+	}).Info("starting Obsidian import")
+
+	v, err := scanObsidianVault(req, r.report)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, note := range v.notes {
+		if err := r.ensureProject(ctx, note.project, note.projectName); err != nil {
+			return nil, err
+		}
+	}
+
+	// Documents are saved first with links as plain text, since a link target
+	// has no path until it is saved; notes that link to other notes are then
+	// saved again with the links in place.
+	for _, note := range v.notes {
+		if note.date != "" {
+			continue
+		}
+		blocks, err := r.convertObsidianNote(ctx, v, note, true)
+		if err != nil {
+			return nil, err
+		}
+		note.path, err = r.saveDocument(ctx, note.draft(blocks), "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !req.DryRun {
+		for _, note := range v.notes {
+			if note.date != "" || !note.hasLinks {
+				continue
+			}
+			blocks, err := r.convertObsidianNote(ctx, v, note, false)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := r.saveDocument(ctx, note.draft(blocks), note.path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, note := range v.notes {
+		if note.date == "" {
+			continue
+		}
+		for _, entry := range splitJournalEntries(note.body) {
+			content := r.obsidianPlainText(v, note, entry)
+			tags := normalizeTags(append(append([]string{}, note.tags...), inlineTags(entry)...))
+			if err := r.appendJournal(ctx, note.project, note.date, content, tags); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	logger.WithFields(map[string]any{
+		"vaultPath":      req.VaultPath,
+		"dryRun":         req.DryRun,
+		"projects":       len(r.report.Projects),
+		"documents":      len(r.report.Documents),
+		"journalEntries": r.report.JournalEntries,
+		"assets":         r.report.Assets,
+		"warnings":       len(r.report.Warnings),
+	}).Info("Obsidian import completed")
+
+	return r.report, nil
+}
+
+func (n *obsidianNote) draft(blocks []document.BlockNoteBlock) documentDraft {
+	return documentDraft{
+		Source:  n.rel,
+		Project: n.project,
+		Title:   n.title,
+		Tags:    n.tags,
+		Aliases: normalizeAliases(n.aliases),
+		Blocks:  blocks,
+		Created: n.created,
+		Updated: n.updated,
+	}
+}
+
+func scanObsidianVault(req ObsidianImportRequest, report *Report) (*obsidianVault, error) {
+	root := req.VaultPath
+	defaultAlias := req.DefaultProject
+	defaultName := filepath.Base(filepath.Clean(root))
+	if defaultAlias == "" {
+		alias, err := projectAlias(defaultName)
+		if err != nil {
+			return nil, err
+		}
+		defaultAlias = alias
+	}
+
+	v := &obsidianVault{
+		root:   root,
+		byName: make(map[string]*obsidianNote),
+		files:  make(map[string]string),
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel := filepath.ToSlash(relPath)
+
+		if !strings.EqualFold(path.Ext(rel), ".md") {
+			v.files[strings.ToLower(rel)] = p
+			if _, exists := v.files[strings.ToLower(path.Base(rel))]; !exists {
+				v.files[strings.ToLower(path.Base(rel))] = p
+			}
+			return nil
+		}
+
+		note, err := readObsidianNote(p, rel, report)
+		if err != nil {
+			report.warnf("%s: %v", rel, err)
+			return nil
+		}
+
+		note.project, note.projectName = defaultAlias, defaultName
+		if top, _, nested := strings.Cut(rel, "/"); nested {
+			note.project, note.projectName = top, top
+			if mapped, ok := req.ProjectMapping[top]; ok {
+				note.project = mapped
+			} else if alias, err := projectAlias(top); err == nil {
+				note.project = alias
+			} else {
+				report.warnf("%s: %v; importing into %s", rel, err, defaultAlias)
+				note.project, note.projectName = defaultAlias, defaultName
+			}
+		}
+
+		v.notes = append(v.notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning vault: %w", err)
+	}
+
+	sort.Slice(v.notes, func(i, j int) bool { return v.notes[i].rel < v.notes[j].rel })
+
+	// Paths take precedence over bare names, which take precedence over
+	// aliases, matching Obsidian's resolution order.
+	for _, note := range v.notes {
+		v.byName[strings.ToLower(strings.TrimSuffix(note.rel, path.Ext(note.rel)))] = note
+	}
+	for _, note := range v.notes {
+		name := strings.ToLower(strings.TrimSuffix(path.Base(note.rel), path.Ext(note.rel)))
+		if _, exists := v.byName[name]; !exists {
+			v.byName[name] = note
+		}
+	}
+	for _, note := range v.notes {
+		for _, alias := range note.aliases {
+			if _, exists := v.byName[strings.ToLower(alias)]; !exists {
+				v.byName[strings.ToLower(alias)] = note
+			}
+		}
+	}
+
+	return v, nil
+}
+
+func readObsidianNote(absPath, rel string, report *Report) (*obsidianNote, error) {
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, body, err := splitFrontmatter(string(data))
+	if err != nil {
+		report.warnf("%s: %v; importing without frontmatter", rel, err)
+	}
+
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	note := &obsidianNote{
+		rel:     rel,
+		title:   name,
+		body:    body,
+		created: frontmatterTime(meta, "created", "date created", "created_at"),
+		updated: frontmatterTime(meta, "updated", "modified", "date modified", "updated_at"),
+	}
+	if title := frontmatterString(meta, "title"); title != "" {
+		note.title = title
+	}
+
+	rawTags := append(stringList(meta["tags"]), stringList(meta["tag"])...)
+	note.tags = normalizeTags(append(rawTags, inlineTags(body)...))
+	note.aliases = append(stringList(meta["aliases"]), stringList(meta["alias"])...)
+
+	if note.updated.IsZero() {
+		note.updated = info.ModTime()
+	}
+	if note.created.IsZero() || note.created.After(note.updated) {
+		note.created = note.updated
+	}
+
+	if dailyNoteRe.MatchString(name) && journal.ValidateDate(name) == nil {
+		note.date = name
+	}
+	return note, nil
+}
+
+// inlineTags returns the #tags in Markdown text, ignoring code and headings.
+func inlineTags(md string) []string {
+	var tags []string
+	inFence := false
+	for _, line := range strings.Split(md, "\n") {
+		if fenceMarkerRe.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		line = inlineCodeRe.ReplaceAllString(line, "")
+		for _, m := range inlineTagRe.FindAllStringSubmatch(line, -1) {
+			if !allDigitsTag.MatchString(m[1]) {
+				tags = append(tags, m[1])
+			}
+		}
+	}
+	return tags
+}
+
+// resolveNote finds the note a link target refers to. from is the linking
+// note's path, for relative Markdown links.
+func (v *obsidianVault) resolveNote(target, from string) *obsidianNote {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil
+	}
+	key := strings.ToLower(strings.TrimSuffix(target, ".md"))
+	if note, ok := v.byName[key]; ok {
+		return note
+	}
+	rel := strings.ToLower(strings.TrimSuffix(path.Join(path.Dir(from), target), ".md"))
+	if note, ok := v.byName[rel]; ok {
+		return note
+	}
+	return v.byName[strings.ToLower(path.Base(key))]
+}
+
+// resolveFile finds an attachment by path relative to the vault, relative to
+// the linking note, or by bare file name.
+func (v *obsidianVault) resolveFile(target, from string) (string, bool) {
+	for _, key := range []string{target, path.Join(path.Dir(from), target), path.Base(target)} {
+		if p, ok := v.files[strings.ToLower(key)]; ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// splitWikiTarget splits "Note#Heading|Label" into its target and label.
+func splitWikiTarget(inner string) (target, label string) {
+	target, label, hasLabel := strings.Cut(inner, "|")
+	target, _, _ = strings.Cut(target, "#")
+	target = strings.TrimSpace(target)
+	if !hasLabel {
+		label = strings.TrimSpace(strings.ReplaceAll(inner, "#", " > "))
+	}
+	return target, strings.TrimSpace(label)
+}
+
+// wikiHeading returns the heading a "Note#Heading#Subheading" reference
+// points at, or "" for a whole note or a "^block" reference.
+func wikiHeading(ref string) string {
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return ""
+	}
+	heading := strings.TrimSpace(ref[i+1:])
+	if strings.HasPrefix(heading, "^") {
+		return ""
+	}
+	return heading
+}
+
+// convertObsidianNote converts a note body into blocks. In the first pass
+// links render as text and problems are reported; the second pass links to
+// the now-saved documents.
+func (r *run) convertObsidianNote(ctx context.Context, v *obsidianVault, note *obsidianNote, firstPass bool) ([]document.BlockNoteBlock, error) {
+	warn := r.report.warnf
+	if !firstPass {
+		warn = func(string, ...any) {}
+	}
+
+	link := func(target, heading, label string) string {
+		dest := v.resolveNote(target, note.rel)
+		if dest == nil {
+			if _, isFile := v.resolveFile(target, note.rel); !isFile {
+				warn("%s: unresolved link to %q", note.rel, target)
+			}
+			return label
+		}
+		if dest.date != "" {
+			return label
+		}
+		if firstPass {
+			note.hasLinks = true
+			return label
+		}
+		href := dest.path
+		if heading != "" {
+			href += "#" + document.Slugify(heading)
+		}
+		return "[" + label + "](" + href + ")"
+	}
+
+	blocks, err := convertMarkdown(note.body, func(ref embedRef) (*document.BlockNoteBlock, string) {
+		return r.obsidianEmbed(ctx, v, note, ref, warn)
+	}, func(line string) string {
+		return rewriteObsidianLinks(line, link)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("converting %s: %w", note.rel, err)
+	}
+	return blocks, nil
+}
+
+// obsidianEmbed turns an embed into an image block, or into replacement text
+// when it isn't an image: embedded notes become links and other attachments
+// their file name.
+func (r *run) obsidianEmbed(ctx context.Context, v *obsidianVault, note *obsidianNote, ref embedRef, warn func(string, ...any)) (*document.BlockNoteBlock, string) {
+	if document.IsExternalURL(ref.target) {
+		block := imageBlock(ref.target, "", ref.label)
+		return &block, ""
+	}
+
+	filePath, ok := v.resolveFile(ref.target, note.rel)
+	if !ok {
+		if v.resolveNote(ref.target, note.rel) != nil {
+			return nil, "[[" + ref.target + "]]"
+		}
+		warn("%s: embedded file %q not found", note.rel, ref.target)
+		return nil, ref.label
+	}
+
+	name := filepath.Base(filePath)
+	if !imageExts[strings.ToLower(filepath.Ext(name))] {
+		warn("%s: attachment %q is not an image and was not imported", note.rel, name)
+		return nil, name
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		warn("%s: reading %q: %v", note.rel, name, err)
+		return nil, name
+	}
+
+	assetURL, err := r.uploadAsset(ctx, note.project, filePath, name, data)
+	if err != nil {
+		warn("%s: %v", note.rel, err)
+		return nil, name
+	}
+
+	caption := ref.label
+	if caption == name || caption == ref.target {
+		caption = ""
+	}
+	block := imageBlock(assetURL, name, caption)
+	return &block, ""
+}
+
+// rewriteObsidianLinks replaces [[wikilinks]] and relative Markdown links to
+// notes with whatever link returns for them. heading is the innermost heading
+// the link points at, if any.
+func rewriteObsidianLinks(line string, link func(target, heading, label string) string) string {
+	line = wikiLinkRe.ReplaceAllStringFunc(line, func(m string) string {
+		inner := m[2 : len(m)-2]
+		target, label := splitWikiTarget(inner)
+		ref, _, _ := strings.Cut(inner, "|")
+		return link(target, wikiHeading(ref), label)
+	})
+	return mdLinkRe.ReplaceAllStringFunc(line, func(m string) string {
+		sub := mdLinkRe.FindStringSubmatch(m)
+		label, href := sub[1], sub[2]
+		if document.IsExternalURL(href) || strings.HasPrefix(href, "#") || !strings.HasSuffix(strings.ToLower(strings.SplitN(href, "#", 2)[0]), ".md") {
+			return m
+		}
+		target, heading, _ := strings.Cut(href, "#")
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+		if decoded, err := url.PathUnescape(heading); err == nil {
+			heading = decoded
+		}
+		if label == "" {
+			label = strings.TrimSuffix(path.Base(target), ".md")
+		}
+		return link(target, heading, label)
+	})
+}
+
+// obsidianPlainText renders a daily-note entry as plain journal text: links
+// keep their labels and embeds are dropped with a warning.
+func (r *run) obsidianPlainText(v *obsidianVault, note *obsidianNote, text string) string {
+	text = wikiEmbedRe.ReplaceAllStringFunc(text, func(m string) string {
+		target, _ := splitWikiTarget(m[3 : len(m)-2])
+		r.report.warnf("%s: embed %q dropped from journal entry", note.rel, target)
+		return ""
+	})
+	return rewriteObsidianLinks(text, func(target, heading, label string) string { return label })
+}
+
+// splitJournalEntries splits a daily note into entries: each top-level list
+// item (with its nested lines) and each blank-line separated paragraph.
+func splitJournalEntries(body string) []string {
+	var entries []string
+	var current []string
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+			entries = append(entries, text)
+		}
+		current = nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if fenceMarkerRe.MatchString(line) {
+			inFence = !inFence
+			current = append(current, line)
+			continue
+		}
+		if inFence {
+			current = append(current, line)
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case leadingSpaces(line) == 0 && listMarkerRe.MatchString(line):
+			flush()
+			current = append(current, listMarkerRe.ReplaceAllString(line, ""))
+		default:
+			current = append(current, line)
+		}
+	}
+	flush()
+	return entries
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngBytes is a valid 1x1 PNG.
+var pngBytes = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 0x49, 0x48, 0x44, 0x52,
+	0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x08, 0x02, 0x00, 0x00, 0x00, 0x90, 0x77, 0x53,
+	0xde, 0x00, 0x00, 0x00, 0x0c, 0x49, 0x44, 0x41, 0x54, 0x08, 0x99, 0x63, 0xf8, 0xcf, 0xc0, 0x00,
+	0x00, 0x03, 0x01, 0x01, 0x00, 0x18, 0xdd, 0x8d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49, 0x45, 0x4e,
+	0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func setupObsidianVault(t *testing.T) string {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "My Vault")
+	writeTestFiles(t, root, map[string]string{
+		"Work/Plan.md": "---\ntitle: The Plan\ntags: [planning]\naliases: [Roadmap]\ncreated: 2023-01-02\n---\n" +
+			"Intro with #inline/tag and a link to [[Notes|the notes]].\n\n" +
+			"![[diagram.png]]\n\n" +
+			"- item linking [[Missing Note]]\n" +
+			"```go\n// #notatag [[not a link]]\n```\n",
+		"Work/Notes.md":                "See [Plan](Plan.md) and [[Roadmap]].\n\n![[manual.pdf]]\n\nJump to [[Plan#Next Steps|next steps]] or [intro](Plan.md#The%20Intro).\n",
+		"Inbox.md":                     "Root note\n",
+		"Daily/2024-05-01.md":          "---\ntags: daily\n---\n- Met with [[Plan|the team]] #meeting\n  - follow up\n- Second thought\n\nLoose paragraph\n",
+		"Work/attachments/diagram.png": string(pngBytes),
+		"Work/manual.pdf":              "%PDF-1.4",
+		".obsidian/app.json":           "{}",
+	})
+	return root
+}
+
+func TestService_ImportObsidian_DryRun(t *testing.T) {
+	svc, f := setupImporterTest(t, "@work")
+	root := setupObsidianVault(t)
+
+	report, err := svc.ImportObsidian(context.Background(), ObsidianImportRequest{VaultPath: root, DryRun: true})
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.ElementsMatch(t, []string{"@daily", "@my-vault"}, report.Projects)
+	require.Len(t, report.Documents, 3)
+	assert.Equal(t, "Inbox.md", report.Documents[0].Source)
+	assert.Equal(t, "@my-vault", report.Documents[0].Project)
+	assert.Equal(t, "The Plan", report.Documents[2].Title)
+	assert.Empty(t, report.Documents[2].Path)
+	assert.Equal(t, 3, report.JournalEntries)
+	assert.Equal(t, 1, report.Assets)
+	assert.Contains(t, report.Warnings, `Work/Plan.md: unresolved link to "Missing Note"`)
+	assert.Contains(t, report.Warnings, `Work/Notes.md: attachment "manual.pdf" is not an image and was not imported`)
+
+	assert.Empty(t, f.projects.created)
+	assert.Empty(t, f.documents.saved)
+	assert.Empty(t, f.assets.uploads)
+	assert.Empty(t, f.journal.entries)
+}
+
+func TestService_ImportObsidian(t *testing.T) {
+	svc, f := setupImporterTest(t, "@work")
+	root := setupObsidianVault(t)
+
+	report, err := svc.ImportObsidian(context.Background(), ObsidianImportRequest{
+		VaultPath:      root,
+		ProjectMapping: map[string]string{"Daily": "@journal"},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"@journal", "@my-vault"}, f.projects.created)
+	assert.Equal(t, []string{"@work/diagram.png"}, f.assets.uploads)
+
+	planPath, plan, ok := f.documents.byTitle("The Plan")
+	require.True(t, ok)
+	assert.Equal(t, "@work", plan.ProjectAlias)
+	assert.Equal(t, []string{"inline-tag", "planning"}, plan.Tags)
+	assert.Equal(t, []string{"Roadmap"}, plan.Aliases)
+	assert.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), plan.Created.UTC())
+
+	notesPath, notes, ok := f.documents.byTitle("Notes")
+	require.True(t, ok)
+
+	planText := blockTexts(t, plan.Blocks)
+	assert.Equal(t, "paragraph:Intro with #inline/tag and a link to the notes->"+notesPath+".", planText[0])
+	assert.Equal(t, "image:", planText[1])
+	assert.Contains(t, plan.Blocks[1].Props["url"], "/assets/@work/")
+	assert.Equal(t, "bulletListItem:item linking Missing Note", planText[2])
+	assert.Equal(t, "codeBlock:// #notatag [[not a link]]", planText[3])
+
+	notesText := blockTexts(t, notes.Blocks)
+	assert.Equal(t, "paragraph:See Plan->"+planPath+" and Roadmap->"+planPath+".", notesText[0])
+	assert.Equal(t, "paragraph:manual.pdf", notesText[1])
+	assert.Equal(t, "paragraph:Jump to next steps->"+planPath+"#next-steps or intro->"+planPath+"#the-intro.", notesText[2])
+
+	// Four first saves plus one re-save each for the two linking notes.
+	assert.Equal(t, 5, f.documents.saves)
+	for _, doc := range report.Documents {
+		assert.NotEmpty(t, doc.Path)
+	}
+
+	require.Len(t, f.journal.entries, 3)
+	first := f.journal.entries[0]
+	assert.Equal(t, "@journal", first.ProjectAlias)
+	assert.Equal(t, "2024-05-01", first.Date)
+	assert.Equal(t, "Met with the team #meeting\n  - follow up", first.Content)
+	assert.Equal(t, []string{"daily", "meeting"}, first.Tags)
+	assert.Equal(t, "Loose paragraph", f.journal.entries[2].Content)
+}
+
+func TestService_ImportObsidian_Validation(t *testing.T) {
+	svc, _ := setupImporterTest(t)
+
+	_, err := svc.ImportObsidian(context.Background(), ObsidianImportRequest{})
+	assert.ErrorContains(t, err, "vault path is required")
+
+	_, err = svc.ImportObsidian(context.Background(), ObsidianImportRequest{VaultPath: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorContains(t, err, "not a directory")
+
+	_, err = svc.ImportObsidian(context.Background(), ObsidianImportRequest{
+		VaultPath:      t.TempDir(),
+		ProjectMapping: map[string]string{"Work": "work"},
+	})
+	assert.ErrorContains(t, err, "invalid project alias for folder Work")
+}
+
+func TestSplitJournalEntries(t *testing.T) {
+	entries := splitJournalEntries("- one\n  - nested\n- two\n\npara line 1\npara line 2\n\n```\ncode\n\nmore\n```\n")
+	assert.Equal(t, []string{"one\n  - nested", "two", "para line 1\npara line 2", "```\ncode\n\nmore\n```"}, entries)
+}
+
+func TestInlineTags(t *testing.T) {
+	tags := inlineTags("# Heading\nText #one and #two/three, not#this `#code` #123\n```\n#fenced\n```\n(#paren)")
+	assert.Equal(t, []string{"one", "two/three", "paren"}, tags)
+}
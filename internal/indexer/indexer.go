@@ -334,6 +334,10 @@ func (idx *Indexer) IndexDocument(ctx context.Context, docPath string) error {
 		return fmt.Errorf("updating fts_doc: %w", err)
 	}
 
+	if err = idx.docStore.ReplaceOutlineTx(ctx, tx, docPath, content.Outline); err != nil {
+		return fmt.Errorf("updating document outline: %w", err)
+	}
+
 	err = idx.tagStore.RemoveAllDocumentTagsTx(ctx, tx, docPath)
 	if err != nil {
 		return fmt.Errorf("removing existing tags: %w", err)
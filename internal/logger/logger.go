@@ -41,6 +41,8 @@ type Config struct {
 	MaxBackups int
 	MaxAge     int
 	Compress   bool
+	// Console receives log lines besides the log file; nil means stdout.
+	Console io.Writer
 }
 
 func DefaultConfig() *Config {
@@ -122,6 +124,8 @@ func Init(config *Config) error {
 		if len(writers) == 0 {
 			writers = append(writers, io.Discard)
 		}
+	} else if config.Console != nil {
+		writers = append(writers, config.Console)
 	} else {
 		writers = append(writers, os.Stdout)
 	}
@@ -166,6 +170,18 @@ func writeEmergencyLog(logDir, message string) {
 }
 
 func InitFromEnv() error {
+	return Init(envConfig())
+}
+
+// InitStderrFromEnv is InitFromEnv for processes whose stdout carries a
+// protocol, such as `yanta mcp --standalone`: console logging goes to stderr.
+func InitStderrFromEnv() error {
+	cfg := envConfig()
+	cfg.Console = os.Stderr
+	return Init(cfg)
+}
+
+func envConfig() *Config {
 	cfg := DefaultConfig()
 	cfg.Level = config.GetLogLevel()
 
@@ -177,7 +193,7 @@ func InitFromEnv() error {
 		cfg.LogDir = logDir
 	}
 
-	return Init(cfg)
+	return cfg
 }
 
 func GetLogger() *logrus.Logger {
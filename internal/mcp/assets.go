@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxAssetBytes caps an asset read or uploaded through MCP. It matches the
+// app's own upload limit, and its base64 form still fits in maxRequestBytes.
+const maxAssetBytes = 10 << 20 // 10 MiB
+
+const assetURIPrefix = "yanta://asset/"
+
+// AssetURI returns the resource URI of an asset file (hash plus extension)
+// in a project.
+func AssetURI(projectAlias, file string) string {
+	return assetURIPrefix + strings.TrimPrefix(projectAlias, "@") + "/" + file
+}
+
+// parseAssetRef accepts the forms an asset reference takes in document
+// Markdown (/assets/@work/<hash>.png, also behind /api or wails://) and asset
+// resource URIs. It returns the alias with its leading @ and the file name.
+func parseAssetRef(ref string) (alias, file string, ok bool) {
+	var rest string
+	if strings.HasPrefix(ref, assetURIPrefix) {
+		rest = "@" + strings.TrimPrefix(ref, assetURIPrefix)
+	} else {
+		i := strings.Index(ref, "/assets/")
+		if i < 0 {
+			return "", "", false
+		}
+		rest = ref[i+len("/assets/"):]
+	}
+	alias, file, ok = strings.Cut(rest, "/")
+	if !ok || len(alias) < 2 || !strings.HasPrefix(alias, "@") || file == "" || strings.ContainsAny(file, `/\`) {
+		return "", "", false
+	}
+	return alias, file, true
+}
+
+// isInlineImage reports whether clients can be expected to render the MIME
+// type as image content. SVG is sent as a blob: few clients display it, and it
+// can carry script.
+func isInlineImage(mime string) bool {
+	return strings.HasPrefix(mime, "image/") && mime != "image/svg+xml"
+}
+
+type getAssetArgs struct {
+	Ref string `json:"ref" jsonschema:"The asset reference as it appears in document Markdown, e.g. /assets/@work/<hash>.png, or a yanta://asset/ URI."`
+}
+type assetResult struct {
+	Ref   string `json:"ref"`
+	MIME  string `json:"mime"`
+	Bytes int64  `json:"bytes"`
+}
+
+func (s *Server) handleGetAsset(ctx context.Context, req *mcp.CallToolRequest, a getAssetArgs) (*mcp.CallToolResult, assetResult, error) {
+	alias, file, ok := parseAssetRef(a.Ref)
+	if !ok {
+		return nil, assetResult{}, fmt.Errorf("%q is not an asset reference", a.Ref)
+	}
+	if err := toolScope(req).requireProject(alias); err != nil {
+		return nil, assetResult{}, err
+	}
+	data, err := s.vault.ReadAsset(ctx, alias, file, maxAssetBytes)
+	if err != nil {
+		return nil, assetResult{}, err
+	}
+	res := assetResult{Ref: assetRef(alias, file), MIME: data.MIME, Bytes: int64(len(data.Data))}
+	var content mcp.Content
+	if isInlineImage(data.MIME) {
+		content = &mcp.ImageContent{Data: data.Data, MIMEType: data.MIME}
+	} else {
+		content = &mcp.EmbeddedResource{Resource: &mcp.ResourceContents{
+			URI:      AssetURI(alias, file),
+			MIMEType: data.MIME,
+			Blob:     data.Data,
+		}}
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{content}}, res, nil
+}
+
+type uploadAssetArgs struct {
+	ProjectAlias string `json:"project_alias"`
+	Filename     string `json:"filename" jsonschema:"Original file name; its extension picks the type (png, jpg, gif or webp)."`
+	Data         string `json:"data" jsonschema:"The file's bytes, base64-encoded. At most 10 MiB once decoded."`
+}
+type uploadAssetResult struct {
+	assetResult
+	Markdown string `json:"markdown"`
+}
+
+func (s *Server) handleUploadAsset(ctx context.Context, req *mcp.CallToolRequest, a uploadAssetArgs) (*mcp.CallToolResult, uploadAssetResult, error) {
+	if a.ProjectAlias == "" || a.Data == "" {
+		return nil, uploadAssetResult{}, fmt.Errorf("project_alias and data are required")
+	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, uploadAssetResult{}, err
+	}
+	if base64.StdEncoding.DecodedLen(len(a.Data)) > maxAssetBytes+2 {
+		return nil, uploadAssetResult{}, fmt.Errorf("asset too large: at most %d bytes", maxAssetBytes)
+	}
+	data, err := base64.StdEncoding.DecodeString(a.Data)
+	if err != nil {
+		return nil, uploadAssetResult{}, fmt.Errorf("data is not valid base64: %w", err)
+	}
+	if len(data) > maxAssetBytes {
+		return nil, uploadAssetResult{}, fmt.Errorf("asset too large: at most %d bytes", maxAssetBytes)
+	}
+	info, err := s.vault.UploadAsset(ctx, a.ProjectAlias, a.Filename, data)
+	if err != nil {
+		return nil, uploadAssetResult{}, err
+	}
+	md := fmt.Sprintf("![%s](%s)", strings.TrimSuffix(a.Filename, path.Ext(a.Filename)), info.Ref)
+	return text("Uploaded " + info.Ref + "\n\nUse it in a document as:\n" + md), uploadAssetResult{
+		assetResult: assetResult{Ref: info.Ref, MIME: info.MIME, Bytes: info.Bytes},
+		Markdown:    md,
+	}, nil
+}
+
+// readAssetResource serves asset resource URIs as blobs.
+func (s *Server) readAssetResource(ctx context.Context, scope Scope, uri, alias, file string) (*mcp.ReadResourceResult, error) {
+	if err := scope.requireProject(alias); err != nil {
+		return nil, err
+	}
+	data, err := s.vault.ReadAsset(ctx, alias, file, maxAssetBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: uri, MIMEType: data.MIME, Blob: data.Data},
+	}}, nil
+}
+
+func assetRef(alias, file string) string {
+	return "/assets/" + alias + "/" + file
+}
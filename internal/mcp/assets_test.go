@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParseAssetRef(t *testing.T) {
+	tests := []struct {
+		ref, alias, file string
+	}{
+		{"/assets/@work/abc.png", "@work", "abc.png"},
+		{"/api/assets/@work/abc.png", "@work", "abc.png"},
+		{"wails://assets/@work/abc", "@work", "abc"},
+		{"yanta://asset/work/abc.pdf", "@work", "abc.pdf"},
+	}
+	for _, tt := range tests {
+		alias, file, ok := parseAssetRef(tt.ref)
+		if !ok || alias != tt.alias || file != tt.file {
+			t.Errorf("parseAssetRef(%q) = %q, %q, %v", tt.ref, alias, file, ok)
+		}
+	}
+	for _, ref := range []string{"", "abc.png", "/assets/work/abc.png", "/assets/@work/", "/assets/@work/a/b.png", `/assets/@work/..\b`} {
+		if _, _, ok := parseAssetRef(ref); ok {
+			t.Errorf("parseAssetRef(%q) should fail", ref)
+		}
+	}
+}
+
+func TestHandleGetAsset(t *testing.T) {
+	fv := &fakeVault{asset: AssetData{MIME: "image/png", Data: []byte("png")}}
+	s := NewServer(fv, "test")
+	ctx := context.Background()
+
+	res, out, err := s.handleGetAsset(ctx, nil, getAssetArgs{Ref: "/api/assets/@work/abc.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.assetAlias != "@work" || fv.assetFile != "abc.png" {
+		t.Errorf("read %q %q", fv.assetAlias, fv.assetFile)
+	}
+	if img, ok := res.Content[0].(*mcp.ImageContent); !ok || string(img.Data) != "png" || img.MIMEType != "image/png" {
+		t.Errorf("content = %#v; want image content", res.Content[0])
+	}
+	if out.Ref != "/assets/@work/abc.png" || out.Bytes != 3 {
+		t.Errorf("result = %+v", out)
+	}
+
+	fv.asset = AssetData{MIME: "application/pdf", Data: []byte("%PDF")}
+	res, _, err = s.handleGetAsset(ctx, nil, getAssetArgs{Ref: "/assets/@work/abc.pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, ok := res.Content[0].(*mcp.EmbeddedResource)
+	if !ok || blob.Resource.URI != "yanta://asset/work/abc.pdf" || string(blob.Resource.Blob) != "%PDF" {
+		t.Errorf("content = %#v; want an embedded blob", res.Content[0])
+	}
+
+	if _, _, err := s.handleGetAsset(ctx, nil, getAssetArgs{Ref: "https://example.com/x.png"}); err == nil {
+		t.Error("expected an error for a non-asset reference")
+	}
+	work := scoped(Scope{Access: AccessRead, Projects: []string{"@work"}})
+	if _, _, err := s.handleGetAsset(ctx, work, getAssetArgs{Ref: "/assets/@home/abc.png"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("other project's asset: got %v, want ErrForbidden", err)
+	}
+}
+
+func TestHandleUploadAsset(t *testing.T) {
+	fv := &fakeVault{}
+	s := NewServer(fv, "test")
+	ctx := context.Background()
+
+	_, out, err := s.handleUploadAsset(ctx, nil, uploadAssetArgs{
+		ProjectAlias: "@work",
+		Filename:     "diagram.png",
+		Data:         base64.StdEncoding.EncodeToString([]byte("png")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fv.uploaded) != "png" || fv.assetFile != "diagram.png" {
+		t.Errorf("upload forwarded %q as %q", fv.uploaded, fv.assetFile)
+	}
+	if out.Ref != "/assets/@work/abc.png" || out.Markdown != "![diagram](/assets/@work/abc.png)" {
+		t.Errorf("result = %+v", out)
+	}
+
+	if _, _, err := s.handleUploadAsset(ctx, nil, uploadAssetArgs{ProjectAlias: "@work", Data: "not base64!"}); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+	big := base64.StdEncoding.EncodeToString(make([]byte, maxAssetBytes+1))
+	if _, _, err := s.handleUploadAsset(ctx, nil, uploadAssetArgs{ProjectAlias: "@work", Data: big}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("oversized upload: got %v", err)
+	}
+	readOnly := scoped(Scope{Access: AccessRead})
+	if _, _, err := s.handleUploadAsset(ctx, readOnly, uploadAssetArgs{ProjectAlias: "@work", Data: "cG5n"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("read-only upload: got %v, want ErrForbidden", err)
+	}
+}
+
+func TestResources_ReadAsset(t *testing.T) {
+	fv := &fakeVault{asset: AssetData{MIME: "image/png", Data: []byte("png")}}
+	cs, _ := connect(t, NewServer(fv, "test"))
+
+	res, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "yanta://asset/work/abc.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.assetAlias != "@work" || string(res.Contents[0].Blob) != "png" || res.Contents[0].MIMEType != "image/png" {
+		t.Errorf("asset read: alias %q, contents %+v", fv.assetAlias, res.Contents[0])
+	}
+}
@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxAuditString caps each string argument and the result text kept in an
+// audit entry, so document bodies and asset data do not end up in the log.
+const maxAuditString = 200
+
+// readOnlyTools are the tools that never change the vault. Every other tool
+// call is a write: it is attributed in git and may be undoable.
+var readOnlyTools = map[string]bool{
+	"search_notes":       true,
+	"list_projects":      true,
+	"list_documents":     true,
+	"get_document":       true,
+	"read_journal":       true,
+	"list_journal_dates": true,
+	"search_journal":     true,
+	"list_tags":          true,
+	"get_asset":          true,
+}
+
+// inPlaceTools edit an existing document without moving it. The audit
+// snapshots the document before they run, so the change can be undone.
+var inPlaceTools = map[string]bool{
+	"update_document":           true,
+	"insert_blocks":             true,
+	"append_blocks":             true,
+	"replace_section":           true,
+	"delete_block":              true,
+	"toggle_checklist_item":     true,
+	"add_tags_to_document":      true,
+	"remove_tags_from_document": true,
+}
+
+// AuditEntry records one tool call. Client is the name the MCP client gave
+// when it connected and Token the name of the token it used; both are empty
+// for the stdio owner connection where the client sent no name.
+type AuditEntry struct {
+	Time       time.Time      `json:"time"`
+	Client     string         `json:"client,omitempty"`
+	Token      string         `json:"token,omitempty"`
+	Tool       string         `json:"tool"`
+	Args       map[string]any `json:"args,omitempty"`
+	Paths      []string       `json:"paths,omitempty"`
+	Project    string         `json:"project,omitempty"`
+	Write      bool           `json:"write"`
+	OK         bool           `json:"ok"`
+	Result     string         `json:"result,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	// Undo is set for a successful call whose change can be reversed. It is
+	// kept in memory only.
+	Undo *Undo `json:"-"`
+}
+
+// Who names the caller for commit messages and the UI.
+func (e AuditEntry) Who() string {
+	switch {
+	case e.Client != "" && e.Token != "":
+		return e.Client + "/" + e.Token
+	case e.Client != "":
+		return e.Client
+	case e.Token != "":
+		return e.Token
+	}
+	return "owner"
+}
+
+// Reason describes the call as a git auto-commit reason.
+func (e AuditEntry) Reason() string {
+	r := fmt.Sprintf("mcp(%s): %s", e.Who(), e.Tool)
+	if len(e.Paths) > 0 {
+		r += " " + strings.Join(e.Paths, ", ")
+	} else if e.Project != "" {
+		r += " " + e.Project
+	}
+	return r
+}
+
+// ErrUndoConflict is returned when the document an undo would restore has
+// changed since the MCP call.
+var ErrUndoConflict = errors.New("document changed since the MCP change")
+
+// Undo reverses a document change made by one tool call. Before is the
+// stored document as it was, or nil if the call created it; AfterHash is its
+// hash right after the call.
+type Undo struct {
+	Path      string
+	Before    *DocumentSnapshot
+	AfterHash string
+}
+
+// Apply restores the document exactly as it was before the call, or moves a
+// created document to the trash. It refuses when the document has changed
+// since, rather than discard the newer edit.
+func (u *Undo) Apply(ctx context.Context, v Vault) error {
+	if u.Before == nil {
+		cur, err := v.GetDocument(ctx, u.Path)
+		if err != nil {
+			return err
+		}
+		if cur.Hash != u.AfterHash {
+			return fmt.Errorf("%w: %s", ErrUndoConflict, u.Path)
+		}
+		return v.DeleteDocument(ctx, u.Path, false)
+	}
+	_, err := v.RestoreDocument(ctx, u.Path, u.AfterHash, *u.Before)
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("%w: %s", ErrUndoConflict, u.Path)
+	}
+	return err
+}
+
+// SetAudit installs fn to receive an entry after every tool call. fn runs on
+// the request goroutine and should not block for long.
+func (s *Server) SetAudit(fn func(AuditEntry)) {
+	s.audit = fn
+}
+
+// auditMiddleware times each tools/call, snapshots documents that in-place
+// edits are about to change, and reports the call to the audit function.
+func (s *Server) auditMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		call, ok := req.(*mcp.CallToolRequest)
+		if method != "tools/call" || !ok || s.audit == nil {
+			return next(ctx, method, req)
+		}
+
+		var args map[string]any
+		_ = json.Unmarshal(call.Params.Arguments, &args)
+		tool := call.Params.Name
+		entry := AuditEntry{
+			Client:  clientName(req),
+			Token:   tokenName(req.GetExtra()),
+			Tool:    tool,
+			Project: stringArg(args, "project_alias"),
+			Write:   !readOnlyTools[tool],
+		}
+
+		path, _, _ := strings.Cut(stringArg(args, "path"), "#")
+		var before *DocumentSnapshot
+		if inPlaceTools[tool] && path != "" {
+			if snap, err := s.vault.SnapshotDocument(ctx, path); err == nil {
+				before = &snap
+			}
+		}
+
+		start := time.Now()
+		res, err := next(ctx, method, req)
+		entry.Time = start.UTC()
+		entry.DurationMS = time.Since(start).Milliseconds()
+		entry.Args = truncateArgs(args)
+
+		result, _ := res.(*mcp.CallToolResult)
+		switch {
+		case err != nil:
+			entry.Result = truncate(err.Error())
+		case result != nil:
+			entry.OK = !result.IsError
+			entry.Result = truncate(resultText(result))
+		}
+
+		after := resultPath(result)
+		for _, p := range []string{path, after} {
+			if p != "" && !slices.Contains(entry.Paths, p) {
+				entry.Paths = append(entry.Paths, p)
+			}
+		}
+
+		if entry.OK && entry.Write {
+			switch {
+			case before != nil:
+				entry.Undo = s.undoFor(ctx, path, before)
+			case tool == "create_document" && after != "":
+				entry.Undo = s.undoFor(ctx, after, nil)
+			}
+		}
+
+		s.audit(entry)
+		return res, err
+	}
+}
+
+func (s *Server) undoFor(ctx context.Context, path string, before *DocumentSnapshot) *Undo {
+	doc, err := s.vault.GetDocument(ctx, path)
+	if err != nil {
+		return nil
+	}
+	return &Undo{Path: path, Before: before, AfterHash: doc.Hash}
+}
+
+func clientName(req mcp.Request) string {
+	ss, ok := req.GetSession().(*mcp.ServerSession)
+	if !ok || ss == nil {
+		return ""
+	}
+	if p := ss.InitializeParams(); p != nil && p.ClientInfo != nil {
+		return p.ClientInfo.Name
+	}
+	return ""
+}
+
+func tokenName(extra *mcp.RequestExtra) string {
+	if extra == nil || extra.TokenInfo == nil {
+		return ""
+	}
+	if name, ok := extra.TokenInfo.Extra[tokenNameKey].(string); ok && name != "" {
+		return name
+	}
+	return extra.TokenInfo.UserID
+}
+
+func stringArg(args map[string]any, key string) string {
+	s, _ := args[key].(string)
+	return s
+}
+
+// resultPath returns the path in a tool's structured result, such as the new
+// document of create_document or promote_journal_entries.
+func resultPath(res *mcp.CallToolResult) string {
+	if res == nil || res.IsError || res.StructuredContent == nil {
+		return ""
+	}
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		return ""
+	}
+	var out struct {
+		Path string `json:"path"`
+	}
+	_ = json.Unmarshal(data, &out)
+	return out.Path
+}
+
+func resultText(res *mcp.CallToolResult) string {
+	for _, c := range res.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			return t.Text
+		}
+	}
+	return ""
+}
+
+// truncateArgs copies args with every long string shortened.
+func truncateArgs(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(args))
+	for k, v := range args {
+		out[k] = truncateValue(v)
+	}
+	return out
+}
+
+func truncateValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return truncate(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = truncateValue(e)
+		}
+		return out
+	case map[string]any:
+		return truncateArgs(v)
+	}
+	return v
+}
+
+func truncate(s string) string {
+	if len(s) <= maxAuditString {
+		return s
+	}
+	cut := maxAuditString
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… (%d bytes)", s[:cut], len(s))
+}
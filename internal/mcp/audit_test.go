@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	fv := &fakeVault{
+		doc:      DocumentContent{Path: "projects/@work/doc-1.json", Title: "Plan", Markdown: "# Plan\n", Hash: "h1"},
+		snapshot: DocumentSnapshot{Hash: "h0", Data: []byte(`{"blocks":[{"id":"b1"}]}`)},
+	}
+	s := NewServer(fv, "test")
+	var entries []AuditEntry
+	s.SetAudit(func(e AuditEntry) { entries = append(entries, e) })
+	cs, _ := connect(t, s)
+	ctx := context.Background()
+
+	body := strings.Repeat("x", 1000)
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "update_document", Arguments: map[string]any{
+		"path":     "projects/@work/doc-1.json",
+		"markdown": body,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "get_document", Arguments: map[string]any{
+		"path": "projects/@work/doc-1.json",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	fv.err = errors.New("disk full")
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "append_journal", Arguments: map[string]any{
+		"project_alias": "@work",
+		"content":       "standup",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("got %d audit entries, want 3", len(entries))
+	}
+	update := entries[0]
+	if update.Tool != "update_document" || update.Client != "test" || !update.Write || !update.OK {
+		t.Errorf("update entry = %+v", update)
+	}
+	if len(update.Paths) != 1 || update.Paths[0] != "projects/@work/doc-1.json" {
+		t.Errorf("paths = %v", update.Paths)
+	}
+	if md, _ := update.Args["markdown"].(string); len(md) > maxAuditString+32 || !strings.HasSuffix(md, "(1000 bytes)") {
+		t.Errorf("body not truncated: %q", md)
+	}
+	if update.Undo == nil || update.Undo.Before == nil || string(update.Undo.Before.Data) != `{"blocks":[{"id":"b1"}]}` || update.Undo.AfterHash != "h1" {
+		t.Errorf("undo = %+v", update.Undo)
+	}
+	if got := update.Reason(); got != "mcp(test): update_document projects/@work/doc-1.json" {
+		t.Errorf("reason = %q", got)
+	}
+
+	if read := entries[1]; read.Write || read.Undo != nil || !read.OK {
+		t.Errorf("read entry = %+v", read)
+	}
+	if failed := entries[2]; failed.OK || !strings.Contains(failed.Result, "disk full") || failed.Project != "@work" {
+		t.Errorf("failed entry = %+v", failed)
+	}
+}
+
+func TestUndoApply(t *testing.T) {
+	fv := &fakeVault{doc: DocumentContent{Path: "p", Hash: "after"}}
+	ctx := context.Background()
+	before := DocumentSnapshot{Hash: "before", Data: []byte(`{"blocks":[{"id":"b1","props":{"checked":true}}]}`)}
+	u := &Undo{Path: "p", Before: &before, AfterHash: "after"}
+
+	if err := u.Apply(ctx, fv); err != nil {
+		t.Fatal(err)
+	}
+	if fv.restoredPath != "p" || fv.restoredHash != "after" || fv.restored == nil || string(fv.restored.Data) != string(before.Data) {
+		t.Errorf("snapshot not restored as taken: %+v", fv)
+	}
+	if fv.updatedMD != nil {
+		t.Error("undo must not go through the Markdown update")
+	}
+
+	fv.restoreErr = &ConflictError{Path: "p", CurrentHash: "edited"}
+	if err := u.Apply(ctx, fv); !errors.Is(err, ErrUndoConflict) {
+		t.Errorf("got %v, want ErrUndoConflict", err)
+	}
+
+	created := &Undo{Path: "p", AfterHash: "after"}
+	fv.doc.Hash = "edited"
+	if err := created.Apply(ctx, fv); !errors.Is(err, ErrUndoConflict) {
+		t.Errorf("created and edited since: got %v, want ErrUndoConflict", err)
+	}
+}
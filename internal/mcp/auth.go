@@ -1,13 +1,197 @@
 package mcp
 
 import (
+	"context"
 	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Access is how much a token may do. Each level includes the ones before it.
+type Access string
+
+const (
+	// AccessRead allows the read tools and resources only.
+	AccessRead Access = "read"
+	// AccessWrite also allows the write tools, except hard deletes.
+	AccessWrite Access = "write"
+	// AccessAdmin allows everything, including permanent deletion.
+	AccessAdmin Access = "admin"
+)
+
+func (a Access) rank() int {
+	switch a {
+	case AccessRead:
+		return 1
+	case AccessWrite:
+		return 2
+	case AccessAdmin:
+		return 3
+	}
+	return 0
+}
+
+// ErrForbidden is returned for a tool call or resource the token's scope
+// does not cover.
+var ErrForbidden = errors.New("forbidden")
+
+// Scope limits what a token may do: its access level and, optionally, the
+// projects (by alias) it may see and change.
+type Scope struct {
+	Access   Access   `json:"access"`
+	Projects []string `json:"projects,omitempty"`
+}
+
+// FullScope grants everything. It is the scope of the default token and of
+// requests made with token auth disabled.
+var FullScope = Scope{Access: AccessAdmin}
+
+// Validate reports whether the scope names a known access level.
+func (s Scope) Validate() error {
+	if s.Access.rank() == 0 {
+		return fmt.Errorf("unknown access level %q (want read, write or admin)", s.Access)
+	}
+	for _, p := range s.Projects {
+		if strings.TrimPrefix(p, "@") == "" {
+			return fmt.Errorf("empty project alias in scope")
+		}
+	}
+	return nil
+}
+
+// Restricted reports whether the scope is limited to some projects.
+func (s Scope) Restricted() bool {
+	return len(s.Projects) > 0
+}
+
+// AllowsProject reports whether the scope covers the project. Aliases match
+// with or without the leading @.
+func (s Scope) AllowsProject(alias string) bool {
+	if !s.Restricted() {
+		return true
+	}
+	alias = strings.TrimPrefix(alias, "@")
+	return slices.ContainsFunc(s.Projects, func(p string) bool {
+		return strings.TrimPrefix(p, "@") == alias
+	})
+}
+
+func (s Scope) require(a Access) error {
+	if s.Access.rank() < a.rank() {
+		return fmt.Errorf("%w: this token has %s access, %s is required", ErrForbidden, s.Access, a)
+	}
+	return nil
+}
+
+func (s Scope) requireProject(alias string) error {
+	if !s.AllowsProject(alias) {
+		return fmt.Errorf("%w: this token has no access to project %s", ErrForbidden, alias)
+	}
+	return nil
+}
+
+// requireVaultWrite checks that the scope may make vault-wide changes, such as
+// creating projects or renaming tags, which a project-scoped token may not.
+func requireVaultWrite(scope Scope, tool string) error {
+	if err := scope.require(AccessWrite); err != nil {
+		return err
+	}
+	if scope.Restricted() {
+		return fmt.Errorf("%w: %s is not available to a project-scoped token", ErrForbidden, tool)
+	}
+	return nil
+}
+
+// requireProjectWrite checks that the scope may write to the project.
+func requireProjectWrite(scope Scope, alias string) error {
+	if err := scope.require(AccessWrite); err != nil {
+		return err
+	}
+	return scope.requireProject(alias)
+}
+
+// requireDocumentWrite checks that the scope may write to the document. For
+// a project-scoped token it looks the document up, since a moved document's
+// path does not name its project.
+func (s *Server) requireDocumentWrite(ctx context.Context, scope Scope, path string) error {
+	if err := scope.require(AccessWrite); err != nil {
+		return err
+	}
+	return s.requireDocument(ctx, scope, path)
+}
+
+func (s *Server) requireDocument(ctx context.Context, scope Scope, path string) error {
+	if !scope.Restricted() {
+		return nil
+	}
+	path, _, _ = strings.Cut(path, "#")
+	doc, err := s.vault.GetDocument(ctx, path)
+	if err != nil {
+		return err
+	}
+	return scope.requireProject(doc.ProjectAlias)
+}
+
+// Grant is what a verified token resolves to. TokenID ties a client session
+// to the token that opened it; Name labels its calls in the audit log.
+type Grant struct {
+	TokenID string
+	Name    string
+	Scope   Scope
+}
+
+// Authenticator resolves a presented bearer token, reporting false for an
+// unknown or revoked one.
+type Authenticator func(token string) (Grant, bool)
+
+// StaticToken accepts exactly one token, with full access.
+func StaticToken(token string) Authenticator {
+	return func(presented string) (Grant, bool) {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			return Grant{}, false
+		}
+		return Grant{TokenID: "default", Name: "default", Scope: FullScope}, true
+	}
+}
+
+// scopeKey and tokenNameKey are where the grant's scope and name travel in
+// auth.TokenInfo.Extra, which the SDK hands to every request of the session.
+const (
+	scopeKey     = "yanta.scope"
+	tokenNameKey = "yanta.token_name"
 )
 
+// scopeOf returns the scope of the token a request was made with. Requests
+// without token info (auth disabled, in-process calls) have full access.
+func scopeOf(extra *mcp.RequestExtra) Scope {
+	if extra == nil || extra.TokenInfo == nil {
+		return FullScope
+	}
+	if scope, ok := extra.TokenInfo.Extra[scopeKey].(Scope); ok {
+		return scope
+	}
+	// Token info without a scope did not come from withAuth; grant nothing.
+	return Scope{}
+}
+
+// toolScope is scopeOf for a tool call; tests call handlers with a nil
+// request.
+func toolScope(req *mcp.CallToolRequest) Scope {
+	if req == nil {
+		return FullScope
+	}
+	return scopeOf(req.Extra)
+}
+
 // withAuth wraps an MCP handler with a bearer-token check and an origin guard.
 //
 // The origin guard rejects requests carrying a non-loopback Origin header,
@@ -15,20 +199,30 @@ import (
 // to reach the local server through the user's browser. Non-browser clients
 // (the `yanta mcp` bridge, direct HTTP clients) send no Origin header and pass.
 //
-// If token is non-empty, requests must present "Authorization: Bearer <token>".
-func withAuth(next http.Handler, token string) http.Handler {
+// If authn is non-nil, requests must present "Authorization: Bearer <token>"
+// with a token it accepts. The token's scope is attached to the request, and
+// the tool and resource handlers enforce it.
+func withAuth(next http.Handler, authn Authenticator) http.Handler {
+	if authn != nil {
+		next = auth.RequireBearerToken(func(_ context.Context, token string, _ *http.Request) (*auth.TokenInfo, error) {
+			grant, ok := authn(token)
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown or revoked token", auth.ErrInvalidToken)
+			}
+			// Every request is verified afresh, so a revoked token stops
+			// working at once; the expiry only satisfies the SDK.
+			return &auth.TokenInfo{
+				UserID:     grant.TokenID,
+				Expiration: time.Now().Add(time.Hour),
+				Extra:      map[string]any{scopeKey: grant.Scope, tokenNameKey: grant.Name},
+			}, nil
+		}, nil)(next)
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if origin := r.Header.Get("Origin"); origin != "" && !isLoopbackOrigin(origin) {
 			http.Error(w, "forbidden: non-loopback origin", http.StatusForbidden)
 			return
 		}
-		if token != "" {
-			presented := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-			if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
-				return
-			}
-		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,395 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Prompts pull their material from the vault when they are requested, within
+// these bounds so a large project cannot overflow the client's context.
+const (
+	promptMaxDocuments    = 10
+	promptMaxTagDocuments = 50
+	promptMaxExcerpts     = 20
+	promptExcerptBytes    = 4000
+	maxCompletions        = 100
+)
+
+// dateLayout is the journal's day format.
+const dateLayout = "2006-01-02"
+
+func (s *Server) registerPrompts() {
+	s.srv.AddPrompt(&mcp.Prompt{
+		Name:        "summarize_project",
+		Title:       "Summarize project",
+		Description: "Summarize a project from its recently updated documents and its journal.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "project_alias", Description: "The project to summarize.", Required: true},
+			{Name: "days", Description: "How many days of journal to include (default 7)."},
+		},
+	}, s.promptSummarizeProject)
+
+	s.srv.AddPrompt(&mcp.Prompt{
+		Name:        "daily_standup",
+		Title:       "Daily stand-up",
+		Description: "Draft a stand-up update from yesterday's and today's journal entries across all projects.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "date", Description: "The stand-up day as YYYY-MM-DD (default today)."},
+		},
+	}, s.promptDailyStandup)
+
+	s.srv.AddPrompt(&mcp.Prompt{
+		Name:        "review_tag",
+		Title:       "Review tag",
+		Description: "Review every document carrying a tag: themes, duplicates, gaps and stale notes.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "tag", Description: "The tag to review.", Required: true},
+		},
+	}, s.promptReviewTag)
+
+	s.srv.AddPrompt(&mcp.Prompt{
+		Name:        "draft_from_journal",
+		Title:       "Draft from journal",
+		Description: "Draft a document from a day's journal entries in a project.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "project_alias", Description: "The project whose journal to use.", Required: true},
+			{Name: "date", Description: "The journal day as YYYY-MM-DD (default today)."},
+			{Name: "title", Description: "A title for the document, if you have one in mind."},
+		},
+	}, s.promptDraftFromJournal)
+}
+
+// promptArgs reads the string arguments of a prompt request into typed
+// values, reporting the first invalid one.
+type promptArgs struct {
+	values map[string]string
+	err    error
+}
+
+func (a *promptArgs) get(name string, required bool) string {
+	v := strings.TrimSpace(a.values[name])
+	if v == "" && required && a.err == nil {
+		a.err = fmt.Errorf("argument %s is required", name)
+	}
+	return v
+}
+
+func (a *promptArgs) number(name string, def, lo, hi int) int {
+	v := a.get(name, false)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if (err != nil || n < lo || n > hi) && a.err == nil {
+		a.err = fmt.Errorf("argument %s must be a number from %d to %d", name, lo, hi)
+	}
+	return n
+}
+
+func (a *promptArgs) date(name string) time.Time {
+	v := a.get(name, false)
+	if v == "" {
+		return time.Now()
+	}
+	d, err := time.ParseInLocation(dateLayout, v, time.Local)
+	if err != nil && a.err == nil {
+		a.err = fmt.Errorf("argument %s must be a date as YYYY-MM-DD", name)
+	}
+	return d
+}
+
+func userPrompt(description, body string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{
+			{Role: "user", Content: &mcp.TextContent{Text: body}},
+		},
+	}
+}
+
+func (s *Server) promptSummarizeProject(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs{values: req.Params.Arguments}
+	alias := "@" + strings.TrimPrefix(args.get("project_alias", true), "@")
+	days := args.number("days", 7, 1, 90)
+	if args.err != nil {
+		return nil, args.err
+	}
+	if err := scopeOf(req.Extra).requireProject(alias); err != nil {
+		return nil, err
+	}
+
+	docs, err := s.vault.ListDocuments(ctx, alias, false, defaultListLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(docs, func(a, b DocumentInfo) int { return strings.Compare(b.Updated, a.Updated) })
+	if len(docs) > promptMaxDocuments {
+		docs = docs[:promptMaxDocuments]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summarize the project %s for me. Cover what was worked on, decisions made, open questions and next steps. Refer to documents by their path.\n", alias)
+	b.WriteString("\n## Recently updated documents\n")
+	if len(docs) == 0 {
+		b.WriteString("\nThe project has no documents.\n")
+	}
+	for _, d := range docs {
+		s.writeDocumentExcerpt(ctx, &b, d)
+	}
+
+	fmt.Fprintf(&b, "\n## Journal, last %d days\n", days)
+	dates, err := s.vault.ListJournalDates(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().AddDate(0, 0, -days+1).Format(dateLayout)
+	slices.Sort(dates)
+	wrote := false
+	for _, date := range dates {
+		if date < since {
+			continue
+		}
+		entries, err := s.vault.ReadJournal(ctx, alias, date, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			fmt.Fprintf(&b, "\n### %s\n\n", date)
+			writeJournalEntries(&b, entries)
+			wrote = true
+		}
+	}
+	if !wrote {
+		b.WriteString("\nNo journal entries in this period.\n")
+	}
+	return userPrompt("Summary of "+alias, b.String()), nil
+}
+
+func (s *Server) promptDailyStandup(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs{values: req.Params.Arguments}
+	day := args.date("date")
+	if args.err != nil {
+		return nil, args.err
+	}
+	scope := scopeOf(req.Extra)
+
+	var b strings.Builder
+	b.WriteString("Write my stand-up update from the journal entries below: what I did yesterday, what I am doing today, and any blockers. Group it by project and keep it short.\n")
+	for _, d := range []struct {
+		heading string
+		date    string
+	}{
+		{"Yesterday", day.AddDate(0, 0, -1).Format(dateLayout)},
+		{"Today", day.Format(dateLayout)},
+	} {
+		entries, err := s.vault.ReadAllJournals(ctx, d.date)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\n## %s (%s)\n", d.heading, d.date)
+		byProject := map[string][]JournalEntryInfo{}
+		var projects []string
+		for _, e := range entries {
+			if !scope.AllowsProject(e.ProjectAlias) {
+				continue
+			}
+			if _, ok := byProject[e.ProjectAlias]; !ok {
+				projects = append(projects, e.ProjectAlias)
+			}
+			byProject[e.ProjectAlias] = append(byProject[e.ProjectAlias], e.JournalEntryInfo)
+		}
+		if len(projects) == 0 {
+			b.WriteString("\nNo journal entries.\n")
+		}
+		slices.Sort(projects)
+		for _, p := range projects {
+			fmt.Fprintf(&b, "\n### %s\n\n", p)
+			writeJournalEntries(&b, byProject[p])
+		}
+	}
+	return userPrompt("Stand-up for "+day.Format(dateLayout), b.String()), nil
+}
+
+func (s *Server) promptReviewTag(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs{values: req.Params.Arguments}
+	tag := strings.TrimPrefix(args.get("tag", true), "#")
+	if args.err != nil {
+		return nil, args.err
+	}
+	scope := scopeOf(req.Extra)
+
+	docs, err := s.vault.ListTaggedDocuments(ctx, tag, promptMaxTagDocuments)
+	if err != nil {
+		return nil, err
+	}
+	docs = slices.DeleteFunc(docs, func(d DocumentInfo) bool { return !scope.AllowsProject(d.ProjectAlias) })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review my notes tagged #%s. Point out common themes, contradictions, duplicates worth merging, gaps, and notes that look stale. Refer to documents by their path.\n", tag)
+	if len(docs) == 0 {
+		b.WriteString("\nNo documents carry this tag.\n")
+	}
+	for i, d := range docs {
+		if i == promptMaxExcerpts {
+			fmt.Fprintf(&b, "\n## Also tagged (%d more)\n\n", len(docs)-i)
+			for _, rest := range docs[i:] {
+				fmt.Fprintf(&b, "- %s (%s)\n", rest.Title, rest.Path)
+			}
+			break
+		}
+		s.writeDocumentExcerpt(ctx, &b, d)
+	}
+	return userPrompt("Review of #"+tag, b.String()), nil
+}
+
+func (s *Server) promptDraftFromJournal(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs{values: req.Params.Arguments}
+	alias := "@" + strings.TrimPrefix(args.get("project_alias", true), "@")
+	date := args.date("date").Format(dateLayout)
+	title := args.get("title", false)
+	if args.err != nil {
+		return nil, args.err
+	}
+	if err := scopeOf(req.Extra).requireProject(alias); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.vault.ReadJournal(ctx, alias, date, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Turn my journal entries from %s in %s into a well-structured document in Markdown, with headings, lists and checklists for any to-dos. Keep my wording where it is precise; do not invent facts.\n", date, alias)
+	if title != "" {
+		fmt.Fprintf(&b, "Title it %q.\n", title)
+	}
+	fmt.Fprintf(&b, "When I approve the draft, save it with create_document in %s, or use promote_journal_entries to move the entries into it.\n", alias)
+	fmt.Fprintf(&b, "\n## Journal, %s\n\n", date)
+	if len(entries) == 0 {
+		b.WriteString("No journal entries on this day.\n")
+	}
+	writeJournalEntries(&b, entries)
+	return userPrompt("Draft from the "+date+" journal of "+alias, b.String()), nil
+}
+
+// writeDocumentExcerpt adds a document's heading and the start of its body.
+// A document that cannot be read is listed without a body.
+func (s *Server) writeDocumentExcerpt(ctx context.Context, b *strings.Builder, d DocumentInfo) {
+	fmt.Fprintf(b, "\n### %s\n\n", d.Title)
+	fmt.Fprintf(b, "Path: %s", d.Path)
+	if d.Updated != "" {
+		fmt.Fprintf(b, ", updated %s", d.Updated)
+	}
+	if len(d.Tags) > 0 {
+		fmt.Fprintf(b, ", tags: %s", strings.Join(d.Tags, ", "))
+	}
+	b.WriteString("\n\n")
+	doc, err := s.vault.GetDocument(ctx, d.Path)
+	if err != nil {
+		return
+	}
+	md := strings.TrimSpace(doc.Markdown)
+	if len(md) > promptExcerptBytes {
+		md = strings.ToValidUTF8(md[:promptExcerptBytes], "") + "\n\n[…]"
+	}
+	b.WriteString(md)
+	b.WriteString("\n")
+}
+
+func writeJournalEntries(b *strings.Builder, entries []JournalEntryInfo) {
+	for _, e := range entries {
+		b.WriteString("- ")
+		b.WriteString(strings.ReplaceAll(strings.TrimSpace(e.Content), "\n", "\n  "))
+		if len(e.Tags) > 0 {
+			fmt.Fprintf(b, " (#%s)", strings.Join(e.Tags, " #"))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// complete offers project aliases, tags and journal dates for prompt
+// arguments, limited to what the token may see.
+func (s *Server) complete(ctx context.Context, req *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	p := req.Params
+	var values []string
+	if p.Ref != nil && p.Ref.Type == "ref/prompt" {
+		scope := scopeOf(req.Extra)
+		var err error
+		switch p.Argument.Name {
+		case "project_alias":
+			values, err = s.completeProjects(ctx, scope)
+		case "tag":
+			if !scope.Restricted() {
+				values, err = s.vault.ListTags(ctx)
+			}
+		case "date":
+			var alias string
+			if p.Context != nil {
+				alias = p.Context.Arguments["project_alias"]
+			}
+			values, err = s.completeDates(ctx, scope, alias)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prefix := strings.ToLower(strings.TrimLeft(p.Argument.Value, "@#"))
+	matches := []string{}
+	for _, v := range values {
+		if strings.HasPrefix(strings.ToLower(strings.TrimLeft(v, "@#")), prefix) {
+			matches = append(matches, v)
+		}
+	}
+	total := len(matches)
+	if total > maxCompletions {
+		matches = matches[:maxCompletions]
+	}
+	return &mcp.CompleteResult{Completion: mcp.CompletionResultDetails{
+		Values:  matches,
+		Total:   total,
+		HasMore: total > len(matches),
+	}}, nil
+}
+
+func (s *Server) completeProjects(ctx context.Context, scope Scope) ([]string, error) {
+	projects, err := s.vault.ListProjects(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var aliases []string
+	for _, p := range projects {
+		if scope.AllowsProject(p.Alias) {
+			aliases = append(aliases, p.Alias)
+		}
+	}
+	return aliases, nil
+}
+
+// completeDates lists journal days newest first, for one project when the
+// client has already chosen it.
+func (s *Server) completeDates(ctx context.Context, scope Scope, alias string) ([]string, error) {
+	if alias != "" {
+		alias = "@" + strings.TrimPrefix(alias, "@")
+		if !scope.AllowsProject(alias) {
+			return nil, nil
+		}
+	} else if scope.Restricted() {
+		// The vault-wide date list would reveal other projects' activity.
+		return nil, nil
+	}
+	dates, err := s.vault.ListJournalDates(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(dates)
+	slices.Reverse(dates)
+	return dates, nil
+}
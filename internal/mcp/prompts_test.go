@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func promptText(t *testing.T, res *mcp.GetPromptResult) string {
+	t.Helper()
+	if len(res.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(res.Messages))
+	}
+	tc, ok := res.Messages[0].Content.(*mcp.TextContent)
+	if !ok {
+		t.Fatalf("content = %#v; want text", res.Messages[0].Content)
+	}
+	return tc.Text
+}
+
+func TestPrompts(t *testing.T) {
+	today := time.Now().Format(dateLayout)
+	fv := &fakeVault{
+		projects: []ProjectInfo{{Alias: "@work"}, {Alias: "@home"}},
+		docs: []DocumentInfo{
+			{Path: "projects/@work/doc-old.json", Title: "Old", Updated: "2026-01-01T00:00:00Z"},
+			{Path: "projects/@work/doc-new.json", Title: "New", Updated: "2026-07-01T00:00:00Z"},
+		},
+		doc:     DocumentContent{Markdown: "# Body\n"},
+		dates:   []string{"2020-01-01", today},
+		entries: []JournalEntryInfo{{ID: "e1", Content: "shipped the importer", Tags: []string{"release"}}},
+		allEntries: []ProjectJournalEntry{
+			{ProjectAlias: "@work", JournalEntryInfo: JournalEntryInfo{Content: "reviewed PRs", Created: "2026-07-02T09:00:00Z"}},
+			{ProjectAlias: "@home", JournalEntryInfo: JournalEntryInfo{Content: "fixed the sink", Created: "2026-07-03T08:00:00Z"}},
+		},
+		tagged: []DocumentInfo{{Path: "projects/@work/doc-1.json", Title: "Tagged", ProjectAlias: "@work"}},
+		tags:   []string{"golang", "go-live", "release"},
+	}
+	cs, _ := connect(t, NewServer(fv, "test"))
+	ctx := context.Background()
+
+	list, err := cs.ListPrompts(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Prompts) != 4 {
+		t.Fatalf("got %d prompts, want 4", len(list.Prompts))
+	}
+
+	res, err := cs.GetPrompt(ctx, &mcp.GetPromptParams{Name: "summarize_project", Arguments: map[string]string{"project_alias": "work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := promptText(t, res)
+	if !strings.Contains(text, "@work") || strings.Index(text, "### New") > strings.Index(text, "### Old") {
+		t.Errorf("documents missing or not newest first:\n%s", text)
+	}
+	if !strings.Contains(text, "### "+today) || strings.Contains(text, "2020-01-01") || !strings.Contains(text, "shipped the importer (#release)") {
+		t.Errorf("journal not limited to recent days:\n%s", text)
+	}
+
+	res, err = cs.GetPrompt(ctx, &mcp.GetPromptParams{Name: "daily_standup", Arguments: map[string]string{"date": "2026-07-03"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text = promptText(t, res)
+	if !strings.Contains(text, "Yesterday (2026-07-02)") || strings.Index(text, "reviewed PRs") > strings.Index(text, "fixed the sink") {
+		t.Errorf("stand-up:\n%s", text)
+	}
+
+	res, err = cs.GetPrompt(ctx, &mcp.GetPromptParams{Name: "review_tag", Arguments: map[string]string{"tag": "#release"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text = promptText(t, res); fv.gotTag != "release" || !strings.Contains(text, "projects/@work/doc-1.json") {
+		t.Errorf("review_tag for %q:\n%s", fv.gotTag, text)
+	}
+
+	res, err = cs.GetPrompt(ctx, &mcp.GetPromptParams{Name: "draft_from_journal", Arguments: map[string]string{"project_alias": "@work", "date": "2026-07-03", "title": "Release notes"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text = promptText(t, res); fv.gotDate != "2026-07-03" || !strings.Contains(text, `"Release notes"`) || !strings.Contains(text, "create_document") {
+		t.Errorf("draft_from_journal:\n%s", text)
+	}
+
+	if _, err := cs.GetPrompt(ctx, &mcp.GetPromptParams{Name: "summarize_project"}); err == nil {
+		t.Error("expected an error without project_alias")
+	}
+	if _, err := cs.GetPrompt(ctx, &mcp.GetPromptParams{Name: "daily_standup", Arguments: map[string]string{"date": "July 3"}}); err == nil {
+		t.Error("expected an error for a malformed date")
+	}
+}
+
+func TestPromptScope(t *testing.T) {
+	fv := &fakeVault{allEntries: []ProjectJournalEntry{
+		{ProjectAlias: "@work", JournalEntryInfo: JournalEntryInfo{Content: "reviewed PRs", Created: "2026-07-03T09:00:00Z"}},
+		{ProjectAlias: "@home", JournalEntryInfo: JournalEntryInfo{Content: "fixed the sink", Created: "2026-07-03T08:00:00Z"}},
+	}}
+	s := NewServer(fv, "test")
+	ctx := context.Background()
+	extra := scoped(Scope{Access: AccessRead, Projects: []string{"@work"}}).Extra
+
+	res, err := s.promptDailyStandup(ctx, &mcp.GetPromptRequest{Extra: extra, Params: &mcp.GetPromptParams{Arguments: map[string]string{"date": "2026-07-03"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := promptText(t, res); strings.Contains(text, "fixed the sink") || !strings.Contains(text, "reviewed PRs") {
+		t.Errorf("stand-up leaks other projects:\n%s", text)
+	}
+	_, err = s.promptSummarizeProject(ctx, &mcp.GetPromptRequest{Extra: extra, Params: &mcp.GetPromptParams{Arguments: map[string]string{"project_alias": "home"}}})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("summarize other project: got %v, want ErrForbidden", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	fv := &fakeVault{
+		projects: []ProjectInfo{{Alias: "@work"}, {Alias: "@web"}, {Alias: "@home"}},
+		tags:     []string{"golang", "go-live", "release"},
+		dates:    []string{"2026-07-01", "2026-07-03"},
+	}
+	cs, _ := connect(t, NewServer(fv, "test"))
+	ctx := context.Background()
+
+	complete := func(prompt, arg, value string, args map[string]string) []string {
+		t.Helper()
+		params := &mcp.CompleteParams{
+			Ref:      &mcp.CompleteReference{Type: "ref/prompt", Name: prompt},
+			Argument: mcp.CompleteParamsArgument{Name: arg, Value: value},
+		}
+		if args != nil {
+			params.Context = &mcp.CompleteContext{Arguments: args}
+		}
+		res, err := cs.Complete(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Completion.Values
+	}
+
+	if got := complete("summarize_project", "project_alias", "@w", nil); len(got) != 2 || got[0] != "@work" || got[1] != "@web" {
+		t.Errorf("project completions = %v", got)
+	}
+	if got := complete("review_tag", "tag", "#go", nil); len(got) != 2 {
+		t.Errorf("tag completions = %v", got)
+	}
+	if got := complete("draft_from_journal", "date", "2026-07", map[string]string{"project_alias": "work"}); len(got) != 2 || got[0] != "2026-07-03" {
+		t.Errorf("date completions = %v; want newest first", got)
+	}
+}
@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resource URIs. Documents are addressed by their vault path, journals by
+// project and day, projects by alias, assets (see assets.go) by project and
+// file name. Aliases appear without the leading @,
+// which URI template variables do not match.
+const (
+	docURIPrefix     = "yanta://doc/"
+	journalURIPrefix = "yanta://journal/"
+	projectURIPrefix = "yanta://project/"
+)
+
+// maxListedDocuments caps how many documents per project resources/list
+// returns; the rest stay reachable through the document template.
+const maxListedDocuments = 500
+
+// DocumentURI returns the resource URI of the document at path.
+func DocumentURI(path string) string {
+	return docURIPrefix + path
+}
+
+// JournalURI returns the resource URI of a project's journal for one day
+// (YYYY-MM-DD).
+func JournalURI(projectAlias, date string) string {
+	return journalURIPrefix + strings.TrimPrefix(projectAlias, "@") + "/" + date
+}
+
+// ProjectURI returns the resource URI of a project.
+func ProjectURI(projectAlias string) string {
+	return projectURIPrefix + strings.TrimPrefix(projectAlias, "@")
+}
+
+// resourceRef is a parsed resource URI. Alias carries the leading @ the vault
+// expects.
+type resourceRef struct {
+	kind  string // "doc" | "journal" | "project" | "asset"
+	path  string // document path, or asset file name
+	alias string
+	date  string
+}
+
+func parseResourceURI(uri string) (resourceRef, bool) {
+	switch {
+	case strings.HasPrefix(uri, docURIPrefix):
+		path := strings.TrimPrefix(uri, docURIPrefix)
+		return resourceRef{kind: "doc", path: path}, path != ""
+	case strings.HasPrefix(uri, journalURIPrefix):
+		alias, date, ok := strings.Cut(strings.TrimPrefix(uri, journalURIPrefix), "/")
+		if !ok || alias == "" || date == "" || strings.Contains(date, "/") {
+			return resourceRef{}, false
+		}
+		return resourceRef{kind: "journal", alias: "@" + alias, date: date}, true
+	case strings.HasPrefix(uri, assetURIPrefix):
+		alias, file, ok := parseAssetRef(uri)
+		return resourceRef{kind: "asset", alias: alias, path: file}, ok
+	case strings.HasPrefix(uri, projectURIPrefix):
+		alias := strings.TrimPrefix(uri, projectURIPrefix)
+		if alias == "" || strings.Contains(alias, "/") {
+			return resourceRef{}, false
+		}
+		return resourceRef{kind: "project", alias: "@" + alias}, true
+	}
+	return resourceRef{}, false
+}
+
+func (s *Server) registerResources() {
+	s.srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "document",
+		URITemplate: docURIPrefix + "{+path}",
+		Description: "A document as Markdown, by vault path (e.g. yanta://doc/projects/@work/doc-....json). Append #heading-slug to read one section.",
+		MIMEType:    "text/markdown",
+	}, s.readResource)
+
+	s.srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "journal",
+		URITemplate: journalURIPrefix + "{project}/{date}",
+		Description: "A project's journal entries for one day, as JSON. project is the alias without @, date is YYYY-MM-DD.",
+		MIMEType:    "application/json",
+	}, s.readResource)
+
+	s.srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "project",
+		URITemplate: projectURIPrefix + "{alias}",
+		Description: "A project and its documents, as JSON. alias is the project alias without @.",
+		MIMEType:    "application/json",
+	}, s.readResource)
+
+	s.srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "asset",
+		URITemplate: assetURIPrefix + "{project}/{file}",
+		Description: "An image or attachment as a blob. project is the alias without @, file is the <hash>.<ext> name from its /assets/ reference.",
+	}, s.readResource)
+
+	s.srv.AddReceivingMiddleware(s.listResourcesMiddleware)
+}
+
+// listResourcesMiddleware answers resources/list from the vault. The SDK's own
+// list only knows statically added resources, and the vault's documents come
+// and go.
+func (s *Server) listResourcesMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "resources/list" {
+			return next(ctx, method, req)
+		}
+		resources, err := s.listResources(ctx, scopeOf(req.GetExtra()))
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.ListResourcesResult{Resources: resources}, nil
+	}
+}
+
+func (s *Server) listResources(ctx context.Context, scope Scope) ([]*mcp.Resource, error) {
+	projects, err := s.vault.ListProjects(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	resources := []*mcp.Resource{}
+	for _, p := range projects {
+		if !scope.AllowsProject(p.Alias) {
+			continue
+		}
+		resources = append(resources, &mcp.Resource{
+			URI:      ProjectURI(p.Alias),
+			Name:     p.Alias,
+			Title:    p.Name,
+			MIMEType: "application/json",
+		})
+		docs, err := s.vault.ListDocuments(ctx, p.Alias, false, maxListedDocuments, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range docs {
+			resources = append(resources, &mcp.Resource{
+				URI:      DocumentURI(d.Path),
+				Name:     d.Path,
+				Title:    d.Title,
+				MIMEType: "text/markdown",
+			})
+		}
+	}
+	return resources, nil
+}
+
+type journalResource struct {
+	ProjectAlias string             `json:"project_alias"`
+	Date         string             `json:"date"`
+	Entries      []JournalEntryInfo `json:"entries"`
+}
+
+type projectResource struct {
+	Project   ProjectInfo    `json:"project"`
+	Documents []DocumentInfo `json:"documents"`
+}
+
+func (s *Server) readResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	ref, ok := parseResourceURI(uri)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	scope := scopeOf(req.Extra)
+
+	switch ref.kind {
+	case "doc":
+		doc, err := s.vault.GetDocument(ctx, ref.path)
+		if err != nil {
+			return nil, err
+		}
+		if err := scope.requireProject(doc.ProjectAlias); err != nil {
+			return nil, err
+		}
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+			{URI: uri, MIMEType: "text/markdown", Text: doc.Markdown},
+		}}, nil
+
+	case "asset":
+		return s.readAssetResource(ctx, scope, uri, ref.alias, ref.path)
+
+	case "journal":
+		if err := scope.requireProject(ref.alias); err != nil {
+			return nil, err
+		}
+		entries, err := s.vault.ReadJournal(ctx, ref.alias, ref.date, false)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResource(uri, journalResource{ProjectAlias: ref.alias, Date: ref.date, Entries: entries})
+
+	default:
+		if err := scope.requireProject(ref.alias); err != nil {
+			return nil, err
+		}
+		projects, err := s.vault.ListProjects(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			if p.Alias != ref.alias {
+				continue
+			}
+			docs, err := s.vault.ListDocuments(ctx, p.Alias, false, maxListedDocuments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return jsonResource(uri, projectResource{Project: p, Documents: docs})
+		}
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+}
+
+func jsonResource(uri string, v any) (*mcp.ReadResourceResult, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", uri, err)
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: uri, MIMEType: "application/json", Text: string(data)},
+	}}, nil
+}
+
+// subscribe accepts subscriptions to any URI the templates cover that the
+// token may read; the SDK keeps track of which session is subscribed to what.
+func (s *Server) subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	ref, ok := parseResourceURI(req.Params.URI)
+	if !ok {
+		return mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	scope := scopeOf(req.Extra)
+	if ref.kind == "doc" {
+		return s.requireDocument(ctx, scope, ref.path)
+	}
+	return scope.requireProject(ref.alias)
+}
+
+func (s *Server) unsubscribe(context.Context, *mcp.UnsubscribeRequest) error {
+	return nil
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated for uri to the
+// sessions subscribed to it.
+func (s *Server) NotifyResourceUpdated(ctx context.Context, uri string) error {
+	return s.srv.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri})
+}
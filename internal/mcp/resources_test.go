@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connect runs s over in-memory transports and returns a client session.
+// Resource updates the client receives are sent on updates.
+func connect(t *testing.T, s *Server) (*mcp.ClientSession, <-chan string) {
+	t.Helper()
+	ctx := context.Background()
+	updates := make(chan string, 8)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test"}, &mcp.ClientOptions{
+		ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updates <- req.Params.URI
+		},
+	})
+	st, ct := mcp.NewInMemoryTransports()
+	ss, err := s.srv.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cs.Close()
+		_ = ss.Wait()
+	})
+	return cs, updates
+}
+
+func TestResourceURIs(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want resourceRef
+	}{
+		{DocumentURI("projects/@work/doc-1.json"), resourceRef{kind: "doc", path: "projects/@work/doc-1.json"}},
+		{JournalURI("@work", "2026-07-03"), resourceRef{kind: "journal", alias: "@work", date: "2026-07-03"}},
+		{ProjectURI("@work"), resourceRef{kind: "project", alias: "@work"}},
+		{AssetURI("@work", "abc.png"), resourceRef{kind: "asset", alias: "@work", path: "abc.png"}},
+	}
+	for _, tt := range tests {
+		got, ok := parseResourceURI(tt.uri)
+		if !ok || got != tt.want {
+			t.Errorf("parseResourceURI(%q) = %+v, %v; want %+v", tt.uri, got, ok, tt.want)
+		}
+	}
+
+	for _, uri := range []string{"yanta://doc/", "yanta://journal/work", "yanta://project/a/b", "yanta://asset/work", "yanta://asset/work/a/b.png", "file:///etc/passwd"} {
+		if _, ok := parseResourceURI(uri); ok {
+			t.Errorf("parseResourceURI(%q) should fail", uri)
+		}
+	}
+}
+
+func TestResources_ListAndRead(t *testing.T) {
+	fv := &fakeVault{
+		projects: []ProjectInfo{{ID: "p1", Name: "Work", Alias: "@work"}},
+		docs:     []DocumentInfo{{Path: "projects/@work/doc-1.json", Title: "Plan", ProjectAlias: "@work"}},
+		doc:      DocumentContent{Path: "projects/@work/doc-1.json", Markdown: "# Plan\n"},
+		entries:  []JournalEntryInfo{{ID: "e1", Content: "standup"}},
+	}
+	cs, _ := connect(t, NewServer(fv, "test"))
+	ctx := context.Background()
+
+	list, err := cs.ListResources(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 2 || list.Resources[0].URI != "yanta://project/work" ||
+		list.Resources[1].URI != "yanta://doc/projects/@work/doc-1.json" {
+		t.Fatalf("unexpected resources: %+v", list.Resources)
+	}
+
+	templates, err := cs.ListResourceTemplates(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates.ResourceTemplates) != 4 {
+		t.Fatalf("got %d templates, want 4", len(templates.ResourceTemplates))
+	}
+
+	res, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "yanta://doc/projects/@work/doc-1.json#setup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.gotPath != "projects/@work/doc-1.json#setup" || res.Contents[0].Text != "# Plan\n" ||
+		res.Contents[0].MIMEType != "text/markdown" {
+		t.Errorf("document read: path %q, contents %+v", fv.gotPath, res.Contents[0])
+	}
+
+	res, err = cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "yanta://journal/work/2026-07-03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var journal journalResource
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &journal); err != nil {
+		t.Fatal(err)
+	}
+	if fv.gotAlias != "@work" || fv.gotDate != "2026-07-03" || len(journal.Entries) != 1 {
+		t.Errorf("journal read: alias %q, date %q, %+v", fv.gotAlias, fv.gotDate, journal)
+	}
+
+	res, err = cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "yanta://project/work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proj projectResource
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &proj); err != nil {
+		t.Fatal(err)
+	}
+	if proj.Project.ID != "p1" || len(proj.Documents) != 1 {
+		t.Errorf("project read: %+v", proj)
+	}
+
+	if _, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "yanta://project/other"}); err == nil {
+		t.Error("expected an error for an unknown project")
+	}
+}
+
+func TestResources_Subscribe(t *testing.T) {
+	s := NewServer(&fakeVault{}, "test")
+	cs, updates := connect(t, s)
+	ctx := context.Background()
+
+	doc := DocumentURI("projects/@work/doc-1.json")
+	if err := cs.Subscribe(ctx, &mcp.SubscribeParams{URI: doc}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Subscribe(ctx, &mcp.SubscribeParams{URI: "file:///etc/passwd"}); err == nil {
+		t.Error("expected subscribing to a foreign URI to fail")
+	}
+
+	if err := s.NotifyResourceUpdated(ctx, JournalURI("@work", "2026-07-03")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.NotifyResourceUpdated(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case uri := <-updates:
+		if uri != doc {
+			t.Errorf("got update for %q, want only %q", uri, doc)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resource update received")
+	}
+
+	if err := cs.Unsubscribe(ctx, &mcp.UnsubscribeParams{URI: doc}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.NotifyResourceUpdated(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case uri := <-updates:
+		t.Errorf("got update for %q after unsubscribing", uri)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -11,21 +12,32 @@ import (
 const (
 	defaultSearchLimit = 20
 	defaultListLimit   = 50
+	// scopedSearchPage is how many hits a project-scoped search reads at a
+	// time while collecting the ones its token may see.
+	scopedSearchPage = 100
 )
 
-// Server registers Yanta's vault tools on an MCP server and serves them over a
-// Streamable HTTP transport.
+// Server registers Yanta's vault tools, resources and prompts on an MCP
+// server and serves them over a Streamable HTTP transport.
 type Server struct {
 	vault Vault
 	srv   *mcp.Server
+	audit func(AuditEntry)
 }
 
 // NewServer builds an MCP server exposing the vault. version is reported to
 // clients during initialization.
 func NewServer(v Vault, version string) *Server {
 	s := &Server{vault: v}
-	s.srv = mcp.NewServer(&mcp.Implementation{Name: "yanta", Version: version}, nil)
+	s.srv = mcp.NewServer(&mcp.Implementation{Name: "yanta", Version: version}, &mcp.ServerOptions{
+		SubscribeHandler:   s.subscribe,
+		UnsubscribeHandler: s.unsubscribe,
+		CompletionHandler:  s.complete,
+	})
 	s.register()
+	s.registerResources()
+	s.registerPrompts()
+	s.srv.AddReceivingMiddleware(s.auditMiddleware)
 	return s
 }
 
@@ -36,10 +48,17 @@ func (s *Server) StreamableHandler() http.Handler {
 }
 
 // Handler returns the MCP HTTP handler wrapped with bearer-token auth, an
-// anti-DNS-rebinding origin check, and an inbound body-size cap. An empty token
-// disables token auth (not recommended outside tests).
-func (s *Server) Handler(token string) http.Handler {
-	return withAuth(withBodyLimit(s.StreamableHandler()), token)
+// anti-DNS-rebinding origin check, and an inbound body-size cap. A nil
+// authenticator disables token auth (not recommended outside tests).
+func (s *Server) Handler(authn Authenticator) http.Handler {
+	return withAuth(withBodyLimit(s.StreamableHandler()), authn)
+}
+
+// RunStdio serves MCP over stdin/stdout until the client disconnects or ctx is
+// done. The stdio client is the process owner, so its requests carry no token
+// and have full access.
+func (s *Server) RunStdio(ctx context.Context) error {
+	return s.srv.Run(ctx, &mcp.StdioTransport{})
 }
 
 // maxRequestBytes caps the size of an inbound MCP request body. Client->server
@@ -79,12 +98,12 @@ func (s *Server) register() {
 
 	mcp.AddTool(s.srv, &mcp.Tool{
 		Name:        "get_document",
-		Description: "Read a single document, returning its body as Markdown.",
+		Description: "Read a single document, returning its body as Markdown and its heading outline. Append '#' and a heading slug from the outline to the path to read just that section.",
 	}, s.handleGetDocument)
 
 	mcp.AddTool(s.srv, &mcp.Tool{
 		Name:        "read_journal",
-		Description: "Read the journal entries for a project on a given day (defaults to today).",
+		Description: "Read the journal entries for a project on a given day (defaults to today). Each entry's id addresses it in the journal editing tools.",
 	}, s.handleReadJournal)
 
 	mcp.AddTool(s.srv, &mcp.Tool{
@@ -92,6 +111,11 @@ func (s *Server) register() {
 		Description: "List the dates (YYYY-MM-DD) that have journal entries, optionally restricted to one project.",
 	}, s.handleListJournalDates)
 
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "search_journal",
+		Description: "Search one project's journal entries across all days (case-insensitive substring match), returning each hit's date and entry id.",
+	}, s.handleSearchJournal)
+
 	mcp.AddTool(s.srv, &mcp.Tool{
 		Name:        "list_tags",
 		Description: "List all tags defined in the vault.",
@@ -104,9 +128,34 @@ func (s *Server) register() {
 
 	mcp.AddTool(s.srv, &mcp.Tool{
 		Name:        "update_document",
-		Description: "Update an existing document. Only the provided fields change; the body, when given, replaces the existing content.",
+		Description: "Update an existing document. Only the provided fields change; the body, when given, replaces the existing content. Pass expected_hash from get_document so a concurrent change is reported as a conflict instead of being overwritten.",
 	}, s.handleUpdateDocument)
 
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "insert_blocks",
+		Description: "Insert Markdown content directly after a block (by block_id) or a heading (by slug) without resending the document. Requires the hash from get_document; a document changed since is left untouched.",
+	}, s.handleInsertBlocks)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "append_blocks",
+		Description: "Append Markdown content to the end of a document. Requires the hash from get_document.",
+	}, s.handleAppendBlocks)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "replace_section",
+		Description: "Replace the content under a heading (up to the next heading of the same or a higher level) with Markdown, keeping the heading. Requires the hash from get_document.",
+	}, s.handleReplaceSection)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "delete_block",
+		Description: "Delete a block, with its nested blocks, by block_id or heading slug. Requires the hash from get_document.",
+	}, s.handleDeleteBlock)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "toggle_checklist_item",
+		Description: "Check or uncheck a checklist item by block_id. Requires the hash from get_document.",
+	}, s.handleToggleCheckListItem)
+
 	mcp.AddTool(s.srv, &mcp.Tool{
 		Name:        "move_document",
 		Description: "Move a document to a different project.",
@@ -122,6 +171,71 @@ func (s *Server) register() {
 		Description: "Append a plain-text entry to a project's journal (today by default, or a backdated date).",
 	}, s.handleAppendJournal)
 
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "update_journal_entry",
+		Description: "Replace the text of a journal entry, and optionally its tags. The entry is addressed by project, date and entry id from read_journal.",
+	}, s.handleUpdateJournalEntry)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "delete_journal_entry",
+		Description: "Soft-delete a journal entry. It can be brought back with restore_journal_entry.",
+	}, s.handleDeleteJournalEntry)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "restore_journal_entry",
+		Description: "Restore a soft-deleted journal entry. read_journal with include_deleted lists deleted entries.",
+	}, s.handleRestoreJournalEntry)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "promote_journal_entries",
+		Description: "Turn journal entries (by default all of a day's entries) into a new document. The entries are moved out of the journal unless keep_original is set.",
+	}, s.handlePromoteJournalEntries)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "get_asset",
+		Description: "Fetch an image or attachment referenced in a document, such as /assets/@work/<hash>.png. Images come back as image content, other files as an embedded blob. Files over 10 MiB are refused.",
+	}, s.handleGetAsset)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "upload_asset",
+		Description: "Store a base64-encoded image (png, jpg, gif or webp, up to 10 MiB) in a project. Returns its /assets/ reference and a Markdown image to paste into create_document or update_document.",
+	}, s.handleUploadAsset)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "create_project",
+		Description: "Create a project. The alias defaults to one derived from the name.",
+	}, s.handleCreateProject)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "update_project",
+		Description: "Change a project's name, dates or alias. Only the provided fields change. The alias can only be changed while the project has no documents, journal entries or assets.",
+	}, s.handleUpdateProject)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "archive_project",
+		Description: "Archive a project. Its documents stay in the vault; restore_project brings it back.",
+	}, s.handleArchiveProject)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "restore_project",
+		Description: "Restore an archived project.",
+	}, s.handleRestoreProject)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "create_tag",
+		Description: "Create a tag. Names are normalized to lowercase letters, digits, '-' and '_'.",
+	}, s.handleCreateTag)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "delete_tag",
+		Description: "Delete a tag from the tag list. Documents keep the tag until it is removed from them.",
+	}, s.handleDeleteTag)
+
+	mcp.AddTool(s.srv, &mcp.Tool{
+		Name:        "rename_tag",
+		Description: "Rename a tag on every document. If the new name is an existing tag, the two are merged.",
+	}, s.handleRenameTag)
+
 	mcp.AddTool(s.srv, &mcp.Tool{
 		Name:        "add_tags_to_document",
 		Description: "Add one or more tags to a document.",
@@ -144,7 +258,7 @@ type searchResult struct {
 	Hits []SearchHit `json:"hits"`
 }
 
-func (s *Server) handleSearch(ctx context.Context, _ *mcp.CallToolRequest, a searchArgs) (*mcp.CallToolResult, searchResult, error) {
+func (s *Server) handleSearch(ctx context.Context, req *mcp.CallToolRequest, a searchArgs) (*mcp.CallToolResult, searchResult, error) {
 	if a.Query == "" {
 		return nil, searchResult{}, fmt.Errorf("query is required")
 	}
@@ -156,13 +270,48 @@ func (s *Server) handleSearch(ctx context.Context, _ *mcp.CallToolRequest, a sea
 	if offset < 0 {
 		offset = 0
 	}
-	hits, err := s.vault.SearchNotes(ctx, a.Query, limit, offset)
+	var hits []SearchHit
+	var err error
+	if scope := toolScope(req); scope.Restricted() {
+		hits, err = s.searchScoped(ctx, scope, a.Query, limit, offset)
+	} else {
+		hits, err = s.vault.SearchNotes(ctx, a.Query, limit, offset)
+	}
 	if err != nil {
 		return nil, searchResult{}, err
 	}
 	return text(fmt.Sprintf("Found %d result(s).", len(hits))), searchResult{Hits: hits}, nil
 }
 
+// searchScoped pages through the search results until it has limit hits in
+// the scope's projects. The offset counts only those hits, so a scoped token
+// pages through what it can see, whatever other projects rank above it.
+func (s *Server) searchScoped(ctx context.Context, scope Scope, query string, limit, offset int) ([]SearchHit, error) {
+	hits := []SearchHit{}
+	for from := 0; ; from += scopedSearchPage {
+		page, err := s.vault.SearchNotes(ctx, query, scopedSearchPage, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range page {
+			if !scope.AllowsProject(h.ProjectAlias) {
+				continue
+			}
+			if offset > 0 {
+				offset--
+				continue
+			}
+			hits = append(hits, h)
+			if len(hits) == limit {
+				return hits, nil
+			}
+		}
+		if len(page) < scopedSearchPage {
+			return hits, nil
+		}
+	}
+}
+
 type listProjectsArgs struct {
 	IncludeArchived bool `json:"include_archived,omitempty" jsonschema:"Include archived projects (default false)."`
 }
@@ -170,11 +319,14 @@ type listProjectsResult struct {
 	Projects []ProjectInfo `json:"projects"`
 }
 
-func (s *Server) handleListProjects(ctx context.Context, _ *mcp.CallToolRequest, a listProjectsArgs) (*mcp.CallToolResult, listProjectsResult, error) {
+func (s *Server) handleListProjects(ctx context.Context, req *mcp.CallToolRequest, a listProjectsArgs) (*mcp.CallToolResult, listProjectsResult, error) {
 	projects, err := s.vault.ListProjects(ctx, a.IncludeArchived)
 	if err != nil {
 		return nil, listProjectsResult{}, err
 	}
+	if scope := toolScope(req); scope.Restricted() {
+		projects = slices.DeleteFunc(projects, func(p ProjectInfo) bool { return !scope.AllowsProject(p.Alias) })
+	}
 	return text(fmt.Sprintf("%d project(s).", len(projects))), listProjectsResult{Projects: projects}, nil
 }
 
@@ -188,10 +340,13 @@ type listDocumentsResult struct {
 	Documents []DocumentInfo `json:"documents"`
 }
 
-func (s *Server) handleListDocuments(ctx context.Context, _ *mcp.CallToolRequest, a listDocumentsArgs) (*mcp.CallToolResult, listDocumentsResult, error) {
+func (s *Server) handleListDocuments(ctx context.Context, req *mcp.CallToolRequest, a listDocumentsArgs) (*mcp.CallToolResult, listDocumentsResult, error) {
 	if a.ProjectAlias == "" {
 		return nil, listDocumentsResult{}, fmt.Errorf("project_alias is required")
 	}
+	if err := toolScope(req).requireProject(a.ProjectAlias); err != nil {
+		return nil, listDocumentsResult{}, err
+	}
 	limit := a.Limit
 	if limit <= 0 {
 		limit = defaultListLimit
@@ -208,10 +363,10 @@ func (s *Server) handleListDocuments(ctx context.Context, _ *mcp.CallToolRequest
 }
 
 type getDocumentArgs struct {
-	Path string `json:"path" jsonschema:"Document path as returned by list_documents or search_notes (e.g. 'projects/@work/doc-....json')."`
+	Path string `json:"path" jsonschema:"Document path as returned by list_documents or search_notes (e.g. 'projects/@work/doc-....json'), optionally followed by '#heading-slug' to read one section."`
 }
 
-func (s *Server) handleGetDocument(ctx context.Context, _ *mcp.CallToolRequest, a getDocumentArgs) (*mcp.CallToolResult, DocumentContent, error) {
+func (s *Server) handleGetDocument(ctx context.Context, req *mcp.CallToolRequest, a getDocumentArgs) (*mcp.CallToolResult, DocumentContent, error) {
 	if a.Path == "" {
 		return nil, DocumentContent{}, fmt.Errorf("path is required")
 	}
@@ -219,22 +374,32 @@ func (s *Server) handleGetDocument(ctx context.Context, _ *mcp.CallToolRequest,
 	if err != nil {
 		return nil, DocumentContent{}, err
 	}
-	return text(fmt.Sprintf("%q (%d chars).", doc.Title, len(doc.Markdown))), doc, nil
+	if err := toolScope(req).requireProject(doc.ProjectAlias); err != nil {
+		return nil, DocumentContent{}, err
+	}
+	if doc.Section != "" {
+		return text(fmt.Sprintf("%q, section %q (%d chars).", doc.Title, doc.Section, len(doc.Markdown))), doc, nil
+	}
+	return text(fmt.Sprintf("%q (%d chars, %d headings).", doc.Title, len(doc.Markdown), len(doc.Outline))), doc, nil
 }
 
 type readJournalArgs struct {
-	ProjectAlias string `json:"project_alias"`
-	Date         string `json:"date,omitempty" jsonschema:"Day as YYYY-MM-DD. Defaults to today."`
+	ProjectAlias   string `json:"project_alias"`
+	Date           string `json:"date,omitempty" jsonschema:"Day as YYYY-MM-DD. Defaults to today."`
+	IncludeDeleted bool   `json:"include_deleted,omitempty" jsonschema:"Also list soft-deleted entries, marked deleted (default false)."`
 }
 type readJournalResult struct {
 	Entries []JournalEntryInfo `json:"entries"`
 }
 
-func (s *Server) handleReadJournal(ctx context.Context, _ *mcp.CallToolRequest, a readJournalArgs) (*mcp.CallToolResult, readJournalResult, error) {
+func (s *Server) handleReadJournal(ctx context.Context, req *mcp.CallToolRequest, a readJournalArgs) (*mcp.CallToolResult, readJournalResult, error) {
 	if a.ProjectAlias == "" {
 		return nil, readJournalResult{}, fmt.Errorf("project_alias is required")
 	}
-	entries, err := s.vault.ReadJournal(ctx, a.ProjectAlias, a.Date)
+	if err := toolScope(req).requireProject(a.ProjectAlias); err != nil {
+		return nil, readJournalResult{}, err
+	}
+	entries, err := s.vault.ReadJournal(ctx, a.ProjectAlias, a.Date, a.IncludeDeleted)
 	if err != nil {
 		return nil, readJournalResult{}, err
 	}
@@ -248,20 +413,69 @@ type listJournalDatesResult struct {
 	Dates []string `json:"dates"`
 }
 
-func (s *Server) handleListJournalDates(ctx context.Context, _ *mcp.CallToolRequest, a listJournalDatesArgs) (*mcp.CallToolResult, listJournalDatesResult, error) {
-	dates, err := s.vault.ListJournalDates(ctx, a.ProjectAlias)
-	if err != nil {
-		return nil, listJournalDatesResult{}, err
+func (s *Server) handleListJournalDates(ctx context.Context, req *mcp.CallToolRequest, a listJournalDatesArgs) (*mcp.CallToolResult, listJournalDatesResult, error) {
+	scope := toolScope(req)
+	if a.ProjectAlias != "" || !scope.Restricted() {
+		if err := scope.requireProject(a.ProjectAlias); err != nil {
+			return nil, listJournalDatesResult{}, err
+		}
+		dates, err := s.vault.ListJournalDates(ctx, a.ProjectAlias)
+		if err != nil {
+			return nil, listJournalDatesResult{}, err
+		}
+		return text(fmt.Sprintf("%d date(s).", len(dates))), listJournalDatesResult{Dates: dates}, nil
 	}
+	// A project-scoped token sees the dates of its own projects only.
+	var dates []string
+	for _, alias := range scope.Projects {
+		projectDates, err := s.vault.ListJournalDates(ctx, alias)
+		if err != nil {
+			return nil, listJournalDatesResult{}, err
+		}
+		dates = append(dates, projectDates...)
+	}
+	slices.Sort(dates)
+	dates = slices.Compact(dates)
 	return text(fmt.Sprintf("%d date(s).", len(dates))), listJournalDatesResult{Dates: dates}, nil
 }
 
+type searchJournalArgs struct {
+	ProjectAlias string `json:"project_alias"`
+	Query        string `json:"query" jsonschema:"Text to look for in entry content."`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum number of results (default 20)."`
+}
+type searchJournalResult struct {
+	Hits []JournalHit `json:"hits"`
+}
+
+func (s *Server) handleSearchJournal(ctx context.Context, req *mcp.CallToolRequest, a searchJournalArgs) (*mcp.CallToolResult, searchJournalResult, error) {
+	if a.ProjectAlias == "" || a.Query == "" {
+		return nil, searchJournalResult{}, fmt.Errorf("project_alias and query are required")
+	}
+	if err := toolScope(req).requireProject(a.ProjectAlias); err != nil {
+		return nil, searchJournalResult{}, err
+	}
+	limit := a.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	hits, err := s.vault.SearchJournal(ctx, a.ProjectAlias, a.Query, limit)
+	if err != nil {
+		return nil, searchJournalResult{}, err
+	}
+	return text(fmt.Sprintf("Found %d journal entr(ies).", len(hits))), searchJournalResult{Hits: hits}, nil
+}
+
 type noArgs struct{}
 type listTagsResult struct {
 	Tags []string `json:"tags"`
 }
 
-func (s *Server) handleListTags(ctx context.Context, _ *mcp.CallToolRequest, _ noArgs) (*mcp.CallToolResult, listTagsResult, error) {
+func (s *Server) handleListTags(ctx context.Context, req *mcp.CallToolRequest, _ noArgs) (*mcp.CallToolResult, listTagsResult, error) {
+	// Tags are vault-wide, so their names would leak other projects.
+	if toolScope(req).Restricted() {
+		return nil, listTagsResult{}, fmt.Errorf("%w: list_tags is not available to a project-scoped token", ErrForbidden)
+	}
 	tags, err := s.vault.ListTags(ctx)
 	if err != nil {
 		return nil, listTagsResult{}, err
@@ -282,10 +496,13 @@ type documentRef struct {
 	Message string `json:"message,omitempty"`
 }
 
-func (s *Server) handleCreateDocument(ctx context.Context, _ *mcp.CallToolRequest, a createDocumentArgs) (*mcp.CallToolResult, documentRef, error) {
+func (s *Server) handleCreateDocument(ctx context.Context, req *mcp.CallToolRequest, a createDocumentArgs) (*mcp.CallToolResult, documentRef, error) {
 	if a.ProjectAlias == "" || a.Title == "" {
 		return nil, documentRef{}, fmt.Errorf("project_alias and title are required")
 	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, documentRef{}, err
+	}
 	path, err := s.vault.CreateDocument(ctx, a.ProjectAlias, a.Title, a.Markdown, a.Tags)
 	if err != nil {
 		return nil, documentRef{}, err
@@ -294,23 +511,157 @@ func (s *Server) handleCreateDocument(ctx context.Context, _ *mcp.CallToolReques
 }
 
 type updateDocumentArgs struct {
-	Path     string    `json:"path"`
-	Title    *string   `json:"title,omitempty" jsonschema:"New title. Omit to leave unchanged."`
-	Markdown *string   `json:"markdown,omitempty" jsonschema:"New body as Markdown, replacing the existing content. Omit to leave unchanged."`
-	Tags     *[]string `json:"tags,omitempty" jsonschema:"New complete tag set, replacing the existing tags. Omit to leave unchanged."`
+	Path         string    `json:"path"`
+	ExpectedHash string    `json:"expected_hash,omitempty" jsonschema:"The document hash from get_document. When given, the update is refused if the document has changed since."`
+	Title        *string   `json:"title,omitempty" jsonschema:"New title. Omit to leave unchanged."`
+	Markdown     *string   `json:"markdown,omitempty" jsonschema:"New body as Markdown, replacing the existing content. Omit to leave unchanged."`
+	Tags         *[]string `json:"tags,omitempty" jsonschema:"New complete tag set, replacing the existing tags. Omit to leave unchanged."`
 }
 
-func (s *Server) handleUpdateDocument(ctx context.Context, _ *mcp.CallToolRequest, a updateDocumentArgs) (*mcp.CallToolResult, opResult, error) {
+func (s *Server) handleUpdateDocument(ctx context.Context, req *mcp.CallToolRequest, a updateDocumentArgs) (*mcp.CallToolResult, EditResult, error) {
 	if a.Path == "" {
-		return nil, opResult{}, fmt.Errorf("path is required")
+		return nil, EditResult{}, fmt.Errorf("path is required")
 	}
 	if a.Title == nil && a.Markdown == nil && a.Tags == nil {
-		return nil, opResult{}, fmt.Errorf("nothing to update: provide at least one of title, markdown, tags")
+		return nil, EditResult{}, fmt.Errorf("nothing to update: provide at least one of title, markdown, tags")
 	}
-	if err := s.vault.UpdateDocument(ctx, a.Path, a.Title, a.Markdown, a.Tags); err != nil {
-		return nil, opResult{}, err
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, EditResult{}, err
+	}
+	res, err := s.vault.UpdateDocument(ctx, a.Path, a.ExpectedHash, a.Title, a.Markdown, a.Tags)
+	if err != nil {
+		return nil, EditResult{}, err
+	}
+	return text("Updated " + a.Path), res, nil
+}
+
+type insertBlocksArgs struct {
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expected_hash" jsonschema:"The document hash from get_document (or from the previous edit). The edit is refused if the document has changed since."`
+	BlockID      string `json:"block_id,omitempty" jsonschema:"Insert after this block. Give this or heading."`
+	Heading      string `json:"heading,omitempty" jsonschema:"Insert directly after the heading with this slug, at the start of its section. Give this or block_id."`
+	Markdown     string `json:"markdown" jsonschema:"Content to insert, as Markdown."`
+}
+
+func (s *Server) handleInsertBlocks(ctx context.Context, req *mcp.CallToolRequest, a insertBlocksArgs) (*mcp.CallToolResult, EditResult, error) {
+	if err := requireEdit(a.Path, a.ExpectedHash); err != nil {
+		return nil, EditResult{}, err
+	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, EditResult{}, err
+	}
+	if a.Markdown == "" {
+		return nil, EditResult{}, fmt.Errorf("markdown is required")
+	}
+	if (a.BlockID == "") == (a.Heading == "") {
+		return nil, EditResult{}, fmt.Errorf("exactly one of block_id or heading is required")
+	}
+	res, err := s.vault.InsertBlocks(ctx, a.Path, a.ExpectedHash, BlockTarget{BlockID: a.BlockID, Heading: a.Heading}, a.Markdown)
+	if err != nil {
+		return nil, EditResult{}, err
+	}
+	return text("Inserted into " + a.Path), res, nil
+}
+
+type appendBlocksArgs struct {
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expected_hash" jsonschema:"The document hash from get_document (or from the previous edit). The edit is refused if the document has changed since."`
+	Markdown     string `json:"markdown" jsonschema:"Content to append, as Markdown."`
+}
+
+func (s *Server) handleAppendBlocks(ctx context.Context, req *mcp.CallToolRequest, a appendBlocksArgs) (*mcp.CallToolResult, EditResult, error) {
+	if err := requireEdit(a.Path, a.ExpectedHash); err != nil {
+		return nil, EditResult{}, err
+	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, EditResult{}, err
+	}
+	if a.Markdown == "" {
+		return nil, EditResult{}, fmt.Errorf("markdown is required")
+	}
+	res, err := s.vault.AppendBlocks(ctx, a.Path, a.ExpectedHash, a.Markdown)
+	if err != nil {
+		return nil, EditResult{}, err
+	}
+	return text("Appended to " + a.Path), res, nil
+}
+
+type replaceSectionArgs struct {
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expected_hash" jsonschema:"The document hash from get_document (or from the previous edit). The edit is refused if the document has changed since."`
+	Heading      string `json:"heading" jsonschema:"Slug of the heading whose section is replaced, from the get_document outline."`
+	Markdown     string `json:"markdown" jsonschema:"New section content as Markdown, without the heading itself. Empty clears the section."`
+}
+
+func (s *Server) handleReplaceSection(ctx context.Context, req *mcp.CallToolRequest, a replaceSectionArgs) (*mcp.CallToolResult, EditResult, error) {
+	if err := requireEdit(a.Path, a.ExpectedHash); err != nil {
+		return nil, EditResult{}, err
+	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, EditResult{}, err
+	}
+	if a.Heading == "" {
+		return nil, EditResult{}, fmt.Errorf("heading is required")
+	}
+	res, err := s.vault.ReplaceSection(ctx, a.Path, a.ExpectedHash, a.Heading, a.Markdown)
+	if err != nil {
+		return nil, EditResult{}, err
+	}
+	return text("Replaced section " + a.Heading + " of " + a.Path), res, nil
+}
+
+type deleteBlockArgs struct {
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expected_hash" jsonschema:"The document hash from get_document (or from the previous edit). The edit is refused if the document has changed since."`
+	BlockID      string `json:"block_id,omitempty" jsonschema:"Block to delete. Give this or heading."`
+	Heading      string `json:"heading,omitempty" jsonschema:"Slug of a heading to delete (the heading block only, not its section). Give this or block_id."`
+}
+
+func (s *Server) handleDeleteBlock(ctx context.Context, req *mcp.CallToolRequest, a deleteBlockArgs) (*mcp.CallToolResult, EditResult, error) {
+	if err := requireEdit(a.Path, a.ExpectedHash); err != nil {
+		return nil, EditResult{}, err
+	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, EditResult{}, err
+	}
+	if (a.BlockID == "") == (a.Heading == "") {
+		return nil, EditResult{}, fmt.Errorf("exactly one of block_id or heading is required")
+	}
+	res, err := s.vault.DeleteBlock(ctx, a.Path, a.ExpectedHash, BlockTarget{BlockID: a.BlockID, Heading: a.Heading})
+	if err != nil {
+		return nil, EditResult{}, err
+	}
+	return text("Deleted a block from " + a.Path), res, nil
+}
+
+type toggleCheckListItemArgs struct {
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expected_hash" jsonschema:"The document hash from get_document (or from the previous edit). The edit is refused if the document has changed since."`
+	BlockID      string `json:"block_id" jsonschema:"The checklist item's block ID, from the get_document blocks list."`
+}
+
+func (s *Server) handleToggleCheckListItem(ctx context.Context, req *mcp.CallToolRequest, a toggleCheckListItemArgs) (*mcp.CallToolResult, EditResult, error) {
+	if err := requireEdit(a.Path, a.ExpectedHash); err != nil {
+		return nil, EditResult{}, err
+	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, EditResult{}, err
+	}
+	if a.BlockID == "" {
+		return nil, EditResult{}, fmt.Errorf("block_id is required")
 	}
-	return text("Updated " + a.Path), opResult{OK: true, Message: "updated"}, nil
+	res, err := s.vault.ToggleCheckListItem(ctx, a.Path, a.ExpectedHash, a.BlockID)
+	if err != nil {
+		return nil, EditResult{}, err
+	}
+	return text("Toggled " + a.BlockID + " in " + a.Path), res, nil
+}
+
+func requireEdit(path, expectedHash string) error {
+	if path == "" || expectedHash == "" {
+		return fmt.Errorf("path and expected_hash are required")
+	}
+	return nil
 }
 
 type moveDocumentArgs struct {
@@ -318,10 +669,17 @@ type moveDocumentArgs struct {
 	TargetProject string `json:"target_project" jsonschema:"Alias of the destination project (without @)."`
 }
 
-func (s *Server) handleMoveDocument(ctx context.Context, _ *mcp.CallToolRequest, a moveDocumentArgs) (*mcp.CallToolResult, opResult, error) {
+func (s *Server) handleMoveDocument(ctx context.Context, req *mcp.CallToolRequest, a moveDocumentArgs) (*mcp.CallToolResult, opResult, error) {
 	if a.Path == "" || a.TargetProject == "" {
 		return nil, opResult{}, fmt.Errorf("path and target_project are required")
 	}
+	scope := toolScope(req)
+	if err := s.requireDocumentWrite(ctx, scope, a.Path); err != nil {
+		return nil, opResult{}, err
+	}
+	if err := scope.requireProject(a.TargetProject); err != nil {
+		return nil, opResult{}, err
+	}
 	if err := s.vault.MoveDocument(ctx, a.Path, a.TargetProject); err != nil {
 		return nil, opResult{}, err
 	}
@@ -333,10 +691,19 @@ type deleteDocumentArgs struct {
 	Hard bool   `json:"hard,omitempty" jsonschema:"If true, permanently delete the file and record. If false (default), soft-delete (recoverable)."`
 }
 
-func (s *Server) handleDeleteDocument(ctx context.Context, _ *mcp.CallToolRequest, a deleteDocumentArgs) (*mcp.CallToolResult, opResult, error) {
+func (s *Server) handleDeleteDocument(ctx context.Context, req *mcp.CallToolRequest, a deleteDocumentArgs) (*mcp.CallToolResult, opResult, error) {
 	if a.Path == "" {
 		return nil, opResult{}, fmt.Errorf("path is required")
 	}
+	scope := toolScope(req)
+	if a.Hard {
+		if err := scope.require(AccessAdmin); err != nil {
+			return nil, opResult{}, err
+		}
+	}
+	if err := s.requireDocumentWrite(ctx, scope, a.Path); err != nil {
+		return nil, opResult{}, err
+	}
 	if err := s.vault.DeleteDocument(ctx, a.Path, a.Hard); err != nil {
 		return nil, opResult{}, err
 	}
@@ -354,10 +721,13 @@ type appendJournalArgs struct {
 	Date         string   `json:"date,omitempty" jsonschema:"Backdate the entry to YYYY-MM-DD. Defaults to today."`
 }
 
-func (s *Server) handleAppendJournal(ctx context.Context, _ *mcp.CallToolRequest, a appendJournalArgs) (*mcp.CallToolResult, JournalEntryInfo, error) {
+func (s *Server) handleAppendJournal(ctx context.Context, req *mcp.CallToolRequest, a appendJournalArgs) (*mcp.CallToolResult, JournalEntryInfo, error) {
 	if a.ProjectAlias == "" || a.Content == "" {
 		return nil, JournalEntryInfo{}, fmt.Errorf("project_alias and content are required")
 	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, JournalEntryInfo{}, err
+	}
 	entry, err := s.vault.AppendJournal(ctx, a.ProjectAlias, a.Content, a.Tags, a.Date)
 	if err != nil {
 		return nil, JournalEntryInfo{}, err
@@ -365,31 +735,296 @@ func (s *Server) handleAppendJournal(ctx context.Context, _ *mcp.CallToolRequest
 	return text("Appended journal entry " + entry.ID), entry, nil
 }
 
+// journalEntryArgs addresses one journal entry.
+type journalEntryArgs struct {
+	ProjectAlias string `json:"project_alias"`
+	Date         string `json:"date" jsonschema:"The entry's day as YYYY-MM-DD."`
+	EntryID      string `json:"entry_id" jsonschema:"The entry id from read_journal or search_journal."`
+}
+
+func (a journalEntryArgs) validate() error {
+	if a.ProjectAlias == "" || a.Date == "" || a.EntryID == "" {
+		return fmt.Errorf("project_alias, date and entry_id are required")
+	}
+	return nil
+}
+
+// journalEntryResult identifies the entry a journal tool changed and, for
+// updates, its new content.
+type journalEntryResult struct {
+	ProjectAlias string            `json:"project_alias"`
+	Date         string            `json:"date"`
+	EntryID      string            `json:"entry_id"`
+	Entry        *JournalEntryInfo `json:"entry,omitempty"`
+}
+
+func (a journalEntryArgs) result(entry *JournalEntryInfo) journalEntryResult {
+	return journalEntryResult{ProjectAlias: a.ProjectAlias, Date: a.Date, EntryID: a.EntryID, Entry: entry}
+}
+
+type updateJournalEntryArgs struct {
+	journalEntryArgs
+	Content string    `json:"content" jsonschema:"New entry text (plain text, max 10000 chars)."`
+	Tags    *[]string `json:"tags,omitempty" jsonschema:"New tags. Omit to keep the current ones; an empty list clears them."`
+}
+
+func (s *Server) handleUpdateJournalEntry(ctx context.Context, req *mcp.CallToolRequest, a updateJournalEntryArgs) (*mcp.CallToolResult, journalEntryResult, error) {
+	if err := a.validate(); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	if a.Content == "" {
+		return nil, journalEntryResult{}, fmt.Errorf("content is required")
+	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	entry, err := s.vault.UpdateJournalEntry(ctx, a.ProjectAlias, a.Date, a.EntryID, a.Content, a.Tags)
+	if err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	return text("Updated journal entry " + a.EntryID), a.result(&entry), nil
+}
+
+func (s *Server) handleDeleteJournalEntry(ctx context.Context, req *mcp.CallToolRequest, a journalEntryArgs) (*mcp.CallToolResult, journalEntryResult, error) {
+	if err := a.validate(); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	if err := s.vault.DeleteJournalEntry(ctx, a.ProjectAlias, a.Date, a.EntryID); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	return text("Soft-deleted journal entry " + a.EntryID), a.result(nil), nil
+}
+
+func (s *Server) handleRestoreJournalEntry(ctx context.Context, req *mcp.CallToolRequest, a journalEntryArgs) (*mcp.CallToolResult, journalEntryResult, error) {
+	if err := a.validate(); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	if err := s.vault.RestoreJournalEntry(ctx, a.ProjectAlias, a.Date, a.EntryID); err != nil {
+		return nil, journalEntryResult{}, err
+	}
+	return text("Restored journal entry " + a.EntryID), a.result(nil), nil
+}
+
+type promoteJournalEntriesArgs struct {
+	ProjectAlias  string   `json:"project_alias" jsonschema:"Project whose journal the entries are in."`
+	Date          string   `json:"date" jsonschema:"The entries' day as YYYY-MM-DD."`
+	EntryIDs      []string `json:"entry_ids,omitempty" jsonschema:"Entries to promote, in order. Omit for all of the day's active entries."`
+	Title         string   `json:"title" jsonschema:"Title of the new document."`
+	TargetProject string   `json:"target_project,omitempty" jsonschema:"Project to create the document in. Defaults to project_alias."`
+	KeepOriginal  bool     `json:"keep_original,omitempty" jsonschema:"Keep the entries in the journal instead of soft-deleting them (default false)."`
+}
+
+func (s *Server) handlePromoteJournalEntries(ctx context.Context, req *mcp.CallToolRequest, a promoteJournalEntriesArgs) (*mcp.CallToolResult, JournalPromotionResult, error) {
+	if a.ProjectAlias == "" || a.Date == "" || a.Title == "" {
+		return nil, JournalPromotionResult{}, fmt.Errorf("project_alias, date and title are required")
+	}
+	target := a.TargetProject
+	if target == "" {
+		target = a.ProjectAlias
+	}
+	// Write access to the target covers soft-deleting the originals, which
+	// only needs the source project to be in scope as well.
+	scope := toolScope(req)
+	if err := requireProjectWrite(scope, target); err != nil {
+		return nil, JournalPromotionResult{}, err
+	}
+	if err := scope.requireProject(a.ProjectAlias); err != nil {
+		return nil, JournalPromotionResult{}, err
+	}
+	res, err := s.vault.PromoteJournalEntries(ctx, JournalPromotion{
+		SourceProject: a.ProjectAlias,
+		Date:          a.Date,
+		EntryIDs:      a.EntryIDs,
+		TargetProject: target,
+		Title:         a.Title,
+		KeepOriginal:  a.KeepOriginal,
+	})
+	if err != nil {
+		return nil, JournalPromotionResult{}, err
+	}
+	return text(fmt.Sprintf("Promoted %d journal entr(ies) to %s", len(res.EntryIDs), res.Path)), res, nil
+}
+
 type docTagsArgs struct {
 	Path string   `json:"path"`
 	Tags []string `json:"tags" jsonschema:"Tag names (lowercase alphanumeric, plus _ and -)."`
 }
 
-func (s *Server) handleAddTags(ctx context.Context, _ *mcp.CallToolRequest, a docTagsArgs) (*mcp.CallToolResult, opResult, error) {
+func (s *Server) handleAddTags(ctx context.Context, req *mcp.CallToolRequest, a docTagsArgs) (*mcp.CallToolResult, opResult, error) {
 	if a.Path == "" || len(a.Tags) == 0 {
 		return nil, opResult{}, fmt.Errorf("path and at least one tag are required")
 	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, opResult{}, err
+	}
 	if err := s.vault.AddTagsToDocument(ctx, a.Path, a.Tags); err != nil {
 		return nil, opResult{}, err
 	}
 	return text("Tagged " + a.Path), opResult{OK: true, Message: "tags added"}, nil
 }
 
-func (s *Server) handleRemoveTags(ctx context.Context, _ *mcp.CallToolRequest, a docTagsArgs) (*mcp.CallToolResult, opResult, error) {
+func (s *Server) handleRemoveTags(ctx context.Context, req *mcp.CallToolRequest, a docTagsArgs) (*mcp.CallToolResult, opResult, error) {
 	if a.Path == "" || len(a.Tags) == 0 {
 		return nil, opResult{}, fmt.Errorf("path and at least one tag are required")
 	}
+	if err := s.requireDocumentWrite(ctx, toolScope(req), a.Path); err != nil {
+		return nil, opResult{}, err
+	}
 	if err := s.vault.RemoveTagsFromDocument(ctx, a.Path, a.Tags); err != nil {
 		return nil, opResult{}, err
 	}
 	return text("Untagged " + a.Path), opResult{OK: true, Message: "tags removed"}, nil
 }
 
+// --- administration handlers ---
+
+type createProjectArgs struct {
+	Name      string `json:"name"`
+	Alias     string `json:"alias,omitempty" jsonschema:"Alias, with or without @: 2-32 lowercase letters, digits and hyphens. Defaults to one derived from the name."`
+	StartDate string `json:"start_date,omitempty" jsonschema:"YYYY-MM-DD."`
+	EndDate   string `json:"end_date,omitempty" jsonschema:"YYYY-MM-DD."`
+}
+
+func (s *Server) handleCreateProject(ctx context.Context, req *mcp.CallToolRequest, a createProjectArgs) (*mcp.CallToolResult, ProjectInfo, error) {
+	if a.Name == "" {
+		return nil, ProjectInfo{}, fmt.Errorf("name is required")
+	}
+	if err := requireVaultWrite(toolScope(req), "create_project"); err != nil {
+		return nil, ProjectInfo{}, err
+	}
+	p, err := s.vault.CreateProject(ctx, a.Name, a.Alias, a.StartDate, a.EndDate)
+	if err != nil {
+		return nil, ProjectInfo{}, err
+	}
+	return text("Created project " + p.Alias), p, nil
+}
+
+type updateProjectArgs struct {
+	ProjectAlias string  `json:"project_alias"`
+	Name         *string `json:"name,omitempty"`
+	Alias        *string `json:"alias,omitempty" jsonschema:"New alias. Only possible while the project has no content."`
+	StartDate    *string `json:"start_date,omitempty" jsonschema:"YYYY-MM-DD, or empty to clear."`
+	EndDate      *string `json:"end_date,omitempty" jsonschema:"YYYY-MM-DD, or empty to clear."`
+}
+
+func (s *Server) handleUpdateProject(ctx context.Context, req *mcp.CallToolRequest, a updateProjectArgs) (*mcp.CallToolResult, ProjectInfo, error) {
+	if a.ProjectAlias == "" {
+		return nil, ProjectInfo{}, fmt.Errorf("project_alias is required")
+	}
+	if a.Name == nil && a.Alias == nil && a.StartDate == nil && a.EndDate == nil {
+		return nil, ProjectInfo{}, fmt.Errorf("provide at least one of name, alias, start_date, end_date")
+	}
+	scope := toolScope(req)
+	if err := requireProjectWrite(scope, a.ProjectAlias); err != nil {
+		return nil, ProjectInfo{}, err
+	}
+	if a.Alias != nil {
+		if err := scope.requireProject(*a.Alias); err != nil {
+			return nil, ProjectInfo{}, err
+		}
+	}
+	p, err := s.vault.UpdateProject(ctx, a.ProjectAlias, ProjectPatch{
+		Name:      a.Name,
+		Alias:     a.Alias,
+		StartDate: a.StartDate,
+		EndDate:   a.EndDate,
+	})
+	if err != nil {
+		return nil, ProjectInfo{}, err
+	}
+	return text("Updated project " + p.Alias), p, nil
+}
+
+type projectArgs struct {
+	ProjectAlias string `json:"project_alias"`
+}
+
+func (s *Server) handleArchiveProject(ctx context.Context, req *mcp.CallToolRequest, a projectArgs) (*mcp.CallToolResult, opResult, error) {
+	if a.ProjectAlias == "" {
+		return nil, opResult{}, fmt.Errorf("project_alias is required")
+	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, opResult{}, err
+	}
+	if err := s.vault.ArchiveProject(ctx, a.ProjectAlias); err != nil {
+		return nil, opResult{}, err
+	}
+	return text("Archived " + a.ProjectAlias), opResult{OK: true, Message: "archived"}, nil
+}
+
+func (s *Server) handleRestoreProject(ctx context.Context, req *mcp.CallToolRequest, a projectArgs) (*mcp.CallToolResult, opResult, error) {
+	if a.ProjectAlias == "" {
+		return nil, opResult{}, fmt.Errorf("project_alias is required")
+	}
+	if err := requireProjectWrite(toolScope(req), a.ProjectAlias); err != nil {
+		return nil, opResult{}, err
+	}
+	if err := s.vault.RestoreProject(ctx, a.ProjectAlias); err != nil {
+		return nil, opResult{}, err
+	}
+	return text("Restored " + a.ProjectAlias), opResult{OK: true, Message: "restored"}, nil
+}
+
+type tagArgs struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) handleCreateTag(ctx context.Context, req *mcp.CallToolRequest, a tagArgs) (*mcp.CallToolResult, opResult, error) {
+	if a.Name == "" {
+		return nil, opResult{}, fmt.Errorf("name is required")
+	}
+	if err := requireVaultWrite(toolScope(req), "create_tag"); err != nil {
+		return nil, opResult{}, err
+	}
+	name, err := s.vault.CreateTag(ctx, a.Name)
+	if err != nil {
+		return nil, opResult{}, err
+	}
+	return text("Created tag " + name), opResult{OK: true, Message: name}, nil
+}
+
+func (s *Server) handleDeleteTag(ctx context.Context, req *mcp.CallToolRequest, a tagArgs) (*mcp.CallToolResult, opResult, error) {
+	if a.Name == "" {
+		return nil, opResult{}, fmt.Errorf("name is required")
+	}
+	if err := requireVaultWrite(toolScope(req), "delete_tag"); err != nil {
+		return nil, opResult{}, err
+	}
+	if err := s.vault.DeleteTag(ctx, a.Name); err != nil {
+		return nil, opResult{}, err
+	}
+	return text("Deleted tag " + a.Name), opResult{OK: true, Message: "deleted"}, nil
+}
+
+type renameTagArgs struct {
+	From string `json:"from"`
+	To   string `json:"to" jsonschema:"New name. An existing tag of that name absorbs the old one."`
+}
+type renameTagResult struct {
+	Tag       string `json:"tag"`
+	Documents int    `json:"documents"`
+}
+
+func (s *Server) handleRenameTag(ctx context.Context, req *mcp.CallToolRequest, a renameTagArgs) (*mcp.CallToolResult, renameTagResult, error) {
+	if a.From == "" || a.To == "" {
+		return nil, renameTagResult{}, fmt.Errorf("from and to are required")
+	}
+	if err := requireVaultWrite(toolScope(req), "rename_tag"); err != nil {
+		return nil, renameTagResult{}, err
+	}
+	n, err := s.vault.RenameTag(ctx, a.From, a.To)
+	if err != nil {
+		return nil, renameTagResult{}, err
+	}
+	return text(fmt.Sprintf("Renamed %s to %s on %d document(s).", a.From, a.To, n)), renameTagResult{Tag: a.To, Documents: n}, nil
+}
+
 type opResult struct {
 	OK      bool   `json:"ok"`
 	Message string `json:"message,omitempty"`
@@ -3,9 +3,14 @@ package mcp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // fakeVault is a configurable in-memory Vault for tests.
@@ -21,13 +26,37 @@ type fakeVault struct {
 
 	createdAlias, createdTitle, createdMD string
 	createdTags                           []string
-	updatedPath                           string
+	gotPath, gotAlias, gotDate            string
+	updatedPath, updatedHash              string
 	updatedTitle, updatedMD               *string
 	updatedTags                           *[]string
+	editOp, editHash, editMD              string
+	editTarget                            BlockTarget
+	gotIncludeDeleted                     bool
+	journalOp, journalEntryID             string
+	journalTags                           *[]string
+	promotion                             JournalPromotion
+	adminOp, adminTarget                  string
+	projectPatch                          ProjectPatch
+	asset                                 AssetData
+	assetAlias, assetFile                 string
+	uploaded                              []byte
+	allEntries                            []ProjectJournalEntry
+	tagged                                []DocumentInfo
+	gotTag                                string
+	snapshot                              DocumentSnapshot
+	restoredPath, restoredHash            string
+	restored                              *DocumentSnapshot
+	restoreErr                            error
+	searchCalls                           int
 }
 
-func (f *fakeVault) SearchNotes(_ context.Context, _ string, _, _ int) ([]SearchHit, error) {
-	return f.hits, f.err
+func (f *fakeVault) SearchNotes(_ context.Context, _ string, limit, offset int) ([]SearchHit, error) {
+	f.searchCalls++
+	if offset >= len(f.hits) {
+		return []SearchHit{}, f.err
+	}
+	return f.hits[offset:min(offset+limit, len(f.hits))], f.err
 }
 func (f *fakeVault) ListProjects(_ context.Context, _ bool) ([]ProjectInfo, error) {
 	return f.projects, f.err
@@ -35,18 +64,41 @@ func (f *fakeVault) ListProjects(_ context.Context, _ bool) ([]ProjectInfo, erro
 func (f *fakeVault) ListDocuments(_ context.Context, _ string, _ bool, _, _ int) ([]DocumentInfo, error) {
 	return f.docs, f.err
 }
-func (f *fakeVault) GetDocument(_ context.Context, _ string) (DocumentContent, error) {
+func (f *fakeVault) GetDocument(_ context.Context, path string) (DocumentContent, error) {
+	f.gotPath = path
 	return f.doc, f.err
 }
-func (f *fakeVault) ReadJournal(_ context.Context, _, _ string) ([]JournalEntryInfo, error) {
+func (f *fakeVault) ReadJournal(_ context.Context, alias, date string, includeDeleted bool) ([]JournalEntryInfo, error) {
+	f.gotAlias, f.gotDate, f.gotIncludeDeleted = alias, date, includeDeleted
 	return f.entries, f.err
 }
+func (f *fakeVault) SearchJournal(_ context.Context, alias, _ string, _ int) ([]JournalHit, error) {
+	f.gotAlias = alias
+	hits := make([]JournalHit, 0, len(f.entries))
+	for _, e := range f.entries {
+		hits = append(hits, JournalHit{ProjectAlias: alias, Date: "2026-07-03", Entry: e})
+	}
+	return hits, f.err
+}
 func (f *fakeVault) ListJournalDates(_ context.Context, _ string) ([]string, error) {
 	return f.dates, f.err
 }
 func (f *fakeVault) ListTags(_ context.Context) ([]string, error) {
 	return f.tags, f.err
 }
+func (f *fakeVault) ReadAllJournals(_ context.Context, date string) ([]ProjectJournalEntry, error) {
+	var out []ProjectJournalEntry
+	for _, e := range f.allEntries {
+		if strings.HasPrefix(e.Created, date) {
+			out = append(out, e)
+		}
+	}
+	return out, f.err
+}
+func (f *fakeVault) ListTaggedDocuments(_ context.Context, tag string, _ int) ([]DocumentInfo, error) {
+	f.gotTag = tag
+	return f.tagged, f.err
+}
 func (f *fakeVault) CreateDocument(_ context.Context, alias, title, md string, tags []string) (string, error) {
 	f.createdAlias, f.createdTitle, f.createdMD, f.createdTags = alias, title, md, tags
 	if f.err != nil {
@@ -54,9 +106,16 @@ func (f *fakeVault) CreateDocument(_ context.Context, alias, title, md string, t
 	}
 	return "projects/@" + alias + "/doc-new.json", nil
 }
-func (f *fakeVault) UpdateDocument(_ context.Context, path string, title, md *string, tags *[]string) error {
-	f.updatedPath, f.updatedTitle, f.updatedMD, f.updatedTags = path, title, md, tags
-	return f.err
+func (f *fakeVault) UpdateDocument(_ context.Context, path, hash string, title, md *string, tags *[]string) (EditResult, error) {
+	f.updatedPath, f.updatedHash, f.updatedTitle, f.updatedMD, f.updatedTags = path, hash, title, md, tags
+	return EditResult{Path: path, Hash: "next"}, f.err
+}
+func (f *fakeVault) SnapshotDocument(_ context.Context, _ string) (DocumentSnapshot, error) {
+	return f.snapshot, f.err
+}
+func (f *fakeVault) RestoreDocument(_ context.Context, path, hash string, snap DocumentSnapshot) (EditResult, error) {
+	f.restoredPath, f.restoredHash, f.restored = path, hash, &snap
+	return EditResult{Path: path, Hash: "restored"}, f.restoreErr
 }
 func (f *fakeVault) MoveDocument(_ context.Context, _, _ string) error        { return f.err }
 func (f *fakeVault) DeleteDocument(_ context.Context, _ string, _ bool) error { return f.err }
@@ -66,10 +125,92 @@ func (f *fakeVault) AppendJournal(_ context.Context, _, content string, tags []s
 	}
 	return JournalEntryInfo{ID: "abc123", Content: content, Tags: tags, Created: "2026-07-03T00:00:00Z"}, nil
 }
+func (f *fakeVault) journalEdit(op, alias, date, entryID string) {
+	f.journalOp, f.gotAlias, f.gotDate, f.journalEntryID = op, alias, date, entryID
+}
+func (f *fakeVault) UpdateJournalEntry(_ context.Context, alias, date, entryID, content string, tags *[]string) (JournalEntryInfo, error) {
+	f.journalEdit("update", alias, date, entryID)
+	f.journalTags = tags
+	return JournalEntryInfo{ID: entryID, Content: content}, f.err
+}
+func (f *fakeVault) DeleteJournalEntry(_ context.Context, alias, date, entryID string) error {
+	f.journalEdit("delete", alias, date, entryID)
+	return f.err
+}
+func (f *fakeVault) RestoreJournalEntry(_ context.Context, alias, date, entryID string) error {
+	f.journalEdit("restore", alias, date, entryID)
+	return f.err
+}
+func (f *fakeVault) PromoteJournalEntries(_ context.Context, p JournalPromotion) (JournalPromotionResult, error) {
+	f.promotion = p
+	return JournalPromotionResult{Path: "projects/" + p.TargetProject + "/doc-new.json", EntryIDs: []string{"e1", "e2"}}, f.err
+}
+func (f *fakeVault) ReadAsset(_ context.Context, alias, file string, _ int64) (AssetData, error) {
+	f.assetAlias, f.assetFile = alias, file
+	return f.asset, f.err
+}
+func (f *fakeVault) UploadAsset(_ context.Context, alias, filename string, data []byte) (UploadedAsset, error) {
+	f.assetAlias, f.assetFile, f.uploaded = alias, filename, data
+	return UploadedAsset{Ref: "/assets/" + alias + "/abc.png", MIME: "image/png", Bytes: int64(len(data))}, f.err
+}
+func (f *fakeVault) admin(op, target string) {
+	f.adminOp, f.adminTarget = op, target
+}
+func (f *fakeVault) CreateProject(_ context.Context, name, alias, startDate, endDate string) (ProjectInfo, error) {
+	f.admin("create_project", alias)
+	return ProjectInfo{ID: "p1", Name: name, Alias: alias, StartDate: startDate, EndDate: endDate}, f.err
+}
+func (f *fakeVault) UpdateProject(_ context.Context, alias string, patch ProjectPatch) (ProjectInfo, error) {
+	f.admin("update_project", alias)
+	f.projectPatch = patch
+	if patch.Alias != nil {
+		alias = *patch.Alias
+	}
+	return ProjectInfo{ID: "p1", Alias: alias}, f.err
+}
+func (f *fakeVault) ArchiveProject(_ context.Context, alias string) error {
+	f.admin("archive_project", alias)
+	return f.err
+}
+func (f *fakeVault) RestoreProject(_ context.Context, alias string) error {
+	f.admin("restore_project", alias)
+	return f.err
+}
+func (f *fakeVault) CreateTag(_ context.Context, name string) (string, error) {
+	f.admin("create_tag", name)
+	return name, f.err
+}
+func (f *fakeVault) DeleteTag(_ context.Context, name string) error {
+	f.admin("delete_tag", name)
+	return f.err
+}
+func (f *fakeVault) RenameTag(_ context.Context, from, _ string) (int, error) {
+	f.admin("rename_tag", from)
+	return 3, f.err
+}
 func (f *fakeVault) AddTagsToDocument(_ context.Context, _ string, _ []string) error { return f.err }
 func (f *fakeVault) RemoveTagsFromDocument(_ context.Context, _ string, _ []string) error {
 	return f.err
 }
+func (f *fakeVault) edit(op, hash string, target BlockTarget, md string) (EditResult, error) {
+	f.editOp, f.editHash, f.editTarget, f.editMD = op, hash, target, md
+	return EditResult{Path: "p", Hash: "next"}, f.err
+}
+func (f *fakeVault) InsertBlocks(_ context.Context, _, hash string, after BlockTarget, md string) (EditResult, error) {
+	return f.edit("insert", hash, after, md)
+}
+func (f *fakeVault) AppendBlocks(_ context.Context, _, hash, md string) (EditResult, error) {
+	return f.edit("append", hash, BlockTarget{}, md)
+}
+func (f *fakeVault) ReplaceSection(_ context.Context, _, hash, heading, md string) (EditResult, error) {
+	return f.edit("replace", hash, BlockTarget{Heading: heading}, md)
+}
+func (f *fakeVault) DeleteBlock(_ context.Context, _, hash string, target BlockTarget) (EditResult, error) {
+	return f.edit("delete", hash, target, "")
+}
+func (f *fakeVault) ToggleCheckListItem(_ context.Context, _, hash, blockID string) (EditResult, error) {
+	return f.edit("toggle", hash, BlockTarget{BlockID: blockID}, "")
+}
 
 // TestNewServerRegistersTools ensures the SDK can infer JSON schemas for every
 // tool's argument type (this is where a bad type — e.g. the *[]string patch
@@ -107,6 +248,184 @@ func TestHandleSearch(t *testing.T) {
 	}
 }
 
+func TestHandleGetDocument_Section(t *testing.T) {
+	fv := &fakeVault{doc: DocumentContent{
+		Path:     "projects/@work/doc-1.json",
+		Section:  "setup",
+		Markdown: "## Setup\n\nInstall it.",
+		Outline:  []OutlineEntry{{Level: 2, Text: "Setup", BlockID: "b1", Slug: "setup"}},
+	}}
+	s := NewServer(fv, "test")
+
+	res, doc, err := s.handleGetDocument(context.Background(), nil, getDocumentArgs{Path: "projects/@work/doc-1.json#setup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.gotPath != "projects/@work/doc-1.json#setup" {
+		t.Errorf("anchor not forwarded: %q", fv.gotPath)
+	}
+	if len(doc.Outline) != 1 || doc.Section != "setup" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	if len(res.Content) == 0 {
+		t.Error("expected a text summary")
+	}
+
+	if _, _, err := s.handleGetDocument(context.Background(), nil, getDocumentArgs{}); err == nil {
+		t.Error("expected error for empty path")
+	}
+}
+
+func TestHandleBlockEdits(t *testing.T) {
+	fv := &fakeVault{}
+	s := NewServer(fv, "test")
+	ctx := context.Background()
+
+	_, res, err := s.handleInsertBlocks(ctx, nil, insertBlocksArgs{Path: "p", ExpectedHash: "h", Heading: "setup", Markdown: "- [ ] new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.editOp != "insert" || fv.editHash != "h" || fv.editTarget.Heading != "setup" || fv.editMD != "- [ ] new" {
+		t.Errorf("insert not forwarded: %+v", fv)
+	}
+	if res.Hash != "next" {
+		t.Errorf("expected the new hash in the result, got %+v", res)
+	}
+
+	if _, _, err := s.handleReplaceSection(ctx, nil, replaceSectionArgs{Path: "p", ExpectedHash: "h", Heading: "setup"}); err != nil {
+		t.Errorf("an empty replacement should clear the section: %v", err)
+	}
+	if _, _, err := s.handleToggleCheckListItem(ctx, nil, toggleCheckListItemArgs{Path: "p", ExpectedHash: "h", BlockID: "b1"}); err != nil || fv.editTarget.BlockID != "b1" {
+		t.Errorf("toggle not forwarded: %v %+v", err, fv)
+	}
+
+	for name, call := range map[string]func() error{
+		"insert without hash": func() error {
+			_, _, err := s.handleInsertBlocks(ctx, nil, insertBlocksArgs{Path: "p", BlockID: "b", Markdown: "x"})
+			return err
+		},
+		"insert with two targets": func() error {
+			_, _, err := s.handleInsertBlocks(ctx, nil, insertBlocksArgs{Path: "p", ExpectedHash: "h", BlockID: "b", Heading: "s", Markdown: "x"})
+			return err
+		},
+		"append without markdown": func() error {
+			_, _, err := s.handleAppendBlocks(ctx, nil, appendBlocksArgs{Path: "p", ExpectedHash: "h"})
+			return err
+		},
+		"delete without target": func() error {
+			_, _, err := s.handleDeleteBlock(ctx, nil, deleteBlockArgs{Path: "p", ExpectedHash: "h"})
+			return err
+		},
+	} {
+		if call() == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	fv.err = errors.New("conflict")
+	if _, _, err := s.handleDeleteBlock(ctx, nil, deleteBlockArgs{Path: "p", ExpectedHash: "h", BlockID: "b"}); err == nil {
+		t.Error("expected vault error to propagate")
+	}
+}
+
+func TestHandleJournalEdits(t *testing.T) {
+	fv := &fakeVault{}
+	s := NewServer(fv, "test")
+	ctx := context.Background()
+	entry := journalEntryArgs{ProjectAlias: "@work", Date: "2026-07-03", EntryID: "e1"}
+
+	_, res, err := s.handleUpdateJournalEntry(ctx, nil, updateJournalEntryArgs{journalEntryArgs: entry, Content: "fixed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.journalOp != "update" || fv.gotAlias != "@work" || fv.gotDate != "2026-07-03" || fv.journalEntryID != "e1" || fv.journalTags != nil {
+		t.Errorf("update not forwarded: %+v", fv)
+	}
+	if res.EntryID != "e1" || res.Entry == nil || res.Entry.Content != "fixed" {
+		t.Errorf("update result = %+v", res)
+	}
+
+	if _, res, err := s.handleDeleteJournalEntry(ctx, nil, entry); err != nil || fv.journalOp != "delete" || res.EntryID != "e1" {
+		t.Errorf("delete: %v, %+v", err, res)
+	}
+	if _, res, err := s.handleRestoreJournalEntry(ctx, nil, entry); err != nil || fv.journalOp != "restore" || res.EntryID != "e1" {
+		t.Errorf("restore: %v, %+v", err, res)
+	}
+	if _, _, err := s.handleDeleteJournalEntry(ctx, nil, journalEntryArgs{ProjectAlias: "@work", Date: "2026-07-03"}); err == nil {
+		t.Error("expected error without entry_id")
+	}
+	if _, _, err := s.handleUpdateJournalEntry(ctx, nil, updateJournalEntryArgs{journalEntryArgs: entry}); err == nil {
+		t.Error("expected error without content")
+	}
+
+	_, promoted, err := s.handlePromoteJournalEntries(ctx, nil, promoteJournalEntriesArgs{ProjectAlias: "@work", Date: "2026-07-03", Title: "Notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.promotion.TargetProject != "@work" || fv.promotion.SourceProject != "@work" || fv.promotion.KeepOriginal {
+		t.Errorf("promotion = %+v; want the source project as target", fv.promotion)
+	}
+	if len(promoted.EntryIDs) != 2 || promoted.Path == "" {
+		t.Errorf("promote result = %+v", promoted)
+	}
+
+	fv.entries = []JournalEntryInfo{{ID: "e1", Content: "deleted", Deleted: true}}
+	if _, _, err := s.handleReadJournal(ctx, nil, readJournalArgs{ProjectAlias: "@work", IncludeDeleted: true}); err != nil || !fv.gotIncludeDeleted {
+		t.Errorf("include_deleted not forwarded: %v", err)
+	}
+	_, found, err := s.handleSearchJournal(ctx, nil, searchJournalArgs{ProjectAlias: "@work", Query: "del"})
+	if err != nil || len(found.Hits) != 1 || found.Hits[0].Entry.ID != "e1" {
+		t.Errorf("search_journal = %+v, %v", found, err)
+	}
+}
+
+func TestHandleAdministration(t *testing.T) {
+	fv := &fakeVault{}
+	s := NewServer(fv, "test")
+	ctx := context.Background()
+
+	_, created, err := s.handleCreateProject(ctx, nil, createProjectArgs{Name: "Work", Alias: "@work", StartDate: "2026-01-01"})
+	if err != nil || created.Alias != "@work" || created.StartDate != "2026-01-01" {
+		t.Errorf("create_project = %+v, %v", created, err)
+	}
+	if _, _, err := s.handleCreateProject(ctx, nil, createProjectArgs{}); err == nil {
+		t.Error("expected error without name")
+	}
+
+	name, alias := "Work stuff", "@job"
+	_, updated, err := s.handleUpdateProject(ctx, nil, updateProjectArgs{ProjectAlias: "@work", Name: &name, Alias: &alias})
+	if err != nil || updated.Alias != "@job" {
+		t.Errorf("update_project = %+v, %v", updated, err)
+	}
+	if fv.adminTarget != "@work" || fv.projectPatch.Name == nil || *fv.projectPatch.Name != name || fv.projectPatch.StartDate != nil {
+		t.Errorf("patch not forwarded: target %q, %+v", fv.adminTarget, fv.projectPatch)
+	}
+	if _, _, err := s.handleUpdateProject(ctx, nil, updateProjectArgs{ProjectAlias: "@work"}); err == nil {
+		t.Error("expected error for an empty update")
+	}
+
+	if _, _, err := s.handleArchiveProject(ctx, nil, projectArgs{ProjectAlias: "@work"}); err != nil || fv.adminOp != "archive_project" {
+		t.Errorf("archive_project: %v, op %q", err, fv.adminOp)
+	}
+	if _, _, err := s.handleRestoreProject(ctx, nil, projectArgs{ProjectAlias: "@work"}); err != nil || fv.adminOp != "restore_project" {
+		t.Errorf("restore_project: %v, op %q", err, fv.adminOp)
+	}
+
+	if _, res, err := s.handleCreateTag(ctx, nil, tagArgs{Name: "go"}); err != nil || res.Message != "go" {
+		t.Errorf("create_tag = %+v, %v", res, err)
+	}
+	if _, _, err := s.handleDeleteTag(ctx, nil, tagArgs{Name: "go"}); err != nil || fv.adminOp != "delete_tag" {
+		t.Errorf("delete_tag: %v, op %q", err, fv.adminOp)
+	}
+	_, renamed, err := s.handleRenameTag(ctx, nil, renameTagArgs{From: "go", To: "golang"})
+	if err != nil || renamed.Tag != "golang" || renamed.Documents != 3 || fv.adminTarget != "go" {
+		t.Errorf("rename_tag = %+v, %v", renamed, err)
+	}
+	if _, _, err := s.handleRenameTag(ctx, nil, renameTagArgs{From: "go"}); err == nil {
+		t.Error("expected error without to")
+	}
+}
+
 func TestHandleCreateDocument(t *testing.T) {
 	fv := &fakeVault{}
 	s := NewServer(fv, "test")
@@ -147,6 +466,31 @@ func TestHandleUpdateDocument_RequiresAField(t *testing.T) {
 	if fv.updatedMD != nil || fv.updatedTags != nil {
 		t.Error("omitted fields should stay nil")
 	}
+
+	_, res, err := s.handleUpdateDocument(context.Background(), nil, updateDocumentArgs{Path: "p", ExpectedHash: "h", Title: &title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fv.updatedHash != "h" || res.Hash != "next" {
+		t.Errorf("expected hash not forwarded or new hash not returned: %+v %+v", fv, res)
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	fv := &fakeVault{err: &ConflictError{Path: "p", CurrentHash: "abc", Markdown: "# Theirs"}}
+	s := NewServer(fv, "test")
+	title := "Mine"
+
+	_, _, err := s.handleUpdateDocument(context.Background(), nil, updateDocumentArgs{Path: "p", ExpectedHash: "old", Title: &title})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	for _, want := range []string{`"abc"`, "# Theirs", "nothing was written"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("conflict message %q should mention %q", err.Error(), want)
+		}
+	}
 }
 
 func TestHandleAppendJournal(t *testing.T) {
@@ -167,7 +511,7 @@ func TestHandleAppendJournal(t *testing.T) {
 func TestWithAuth(t *testing.T) {
 	const token = "secret-token"
 	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
-	h := withAuth(ok, token)
+	h := withAuth(ok, StaticToken(token))
 
 	cases := []struct {
 		name     string
@@ -199,3 +543,166 @@ func TestWithAuth(t *testing.T) {
 		})
 	}
 }
+
+// scoped returns a tool request made with a token of the given scope.
+func scoped(scope Scope) *mcp.CallToolRequest {
+	return &mcp.CallToolRequest{Extra: &mcp.RequestExtra{TokenInfo: &auth.TokenInfo{
+		Extra: map[string]any{scopeKey: scope},
+	}}}
+}
+
+func TestScopeEnforcement(t *testing.T) {
+	ctx := context.Background()
+	fv := &fakeVault{
+		projects: []ProjectInfo{{Alias: "@work"}, {Alias: "@home"}},
+		doc:      DocumentContent{Path: "projects/@home/doc-1.json", ProjectAlias: "@home"},
+		hits:     []SearchHit{{ID: "1", ProjectAlias: "@work"}, {ID: "2", ProjectAlias: "@home"}},
+	}
+	s := NewServer(fv, "test")
+
+	readOnly := scoped(Scope{Access: AccessRead})
+	if _, _, err := s.handleGetDocument(ctx, readOnly, getDocumentArgs{Path: "projects/@home/doc-1.json"}); err != nil {
+		t.Errorf("read-only token should read: %v", err)
+	}
+	if _, _, err := s.handleCreateDocument(ctx, readOnly, createDocumentArgs{ProjectAlias: "work", Title: "x"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("read-only create: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleAppendBlocks(ctx, readOnly, appendBlocksArgs{Path: "p", ExpectedHash: "h", Markdown: "x"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("read-only append: got %v, want ErrForbidden", err)
+	}
+
+	write := scoped(Scope{Access: AccessWrite})
+	if _, _, err := s.handleDeleteDocument(ctx, write, deleteDocumentArgs{Path: "p"}); err != nil {
+		t.Errorf("write token should soft-delete: %v", err)
+	}
+	if _, _, err := s.handleDeleteDocument(ctx, write, deleteDocumentArgs{Path: "p", Hard: true}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("write hard delete: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleDeleteDocument(ctx, scoped(FullScope), deleteDocumentArgs{Path: "p", Hard: true}); err != nil {
+		t.Errorf("admin token should hard-delete: %v", err)
+	}
+
+	work := scoped(Scope{Access: AccessWrite, Projects: []string{"@work"}})
+	if _, _, err := s.handleGetDocument(ctx, work, getDocumentArgs{Path: "projects/@home/doc-1.json"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("other project's document: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleUpdateDocument(ctx, work, updateDocumentArgs{Path: "projects/@home/doc-1.json", Title: new(string)}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("update in other project: got %v, want ErrForbidden", err)
+	}
+	if fv.updatedPath != "" {
+		t.Error("forbidden update reached the vault")
+	}
+	if _, _, err := s.handleAppendJournal(ctx, work, appendJournalArgs{ProjectAlias: "work", Content: "x"}); err != nil {
+		t.Errorf("journal in own project: %v", err)
+	}
+	if _, _, err := s.handleReadJournal(ctx, work, readJournalArgs{ProjectAlias: "home"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("journal in other project: got %v, want ErrForbidden", err)
+	}
+	home := journalEntryArgs{ProjectAlias: "@home", Date: "2026-07-03", EntryID: "e1"}
+	if _, _, err := s.handleDeleteJournalEntry(ctx, work, home); !errors.Is(err, ErrForbidden) {
+		t.Errorf("delete journal entry in other project: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleUpdateJournalEntry(ctx, readOnly, updateJournalEntryArgs{journalEntryArgs: home, Content: "x"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("read-only journal update: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleSearchJournal(ctx, work, searchJournalArgs{ProjectAlias: "@home", Query: "x"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("search other project's journal: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handlePromoteJournalEntries(ctx, work, promoteJournalEntriesArgs{ProjectAlias: "@home", Date: "2026-07-03", Title: "x", TargetProject: "@work"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("promote from other project: got %v, want ErrForbidden", err)
+	}
+	if fv.journalOp != "" || fv.promotion.Title != "" {
+		t.Error("forbidden journal edit reached the vault")
+	}
+	if _, _, err := s.handleListTags(ctx, work, noArgs{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("list_tags with project scope: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleCreateProject(ctx, work, createProjectArgs{Name: "New"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("create_project with project scope: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleRenameTag(ctx, work, renameTagArgs{From: "a", To: "b"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("rename_tag with project scope: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleCreateTag(ctx, readOnly, tagArgs{Name: "a"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("read-only create_tag: got %v, want ErrForbidden", err)
+	}
+	if _, _, err := s.handleArchiveProject(ctx, work, projectArgs{ProjectAlias: "@home"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("archive other project: got %v, want ErrForbidden", err)
+	}
+	moved := "@home"
+	if _, _, err := s.handleUpdateProject(ctx, work, updateProjectArgs{ProjectAlias: "@work", Alias: &moved}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("rename into an alias outside the scope: got %v, want ErrForbidden", err)
+	}
+	if fv.adminOp != "" {
+		t.Error("forbidden administration reached the vault")
+	}
+	if _, _, err := s.handleArchiveProject(ctx, work, projectArgs{ProjectAlias: "@work"}); err != nil {
+		t.Errorf("archive own project: %v", err)
+	}
+	_, projects, err := s.handleListProjects(ctx, work, listProjectsArgs{})
+	if err != nil || len(projects.Projects) != 1 || projects.Projects[0].Alias != "@work" {
+		t.Errorf("list_projects = %+v, %v; want only @work", projects, err)
+	}
+	_, found, err := s.handleSearch(ctx, work, searchArgs{Query: "x"})
+	if err != nil || len(found.Hits) != 1 || found.Hits[0].ID != "1" {
+		t.Errorf("search = %+v, %v; want only the @work hit", found, err)
+	}
+}
+
+func TestSearch_ScopedTokenPagesPastOtherProjects(t *testing.T) {
+	ctx := context.Background()
+	fv := &fakeVault{}
+	for i := range 2 * scopedSearchPage {
+		fv.hits = append(fv.hits, SearchHit{ID: fmt.Sprintf("home-%d", i), ProjectAlias: "@home"})
+	}
+	for i := range 30 {
+		fv.hits = append(fv.hits, SearchHit{ID: fmt.Sprintf("work-%d", i), ProjectAlias: "@work"})
+	}
+	s := NewServer(fv, "test")
+	work := scoped(Scope{Access: AccessRead, Projects: []string{"@work"}})
+
+	_, first, err := s.handleSearch(ctx, work, searchArgs{Query: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Hits) != defaultSearchLimit || first.Hits[0].ID != "work-0" || first.Hits[defaultSearchLimit-1].ID != "work-19" {
+		t.Fatalf("first page = %d hits from %+v; want work-0..work-19", len(first.Hits), first.Hits)
+	}
+
+	_, next, err := s.handleSearch(ctx, work, searchArgs{Query: "x", Offset: defaultSearchLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Hits) != 10 || next.Hits[0].ID != "work-20" || next.Hits[9].ID != "work-29" {
+		t.Errorf("second page = %+v; want work-20..work-29", next.Hits)
+	}
+
+	fv.searchCalls = 0
+	if _, all, err := s.handleSearch(ctx, nil, searchArgs{Query: "x"}); err != nil || len(all.Hits) != defaultSearchLimit || fv.searchCalls != 1 {
+		t.Errorf("unscoped search = %d hits in %d calls, %v; want one page", len(all.Hits), fv.searchCalls, err)
+	}
+}
+
+func TestWithAuth_AttachesScope(t *testing.T) {
+	scope := Scope{Access: AccessRead, Projects: []string{"@work"}}
+	authn := func(token string) (Grant, bool) {
+		return Grant{TokenID: "t1", Scope: scope}, token == "scoped"
+	}
+	var got Scope
+	h := withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := auth.TokenInfoFromContext(r.Context())
+		got = scopeOf(&mcp.RequestExtra{TokenInfo: info})
+		w.WriteHeader(http.StatusOK)
+	}), authn)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer scoped")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", rec.Code)
+	}
+	if got.Access != AccessRead || !got.AllowsProject("work") || got.AllowsProject("@home") {
+		t.Errorf("scope = %+v, want %+v", got, scope)
+	}
+}
@@ -10,7 +10,11 @@
 // app layer.
 package mcp
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
 
 // Vault is the set of operations the MCP tools expose. All content crosses this
 // boundary as Markdown; the adapter is responsible for converting to and from
@@ -20,22 +24,78 @@ type Vault interface {
 	SearchNotes(ctx context.Context, query string, limit, offset int) ([]SearchHit, error)
 	ListProjects(ctx context.Context, includeArchived bool) ([]ProjectInfo, error)
 	ListDocuments(ctx context.Context, projectAlias string, includeArchived bool, limit, offset int) ([]DocumentInfo, error)
+	// GetDocument accepts "path#slug" to read a single heading's section.
 	GetDocument(ctx context.Context, path string) (DocumentContent, error)
-	ReadJournal(ctx context.Context, projectAlias, date string) ([]JournalEntryInfo, error)
+	// ReadJournal returns a day's active entries and, with includeDeleted,
+	// the soft-deleted ones too.
+	ReadJournal(ctx context.Context, projectAlias, date string, includeDeleted bool) ([]JournalEntryInfo, error)
 	ListJournalDates(ctx context.Context, projectAlias string) ([]string, error)
+	SearchJournal(ctx context.Context, projectAlias, query string, limit int) ([]JournalHit, error)
 	ListTags(ctx context.Context) ([]string, error)
+	// ReadAllJournals returns a day's active entries across every project.
+	ReadAllJournals(ctx context.Context, date string) ([]ProjectJournalEntry, error)
+	// ListTaggedDocuments returns up to limit active documents carrying tag.
+	ListTaggedDocuments(ctx context.Context, tag string, limit int) ([]DocumentInfo, error)
 
 	// --- write ---
 	// CreateDocument must validate that projectAlias refers to an existing
 	// project before writing (document.Service.Save does not check this).
 	CreateDocument(ctx context.Context, projectAlias, title, markdown string, tags []string) (path string, err error)
-	// UpdateDocument applies only the non-nil fields.
-	UpdateDocument(ctx context.Context, path string, title, markdown *string, tags *[]string) error
+	// UpdateDocument applies only the non-nil fields and returns the new
+	// document hash. With an expectedHash it must refuse to overwrite a
+	// document that has changed since, returning a *ConflictError.
+	UpdateDocument(ctx context.Context, path, expectedHash string, title, markdown *string, tags *[]string) (EditResult, error)
 	MoveDocument(ctx context.Context, path, targetProject string) error
 	DeleteDocument(ctx context.Context, path string, hard bool) error
 	AppendJournal(ctx context.Context, projectAlias, content string, tags []string, date string) (JournalEntryInfo, error)
+	// UpdateJournalEntry replaces an entry's content and, if tags is non-nil,
+	// its tags.
+	UpdateJournalEntry(ctx context.Context, projectAlias, date, entryID, content string, tags *[]string) (JournalEntryInfo, error)
+	DeleteJournalEntry(ctx context.Context, projectAlias, date, entryID string) error
+	RestoreJournalEntry(ctx context.Context, projectAlias, date, entryID string) error
+	// PromoteJournalEntries turns entries into a new document and reports
+	// which entries it used; no entry IDs means the day's active entries.
+	PromoteJournalEntries(ctx context.Context, p JournalPromotion) (JournalPromotionResult, error)
 	AddTagsToDocument(ctx context.Context, path string, tags []string) error
 	RemoveTagsFromDocument(ctx context.Context, path string, tags []string) error
+
+	// --- assets ---
+	// ReadAsset returns an asset file of a project, refusing files larger
+	// than maxBytes.
+	ReadAsset(ctx context.Context, alias, file string, maxBytes int64) (AssetData, error)
+	UploadAsset(ctx context.Context, alias, filename string, data []byte) (UploadedAsset, error)
+
+	// --- administration ---
+	CreateProject(ctx context.Context, name, alias, startDate, endDate string) (ProjectInfo, error)
+	// UpdateProject applies only the non-nil fields of the patch. Changing the
+	// alias is refused for a project that already has content.
+	UpdateProject(ctx context.Context, alias string, patch ProjectPatch) (ProjectInfo, error)
+	ArchiveProject(ctx context.Context, alias string) error
+	RestoreProject(ctx context.Context, alias string) error
+	CreateTag(ctx context.Context, name string) (string, error)
+	DeleteTag(ctx context.Context, name string) error
+	// RenameTag renames a tag on every document, merging it into to if that
+	// tag exists, and returns how many documents changed.
+	RenameTag(ctx context.Context, from, to string) (int, error)
+
+	// --- block edits ---
+	// Each takes the document hash the edit is based on (DocumentContent.Hash)
+	// and must refuse the edit with a *ConflictError if the document has
+	// changed since. They return the new hash, so edits can be chained.
+	InsertBlocks(ctx context.Context, path, expectedHash string, after BlockTarget, markdown string) (EditResult, error)
+	AppendBlocks(ctx context.Context, path, expectedHash, markdown string) (EditResult, error)
+	ReplaceSection(ctx context.Context, path, expectedHash, heading, markdown string) (EditResult, error)
+	DeleteBlock(ctx context.Context, path, expectedHash string, target BlockTarget) (EditResult, error)
+	ToggleCheckListItem(ctx context.Context, path, expectedHash, blockID string) (EditResult, error)
+
+	// --- undo ---
+	// SnapshotDocument returns the document exactly as it is stored, so
+	// RestoreDocument can put it back without a Markdown round trip.
+	SnapshotDocument(ctx context.Context, path string) (DocumentSnapshot, error)
+	// RestoreDocument writes a snapshot back to path. Like the block edits,
+	// it must refuse with a *ConflictError if the document has changed since
+	// expectedHash.
+	RestoreDocument(ctx context.Context, path, expectedHash string, snapshot DocumentSnapshot) (EditResult, error)
 }
 
 // SearchHit is one full-text search result (a document or a journal note).
@@ -50,10 +110,34 @@ type SearchHit struct {
 
 // ProjectInfo describes a project.
 type ProjectInfo struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Alias    string `json:"alias"`
-	Archived bool   `json:"archived"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Alias     string `json:"alias"`
+	Archived  bool   `json:"archived"`
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+}
+
+// AssetData is the content of an asset file.
+type AssetData struct {
+	MIME string
+	Data []byte
+}
+
+// UploadedAsset describes a stored asset. Ref is the /assets/ reference
+// document Markdown uses for it.
+type UploadedAsset struct {
+	Ref   string
+	MIME  string
+	Bytes int64
+}
+
+// ProjectPatch is a partial project update; nil fields stay unchanged.
+type ProjectPatch struct {
+	Name      *string
+	Alias     *string
+	StartDate *string
+	EndDate   *string
 }
 
 // DocumentInfo is a document listing entry (no body).
@@ -65,13 +149,53 @@ type DocumentInfo struct {
 	Updated      string   `json:"updated,omitempty"`
 }
 
-// DocumentContent is a document with its body rendered as Markdown.
+// DocumentContent is a document with its body rendered as Markdown. When it
+// was requested as "path#slug", Section holds the slug and Markdown only that
+// heading's section.
 type DocumentContent struct {
-	Path         string   `json:"path"`
-	Title        string   `json:"title"`
-	ProjectAlias string   `json:"project_alias"`
-	Tags         []string `json:"tags,omitempty"`
-	Markdown     string   `json:"markdown"`
+	Path         string         `json:"path"`
+	Title        string         `json:"title"`
+	ProjectAlias string         `json:"project_alias"`
+	Tags         []string       `json:"tags,omitempty"`
+	Section      string         `json:"section,omitempty"`
+	Markdown     string         `json:"markdown"`
+	Outline      []OutlineEntry `json:"outline"`
+	// Hash identifies this version of the document for block edits.
+	Hash string `json:"hash"`
+	// Blocks lists the blocks in Markdown order, for addressing block edits.
+	Blocks []BlockInfo `json:"blocks"`
+}
+
+// BlockInfo identifies one block of a document. Depth counts nesting levels
+// below the top; Checked is set for checklist items.
+type BlockInfo struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Depth   int    `json:"depth,omitempty"`
+	Text    string `json:"text,omitempty"`
+	Checked *bool  `json:"checked,omitempty"`
+}
+
+// BlockTarget names the block a block edit applies to: a block ID or,
+// instead, the slug of a heading.
+type BlockTarget struct {
+	BlockID string `json:"block_id,omitempty"`
+	Heading string `json:"heading,omitempty"`
+}
+
+// EditResult is the outcome of a block edit.
+type EditResult struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// OutlineEntry is one heading of a document. Slug is the anchor used in
+// "path#slug" references.
+type OutlineEntry struct {
+	Level   int    `json:"level"`
+	Text    string `json:"text"`
+	BlockID string `json:"block_id"`
+	Slug    string `json:"slug"`
 }
 
 // JournalEntryInfo is one journal entry.
@@ -80,4 +204,60 @@ type JournalEntryInfo struct {
 	Content string   `json:"content"`
 	Tags    []string `json:"tags,omitempty"`
 	Created string   `json:"created"`
+	Deleted bool     `json:"deleted,omitempty"`
+}
+
+// ProjectJournalEntry is a journal entry with the project it belongs to.
+type ProjectJournalEntry struct {
+	JournalEntryInfo
+	ProjectAlias string `json:"project_alias"`
+}
+
+// JournalHit is one journal search result.
+type JournalHit struct {
+	ProjectAlias string           `json:"project_alias"`
+	Date         string           `json:"date"`
+	Entry        JournalEntryInfo `json:"entry"`
+	Snippet      string           `json:"snippet"`
+}
+
+// JournalPromotion describes journal entries to turn into a document.
+// KeepOriginal copies the entries; otherwise they are soft-deleted.
+type JournalPromotion struct {
+	SourceProject string
+	Date          string
+	EntryIDs      []string
+	TargetProject string
+	Title         string
+	KeepOriginal  bool
+}
+
+// JournalPromotionResult is the promoted document and the entries it was
+// made from.
+type JournalPromotionResult struct {
+	Path     string   `json:"path"`
+	EntryIDs []string `json:"entry_ids"`
+}
+
+// DocumentSnapshot is a stored document in the adapter's own format. It is
+// opaque to this package: block IDs, props and everything else Markdown
+// cannot represent survive in Data.
+type DocumentSnapshot struct {
+	Hash string
+	Data json.RawMessage
+}
+
+// ConflictError reports an edit that was refused because the document changed
+// since the hash it was based on. It carries the current version so the agent
+// can rebase its edit and retry with CurrentHash.
+type ConflictError struct {
+	Path        string
+	CurrentHash string
+	Markdown    string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict: %s has changed since the given hash and nothing was written. "+
+		"Rebase the edit onto the current content and retry with expected_hash %q. Current content:\n\n%s",
+		e.Path, e.CurrentHash, e.Markdown)
 }
@@ -25,6 +25,7 @@ import (
 
 	"yanta/internal/config"
 	"yanta/internal/system"
+	"yanta/internal/vaultlock"
 )
 
 type discovery struct {
@@ -53,7 +54,7 @@ func Run(ctx context.Context) error {
 		// A stale discovery file (app crashed without cleaning up) points at a
 		// dead endpoint. If its PID is gone, say so plainly instead of leaking a
 		// raw connection-refused; otherwise fall back to the generic message.
-		if disc.PID > 0 && !processAlive(disc.PID) {
+		if disc.PID > 0 && !vaultlock.ProcessAlive(disc.PID) {
 			return fmt.Errorf("Yanta isn't running: %s references PID %d, which is gone (stale discovery file). Start Yanta and enable its MCP server (Settings → MCP Server)", config.MCPDiscoveryPath(), disc.PID)
 		}
 		return fmt.Errorf("cannot reach Yanta at %s — is the app running with its MCP server enabled? (%w)", disc.URL, err)
@@ -96,6 +97,14 @@ func (t *authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return t.base.RoundTrip(r)
 }
 
+// Available reports whether a running app has published a reachable-looking
+// MCP endpoint: a discovery file whose process is still alive. `yanta mcp
+// --standalone` uses it to prefer relaying to the app over opening the vault.
+func Available() bool {
+	disc, err := readDiscovery()
+	return err == nil && (disc.PID <= 0 || vaultlock.ProcessAlive(disc.PID))
+}
+
 func readDiscovery() (*discovery, error) {
 	path := config.MCPDiscoveryPath()
 	data, err := os.ReadFile(path)
@@ -0,0 +1,173 @@
+package mcpctl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"yanta/internal/config"
+	"yanta/internal/logger"
+	"yanta/internal/mcp"
+)
+
+// maxUndo bounds how many MCP changes can be undone, newest first.
+const maxUndo = 50
+
+// ErrNothingToUndo is returned by Undo when no MCP change is left to undo.
+var ErrNothingToUndo = errors.New("no MCP change to undo")
+
+func auditPath() string { return filepath.Join(config.GetAppRootDirectory(), "mcp-audit.jsonl") }
+
+// SyncNotifier is told about every successful MCP write, so the next git
+// auto-commit names the agent that made it.
+type SyncNotifier interface {
+	NotifyChange(reason string)
+}
+
+// AuditLog appends every MCP tool call to mcp-audit.jsonl in the app
+// directory, one JSON object per line, and remembers the recent changes that
+// can be undone. The log is never rewritten; the undo history lives in memory
+// and is lost on restart.
+type AuditLog struct {
+	mu   sync.Mutex
+	sync SyncNotifier
+	undo []mcp.AuditEntry
+}
+
+func NewAuditLog() *AuditLog { return &AuditLog{} }
+
+func (l *AuditLog) SetSyncNotifier(n SyncNotifier) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sync = n
+}
+
+// Record appends the entry to the log. It is the mcp.Server audit function.
+func (l *AuditLog) Record(e mcp.AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := appendAudit(e); err != nil {
+		logger.WithError(err).Warn("failed to write MCP audit entry")
+	}
+	if !e.Write || !e.OK {
+		return
+	}
+	if l.sync != nil {
+		l.sync.NotifyChange(e.Reason())
+	}
+	if e.Undo != nil {
+		l.undo = append(l.undo, e)
+		if len(l.undo) > maxUndo {
+			l.undo = slices.Delete(l.undo, 0, len(l.undo)-maxUndo)
+		}
+	}
+}
+
+// List returns up to limit entries from the log, newest first. A limit of
+// zero or less returns them all.
+func (l *AuditLog) List(limit int) ([]mcp.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(auditPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []mcp.AuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []mcp.AuditEntry{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var e mcp.AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// A line cut short by a crash; skip it rather than lose the rest.
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading MCP audit log: %w", err)
+	}
+	slices.Reverse(entries)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// Undo reverts the most recent MCP change that can still be undone, using
+// the document as stored before the change. It returns the entry it
+// reverted. A change that was edited over since is refused with
+// mcp.ErrUndoConflict and dropped from the history, so the next Undo reaches
+// the change before it.
+func (l *AuditLog) Undo(ctx context.Context, v mcp.Vault) (mcp.AuditEntry, error) {
+	l.mu.Lock()
+	if len(l.undo) == 0 {
+		l.mu.Unlock()
+		return mcp.AuditEntry{}, ErrNothingToUndo
+	}
+	last := l.undo[len(l.undo)-1]
+	l.mu.Unlock()
+
+	start := time.Now()
+	err := last.Undo.Apply(ctx, v)
+	undone := mcp.AuditEntry{
+		Time:       start.UTC(),
+		Client:     "yanta",
+		Tool:       "undo " + last.Tool,
+		Paths:      []string{last.Undo.Path},
+		Write:      true,
+		OK:         err == nil,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		undone.Result = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if appendErr := appendAudit(undone); appendErr != nil {
+		logger.WithError(appendErr).Warn("failed to write MCP audit entry")
+	}
+	if err != nil && !errors.Is(err, mcp.ErrUndoConflict) {
+		return mcp.AuditEntry{}, err
+	}
+	if i := len(l.undo) - 1; i >= 0 && l.undo[i].Undo == last.Undo {
+		l.undo = l.undo[:i]
+	}
+	if err != nil {
+		return mcp.AuditEntry{}, err
+	}
+	if l.sync != nil {
+		l.sync.NotifyChange(undone.Reason())
+	}
+	return last, nil
+}
+
+func appendAudit(e mcp.AuditEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(auditPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -6,8 +6,6 @@ package mcpctl
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -43,8 +41,11 @@ type Status struct {
 type Manager struct {
 	vault   mcp.Vault
 	version string
+	tokens  tokenStore
+	audit   *AuditLog
 
 	mu      sync.Mutex
+	srv     *mcp.Server
 	httpSrv *http.Server
 	running bool
 	url     string
@@ -54,7 +55,12 @@ type Manager struct {
 }
 
 func NewManager(vault mcp.Vault) *Manager {
-	return &Manager{vault: vault, version: system.BuildVersion}
+	return &Manager{vault: vault, version: system.BuildVersion, audit: NewAuditLog()}
+}
+
+// SetSyncNotifier has successful MCP writes named in git auto-commits.
+func (m *Manager) SetSyncNotifier(n SyncNotifier) {
+	m.audit.SetSyncNotifier(n)
 }
 
 // StartIfEnabled starts the server when config has it enabled. Used at boot.
@@ -110,7 +116,8 @@ func (m *Manager) startLocked() error {
 	}
 
 	srv := mcp.NewServer(m.vault, m.version)
-	httpSrv := &http.Server{Handler: srv.Handler(token), ReadHeaderTimeout: 10 * time.Second}
+	srv.SetAudit(m.audit.Record)
+	httpSrv := &http.Server{Handler: srv.Handler(m.tokens.authenticator(token)), ReadHeaderTimeout: 10 * time.Second}
 	go func() {
 		logger.Infof("MCP server listening on %s", url)
 		if err := httpSrv.Serve(ln); err != nil && err != http.ErrServerClosed {
@@ -118,6 +125,7 @@ func (m *Manager) startLocked() error {
 		}
 	}()
 
+	m.srv = srv
 	m.httpSrv = httpSrv
 	m.running = true
 	m.url = url
@@ -141,6 +149,7 @@ func (m *Manager) stopLocked(ctx context.Context) error {
 	}
 	_ = os.Remove(discoveryPath())
 	err := m.httpSrv.Shutdown(ctx)
+	m.srv = nil
 	m.httpSrv = nil
 	m.running = false
 	m.url = ""
@@ -170,6 +179,20 @@ func (m *Manager) Status() Status {
 	}
 }
 
+// ResourceUpdated notifies the clients subscribed to uri that the resource
+// changed. A no-op while the server is stopped.
+func (m *Manager) ResourceUpdated(ctx context.Context, uri string) {
+	m.mu.Lock()
+	srv := m.srv
+	m.mu.Unlock()
+	if srv == nil {
+		return
+	}
+	if err := srv.NotifyResourceUpdated(ctx, uri); err != nil {
+		logger.Debugf("MCP resource update for %s not sent: %v", uri, err)
+	}
+}
+
 func (m *Manager) isRunning() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -190,11 +213,10 @@ func loadOrCreateToken() (string, error) {
 			return tok, nil
 		}
 	}
-	buf := make([]byte, 32)
-	if _, err := rand.Read(buf); err != nil {
+	token, err := randomHex(32)
+	if err != nil {
 		return "", err
 	}
-	token := hex.EncodeToString(buf)
 	if err := os.WriteFile(p, []byte(token), 0o600); err != nil {
 		return "", err
 	}
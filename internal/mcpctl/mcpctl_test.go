@@ -2,12 +2,15 @@ package mcpctl
 
 import (
 	"context"
+	"errors"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"yanta/internal/config"
+	"yanta/internal/mcp"
 )
 
 // freePort returns a currently-free localhost TCP port.
@@ -114,3 +117,202 @@ func TestStatusWhenStoppedUsesDefaultPort(t *testing.T) {
 		t.Errorf("expected default port %d, got %d", defaultPort, st.Port)
 	}
 }
+
+func TestServiceTokens(t *testing.T) {
+	setup(t, false, 0)
+	s := NewService(NewManager(nil))
+	ctx := context.Background()
+
+	if _, err := s.CreateToken(ctx, "ci", mcp.Scope{Access: "root"}); err == nil {
+		t.Error("expected an unknown access level to be rejected")
+	}
+
+	scope := mcp.Scope{Access: mcp.AccessRead, Projects: []string{"@work"}}
+	created, err := s.CreateToken(ctx, "ci", scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Token == "" || created.ID == "" {
+		t.Fatalf("unexpected token: %+v", created)
+	}
+
+	stored, err := os.ReadFile(filepath.Join(config.GetAppRootDirectory(), "mcp-tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(stored), created.Token) {
+		t.Error("token stored in the clear")
+	}
+
+	tokens, err := s.ListTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || !tokens[0].Default || tokens[1].ID != created.ID || tokens[1].Scope.Access != mcp.AccessRead {
+		t.Errorf("unexpected tokens: %+v", tokens)
+	}
+
+	authn := s.mgr.tokens.authenticator("default-secret")
+	if grant, ok := authn("default-secret"); !ok || grant.Scope.Access != mcp.AccessAdmin {
+		t.Errorf("default token: %+v, %v", grant, ok)
+	}
+	if grant, ok := authn(created.Token); !ok || grant.TokenID != created.ID || !grant.Scope.AllowsProject("@work") {
+		t.Errorf("named token: %+v, %v", grant, ok)
+	}
+
+	if err := s.RevokeToken(ctx, defaultTokenID); err == nil {
+		t.Error("expected revoking the default token to fail")
+	}
+	if err := s.RevokeToken(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := authn(created.Token); ok {
+		t.Error("revoked token still accepted")
+	}
+	if err := s.RevokeToken(ctx, created.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("second revoke: got %v, want ErrTokenNotFound", err)
+	}
+}
+
+func TestDiscoveryHasOnlyDefaultToken(t *testing.T) {
+	setup(t, true, freePort(t))
+	m := NewManager(nil)
+	s := NewService(m)
+	created, err := s.CreateToken(context.Background(), "agent", mcp.Scope{Access: mcp.AccessWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop(context.Background())
+
+	data, err := os.ReadFile(filepath.Join(config.GetAppRootDirectory(), "mcp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), created.Token) || !strings.Contains(string(data), m.Status().Token) {
+		t.Errorf("discovery file should name only the default token: %s", data)
+	}
+}
+
+// undoVault implements just the document calls an undo makes.
+// undoVault holds the current hash of each document and records what undo
+// restores, refusing a restore over a hash that has moved on.
+type undoVault struct {
+	mcp.Vault
+	hashes   map[string]string
+	restored map[string]string
+}
+
+func newUndoVault(hashes map[string]string) *undoVault {
+	return &undoVault{hashes: hashes, restored: map[string]string{}}
+}
+
+func (v *undoVault) GetDocument(_ context.Context, path string) (mcp.DocumentContent, error) {
+	return mcp.DocumentContent{Path: path, Hash: v.hashes[path]}, nil
+}
+
+func (v *undoVault) RestoreDocument(_ context.Context, path, expectedHash string, snap mcp.DocumentSnapshot) (mcp.EditResult, error) {
+	if v.hashes[path] != expectedHash {
+		return mcp.EditResult{}, &mcp.ConflictError{Path: path, CurrentHash: v.hashes[path]}
+	}
+	v.restored[path] = string(snap.Data)
+	v.hashes[path] = snap.Hash
+	return mcp.EditResult{Path: path, Hash: snap.Hash}, nil
+}
+
+func undoableEdit(path, before, after string) mcp.AuditEntry {
+	return mcp.AuditEntry{
+		Client: "claude",
+		Tool:   "update_document",
+		Paths:  []string{path},
+		Write:  true,
+		OK:     true,
+		Undo: &mcp.Undo{
+			Path:      path,
+			Before:    &mcp.DocumentSnapshot{Hash: "h-" + before, Data: []byte(before)},
+			AfterHash: after,
+		},
+	}
+}
+
+type reasonRecorder []string
+
+func (r *reasonRecorder) NotifyChange(reason string) { *r = append(*r, reason) }
+
+func TestAuditLog(t *testing.T) {
+	setup(t, false, 0)
+	v := newUndoVault(map[string]string{"projects/@work/doc-1.json": "after"})
+	m := NewManager(v)
+	var reasons reasonRecorder
+	m.SetSyncNotifier(&reasons)
+	s := NewService(m)
+	ctx := context.Background()
+
+	if _, err := s.UndoLastChange(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("empty history: got %v, want ErrNothingToUndo", err)
+	}
+
+	m.audit.Record(mcp.AuditEntry{Tool: "get_document", OK: true})
+	m.audit.Record(undoableEdit("projects/@work/doc-1.json", "before", "after"))
+	m.audit.Record(mcp.AuditEntry{Tool: "append_journal", Write: true, OK: false})
+
+	if len(reasons) != 1 || reasons[0] != "mcp(claude): update_document projects/@work/doc-1.json" {
+		t.Errorf("sync reasons = %v", reasons)
+	}
+
+	entries, err := s.ListAuditLog(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Tool != "append_journal" || entries[1].Tool != "update_document" {
+		t.Errorf("entries = %+v; want the newest two, newest first", entries)
+	}
+
+	undone, err := s.UndoLastChange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone.Tool != "update_document" || v.restored["projects/@work/doc-1.json"] != "before" {
+		t.Errorf("undid %+v, restored %v", undone, v.restored)
+	}
+	if len(reasons) != 2 || reasons[1] != "mcp(yanta): undo update_document projects/@work/doc-1.json" {
+		t.Errorf("undo not reported to sync: %v", reasons)
+	}
+	if _, err := s.UndoLastChange(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("second undo: got %v, want ErrNothingToUndo", err)
+	}
+	if all, _ := s.ListAuditLog(ctx, 0); len(all) != 4 || all[0].Tool != "undo update_document" {
+		t.Errorf("undo not logged: %+v", all)
+	}
+}
+
+func TestAuditLog_UndoPastConflict(t *testing.T) {
+	setup(t, false, 0)
+	v := newUndoVault(map[string]string{"doc-1": "after-1", "doc-2": "after-2"})
+	m := NewManager(v)
+	s := NewService(m)
+	ctx := context.Background()
+
+	m.audit.Record(undoableEdit("doc-1", "before-1", "after-1"))
+	m.audit.Record(undoableEdit("doc-2", "before-2", "after-2"))
+	v.hashes["doc-2"] = "edited-by-hand"
+
+	if _, err := s.UndoLastChange(ctx); !errors.Is(err, mcp.ErrUndoConflict) {
+		t.Fatalf("first undo: got %v, want ErrUndoConflict", err)
+	}
+	undone, err := s.UndoLastChange(ctx)
+	if err != nil {
+		t.Fatalf("older change after a conflict: %v", err)
+	}
+	if undone.Undo.Path != "doc-1" || v.restored["doc-1"] != "before-1" {
+		t.Errorf("undid %+v, restored %v", undone, v.restored)
+	}
+	if _, ok := v.restored["doc-2"]; ok {
+		t.Error("the conflicted change must not be restored")
+	}
+	if _, err := s.UndoLastChange(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("third undo: got %v, want ErrNothingToUndo", err)
+	}
+}
@@ -2,10 +2,12 @@ package mcpctl
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
 	"yanta/internal/config"
+	"yanta/internal/mcp"
 )
 
 // Service is the Wails-bindable control surface for the MCP server, backed by a
@@ -67,6 +69,48 @@ func (s *Service) RegenerateToken(ctx context.Context) (Status, error) {
 	return s.mgr.Status(), nil
 }
 
+// ListTokens lists the MCP tokens, starting with the default one.
+func (s *Service) ListTokens(ctx context.Context) ([]TokenInfo, error) {
+	named, err := s.mgr.tokens.list()
+	if err != nil {
+		return nil, err
+	}
+	return append([]TokenInfo{{
+		ID:      defaultTokenID,
+		Name:    "Default",
+		Scope:   mcp.FullScope,
+		Default: true,
+	}}, named...), nil
+}
+
+// CreateToken creates a named token with the given scope. The returned token
+// is not stored anywhere in the clear and cannot be shown again.
+func (s *Service) CreateToken(ctx context.Context, name string, scope mcp.Scope) (NewToken, error) {
+	return s.mgr.tokens.create(name, scope)
+}
+
+// RevokeToken deletes a named token. Requests using it are refused from then
+// on, including those of sessions it already opened.
+func (s *Service) RevokeToken(ctx context.Context, id string) error {
+	if id == defaultTokenID {
+		return fmt.Errorf("the default token cannot be revoked; regenerate it instead")
+	}
+	return s.mgr.tokens.revoke(id)
+}
+
+// ListAuditLog returns the most recent MCP tool calls, newest first. A limit
+// of zero or less returns the whole log.
+func (s *Service) ListAuditLog(ctx context.Context, limit int) ([]mcp.AuditEntry, error) {
+	return s.mgr.audit.List(limit)
+}
+
+// UndoLastChange reverts the most recent MCP document change still in the
+// undo history and returns its audit entry. It refuses if the document has
+// been edited since.
+func (s *Service) UndoLastChange(ctx context.Context) (mcp.AuditEntry, error) {
+	return s.mgr.audit.Undo(ctx, s.mgr.vault)
+}
+
 func (s *Service) restartIfRunning() error {
 	if !s.mgr.isRunning() {
 		return nil
@@ -0,0 +1,208 @@
+package mcpctl
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"yanta/internal/config"
+	"yanta/internal/mcp"
+)
+
+// defaultTokenID names the token in the discovery file, which the `yanta mcp`
+// bridge uses. It always has full access and is replaced, not revoked, with
+// RegenerateToken.
+const defaultTokenID = "default"
+
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenInfo describes an MCP token. The token itself is only ever shown once,
+// when it is created.
+type TokenInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scope     mcp.Scope `json:"scope"`
+	CreatedAt string    `json:"createdAt,omitempty"`
+	Default   bool      `json:"default,omitempty"`
+}
+
+// NewToken is a freshly created token, returned once with its secret.
+type NewToken struct {
+	TokenInfo
+	Token string `json:"token"`
+}
+
+// storedToken is a named token as kept on disk: its SHA-256, never the token.
+type storedToken struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Scope     mcp.Scope `json:"scope"`
+	CreatedAt string    `json:"created_at"`
+}
+
+func (t storedToken) info() TokenInfo {
+	return TokenInfo{ID: t.ID, Name: t.Name, Scope: t.Scope, CreatedAt: t.CreatedAt}
+}
+
+func tokensPath() string { return filepath.Join(config.GetAppRootDirectory(), "mcp-tokens.json") }
+
+// tokenStore holds the named tokens. It is read from disk once and written
+// through on every change, so a revoked token stops working immediately.
+type tokenStore struct {
+	mu     sync.Mutex
+	loaded bool
+	tokens []storedToken
+}
+
+func (s *tokenStore) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(tokensPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading MCP tokens: %w", err)
+	}
+	s.tokens = nil
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.tokens); err != nil {
+			return fmt.Errorf("parsing MCP tokens: %w", err)
+		}
+	}
+	s.loaded = true
+	return nil
+}
+
+func (s *tokenStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tokensPath(), data, 0o600)
+}
+
+func (s *tokenStore) list() ([]TokenInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	out := make([]TokenInfo, 0, len(s.tokens))
+	for _, t := range s.tokens {
+		out = append(out, t.info())
+	}
+	return out, nil
+}
+
+func (s *tokenStore) create(name string, scope mcp.Scope) (NewToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return NewToken{}, fmt.Errorf("token name is required")
+	}
+	if err := scope.Validate(); err != nil {
+		return NewToken{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return NewToken{}, err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return NewToken{}, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return NewToken{}, err
+	}
+	t := storedToken{
+		ID:        id,
+		Name:      name,
+		Hash:      hashToken(secret),
+		Scope:     scope,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	s.tokens = append(s.tokens, t)
+	if err := s.saveLocked(); err != nil {
+		s.tokens = s.tokens[:len(s.tokens)-1]
+		return NewToken{}, fmt.Errorf("saving MCP tokens: %w", err)
+	}
+	return NewToken{TokenInfo: t.info(), Token: secret}, nil
+}
+
+func (s *tokenStore) revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.tokens, func(t storedToken) bool { return t.ID == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	kept := slices.Delete(slices.Clone(s.tokens), i, i+1)
+	prev := s.tokens
+	s.tokens = kept
+	if err := s.saveLocked(); err != nil {
+		s.tokens = prev
+		return fmt.Errorf("saving MCP tokens: %w", err)
+	}
+	return nil
+}
+
+// lookup finds the named token with the given secret.
+func (s *tokenStore) lookup(secret string) (storedToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return storedToken{}, false
+	}
+	hash := hashToken(secret)
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(t.Hash)) == 1 {
+			return t, true
+		}
+	}
+	return storedToken{}, false
+}
+
+// authenticator accepts the default token with full access and every named
+// token with its own scope.
+func (s *tokenStore) authenticator(defaultToken string) mcp.Authenticator {
+	accept := mcp.StaticToken(defaultToken)
+	return func(presented string) (mcp.Grant, bool) {
+		if grant, ok := accept(presented); ok {
+			return grant, true
+		}
+		t, ok := s.lookup(presented)
+		if !ok {
+			return mcp.Grant{}, false
+		}
+		return mcp.Grant{TokenID: t.ID, Name: t.Name, Scope: t.Scope}, true
+	}
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,278 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+
+	"yanta/internal/logger"
+	"yanta/internal/plugins/manifest"
+)
+
+// devReloadDebounce collapses the burst of writes a rebuild makes into one
+// reload.
+const devReloadDebounce = 250 * time.Millisecond
+
+// LinkDevPlugin links a plugin's working directory into the plugin
+// directory, so the app runs it from where it is edited instead of from a
+// copy. The link is named after the manifest ID; linking the same directory
+// again is a no-op. Only the manifest is checked: the entry may not be built
+// yet, and until it is the plugin is listed with a build issue.
+func (s *Service) LinkDevPlugin(sourcePath string) (InstallRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+
+	root, err := s.pluginDir()
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	linked, err := manifest.LinkDev(root, sourcePath)
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	return s.recordAfterSwap(linked.ID)
+}
+
+// UnlinkDevPlugin removes a dev link. The working directory, the plugin's
+// storage and its enabled toggle are left alone.
+func (s *Service) UnlinkDevPlugin(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+
+	root, err := s.pluginDir()
+	if err != nil {
+		return err
+	}
+	if err := manifest.UnlinkDev(root, id); err != nil {
+		return err
+	}
+	s.clearIssues(strings.TrimSpace(id))
+	return nil
+}
+
+// DevWatcher reloads dev-linked plugins: when a link is added or removed,
+// and when a linked plugin's manifest, entry or build metadata changes.
+type DevWatcher struct {
+	svc      *Service
+	onReload func(pluginID string)
+
+	watcher *fsnotify.Watcher
+	root    string
+
+	// mu guards dirs, the watched directories of each linked plugin by
+	// plugin ID, timers, the pending reload of each plugin, and closed. A
+	// reload only starts while closed is unset, and Close waits for the ones
+	// running, in reloading.
+	mu        sync.Mutex
+	dirs      map[string][]string
+	timers    map[string]*time.Timer
+	closed    bool
+	reloading sync.WaitGroup
+	done      chan struct{}
+}
+
+func NewDevWatcher(svc *Service, onReload func(pluginID string)) *DevWatcher {
+	return &DevWatcher{
+		svc:      svc,
+		onReload: onReload,
+		dirs:     map[string][]string{},
+		timers:   map[string]*time.Timer{},
+		done:     make(chan struct{}),
+	}
+}
+
+// Start watches the plugin directory until Close.
+func (w *DevWatcher) Start() error {
+	root, err := w.svc.pluginDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return fmt.Errorf("create plugin directory %s: %w", root, err)
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating fsnotify watcher: %w", err)
+	}
+	if err := fsw.Add(root); err != nil {
+		_ = fsw.Close()
+		return fmt.Errorf("watch plugin directory %s: %w", root, err)
+	}
+	w.watcher = fsw
+	w.root = root
+	w.syncLinks()
+
+	go w.run()
+	return nil
+}
+
+// Close stops watching and drops pending reloads. A reload already running
+// finishes before Close returns, so none is reported after it.
+func (w *DevWatcher) Close() {
+	if w.watcher == nil {
+		return
+	}
+	_ = w.watcher.Close()
+	<-w.done
+
+	w.mu.Lock()
+	w.closed = true
+	for id, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, id)
+	}
+	w.mu.Unlock()
+	w.reloading.Wait()
+}
+
+func (w *DevWatcher) run() {
+	defer close(w.done)
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.WithError(err).Warn("plugin dev watcher error")
+		}
+	}
+}
+
+func (w *DevWatcher) handle(event fsnotify.Event) {
+	if filepath.Dir(event.Name) == w.root {
+		for _, id := range w.syncLinks() {
+			w.schedule(id)
+		}
+		return
+	}
+	if !isReloadTrigger(filepath.Base(event.Name)) {
+		return
+	}
+	dir := filepath.Dir(event.Name)
+	w.mu.Lock()
+	var changed []string
+	for id, dirs := range w.dirs {
+		for _, watched := range dirs {
+			if watched == dir {
+				changed = append(changed, id)
+				break
+			}
+		}
+	}
+	w.mu.Unlock()
+	for _, id := range changed {
+		w.schedule(id)
+	}
+}
+
+// syncLinks watches links added to the plugin directory and forgets
+// removed ones, returning the IDs of both.
+func (w *DevWatcher) syncLinks() []string {
+	current := map[string][]string{}
+	entries, err := os.ReadDir(w.root)
+	if err != nil {
+		logger.WithError(err).Warn("plugin dev watcher: reading plugin directory failed")
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		target, err := filepath.EvalSymlinks(filepath.Join(w.root, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			continue
+		}
+		current[entry.Name()] = devWatchDirs(target)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var changed []string
+	for id, dirs := range w.dirs {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		for _, dir := range dirs {
+			_ = w.watcher.Remove(dir)
+		}
+		delete(w.dirs, id)
+		changed = append(changed, id)
+	}
+	for id, dirs := range current {
+		if _, ok := w.dirs[id]; ok {
+			continue
+		}
+		for _, dir := range dirs {
+			if err := w.watcher.Add(dir); err != nil {
+				logger.WithError(err).WithField("plugin", id).Warn("plugin dev watcher: watching plugin failed")
+			}
+		}
+		w.dirs[id] = dirs
+		changed = append(changed, id)
+	}
+	return changed
+}
+
+func (w *DevWatcher) schedule(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.timers[id]; ok {
+		timer.Stop()
+	}
+	w.timers[id] = time.AfterFunc(devReloadDebounce, func() {
+		w.mu.Lock()
+		if w.closed {
+			// The timer fired as Close stopped it.
+			w.mu.Unlock()
+			return
+		}
+		delete(w.timers, id)
+		w.reloading.Add(1)
+		w.mu.Unlock()
+		defer w.reloading.Done()
+		w.svc.invalidateRecords()
+		w.onReload(id)
+	})
+}
+
+// devWatchDirs is the directory of a linked plugin and, when its entry is a
+// WASM module in a subdirectory, the entry's directory.
+func devWatchDirs(target string) []string {
+	dirs := []string{target}
+	var manifest Manifest
+	if _, err := toml.DecodeFile(filepath.Join(target, "plugin.toml"), &manifest); err != nil {
+		return dirs
+	}
+	if !isWASMEntry(manifest.Entry) || !isLocalPath(manifest.Entry) {
+		return dirs
+	}
+	entryDir := filepath.Dir(filepath.Join(target, filepath.FromSlash(strings.TrimSpace(manifest.Entry))))
+	if entryDir != target {
+		dirs = append(dirs, entryDir)
+	}
+	return dirs
+}
+
+func isReloadTrigger(name string) bool {
+	switch name {
+	case "plugin.toml", requiredPluginEntrypoint, requiredPluginBuildMetadataFile:
+		return true
+	}
+	return isWASMEntry(name)
+}
@@ -0,0 +1,166 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/config"
+	"yanta/internal/testenv"
+)
+
+const devManifest = `id = "dev.plugin"
+name = "Dev Plugin"
+version = "0.1.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["commands"]
+`
+
+func setupDevPlugin(t *testing.T) (string, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cleanup := testenv.SetTestHome(t, tempDir)
+	t.Cleanup(cleanup)
+
+	config.ResetForTesting()
+	require.NoError(t, config.Init())
+
+	workDir := filepath.Join(tempDir, "work", "dev-plugin")
+	require.NoError(t, os.MkdirAll(workDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "plugin.toml"), []byte(devManifest), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "main.js"), []byte("export function setup() {}"), 0o644))
+	writePluginBuildMetadata(t, workDir, nil)
+	return tempDir, workDir
+}
+
+func TestService_LinkDevPlugin(t *testing.T) {
+	tempDir, workDir := setupDevPlugin(t)
+	svc := NewService()
+
+	record, err := svc.LinkDevPlugin(workDir)
+	require.NoError(t, err)
+	require.Equal(t, "dev.plugin", record.Manifest.ID)
+	require.Equal(t, pluginSourceDev, record.Source)
+	require.True(t, record.CanExecute)
+
+	_, err = svc.LinkDevPlugin(workDir)
+	require.NoError(t, err, "relinking the same directory is a no-op")
+
+	otherDir := filepath.Join(tempDir, "work", "other")
+	require.NoError(t, os.MkdirAll(otherDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, "plugin.toml"), []byte(devManifest), 0o644))
+	_, err = svc.LinkDevPlugin(otherDir)
+	require.ErrorContains(t, err, PluginErrAlreadyInstalled)
+
+	source, err := svc.ReadPluginEntrypoint("dev.plugin")
+	require.NoError(t, err)
+	require.Equal(t, "export function setup() {}", source)
+
+	require.NoError(t, svc.UnlinkDevPlugin("dev.plugin"))
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Empty(t, list)
+	_, err = os.Stat(filepath.Join(workDir, "main.js"))
+	require.NoError(t, err, "unlinking leaves the working directory alone")
+}
+
+func TestService_UnlinkDevPluginRefusesInstalledPlugin(t *testing.T) {
+	tempDir, _ := setupDevPlugin(t)
+	createInstalledPlugin(t, tempDir, "sample.plugin", `id = "sample.plugin"
+name = "Sample Plugin"
+version = "1.0.0"
+api_version = "1"
+entry = "main.js"
+`)
+
+	err := NewService().UnlinkDevPlugin("sample.plugin")
+	require.ErrorContains(t, err, PluginErrBadSource)
+	_, err = os.Stat(filepath.Join(tempDir, ".yanta", "plugins", "sample.plugin", "plugin.toml"))
+	require.NoError(t, err)
+}
+
+func TestDevWatcher_ReloadsLinkedPlugin(t *testing.T) {
+	_, workDir := setupDevPlugin(t)
+	svc := NewService()
+
+	reloads := make(chan string, 8)
+	watcher := NewDevWatcher(svc, func(id string) { reloads <- id })
+	require.NoError(t, watcher.Start())
+	t.Cleanup(watcher.Close)
+
+	_, err := svc.LinkDevPlugin(workDir)
+	require.NoError(t, err)
+	requireReload(t, reloads, "dev.plugin")
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "notes.txt"), []byte("not a trigger"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "main.js"), []byte("export function setup() { return 1 }"), 0o644))
+	requireReload(t, reloads, "dev.plugin")
+
+	require.NoError(t, svc.UnlinkDevPlugin("dev.plugin"))
+	requireReload(t, reloads, "dev.plugin")
+}
+
+func TestDevWatcher_CloseWaitsForRunningReload(t *testing.T) {
+	_, workDir := setupDevPlugin(t)
+	svc := NewService()
+
+	started := make(chan string, 1)
+	release := make(chan struct{})
+	watcher := NewDevWatcher(svc, func(id string) {
+		started <- id
+		<-release
+	})
+	require.NoError(t, watcher.Start())
+
+	_, err := svc.LinkDevPlugin(workDir)
+	require.NoError(t, err)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload started")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		watcher.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a reload was running")
+	case <-time.After(2 * devReloadDebounce):
+	}
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return after the reload finished")
+	}
+
+	watcher.schedule("dev.plugin")
+	time.Sleep(2 * devReloadDebounce)
+	select {
+	case id := <-started:
+		t.Fatalf("reload of %q after Close", id)
+	default:
+	}
+}
+
+func requireReload(t *testing.T, reloads <-chan string, id string) {
+	t.Helper()
+	select {
+	case got := <-reloads:
+		require.Equal(t, id, got)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no reload of %q", id)
+	}
+	select {
+	case got := <-reloads:
+		t.Fatalf("unexpected second reload of %q", got)
+	case <-time.After(2 * devReloadDebounce):
+	}
+}
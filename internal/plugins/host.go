@@ -0,0 +1,537 @@
+package plugins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"yanta/internal/logger"
+	"yanta/internal/plugins/manifest"
+)
+
+// maxHostSearchResults caps the results of one search call.
+const maxHostSearchResults = 100
+
+// HostVault is the part of the vault the host API exposes to plugins. The
+// app adapts its services to it, which keeps this package free of them.
+type HostVault interface {
+	GetDocument(ctx context.Context, path string) (HostDocument, error)
+	CreateDocument(ctx context.Context, projectAlias, title, markdown string, tags []string) (HostDocument, error)
+	// UpdateDocument changes the given fields; nil ones are kept. A non-empty
+	// expectedHash makes it fail if the document changed since it was read.
+	UpdateDocument(ctx context.Context, path, expectedHash string, title, markdown *string, tags *[]string) (HostDocument, error)
+	Search(ctx context.Context, query string, limit int) ([]HostSearchHit, error)
+	AppendJournal(ctx context.Context, projectAlias, content string, tags []string) (HostJournalEntry, error)
+}
+
+// HostDocument is a document with its body as Markdown. Hash identifies the
+// version, for updates that must not overwrite a newer save.
+type HostDocument struct {
+	Path         string   `json:"path"`
+	Title        string   `json:"title"`
+	ProjectAlias string   `json:"projectAlias"`
+	Tags         []string `json:"tags,omitempty"`
+	Markdown     string   `json:"markdown"`
+	Hash         string   `json:"hash"`
+}
+
+type HostSearchHit struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	Snippet      string `json:"snippet"`
+	ProjectAlias string `json:"projectAlias"`
+}
+
+type HostJournalEntry struct {
+	ID      string   `json:"id"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
+	Created string   `json:"created"`
+}
+
+// HostCommand is a command a plugin registered with the host.
+type HostCommand struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// PluginCommand is a registered command with the plugin that owns it.
+type PluginCommand struct {
+	PluginID string `json:"pluginId"`
+	HostCommand
+}
+
+// HostRequest is one message from a plugin to the host: the session token
+// the plugin was loaded with, a method name and its JSON parameters. The
+// calling plugin is the one the session was issued to; a request cannot name
+// another.
+type HostRequest struct {
+	Session string          `json:"session"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type hostMethod struct {
+	capability string
+	call       func(h *Host, ctx context.Context, pluginID string, params json.RawMessage) (any, error)
+}
+
+// hostMethods lists every host API method with the capability it requires.
+var hostMethods = map[string]hostMethod{
+	"documents.read":    {manifest.CapabilityDocumentsRead, (*Host).readDocument},
+	"documents.create":  {manifest.CapabilityDocumentsWrite, (*Host).createDocument},
+	"documents.update":  {manifest.CapabilityDocumentsWrite, (*Host).updateDocument},
+	"search":            {manifest.CapabilitySearch, (*Host).search},
+	"journal.append":    {manifest.CapabilityJournal, (*Host).appendJournal},
+	"commands.register": {manifest.CapabilityCommands, (*Host).registerCommands},
+	"storage.get":       {manifest.CapabilityStorage, (*Host).storageGet},
+	"storage.set":       {manifest.CapabilityStorage, (*Host).storageSet},
+	"storage.delete":    {manifest.CapabilityStorage, (*Host).storageDelete},
+	"storage.keys":      {manifest.CapabilityStorage, (*Host).storageKeys},
+}
+
+// Host serves the host API plugins call through the frontend bridge. Every
+// call is checked against the capabilities in the caller's manifest; a
+// denied call is logged and reported in the plugin's install record.
+type Host struct {
+	svc   *Service
+	vault HostVault
+
+	mu       sync.Mutex
+	commands map[string][]HostCommand
+
+	// sessionsMu guards sessions, the plugin each session token was issued
+	// to, and tokens, the open session of each plugin.
+	sessionsMu sync.Mutex
+	sessions   map[string]string
+	tokens     map[string]string
+}
+
+func NewHost(svc *Service, vault HostVault) *Host {
+	return &Host{
+		svc:      svc,
+		vault:    vault,
+		commands: map[string][]HostCommand{},
+		sessions: map[string]string{},
+		tokens:   map[string]string{},
+	}
+}
+
+// OpenSession issues the token a plugin's frontend code calls the host with,
+// when the frontend reads its entrypoint. A plugin has one session until its
+// code goes away; opening another is refused, so code already running cannot
+// take the session of a plugin loaded after it.
+func (h *Host) OpenSession(pluginID string) (string, error) {
+	pluginID = strings.TrimSpace(pluginID)
+	if pluginID == "" {
+		return "", errors.New("plugin id is required")
+	}
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	if _, ok := h.tokens[pluginID]; ok {
+		return "", pluginError(PluginErrAlreadyLoaded, fmt.Sprintf("plugin %q is already loaded", pluginID))
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate plugin session: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+	h.sessions[token] = pluginID
+	h.tokens[pluginID] = token
+	return token, nil
+}
+
+// EndSession closes a plugin's session, when its code is removed or replaced.
+func (h *Host) EndSession(pluginID string) {
+	pluginID = strings.TrimSpace(pluginID)
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	if token, ok := h.tokens[pluginID]; ok {
+		delete(h.sessions, token)
+		delete(h.tokens, pluginID)
+	}
+}
+
+// ResetSessions closes every session, when the frontend reloads and the
+// plugin code with it.
+func (h *Host) ResetSessions() {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	h.sessions = map[string]string{}
+	h.tokens = map[string]string{}
+}
+
+// sessionPlugin returns the plugin a session token was issued to.
+func (h *Host) sessionPlugin(token string) (string, error) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	pluginID, ok := h.sessions[token]
+	if !ok || token == "" {
+		return "", pluginError(PluginErrBadSession, "unknown plugin session")
+	}
+	return pluginID, nil
+}
+
+// Call runs one host API request from the frontend, for the enabled plugin
+// its session was issued to.
+func (h *Host) Call(ctx context.Context, req HostRequest) (any, error) {
+	pluginID, err := h.sessionPlugin(req.Session)
+	if err != nil {
+		return nil, err
+	}
+	return h.call(ctx, pluginID, req.Method, req.Params)
+}
+
+// call runs one host API request for an enabled plugin. Callers have
+// established which plugin is calling.
+func (h *Host) call(ctx context.Context, pluginID, name string, params json.RawMessage) (any, error) {
+	method, ok := hostMethods[name]
+	if !ok {
+		return nil, pluginError(PluginErrBadHostCall, fmt.Sprintf("unknown host method %q", name))
+	}
+	record, err := h.svc.runnableRecord(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasCapability(record.Manifest, method.capability) {
+		return nil, h.deny(pluginID, name, method.capability)
+	}
+	return method.call(h, ctx, pluginID, params)
+}
+
+// Commands lists the commands registered by plugins, by plugin then ID.
+func (h *Host) Commands() []PluginCommand {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := []PluginCommand{}
+	for pluginID, commands := range h.commands {
+		for _, c := range commands {
+			out = append(out, PluginCommand{PluginID: pluginID, HostCommand: c})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PluginID != out[j].PluginID {
+			return out[i].PluginID < out[j].PluginID
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Release drops what a plugin registered with the host, when it is disabled
+// or uninstalled.
+func (h *Host) Release(pluginID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.commands, strings.TrimSpace(pluginID))
+}
+
+// ReleaseSession is Release for the plugin a session was issued to, when the
+// frontend unloads it. The session stays open, for the plugin to be loaded
+// again.
+func (h *Host) ReleaseSession(token string) error {
+	pluginID, err := h.sessionPlugin(token)
+	if err != nil {
+		return err
+	}
+	h.Release(pluginID)
+	return nil
+}
+
+func (h *Host) deny(pluginID, method, capability string) error {
+	message := fmt.Sprintf("host call %s requires undeclared capability %q", method, capability)
+	logger.WithFields(map[string]any{
+		"plugin":     pluginID,
+		"method":     method,
+		"capability": capability,
+	}).Warn("plugin host call denied")
+	h.svc.recordIssue(pluginID, ValidationIssue{
+		Code:    "CAPABILITY_DENIED",
+		Message: message,
+		Field:   "capabilities",
+	})
+	return pluginError(PluginErrCapabilityDenied, fmt.Sprintf("plugin %q: %s", pluginID, message))
+}
+
+func decodeParams(method string, raw json.RawMessage, dst any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return pluginError(PluginErrBadHostCall, fmt.Sprintf("%s: invalid params: %v", method, err))
+	}
+	return nil
+}
+
+func (h *Host) readDocument(ctx context.Context, _ string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Path string `json:"path"`
+	}
+	if err := decodeParams("documents.read", raw, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.Path) == "" {
+		return nil, pluginError(PluginErrBadHostCall, "documents.read: path is required")
+	}
+	return h.vault.GetDocument(ctx, p.Path)
+}
+
+func (h *Host) createDocument(ctx context.Context, _ string, raw json.RawMessage) (any, error) {
+	var p struct {
+		ProjectAlias string   `json:"projectAlias"`
+		Title        string   `json:"title"`
+		Markdown     string   `json:"markdown"`
+		Tags         []string `json:"tags"`
+	}
+	if err := decodeParams("documents.create", raw, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.ProjectAlias) == "" || strings.TrimSpace(p.Title) == "" {
+		return nil, pluginError(PluginErrBadHostCall, "documents.create: projectAlias and title are required")
+	}
+	return h.vault.CreateDocument(ctx, p.ProjectAlias, p.Title, p.Markdown, p.Tags)
+}
+
+func (h *Host) updateDocument(ctx context.Context, _ string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Path         string    `json:"path"`
+		ExpectedHash string    `json:"expectedHash"`
+		Title        *string   `json:"title"`
+		Markdown     *string   `json:"markdown"`
+		Tags         *[]string `json:"tags"`
+	}
+	if err := decodeParams("documents.update", raw, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.Path) == "" {
+		return nil, pluginError(PluginErrBadHostCall, "documents.update: path is required")
+	}
+	return h.vault.UpdateDocument(ctx, p.Path, p.ExpectedHash, p.Title, p.Markdown, p.Tags)
+}
+
+func (h *Host) search(ctx context.Context, _ string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := decodeParams("search", raw, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.Query) == "" {
+		return nil, pluginError(PluginErrBadHostCall, "search: query is required")
+	}
+	if p.Limit <= 0 || p.Limit > maxHostSearchResults {
+		p.Limit = maxHostSearchResults
+	}
+	return h.vault.Search(ctx, p.Query, p.Limit)
+}
+
+func (h *Host) appendJournal(ctx context.Context, _ string, raw json.RawMessage) (any, error) {
+	var p struct {
+		ProjectAlias string   `json:"projectAlias"`
+		Content      string   `json:"content"`
+		Tags         []string `json:"tags"`
+	}
+	if err := decodeParams("journal.append", raw, &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.ProjectAlias) == "" || strings.TrimSpace(p.Content) == "" {
+		return nil, pluginError(PluginErrBadHostCall, "journal.append: projectAlias and content are required")
+	}
+	return h.vault.AppendJournal(ctx, p.ProjectAlias, p.Content, p.Tags)
+}
+
+// registerCommands replaces the plugin's registered commands.
+func (h *Host) registerCommands(_ context.Context, pluginID string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Commands []HostCommand `json:"commands"`
+	}
+	if err := decodeParams("commands.register", raw, &p); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, c := range p.Commands {
+		if strings.TrimSpace(c.ID) == "" {
+			return nil, pluginError(PluginErrBadHostCall, "commands.register: every command needs an id")
+		}
+		if seen[c.ID] {
+			return nil, pluginError(PluginErrBadHostCall, fmt.Sprintf("commands.register: duplicate command id %q", c.ID))
+		}
+		seen[c.ID] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.commands[pluginID] = append([]HostCommand(nil), p.Commands...)
+	return len(p.Commands), nil
+}
+
+func (h *Host) storageGet(_ context.Context, pluginID string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Key string `json:"key"`
+	}
+	if err := decodeParams("storage.get", raw, &p); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := loadPluginStorage(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := data[p.Key]
+	if !ok {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (h *Host) storageSet(_ context.Context, pluginID string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := decodeParams("storage.set", raw, &p); err != nil {
+		return nil, err
+	}
+	if err := validateStorageKey(p.Key); err != nil {
+		return nil, err
+	}
+	if len(p.Value) == 0 {
+		return nil, pluginError(PluginErrBadHostCall, "storage.set: value is required")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := loadPluginStorage(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	data[p.Key] = p.Value
+	return nil, savePluginStorage(pluginID, data)
+}
+
+func (h *Host) storageDelete(_ context.Context, pluginID string, raw json.RawMessage) (any, error) {
+	var p struct {
+		Key string `json:"key"`
+	}
+	if err := decodeParams("storage.delete", raw, &p); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := loadPluginStorage(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := data[p.Key]; !ok {
+		return nil, nil
+	}
+	delete(data, p.Key)
+	return nil, savePluginStorage(pluginID, data)
+}
+
+func (h *Host) storageKeys(_ context.Context, pluginID string, _ json.RawMessage) (any, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := loadPluginStorage(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// runnableRecord returns the install record of a plugin that may run: it is
+// installed, valid, executable and enabled.
+func (s *Service) runnableRecord(id string) (InstallRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id == "" {
+		return InstallRecord{}, errors.New("plugin id is required")
+	}
+	records, err := s.cachedRecords()
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	for _, r := range records {
+		if r.Manifest.ID != id {
+			continue
+		}
+		if r.Status != PluginStatusOK {
+			return InstallRecord{}, pluginError(PluginErrNotOperational, fmt.Sprintf("plugin %q is not operational", id))
+		}
+		if !r.CanExecute {
+			return InstallRecord{}, pluginError(PluginErrSandboxRestricted, fmt.Sprintf("plugin %q cannot execute", id))
+		}
+		if !r.Enabled {
+			return InstallRecord{}, pluginError(PluginErrNotOperational, fmt.Sprintf("plugin %q is not enabled", id))
+		}
+		return r, nil
+	}
+	return InstallRecord{}, pluginError(PluginErrNotInstalled, fmt.Sprintf("plugin %q is not installed", id))
+}
+
+// cachedRecords returns the install records, scanning the plugin directory
+// only when they were dropped. The caller holds s.mu.
+func (s *Service) cachedRecords() ([]InstallRecord, error) {
+	s.recordsMu.Lock()
+	defer s.recordsMu.Unlock()
+	if s.records != nil {
+		return s.records, nil
+	}
+	records, err := s.scanLocalPlugins()
+	if err != nil {
+		return nil, err
+	}
+	s.records = records
+	return records, nil
+}
+
+// invalidateRecords drops the cached install records after a change to what
+// is installed or enabled, or to a dev-linked plugin's files.
+func (s *Service) invalidateRecords() {
+	s.recordsMu.Lock()
+	defer s.recordsMu.Unlock()
+	s.records = nil
+}
+
+// recordIssue remembers a runtime problem once per distinct message.
+func (s *Service) recordIssue(id string, issue ValidationIssue) {
+	s.issuesMu.Lock()
+	defer s.issuesMu.Unlock()
+
+	for _, existing := range s.runtimeIssues[id] {
+		if existing.Message == issue.Message {
+			return
+		}
+	}
+	if s.runtimeIssues == nil {
+		s.runtimeIssues = map[string][]ValidationIssue{}
+	}
+	s.runtimeIssues[id] = append(s.runtimeIssues[id], issue)
+}
+
+func (s *Service) issuesOf(id string) []ValidationIssue {
+	s.issuesMu.Lock()
+	defer s.issuesMu.Unlock()
+	return append([]ValidationIssue(nil), s.runtimeIssues[id]...)
+}
+
+func (s *Service) clearIssues(id string) {
+	s.issuesMu.Lock()
+	defer s.issuesMu.Unlock()
+	delete(s.runtimeIssues, id)
+}
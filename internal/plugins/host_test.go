@@ -0,0 +1,301 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/config"
+	"yanta/internal/testenv"
+)
+
+type fakeHostVault struct {
+	docs    map[string]HostDocument
+	journal []HostJournalEntry
+	query   string
+	onGet   func(path string)
+}
+
+func (f *fakeHostVault) GetDocument(_ context.Context, path string) (HostDocument, error) {
+	if f.onGet != nil {
+		f.onGet(path)
+	}
+	return f.docs[path], nil
+}
+
+func (f *fakeHostVault) CreateDocument(_ context.Context, alias, title, markdown string, tags []string) (HostDocument, error) {
+	doc := HostDocument{Path: "projects/" + alias + "/doc-new.json", Title: title, ProjectAlias: alias, Tags: tags, Markdown: markdown, Hash: "h1"}
+	f.docs[doc.Path] = doc
+	return doc, nil
+}
+
+func (f *fakeHostVault) UpdateDocument(_ context.Context, path, _ string, title, markdown *string, _ *[]string) (HostDocument, error) {
+	doc := f.docs[path]
+	if title != nil {
+		doc.Title = *title
+	}
+	if markdown != nil {
+		doc.Markdown = *markdown
+	}
+	f.docs[path] = doc
+	return doc, nil
+}
+
+func (f *fakeHostVault) Search(_ context.Context, query string, _ int) ([]HostSearchHit, error) {
+	f.query = query
+	return []HostSearchHit{{ID: "projects/@work/doc-1.json", Type: "document", Title: "Plan"}}, nil
+}
+
+func (f *fakeHostVault) AppendJournal(_ context.Context, _, content string, tags []string) (HostJournalEntry, error) {
+	entry := HostJournalEntry{ID: "e1", Content: content, Tags: tags}
+	f.journal = append(f.journal, entry)
+	return entry, nil
+}
+
+func setupHost(t *testing.T, capabilities string) (*Service, *Host, *fakeHostVault) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cleanup := testenv.SetTestHome(t, tempDir)
+	t.Cleanup(cleanup)
+
+	config.ResetForTesting()
+	require.NoError(t, config.Init())
+
+	createInstalledPlugin(t, tempDir, "host.plugin", `id = "host.plugin"
+name = "Host Plugin"
+version = "1.0.0"
+api_version = "1"
+entry = "main.js"
+capabilities = `+capabilities+`
+`)
+	svc := NewService()
+	require.NoError(t, svc.SetCommunityPluginsEnabled(true))
+	require.NoError(t, svc.SetPluginEnabled("host.plugin", true))
+
+	vault := &fakeHostVault{docs: map[string]HostDocument{
+		"projects/@work/doc-1.json": {Path: "projects/@work/doc-1.json", Title: "Plan", Markdown: "# Plan\n", Hash: "h0"},
+	}}
+	return svc, NewHost(svc, vault), vault
+}
+
+func call(t *testing.T, h *Host, method string, params any) (any, error) {
+	t.Helper()
+	raw, err := json.Marshal(params)
+	require.NoError(t, err)
+	return h.Call(context.Background(), HostRequest{Session: sessionFor(t, h, "host.plugin"), Method: method, Params: raw})
+}
+
+// sessionFor returns a plugin's session, opening it on first use.
+func sessionFor(t *testing.T, h *Host, pluginID string) string {
+	t.Helper()
+	h.sessionsMu.Lock()
+	token, ok := h.tokens[pluginID]
+	h.sessionsMu.Unlock()
+	if ok {
+		return token
+	}
+	token, err := h.OpenSession(pluginID)
+	require.NoError(t, err)
+	return token
+}
+
+func TestHost_CallsAllowedByCapabilities(t *testing.T) {
+	_, host, vault := setupHost(t, `["documentsRead", "documentsWrite", "search", "journal", "commands", "storage"]`)
+
+	res, err := call(t, host, "documents.read", map[string]any{"path": "projects/@work/doc-1.json"})
+	require.NoError(t, err)
+	require.Equal(t, "# Plan\n", res.(HostDocument).Markdown)
+
+	res, err = call(t, host, "documents.create", map[string]any{"projectAlias": "@work", "title": "Notes", "markdown": "hi"})
+	require.NoError(t, err)
+	require.Equal(t, "projects/@work/doc-new.json", res.(HostDocument).Path)
+
+	res, err = call(t, host, "documents.update", map[string]any{"path": "projects/@work/doc-1.json", "title": "Renamed"})
+	require.NoError(t, err)
+	require.Equal(t, "Renamed", res.(HostDocument).Title)
+	require.Equal(t, "# Plan\n", res.(HostDocument).Markdown)
+
+	_, err = call(t, host, "search", map[string]any{"query": "plan"})
+	require.NoError(t, err)
+	require.Equal(t, "plan", vault.query)
+
+	_, err = call(t, host, "journal.append", map[string]any{"projectAlias": "@work", "content": "did a thing"})
+	require.NoError(t, err)
+	require.Len(t, vault.journal, 1)
+
+	_, err = call(t, host, "commands.register", map[string]any{"commands": []HostCommand{{ID: "b", Title: "B"}, {ID: "a", Title: "A"}}})
+	require.NoError(t, err)
+	commands := host.Commands()
+	require.Len(t, commands, 2)
+	require.Equal(t, "a", commands[0].ID)
+	require.Equal(t, "host.plugin", commands[0].PluginID)
+	host.Release("host.plugin")
+	require.Empty(t, host.Commands())
+
+	_, err = call(t, host, "storage.set", map[string]any{"key": "count", "value": 3})
+	require.NoError(t, err)
+	res, err = call(t, host, "storage.get", map[string]any{"key": "count"})
+	require.NoError(t, err)
+	require.JSONEq(t, "3", string(res.(json.RawMessage)))
+	res, err = call(t, host, "storage.keys", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"count"}, res)
+	_, err = call(t, host, "storage.delete", map[string]any{"key": "count"})
+	require.NoError(t, err)
+	res, err = call(t, host, "storage.get", map[string]any{"key": "count"})
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	_, err = call(t, host, "storage.set", map[string]any{"key": "big", "value": strings.Repeat("x", maxStorageBytes)})
+	require.ErrorContains(t, err, PluginErrStorageQuota)
+
+	_, err = call(t, host, "documents.destroy", nil)
+	require.ErrorContains(t, err, PluginErrBadHostCall)
+}
+
+func TestHost_DeniesUndeclaredCapability(t *testing.T) {
+	svc, host, vault := setupHost(t, `["documentsRead"]`)
+
+	_, err := call(t, host, "documents.update", map[string]any{"path": "projects/@work/doc-1.json", "title": "Hijacked"})
+	require.ErrorContains(t, err, PluginErrCapabilityDenied)
+	require.Equal(t, "Plan", vault.docs["projects/@work/doc-1.json"].Title)
+
+	// Repeated denials are reported once.
+	_, err = call(t, host, "documents.update", map[string]any{"path": "projects/@work/doc-1.json"})
+	require.ErrorContains(t, err, PluginErrCapabilityDenied)
+	_, err = call(t, host, "storage.keys", nil)
+	require.ErrorContains(t, err, PluginErrCapabilityDenied)
+
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Len(t, list[0].Issues, 2)
+	require.Equal(t, "CAPABILITY_DENIED", list[0].Issues[0].Code)
+	require.Contains(t, list[0].Issues[0].Message, `"documentsWrite"`)
+	require.Contains(t, list[0].Issues[1].Message, `"storage"`)
+	require.Equal(t, PluginStatusOK, list[0].Status)
+}
+
+func TestHost_RequiresEnabledPlugin(t *testing.T) {
+	svc, host, _ := setupHost(t, `["documentsRead"]`)
+	require.NoError(t, svc.SetPluginEnabled("host.plugin", false))
+
+	_, err := call(t, host, "documents.read", map[string]any{"path": "projects/@work/doc-1.json"})
+	require.ErrorContains(t, err, PluginErrNotOperational)
+
+	_, err = host.Call(context.Background(), HostRequest{Session: sessionFor(t, host, "missing.plugin"), Method: "documents.read"})
+	require.ErrorContains(t, err, PluginErrNotInstalled)
+}
+
+func TestHost_SessionDecidesTheCallingPlugin(t *testing.T) {
+	svc, host, _ := setupHost(t, `["storage"]`)
+	root, err := svc.pluginDir()
+	require.NoError(t, err)
+	createInstalledPlugin(t, filepath.Dir(filepath.Dir(root)), "other.plugin", `id = "other.plugin"
+name = "Other Plugin"
+version = "1.0.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["storage"]
+`)
+	require.NoError(t, svc.SetPluginEnabled("other.plugin", true))
+
+	ctx := context.Background()
+	ws := NewWailsService(svc, host, nil)
+	mine, err := ws.ReadPluginEntrypoint(ctx, "host.plugin")
+	require.NoError(t, err)
+	require.Equal(t, "console.log('installed')", mine.Source)
+	theirs, err := ws.ReadPluginEntrypoint(ctx, "other.plugin")
+	require.NoError(t, err)
+	require.NotEqual(t, mine.Session, theirs.Session)
+
+	_, err = ws.CallHost(ctx, HostRequest{Session: theirs.Session, Method: "storage.set", Params: json.RawMessage(`{"key":"secret","value":"s3"}`)})
+	require.NoError(t, err)
+
+	// A request naming another plugin, as the frontend bridge once sent, is
+	// refused without that plugin's session.
+	var req HostRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"pluginId":"other.plugin","method":"storage.get","params":{"key":"secret"}}`), &req))
+	_, err = ws.CallHost(ctx, req)
+	require.ErrorContains(t, err, PluginErrBadSession)
+
+	// With its own session it runs as itself, whatever ID it names.
+	req = HostRequest{}
+	require.NoError(t, json.Unmarshal([]byte(`{"pluginId":"other.plugin","session":"`+mine.Session+`","method":"storage.get","params":{"key":"secret"}}`), &req))
+	res, err := ws.CallHost(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	// Nor can it open, or release by ID, a session of a loaded plugin.
+	_, err = ws.ReadPluginEntrypoint(ctx, "other.plugin")
+	require.ErrorContains(t, err, PluginErrAlreadyLoaded)
+	require.ErrorContains(t, ws.ReleasePlugin(ctx, "other.plugin"), PluginErrBadSession)
+	require.NoError(t, ws.ReleasePlugin(ctx, theirs.Session))
+
+	// A frontend reload closes every session; plugins are read again.
+	host.ResetSessions()
+	_, err = ws.CallHost(ctx, HostRequest{Session: theirs.Session, Method: "storage.keys"})
+	require.ErrorContains(t, err, PluginErrBadSession)
+	again, err := ws.ReadPluginEntrypoint(ctx, "other.plugin")
+	require.NoError(t, err)
+	require.NotEqual(t, theirs.Session, again.Session)
+}
+
+func TestHost_RereadsInstallRecordsAfterChanges(t *testing.T) {
+	svc, host, _ := setupHost(t, `["storage"]`)
+	_, err := call(t, host, "storage.keys", nil)
+	require.NoError(t, err)
+
+	// Calls check the cached records rather than rescanning the directory.
+	root, err := svc.pluginDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "host.plugin", "plugin.toml"), []byte(`id = "host.plugin"
+name = "Host Plugin"
+version = "1.0.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["search"]
+`), 0o644))
+	_, err = call(t, host, "storage.keys", nil)
+	require.NoError(t, err)
+
+	// Toggling the plugin drops them.
+	require.NoError(t, svc.SetPluginEnabled("host.plugin", true))
+	_, err = call(t, host, "storage.keys", nil)
+	require.ErrorContains(t, err, PluginErrCapabilityDenied)
+
+	require.NoError(t, svc.SetPluginEnabled("host.plugin", false))
+	_, err = call(t, host, "search", map[string]any{"query": "plan"})
+	require.ErrorContains(t, err, PluginErrNotOperational)
+
+	require.NoError(t, svc.Uninstall("host.plugin"))
+	_, err = call(t, host, "search", map[string]any{"query": "plan"})
+	require.ErrorContains(t, err, PluginErrNotInstalled)
+}
+
+func TestService_RejectsUnknownCapability(t *testing.T) {
+	tempDir := t.TempDir()
+	cleanup := testenv.SetTestHome(t, tempDir)
+	defer cleanup()
+
+	config.ResetForTesting()
+	require.NoError(t, config.Init())
+
+	createInstalledPlugin(t, tempDir, "greedy.plugin", `id = "greedy.plugin"
+name = "Greedy Plugin"
+version = "1.0.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["commands", "filesystem"]
+`)
+	list, err := NewService().ListInstalled()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, PluginStatusInvalidManifest, list[0].Status)
+	require.Equal(t, "UNKNOWN_CAPABILITY", list[0].Issues[0].Code)
+}
@@ -0,0 +1,63 @@
+package plugins
+
+import "yanta/internal/plugins/manifest"
+
+// The manifest types and checks live in package manifest, which the
+// yanta-plugin CLI shares without the app's GUI dependencies.
+type (
+	Manifest            = manifest.Manifest
+	ValidationIssue     = manifest.ValidationIssue
+	PluginStatus        = manifest.Status
+	WASMLimits          = manifest.WASMLimits
+	PluginBuildMetadata = manifest.BuildMetadata
+)
+
+const (
+	PluginStatusOK              = manifest.StatusOK
+	PluginStatusInvalidManifest = manifest.StatusInvalidManifest
+	PluginStatusIncompatibleAPI = manifest.StatusIncompatibleAPI
+)
+
+// SupportedPluginAPIMajor is the single source of truth for plugin API compatibility.
+const SupportedPluginAPIMajor = manifest.SupportedAPIMajor
+
+// SupportedPluginAPIMinor is the newest minor revision of the plugin API this
+// host implements.
+const SupportedPluginAPIMinor = manifest.SupportedAPIMinor
+
+// SupportedPluginAPIVersion is the plugin API version this host implements.
+var SupportedPluginAPIVersion = manifest.SupportedAPIVersion
+
+const pluginStateNamespace = manifest.ReservedID
+
+func validateManifest(m Manifest, decodeErr error) (PluginStatus, []ValidationIssue) {
+	return manifest.Validate(m, decodeErr)
+}
+
+func checkPluginEntry(pluginRoot string, m Manifest) *ValidationIssue {
+	return manifest.CheckEntry(pluginRoot, m)
+}
+
+func summarizeIssues(issues []ValidationIssue) string {
+	return manifest.SummarizeIssues(issues)
+}
+
+func compareVersions(a, b string) int {
+	return manifest.CompareVersions(a, b)
+}
+
+func isAPIVersionCompatible(raw string) bool {
+	return manifest.IsAPIVersionCompatible(raw)
+}
+
+func isWASMEntry(entry string) bool {
+	return manifest.IsWASMEntry(entry)
+}
+
+func isLocalPath(p string) bool {
+	return manifest.IsLocalPath(p)
+}
+
+func hasCapability(m Manifest, capability string) bool {
+	return manifest.HasCapability(m, capability)
+}
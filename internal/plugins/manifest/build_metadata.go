@@ -1,4 +1,4 @@
-package plugins
+package manifest
 
 import (
 	"crypto/sha256"
@@ -11,11 +11,17 @@ import (
 	"strings"
 )
 
-const pluginBuildMetadataBuilder = "yanta-plugin-cli"
-const pluginBuildMetadataTool = "bun"
-const pluginBuildMetadataFormat = "yanta-esm-v1"
+// What a JS plugin's build metadata must name: the yanta-plugin CLI, built
+// with Bun into the host's ES module format.
+const (
+	BuildMetadataBuilder = "yanta-plugin-cli"
+	BuildMetadataTool    = "bun"
+	BuildMetadataFormat  = "yanta-esm-v1"
+)
 
-var forbiddenBundledRuntimePackages = []string{
+// HostExternals are the runtime packages the host provides. A bundle must
+// leave them external and not include its own copy.
+var HostExternals = []string{
 	"react",
 	"react-dom",
 	"@blocknote/core",
@@ -23,86 +29,82 @@ var forbiddenBundledRuntimePackages = []string{
 	"yjs",
 }
 
-type PluginBuildMetadata struct {
+// BuildMetadata is main.meta.json, written by the CLI next to the bundle.
+type BuildMetadata struct {
 	Builder                 string   `json:"builder"`
 	BuilderVersion          string   `json:"builder_version"`
 	BuildTool               string   `json:"build_tool"`
 	Format                  string   `json:"format"`
 	HostExternals           []string `json:"host_externals"`
-	DetectedBundledPackages []string `json:"detected_bundled_packages"`
+	DetectedBundledPackages []string `json:"detected_bundled_packages,omitempty"`
 	EntryHashSHA256         string   `json:"entry_hash_sha256"`
 	GeneratedAt             string   `json:"generated_at"`
 }
 
-type pluginBuildCheckFailure struct {
-	Code    string
-	Message string
-}
-
-func checkPluginBuildMetadata(pluginRoot string) *pluginBuildCheckFailure {
-	metaPath := filepath.Join(pluginRoot, requiredPluginBuildMetadataFile)
+func checkBuildMetadata(pluginRoot string) *ValidationIssue {
+	metaPath := filepath.Join(pluginRoot, BuildMetadataFile)
 	metaData, err := os.ReadFile(metaPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &pluginBuildCheckFailure{
-				Code:    PluginErrBuildMetadataMissing,
-				Message: fmt.Sprintf("missing required build metadata %q", requiredPluginBuildMetadataFile),
+			return &ValidationIssue{
+				Code:    ErrBuildMetadataMissing,
+				Message: fmt.Sprintf("missing required build metadata %q", BuildMetadataFile),
 			}
 		}
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
 			Message: fmt.Sprintf("read build metadata failed: %v", err),
 		}
 	}
 
-	var meta PluginBuildMetadata
+	var meta BuildMetadata
 	if err := json.Unmarshal(metaData, &meta); err != nil {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
 			Message: fmt.Sprintf("invalid build metadata JSON: %v", err),
 		}
 	}
 
-	if strings.TrimSpace(meta.Builder) != pluginBuildMetadataBuilder {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
-			Message: fmt.Sprintf("metadata builder must be %q", pluginBuildMetadataBuilder),
+	if strings.TrimSpace(meta.Builder) != BuildMetadataBuilder {
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
+			Message: fmt.Sprintf("metadata builder must be %q", BuildMetadataBuilder),
 		}
 	}
-	if strings.TrimSpace(meta.BuildTool) != pluginBuildMetadataTool {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
-			Message: fmt.Sprintf("metadata build_tool must be %q", pluginBuildMetadataTool),
+	if strings.TrimSpace(meta.BuildTool) != BuildMetadataTool {
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
+			Message: fmt.Sprintf("metadata build_tool must be %q", BuildMetadataTool),
 		}
 	}
-	if strings.TrimSpace(meta.Format) != pluginBuildMetadataFormat {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
-			Message: fmt.Sprintf("metadata format must be %q", pluginBuildMetadataFormat),
+	if strings.TrimSpace(meta.Format) != BuildMetadataFormat {
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
+			Message: fmt.Sprintf("metadata format must be %q", BuildMetadataFormat),
 		}
 	}
 
 	hostExternals := normalizeStringList(meta.HostExternals)
 	if len(hostExternals) == 0 {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
 			Message: "metadata host_externals must not be empty",
 		}
 	}
-	for _, required := range forbiddenBundledRuntimePackages {
+	for _, required := range HostExternals {
 		if !containsString(hostExternals, required) {
-			return &pluginBuildCheckFailure{
-				Code:    PluginErrBuildMetadataInvalid,
+			return &ValidationIssue{
+				Code:    ErrBuildMetadataInvalid,
 				Message: fmt.Sprintf("metadata host_externals missing %q", required),
 			}
 		}
 	}
 
-	entryPath := filepath.Join(pluginRoot, requiredPluginEntrypoint)
+	entryPath := filepath.Join(pluginRoot, Entrypoint)
 	entryData, err := os.ReadFile(entryPath)
 	if err != nil {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
 			Message: fmt.Sprintf("read entrypoint for metadata validation failed: %v", err),
 		}
 	}
@@ -110,22 +112,22 @@ func checkPluginBuildMetadata(pluginRoot string) *pluginBuildCheckFailure {
 	expectedHash := strings.ToLower(strings.TrimSpace(meta.EntryHashSHA256))
 	actualHash := hex.EncodeToString(entryHash[:])
 	if expectedHash == "" {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildMetadataInvalid,
+		return &ValidationIssue{
+			Code:    ErrBuildMetadataInvalid,
 			Message: "metadata entry_hash_sha256 is required",
 		}
 	}
 	if expectedHash != actualHash {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrBuildHashMismatch,
+		return &ValidationIssue{
+			Code:    ErrBuildHashMismatch,
 			Message: "main.js hash does not match metadata entry_hash_sha256",
 		}
 	}
 
 	detectedByBundleScan := detectBundledForbiddenPackagesFromBundle(string(entryData))
 	if len(detectedByBundleScan) > 0 {
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrForbiddenBundle,
+		return &ValidationIssue{
+			Code:    ErrForbiddenBundle,
 			Message: fmt.Sprintf("bundle includes forbidden runtime packages: %s", strings.Join(detectedByBundleScan, ", ")),
 		}
 	}
@@ -133,14 +135,14 @@ func checkPluginBuildMetadata(pluginRoot string) *pluginBuildCheckFailure {
 	detectedBundled := normalizeStringList(meta.DetectedBundledPackages)
 	forbiddenDetected := make([]string, 0, len(detectedBundled))
 	for _, pkg := range detectedBundled {
-		if containsString(forbiddenBundledRuntimePackages, pkg) {
+		if containsString(HostExternals, pkg) {
 			forbiddenDetected = append(forbiddenDetected, pkg)
 		}
 	}
 	if len(forbiddenDetected) > 0 {
 		sort.Strings(forbiddenDetected)
-		return &pluginBuildCheckFailure{
-			Code:    PluginErrForbiddenBundle,
+		return &ValidationIssue{
+			Code:    ErrForbiddenBundle,
 			Message: fmt.Sprintf("bundle includes forbidden runtime packages: %s", strings.Join(forbiddenDetected, ", ")),
 		}
 	}
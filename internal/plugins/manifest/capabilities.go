@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capabilities a manifest may declare. The UI ones gate what the frontend
+// lets a plugin register; the host ones gate calls through the host API.
+const (
+	CapabilityCommands               = "commands"
+	CapabilitySidebar                = "sidebar"
+	CapabilityEditorExtensions       = "editorExtensions"
+	CapabilityEditorTipTapExtensions = "editorTipTapExtensions"
+	CapabilityEditorBlockSpecs       = "editorBlockSpecs"
+	CapabilityEditorStyleSpecs       = "editorStyleSpecs"
+	CapabilityEditorSlashMenu        = "editorSlashMenu"
+	CapabilityEditorTools            = "editorTools"
+	CapabilityEditorBlockActions     = "editorBlockActions"
+	CapabilityEditorLifecycle        = "editorLifecycle"
+	CapabilitySettings               = "settings"
+
+	CapabilityDocumentsRead  = "documentsRead"
+	CapabilityDocumentsWrite = "documentsWrite"
+	CapabilitySearch         = "search"
+	CapabilityJournal        = "journal"
+	CapabilityStorage        = "storage"
+)
+
+var knownCapabilities = map[string]bool{
+	CapabilityCommands:               true,
+	CapabilitySidebar:                true,
+	CapabilityEditorExtensions:       true,
+	CapabilityEditorTipTapExtensions: true,
+	CapabilityEditorBlockSpecs:       true,
+	CapabilityEditorStyleSpecs:       true,
+	CapabilityEditorSlashMenu:        true,
+	CapabilityEditorTools:            true,
+	CapabilityEditorBlockActions:     true,
+	CapabilityEditorLifecycle:        true,
+	CapabilitySettings:               true,
+	CapabilityDocumentsRead:          true,
+	CapabilityDocumentsWrite:         true,
+	CapabilitySearch:                 true,
+	CapabilityJournal:                true,
+	CapabilityStorage:                true,
+}
+
+func capabilityIssues(capabilities []string) []ValidationIssue {
+	issues := []ValidationIssue{}
+	for _, capability := range capabilities {
+		if !knownCapabilities[strings.TrimSpace(capability)] {
+			issues = append(issues, ValidationIssue{
+				Code:    "UNKNOWN_CAPABILITY",
+				Message: fmt.Sprintf("unknown capability %q", capability),
+				Field:   "capabilities",
+			})
+		}
+	}
+	return issues
+}
+
+// HasCapability reports whether a manifest declares a capability.
+func HasCapability(m Manifest, capability string) bool {
+	for _, declared := range m.Capabilities {
+		if strings.TrimSpace(declared) == capability {
+			return true
+		}
+	}
+	return false
+}
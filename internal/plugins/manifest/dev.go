@@ -0,0 +1,99 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yanta/internal/approot"
+)
+
+// Directory returns the plugin directory: plugins under the app root, which
+// the app's config resolves through the same package.
+func Directory() (string, error) {
+	root, err := approot.Dir()
+	if err != nil {
+		return "", fmt.Errorf("resolve app root directory: %w", err)
+	}
+	return filepath.Join(root, "plugins"), nil
+}
+
+// LinkDev links a plugin's working directory into pluginDir, so the app runs
+// it from where it is edited instead of from a copy. The link is named after
+// the manifest ID; linking the same directory again is a no-op. Only the
+// manifest is checked: the entry may not be built yet.
+func LinkDev(pluginDir, sourcePath string) (Manifest, error) {
+	trimmedPath := strings.TrimSpace(sourcePath)
+	if trimmedPath == "" {
+		return Manifest{}, codedError(ErrBadSource, "source path is required")
+	}
+	source, err := filepath.Abs(trimmedPath)
+	if err != nil {
+		return Manifest{}, codedError(ErrBadSource, fmt.Sprintf("resolve source path: %v", err))
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return Manifest{}, codedError(ErrBadSource, fmt.Sprintf("source path not accessible: %v", err))
+	}
+	if !info.IsDir() {
+		return Manifest{}, codedError(ErrBadSource, "source path must be a directory")
+	}
+
+	m, decodeErr := Load(source)
+	status, issues := Validate(m, decodeErr)
+	if status == StatusInvalidManifest {
+		return Manifest{}, codedError(ErrInvalidManifest, SummarizeIssues(issues))
+	}
+	if status == StatusIncompatibleAPI {
+		return Manifest{}, codedError(ErrIncompatibleAPI, SummarizeIssues(issues))
+	}
+
+	if filepath.Base(m.ID) != m.ID || !filepath.IsLocal(m.ID) {
+		return Manifest{}, codedError(ErrInvalidManifest, fmt.Sprintf("plugin id %q cannot name a directory", m.ID))
+	}
+
+	if err := os.MkdirAll(pluginDir, 0o755); err != nil {
+		return Manifest{}, fmt.Errorf("create plugin directory %s: %w", pluginDir, err)
+	}
+	link := filepath.Join(pluginDir, m.ID)
+	if target, err := os.Readlink(link); err == nil && target == source {
+		return m, nil
+	}
+	if _, err := os.Lstat(link); err == nil {
+		return Manifest{}, codedError(ErrAlreadyInstalled, fmt.Sprintf("plugin %q is already installed", m.ID))
+	} else if !os.IsNotExist(err) {
+		return Manifest{}, fmt.Errorf("stat destination %s: %w", link, err)
+	}
+	if err := os.Symlink(source, link); err != nil {
+		return Manifest{}, fmt.Errorf("link plugin directory: %w", err)
+	}
+	return m, nil
+}
+
+// UnlinkDev removes the dev link of plugin id from pluginDir. The working
+// directory is left alone.
+func UnlinkDev(pluginDir, id string) error {
+	trimmed := strings.TrimSpace(id)
+	if trimmed == "" {
+		return errors.New("plugin id is required")
+	}
+	if !filepath.IsLocal(trimmed) {
+		return errors.New("invalid plugin id")
+	}
+	link := filepath.Join(pluginDir, trimmed)
+	info, err := os.Lstat(link)
+	if os.IsNotExist(err) {
+		return codedError(ErrNotInstalled, fmt.Sprintf("plugin %q is not installed", trimmed))
+	} else if err != nil {
+		return fmt.Errorf("stat plugin path %s: %w", link, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return codedError(ErrBadSource, fmt.Sprintf("plugin %q is installed, not dev-linked", trimmed))
+	}
+	if err := os.Remove(link); err != nil {
+		return fmt.Errorf("remove dev link %s: %w", link, err)
+	}
+	return nil
+}
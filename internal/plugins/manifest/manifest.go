@@ -0,0 +1,179 @@
+// Package manifest reads and checks plugin directories: the plugin.toml
+// manifest, the entry and its build metadata, and dev links into the plugin
+// directory. It has no GUI dependencies, so the yanta-plugin CLI shares it
+// with the app.
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// FileName is the manifest at the root of every plugin directory.
+	FileName = "plugin.toml"
+	// Entrypoint is the bundle a JS plugin's entry must name.
+	Entrypoint = "main.js"
+	// BuildMetadataFile describes how Entrypoint was built.
+	BuildMetadataFile = "main.meta.json"
+	// ReservedID is the namespace plugin state is kept under in the
+	// preferences. No plugin may use it as its ID.
+	ReservedID = "__plugin_state"
+)
+
+// Error codes, at the start of an error's message and in issues.
+const (
+	ErrInvalidManifest      = "PLUGIN_INVALID_MANIFEST"
+	ErrIncompatibleAPI      = "PLUGIN_INCOMPATIBLE_API"
+	ErrAlreadyInstalled     = "PLUGIN_ALREADY_INSTALLED"
+	ErrNotInstalled         = "PLUGIN_NOT_INSTALLED"
+	ErrBadSource            = "PLUGIN_BAD_SOURCE"
+	ErrBuildMetadataMissing = "PLUGIN_BUILD_METADATA_MISSING"
+	ErrBuildMetadataInvalid = "PLUGIN_BUILD_METADATA_INVALID"
+	ErrBuildHashMismatch    = "PLUGIN_BUILD_HASH_MISMATCH"
+	ErrForbiddenBundle      = "PLUGIN_FORBIDDEN_BUNDLE"
+)
+
+// Status is what validating a manifest concluded.
+type Status string
+
+const (
+	StatusOK              Status = "ok"
+	StatusInvalidManifest Status = "invalid_manifest"
+	StatusIncompatibleAPI Status = "incompatible_api"
+)
+
+type ValidationIssue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+type Manifest struct {
+	ID           string   `toml:"id"`
+	Name         string   `toml:"name"`
+	Version      string   `toml:"version"`
+	APIVersion   string   `toml:"api_version"`
+	Entry        string   `toml:"entry"`
+	Capabilities []string `toml:"capabilities"`
+	Description  string   `toml:"description"`
+	Author       string   `toml:"author"`
+	Homepage     string   `toml:"homepage"`
+	// WASM holds the sandbox limits of a plugin whose entry is a .wasm module.
+	WASM WASMLimits `toml:"wasm"`
+}
+
+// Load decodes the manifest of the plugin directory dir. A decode error is
+// returned with whatever was decoded, for Validate to report.
+func Load(dir string) (Manifest, error) {
+	var m Manifest
+	_, err := toml.DecodeFile(filepath.Join(dir, FileName), &m)
+	return m, err
+}
+
+// Validate checks a decoded manifest: its required fields, entry, WASM
+// limits and capabilities, then the API version it targets.
+func Validate(m Manifest, decodeErr error) (Status, []ValidationIssue) {
+	issues := []ValidationIssue{}
+	if decodeErr != nil {
+		issues = append(issues, ValidationIssue{
+			Code:    "MANIFEST_DECODE_ERROR",
+			Message: decodeErr.Error(),
+			Field:   FileName,
+		})
+	}
+	issues = append(issues, fieldIssues(m)...)
+	if len(issues) > 0 {
+		return StatusInvalidManifest, issues
+	}
+	if !IsAPIVersionCompatible(m.APIVersion) {
+		return StatusIncompatibleAPI, []ValidationIssue{
+			{
+				Code:    "INCOMPATIBLE_API_VERSION",
+				Message: fmt.Sprintf("plugin api_version %q is not supported (host implements %s)", m.APIVersion, SupportedAPIVersion),
+				Field:   "api_version",
+			},
+		}
+	}
+	return StatusOK, nil
+}
+
+func fieldIssues(m Manifest) []ValidationIssue {
+	issues := []ValidationIssue{}
+	if strings.TrimSpace(m.ID) == "" {
+		issues = append(issues, ValidationIssue{
+			Code:    "MISSING_REQUIRED_FIELD",
+			Message: "missing required field: id",
+			Field:   "id",
+		})
+	}
+	if strings.TrimSpace(m.Name) == "" {
+		issues = append(issues, ValidationIssue{
+			Code:    "MISSING_REQUIRED_FIELD",
+			Message: "missing required field: name",
+			Field:   "name",
+		})
+	}
+	if strings.TrimSpace(m.Version) == "" {
+		issues = append(issues, ValidationIssue{
+			Code:    "MISSING_REQUIRED_FIELD",
+			Message: "missing required field: version",
+			Field:   "version",
+		})
+	}
+	if strings.TrimSpace(m.APIVersion) == "" {
+		issues = append(issues, ValidationIssue{
+			Code:    "MISSING_REQUIRED_FIELD",
+			Message: "missing required field: api_version",
+			Field:   "api_version",
+		})
+	}
+	if strings.TrimSpace(m.Entry) == "" {
+		issues = append(issues, ValidationIssue{
+			Code:    "MISSING_REQUIRED_FIELD",
+			Message: "missing required field: entry",
+			Field:   "entry",
+		})
+	} else if !IsWASMEntry(m.Entry) && strings.TrimSpace(m.Entry) != Entrypoint {
+		issues = append(issues, ValidationIssue{
+			Code:    "INVALID_ENTRYPOINT",
+			Message: fmt.Sprintf("entry must be %q or a .wasm module", Entrypoint),
+			Field:   "entry",
+		})
+	} else if IsWASMEntry(m.Entry) && !IsLocalPath(m.Entry) {
+		issues = append(issues, ValidationIssue{
+			Code:    "INVALID_ENTRYPOINT",
+			Message: "entry must be a path inside the plugin directory",
+			Field:   "entry",
+		})
+	}
+	issues = append(issues, wasmLimitIssues(m)...)
+	if strings.TrimSpace(m.ID) == ReservedID {
+		issues = append(issues, ValidationIssue{
+			Code:    "RESERVED_PLUGIN_ID",
+			Message: "plugin id uses reserved namespace",
+			Field:   "id",
+		})
+	}
+	issues = append(issues, capabilityIssues(m.Capabilities)...)
+	return issues
+}
+
+// SummarizeIssues joins the messages of issues into one line.
+func SummarizeIssues(issues []ValidationIssue) string {
+	if len(issues) == 0 {
+		return "manifest validation failed"
+	}
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func codedError(code, message string) error {
+	return fmt.Errorf("%s: %s", code, message)
+}
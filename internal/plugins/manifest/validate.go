@@ -0,0 +1,41 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidateDirectory runs the checks installing a plugin directory runs,
+// without installing it: the manifest and its capabilities, the API version,
+// then the entry and its build metadata. No issues means the directory would
+// install.
+func ValidateDirectory(root string) (Manifest, []ValidationIssue) {
+	manifest, decodeErr := Load(root)
+	status, issues := Validate(manifest, decodeErr)
+	if status != StatusOK {
+		return manifest, issues
+	}
+
+	entry := strings.TrimSpace(manifest.Entry)
+	info, err := os.Stat(filepath.Join(root, filepath.Clean(entry)))
+	if err != nil {
+		return manifest, []ValidationIssue{{
+			Code:    ErrInvalidManifest,
+			Message: fmt.Sprintf("required runtime entry %q is missing", entry),
+			Field:   "entry",
+		}}
+	}
+	if info.IsDir() {
+		return manifest, []ValidationIssue{{
+			Code:    ErrInvalidManifest,
+			Message: fmt.Sprintf("required runtime entry %q must be a file", entry),
+			Field:   "entry",
+		}}
+	}
+	if issue := CheckEntry(root, manifest); issue != nil {
+		return manifest, []ValidationIssue{*issue}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,83 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const devManifest = `id = "dev.plugin"
+name = "Dev Plugin"
+version = "0.1.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["commands"]
+`
+
+func writeBuiltPlugin(t *testing.T, dir string) {
+	t.Helper()
+	entry := []byte("export function setup() {}")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(devManifest), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, Entrypoint), entry, 0o644))
+	sum := sha256.Sum256(entry)
+	encoded, err := json.Marshal(BuildMetadata{
+		Builder:         BuildMetadataBuilder,
+		BuildTool:       BuildMetadataTool,
+		Format:          BuildMetadataFormat,
+		HostExternals:   HostExternals,
+		EntryHashSHA256: hex.EncodeToString(sum[:]),
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, BuildMetadataFile), encoded, 0o644))
+}
+
+func TestValidateDirectory(t *testing.T) {
+	workDir := t.TempDir()
+	writeBuiltPlugin(t, workDir)
+
+	manifest, issues := ValidateDirectory(workDir)
+	require.Empty(t, issues)
+	require.Equal(t, "dev.plugin", manifest.ID)
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, Entrypoint), []byte("export const changed = true"), 0o644))
+	_, issues = ValidateDirectory(workDir)
+	require.Len(t, issues, 1)
+	require.Equal(t, ErrBuildHashMismatch, issues[0].Code)
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, FileName), []byte(`id = "dev.plugin"
+name = "Dev Plugin"
+version = "0.1.0"
+api_version = "1"
+entry = "main.js"
+capabilities = ["commands", "teleport"]
+`), 0o644))
+	_, issues = ValidateDirectory(workDir)
+	require.Len(t, issues, 1)
+	require.Equal(t, "UNKNOWN_CAPABILITY", issues[0].Code)
+}
+
+func TestLinkDev(t *testing.T) {
+	pluginDir := filepath.Join(t.TempDir(), "plugins")
+	workDir := t.TempDir()
+	writeBuiltPlugin(t, workDir)
+
+	linked, err := LinkDev(pluginDir, workDir)
+	require.NoError(t, err)
+	require.Equal(t, "dev.plugin", linked.ID)
+	target, err := os.Readlink(filepath.Join(pluginDir, "dev.plugin"))
+	require.NoError(t, err)
+	require.Equal(t, workDir, target)
+
+	_, err = LinkDev(pluginDir, workDir)
+	require.NoError(t, err, "relinking the same directory is a no-op")
+
+	require.NoError(t, UnlinkDev(pluginDir, "dev.plugin"))
+	require.ErrorContains(t, UnlinkDev(pluginDir, "dev.plugin"), ErrNotInstalled)
+	_, err = os.Stat(filepath.Join(workDir, Entrypoint))
+	require.NoError(t, err, "unlinking leaves the working directory alone")
+}
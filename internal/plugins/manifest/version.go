@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SupportedAPIMajor is the single source of truth for plugin API compatibility.
+const SupportedAPIMajor = 1
+
+// SupportedAPIMinor is the newest minor revision of the plugin API this
+// host implements. Minor revisions only add to the API: 1.1 added the host
+// API and capabilities, 1.2 added WASM plugins.
+const SupportedAPIMinor = 2
+
+// SupportedAPIVersion is the plugin API version this host implements.
+var SupportedAPIVersion = fmt.Sprintf("%d.%d", SupportedAPIMajor, SupportedAPIMinor)
+
+// semver is a parsed semantic version. Missing minor and patch parts are
+// zero, so "1" and "1.0.0" are equal.
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseSemver parses versions such as "1", "1.2", "v1.2.3" and
+// "1.2.3-beta.1+build.5". Build metadata is ignored.
+func parseSemver(raw string) (semver, bool) {
+	v := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var out semver
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		out.prerelease = v[i+1:]
+		v = v[:i]
+		if out.prerelease == "" {
+			return semver{}, false
+		}
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return semver{}, false
+	}
+	nums := [3]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part == "" || (len(part) > 1 && part[0] == '0') {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+	out.major, out.minor, out.patch = nums[0], nums[1], nums[2]
+	return out, true
+}
+
+// compare orders versions by semver precedence.
+func (v semver) compare(other semver) int {
+	for _, d := range [3]int{v.major - other.major, v.minor - other.minor, v.patch - other.patch} {
+		if d != 0 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	return comparePrerelease(v.prerelease, other.prerelease)
+}
+
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	left, right := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		ln, lerr := strconv.Atoi(left[i])
+		rn, rerr := strconv.Atoi(right[i])
+		switch {
+		case lerr == nil && rerr == nil:
+			if ln != rn {
+				if ln < rn {
+					return -1
+				}
+				return 1
+			}
+		case lerr == nil:
+			return -1
+		case rerr == nil:
+			return 1
+		default:
+			if c := strings.Compare(left[i], right[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	}
+	return 0
+}
+
+// CompareVersions orders two plugin versions. Versions that are not semver
+// sort before those that are, and compare as plain strings among themselves.
+func CompareVersions(a, b string) int {
+	left, lok := parseSemver(a)
+	right, rok := parseSemver(b)
+	switch {
+	case lok && rok:
+		return left.compare(right)
+	case lok:
+		return 1
+	case rok:
+		return -1
+	}
+	return strings.Compare(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// IsAPIVersionCompatible reports whether the host implements the plugin API
+// version a plugin targets: the same major, and a minor no newer than the
+// host's.
+func IsAPIVersionCompatible(raw string) bool {
+	v, ok := parseSemver(raw)
+	if !ok || v.prerelease != "" {
+		return false
+	}
+	return v.major == SupportedAPIMajor && v.minor <= SupportedAPIMinor
+}
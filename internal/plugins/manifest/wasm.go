@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWASMMemoryMB = 16
+	maxWASMMemoryMB     = 256
+	defaultWASMTimeout  = 2 * time.Second
+	maxWASMTimeout      = 30 * time.Second
+)
+
+var wasmMagic = []byte{0x00, 'a', 's', 'm'}
+
+// WASMLimits bounds a WASM plugin's sandbox. Zero values pick the defaults
+// (16 MiB and 2 seconds).
+type WASMLimits struct {
+	// MemoryLimitMB caps the module's linear memory.
+	MemoryLimitMB int `toml:"memory_limit_mb" json:"memoryLimitMb,omitempty"`
+	// TimeoutMS caps one call into the module, host calls included.
+	TimeoutMS int `toml:"timeout_ms" json:"timeoutMs,omitempty"`
+}
+
+// MemoryPages is the memory limit in 64 KiB WebAssembly pages.
+func (l WASMLimits) MemoryPages() uint32 {
+	mb := l.MemoryLimitMB
+	if mb <= 0 {
+		mb = defaultWASMMemoryMB
+	}
+	return uint32(mb) * 16 // 64 KiB pages
+}
+
+// Timeout is the time one call into the module may take.
+func (l WASMLimits) Timeout() time.Duration {
+	if l.TimeoutMS <= 0 {
+		return defaultWASMTimeout
+	}
+	return time.Duration(l.TimeoutMS) * time.Millisecond
+}
+
+// IsWASMEntry reports whether an entry names a WebAssembly module.
+func IsWASMEntry(entry string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSpace(entry)), ".wasm")
+}
+
+// IsLocalPath reports whether a manifest path stays inside the plugin
+// directory.
+func IsLocalPath(p string) bool {
+	p = strings.TrimSpace(p)
+	return p != "" && filepath.IsLocal(filepath.FromSlash(p))
+}
+
+func wasmLimitIssues(m Manifest) []ValidationIssue {
+	issues := []ValidationIssue{}
+	if m.WASM.MemoryLimitMB < 0 || m.WASM.MemoryLimitMB > maxWASMMemoryMB {
+		issues = append(issues, ValidationIssue{
+			Code:    "INVALID_WASM_LIMIT",
+			Message: fmt.Sprintf("wasm.memory_limit_mb must be between 1 and %d", maxWASMMemoryMB),
+			Field:   "wasm.memory_limit_mb",
+		})
+	}
+	if m.WASM.TimeoutMS < 0 || time.Duration(m.WASM.TimeoutMS)*time.Millisecond > maxWASMTimeout {
+		issues = append(issues, ValidationIssue{
+			Code:    "INVALID_WASM_LIMIT",
+			Message: fmt.Sprintf("wasm.timeout_ms must be between 1 and %d", maxWASMTimeout.Milliseconds()),
+			Field:   "wasm.timeout_ms",
+		})
+	}
+	return issues
+}
+
+// CheckEntry checks that a plugin's entry can run: the build metadata of a
+// JS bundle, or the header of a WASM module.
+func CheckEntry(pluginRoot string, m Manifest) *ValidationIssue {
+	if !IsWASMEntry(m.Entry) {
+		issue := checkBuildMetadata(pluginRoot)
+		if issue != nil {
+			issue.Field = BuildMetadataFile
+		}
+		return issue
+	}
+	if !IsLocalPath(m.Entry) {
+		return &ValidationIssue{
+			Code:    ErrInvalidManifest,
+			Message: "entry must be a path inside the plugin directory",
+			Field:   "entry",
+		}
+	}
+	f, err := os.Open(filepath.Join(pluginRoot, filepath.FromSlash(strings.TrimSpace(m.Entry))))
+	if err != nil {
+		return &ValidationIssue{
+			Code:    ErrInvalidManifest,
+			Message: fmt.Sprintf("wasm entry not accessible: %v", err),
+			Field:   "entry",
+		}
+	}
+	defer f.Close()
+	header := make([]byte, len(wasmMagic))
+	if _, err := f.Read(header); err != nil || !bytes.Equal(header, wasmMagic) {
+		return &ValidationIssue{
+			Code:    ErrInvalidManifest,
+			Message: fmt.Sprintf("entry %q is not a WebAssembly module", m.Entry),
+			Field:   "entry",
+		}
+	}
+	return nil
+}
@@ -12,7 +12,13 @@ import (
 func (s *Service) InstallFromPackage(sourcePath string) (InstallRecord, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+	return s.installPackage(sourcePath, nil)
+}
 
+// installPackage installs a package; from the registry, release is the
+// entry the package must match.
+func (s *Service) installPackage(sourcePath string, release *RegistryRelease) (InstallRecord, error) {
 	trimmedPath := strings.TrimSpace(sourcePath)
 	if trimmedPath == "" {
 		return InstallRecord{}, pluginError(PluginErrBadSource, "package path is required")
@@ -31,41 +37,14 @@ func (s *Service) InstallFromPackage(sourcePath string) (InstallRecord, error) {
 	}
 	defer os.RemoveAll(extractDir)
 
-	if err := unzipPluginPackage(trimmedPath, extractDir); err != nil {
-		return InstallRecord{}, pluginError(PluginErrBadSource, fmt.Sprintf("failed to unpack package: %v", err))
-	}
-
-	manifestPath := filepath.Join(extractDir, "plugin.toml")
-	var manifest Manifest
-	_, decodeErr := toml.DecodeFile(manifestPath, &manifest)
-	status, issues := validateManifest(manifest, decodeErr)
-	if status == PluginStatusInvalidManifest {
-		return InstallRecord{}, pluginError(PluginErrInvalidManifest, summarizeIssues(issues))
-	}
-	if status == PluginStatusIncompatibleAPI {
-		return InstallRecord{}, pluginError(PluginErrIncompatibleAPI, summarizeIssues(issues))
-	}
-
-	if strings.TrimSpace(manifest.Entry) != "" {
-		entry := filepath.Join(extractDir, filepath.Clean(manifest.Entry))
-		if !strings.HasPrefix(entry, extractDir+string(os.PathSeparator)) && entry != extractDir {
-			return InstallRecord{}, pluginError(PluginErrInvalidManifest, "entry path escapes package root")
-		}
-		if _, err := os.Stat(entry); err != nil {
-			return InstallRecord{}, pluginError(PluginErrInvalidManifest, fmt.Sprintf("entry file %q does not exist in package", manifest.Entry))
-		}
-	}
-	if buildFailure := checkPluginBuildMetadata(extractDir); buildFailure != nil {
-		return InstallRecord{}, pluginError(buildFailure.Code, buildFailure.Message)
-	}
-
-	keys, err := s.loadTrustedKeys()
+	manifest, metadata, err := s.unpackVerifiedPackage(trimmedPath, extractDir)
 	if err != nil {
 		return InstallRecord{}, err
 	}
-	metadata, err := verifyPackageSignature(extractDir, keys)
-	if err != nil {
-		return InstallRecord{}, err
+	if release != nil {
+		if err := release.matches(manifest, metadata); err != nil {
+			return InstallRecord{}, err
+		}
 	}
 
 	root, err := s.pluginDir()
@@ -95,16 +74,63 @@ func (s *Service) InstallFromPackage(sourcePath string) (InstallRecord, error) {
 		return InstallRecord{}, err
 	}
 
+	return packageInstallRecord(manifest, destPath, metadata, false), nil
+}
+
+// unpackVerifiedPackage extracts a package into dir and runs every check an
+// install needs: the manifest, the entry and the publisher signature.
+func (s *Service) unpackVerifiedPackage(packagePath, dir string) (Manifest, InstallMetadata, error) {
+	if err := unzipPluginPackage(packagePath, dir); err != nil {
+		return Manifest{}, InstallMetadata{}, pluginError(PluginErrBadSource, fmt.Sprintf("failed to unpack package: %v", err))
+	}
+
+	manifestPath := filepath.Join(dir, "plugin.toml")
+	var manifest Manifest
+	_, decodeErr := toml.DecodeFile(manifestPath, &manifest)
+	status, issues := validateManifest(manifest, decodeErr)
+	if status == PluginStatusInvalidManifest {
+		return Manifest{}, InstallMetadata{}, pluginError(PluginErrInvalidManifest, summarizeIssues(issues))
+	}
+	if status == PluginStatusIncompatibleAPI {
+		return Manifest{}, InstallMetadata{}, pluginError(PluginErrIncompatibleAPI, summarizeIssues(issues))
+	}
+
+	if strings.TrimSpace(manifest.Entry) != "" {
+		entry := filepath.Join(dir, filepath.Clean(manifest.Entry))
+		if !strings.HasPrefix(entry, dir+string(os.PathSeparator)) && entry != dir {
+			return Manifest{}, InstallMetadata{}, pluginError(PluginErrInvalidManifest, "entry path escapes package root")
+		}
+		if _, err := os.Stat(entry); err != nil {
+			return Manifest{}, InstallMetadata{}, pluginError(PluginErrInvalidManifest, fmt.Sprintf("entry file %q does not exist in package", manifest.Entry))
+		}
+	}
+	if buildFailure := checkPluginEntry(dir, manifest); buildFailure != nil {
+		return Manifest{}, InstallMetadata{}, pluginError(buildFailure.Code, buildFailure.Message)
+	}
+
+	keys, err := s.loadTrustedKeys()
+	if err != nil {
+		return Manifest{}, InstallMetadata{}, err
+	}
+	metadata, err := verifyPackageSignature(dir, keys)
+	if err != nil {
+		return Manifest{}, InstallMetadata{}, err
+	}
+	return manifest, metadata, nil
+}
+
+func packageInstallRecord(manifest Manifest, path string, metadata InstallMetadata, enabled bool) InstallRecord {
 	return InstallRecord{
 		Manifest:           manifest,
-		Path:               destPath,
+		Path:               path,
 		Source:             metadata.Source,
-		Enabled:            false,
+		Enabled:            enabled,
 		Status:             PluginStatusOK,
+		Runtime:            runtimeOf(manifest),
 		Isolation:          metadata.Isolation,
 		CanExecute:         metadata.CanExecute,
 		VerificationStatus: metadata.VerificationStatus,
 		PublisherID:        metadata.PublisherID,
 		SigningKeyID:       metadata.SigningKeyID,
-	}, nil
+	}
 }
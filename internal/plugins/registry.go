@@ -0,0 +1,606 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"yanta/internal/config"
+	"yanta/internal/logger"
+)
+
+const pluginStateRegistryKey = "registry"
+const registryIndexFile = "index.json"
+
+// Directories under the plugin root that hold a plugin's next version while
+// an upgrade is staged, and the version an upgrade replaced. Scans skip them
+// as they have no plugin.toml of their own.
+const stagingDirName = ".staging"
+const previousDirName = ".previous"
+
+const (
+	PluginErrRegistryUnavailable = "PLUGIN_REGISTRY_UNAVAILABLE"
+	PluginErrNotInRegistry       = "PLUGIN_NOT_IN_REGISTRY"
+	PluginErrNoUpdate            = "PLUGIN_NO_UPDATE"
+	PluginErrNoPreviousVersion   = "PLUGIN_NO_PREVIOUS_VERSION"
+)
+
+// RegistryIndex is a registry's index.json: the plugins it offers and
+// where their packages are. Teams without network access can share one
+// from a folder or a synced drive.
+type RegistryIndex struct {
+	Plugins []RegistryPlugin `json:"plugins"`
+}
+
+// RegistryPlugin is one plugin in a registry. ListRegistry fills in the
+// fields after Releases from what is installed.
+type RegistryPlugin struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Author      string            `json:"author,omitempty"`
+	Homepage    string            `json:"homepage,omitempty"`
+	Releases    []RegistryRelease `json:"releases"`
+
+	// LatestVersion is the newest release this host can run.
+	LatestVersion    string `json:"latestVersion,omitempty"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	UpdateAvailable  bool   `json:"updateAvailable"`
+}
+
+// RegistryRelease is one published version of a plugin.
+type RegistryRelease struct {
+	Version    string `json:"version"`
+	APIVersion string `json:"apiVersion"`
+	// Package is the signed package, relative to the index or as an
+	// absolute path or file URL.
+	Package string `json:"package"`
+	// SHA256 is the hex digest of the package file.
+	SHA256      string `json:"sha256"`
+	PublisherID string `json:"publisherId"`
+	KeyID       string `json:"keyId"`
+	// Compatible reports whether this host implements the release's
+	// api_version.
+	Compatible bool `json:"compatible"`
+
+	pluginID string
+}
+
+// PluginUpdate is an installed plugin with a newer release in the registry.
+type PluginUpdate struct {
+	PluginID         string `json:"pluginId"`
+	InstalledVersion string `json:"installedVersion"`
+	LatestVersion    string `json:"latestVersion"`
+}
+
+// GetRegistryLocation returns the configured registry: a directory holding
+// index.json, the index file itself, or a file:// URL to either.
+func (s *Service) GetRegistryLocation() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return loadRegistryLocation()
+}
+
+// SetRegistryLocation points the service at a registry after checking its
+// index can be read. An empty location removes the registry.
+func (s *Service) SetRegistryLocation(location string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trimmed := strings.TrimSpace(location)
+	if trimmed != "" {
+		if _, _, err := readRegistryIndex(trimmed); err != nil {
+			return err
+		}
+	}
+	return persistRegistryLocation(trimmed)
+}
+
+// ListRegistry returns the registry's plugins, newest release first, with
+// what is installed and whether an update is available.
+func (s *Service) ListRegistry() ([]RegistryPlugin, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index, _, err := s.registryIndex()
+	if err != nil {
+		return nil, err
+	}
+	installed, err := s.installedVersions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range index.Plugins {
+		p := &index.Plugins[i]
+		p.InstalledVersion = installed[p.ID]
+		if latest, ok := p.latest(); ok {
+			p.LatestVersion = latest.Version
+			p.UpdateAvailable = p.InstalledVersion != "" && compareVersions(latest.Version, p.InstalledVersion) > 0
+		}
+	}
+	return index.Plugins, nil
+}
+
+// CheckForUpdates lists installed plugins that have a newer release this
+// host can run.
+func (s *Service) CheckForUpdates() ([]PluginUpdate, error) {
+	plugins, err := s.ListRegistry()
+	if err != nil {
+		return nil, err
+	}
+	updates := []PluginUpdate{}
+	for _, p := range plugins {
+		if p.UpdateAvailable {
+			updates = append(updates, PluginUpdate{
+				PluginID:         p.ID,
+				InstalledVersion: p.InstalledVersion,
+				LatestVersion:    p.LatestVersion,
+			})
+		}
+	}
+	return updates, nil
+}
+
+// InstallFromRegistry installs a plugin by ID. An empty version picks the
+// newest release this host can run. The package must match the digest and
+// signer the index lists, as well as pass every InstallFromPackage check.
+func (s *Service) InstallFromRegistry(id, version string) (InstallRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+
+	release, packagePath, err := s.resolveRelease(id, version)
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	return s.installPackage(packagePath, &release)
+}
+
+// UpgradePlugin replaces an installed plugin with a newer registry release.
+// An empty version picks the newest release this host can run. The plugin
+// keeps running its old version until the new one has been verified, and the
+// old version is kept for RollbackPlugin. The replacement itself is two
+// renames; if the app stops between them, RecoverInterruptedSwaps puts the
+// old version back on the next start.
+func (s *Service) UpgradePlugin(id, version string) (InstallRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+
+	installed, err := s.installedVersions()
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	current, ok := installed[strings.TrimSpace(id)]
+	if !ok {
+		return InstallRecord{}, pluginError(PluginErrNotInstalled, fmt.Sprintf("plugin %q is not installed", id))
+	}
+	release, packagePath, err := s.resolveRelease(id, version)
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	if compareVersions(release.Version, current) <= 0 {
+		return InstallRecord{}, pluginError(PluginErrNoUpdate, fmt.Sprintf("plugin %q is already at %s", id, current))
+	}
+
+	root, err := s.pluginDir()
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	staging := filepath.Join(root, stagingDirName, release.pluginID)
+	if err := os.RemoveAll(staging); err != nil {
+		return InstallRecord{}, fmt.Errorf("clear staging directory: %w", err)
+	}
+	if err := os.MkdirAll(staging, 0o755); err != nil {
+		return InstallRecord{}, fmt.Errorf("create staging directory: %w", err)
+	}
+	manifest, metadata, err := s.unpackVerifiedPackage(packagePath, staging)
+	if err == nil {
+		err = release.matches(manifest, metadata)
+	}
+	if err == nil {
+		err = persistInstallMetadata(filepath.Join(staging, installMetadataFile), metadata)
+	}
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return InstallRecord{}, err
+	}
+
+	if err := s.swapInVersion(release.pluginID, staging); err != nil {
+		_ = os.RemoveAll(staging)
+		return InstallRecord{}, err
+	}
+	return s.recordAfterSwap(release.pluginID)
+}
+
+// RollbackPlugin restores the version an upgrade replaced. The replaced
+// version becomes the previous one, so a rollback can itself be undone. A
+// previous version this host can no longer run is refused, and one that
+// declares capabilities the installed version did not is disabled, as on
+// upgrade.
+func (s *Service) RollbackPlugin(id string) (InstallRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+
+	trimmed := strings.TrimSpace(id)
+	if trimmed == "" {
+		return InstallRecord{}, errors.New("plugin id is required")
+	}
+	if trimmed == pluginStateNamespace {
+		return InstallRecord{}, errors.New("reserved plugin id")
+	}
+	if !filepath.IsLocal(trimmed) {
+		return InstallRecord{}, errors.New("invalid plugin id")
+	}
+	root, err := s.pluginDir()
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	previous := filepath.Join(root, previousDirName, trimmed)
+	if _, err := os.Stat(previous); err != nil {
+		return InstallRecord{}, pluginError(PluginErrNoPreviousVersion, fmt.Sprintf("plugin %q has no previous version", trimmed))
+	}
+	if _, err := os.Stat(filepath.Join(root, trimmed)); err != nil {
+		return InstallRecord{}, pluginError(PluginErrNotInstalled, fmt.Sprintf("plugin %q is not installed", trimmed))
+	}
+	manifest, decodeErr := readManifest(previous)
+	status, issues := validateManifest(manifest, decodeErr)
+	if status == PluginStatusInvalidManifest {
+		return InstallRecord{}, pluginError(PluginErrInvalidManifest, summarizeIssues(issues))
+	}
+	if status == PluginStatusIncompatibleAPI {
+		return InstallRecord{}, pluginError(PluginErrIncompatibleAPI, summarizeIssues(issues))
+	}
+
+	staging := filepath.Join(root, stagingDirName, trimmed)
+	if err := os.RemoveAll(staging); err != nil {
+		return InstallRecord{}, fmt.Errorf("clear staging directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(staging), 0o755); err != nil {
+		return InstallRecord{}, fmt.Errorf("create staging directory: %w", err)
+	}
+	if err := os.Rename(previous, staging); err != nil {
+		return InstallRecord{}, fmt.Errorf("stage previous version: %w", err)
+	}
+	if err := s.swapInVersion(trimmed, staging); err != nil {
+		_ = os.Rename(staging, previous)
+		return InstallRecord{}, err
+	}
+	return s.recordAfterSwap(trimmed)
+}
+
+// swapInVersion moves the installed plugin to the previous-version slot and
+// the staged one into its place, putting the installed one back if the
+// second rename fails. It is not atomic: a crash between the renames leaves
+// the plugin only in the previous-version slot, for RecoverInterruptedSwaps.
+// A plugin that declares capabilities the replaced version did not is
+// disabled until the user enables it again.
+func (s *Service) swapInVersion(id, staging string) error {
+	root, err := s.pluginDir()
+	if err != nil {
+		return err
+	}
+	current := filepath.Join(root, id)
+	previous := filepath.Join(root, previousDirName, id)
+
+	oldManifest, _ := readManifest(current)
+	newManifest, _ := readManifest(staging)
+
+	if err := os.RemoveAll(previous); err != nil {
+		return fmt.Errorf("clear previous version: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(previous), 0o755); err != nil {
+		return fmt.Errorf("create previous version directory: %w", err)
+	}
+	if err := os.Rename(current, previous); err != nil {
+		return fmt.Errorf("set aside installed version: %w", err)
+	}
+	if err := os.Rename(staging, current); err != nil {
+		if restoreErr := os.Rename(previous, current); restoreErr != nil {
+			return fmt.Errorf("install new version: %w (restore failed: %v)", err, restoreErr)
+		}
+		return fmt.Errorf("install new version: %w", err)
+	}
+
+	if addsCapabilities(oldManifest, newManifest) {
+		return persistEnabledState(id, false)
+	}
+	return nil
+}
+
+// RecoverInterruptedSwaps reinstalls the previous version of every plugin
+// whose upgrade or rollback stopped between the two renames of
+// swapInVersion, leaving it with no installed version. The app runs it at
+// startup, before any plugin is loaded.
+func (s *Service) RecoverInterruptedSwaps() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.invalidateRecords()
+
+	root, err := s.pluginDir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(filepath.Join(root, previousDirName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read previous versions: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		current := filepath.Join(root, entry.Name())
+		if _, err := os.Lstat(current); !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(root, previousDirName, entry.Name()), current); err != nil {
+			return fmt.Errorf("restore plugin %q: %w", entry.Name(), err)
+		}
+		_ = os.RemoveAll(filepath.Join(root, stagingDirName, entry.Name()))
+		logger.WithField("plugin", entry.Name()).Warn("restored plugin left uninstalled by an interrupted upgrade")
+	}
+	return nil
+}
+
+func (s *Service) recordAfterSwap(id string) (InstallRecord, error) {
+	records, err := s.scanLocalPlugins()
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	for _, record := range records {
+		if record.Manifest.ID == id {
+			return record, nil
+		}
+	}
+	return InstallRecord{}, pluginError(PluginErrNotInstalled, fmt.Sprintf("plugin %q is not installed", id))
+}
+
+// installedVersions maps the ID of each installed plugin to its version.
+func (s *Service) installedVersions() (map[string]string, error) {
+	records, err := s.scanLocalPlugins()
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]string, len(records))
+	for _, record := range records {
+		versions[record.Manifest.ID] = record.Manifest.Version
+	}
+	return versions, nil
+}
+
+func (s *Service) registryIndex() (RegistryIndex, string, error) {
+	location := loadRegistryLocation()
+	if location == "" {
+		return RegistryIndex{}, "", pluginError(PluginErrRegistryUnavailable, "no plugin registry is configured")
+	}
+	return readRegistryIndex(location)
+}
+
+// resolveRelease finds a plugin's release in the registry and the path of
+// its package, after checking the package file against the index digest.
+func (s *Service) resolveRelease(id, version string) (RegistryRelease, string, error) {
+	trimmed := strings.TrimSpace(id)
+	if trimmed == "" {
+		return RegistryRelease{}, "", errors.New("plugin id is required")
+	}
+	index, indexDir, err := s.registryIndex()
+	if err != nil {
+		return RegistryRelease{}, "", err
+	}
+
+	var plugin *RegistryPlugin
+	for i := range index.Plugins {
+		if index.Plugins[i].ID == trimmed {
+			plugin = &index.Plugins[i]
+			break
+		}
+	}
+	if plugin == nil {
+		return RegistryRelease{}, "", pluginError(PluginErrNotInRegistry, fmt.Sprintf("plugin %q is not in the registry", trimmed))
+	}
+
+	var release RegistryRelease
+	if strings.TrimSpace(version) == "" {
+		latest, ok := plugin.latest()
+		if !ok {
+			return RegistryRelease{}, "", pluginError(
+				PluginErrIncompatibleAPI,
+				fmt.Sprintf("no release of %q supports plugin API %s", trimmed, SupportedPluginAPIVersion),
+			)
+		}
+		release = latest
+	} else {
+		found := false
+		for _, r := range plugin.Releases {
+			if compareVersions(r.Version, version) == 0 {
+				release, found = r, true
+				break
+			}
+		}
+		if !found {
+			return RegistryRelease{}, "", pluginError(PluginErrNotInRegistry, fmt.Sprintf("plugin %q has no release %s", trimmed, version))
+		}
+		if !release.Compatible {
+			return RegistryRelease{}, "", pluginError(
+				PluginErrIncompatibleAPI,
+				fmt.Sprintf("%s %s needs plugin API %s; host implements %s", trimmed, release.Version, release.APIVersion, SupportedPluginAPIVersion),
+			)
+		}
+	}
+	release.pluginID = trimmed
+
+	packagePath, err := resolveRegistryPath(release.Package, indexDir)
+	if err != nil {
+		return RegistryRelease{}, "", err
+	}
+	sum, err := fileSHA256(packagePath)
+	if err != nil {
+		return RegistryRelease{}, "", pluginError(PluginErrBadSource, fmt.Sprintf("package not accessible: %v", err))
+	}
+	if !strings.EqualFold(strings.TrimSpace(release.SHA256), sum) {
+		return RegistryRelease{}, "", pluginError(PluginErrTamperedPackage, fmt.Sprintf("package for %s %s does not match the registry digest", trimmed, release.Version))
+	}
+	return release, packagePath, nil
+}
+
+// latest returns the newest release this host can run.
+func (p RegistryPlugin) latest() (RegistryRelease, bool) {
+	for _, r := range p.Releases {
+		if r.Compatible {
+			return r, true
+		}
+	}
+	return RegistryRelease{}, false
+}
+
+// matches checks a verified package is the release the index describes, so
+// a registry entry cannot be pointed at another plugin or signer.
+func (r RegistryRelease) matches(m Manifest, metadata InstallMetadata) error {
+	if m.ID != r.pluginID {
+		return pluginError(PluginErrBadSource, fmt.Sprintf("package is plugin %q, registry lists %q", m.ID, r.pluginID))
+	}
+	if compareVersions(m.Version, r.Version) != 0 {
+		return pluginError(PluginErrBadSource, fmt.Sprintf("package is version %s, registry lists %s", m.Version, r.Version))
+	}
+	if r.PublisherID != "" && r.PublisherID != metadata.PublisherID {
+		return pluginError(PluginErrUntrustedSigner, fmt.Sprintf("package is signed by %q, registry lists %q", metadata.PublisherID, r.PublisherID))
+	}
+	if r.KeyID != "" && r.KeyID != metadata.SigningKeyID {
+		return pluginError(PluginErrUntrustedSigner, fmt.Sprintf("package is signed with key %q, registry lists %q", metadata.SigningKeyID, r.KeyID))
+	}
+	return nil
+}
+
+// readRegistryIndex loads an index and returns it with the directory its
+// relative package paths start from. Releases are sorted newest first.
+func readRegistryIndex(location string) (RegistryIndex, string, error) {
+	path, err := resolveRegistryPath(location, "")
+	if err != nil {
+		return RegistryIndex{}, "", err
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, registryIndexFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RegistryIndex{}, "", pluginError(PluginErrRegistryUnavailable, fmt.Sprintf("read registry index: %v", err))
+	}
+	var index RegistryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return RegistryIndex{}, "", pluginError(PluginErrRegistryUnavailable, fmt.Sprintf("decode registry index: %v", err))
+	}
+
+	plugins := make([]RegistryPlugin, 0, len(index.Plugins))
+	for _, p := range index.Plugins {
+		p.ID = strings.TrimSpace(p.ID)
+		if p.ID == "" || p.ID == pluginStateNamespace || !filepath.IsLocal(p.ID) {
+			continue
+		}
+		p.LatestVersion, p.InstalledVersion, p.UpdateAvailable = "", "", false
+		for i := range p.Releases {
+			p.Releases[i].Compatible = isAPIVersionCompatible(p.Releases[i].APIVersion)
+		}
+		sort.SliceStable(p.Releases, func(i, j int) bool {
+			return compareVersions(p.Releases[i].Version, p.Releases[j].Version) > 0
+		})
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].ID < plugins[j].ID })
+	index.Plugins = plugins
+	return index, filepath.Dir(path), nil
+}
+
+// resolveRegistryPath turns an index location or package reference into a
+// local path. Only local paths and file URLs are accepted; relative paths
+// start from base.
+func resolveRegistryPath(ref, base string) (string, error) {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" {
+		return "", pluginError(PluginErrBadSource, "registry path is required")
+	}
+	if strings.Contains(trimmed, "://") {
+		u, err := url.Parse(trimmed)
+		if err != nil || u.Scheme != "file" {
+			return "", pluginError(PluginErrBadSource, fmt.Sprintf("unsupported registry location %q: use a local path or file:// URL", trimmed))
+		}
+		p := u.Path
+		if runtime.GOOS == "windows" {
+			p = strings.TrimPrefix(p, "/")
+		}
+		return filepath.FromSlash(p), nil
+	}
+	p := filepath.FromSlash(trimmed)
+	if !filepath.IsAbs(p) && base != "" {
+		p = filepath.Join(base, p)
+	}
+	return p, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func readManifest(pluginRoot string) (Manifest, error) {
+	var manifest Manifest
+	_, err := toml.DecodeFile(filepath.Join(pluginRoot, "plugin.toml"), &manifest)
+	return manifest, err
+}
+
+// addsCapabilities reports whether next declares a capability prev did not.
+func addsCapabilities(prev, next Manifest) bool {
+	for _, c := range next.Capabilities {
+		if !hasCapability(prev, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadRegistryLocation() string {
+	overrides := config.GetPreferencesOverrides()
+	location, _ := overrides.Plugins[pluginStateNamespace][pluginStateRegistryKey].(string)
+	return strings.TrimSpace(location)
+}
+
+func persistRegistryLocation(location string) error {
+	overrides := config.GetPreferencesOverrides()
+	if overrides.Plugins == nil {
+		overrides.Plugins = map[string]config.PreferencesPluginConfig{}
+	}
+
+	stateConfig := clonePluginConfig(overrides.Plugins[pluginStateNamespace])
+	if location == "" {
+		delete(stateConfig, pluginStateRegistryKey)
+	} else {
+		stateConfig[pluginStateRegistryKey] = location
+	}
+	overrides.Plugins[pluginStateNamespace] = stateConfig
+
+	if err := config.SetPreferencesOverrides(overrides); err != nil {
+		return fmt.Errorf("persist plugin registry: %w", err)
+	}
+	return nil
+}
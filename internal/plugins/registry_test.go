@@ -0,0 +1,297 @@
+package plugins
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/config"
+	"yanta/internal/testenv"
+)
+
+type testRegistry struct {
+	dir        string
+	privateKey ed25519.PrivateKey
+	releases   []RegistryRelease
+}
+
+func setupRegistry(t *testing.T) (*Service, *testRegistry) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cleanup := testenv.SetTestHome(t, tempDir)
+	t.Cleanup(cleanup)
+
+	config.ResetForTesting()
+	require.NoError(t, config.Init())
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	svc := NewService()
+	require.NoError(t, svc.AddTrustedPublisherKey("publisher-key-1", "publisher.acme", base64.StdEncoding.EncodeToString(publicKey)))
+	require.NoError(t, svc.SetCommunityPluginsEnabled(true))
+
+	dir := filepath.Join(tempDir, "registry")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "packages"), 0o755))
+	return svc, &testRegistry{dir: dir, privateKey: privateKey}
+}
+
+// add publishes a release of registry.plugin and rewrites index.json.
+func (r *testRegistry) add(t *testing.T, version, apiVersion, capabilities string) {
+	t.Helper()
+	name := "registry.plugin-" + version
+	packagePath := createSignedPluginPackage(t, filepath.Join(r.dir, "packages"), signedPackageOptions{
+		ManifestTOML: `id = "registry.plugin"
+name = "Registry Plugin"
+version = "` + version + `"
+api_version = "` + apiVersion + `"
+entry = "main.js"
+capabilities = ` + capabilities + `
+`,
+		PrivateKey:  r.privateKey,
+		KeyID:       "publisher-key-1",
+		PublisherID: "publisher.acme",
+		Name:        name,
+	})
+	sum, err := fileSHA256(packagePath)
+	require.NoError(t, err)
+	r.releases = append(r.releases, RegistryRelease{
+		Version:     version,
+		APIVersion:  apiVersion,
+		Package:     "packages/" + filepath.Base(packagePath),
+		SHA256:      sum,
+		PublisherID: "publisher.acme",
+		KeyID:       "publisher-key-1",
+	})
+	r.write(t)
+}
+
+func (r *testRegistry) write(t *testing.T) {
+	t.Helper()
+	index := RegistryIndex{Plugins: []RegistryPlugin{{ID: "registry.plugin", Name: "Registry Plugin", Releases: r.releases}}}
+	encoded, err := json.MarshalIndent(index, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, registryIndexFile), encoded, 0o644))
+}
+
+func installedVersion(t *testing.T, svc *Service) InstallRecord {
+	t.Helper()
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	return list[0]
+}
+
+func TestRegistry_ListAndInstall(t *testing.T) {
+	svc, registry := setupRegistry(t)
+	registry.add(t, "1.0.0", "1", `["commands"]`)
+	registry.add(t, "1.1.0", "1.1", `["commands"]`)
+	registry.add(t, "2.0.0", "1.9", `["commands"]`)
+
+	_, err := svc.ListRegistry()
+	require.ErrorContains(t, err, PluginErrRegistryUnavailable)
+	require.ErrorContains(t, svc.SetRegistryLocation("https://plugins.example.com/index.json"), PluginErrBadSource)
+	require.ErrorContains(t, svc.SetRegistryLocation(filepath.Join(registry.dir, "missing")), PluginErrRegistryUnavailable)
+	require.NoError(t, svc.SetRegistryLocation("file://"+filepath.ToSlash(registry.dir)))
+
+	plugins, err := svc.ListRegistry()
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	require.Equal(t, "1.1.0", plugins[0].LatestVersion)
+	require.Equal(t, "2.0.0", plugins[0].Releases[0].Version)
+	require.False(t, plugins[0].Releases[0].Compatible)
+	require.Empty(t, plugins[0].InstalledVersion)
+
+	_, err = svc.InstallFromRegistry("registry.plugin", "2.0.0")
+	require.ErrorContains(t, err, PluginErrIncompatibleAPI)
+	_, err = svc.InstallFromRegistry("missing.plugin", "")
+	require.ErrorContains(t, err, PluginErrNotInRegistry)
+
+	record, err := svc.InstallFromRegistry("registry.plugin", "")
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", record.Manifest.Version)
+	require.Equal(t, VerificationStatusVerified, record.VerificationStatus)
+	require.False(t, record.Enabled)
+
+	plugins, err = svc.ListRegistry()
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", plugins[0].InstalledVersion)
+	require.False(t, plugins[0].UpdateAvailable)
+
+	_, err = svc.InstallFromRegistry("registry.plugin", "1.0.0")
+	require.ErrorContains(t, err, PluginErrAlreadyInstalled)
+}
+
+func TestRegistry_UpgradeAndRollback(t *testing.T) {
+	svc, registry := setupRegistry(t)
+	registry.add(t, "1.0.0", "1", `["commands"]`)
+	require.NoError(t, svc.SetRegistryLocation(registry.dir))
+
+	_, err := svc.InstallFromRegistry("registry.plugin", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.SetPluginEnabled("registry.plugin", true))
+
+	updates, err := svc.CheckForUpdates()
+	require.NoError(t, err)
+	require.Empty(t, updates)
+
+	registry.add(t, "1.1.0", "1", `["commands"]`)
+	updates, err = svc.CheckForUpdates()
+	require.NoError(t, err)
+	require.Equal(t, []PluginUpdate{{PluginID: "registry.plugin", InstalledVersion: "1.0.0", LatestVersion: "1.1.0"}}, updates)
+
+	record, err := svc.UpgradePlugin("registry.plugin", "")
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", record.Manifest.Version)
+	require.True(t, record.Enabled)
+	require.Equal(t, VerificationStatusVerified, record.VerificationStatus)
+
+	_, err = svc.UpgradePlugin("registry.plugin", "")
+	require.ErrorContains(t, err, PluginErrNoUpdate)
+
+	record, err = svc.RollbackPlugin("registry.plugin")
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", record.Manifest.Version)
+	record, err = svc.RollbackPlugin("registry.plugin")
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", record.Manifest.Version)
+
+	require.NoError(t, svc.Uninstall("registry.plugin"))
+	_, err = svc.RollbackPlugin("registry.plugin")
+	require.ErrorContains(t, err, PluginErrNoPreviousVersion)
+}
+
+func TestRegistry_FailedUpgradeKeepsInstalledVersion(t *testing.T) {
+	svc, registry := setupRegistry(t)
+	registry.add(t, "1.0.0", "1", `["commands"]`)
+	require.NoError(t, svc.SetRegistryLocation(filepath.Join(registry.dir, registryIndexFile)))
+	_, err := svc.InstallFromRegistry("registry.plugin", "")
+	require.NoError(t, err)
+
+	registry.add(t, "1.1.0", "1", `["commands"]`)
+	registry.releases[1].SHA256 = registry.releases[0].SHA256
+	registry.write(t)
+	_, err = svc.UpgradePlugin("registry.plugin", "")
+	require.ErrorContains(t, err, PluginErrTamperedPackage)
+
+	registry.add(t, "1.2.0", "1", `["commands"]`)
+	registry.releases[2].PublisherID = "publisher.other"
+	registry.write(t)
+	_, err = svc.UpgradePlugin("registry.plugin", "1.2.0")
+	require.ErrorContains(t, err, PluginErrUntrustedSigner)
+
+	require.Equal(t, "1.0.0", installedVersion(t, svc).Manifest.Version)
+	root, err := svc.GetPluginDirectory()
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(root, stagingDirName, "registry.plugin"))
+	require.True(t, os.IsNotExist(err))
+	_, err = svc.RollbackPlugin("registry.plugin")
+	require.ErrorContains(t, err, PluginErrNoPreviousVersion)
+}
+
+func TestRegistry_UpgradeWithNewCapabilitiesDisablesPlugin(t *testing.T) {
+	svc, registry := setupRegistry(t)
+	registry.add(t, "1.0.0", "1", `["commands"]`)
+	require.NoError(t, svc.SetRegistryLocation(registry.dir))
+	_, err := svc.InstallFromRegistry("registry.plugin", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.SetPluginEnabled("registry.plugin", true))
+
+	registry.add(t, "1.1.0", "1.1", `["commands", "documentsWrite"]`)
+	record, err := svc.UpgradePlugin("registry.plugin", "")
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", record.Manifest.Version)
+	require.False(t, record.Enabled)
+}
+
+func TestRegistry_RollbackRechecksPreviousVersion(t *testing.T) {
+	svc, registry := setupRegistry(t)
+	registry.add(t, "1.0.0", "1", `["commands", "documentsWrite"]`)
+	require.NoError(t, svc.SetRegistryLocation(registry.dir))
+	_, err := svc.InstallFromRegistry("registry.plugin", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.SetPluginEnabled("registry.plugin", true))
+
+	registry.add(t, "1.1.0", "1", `["commands"]`)
+	record, err := svc.UpgradePlugin("registry.plugin", "")
+	require.NoError(t, err)
+	require.True(t, record.Enabled, "dropping a capability keeps the plugin enabled")
+
+	record, err = svc.RollbackPlugin("registry.plugin")
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", record.Manifest.Version)
+	require.False(t, record.Enabled, "rolling back to more capabilities disables the plugin")
+
+	root, err := svc.GetPluginDirectory()
+	require.NoError(t, err)
+	previousManifest := filepath.Join(root, previousDirName, "registry.plugin", "plugin.toml")
+	data, err := os.ReadFile(previousManifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(previousManifest, []byte(strings.Replace(string(data), `api_version = "1"`, `api_version = "2"`, 1)), 0o644))
+
+	_, err = svc.RollbackPlugin("registry.plugin")
+	require.ErrorContains(t, err, PluginErrIncompatibleAPI)
+	require.Equal(t, "1.0.0", installedVersion(t, svc).Manifest.Version)
+	_, err = os.Stat(previousManifest)
+	require.NoError(t, err, "a refused rollback keeps the previous version")
+}
+
+func TestRegistry_RecoversInterruptedSwap(t *testing.T) {
+	svc, registry := setupRegistry(t)
+	registry.add(t, "1.0.0", "1", `["commands"]`)
+	require.NoError(t, svc.SetRegistryLocation(registry.dir))
+	_, err := svc.InstallFromRegistry("registry.plugin", "")
+	require.NoError(t, err)
+	registry.add(t, "1.1.0", "1", `["commands"]`)
+	_, err = svc.UpgradePlugin("registry.plugin", "")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RecoverInterruptedSwaps())
+	require.Equal(t, "1.1.0", installedVersion(t, svc).Manifest.Version, "a completed swap is left alone")
+
+	// The app stopped after setting the installed version aside and before
+	// the staged one took its place.
+	root, err := svc.GetPluginDirectory()
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(filepath.Join(root, previousDirName, "registry.plugin")))
+	require.NoError(t, os.Rename(filepath.Join(root, "registry.plugin"), filepath.Join(root, previousDirName, "registry.plugin")))
+	staging := filepath.Join(root, stagingDirName, "registry.plugin")
+	require.NoError(t, os.MkdirAll(staging, 0o755))
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	require.NoError(t, svc.RecoverInterruptedSwaps())
+	require.Equal(t, "1.1.0", installedVersion(t, svc).Manifest.Version)
+	_, err = os.Stat(staging)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, svc.Uninstall("registry.plugin"))
+	require.NoError(t, svc.RecoverInterruptedSwaps())
+	list, err = svc.ListInstalled()
+	require.NoError(t, err)
+	require.Empty(t, list, "an uninstalled plugin stays uninstalled")
+}
+
+func TestVersion_CompareAndAPICompatibility(t *testing.T) {
+	require.Equal(t, 0, compareVersions("1", "1.0.0"))
+	require.Equal(t, 1, compareVersions("1.10.0", "1.9.3"))
+	require.Equal(t, -1, compareVersions("1.0.0-beta.2", "1.0.0"))
+	require.Equal(t, -1, compareVersions("1.0.0-beta.2", "1.0.0-beta.10"))
+	require.Equal(t, 1, compareVersions("v2.0.0+build.7", "1.99.0"))
+	require.Equal(t, -1, compareVersions("nightly", "0.0.1"))
+
+	require.True(t, isAPIVersionCompatible("1"))
+	require.True(t, isAPIVersionCompatible("1.0.0"))
+	require.True(t, isAPIVersionCompatible(SupportedPluginAPIVersion))
+	require.False(t, isAPIVersionCompatible("1.99"))
+	require.False(t, isAPIVersionCompatible("2"))
+	require.False(t, isAPIVersionCompatible("1.0.0-rc.1"))
+	require.False(t, isAPIVersionCompatible("one"))
+}
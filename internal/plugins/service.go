@@ -7,48 +7,45 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/BurntSushi/toml"
 
 	"yanta/internal/config"
+	"yanta/internal/plugins/manifest"
 )
 
-const pluginStateNamespace = "__plugin_state"
 const pluginStateEnabledKey = "enabled"
 const pluginStateCommunityEnabledKey = "community_enabled"
 const pluginSourceLocal = "local"
 const pluginSourcePackage = "package"
+const pluginSourceDev = "dev"
 const installMetadataFile = ".yanta-install.json"
-const requiredPluginEntrypoint = "main.js"
-const requiredPluginBuildMetadataFile = "main.meta.json"
+const requiredPluginEntrypoint = manifest.Entrypoint
+const requiredPluginBuildMetadataFile = manifest.BuildMetadataFile
 
 const (
-	PluginErrInvalidManifest      = "PLUGIN_INVALID_MANIFEST"
-	PluginErrIncompatibleAPI      = "PLUGIN_INCOMPATIBLE_API"
-	PluginErrAlreadyInstalled     = "PLUGIN_ALREADY_INSTALLED"
-	PluginErrNotInstalled         = "PLUGIN_NOT_INSTALLED"
+	PluginErrInvalidManifest      = manifest.ErrInvalidManifest
+	PluginErrIncompatibleAPI      = manifest.ErrIncompatibleAPI
+	PluginErrAlreadyInstalled     = manifest.ErrAlreadyInstalled
+	PluginErrNotInstalled         = manifest.ErrNotInstalled
 	PluginErrNotOperational       = "PLUGIN_NOT_OPERATIONAL"
 	PluginErrSandboxRestricted    = "PLUGIN_SANDBOX_RESTRICTED"
-	PluginErrBadSource            = "PLUGIN_BAD_SOURCE"
+	PluginErrBadSource            = manifest.ErrBadSource
 	PluginErrUnsignedPackage      = "PLUGIN_UNSIGNED_PACKAGE"
 	PluginErrTamperedPackage      = "PLUGIN_TAMPERED_PACKAGE"
 	PluginErrInvalidSignature     = "PLUGIN_INVALID_SIGNATURE"
 	PluginErrUntrustedSigner      = "PLUGIN_UNTRUSTED_SIGNER"
-	PluginErrBuildMetadataMissing = "PLUGIN_BUILD_METADATA_MISSING"
-	PluginErrBuildMetadataInvalid = "PLUGIN_BUILD_METADATA_INVALID"
-	PluginErrBuildHashMismatch    = "PLUGIN_BUILD_HASH_MISMATCH"
-	PluginErrForbiddenBundle      = "PLUGIN_FORBIDDEN_BUNDLE"
-)
-
-type PluginStatus string
-
-const (
-	PluginStatusOK              PluginStatus = "ok"
-	PluginStatusInvalidManifest PluginStatus = "invalid_manifest"
-	PluginStatusIncompatibleAPI PluginStatus = "incompatible_api"
+	PluginErrBuildMetadataMissing = manifest.ErrBuildMetadataMissing
+	PluginErrBuildMetadataInvalid = manifest.ErrBuildMetadataInvalid
+	PluginErrBuildHashMismatch    = manifest.ErrBuildHashMismatch
+	PluginErrForbiddenBundle      = manifest.ErrForbiddenBundle
+	PluginErrCapabilityDenied     = "PLUGIN_CAPABILITY_DENIED"
+	PluginErrBadHostCall          = "PLUGIN_BAD_HOST_CALL"
+	PluginErrStorageQuota         = "PLUGIN_STORAGE_QUOTA"
+	PluginErrBadSession           = "PLUGIN_BAD_SESSION"
+	PluginErrAlreadyLoaded        = "PLUGIN_ALREADY_LOADED"
 )
 
 type IsolationMode string
@@ -68,30 +65,13 @@ const (
 	VerificationStatusInvalid   VerificationStatus = "invalid"
 )
 
-type ValidationIssue struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Field   string `json:"field,omitempty"`
-}
-
-type Manifest struct {
-	ID           string   `toml:"id"`
-	Name         string   `toml:"name"`
-	Version      string   `toml:"version"`
-	APIVersion   string   `toml:"api_version"`
-	Entry        string   `toml:"entry"`
-	Capabilities []string `toml:"capabilities"`
-	Description  string   `toml:"description"`
-	Author       string   `toml:"author"`
-	Homepage     string   `toml:"homepage"`
-}
-
 type InstallRecord struct {
 	Manifest           Manifest           `json:"manifest"`
 	Path               string             `json:"path"`
 	Source             string             `json:"source"`
 	Enabled            bool               `json:"enabled"`
 	Status             PluginStatus       `json:"status"`
+	Runtime            PluginRuntime      `json:"runtime"`
 	Isolation          IsolationMode      `json:"isolation"`
 	CanExecute         bool               `json:"canExecute"`
 	VerificationStatus VerificationStatus `json:"verificationStatus"`
@@ -107,6 +87,18 @@ type State struct {
 
 type Service struct {
 	mu sync.RWMutex
+
+	// issuesMu guards runtimeIssues: what went wrong running each plugin
+	// since startup, such as denied host calls. They are reported on the
+	// plugin's install record.
+	issuesMu      sync.Mutex
+	runtimeIssues map[string][]ValidationIssue
+
+	// recordsMu guards records, the install records host calls check
+	// against, so a call does not rescan the plugin directory. Every change
+	// to what is installed or enabled drops them.
+	recordsMu sync.Mutex
+	records   []InstallRecord
 }
 
 type InstallMetadata struct {
@@ -151,11 +143,18 @@ func (s *Service) scanLocalPlugins() ([]InstallRecord, error) {
 	communityEnabled := loadCommunityPluginsEnabled()
 	records := make([]InstallRecord, 0, len(entries))
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		pluginPath := filepath.Join(root, entry.Name())
+		devLinked := entry.Type()&os.ModeSymlink != 0
+		if devLinked {
+			// A dev link points at a plugin's working directory; the plugin
+			// runs from there.
+			if info, err := os.Stat(pluginPath); err != nil || !info.IsDir() {
+				continue
+			}
+		} else if !entry.IsDir() {
 			continue
 		}
 
-		pluginPath := filepath.Join(root, entry.Name())
 		manifestPath := filepath.Join(pluginPath, "plugin.toml")
 		if _, err := os.Stat(manifestPath); err != nil {
 			continue
@@ -189,12 +188,15 @@ func (s *Service) scanLocalPlugins() ([]InstallRecord, error) {
 			publisherID = metadata.PublisherID
 			signingKeyID = metadata.SigningKeyID
 		}
-		if buildFailure := checkPluginBuildMetadata(pluginPath); buildFailure != nil {
+		if devLinked {
+			source = pluginSourceDev
+		}
+		if buildFailure := checkPluginEntry(pluginPath, manifest); buildFailure != nil {
 			canExecute = false
 			issues = append(issues, ValidationIssue{
 				Code:    buildFailure.Code,
 				Message: buildFailure.Message,
-				Field:   requiredPluginBuildMetadataFile,
+				Field:   buildFailure.Field,
 			})
 		}
 		enabled := enabledMap[manifest.ID] && canExecute && communityEnabled
@@ -204,12 +206,13 @@ func (s *Service) scanLocalPlugins() ([]InstallRecord, error) {
 			Source:             source,
 			Enabled:            enabled,
 			Status:             status,
+			Runtime:            runtimeOf(manifest),
 			Isolation:          isolation,
 			CanExecute:         canExecute,
 			VerificationStatus: verificationStatus,
 			PublisherID:        publisherID,
 			SigningKeyID:       signingKeyID,
-			Issues:             issues,
+			Issues:             append(issues, s.issuesOf(manifest.ID)...),
 		})
 	}
 
@@ -260,12 +263,14 @@ func (s *Service) GetCommunityPluginsEnabled() bool {
 func (s *Service) SetCommunityPluginsEnabled(enabled bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.invalidateRecords()
 	return persistCommunityPluginsEnabled(enabled)
 }
 
 func (s *Service) SetPluginEnabled(id string, enabled bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.invalidateRecords()
 
 	trimmed := strings.TrimSpace(id)
 	if trimmed == "" {
@@ -304,6 +309,7 @@ func (s *Service) SetPluginEnabled(id string, enabled bool) error {
 func (s *Service) InstallFromDirectory(sourcePath string) (InstallRecord, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.invalidateRecords()
 
 	trimmedPath := strings.TrimSpace(sourcePath)
 	if trimmedPath == "" {
@@ -327,21 +333,21 @@ func (s *Service) InstallFromDirectory(sourcePath string) (InstallRecord, error)
 	if status == PluginStatusIncompatibleAPI {
 		return InstallRecord{}, pluginError(PluginErrIncompatibleAPI, summarizeIssues(issues))
 	}
-	entryPath := filepath.Join(trimmedPath, requiredPluginEntrypoint)
-	entryInfo, err := os.Stat(entryPath)
+	entry := strings.TrimSpace(manifest.Entry)
+	entryInfo, err := os.Stat(filepath.Join(trimmedPath, filepath.Clean(entry)))
 	if err != nil {
 		return InstallRecord{}, pluginError(
 			PluginErrInvalidManifest,
-			fmt.Sprintf("required runtime entry %q is missing", requiredPluginEntrypoint),
+			fmt.Sprintf("required runtime entry %q is missing", entry),
 		)
 	}
 	if entryInfo.IsDir() {
 		return InstallRecord{}, pluginError(
 			PluginErrInvalidManifest,
-			fmt.Sprintf("required runtime entry %q must be a file", requiredPluginEntrypoint),
+			fmt.Sprintf("required runtime entry %q must be a file", entry),
 		)
 	}
-	if buildFailure := checkPluginBuildMetadata(trimmedPath); buildFailure != nil {
+	if buildFailure := checkPluginEntry(trimmedPath, manifest); buildFailure != nil {
 		return InstallRecord{}, pluginError(buildFailure.Code, buildFailure.Message)
 	}
 
@@ -376,6 +382,7 @@ func (s *Service) InstallFromDirectory(sourcePath string) (InstallRecord, error)
 		Source:             pluginSourceLocal,
 		Enabled:            false,
 		Status:             PluginStatusOK,
+		Runtime:            runtimeOf(manifest),
 		Isolation:          IsolationModeLocal,
 		CanExecute:         true,
 		VerificationStatus: VerificationStatusNone,
@@ -385,6 +392,7 @@ func (s *Service) InstallFromDirectory(sourcePath string) (InstallRecord, error)
 func (s *Service) Uninstall(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer s.invalidateRecords()
 
 	trimmed := strings.TrimSpace(id)
 	if trimmed == "" {
@@ -405,9 +413,18 @@ func (s *Service) Uninstall(id string) error {
 		return fmt.Errorf("stat plugin path %s: %w", pluginPath, err)
 	}
 
+	// The previous version goes first: left on its own, it would be
+	// reinstalled by RecoverInterruptedSwaps.
+	if err := os.RemoveAll(filepath.Join(root, previousDirName, trimmed)); err != nil {
+		return fmt.Errorf("remove previous plugin version: %w", err)
+	}
 	if err := os.RemoveAll(pluginPath); err != nil {
 		return fmt.Errorf("remove plugin path %s: %w", pluginPath, err)
 	}
+	s.clearIssues(trimmed)
+	if err := removePluginStorage(trimmed); err != nil {
+		return err
+	}
 
 	overrides := config.GetPreferencesOverrides()
 	if overrides.Plugins == nil {
@@ -518,111 +535,6 @@ func copyFile(sourcePath string, destPath string) error {
 	return nil
 }
 
-func validateManifest(m Manifest, decodeErr error) (PluginStatus, []ValidationIssue) {
-	issues := []ValidationIssue{}
-	if decodeErr != nil {
-		issues = append(issues, ValidationIssue{
-			Code:    "MANIFEST_DECODE_ERROR",
-			Message: decodeErr.Error(),
-			Field:   "plugin.toml",
-		})
-	}
-	issues = append(issues, manifestValidationIssues(m)...)
-	if len(issues) > 0 {
-		return PluginStatusInvalidManifest, issues
-	}
-	if !isAPIVersionCompatible(m.APIVersion) {
-		return PluginStatusIncompatibleAPI, []ValidationIssue{
-			{
-				Code:    "INCOMPATIBLE_API_VERSION",
-				Message: fmt.Sprintf("plugin api_version %q is not supported (expected major %d)", m.APIVersion, SupportedPluginAPIMajor),
-				Field:   "api_version",
-			},
-		}
-	}
-	return PluginStatusOK, nil
-}
-
-func manifestValidationIssues(m Manifest) []ValidationIssue {
-	issues := []ValidationIssue{}
-	if strings.TrimSpace(m.ID) == "" {
-		issues = append(issues, ValidationIssue{
-			Code:    "MISSING_REQUIRED_FIELD",
-			Message: "missing required field: id",
-			Field:   "id",
-		})
-	}
-	if strings.TrimSpace(m.Name) == "" {
-		issues = append(issues, ValidationIssue{
-			Code:    "MISSING_REQUIRED_FIELD",
-			Message: "missing required field: name",
-			Field:   "name",
-		})
-	}
-	if strings.TrimSpace(m.Version) == "" {
-		issues = append(issues, ValidationIssue{
-			Code:    "MISSING_REQUIRED_FIELD",
-			Message: "missing required field: version",
-			Field:   "version",
-		})
-	}
-	if strings.TrimSpace(m.APIVersion) == "" {
-		issues = append(issues, ValidationIssue{
-			Code:    "MISSING_REQUIRED_FIELD",
-			Message: "missing required field: api_version",
-			Field:   "api_version",
-		})
-	}
-	if strings.TrimSpace(m.Entry) == "" {
-		issues = append(issues, ValidationIssue{
-			Code:    "MISSING_REQUIRED_FIELD",
-			Message: "missing required field: entry",
-			Field:   "entry",
-		})
-	} else if strings.TrimSpace(m.Entry) != requiredPluginEntrypoint {
-		issues = append(issues, ValidationIssue{
-			Code:    "INVALID_ENTRYPOINT",
-			Message: fmt.Sprintf("entry must be %q", requiredPluginEntrypoint),
-			Field:   "entry",
-		})
-	}
-	if strings.TrimSpace(m.ID) == pluginStateNamespace {
-		issues = append(issues, ValidationIssue{
-			Code:    "RESERVED_PLUGIN_ID",
-			Message: "plugin id uses reserved namespace",
-			Field:   "id",
-		})
-	}
-	return issues
-}
-
-func isAPIVersionCompatible(raw string) bool {
-	trimmed := strings.TrimSpace(raw)
-	if trimmed == "" {
-		return false
-	}
-	majorPart := trimmed
-	if idx := strings.Index(trimmed, "."); idx >= 0 {
-		majorPart = trimmed[:idx]
-	}
-	major, err := strconv.Atoi(majorPart)
-	if err != nil {
-		return false
-	}
-	return major == SupportedPluginAPIMajor
-}
-
-func summarizeIssues(issues []ValidationIssue) string {
-	if len(issues) == 0 {
-		return "manifest validation failed"
-	}
-	messages := make([]string, 0, len(issues))
-	for _, issue := range issues {
-		messages = append(messages, issue.Message)
-	}
-	return strings.Join(messages, "; ")
-}
-
 func pluginError(code, message string) error {
 	return fmt.Errorf("%s: %s", code, message)
 }
@@ -671,6 +583,9 @@ func (s *Service) ReadPluginEntrypoint(id string) (string, error) {
 	if strings.HasPrefix(entry, "builtin:") {
 		return "", pluginError(PluginErrBadSource, "builtin plugin entrypoints are embedded in frontend bundle")
 	}
+	if isWASMEntry(entry) {
+		return "", pluginError(PluginErrBadSource, "wasm plugins run in the backend")
+	}
 	if filepath.IsAbs(entry) {
 		return "", pluginError(PluginErrInvalidManifest, "plugin entry must be relative path")
 	}
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"yanta/internal/config"
+	"yanta/internal/plugins/manifest"
 	"yanta/internal/testenv"
 )
 
@@ -549,12 +550,19 @@ type signedPackageOptions struct {
 	KeyID           string
 	PublisherID     string
 	MutateAfterSign bool
+	// Name tells apart packages signed with the same key. It defaults to
+	// the key ID.
+	Name string
 }
 
 func createSignedPluginPackage(t *testing.T, root string, opts signedPackageOptions) string {
 	t.Helper()
 
-	sourceDir := filepath.Join(root, "plugin-source-"+opts.KeyID)
+	name := opts.Name
+	if name == "" {
+		name = opts.KeyID
+	}
+	sourceDir := filepath.Join(root, "plugin-source-"+name)
 	require.NoError(t, os.MkdirAll(sourceDir, 0o755))
 	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "plugin.toml"), []byte(opts.ManifestTOML), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "main.js"), []byte("export default {}"), 0o644))
@@ -581,7 +589,7 @@ func createSignedPluginPackage(t *testing.T, root string, opts signedPackageOpti
 		writePluginBuildMetadata(t, sourceDir, nil)
 	}
 
-	packagePath := filepath.Join(root, "plugin-"+opts.KeyID+".yplg")
+	packagePath := filepath.Join(root, "plugin-"+name+".yplg")
 	writeZipFromDir(t, sourceDir, packagePath)
 	return packagePath
 }
@@ -647,11 +655,11 @@ func writePluginBuildMetadata(t *testing.T, pluginDir string, bundledPackages []
 	sum := sha256.Sum256(entryData)
 
 	meta := PluginBuildMetadata{
-		Builder:                 pluginBuildMetadataBuilder,
+		Builder:                 manifest.BuildMetadataBuilder,
 		BuilderVersion:          "test",
-		BuildTool:               pluginBuildMetadataTool,
-		Format:                  pluginBuildMetadataFormat,
-		HostExternals:           append([]string(nil), forbiddenBundledRuntimePackages...),
+		BuildTool:               manifest.BuildMetadataTool,
+		Format:                  manifest.BuildMetadataFormat,
+		HostExternals:           append([]string(nil), manifest.HostExternals...),
 		DetectedBundledPackages: append([]string(nil), bundledPackages...),
 		EntryHashSHA256:         hex.EncodeToString(sum[:]),
 		GeneratedAt:             "2026-01-01T00:00:00Z",
@@ -677,4 +685,16 @@ func TestService_PluginDirectoryFollowsAppRoot(t *testing.T) {
 	dir, err := svc.GetPluginDirectory()
 	require.NoError(t, err)
 	require.Equal(t, filepath.Join(appRoot, "plugins"), dir)
+
+	// The yanta-plugin CLI resolves the same directory without the config.
+	cliDir, err := manifest.Directory()
+	require.NoError(t, err)
+	require.Equal(t, dir, cliDir)
+
+	cleanupAppRoot()
+	dir, err = svc.GetPluginDirectory()
+	require.NoError(t, err)
+	cliDir, err = manifest.Directory()
+	require.NoError(t, err)
+	require.Equal(t, dir, cliDir)
 }
@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"yanta/internal/config"
+)
+
+const (
+	maxStorageKeyBytes = 256
+	// maxStorageBytes caps a plugin's storage file, so a plugin cannot fill
+	// the disk or slow every call with a huge document.
+	maxStorageBytes = 1 << 20 // 1 MiB
+)
+
+// pluginStoragePath is the key-value store of one plugin. It lives outside
+// the plugin's install directory so it survives reinstalling the plugin.
+func pluginStoragePath(pluginID string) (string, error) {
+	root := config.GetAppRootDirectory()
+	if root == "" {
+		return "", fmt.Errorf("resolve app root directory: empty path")
+	}
+	return filepath.Join(root, "plugin-data", pluginID+".json"), nil
+}
+
+func validateStorageKey(key string) error {
+	if key == "" {
+		return pluginError(PluginErrBadHostCall, "storage: key is required")
+	}
+	if len(key) > maxStorageKeyBytes {
+		return pluginError(PluginErrBadHostCall, fmt.Sprintf("storage: key longer than %d bytes", maxStorageKeyBytes))
+	}
+	return nil
+}
+
+func loadPluginStorage(pluginID string) (map[string]json.RawMessage, error) {
+	path, err := pluginStoragePath(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read plugin storage %s: %w", path, err)
+	}
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("decode plugin storage %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func savePluginStorage(pluginID string, values map[string]json.RawMessage) error {
+	path, err := pluginStoragePath(pluginID)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if len(encoded) > maxStorageBytes {
+		return pluginError(PluginErrStorageQuota, fmt.Sprintf("plugin %q storage would exceed %d bytes", pluginID, maxStorageBytes))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create plugin storage directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, encoded, 0o600); err != nil {
+		return fmt.Errorf("write plugin storage %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace plugin storage %s: %w", path, err)
+	}
+	return nil
+}
+
+func removePluginStorage(pluginID string) error {
+	path, err := pluginStoragePath(pluginID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove plugin storage %s: %w", path, err)
+	}
+	return nil
+}
@@ -1,4 +0,0 @@
-package plugins
-
-// SupportedPluginAPIMajor is the single source of truth for plugin API compatibility.
-const SupportedPluginAPIMajor = 1
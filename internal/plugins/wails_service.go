@@ -1,14 +1,20 @@
 package plugins
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
-// WailsService exposes plugin metadata and lifecycle state via Wails bindings.
+// WailsService exposes plugin metadata, lifecycle state and the host API via
+// Wails bindings.
 type WailsService struct {
-	svc *Service
+	svc  *Service
+	host *Host
+	wasm *WASMRuntime
 }
 
-func NewWailsService(svc *Service) *WailsService {
-	return &WailsService{svc: svc}
+func NewWailsService(svc *Service, host *Host, wasm *WASMRuntime) *WailsService {
+	return &WailsService{svc: svc, host: host, wasm: wasm}
 }
 
 func (s *WailsService) ListInstalled(ctx context.Context) ([]InstallRecord, error) {
@@ -24,7 +30,14 @@ func (s *WailsService) GetPluginState(ctx context.Context, id string) (State, er
 }
 
 func (s *WailsService) SetPluginEnabled(ctx context.Context, id string, enabled bool) error {
-	return s.svc.SetPluginEnabled(id, enabled)
+	if err := s.svc.SetPluginEnabled(id, enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		s.host.Release(id)
+		s.wasm.Unload(ctx, id)
+	}
+	return nil
 }
 
 func (s *WailsService) GetCommunityPluginsEnabled(ctx context.Context) bool {
@@ -44,21 +57,113 @@ func (s *WailsService) InstallFromPackage(ctx context.Context, sourcePath string
 }
 
 func (s *WailsService) Uninstall(ctx context.Context, pluginID string) error {
-	return s.svc.Uninstall(pluginID)
+	if err := s.svc.Uninstall(pluginID); err != nil {
+		return err
+	}
+	s.host.Release(pluginID)
+	s.host.EndSession(pluginID)
+	s.wasm.Unload(ctx, pluginID)
+	return nil
+}
+
+func (s *WailsService) GetRegistryLocation(ctx context.Context) string {
+	return s.svc.GetRegistryLocation()
+}
+
+func (s *WailsService) SetRegistryLocation(ctx context.Context, location string) error {
+	return s.svc.SetRegistryLocation(location)
+}
+
+func (s *WailsService) ListRegistry(ctx context.Context) ([]RegistryPlugin, error) {
+	return s.svc.ListRegistry()
+}
+
+func (s *WailsService) CheckForUpdates(ctx context.Context) ([]PluginUpdate, error) {
+	return s.svc.CheckForUpdates()
+}
+
+func (s *WailsService) InstallFromRegistry(ctx context.Context, pluginID, version string) (InstallRecord, error) {
+	return s.svc.InstallFromRegistry(pluginID, version)
+}
+
+// UpgradePlugin swaps in a newer release and drops what the old version
+// registered, so the frontend and the WASM runtime load the new one.
+func (s *WailsService) UpgradePlugin(ctx context.Context, pluginID, version string) (InstallRecord, error) {
+	record, err := s.svc.UpgradePlugin(pluginID, version)
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	s.host.Release(pluginID)
+	s.host.EndSession(pluginID)
+	s.wasm.Unload(ctx, pluginID)
+	return record, nil
+}
+
+func (s *WailsService) RollbackPlugin(ctx context.Context, pluginID string) (InstallRecord, error) {
+	record, err := s.svc.RollbackPlugin(pluginID)
+	if err != nil {
+		return InstallRecord{}, err
+	}
+	s.host.Release(pluginID)
+	s.host.EndSession(pluginID)
+	s.wasm.Unload(ctx, pluginID)
+	return record, nil
 }
 
 func (s *WailsService) GetPluginDirectory(ctx context.Context) (string, error) {
 	return s.svc.GetPluginDirectory()
 }
 
-func (s *WailsService) ReadPluginEntrypoint(ctx context.Context, pluginID string) (string, error) {
-	return s.svc.ReadPluginEntrypoint(pluginID)
+// PluginEntrypoint is a frontend plugin's code and the session its host
+// calls carry.
+type PluginEntrypoint struct {
+	Source  string `json:"source"`
+	Session string `json:"session"`
+}
+
+// ReadPluginEntrypoint returns a plugin's code and opens its host session.
+// Each plugin is read once per frontend load.
+func (s *WailsService) ReadPluginEntrypoint(ctx context.Context, pluginID string) (PluginEntrypoint, error) {
+	source, err := s.svc.ReadPluginEntrypoint(pluginID)
+	if err != nil {
+		return PluginEntrypoint{}, err
+	}
+	session, err := s.host.OpenSession(pluginID)
+	if err != nil {
+		return PluginEntrypoint{}, err
+	}
+	return PluginEntrypoint{Source: source, Session: session}, nil
+}
+
+// CallHost is the bridge plugins reach the host API through.
+func (s *WailsService) CallHost(ctx context.Context, req HostRequest) (any, error) {
+	return s.host.Call(ctx, req)
+}
+
+// ReleasePlugin drops what a plugin registered with the host when the
+// frontend unloads it.
+func (s *WailsService) ReleasePlugin(ctx context.Context, session string) error {
+	return s.host.ReleaseSession(session)
+}
+
+// RunPluginAction runs an action, such as an import or export, in a WASM
+// plugin and returns its JSON reply.
+func (s *WailsService) RunPluginAction(ctx context.Context, pluginID, action string, input json.RawMessage) (json.RawMessage, error) {
+	return s.wasm.Invoke(ctx, pluginID, action, input)
+}
+
+func (s *WailsService) ListPluginCommands(ctx context.Context) []PluginCommand {
+	return s.host.Commands()
 }
 
 func (s *WailsService) GetSupportedPluginAPIMajor(ctx context.Context) int {
 	return SupportedPluginAPIMajor
 }
 
+func (s *WailsService) GetSupportedPluginAPIVersion(ctx context.Context) string {
+	return SupportedPluginAPIVersion
+}
+
 func (s *WailsService) ListTrustedPublisherKeys(ctx context.Context) ([]TrustedPublisherKey, error) {
 	return s.svc.ListTrustedPublisherKeys()
 }
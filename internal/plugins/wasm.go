@@ -0,0 +1,21 @@
+package plugins
+
+import "yanta/internal/plugins/manifest"
+
+// PluginRuntime is where a plugin's entry runs.
+type PluginRuntime string
+
+const (
+	// PluginRuntimeJS plugins are ES modules the frontend loads.
+	PluginRuntimeJS PluginRuntime = "js"
+	// PluginRuntimeWASM plugins are WebAssembly modules the backend runs in a
+	// sandbox, with or without the UI open.
+	PluginRuntimeWASM PluginRuntime = "wasm"
+)
+
+func runtimeOf(m Manifest) PluginRuntime {
+	if manifest.IsWASMEntry(m.Entry) {
+		return PluginRuntimeWASM
+	}
+	return PluginRuntimeJS
+}
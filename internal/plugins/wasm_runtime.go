@@ -0,0 +1,416 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"yanta/internal/events"
+	"yanta/internal/logger"
+	"yanta/internal/plugins/manifest"
+)
+
+// wasmHostModule is the import module name of the host functions.
+const wasmHostModule = "yanta"
+
+// wasmEchoWindow is how long after a plugin writes a document or journal the
+// resulting save events are not sent back to it, so a plugin that edits a
+// document on save does not trigger itself forever.
+const wasmEchoWindow = 2 * time.Second
+
+const wasmEventQueue = 64
+
+// WASMRuntime runs the enabled WASM plugins in the backend, each in its own
+// wazero sandbox with capped memory and no filesystem, network or
+// environment: the host functions are its only way out.
+//
+// A module exports "memory", "alloc(size i32) i32" and
+// "handle(ptr i32, len i32) i64". Each event or action creates a fresh
+// instance and passes handle a JSON message; handle returns the location of
+// its JSON reply packed as ptr<<32|len, or 0. State that must outlive a call
+// goes in plugin storage.
+//
+// The host functions live in the "yanta" import module: one per host API
+// method with "." replaced by "_" (documents_read, search, journal_append,
+// ...), each taking the JSON params as (ptr, len) and returning a packed
+// {"result": ...} or {"error": "..."} written through alloc, plus
+// "log(ptr, len)". They run through Host.Call, so the manifest's
+// capabilities apply exactly as for JS plugins.
+type WASMRuntime struct {
+	svc  *Service
+	host *Host
+
+	mu      sync.Mutex
+	modules map[string]*wasmModule
+
+	events chan wasmMessage
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+type wasmModule struct {
+	id       string
+	key      string
+	limits   WASMLimits
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+
+	// mu serializes calls and guards echoes, the time of the module's last
+	// write to each document or journal.
+	mu     sync.Mutex
+	echoes map[string]time.Time
+}
+
+// wasmMessage is the JSON handed to a module's handle export. Events carry
+// the saved entry's fields; actions carry the caller's input.
+type wasmMessage struct {
+	Kind      string          `json:"kind"`
+	Event     string          `json:"event,omitempty"`
+	Action    string          `json:"action,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	Path      string          `json:"path,omitempty"`
+	ProjectID string          `json:"projectId,omitempty"`
+	Title     string          `json:"title,omitempty"`
+	Type      string          `json:"type,omitempty"`
+	Date      string          `json:"date,omitempty"`
+	EntryID   string          `json:"entryId,omitempty"`
+}
+
+type wasmHostReply struct {
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func NewWASMRuntime(svc *Service, host *Host) *WASMRuntime {
+	return &WASMRuntime{
+		svc:     svc,
+		host:    host,
+		modules: map[string]*wasmModule{},
+		events:  make(chan wasmMessage, wasmEventQueue),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start delivers queued events to plugins until Close.
+func (r *WASMRuntime) Start() {
+	go func() {
+		defer close(r.done)
+		for {
+			select {
+			case <-r.stop:
+				return
+			case msg := <-r.events:
+				r.dispatch(context.Background(), msg)
+			}
+		}
+	}()
+}
+
+// Close stops event delivery and releases every loaded module.
+func (r *WASMRuntime) Close(ctx context.Context) {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, m := range r.modules {
+		_ = m.runtime.Close(ctx)
+		delete(r.modules, id)
+	}
+}
+
+// Listen is the event bus listener. It queues document and journal saves for
+// the plugins without blocking the emitter; when the queue is full the event
+// is dropped.
+func (r *WASMRuntime) Listen(name string, data any) {
+	var msg wasmMessage
+	switch d := data.(type) {
+	case events.EntryCreatedData:
+		if name != events.EntryCreated {
+			return
+		}
+		msg = wasmMessage{Kind: "event", Event: "entry.created", Path: d.Path, ProjectID: d.ProjectID, Title: d.Title, Type: d.Type, Date: d.Date, EntryID: d.EntryID}
+	case events.EntryUpdatedData:
+		if name != events.EntryUpdated {
+			return
+		}
+		msg = wasmMessage{Kind: "event", Event: "entry.updated", Path: d.Path, ProjectID: d.ProjectID, Title: d.Title, Type: d.Type, Date: d.Date, EntryID: d.EntryID}
+	default:
+		return
+	}
+	select {
+	case r.events <- msg:
+	default:
+		logger.WithField("event", msg.Event).Warn("wasm plugin event queue full; event dropped")
+	}
+}
+
+// Invoke runs an action, such as an importer or exporter, in an enabled WASM
+// plugin and returns its reply.
+func (r *WASMRuntime) Invoke(ctx context.Context, pluginID, action string, input json.RawMessage) (json.RawMessage, error) {
+	record, err := r.svc.runnableRecord(strings.TrimSpace(pluginID))
+	if err != nil {
+		return nil, err
+	}
+	if record.Runtime != PluginRuntimeWASM {
+		return nil, pluginError(PluginErrBadSource, fmt.Sprintf("plugin %q is not a wasm plugin", record.Manifest.ID))
+	}
+	m, err := r.load(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := r.call(ctx, m, wasmMessage{Kind: "action", Action: action, Input: input})
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(reply), nil
+}
+
+// Unload releases a plugin's module, when it is disabled or uninstalled.
+func (r *WASMRuntime) Unload(ctx context.Context, pluginID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.modules[pluginID]; ok {
+		_ = m.runtime.Close(ctx)
+		delete(r.modules, pluginID)
+	}
+}
+
+// dispatch sends an event to every enabled WASM plugin allowed to read the
+// vault.
+func (r *WASMRuntime) dispatch(ctx context.Context, msg wasmMessage) {
+	records, err := r.svc.ListInstalled()
+	if err != nil {
+		logger.WithError(err).Warn("wasm plugins: listing plugins failed")
+		return
+	}
+	for _, record := range records {
+		if record.Runtime != PluginRuntimeWASM || !record.Enabled || record.Status != PluginStatusOK ||
+			!hasCapability(record.Manifest, manifest.CapabilityDocumentsRead) {
+			continue
+		}
+		m, err := r.load(ctx, record)
+		if err != nil {
+			continue
+		}
+		if m.isEcho(msg) {
+			continue
+		}
+		_, _ = r.call(ctx, m, msg)
+	}
+}
+
+// load returns the plugin's compiled module, recompiling it when the file
+// changed since it was loaded.
+func (r *WASMRuntime) load(ctx context.Context, record InstallRecord) (*wasmModule, error) {
+	id := record.Manifest.ID
+	entry := filepath.Join(record.Path, filepath.FromSlash(strings.TrimSpace(record.Manifest.Entry)))
+	info, err := os.Stat(entry)
+	if err != nil {
+		return nil, r.fail(id, "WASM_LOAD_FAILED", fmt.Errorf("wasm entry not accessible: %w", err))
+	}
+	key := fmt.Sprintf("%s|%d|%d|%+v", entry, info.Size(), info.ModTime().UnixNano(), record.Manifest.WASM)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.modules[id]; ok {
+		if m.key == key {
+			return m, nil
+		}
+		_ = m.runtime.Close(ctx)
+		delete(r.modules, id)
+	}
+
+	code, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, r.fail(id, "WASM_LOAD_FAILED", err)
+	}
+	limits := record.Manifest.WASM
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(limits.MemoryPages()).
+		WithCloseOnContextDone(true))
+	m := &wasmModule{id: id, key: key, limits: limits, runtime: rt, echoes: map[string]time.Time{}}
+	if err := r.instantiateHost(ctx, m); err != nil {
+		_ = rt.Close(ctx)
+		return nil, r.fail(id, "WASM_LOAD_FAILED", err)
+	}
+	compiled, err := rt.CompileModule(ctx, code)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, r.fail(id, "WASM_LOAD_FAILED", fmt.Errorf("compile: %w", err))
+	}
+	m.compiled = compiled
+	r.modules[id] = m
+	return m, nil
+}
+
+// instantiateHost provides WASI with nothing mounted, for modules built by
+// wasip1 toolchains, and the yanta host functions.
+func (r *WASMRuntime) instantiateHost(ctx context.Context, m *wasmModule) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, m.runtime); err != nil {
+		return err
+	}
+	b := m.runtime.NewHostModuleBuilder(wasmHostModule)
+	for method := range hostMethods {
+		b.NewFunctionBuilder().
+			WithFunc(r.hostFunction(m, method)).
+			Export(strings.ReplaceAll(method, ".", "_"))
+	}
+	b.NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, caller api.Module, ptr, size uint32) {
+			if text, ok := caller.Memory().Read(ptr, size); ok {
+				logger.WithField("plugin", m.id).Info(string(text))
+			}
+		}).
+		Export("log")
+	_, err := b.Instantiate(ctx)
+	return err
+}
+
+func (r *WASMRuntime) hostFunction(m *wasmModule, method string) func(context.Context, api.Module, uint32, uint32) uint64 {
+	return func(ctx context.Context, caller api.Module, ptr, size uint32) uint64 {
+		var reply wasmHostReply
+		params, ok := caller.Memory().Read(ptr, size)
+		if !ok {
+			reply.Error = "params out of bounds"
+		} else {
+			params = bytes.Clone(params)
+			result, err := r.host.call(ctx, m.id, method, params)
+			if err != nil {
+				reply.Error = err.Error()
+			} else {
+				reply.Result = result
+				m.noteWrite(method, params, result)
+			}
+		}
+		data, err := json.Marshal(reply)
+		if err != nil {
+			data, _ = json.Marshal(wasmHostReply{Error: err.Error()})
+		}
+		p, err := writeGuest(ctx, caller, data)
+		if err != nil {
+			return 0
+		}
+		return uint64(p)<<32 | uint64(len(data))
+	}
+}
+
+// call runs one message in a fresh instance, within the plugin's time limit.
+func (r *WASMRuntime) call(ctx context.Context, m *wasmModule, msg wasmMessage) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, m.limits.Timeout())
+	defer cancel()
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := m.runtime.InstantiateModule(ctx, m.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, r.fail(m.id, "WASM_CALL_FAILED", fmt.Errorf("instantiate: %w", err))
+	}
+	defer inst.Close(context.Background())
+
+	handle := inst.ExportedFunction("handle")
+	if handle == nil || inst.Memory() == nil {
+		return nil, r.fail(m.id, "WASM_CALL_FAILED", errors.New(`module must export "memory", "alloc" and "handle"`))
+	}
+	p, err := writeGuest(ctx, inst, data)
+	if err != nil {
+		return nil, r.fail(m.id, "WASM_CALL_FAILED", err)
+	}
+	res, err := handle.Call(ctx, uint64(p), uint64(len(data)))
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", m.limits.Timeout())
+		}
+		return nil, r.fail(m.id, "WASM_CALL_FAILED", err)
+	}
+	if len(res) == 0 || res[0] == 0 {
+		return nil, nil
+	}
+	out, ok := inst.Memory().Read(uint32(res[0]>>32), uint32(res[0]))
+	if !ok {
+		return nil, r.fail(m.id, "WASM_CALL_FAILED", errors.New("reply out of bounds"))
+	}
+	return bytes.Clone(out), nil
+}
+
+// fail logs a plugin runtime error and reports it on the install record.
+func (r *WASMRuntime) fail(pluginID, code string, err error) error {
+	logger.WithError(err).WithField("plugin", pluginID).Warn("wasm plugin failed")
+	r.svc.recordIssue(pluginID, ValidationIssue{Code: code, Message: err.Error(), Field: "entry"})
+	return fmt.Errorf("wasm plugin %q: %w", pluginID, err)
+}
+
+// writeGuest copies data into memory the module allocates for it.
+func writeGuest(ctx context.Context, mod api.Module, data []byte) (uint32, error) {
+	alloc := mod.ExportedFunction("alloc")
+	if alloc == nil {
+		return 0, errors.New(`module does not export "alloc"`)
+	}
+	res, err := alloc.Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("alloc: %w", err)
+	}
+	p := uint32(res[0])
+	if !mod.Memory().Write(p, data) {
+		return 0, errors.New("alloc returned memory out of bounds")
+	}
+	return p, nil
+}
+
+func echoKey(path, projectAlias string) string {
+	if path != "" {
+		return path
+	}
+	return "journal:" + projectAlias
+}
+
+// noteWrite remembers what a successful host call wrote. m.mu is held: host
+// functions only run inside call.
+func (m *wasmModule) noteWrite(method string, params []byte, result any) {
+	switch method {
+	case "documents.create", "documents.update":
+		if doc, ok := result.(HostDocument); ok {
+			m.echoes[echoKey(doc.Path, "")] = time.Now()
+		}
+	case "journal.append":
+		var p struct {
+			ProjectAlias string `json:"projectAlias"`
+		}
+		if json.Unmarshal(params, &p) == nil {
+			m.echoes[echoKey("", p.ProjectAlias)] = time.Now()
+		}
+	}
+}
+
+// isEcho reports whether an event comes from the module's own recent write.
+func (m *wasmModule) isEcho(msg wasmMessage) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, at := range m.echoes {
+		if time.Since(at) > wasmEchoWindow {
+			delete(m.echoes, k)
+		}
+	}
+	_, ok := m.echoes[echoKey(msg.Path, msg.ProjectID)]
+	return ok
+}
@@ -0,0 +1,184 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"yanta/internal/config"
+	"yanta/internal/events"
+	"yanta/internal/testenv"
+)
+
+// wasmTestModule assembles a module that imports yanta.documents_read and
+// exports memory, a bump allocator and handle with the given body.
+func wasmTestModule(handleBody ...byte) []byte {
+	section := func(id byte, content ...byte) []byte {
+		return append([]byte{id, byte(len(content))}, content...)
+	}
+	name := func(s string) []byte { return append([]byte{byte(len(s))}, s...) }
+
+	mod := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = (i32, i32) -> i64, 1 = (i32) -> i32.
+	mod = append(mod, section(1, 0x02,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e,
+		0x60, 0x01, 0x7f, 0x01, 0x7f)...)
+	imp := []byte{0x01}
+	imp = append(imp, name("yanta")...)
+	imp = append(imp, name("documents_read")...)
+	imp = append(imp, 0x00, 0x00)
+	mod = append(mod, section(2, imp...)...)
+	mod = append(mod, section(3, 0x02, 0x01, 0x00)...)
+	mod = append(mod, section(5, 0x01, 0x00, 0x01)...)
+	// A mutable i32 heap pointer starting at 1024.
+	mod = append(mod, section(6, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b)...)
+	exp := []byte{0x03}
+	exp = append(exp, name("memory")...)
+	exp = append(exp, 0x02, 0x00)
+	exp = append(exp, name("alloc")...)
+	exp = append(exp, 0x00, 0x01)
+	exp = append(exp, name("handle")...)
+	exp = append(exp, 0x00, 0x02)
+	mod = append(mod, section(7, exp...)...)
+	// alloc returns the heap pointer and bumps it by size.
+	alloc := []byte{0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}
+	handle := append([]byte{0x00}, handleBody...)
+	code := []byte{0x02, byte(len(alloc))}
+	code = append(code, alloc...)
+	code = append(code, byte(len(handle)))
+	code = append(code, handle...)
+	return append(mod, section(10, code...)...)
+}
+
+// forwardToDocumentsRead passes the message to documents_read and returns
+// the host's reply.
+var forwardToDocumentsRead = []byte{0x20, 0x00, 0x20, 0x01, 0x10, 0x00, 0x0b}
+
+// spinForever never returns.
+var spinForever = []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b}
+
+func setupWASMPlugin(t *testing.T, capabilities, extraManifest string, module []byte) (*Service, *WASMRuntime, *fakeHostVault) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cleanup := testenv.SetTestHome(t, tempDir)
+	t.Cleanup(cleanup)
+
+	config.ResetForTesting()
+	require.NoError(t, config.Init())
+
+	pluginDir := filepath.Join(tempDir, ".yanta", "plugins", "wasm.plugin")
+	require.NoError(t, os.MkdirAll(pluginDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.toml"), []byte(`id = "wasm.plugin"
+name = "WASM Plugin"
+version = "1.0.0"
+api_version = "1"
+entry = "plugin.wasm"
+capabilities = `+capabilities+`
+`+extraManifest), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.wasm"), module, 0o644))
+
+	svc := NewService()
+	require.NoError(t, svc.SetCommunityPluginsEnabled(true))
+	require.NoError(t, svc.SetPluginEnabled("wasm.plugin", true))
+
+	vault := &fakeHostVault{docs: map[string]HostDocument{
+		"projects/@work/doc-1.json": {Path: "projects/@work/doc-1.json", Title: "Plan", Markdown: "# Plan\n", Hash: "h0"},
+	}}
+	rt := NewWASMRuntime(svc, NewHost(svc, vault))
+	t.Cleanup(func() { rt.Close(context.Background()) })
+	return svc, rt, vault
+}
+
+func TestWASMRuntime_InvokeCallsHostFunctions(t *testing.T) {
+	svc, rt, _ := setupWASMPlugin(t, `["documentsRead"]`, "", wasmTestModule(forwardToDocumentsRead...))
+
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Equal(t, PluginRuntimeWASM, list[0].Runtime)
+	require.True(t, list[0].CanExecute)
+	_, err = svc.ReadPluginEntrypoint("wasm.plugin")
+	require.ErrorContains(t, err, "backend")
+
+	// The action message has no path, so the host rejects the params and
+	// the module hands that reply back.
+	reply, err := rt.Invoke(context.Background(), "wasm.plugin", "import", json.RawMessage(`{"file":"a.csv"}`))
+	require.NoError(t, err)
+	var out wasmHostReply
+	require.NoError(t, json.Unmarshal(reply, &out))
+	require.Contains(t, out.Error, "path is required")
+}
+
+func TestWASMRuntime_EventsReachPlugins(t *testing.T) {
+	_, rt, vault := setupWASMPlugin(t, `["documentsRead"]`, "", wasmTestModule(forwardToDocumentsRead...))
+	var read []string
+	vault.onGet = func(path string) { read = append(read, path) }
+
+	rt.Listen(events.EntryUpdated, events.EntryUpdatedData{Path: "projects/@work/doc-1.json", ProjectID: "p1"})
+	rt.Listen(events.EntryDeleted, events.EntryDeletedData{Path: "projects/@work/doc-1.json"})
+	require.Len(t, rt.events, 1)
+	rt.dispatch(context.Background(), <-rt.events)
+	require.Equal(t, []string{"projects/@work/doc-1.json"}, read)
+}
+
+func TestWASMRuntime_HostFunctionsEnforceCapabilities(t *testing.T) {
+	svc, rt, _ := setupWASMPlugin(t, `["search"]`, "", wasmTestModule(forwardToDocumentsRead...))
+
+	reply, err := rt.Invoke(context.Background(), "wasm.plugin", "read", nil)
+	require.NoError(t, err)
+	var out wasmHostReply
+	require.NoError(t, json.Unmarshal(reply, &out))
+	require.Contains(t, out.Error, PluginErrCapabilityDenied)
+
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Equal(t, "CAPABILITY_DENIED", list[0].Issues[0].Code)
+}
+
+func TestWASMRuntime_TimeLimit(t *testing.T) {
+	svc, rt, _ := setupWASMPlugin(t, `[]`, "\n[wasm]\ntimeout_ms = 50\n", wasmTestModule(spinForever...))
+
+	start := time.Now()
+	_, err := rt.Invoke(context.Background(), "wasm.plugin", "spin", nil)
+	require.ErrorContains(t, err, "timed out")
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	list, err := svc.ListInstalled()
+	require.NoError(t, err)
+	require.Equal(t, "WASM_CALL_FAILED", list[0].Issues[0].Code)
+}
+
+func TestWASMRuntime_SkipsOwnWrites(t *testing.T) {
+	m := &wasmModule{echoes: map[string]time.Time{}}
+	m.noteWrite("documents.update", nil, HostDocument{Path: "projects/@work/doc-1.json"})
+	m.noteWrite("journal.append", []byte(`{"projectAlias":"@work"}`), HostJournalEntry{})
+
+	require.True(t, m.isEcho(wasmMessage{Path: "projects/@work/doc-1.json"}))
+	require.True(t, m.isEcho(wasmMessage{ProjectID: "@work", Date: "2026-07-03"}))
+	require.False(t, m.isEcho(wasmMessage{Path: "projects/@work/doc-2.json"}))
+
+	m.echoes["projects/@work/doc-1.json"] = time.Now().Add(-2 * wasmEchoWindow)
+	require.False(t, m.isEcho(wasmMessage{Path: "projects/@work/doc-1.json"}))
+}
+
+func TestService_ValidatesWASMManifest(t *testing.T) {
+	m := Manifest{ID: "x", Name: "x", Version: "1", APIVersion: "1", Entry: "../escape.wasm"}
+	status, issues := validateManifest(m, nil)
+	require.Equal(t, PluginStatusInvalidManifest, status)
+	require.Equal(t, "INVALID_ENTRYPOINT", issues[0].Code)
+
+	m.Entry = "bin/plugin.wasm"
+	m.WASM = WASMLimits{MemoryLimitMB: 4096}
+	status, issues = validateManifest(m, nil)
+	require.Equal(t, PluginStatusInvalidManifest, status)
+	require.Equal(t, "wasm.memory_limit_mb", issues[0].Field)
+
+	m.WASM = WASMLimits{MemoryLimitMB: 32, TimeoutMS: 500}
+	status, _ = validateManifest(m, nil)
+	require.Equal(t, PluginStatusOK, status)
+	require.Equal(t, uint32(512), m.WASM.MemoryPages())
+}
@@ -173,6 +173,108 @@ func (s *Service) Update(ctx context.Context, p *Project) error {
 	return nil
 }
 
+// ErrProjectHasContent is returned when renaming the alias of a project that
+// already has documents, journals or assets.
+var ErrProjectHasContent = errors.New("project has content")
+
+// RenameAlias changes a project's alias. Document paths, links between
+// documents and asset references all contain the alias, so only a project
+// without any content can be renamed; rename the display name with Update
+// instead.
+func (s *Service) RenameAlias(ctx context.Context, id, newAlias string) (*Project, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, errors.New("id is required")
+	}
+
+	newAlias = NormalizeAlias(newAlias)
+	if err := ValidateAlias(newAlias); err != nil {
+		return nil, fmt.Errorf("invalid alias: %w", err)
+	}
+
+	p, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting project: %w", err)
+	}
+	oldAlias := p.Alias
+	if oldAlias == newAlias {
+		return p, nil
+	}
+
+	if taken, err := s.store.Get(ctx, &GetFilters{Alias: &newAlias, IncludeDeleted: true}); err != nil {
+		return nil, fmt.Errorf("checking alias: %w", err)
+	} else if len(taken) > 0 {
+		return nil, fmt.Errorf("alias %s is already in use", newAlias)
+	}
+
+	hasContent, err := s.vault.ProjectHasContent(oldAlias)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.GetDocumentCount(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hasContent || count > 0 {
+		return nil, fmt.Errorf("%w: %s cannot be renamed because its documents, links and assets refer to it by alias", ErrProjectHasContent, oldAlias)
+	}
+
+	exists, err := s.vault.ProjectExists(oldAlias)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := s.vault.RenameProject(oldAlias, newAlias); err != nil {
+			return nil, err
+		}
+	}
+
+	p.Alias = newAlias
+	updated, err := s.store.Update(ctx, p)
+	if err != nil {
+		if exists {
+			if rerr := s.vault.RenameProject(newAlias, oldAlias); rerr != nil {
+				logger.WithError(rerr).WithField("alias", newAlias).Error("failed to roll back project directory rename")
+			}
+		}
+		return nil, fmt.Errorf("updating project: %w", err)
+	}
+
+	metadata := &vault.ProjectMetadata{
+		Alias:     updated.Alias,
+		Name:      updated.Name,
+		StartDate: updated.StartDate,
+		EndDate:   updated.EndDate,
+		CreatedAt: updated.CreatedAt,
+		UpdatedAt: updated.UpdatedAt,
+	}
+	if err := s.vault.WriteProjectMetadata(metadata); err != nil {
+		logger.WithField("alias", updated.Alias).
+			WithError(err).
+			Warn("failed to update project metadata file")
+	}
+	s.notifySync(fmt.Sprintf("project %s renamed to %s", oldAlias, updated.Alias))
+
+	s.cache.Invalidate(updated.ID)
+
+	s.emitEvent(events.ProjectUpdated, map[string]any{
+		"id":    updated.ID,
+		"name":  updated.Name,
+		"alias": updated.Alias,
+	})
+	s.emitEvent(events.ProjectChanged, events.ProjectChangedData{
+		ID: updated.ID,
+		Op: "update",
+	})
+
+	logger.WithFields(map[string]any{
+		"id":   updated.ID,
+		"from": oldAlias,
+		"to":   updated.Alias,
+	}).Info("project alias renamed")
+
+	return updated, nil
+}
+
 func (s *Service) Get(ctx context.Context, id string) (*Project, error) {
 	if strings.TrimSpace(id) == "" {
 		return nil, errors.New("id is required")
@@ -3,6 +3,7 @@ package project
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -118,6 +119,54 @@ func TestService_Update_NotifiesSync(t *testing.T) {
 	assert.Contains(t, notifier.reasons[len(notifier.reasons)-1], "updated")
 }
 
+func TestService_RenameAlias(t *testing.T) {
+	notifier := &mockSyncNotifier{}
+	service, cleanup := setupServiceTest(t, notifier)
+	defer cleanup()
+	ctx := context.Background()
+
+	id, err := service.Create(ctx, "Typo", "tpyo", "", "")
+	require.NoError(t, err)
+
+	renamed, err := service.RenameAlias(ctx, id, "typo")
+	require.NoError(t, err)
+	assert.Equal(t, "@typo", renamed.Alias)
+	assert.True(t, service.vault.ProjectMetadataExists("@typo"))
+	assert.False(t, service.vault.ProjectMetadataExists("@tpyo"))
+	assert.Contains(t, notifier.reasons, "project @tpyo renamed to @typo")
+
+	cached, err := service.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "@typo", cached.Alias)
+
+	_, err = service.Create(ctx, "Other", "other", "", "")
+	require.NoError(t, err)
+	_, err = service.RenameAlias(ctx, id, "@other")
+	assert.Error(t, err, "Expected error for an alias in use")
+
+	_, err = service.RenameAlias(ctx, id, "x")
+	assert.Error(t, err, "Expected error for an invalid alias")
+}
+
+func TestService_RenameAlias_WithContent(t *testing.T) {
+	service, cleanup := setupServiceTest(t, nil)
+	defer cleanup()
+	ctx := context.Background()
+
+	id, err := service.Create(ctx, "Busy", "busy", "", "")
+	require.NoError(t, err)
+	journalDir := filepath.Join(service.vault.ProjectPath("@busy"), "journal")
+	require.NoError(t, os.MkdirAll(journalDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(journalDir, "2026-07-03.json"), []byte("{}"), 0644))
+
+	_, err = service.RenameAlias(ctx, id, "idle")
+	assert.ErrorIs(t, err, ErrProjectHasContent)
+
+	p, err := service.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "@busy", p.Alias)
+}
+
 func TestService_SoftDelete(t *testing.T) {
 	service, cleanup := setupServiceTest(t, nil)
 	defer cleanup()
@@ -148,6 +148,88 @@ func (s *Service) Delete(ctx context.Context, name string) error {
 	return nil
 }
 
+// Rename renames tag from to to on every document that carries it, and
+// removes from. If to already exists the two tags are merged. It returns the
+// number of documents changed. Journal entry tags are not touched.
+func (s *Service) Rename(ctx context.Context, from, to string) (int, error) {
+	from = Normalize(from)
+	if from == "" {
+		return 0, fmt.Errorf("tag name is required")
+	}
+	target, err := New(to)
+	if err != nil {
+		return 0, fmt.Errorf("validating tag: %w", err)
+	}
+	if target.Name == from {
+		return 0, fmt.Errorf("tag is already named %s", from)
+	}
+
+	if _, err := s.store.GetByName(ctx, from); err != nil {
+		return 0, fmt.Errorf("getting tag: %w", err)
+	}
+	if _, err := s.store.GetByName(ctx, target.Name); err != nil {
+		if _, err := s.store.Create(ctx, target); err != nil {
+			return 0, fmt.Errorf("creating tag: %w", err)
+		}
+		s.emitEvent(events.TagCreated, target)
+	}
+
+	paths, err := s.store.GetDocumentPaths(ctx, from)
+	if err != nil {
+		return 0, fmt.Errorf("listing tagged documents: %w", err)
+	}
+
+	for _, docPath := range paths {
+		err := s.fm.UpdateFile(docPath, func(doc *document.DocumentFile) error {
+			renamed := make([]string, 0, len(doc.Meta.Tags))
+			seen := make(map[string]bool)
+			for _, tag := range doc.Meta.Tags {
+				if Normalize(tag) == from {
+					tag = target.Name
+				}
+				if !seen[Normalize(tag)] {
+					seen[Normalize(tag)] = true
+					renamed = append(renamed, tag)
+				}
+			}
+			doc.Meta.Tags = renamed
+			return nil
+		})
+		if err != nil {
+			logger.WithError(err).WithField("docPath", docPath).Error("failed to update document file")
+			return 0, fmt.Errorf("updating document file %s: %w", docPath, err)
+		}
+
+		if err := s.store.RemoveTagsFromDocument(ctx, docPath, []string{from}); err != nil {
+			return 0, fmt.Errorf("updating tag index: %w", err)
+		}
+		if err := s.store.AddTagsToDocument(ctx, docPath, []string{target.Name}); err != nil {
+			return 0, fmt.Errorf("updating tag index: %w", err)
+		}
+
+		s.emitEvent(events.DocumentTagsUpdated, map[string]any{
+			"path": docPath,
+			"tags": []string{target.Name},
+		})
+	}
+
+	if err := s.store.HardDelete(ctx, from); err != nil {
+		return 0, fmt.Errorf("deleting tag: %w", err)
+	}
+	s.emitEvent(events.TagDeleted, from)
+
+	logger.WithFields(logrus.Fields{
+		"from":      from,
+		"to":        target.Name,
+		"documents": len(paths),
+	}).Info("tag renamed")
+
+	if len(paths) > 0 {
+		s.notifySync(fmt.Sprintf("tag %s renamed to %s", from, target.Name))
+	}
+	return len(paths), nil
+}
+
 func (s *Service) AddTagsToDocument(ctx context.Context, docPath string, tagNames []string) error {
 	if docPath == "" {
 		return fmt.Errorf("document path is required")
@@ -279,6 +361,17 @@ func (s *Service) RemoveAllDocumentTags(ctx context.Context, docPath string) err
 	return nil
 }
 
+// GetDocumentPaths returns the paths of the documents carrying the tag.
+func (s *Service) GetDocumentPaths(ctx context.Context, name string) ([]string, error) {
+	normalized := Normalize(name)
+	paths, err := s.store.GetDocumentPaths(ctx, normalized)
+	if err != nil {
+		logger.WithError(err).WithField("name", normalized).Error("failed to get tagged documents")
+		return nil, fmt.Errorf("getting tagged documents: %w", err)
+	}
+	return paths, nil
+}
+
 func (s *Service) GetDocumentTags(ctx context.Context, docPath string) ([]string, error) {
 	if docPath == "" {
 		return nil, fmt.Errorf("document path is required")
@@ -375,6 +375,70 @@ func TestService_AddTagsToDocument_UpdatesJSONFile(t *testing.T) {
 	}
 }
 
+type mockSyncNotifier struct {
+	reasons []string
+}
+
+func (m *mockSyncNotifier) NotifyChange(reason string) {
+	m.reasons = append(m.reasons, reason)
+}
+
+func TestService_Rename_MergesIntoExistingTag(t *testing.T) {
+	database := testutil.SetupTestDB(t)
+	defer testutil.CleanupTestDB(t, database)
+	ctx := context.Background()
+
+	v, err := vault.New(vault.Config{RootPath: t.TempDir()})
+	require.NoError(t, err, "Failed to create vault")
+
+	projectStore := project.NewStore(database)
+	p, err := project.New("Test", "@test", "", "")
+	require.NoError(t, err, "Failed to create project")
+	p, err = projectStore.Create(ctx, p)
+	require.NoError(t, err, "Failed to save test project")
+
+	docStore := document.NewStore(database)
+	fm := document.NewFileManager(v)
+	idx := &mockIndexer{store: docStore, fm: fm}
+	projectCache := &mockProjectCache{projects: map[string]*project.Project{p.Alias: p}}
+	docService := document.NewService(database, docStore, v, idx, projectCache, events.NewEventBus())
+
+	tagStore := NewStore(database)
+	tagService := NewService(database, tagStore, fm, events.NewEventBus())
+	notifier := &mockSyncNotifier{}
+	tagService.SetSyncNotifier(notifier)
+
+	docPath, err := docService.Save(ctx, document.SaveRequest{
+		ProjectAlias: "@test",
+		Title:        "Test Document",
+		Blocks:       []document.BlockNoteBlock{},
+		Tags:         []string{},
+	})
+	require.NoError(t, err, "Failed to create test document")
+	require.NoError(t, tagService.AddTagsToDocument(ctx, docPath, []string{"js", "javascript", "web"}))
+
+	n, err := tagService.Rename(ctx, "js", "JavaScript")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	file, err := fm.ReadFile(docPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"javascript", "web"}, file.Meta.Tags)
+
+	tags, err := tagService.GetDocumentTags(ctx, docPath)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"javascript", "web"}, tags)
+
+	_, err = tagService.GetByName(ctx, "js")
+	assert.Error(t, err, "Expected the old tag to be gone")
+	assert.Equal(t, []string{"tag js renamed to javascript"}, notifier.reasons[len(notifier.reasons)-1:])
+
+	_, err = tagService.Rename(ctx, "web", "Web")
+	assert.Error(t, err, "Expected error renaming a tag to itself")
+	_, err = tagService.Rename(ctx, "missing", "other")
+	assert.Error(t, err, "Expected error for an unknown tag")
+}
+
 func TestService_RemoveTagsFromDocument_UpdatesJSONFile(t *testing.T) {
 	// Setup database and tag service
 	database := testutil.SetupTestDB(t)
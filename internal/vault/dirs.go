@@ -47,6 +47,36 @@ func (v *Vault) ProjectExists(projectAlias string) (bool, error) {
 	return true, nil
 }
 
+// ProjectHasContent reports whether a project's directory holds any file
+// besides its metadata: documents, journals or assets.
+func (v *Vault) ProjectHasContent(projectAlias string) (bool, error) {
+	if err := validateProjectAlias(projectAlias); err != nil {
+		return false, err
+	}
+
+	projectDir := v.ProjectPath(projectAlias)
+
+	found := false
+	err := filepath.WalkDir(projectDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (filepath.Dir(path) == projectDir && d.Name() == ProjectMetadataFileName) {
+			return nil
+		}
+		found = true
+		return filepath.SkipAll
+	})
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("scanning project directory: %w", err)
+	}
+
+	return found, nil
+}
+
 func (v *Vault) ListProjects() ([]string, error) {
 	projectsDir := filepath.Join(v.rootPath, "projects")
 
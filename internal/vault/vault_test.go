@@ -175,6 +175,39 @@ func TestVault_RenameProject(t *testing.T) {
 	}
 }
 
+func TestVault_ProjectHasContent(t *testing.T) {
+	v, err := New(Config{RootPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+
+	alias := "@empty"
+	if has, err := v.ProjectHasContent(alias); err != nil || has {
+		t.Errorf("missing project: ProjectHasContent() = %v, %v; want false", has, err)
+	}
+
+	if err := v.EnsureProjectDir(alias); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	if err := v.WriteProjectMetadata(&ProjectMetadata{Alias: alias, Name: "Empty"}); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+	if has, err := v.ProjectHasContent(alias); err != nil || has {
+		t.Errorf("metadata only: ProjectHasContent() = %v, %v; want false", has, err)
+	}
+
+	journalDir := filepath.Join(v.ProjectPath(alias), "journal")
+	if err := os.MkdirAll(journalDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(journalDir, "2026-07-03.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := v.ProjectHasContent(alias); err != nil || !has {
+		t.Errorf("with a journal: ProjectHasContent() = %v, %v; want true", has, err)
+	}
+}
+
 func TestValidateProjectAlias(t *testing.T) {
 	tests := []struct {
 		name    string
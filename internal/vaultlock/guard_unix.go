@@ -0,0 +1,19 @@
+//go:build !windows
+
+package vaultlock
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until it holds an exclusive advisory lock on f. flock locks
+// belong to the open file, so two opens of the guard exclude each other even
+// within one process.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package vaultlock
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile blocks until it holds an exclusive lock on the first byte of f.
+// LockFileEx locks belong to the handle, so two opens of the guard exclude
+// each other even within one process.
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, new(windows.Overlapped))
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
@@ -0,0 +1,232 @@
+// Package vaultlock coordinates the processes that open a vault's database:
+// the Yanta app and `yanta mcp --standalone` servers.
+//
+// All of them read and write the database in shared WAL mode, but only one,
+// the primary, runs migrations, the file watcher and git auto-sync. The
+// primary is whoever holds the lock file. The app always becomes primary: if
+// a standalone server holds the lock, the app takes it over and waits for the
+// standalone server to stop its sync and watcher and acknowledge, so the two
+// never commit to the vault's repository at the same time.
+//
+// Every change to the lock file happens under an OS file lock on a guard file
+// next to it, so processes that claim at the same moment are serialized and
+// exactly one of them becomes primary.
+package vaultlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"yanta/internal/config"
+)
+
+// Owner is the kind of process holding the lock.
+type Owner string
+
+const (
+	OwnerApp        Owner = "app"
+	OwnerStandalone Owner = "mcp-standalone"
+)
+
+// handoverTimeout bounds how long the app waits for a standalone server to
+// acknowledge a takeover before it proceeds anyway.
+const handoverTimeout = 10 * time.Second
+
+// pollInterval is how often a standalone primary checks whether it still
+// holds the lock, and the app whether the handover was acknowledged.
+var pollInterval = time.Second
+
+// afterRead, if set, runs in Claim between reading the holder and replacing
+// it. Tests use it to widen the window racing claims could overlap in.
+var afterRead func()
+
+// ErrHeld is returned by Claim when another live process is primary.
+var ErrHeld = errors.New("vault is locked by another process")
+
+// Holder describes the process holding the lock.
+type Holder struct {
+	Owner Owner  `json:"owner"`
+	PID   int    `json:"pid"`
+	Since string `json:"since"`
+}
+
+// HeldError reports who holds the lock.
+type HeldError struct {
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("%s (%s, pid %d)", ErrHeld, e.Holder.Owner, e.Holder.PID)
+}
+
+func (e *HeldError) Is(target error) bool { return target == ErrHeld }
+
+// Lock is a held vault lock.
+type Lock struct {
+	holder Holder
+}
+
+func lockPath() string     { return filepath.Join(config.GetAppRootDirectory(), "vault.lock") }
+func handoverPath() string { return lockPath() + ".handover" }
+
+// guardPath is never removed: deleting a file others may hold a lock on would
+// let a later process lock a new file while they still hold the old one.
+func guardPath() string { return lockPath() + ".guard" }
+
+// withGuard runs fn while holding the OS lock on the guard file, so reading
+// the lock file and replacing or removing it is atomic across processes.
+func withGuard(fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(lockPath()), 0o755); err != nil {
+		return fmt.Errorf("locking vault lock: %w", err)
+	}
+	f, err := os.OpenFile(guardPath(), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("locking vault lock: %w", err)
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("locking vault lock: %w", err)
+	}
+	defer func() { _ = unlockFile(f) }()
+	return fn()
+}
+
+// Read returns the current holder, if the lock file exists and names a live
+// process.
+func Read() (Holder, bool) {
+	data, err := os.ReadFile(lockPath())
+	if err != nil {
+		return Holder{}, false
+	}
+	var h Holder
+	if err := json.Unmarshal(data, &h); err != nil || !ProcessAlive(h.PID) {
+		return Holder{}, false
+	}
+	return h, true
+}
+
+// Claim makes the calling process primary.
+//
+// A free or stale lock is taken by anyone. A live standalone server's lock is
+// taken over by the app, which then waits (bounded) for the standalone server
+// to acknowledge. Any other live holder makes Claim fail with a *HeldError:
+// a standalone server then runs as a secondary, and a second app instance is
+// about to exit through the single-instance guard anyway.
+//
+// The holder is read and replaced under the guard, so of several processes
+// claiming a free or stale lock at once exactly one wins. The wait for a
+// handover happens after the guard is released.
+func Claim(ctx context.Context, owner Owner) (*Lock, error) {
+	me := Holder{Owner: owner, PID: os.Getpid(), Since: time.Now().UTC().Format(time.RFC3339)}
+	var takenFrom int
+	err := withGuard(func() error {
+		current, held := Read()
+		if afterRead != nil {
+			afterRead()
+		}
+		switch {
+		case !held || current.PID == me.PID:
+		case owner == OwnerApp && current.Owner == OwnerStandalone:
+			takenFrom = current.PID
+		default:
+			return &HeldError{Holder: current}
+		}
+		return write(me)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if takenFrom != 0 {
+		awaitHandover(ctx, takenFrom)
+	}
+	return &Lock{holder: me}, nil
+}
+
+// write replaces the lock file atomically. Callers hold the guard.
+func write(h Holder) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	tmp := lockPath() + "." + strconv.Itoa(h.PID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing vault lock: %w", err)
+	}
+	if err := os.Rename(tmp, lockPath()); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("writing vault lock: %w", err)
+	}
+	return nil
+}
+
+// awaitHandover waits until the standalone server with the given PID has
+// acknowledged the takeover or exited.
+func awaitHandover(ctx context.Context, pid int) {
+	defer os.Remove(handoverPath())
+	ctx, cancel := context.WithTimeout(ctx, handoverTimeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval / 10)
+	defer ticker.Stop()
+	for {
+		if data, err := os.ReadFile(handoverPath()); err == nil && strings.TrimSpace(string(data)) == strconv.Itoa(pid) {
+			return
+		}
+		if !ProcessAlive(pid) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Held reports whether the lock file still names this process.
+func (l *Lock) Held() bool {
+	h, ok := Read()
+	return ok && h.PID == l.holder.PID && h.Owner == l.holder.Owner
+}
+
+// Watch calls onLost once another process has taken the lock over, then
+// acknowledges the handover. onLost must stop everything only the primary
+// may do. Watch returns when ctx is done or after the handover.
+func (l *Lock) Watch(ctx context.Context, onLost func()) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if l.Held() {
+			continue
+		}
+		onLost()
+		_ = os.WriteFile(handoverPath(), []byte(strconv.Itoa(l.holder.PID)), 0o600)
+		return
+	}
+}
+
+// Release removes the lock file if it still names this process. The check
+// and the removal happen under the guard, so a lock another process has just
+// claimed is never removed.
+func (l *Lock) Release() {
+	if l == nil {
+		return
+	}
+	_ = withGuard(func() error {
+		if l.Held() {
+			return os.Remove(lockPath())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,234 @@
+package vaultlock
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"yanta/internal/config"
+)
+
+// setup isolates the lock in a temp YANTA_HOME and speeds up polling.
+func setup(t *testing.T) {
+	t.Helper()
+	t.Setenv("YANTA_HOME", t.TempDir())
+	config.ResetForTesting()
+	prev := pollInterval
+	pollInterval = 20 * time.Millisecond
+	t.Cleanup(func() { pollInterval = prev })
+}
+
+// otherLiveHolder writes a lock held by another live process: the test's
+// parent, which outlives the test.
+func otherLiveHolder(t *testing.T, owner Owner) Holder {
+	t.Helper()
+	h := Holder{Owner: owner, PID: os.Getppid()}
+	if err := write(h); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestClaimFreeLock(t *testing.T) {
+	setup(t)
+
+	lock, err := Claim(context.Background(), OwnerStandalone)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if !lock.Held() {
+		t.Fatal("lock not held after Claim")
+	}
+	if h, ok := Read(); !ok || h.PID != os.Getpid() || h.Owner != OwnerStandalone {
+		t.Fatalf("Read() = %+v, %v", h, ok)
+	}
+
+	lock.Release()
+	if _, ok := Read(); ok {
+		t.Fatal("lock still present after Release")
+	}
+}
+
+func TestClaimStaleLock(t *testing.T) {
+	setup(t)
+	// PIDs are far below this on every supported platform.
+	if err := write(Holder{Owner: OwnerApp, PID: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Claim(context.Background(), OwnerStandalone); err != nil {
+		t.Fatalf("Claim over a stale lock: %v", err)
+	}
+}
+
+// claimHelperEnv makes TestClaimHelperProcess act as a claimant: it waits for
+// a line on stdin, claims as a standalone server, prints "primary" or "held",
+// and keeps its lock until stdin closes.
+const claimHelperEnv = "YANTA_VAULTLOCK_CLAIM_HELPER"
+
+func TestClaimHelperProcess(t *testing.T) {
+	if os.Getenv(claimHelperEnv) != "1" {
+		t.Skip("helper process")
+	}
+	config.ResetForTesting()
+	afterRead = func() { time.Sleep(100 * time.Millisecond) }
+	in := bufio.NewReader(os.Stdin)
+	_, _ = in.ReadString('\n')
+	lock, err := Claim(context.Background(), OwnerStandalone)
+	switch {
+	case errors.Is(err, ErrHeld):
+		fmt.Println("held")
+	case err != nil:
+		fmt.Println("error:", err)
+	default:
+		fmt.Println("primary")
+	}
+	_, _ = io.Copy(io.Discard, in)
+	lock.Release()
+}
+
+func TestConcurrentClaimsElectOnePrimary(t *testing.T) {
+	setup(t)
+	const claimants = 8
+
+	type claimant struct {
+		cmd   *exec.Cmd
+		stdin io.WriteCloser
+		out   *bufio.Reader
+	}
+	procs := make([]claimant, 0, claimants)
+	for range claimants {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestClaimHelperProcess$")
+		cmd.Env = append(os.Environ(), claimHelperEnv+"=1")
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		procs = append(procs, claimant{cmd: cmd, stdin: stdin, out: bufio.NewReader(stdout)})
+	}
+	t.Cleanup(func() {
+		for _, p := range procs {
+			_ = p.stdin.Close()
+			_ = p.cmd.Wait()
+		}
+	})
+
+	// Release every claimant at once, then collect their outcomes while all
+	// of them still run, so a lost primary cannot look stale.
+	for _, p := range procs {
+		if _, err := io.WriteString(p.stdin, "go\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	primaries := 0
+	for i, p := range procs {
+		line, err := p.out.ReadString('\n')
+		if err != nil {
+			t.Fatalf("claimant %d: %v", i, err)
+		}
+		switch strings.TrimSpace(line) {
+		case "primary":
+			primaries++
+		case "held":
+		default:
+			t.Fatalf("claimant %d: %q", i, line)
+		}
+	}
+	if primaries != 1 {
+		t.Fatalf("%d claimants became primary, want 1", primaries)
+	}
+}
+
+func TestClaimHeldByApp(t *testing.T) {
+	setup(t)
+	holder := otherLiveHolder(t, OwnerApp)
+
+	_, err := Claim(context.Background(), OwnerStandalone)
+	if !errors.Is(err, ErrHeld) {
+		t.Fatalf("Claim error = %v, want ErrHeld", err)
+	}
+	var held *HeldError
+	if !errors.As(err, &held) || held.Holder.PID != holder.PID || held.Holder.Owner != OwnerApp {
+		t.Fatalf("Claim error = %#v, want HeldError for %+v", err, holder)
+	}
+}
+
+func TestAppTakesOverStandalone(t *testing.T) {
+	setup(t)
+	holder := otherLiveHolder(t, OwnerStandalone)
+
+	// Play the standalone server: acknowledge once the lock names the app.
+	go func() {
+		for {
+			if h, ok := Read(); ok && h.Owner == OwnerApp {
+				_ = os.WriteFile(handoverPath(), []byte(strconv.Itoa(holder.PID)), 0o600)
+				return
+			}
+			time.Sleep(pollInterval)
+		}
+	}()
+
+	start := time.Now()
+	lock, err := Claim(context.Background(), OwnerApp)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if time.Since(start) >= handoverTimeout {
+		t.Fatal("Claim waited for the timeout instead of the acknowledgement")
+	}
+	if !lock.Held() {
+		t.Fatal("app does not hold the lock after takeover")
+	}
+	if _, err := os.Stat(handoverPath()); !os.IsNotExist(err) {
+		t.Fatalf("handover file left behind: %v", err)
+	}
+}
+
+func TestWatchAcknowledgesTakeover(t *testing.T) {
+	setup(t)
+	lock, err := Claim(context.Background(), OwnerStandalone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lock.Watch(context.Background(), func() { close(lost) })
+	}()
+
+	app := otherLiveHolder(t, OwnerApp)
+	select {
+	case <-lost:
+	case <-time.After(5 * time.Second):
+		t.Fatal("onLost not called after takeover")
+	}
+	<-done
+
+	data, err := os.ReadFile(handoverPath())
+	if err != nil || strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("handover file = %q, %v", data, err)
+	}
+
+	// Releasing a lost lock must not remove the app's lock file.
+	lock.Release()
+	if h, ok := Read(); !ok || h.PID != app.PID {
+		t.Fatalf("Read() after Release = %+v, %v", h, ok)
+	}
+}
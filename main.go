@@ -28,6 +28,7 @@ import (
 	"yanta/internal/nativeinput"
 	"yanta/internal/quickcapture"
 	"yanta/internal/vault"
+	"yanta/internal/vaultlock"
 	windowcfg "yanta/internal/window"
 )
 
@@ -42,7 +43,7 @@ func main() {
 	// Dispatch before any GUI/Wails init so it stays headless and never trips the
 	// single-instance guard or spawns a window.
 	if len(os.Args) > 1 && os.Args[1] == "mcp" {
-		if err := mcpbridge.Run(context.Background()); err != nil {
+		if err := runMCP(os.Args[2:]); err != nil {
 			fmt.Fprintln(os.Stderr, "yanta mcp: "+err.Error())
 			os.Exit(1)
 		}
@@ -51,6 +52,38 @@ func main() {
 	run()
 }
 
+// runMCP handles `yanta mcp [--standalone]`. Without --standalone it relays to
+// the running app. With it, it still relays if the app is serving MCP, and
+// otherwise opens the vault itself.
+func runMCP(args []string) error {
+	standalone := false
+	for _, arg := range args {
+		switch arg {
+		case "--standalone":
+			standalone = true
+		default:
+			return fmt.Errorf("unknown argument %q (usage: yanta mcp [--standalone])", arg)
+		}
+	}
+
+	ctx := context.Background()
+	if !standalone {
+		return mcpbridge.Run(ctx)
+	}
+
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("initializing config: %w", err)
+	}
+	// stdout carries the MCP protocol; logs must stay off it.
+	if err := logger.InitStderrFromEnv(); err != nil {
+		return fmt.Errorf("initializing logger: %w", err)
+	}
+	if holder, ok := vaultlock.Read(); ok && holder.Owner == vaultlock.OwnerApp && mcpbridge.Available() {
+		return mcpbridge.Run(ctx)
+	}
+	return app.RunStandalone(ctx, db.DefaultPath())
+}
+
 func run() {
 	if err := config.Init(); err != nil {
 		writeStartupError(fmt.Sprintf("Failed to initialize config: %v", err))
@@ -147,6 +180,7 @@ func run() {
 			application.NewService(a.Bindings.Config),
 			application.NewService(a.Bindings.Backup),
 			application.NewService(a.Bindings.Export),
+			application.NewService(a.Bindings.Import),
 			application.NewService(a.Bindings.ProjectCommands),
 			application.NewService(a.Bindings.GlobalCommands),
 			application.NewService(a.Bindings.DocumentCommands),
@@ -384,6 +418,10 @@ func run() {
 		a.Shutdown()
 	})
 
+	mainWindow.RegisterHook(events.Common.WindowRuntimeReady, func(e *application.WindowEvent) {
+		a.FrontendLoaded()
+	})
+
 	isQuitting := false
 	mainWindow.RegisterHook(events.Common.WindowClosing, func(e *application.WindowEvent) {
 		logger.Debug("WindowClosing event fired")